@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 
 	logging "github.com/inconshreveable/log15"
 	"github.com/spf13/cobra"
@@ -14,6 +16,7 @@ import (
 	"boscoin.io/sebak/lib/block"
 	"boscoin.io/sebak/lib/common"
 	"boscoin.io/sebak/lib/storage"
+	"boscoin.io/sebak/lib/transaction"
 )
 
 const (
@@ -21,17 +24,29 @@ const (
 )
 
 var (
-	genesisCmd  *cobra.Command
-	flagBalance string = common.GetENVValue("SEBAK_GENESIS_BALANCE", initialBalance)
+	genesisCmd      *cobra.Command
+	flagBalance     string = common.GetENVValue("SEBAK_GENESIS_BALANCE", initialBalance)
+	flagMaxSupply   string = common.GetENVValue("SEBAK_MAX_SUPPLY", "")
+	flagGenesisTime string = common.GetENVValue("SEBAK_GENESIS_TIME", "")
+	// flagStorageBudget is the storage budget of account, which is stored with
+	// the genesis block.
+	flagStorageBudget string = common.GetENVValue("SEBAK_ACCOUNT_STORAGE_BUDGET", "0")
+	// flagOperationFees and flagPerByteFees are the fee policy of network,
+	// which is stored with the genesis block; see `ParseFeesFromString`.
+	flagOperationFees string = common.GetENVValue("SEBAK_OPERATION_FEES", "")
+	flagPerByteFees   string = common.GetENVValue("SEBAK_PER_BYTE_FEES", "")
+	// flagGenesisValidators is the addresses of the initial validators, which
+	// are recorded as the validator set at the genesis block.
+	flagGenesisValidators string = common.GetENVValue("SEBAK_GENESIS_VALIDATORS", "")
 )
 
 func init() {
 	var genesisCmd = &cobra.Command{
-		Use:   "genesis <public key>",
+		Use:   "genesis <secret seed>",
 		Short: "initialize new network",
 		Args:  cobra.ExactArgs(1),
 		Run: func(c *cobra.Command, args []string) {
-			flagName, err := MakeGenesisBlock(args[0], flagNetworkID, flagBalance, flagStorageConfigString, log)
+			flagName, err := MakeGenesisBlock(args[0], flagNetworkID, flagBalance, flagGenesisTime, flagStorageConfigString, log)
 			if len(flagName) != 0 || err != nil {
 				cmdcommon.PrintFlagsError(c, flagName, err)
 			}
@@ -41,6 +56,12 @@ func init() {
 	}
 
 	genesisCmd.Flags().StringVar(&flagBalance, "balance", flagBalance, "initial balance of genesis block")
+	genesisCmd.Flags().StringVar(&flagMaxSupply, "max-supply", flagMaxSupply, "max supply of network; by default, the maximum balance")
+	genesisCmd.Flags().StringVar(&flagGenesisTime, "genesis-time", flagGenesisTime, "confirmed time of genesis block in ISO8601; by default, the time of main network")
+	genesisCmd.Flags().StringVar(&flagStorageBudget, "account-storage-budget", flagStorageBudget, "maximum bytes of metadata of one account of network; 0 disables budget")
+	genesisCmd.Flags().StringVar(&flagOperationFees, "operation-fees", flagOperationFees, "fee of each operation type of network, like 'payment=10000;set-account-data=20000'; by default, the base fee")
+	genesisCmd.Flags().StringVar(&flagPerByteFees, "per-byte-fees", flagPerByteFees, "fee for each byte of serialized operation by the type of network, like 'set-account-data=100'")
+	genesisCmd.Flags().StringVar(&flagGenesisValidators, "genesis-validators", flagGenesisValidators, "public addresses of the initial validators of network, separated by comma")
 	genesisCmd.Flags().StringVar(&flagStorageConfigString, "storage", flagStorageConfigString, "storage uri")
 	genesisCmd.Flags().StringVar(&flagNetworkID, "network-id", flagNetworkID, "network id")
 
@@ -54,11 +75,14 @@ func init() {
 // (at the moment, only `run`) so it can provide the same behavior (defaults, error messages).
 //
 // Params:
-//   address   = public address of the account owning the genesis block
+//   secretSeed = secret seed of the account owning the genesis block; the
+//                genesis transaction is signed by it
 //   networkID = `--network-id` argument, used for signing
 //   balanceStr = Amount of coins to put in the account
 //                If not provided, `flagBalance`, which is the value set in the env
 //                when called from another module, will be used
+//   confirmed  = Confirmed time of the genesis block in ISO8601
+//                If not provided, `common.GenesisBlockConfirmedTime` will be used
 //   storageUri = URI to include storage path("file://path")
 //                If not provided, a default value will be used
 //
@@ -68,13 +92,18 @@ func init() {
 //   and error is the more detailed error.
 //   Note that only one needs be non-`nil` for it to be considered an error.
 //
-func MakeGenesisBlock(addressStr, networkID, balanceStr, storageUri string, log logging.Logger) (string, error) {
+func MakeGenesisBlock(secretSeed, networkID, balanceStr, confirmed, storageUri string, log logging.Logger) (string, error) {
 	var balance common.Amount
 	var err error
-	var kp keypair.KP
+	var parsedKP keypair.KP
 
-	if kp, err = keypair.Parse(addressStr); err != nil {
-		return "<address>", err
+	if parsedKP, err = keypair.Parse(secretSeed); err != nil {
+		return "<secret seed>", err
+	}
+
+	kp, ok := parsedKP.(*keypair.Full)
+	if !ok {
+		return "<secret seed>", errors.New("secret seed of genesis account must be provided, not public address")
 	}
 
 	if len(networkID) == 0 {
@@ -89,6 +118,39 @@ func MakeGenesisBlock(addressStr, networkID, balanceStr, storageUri string, log
 		return "--balance", err
 	}
 
+	if len(flagMaxSupply) > 0 {
+		if common.MaxSupply, err = cmdcommon.ParseAmountFromString(flagMaxSupply); err != nil {
+			return "--max-supply", err
+		}
+	}
+	if balance > common.MaxSupply {
+		return "--balance", fmt.Errorf("balance is over max supply, %v", common.MaxSupply)
+	}
+
+	if len(confirmed) > 0 {
+		if _, err = common.ParseISO8601(confirmed); err != nil {
+			return "--genesis-time", err
+		}
+	}
+
+	var storageBudget uint64
+	if storageBudget, err = strconv.ParseUint(flagStorageBudget, 10, 64); err != nil {
+		return "--account-storage-budget", err
+	}
+
+	var feePolicy transaction.OperationFeePolicy
+	if feePolicy.Fees, err = cmdcommon.ParseFeesFromString(flagOperationFees); err != nil {
+		return "--operation-fees", err
+	}
+	if feePolicy.PerByteFees, err = cmdcommon.ParseFeesFromString(flagPerByteFees); err != nil {
+		return "--per-byte-fees", err
+	}
+
+	var validators []string
+	if validators, err = parseGenesisValidators(flagGenesisValidators); err != nil {
+		return "--genesis-validators", err
+	}
+
 	// Use the default value
 	if len(storageUri) == 0 {
 		// We try to get the env value first, before doing IO which could fail
@@ -120,20 +182,26 @@ func MakeGenesisBlock(addressStr, networkID, balanceStr, storageUri string, log
 
 	// check account does not exists
 	if _, err = block.GetBlockAccount(st, kp.Address()); err == nil {
-		return "<public key>", errors.New("account is already created")
+		return "<secret seed>", errors.New("account is already created")
 	}
 
 	account := block.NewBlockAccount(
 		kp.Address(),
 		balance,
 	)
-	if err := account.Save(st); err != nil {
-		return "<public key>", fmt.Errorf("failed to create genesis account: %v", err)
+	if err := account.Save(st, nil); err != nil {
+		return "<secret seed>", fmt.Errorf("failed to create genesis account: %v", err)
 	}
 
-	b, err := block.MakeGenesisBlock(st, *account, []byte(flagNetworkID))
+	config := block.NewGenesisConfigFromAccount(*account)
+	config.Confirmed = confirmed
+	config.AccountStorageBudget = int(storageBudget)
+	config.FeePolicy = feePolicy
+	config.Validators = validators
+
+	b, err := block.MakeGenesisBlockFromConfig(st, config, []byte(flagNetworkID), kp)
 	if err != nil {
-		return "<public key>", fmt.Errorf("failed to create genesis block: %v", err)
+		return "<secret seed>", fmt.Errorf("failed to create genesis block: %v", err)
 	}
 
 	log.Info("GenesisBlock created",
@@ -146,3 +214,24 @@ func MakeGenesisBlock(addressStr, networkID, balanceStr, storageUri string, log
 
 	return "", nil
 }
+
+// parseGenesisValidators parses the public addresses of validators, which are
+// separated by comma.
+func parseGenesisValidators(input string) (validators []string, err error) {
+	for _, address := range strings.Split(input, ",") {
+		if address = strings.TrimSpace(address); len(address) < 1 {
+			continue
+		}
+		var kp keypair.KP
+		if kp, err = keypair.Parse(address); err != nil {
+			err = fmt.Errorf("invalid validator address, %q: %v", address, err)
+			return
+		} else if _, ok := kp.(*keypair.FromAddress); !ok {
+			err = fmt.Errorf("validator must be public address, %q", address)
+			return
+		}
+		validators = append(validators, address)
+	}
+
+	return
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
 	"time"
@@ -17,12 +18,15 @@ import (
 	"github.com/stellar/go/keypair"
 
 	cmdcommon "boscoin.io/sebak/cmd/sebak/common"
+	"boscoin.io/sebak/lib/block"
 	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/common/observer"
 	"boscoin.io/sebak/lib/consensus"
 	"boscoin.io/sebak/lib/network"
 	"boscoin.io/sebak/lib/node"
 	"boscoin.io/sebak/lib/node/runner"
 	"boscoin.io/sebak/lib/storage"
+	"boscoin.io/sebak/lib/transaction"
 )
 
 const (
@@ -50,6 +54,22 @@ var (
 	flagTimeoutACCEPT       string = common.GetENVValue("SEBAK_TIMEOUT_ACCEPT", "2")
 	flagBlockTime           string = common.GetENVValue("SEBAK_BLOCK_TIME", "5")
 	flagTransactionsLimit   string = common.GetENVValue("SEBAK_TRANSACTIONS_LIMIT", "1000")
+	flagMissedHeartbeats    string = common.GetENVValue("SEBAK_MISSED_HEARTBEATS", "1")
+	flagBroadcastRetries    string = common.GetENVValue("SEBAK_BROADCAST_RETRIES", "3")
+	flagMaxValidatorsSoft   string = common.GetENVValue("SEBAK_MAX_VALIDATORS_SOFT", "50")
+	flagMaxValidatorsHard   string = common.GetENVValue("SEBAK_MAX_VALIDATORS_HARD", "200")
+	flagMaxConsensusLag     string = common.GetENVValue("SEBAK_MAX_CONSENSUS_LAG", "60")
+	flagMaxClockSkew        string = common.GetENVValue("SEBAK_MAX_CLOCK_SKEW", "60")
+	flagReplaceFeeBump      string = common.GetENVValue("SEBAK_REPLACE_FEE_BUMP", "10")
+	flagPersistBallots      bool   = common.GetENVValue("SEBAK_PERSIST_BALLOTS", "0") == "1"
+	flagVerifySupply        bool   = common.GetENVValue("SEBAK_VERIFY_SUPPLY", "0") == "1"
+	flagAsyncBlockObserver  bool   = common.GetENVValue("SEBAK_ASYNC_BLOCK_OBSERVER", "0") == "1"
+	flagAliasFormat         string = common.GetENVValue("SEBAK_ALIAS_FORMAT", string(node.AliasFormatShort))
+	flagMaxBatchTxs         string = common.GetENVValue("SEBAK_MAX_BATCH_TRANSACTIONS", "100")
+	flagValidationWorkers   string = common.GetENVValue("SEBAK_VALIDATION_WORKERS", strconv.Itoa(runtime.NumCPU()))
+	flagSeenCacheSize       string = common.GetENVValue("SEBAK_SEEN_CACHE_SIZE", "10000")
+	flagAdminToken          string = common.GetENVValue("SEBAK_ADMIN_TOKEN", "")
+	flagVotingPolicy        string = common.GetENVValue("SEBAK_VOTING_POLICY", consensus.VotingPolicyISAAC)
 )
 
 var (
@@ -66,6 +86,8 @@ var (
 	timeoutACCEPT     time.Duration
 	blockTime         time.Duration
 	transactionsLimit uint64
+	validationWorkers int
+	seenCacheSize     int
 	logLevel          logging.Lvl
 	log               logging.Logger = logging.New("module", "main")
 )
@@ -85,12 +107,12 @@ func init() {
 				csv := strings.Split(flagGenesis, ",")
 				if len(csv) > 2 {
 					cmdcommon.PrintFlagsError(nodeCmd, "--genesis",
-						errors.New("--genesis expects address[,balance], but more than 2 commas detected"))
+						errors.New("--genesis expects secret-seed[,balance], but more than 2 commas detected"))
 				}
 				if len(csv) == 2 {
 					balanceStr = csv[1]
 				}
-				flagName, err := MakeGenesisBlock(csv[0], flagNetworkID, balanceStr, flagStorageConfigString, log)
+				flagName, err := MakeGenesisBlock(csv[0], flagNetworkID, balanceStr, flagGenesisTime, flagStorageConfigString, log)
 				if len(flagName) != 0 || err != nil {
 					cmdcommon.PrintFlagsError(c, flagName, err)
 				}
@@ -119,7 +141,12 @@ func init() {
 	}
 	flagStorageConfigString = common.GetENVValue("SEBAK_STORAGE", fmt.Sprintf("file://%s/db", currentDirectory))
 
-	nodeCmd.Flags().StringVar(&flagGenesis, "genesis", flagGenesis, "performs the 'genesis' command before running node. Syntax: key[,balance]")
+	nodeCmd.Flags().StringVar(&flagGenesis, "genesis", flagGenesis, "performs the 'genesis' command before running node. Syntax: secret-seed[,balance]")
+	nodeCmd.Flags().StringVar(&flagGenesisTime, "genesis-time", flagGenesisTime, "confirmed time of genesis block of '--genesis' in ISO8601; by default, the time of main network")
+	nodeCmd.Flags().StringVar(&flagStorageBudget, "account-storage-budget", flagStorageBudget, "maximum bytes of metadata of one account of network by '--genesis'; 0 disables budget")
+	nodeCmd.Flags().StringVar(&flagOperationFees, "operation-fees", flagOperationFees, "fee of each operation type of network by '--genesis', like 'payment=10000;set-account-data=20000'")
+	nodeCmd.Flags().StringVar(&flagPerByteFees, "per-byte-fees", flagPerByteFees, "fee for each byte of serialized operation by the type of network by '--genesis', like 'set-account-data=100'")
+	nodeCmd.Flags().StringVar(&flagGenesisValidators, "genesis-validators", flagGenesisValidators, "public addresses of the initial validators of network by '--genesis', separated by comma")
 	nodeCmd.Flags().StringVar(&flagKPSecretSeed, "secret-seed", flagKPSecretSeed, "secret seed of this node")
 	nodeCmd.Flags().StringVar(&flagNetworkID, "network-id", flagNetworkID, "network id")
 	nodeCmd.Flags().StringVar(&flagLogLevel, "log-level", flagLogLevel, "log level, {crit, error, warn, info, debug}")
@@ -133,11 +160,27 @@ func init() {
 	nodeCmd.Flags().StringVar(&flagTLSKeyFile, "tls-key", flagTLSKeyFile, "tls key file")
 	nodeCmd.Flags().StringVar(&flagValidators, "validators", flagValidators, "set validator: <endpoint url>?address=<public address>[&alias=<alias>] [ <validator>...]")
 	nodeCmd.Flags().StringVar(&flagThreshold, "threshold", flagThreshold, "threshold")
+	nodeCmd.Flags().StringVar(&flagVotingPolicy, "voting-policy", flagVotingPolicy, "voting policy: isaac, stake")
 	nodeCmd.Flags().StringVar(&flagTimeoutINIT, "timeout-init", flagTimeoutINIT, "timeout of the init state")
 	nodeCmd.Flags().StringVar(&flagTimeoutSIGN, "timeout-sign", flagTimeoutSIGN, "timeout of the sign state")
 	nodeCmd.Flags().StringVar(&flagTimeoutACCEPT, "timeout-accept", flagTimeoutACCEPT, "timeout of the accept state")
 	nodeCmd.Flags().StringVar(&flagBlockTime, "block-time", flagBlockTime, "block creation time")
 	nodeCmd.Flags().StringVar(&flagTransactionsLimit, "transactions-limit", flagTransactionsLimit, "transactions limit in a ballot")
+	nodeCmd.Flags().BoolVar(&flagPersistBallots, "persist-ballots", flagPersistBallots, "store the ballots of confirmed rounds for audit")
+	nodeCmd.Flags().BoolVar(&flagVerifySupply, "verify-supply", flagVerifySupply, "check the total supply is conserved after every block")
+	nodeCmd.Flags().BoolVar(&flagAsyncBlockObserver, "async-block-observer", flagAsyncBlockObserver, "dispatch the block events asynchronously, so slow subscribers do not block saving block")
+	nodeCmd.Flags().StringVar(&flagMissedHeartbeats, "missed-heartbeats", flagMissedHeartbeats, "number of failed connection checks before validator is disconnected")
+	nodeCmd.Flags().StringVar(&flagBroadcastRetries, "broadcast-retries", flagBroadcastRetries, "number of retries of the failed sends to validator; 0 disables retry")
+	nodeCmd.Flags().StringVar(&flagMaxValidatorsSoft, "max-validators-soft", flagMaxValidatorsSoft, "number of validators over which warning is logged; 0 disables warning")
+	nodeCmd.Flags().StringVar(&flagMaxValidatorsHard, "max-validators-hard", flagMaxValidatorsHard, "maximum number of validators; node refuses to start with more validators. 0 disables limit")
+	nodeCmd.Flags().StringVar(&flagMaxConsensusLag, "max-consensus-lag", flagMaxConsensusLag, "seconds since the last confirmed block before node is not ready")
+	nodeCmd.Flags().StringVar(&flagMaxClockSkew, "max-clock-skew", flagMaxClockSkew, "seconds of the confirmed time of ballot allowed to be ahead or late")
+	nodeCmd.Flags().StringVar(&flagReplaceFeeBump, "replace-fee-bump", flagReplaceFeeBump, "minimum fee increase in percent to replace the pending transaction")
+	nodeCmd.Flags().StringVar(&flagMaxBatchTxs, "max-batch-transactions", flagMaxBatchTxs, "maximum number of transactions in one batch submission")
+	nodeCmd.Flags().StringVar(&flagValidationWorkers, "validation-workers", flagValidationWorkers, "number of workers, which validate the transactions of ballot concurrently")
+	nodeCmd.Flags().StringVar(&flagSeenCacheSize, "seen-cache-size", flagSeenCacheSize, "number of the recently received ballots and transactions, which are kept to drop the duplicated ones; 0 disables")
+	nodeCmd.Flags().StringVar(&flagAdminToken, "admin-token", flagAdminToken, "token of the admin handlers like resync by 'Authorization: Bearer <token>'; empty disables them")
+	nodeCmd.Flags().StringVar(&flagAliasFormat, "alias-format", flagAliasFormat, "format of the default alias of node {short, long, hash}")
 
 	rootCmd.AddCommand(nodeCmd)
 }
@@ -211,6 +254,10 @@ func parseFlagsNode() {
 	queries.Add("IdleTimeout", "3s")
 	bindEndpoint.RawQuery = queries.Encode()
 
+	if node.DefaultAliasFormat, err = node.ParseAliasFormat(flagAliasFormat); err != nil {
+		cmdcommon.PrintFlagsError(nodeCmd, "--alias-format", err)
+	}
+
 	if validators, err = parseFlagValidators(flagValidators); err != nil {
 		cmdcommon.PrintFlagsError(nodeCmd, "--validators", err)
 	}
@@ -219,7 +266,7 @@ func parseFlagsNode() {
 		if n.Address() == kp.Address() {
 			cmdcommon.PrintFlagsError(nodeCmd, "--validators", fmt.Errorf("duplicated public address found"))
 		}
-		if n.Endpoint() == bindEndpoint {
+		if n.Endpoint().Equal(bindEndpoint) {
 			cmdcommon.PrintFlagsError(nodeCmd, "--validators", fmt.Errorf("duplicated endpoint found"))
 		}
 	}
@@ -232,6 +279,8 @@ func parseFlagsNode() {
 	timeoutSIGN = getTime(flagTimeoutSIGN, 2*time.Second, "--timeout-sign")
 	timeoutACCEPT = getTime(flagTimeoutACCEPT, 2*time.Second, "--timeout-accept")
 	blockTime = getTime(flagBlockTime, 5*time.Second, "--block-time")
+	runner.MaxConsensusLag = getTime(flagMaxConsensusLag, time.Minute, "--max-consensus-lag")
+	common.BallotConfirmedTimeAllowDuration = getTime(flagMaxClockSkew, time.Minute, "--max-clock-skew")
 
 	if transactionsLimit, err = strconv.ParseUint(flagTransactionsLimit, 10, 64); err != nil {
 		cmdcommon.PrintFlagsError(nodeCmd, "--transactions-limit", err)
@@ -244,6 +293,69 @@ func parseFlagsNode() {
 		threshold = int(tmpUint64)
 	}
 
+	switch flagVotingPolicy {
+	case consensus.VotingPolicyISAAC, consensus.VotingPolicyStake:
+	default:
+		cmdcommon.PrintFlagsError(nodeCmd, "--voting-policy", fmt.Errorf("unknown voting policy: '%s'", flagVotingPolicy))
+	}
+
+	if tmpUint64, err = strconv.ParseUint(flagMissedHeartbeats, 10, 64); err != nil {
+		cmdcommon.PrintFlagsError(nodeCmd, "--missed-heartbeats", err)
+	} else if tmpUint64 < 1 {
+		cmdcommon.PrintFlagsError(nodeCmd, "--missed-heartbeats", errors.New("must be greater than 0"))
+	} else {
+		network.MissedHeartbeatsThreshold = int(tmpUint64)
+	}
+
+	if tmpUint64, err = strconv.ParseUint(flagBroadcastRetries, 10, 64); err != nil {
+		cmdcommon.PrintFlagsError(nodeCmd, "--broadcast-retries", err)
+	} else {
+		network.BroadcastMaxRetries = int(tmpUint64)
+	}
+
+	if tmpUint64, err = strconv.ParseUint(flagMaxValidatorsSoft, 10, 64); err != nil {
+		cmdcommon.PrintFlagsError(nodeCmd, "--max-validators-soft", err)
+	} else {
+		network.MaxValidatorsSoftLimit = int(tmpUint64)
+	}
+
+	if tmpUint64, err = strconv.ParseUint(flagMaxValidatorsHard, 10, 64); err != nil {
+		cmdcommon.PrintFlagsError(nodeCmd, "--max-validators-hard", err)
+	} else {
+		network.MaxValidatorsHardLimit = int(tmpUint64)
+	}
+	if err = network.CheckValidatorsLimits(); err != nil {
+		cmdcommon.PrintFlagsError(nodeCmd, "--max-validators-soft", err)
+	}
+
+	if tmpUint64, err = strconv.ParseUint(flagReplaceFeeBump, 10, 64); err != nil {
+		cmdcommon.PrintFlagsError(nodeCmd, "--replace-fee-bump", err)
+	} else {
+		common.ReplaceFeeBumpPercent = tmpUint64
+	}
+
+	if tmpUint64, err = strconv.ParseUint(flagMaxBatchTxs, 10, 64); err != nil {
+		cmdcommon.PrintFlagsError(nodeCmd, "--max-batch-transactions", err)
+	} else if tmpUint64 < 1 {
+		cmdcommon.PrintFlagsError(nodeCmd, "--max-batch-transactions", errors.New("must be greater than 0"))
+	} else {
+		common.MaxTransactionsInBatch = int(tmpUint64)
+	}
+
+	if tmpUint64, err = strconv.ParseUint(flagValidationWorkers, 10, 64); err != nil {
+		cmdcommon.PrintFlagsError(nodeCmd, "--validation-workers", err)
+	} else if tmpUint64 < 1 {
+		cmdcommon.PrintFlagsError(nodeCmd, "--validation-workers", errors.New("must be greater than 0"))
+	} else {
+		validationWorkers = int(tmpUint64)
+	}
+
+	if tmpUint64, err = strconv.ParseUint(flagSeenCacheSize, 10, 64); err != nil {
+		cmdcommon.PrintFlagsError(nodeCmd, "--seen-cache-size", err)
+	} else {
+		seenCacheSize = int(tmpUint64)
+	}
+
 	if logLevel, err = logging.LvlFromString(flagLogLevel); err != nil {
 		cmdcommon.PrintFlagsError(nodeCmd, "--log-level", err)
 	}
@@ -285,11 +397,27 @@ func parseFlagsNode() {
 	parsedFlags = append(parsedFlags, "\n\tlog-format", flagLogFormat)
 	parsedFlags = append(parsedFlags, "\n\tlog", flagLog)
 	parsedFlags = append(parsedFlags, "\n\tthreshold", flagThreshold)
+	parsedFlags = append(parsedFlags, "\n\tvoting-policy", flagVotingPolicy)
 	parsedFlags = append(parsedFlags, "\n\ttimeout-init", flagTimeoutINIT)
 	parsedFlags = append(parsedFlags, "\n\ttimeout-sign", flagTimeoutSIGN)
 	parsedFlags = append(parsedFlags, "\n\ttimeout-accept", flagTimeoutACCEPT)
 	parsedFlags = append(parsedFlags, "\n\tblock-time", flagBlockTime)
 	parsedFlags = append(parsedFlags, "\n\ttransactions-limit", flagTransactionsLimit)
+	parsedFlags = append(parsedFlags, "\n\tmissed-heartbeats", flagMissedHeartbeats)
+	parsedFlags = append(parsedFlags, "\n\tbroadcast-retries", flagBroadcastRetries)
+	parsedFlags = append(parsedFlags, "\n\tmax-validators-soft", flagMaxValidatorsSoft)
+	parsedFlags = append(parsedFlags, "\n\tmax-validators-hard", flagMaxValidatorsHard)
+	parsedFlags = append(parsedFlags, "\n\tmax-consensus-lag", flagMaxConsensusLag)
+	parsedFlags = append(parsedFlags, "\n\tmax-clock-skew", flagMaxClockSkew)
+	parsedFlags = append(parsedFlags, "\n\treplace-fee-bump", flagReplaceFeeBump)
+	parsedFlags = append(parsedFlags, "\n\tpersist-ballots", flagPersistBallots)
+	parsedFlags = append(parsedFlags, "\n\tverify-supply", flagVerifySupply)
+	parsedFlags = append(parsedFlags, "\n\tasync-block-observer", flagAsyncBlockObserver)
+	parsedFlags = append(parsedFlags, "\n\talias-format", flagAliasFormat)
+	parsedFlags = append(parsedFlags, "\n\tmax-batch-transactions", flagMaxBatchTxs)
+	parsedFlags = append(parsedFlags, "\n\tvalidation-workers", flagValidationWorkers)
+	parsedFlags = append(parsedFlags, "\n\tseen-cache-size", flagSeenCacheSize)
+	parsedFlags = append(parsedFlags, "\n\tadmin-token", len(flagAdminToken) > 0)
 
 	var vl []interface{}
 	for i, v := range validators {
@@ -328,9 +456,23 @@ func runNode() error {
 		log.Error("failed to launch main node", "error", err)
 		return err
 	}
-	localNode.AddValidators(validators...)
+	localNode.SetMaxValidators(network.MaxValidatorsHardLimit)
+	if err = localNode.AddValidators(validators...); err != nil {
+		log.Error("failed to add validators", "error", err)
+		return err
+	}
 	localNode.SetPublishEndpoint(publishEndpoint)
 
+	observer.BlockDispatcher.SetAsync(flagAsyncBlockObserver)
+
+	allValidators := map[string]*node.Validator{localNode.Address(): localNode.ConvertToValidator()}
+	for address, v := range localNode.GetValidators() {
+		allValidators[address] = v
+	}
+	if collided := node.CheckAliasCollision(allValidators); len(collided) > 0 {
+		log.Warn("validators have the same alias; set the unique alias or try the other --alias-format", "aliases", collided)
+	}
+
 	// create network
 	networkConfig, err := network.NewHTTP2NetworkConfigFromEndpoint(localNode.Alias(), bindEndpoint)
 	if err != nil {
@@ -340,7 +482,7 @@ func runNode() error {
 
 	nt := network.NewHTTP2Network(networkConfig)
 
-	policy, err := consensus.NewDefaultVotingThresholdPolicy(threshold, threshold)
+	policy, err := consensus.NewVotingThresholdPolicy(flagVotingPolicy, threshold, threshold)
 	if err != nil {
 		log.Crit("failed to create VotingThresholdPolicy", "error", err)
 		return err
@@ -365,6 +507,12 @@ func runNode() error {
 		return err
 	}
 
+	// the fee policy of network is stored with the genesis block
+	if transaction.DefaultFeePolicy, err = block.GetFeePolicy(st); err != nil {
+		log.Crit("failed to load fee policy", "error", err)
+		return err
+	}
+
 	// Execution group.
 	var g run.Group
 	{
@@ -374,6 +522,10 @@ func runNode() error {
 			TimeoutACCEPT:     timeoutACCEPT,
 			BlockTime:         blockTime,
 			TransactionsLimit: uint64(transactionsLimit),
+			PersistBallots:    flagPersistBallots,
+			VerifySupply:      flagVerifySupply,
+			ValidationWorkers: validationWorkers,
+			SeenCacheSize:     seenCacheSize,
 		}
 		nr, err := runner.NewNodeRunner(flagNetworkID, localNode, policy, nt, isaac, st, conf)
 
@@ -381,6 +533,7 @@ func runNode() error {
 			fmt.Fprintf(os.Stderr, "%v\n", err)
 			return err
 		}
+		nr.SetAdminToken(flagAdminToken)
 
 		g.Add(func() error {
 			if err := nr.Start(); err != nil {
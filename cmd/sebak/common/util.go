@@ -8,6 +8,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/transaction"
 )
 
 /**
@@ -41,3 +42,39 @@ func ParseAmountFromString(input string) (common.Amount, error) {
 	amountStr = strings.Replace(amountStr, "_", "", -1)
 	return common.AmountFromString(amountStr)
 }
+
+// Parse an input string as the fees of operation types
+//
+// The fees are separated by semicolon(';') and each fee is
+// `<operation type>=<amount>`, like `payment=10000;set-account-data=20000`.
+// The amount is parsed by `ParseAmountFromString`.
+//
+// Params:
+//   input = the string representation of the fees; empty string is no fees
+//
+// Returns:
+//   map[transaction.OperationType]common.Amount: the fee of each operation type
+//   error: an `error`, if any happened
+func ParseFeesFromString(input string) (fees map[transaction.OperationType]common.Amount, err error) {
+	if len(strings.TrimSpace(input)) == 0 {
+		return
+	}
+
+	fees = map[transaction.OperationType]common.Amount{}
+	for _, s := range strings.Split(input, ";") {
+		kv := strings.SplitN(strings.TrimSpace(s), "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("fee must be '<operation type>=<amount>': '%s'", s)
+		}
+
+		opType := transaction.OperationType(strings.TrimSpace(kv[0]))
+		if !opType.IsKnown() {
+			return nil, fmt.Errorf("unknown operation type: '%s'", opType)
+		}
+		if fees[opType], err = ParseAmountFromString(strings.TrimSpace(kv[1])); err != nil {
+			return nil, err
+		}
+	}
+
+	return
+}
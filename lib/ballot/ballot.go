@@ -42,6 +42,15 @@ func NewBallot(fromAddress string, round round.Round, transactions []string) (b
 }
 
 func NewBallotFromJSON(data []byte) (b Ballot, err error) {
+	var exceeded bool
+	exceeded, err = common.JSONArrayExceedsLimit(data, common.MaxTransactionsInBallot, "B", "proposed", "transactions")
+	if err != nil {
+		return
+	} else if exceeded {
+		err = errors.ErrorTooManyTransactions
+		return
+	}
+
 	if err = json.Unmarshal(data, &b); err != nil {
 		return
 	}
@@ -78,6 +87,21 @@ func (b Ballot) IsWellFormed(networkID []byte) (err error) {
 		return
 	}
 
+	if _, err = b.CheckConfirmedTime(time.Now(), common.BallotConfirmedTimeAllowDuration); err != nil {
+		return
+	}
+
+	if err = b.Verify(networkID); err != nil {
+		return
+	}
+
+	return
+}
+
+// ConfirmedTimeSkew returns the skew of the confirmed times of ballot from
+// `now`; of `Ballot.B.Confirmed` and the confirmed time of proposer, the one
+// skewed more is returned. The positive skew means the ballot is ahead.
+func (b Ballot) ConfirmedTimeSkew(now time.Time) (skew time.Duration, err error) {
 	var confirmed, proposerConfirmed time.Time
 	if confirmed, err = common.ParseISO8601(b.B.Confirmed); err != nil {
 		return
@@ -86,25 +110,36 @@ func (b Ballot) IsWellFormed(networkID []byte) (err error) {
 		return
 	}
 
-	now := time.Now()
-	timeStart := now.Add(time.Duration(-1) * common.BallotConfirmedTimeAllowDuration)
-	timeEnd := now.Add(common.BallotConfirmedTimeAllowDuration)
-	if confirmed.Before(timeStart) || confirmed.After(timeEnd) {
-		err = errors.ErrorMessageHasIncorrectTime
-		return
+	skew = confirmed.Sub(now)
+	if proposerSkew := proposerConfirmed.Sub(now); absDuration(proposerSkew) > absDuration(skew) {
+		skew = proposerSkew
 	}
-	if proposerConfirmed.Before(timeStart) || proposerConfirmed.After(timeEnd) {
-		err = errors.ErrorMessageHasIncorrectTime
+
+	return
+}
+
+// CheckConfirmedTime checks the confirmed times of ballot are not too ahead
+// or too late from `now`; the skew up to `allowed` is allowed to the both
+// sides. The observed skew is returned with the error.
+func (b Ballot) CheckConfirmedTime(now time.Time, allowed time.Duration) (skew time.Duration, err error) {
+	if skew, err = b.ConfirmedTimeSkew(now); err != nil {
 		return
 	}
 
-	if err = b.Verify(networkID); err != nil {
-		return
+	if absDuration(skew) > allowed {
+		err = errors.ErrorMessageHasIncorrectTime
 	}
 
 	return
 }
 
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
 func (b Ballot) Equal(m common.Message) bool {
 	return b.H.Hash == m.GetHash()
 }
@@ -140,6 +140,69 @@ func TestBallotBadConfirmedTime(t *testing.T) {
 	}
 }
 
+func TestBallotCheckConfirmedTime(t *testing.T) {
+	kp, _ := keypair.Random()
+	node, _ := node.NewLocalNode(kp, &common.Endpoint{}, "")
+
+	allowed := 30 * time.Second
+	now := time.Now()
+
+	newBallot := func(confirmed, proposerConfirmed time.Time) Ballot {
+		ballot := NewBallot(node.Address(), round.Round{}, []string{})
+		ballot.B.Confirmed = common.FormatISO8601(confirmed)
+		ballot.B.Proposed.Confirmed = common.FormatISO8601(proposerConfirmed)
+		return *ballot
+	}
+
+	{ // at the boundary to the both sides
+		for _, d := range []time.Duration{allowed, -allowed} {
+			skew, err := newBallot(now.Add(d), now).CheckConfirmedTime(now, allowed)
+			require.Nil(t, err)
+			require.Equal(t, d, skew)
+
+			skew, err = newBallot(now, now.Add(d)).CheckConfirmedTime(now, allowed)
+			require.Nil(t, err)
+			require.Equal(t, d, skew)
+		}
+	}
+
+	{ // just over the boundary to the both sides
+		for _, d := range []time.Duration{allowed + time.Millisecond, -allowed - time.Millisecond} {
+			skew, err := newBallot(now.Add(d), now).CheckConfirmedTime(now, allowed)
+			require.Equal(t, errors.ErrorMessageHasIncorrectTime, err)
+			require.Equal(t, d, skew)
+
+			skew, err = newBallot(now, now.Add(d)).CheckConfirmedTime(now, allowed)
+			require.Equal(t, errors.ErrorMessageHasIncorrectTime, err)
+			require.Equal(t, d, skew)
+		}
+	}
+
+	{ // the more skewed one is returned
+		skew, err := newBallot(now.Add(time.Second), now.Add(-2*time.Second)).CheckConfirmedTime(now, allowed)
+		require.Nil(t, err)
+		require.Equal(t, -2*time.Second, skew)
+	}
+
+	{ // `IsWellFormed()` follows `common.BallotConfirmedTimeAllowDuration`
+		defer func(d time.Duration) {
+			common.BallotConfirmedTimeAllowDuration = d
+		}(common.BallotConfirmedTimeAllowDuration)
+
+		ballot := NewBallot(node.Address(), round.Round{}, []string{})
+		ballot.Sign(kp, networkID)
+		ballot.B.Confirmed = common.FormatISO8601(time.Now().Add(-2 * time.Minute))
+		ballot.H.Hash = ballot.B.MakeHashString()
+		signature, _ := common.MakeSignature(kp, networkID, ballot.H.Hash)
+		ballot.H.Signature = base58.Encode(signature)
+
+		require.Equal(t, errors.ErrorMessageHasIncorrectTime, ballot.IsWellFormed(networkID))
+
+		common.BallotConfirmedTimeAllowDuration = 3 * time.Minute
+		require.Nil(t, ballot.IsWellFormed(networkID))
+	}
+}
+
 func TestBallotEmptyHash(t *testing.T) {
 	kp, _ := keypair.Random()
 	node, _ := node.NewLocalNode(kp, &common.Endpoint{}, "")
@@ -0,0 +1,75 @@
+package ballot
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/storage"
+)
+
+// The confirmed ballots can be stored for audit. The storage should support,
+//  * get list by the height of block:
+// 	- key: "`Height`-`Round.Number`-`State`-`Source`": value: `Ballot`
+//
+// `Height` is the height of the block made by the ballot, that is,
+// `Round.BlockHeight + 1`.
+
+const maxBallotHeightStringLength int = 20
+
+func GetBallotKeyPrefixHeight(height uint64) string {
+	f := fmt.Sprintf("%%s%%0%dd-", maxBallotHeightStringLength)
+	return fmt.Sprintf(f, common.BallotPrefixHeight, height)
+}
+
+func GetBallotKey(b Ballot) string {
+	f := fmt.Sprintf("%%s%%0%dd-%%d-%%s", maxBallotHeightStringLength)
+	return fmt.Sprintf(
+		f,
+		GetBallotKeyPrefixHeight(b.Round().BlockHeight+1),
+		b.Round().Number,
+		uint(b.State()),
+		b.Source(),
+	)
+}
+
+// SaveBallot stores the ballot; the ballot of same round, state and source
+// is overwritten.
+func SaveBallot(st *storage.LevelDBBackend, b Ballot) (err error) {
+	key := GetBallotKey(b)
+
+	var exists bool
+	if exists, err = st.Has(key); err != nil {
+		return
+	}
+
+	if exists {
+		err = st.Set(key, b)
+	} else {
+		err = st.New(key, b)
+	}
+
+	return
+}
+
+// GetBallotsByHeight returns the stored ballots of the block height, ordered
+// by round.
+func GetBallotsByHeight(st *storage.LevelDBBackend, height uint64) (ballots []Ballot, err error) {
+	iterFunc, closeFunc := st.GetIterator(GetBallotKeyPrefixHeight(height), storage.NewDefaultListOptions(false, nil, 0))
+	defer closeFunc()
+
+	for {
+		item, hasNext := iterFunc()
+		if !hasNext {
+			break
+		}
+
+		var b Ballot
+		if err = json.Unmarshal(item.Value, &b); err != nil {
+			return
+		}
+		ballots = append(ballots, b)
+	}
+
+	return
+}
@@ -0,0 +1,72 @@
+package ballot
+
+import (
+	"testing"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stretchr/testify/require"
+
+	"boscoin.io/sebak/lib/consensus/round"
+	"boscoin.io/sebak/lib/storage"
+	"boscoin.io/sebak/lib/transaction"
+)
+
+func TestSaveBallotAndGetBallotsByHeight(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	proposer, _ := keypair.Random()
+	var voters []*keypair.Full
+	for i := 0; i < 3; i++ {
+		kp, _ := keypair.Random()
+		voters = append(voters, kp)
+	}
+
+	r := round.Round{Number: 1, BlockHeight: 9, BlockHash: "showme", TotalTxs: 1}
+	_, tx := transaction.TestMakeTransaction(networkID, 1)
+
+	proposed := NewBallot(proposer.Address(), r, []string{tx.GetHash()})
+	proposed.Sign(proposer, networkID)
+	require.Nil(t, SaveBallot(st, *proposed))
+
+	votes := map[string]VotingHole{}
+	for i, kp := range voters {
+		b := *proposed
+		vote := VotingYES
+		if i == 0 {
+			vote = VotingNO
+		}
+		b.SetVote(StateSIGN, vote)
+		b.Sign(kp, networkID)
+		require.Nil(t, SaveBallot(st, b))
+
+		votes[kp.Address()] = vote
+	}
+
+	// the ballot of other height
+	other := NewBallot(proposer.Address(), round.Round{Number: 0, BlockHeight: 10}, []string{})
+	other.Sign(proposer, networkID)
+	require.Nil(t, SaveBallot(st, *other))
+
+	ballots, err := GetBallotsByHeight(st, r.BlockHeight+1)
+	require.Nil(t, err)
+	require.Equal(t, 1+len(voters), len(ballots))
+
+	require.Equal(t, StateINIT, ballots[0].State())
+	require.Equal(t, proposed.GetHash(), ballots[0].GetHash())
+	require.Equal(t, proposer.Address(), ballots[0].Proposer())
+
+	saved := map[string]VotingHole{}
+	for _, b := range ballots[1:] {
+		require.Equal(t, StateSIGN, b.State())
+		require.Equal(t, proposer.Address(), b.Proposer())
+		require.Nil(t, b.Verify(networkID))
+		saved[b.Source()] = b.Vote()
+	}
+	require.Equal(t, votes, saved)
+
+	ballots, err = GetBallotsByHeight(st, 11)
+	require.Nil(t, err)
+	require.Equal(t, 1, len(ballots))
+	require.Equal(t, other.GetHash(), ballots[0].GetHash())
+}
@@ -9,10 +9,45 @@ const (
 	VotingEXP    VotingHole = "EXPIRED"
 )
 
+// VoteTally is the sum of the voting weights of the validators by
+// `VotingHole`.
+type VoteTally struct {
+	Yes     int
+	No      int
+	Expired int
+}
+
+func (t VoteTally) Voted() int {
+	return t.Yes + t.No + t.Expired
+}
+
+// VotingPolicy decides the voting result; the different consensus
+// algorithms can be plugged by implementing it.
+type VotingPolicy interface {
+	// RequiredCount returns the weight of the votes required to agree in
+	// the given state.
+	RequiredCount(State) int
+	// Decide returns the result of the votes and whether the result is
+	// decided.
+	Decide(VoteTally, State) (VotingHole, bool)
+}
+
 type VotingThresholdPolicy interface {
-	Threshold(State) int
+	VotingPolicy
+
 	Validators() int
 	SetValidators(int) error
 	Connected() int
 	SetConnected(int) error
+
+	// Weight returns the voting weight of validator.
+	Weight(string) int
+	// TotalWeight returns the sum of the weights of all the validators.
+	TotalWeight() int
+	// SetDelegatedWeights sets the weights, which are delegated to the
+	// validators by the accounts.
+	SetDelegatedWeights(map[string]uint64)
+	// SetValidatorWeights sets the weights of the stakes of the validators
+	// themselves.
+	SetValidatorWeights(map[string]uint64)
 }
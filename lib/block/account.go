@@ -1,11 +1,15 @@
 package block
 
 import (
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 
+	"github.com/btcsuite/btcutil/base58"
+
 	"boscoin.io/sebak/lib/common"
 	"boscoin.io/sebak/lib/common/observer"
+	"boscoin.io/sebak/lib/error"
 	"boscoin.io/sebak/lib/storage"
 )
 
@@ -19,6 +23,10 @@ import (
 // 	- 'ba-address-<BlockAccount.Address>': `BlockAccount`
 //  * 'created'
 // 	- 'ba-created-<sequential uuid1>': `BlockAccouna.Address`
+//  * 'created' by address
+// 	- 'ba-created-address-<BlockAccount.Address>': the key of 'created'
+//  * 'linked'
+// 	- 'ba-linked-<BlockAccount.Linked>-<BlockAccount.Address>': `BlockAccount.Address`
 
 type BlockAccount struct {
 	Address    string
@@ -28,6 +36,30 @@ type BlockAccount struct {
 	Linked   string
 	CodeHash []byte
 	RootHash common.Hash
+	// Small key/value metadata, set by the owner of account
+	Data map[string]string `json:",omitempty"`
+	// The address of validator, which the consensus weight of account is
+	// delegated to, or "" if not delegated
+	Delegate string `json:",omitempty"`
+	// The maximum amount, which the account can send within
+	// `common.SpendLimitPeriod`, or 0 if not limited
+	SpendLimit common.Amount `json:",omitempty"`
+	// The keys, which can sign for the account, and the sum of their weights
+	// to authorize; if empty, only the account itself can sign
+	Signers   []AccountSigner `json:",omitempty"`
+	Threshold uint64          `json:",omitempty"`
+}
+
+// AccountSigner is the key, which can sign for the account, and it's weight.
+type AccountSigner struct {
+	Address string `json:"address"`
+	Weight  uint64 `json:"weight"`
+}
+
+// AccountSigners is the signer configuration of account.
+type AccountSigners struct {
+	Signers   []AccountSigner `json:"signers"`
+	Threshold uint64          `json:"threshold"`
 }
 
 func NewBlockAccount(address string, balance common.Amount) *BlockAccount {
@@ -47,27 +79,43 @@ func (b *BlockAccount) String() string {
 	return string(common.MustJSONMarshal(b))
 }
 
-func (b *BlockAccount) Save(st *storage.LevelDBBackend) (err error) {
+// Save stores the account; the address of the created account is added to
+// the filter.
+func (b *BlockAccount) Save(st *storage.LevelDBBackend, filter *AccountFilter) (err error) {
 	key := GetBlockAccountKey(b.Address)
 
-	var exists bool
-	exists, err = st.Has(key)
+	var previous BlockAccount
+	if err = st.Get(key, &previous); err == nil {
+		if err = st.Set(key, b); err != nil {
+			return
+		}
+		err = updateBlockAccountStats(st, 0, previous.Balance, b.Balance)
+	} else if err == errors.ErrorStorageRecordDoesNotExist {
+		if err = st.New(key, b); err != nil {
+			return
+		}
+		createdKey := GetBlockAccountCreatedKey(common.GetUniqueIDFromUUID())
+		if err = st.New(createdKey, b.Address); err != nil {
+			return
+		}
+		if err = st.New(GetBlockAccountCreatedByAddressKey(b.Address), createdKey); err != nil {
+			return
+		}
+		if len(b.Linked) > 0 {
+			if err = st.New(GetBlockAccountLinkedKey(b.Linked, b.Address), b.Address); err != nil {
+				return
+			}
+		}
+		filter.Add(b.Address)
+		err = updateBlockAccountStats(st, 1, 0, b.Balance)
+	}
 	if err != nil {
 		return
 	}
 
-	if exists {
-		err = st.Set(key, b)
-	} else {
-		err = st.New(key, b)
-		createdKey := GetBlockAccountCreatedKey(common.GetUniqueIDFromUUID())
-		err = st.New(createdKey, b.Address)
-	}
-	if err == nil {
-		event := "saved"
-		event += " " + fmt.Sprintf("address-%s", b.Address)
-		observer.BlockAccountObserver.Trigger(event, b)
-	}
+	event := "saved"
+	event += " " + fmt.Sprintf("address-%s", b.Address)
+	observer.BlockAccountObserver.Trigger(event, b)
 
 	bac := BlockAccountSequenceID{
 		SequenceID: b.SequenceID,
@@ -87,6 +135,25 @@ func (b *BlockAccount) Deserialize(encoded []byte) (err error) {
 	return common.DecodeJSONValue(encoded, b)
 }
 
+// GetSigners returns the signers and the threshold of account. Without
+// `Signers`, the account itself is the only signer with the weight, 1, and the
+// threshold is 1.
+func (b *BlockAccount) GetSigners() AccountSigners {
+	if len(b.Signers) < 1 {
+		return AccountSigners{
+			Signers:   []AccountSigner{{Address: b.Address, Weight: 1}},
+			Threshold: 1,
+		}
+	}
+
+	threshold := b.Threshold
+	if threshold < 1 {
+		threshold = 1
+	}
+
+	return AccountSigners{Signers: b.Signers, Threshold: threshold}
+}
+
 func GetBlockAccountKey(address string) string {
 	return fmt.Sprintf("%s%s", common.BlockAccountPrefixAddress, address)
 }
@@ -95,18 +162,196 @@ func GetBlockAccountCreatedKey(created string) string {
 	return fmt.Sprintf("%s%s", common.BlockAccountPrefixCreated, created)
 }
 
+func GetBlockAccountCreatedByAddressKey(address string) string {
+	return fmt.Sprintf("%s%s", common.BlockAccountPrefixCreatedByAddress, address)
+}
+
+func GetBlockAccountLinkedKey(linked, address string) string {
+	return fmt.Sprintf("%s%s-%s", common.BlockAccountPrefixLinked, linked, address)
+}
+
+func GetBlockAccountLinkedKeyPrefix(linked string) string {
+	return fmt.Sprintf("%s%s-", common.BlockAccountPrefixLinked, linked)
+}
+
 func ExistsBlockAccount(st *storage.LevelDBBackend, address string) (exists bool, err error) {
 	return st.Has(GetBlockAccountKey(address))
 }
 
+// GetBlockAccount loads the account by one read. If the account does not
+// exist, `errors.ErrorBlockAccountDoesNotExists` is returned, so the caller
+// does not need to check `ExistsBlockAccount()` first.
 func GetBlockAccount(st *storage.LevelDBBackend, address string) (b *BlockAccount, err error) {
-	if err = st.Get(GetBlockAccountKey(address), &b); err != nil {
-		return
+	if err = st.Get(GetBlockAccountKey(address), &b); err == errors.ErrorStorageRecordDoesNotExist {
+		err = errors.ErrorBlockAccountDoesNotExists
 	}
 
 	return
 }
 
+// DeleteBlockAccount removes the account with it's indices; the created
+// index, the sequenceID and balance history, the operation and transaction
+// history by the account, and the delegations from and to the account. The
+// delegators of the account do not delegate any more. The keys are removed
+// and the stats are updated at once in the storage transaction, so `st` must
+// not be in the transaction.
+//
+// The account, which still has balance, can not be removed, so the total
+// supply is kept; the balance must be moved first. The account, which is
+// linked by the frozen account, also can not be removed.
+func DeleteBlockAccount(st *storage.LevelDBBackend, address string) (err error) {
+	var ba *BlockAccount
+	if ba, err = GetBlockAccount(st, address); err != nil {
+		return
+	}
+	if ba.Balance > 0 {
+		err = errors.ErrorBlockAccountHasBalance
+		return
+	}
+
+	var linked bool
+	if linked, err = isBlockAccountLinked(st, address); err != nil {
+		return
+	} else if linked {
+		err = errors.ErrorBlockAccountLinkedByFrozen
+		return
+	}
+
+	keys := []string{GetBlockAccountKey(address)}
+
+	var createdKey string
+	if createdKey, err = getBlockAccountCreatedKey(st, address); err != nil {
+		return
+	}
+	if len(createdKey) > 0 {
+		keys = append(keys, createdKey, GetBlockAccountCreatedByAddressKey(address))
+	}
+	if len(ba.Linked) > 0 {
+		keys = append(keys, GetBlockAccountLinkedKey(ba.Linked, address))
+	}
+
+	for _, prefix := range []string{
+		fmt.Sprintf("%s%s-", common.BlockAccountSequenceIDPrefix, address),
+		GetBlockAccountSequenceIDByAddressKeyPrefix(address),
+		fmt.Sprintf("%s%s-", common.BlockAccountSequenceIDSeenPrefix, address),
+		GetBlockOperationKeyPrefixSource(address),
+		GetBlockTransactionKeyPrefixSource(address),
+		GetBlockTransactionKeyPrefixAccount(address),
+	} {
+		iterFunc, closeFunc := st.GetIterator(prefix, nil)
+		for {
+			item, hasNext := iterFunc()
+			if !hasNext {
+				break
+			}
+			keys = append(keys, string(item.Key))
+		}
+		closeFunc()
+	}
+
+	if len(ba.Delegate) > 0 {
+		keys = append(keys, GetBlockAccountDelegationKey(ba.Delegate, address))
+	}
+
+	var delegators []string
+	if delegators, err = GetDelegators(st, address); err != nil {
+		return
+	}
+	for _, delegator := range delegators {
+		keys = append(keys, GetBlockAccountDelegationKey(address, delegator))
+	}
+
+	var ts *storage.LevelDBBackend
+	if ts, err = st.OpenTransaction(); err != nil {
+		return
+	}
+	if err = ts.Deletes(keys...); err != nil {
+		ts.Discard()
+		return
+	}
+	for _, delegator := range delegators {
+		var da *BlockAccount
+		if da, err = GetBlockAccount(ts, delegator); err != nil {
+			ts.Discard()
+			return
+		}
+		da.Delegate = ""
+		if err = ts.Set(GetBlockAccountKey(delegator), da); err != nil {
+			ts.Discard()
+			return
+		}
+	}
+	if err = updateBlockAccountStats(ts, -1, 0, 0); err != nil {
+		ts.Discard()
+		return
+	}
+
+	return ts.Commit()
+}
+
+// isBlockAccountLinked checks the account is linked by the frozen account.
+// The frozen account created before the 'linked' index is found by scanning
+// the accounts only if the account itself is also created before the index.
+func isBlockAccountLinked(st *storage.LevelDBBackend, address string) (linked bool, err error) {
+	iterFunc, closeFunc := st.GetIterator(GetBlockAccountLinkedKeyPrefix(address), nil)
+	_, linked = iterFunc()
+	closeFunc()
+	if linked {
+		return
+	}
+
+	var indexed bool
+	if indexed, err = st.Has(GetBlockAccountCreatedByAddressKey(address)); err != nil || indexed {
+		return
+	}
+
+	iterFunc, closeFunc = st.GetIterator(common.BlockAccountPrefixAddress, nil)
+	defer closeFunc()
+	for {
+		item, hasNext := iterFunc()
+		if !hasNext {
+			return
+		}
+		var other BlockAccount
+		if err = common.DecodeJSONValue(item.Value, &other); err != nil {
+			return
+		}
+		if other.Linked == address {
+			linked = true
+			return
+		}
+	}
+}
+
+// getBlockAccountCreatedKey returns the key of the 'created' index of the
+// account. The account created before the index by address is found by
+// scanning the 'created' index.
+func getBlockAccountCreatedKey(st *storage.LevelDBBackend, address string) (key string, err error) {
+	if err = st.Get(GetBlockAccountCreatedByAddressKey(address), &key); err == nil {
+		return
+	} else if err != errors.ErrorStorageRecordDoesNotExist {
+		return
+	}
+	err = nil
+
+	iterFunc, closeFunc := st.GetIterator(common.BlockAccountPrefixCreated, nil)
+	defer closeFunc()
+	for {
+		item, hasNext := iterFunc()
+		if !hasNext {
+			return
+		}
+		var created string
+		if err = json.Unmarshal(item.Value, &created); err != nil {
+			return
+		}
+		if created == address {
+			key = string(item.Key)
+			return
+		}
+	}
+}
+
 func GetBlockAccountAddressesByCreated(st *storage.LevelDBBackend, options storage.ListOptions) (func() (string, bool, []byte), func()) {
 	iterFunc, closeFunc := st.GetIterator(common.BlockAccountPrefixCreated, options)
 
@@ -146,6 +391,118 @@ func GetBlockAccountsByCreated(st *storage.LevelDBBackend, options storage.ListO
 		})
 }
 
+// GetAllBlockAccounts iterates all the accounts in the order of address.
+func GetAllBlockAccounts(st *storage.LevelDBBackend, options storage.ListOptions) (func() (BlockAccount, bool, []byte), func()) {
+	iterFunc, closeFunc := st.GetIterator(common.BlockAccountPrefixAddress, options)
+
+	return (func() (BlockAccount, bool, []byte) {
+			item, hasNext := iterFunc()
+			if !hasNext {
+				return BlockAccount{}, false, item.Key
+			}
+
+			var ba BlockAccount
+			if err := common.DecodeJSONValue(item.Value, &ba); err != nil {
+				return BlockAccount{}, false, item.Key
+			}
+			return ba, hasNext, item.Key
+		}), (func() {
+			closeFunc()
+		})
+}
+
+// AccountStateHash returns the hash of all the accounts, which are sorted by
+// address. The nodes, which have the same accounts, have the same hash, so
+// the whole state can be compared quickly. It uses SHA-256 regardless of
+// `common.DefaultHashAlgo`, because the accounts can be too many for argon2.
+func AccountStateHash(st *storage.LevelDBBackend) (hash string, err error) {
+	h := sha256.New()
+
+	iterFunc, closeFunc := st.GetIterator(common.BlockAccountPrefixAddress, nil)
+	defer closeFunc()
+
+	for {
+		item, hasNext := iterFunc()
+		if !hasNext {
+			break
+		}
+
+		var ba BlockAccount
+		if err = common.DecodeJSONValue(item.Value, &ba); err != nil {
+			return
+		}
+
+		var encoded []byte
+		if encoded, err = ba.Serialize(); err != nil {
+			return
+		}
+		h.Write(encoded)
+	}
+
+	hash = base58.Encode(h.Sum(nil))
+
+	return
+}
+
+// StorageSize returns the bytes of the metadata of account, which is counted
+// in the storage budget; the keys and values of `Data` and the linked and
+// delegated addresses.
+func (b *BlockAccount) StorageSize() (size int) {
+	for k, v := range b.Data {
+		size += len(k) + len(v)
+	}
+	size += len(b.Linked) + len(b.Delegate)
+
+	return
+}
+
+// CheckStorageBudget checks the metadata of account is not over the storage
+// budget of network; see `GetAccountStorageBudget()`.
+func (b *BlockAccount) CheckStorageBudget(st *storage.LevelDBBackend) error {
+	budget, err := GetAccountStorageBudget(st)
+	if err != nil {
+		return err
+	} else if budget < 1 {
+		return nil
+	}
+
+	if size := b.StorageSize(); size > budget {
+		return errors.ErrorAccountStorageBudgetExceeded.Clone().
+			SetData("size", size).
+			SetData("budget", budget)
+	}
+
+	return nil
+}
+
+// SaveAccountStorageBudget stores the storage budget of account, which is
+// the network parameter stored with the genesis block.
+func SaveAccountStorageBudget(st *storage.LevelDBBackend, budget int) (err error) {
+	var exists bool
+	if exists, err = st.Has(common.BlockAccountStorageBudgetPrefix); err != nil {
+		return
+	}
+
+	if exists {
+		err = st.Set(common.BlockAccountStorageBudgetPrefix, budget)
+	} else {
+		err = st.New(common.BlockAccountStorageBudgetPrefix, budget)
+	}
+
+	return
+}
+
+// GetAccountStorageBudget returns the stored storage budget of account. If it
+// is not stored, like the network made before the budget, `0` is returned,
+// so the budget is disabled.
+func GetAccountStorageBudget(st *storage.LevelDBBackend) (budget int, err error) {
+	if err = st.Get(common.BlockAccountStorageBudgetPrefix, &budget); err == errors.ErrorStorageRecordDoesNotExist {
+		err = nil
+	}
+
+	return
+}
+
 func (b *BlockAccount) GetBalance() common.Amount {
 	return b.Balance
 }
@@ -176,6 +533,58 @@ func (b *BlockAccount) Withdraw(fund common.Amount) error {
 	return nil
 }
 
+// WithdrawWithSequenceID removes fund like `Withdraw`, but the sequenceID of
+// the transaction is committed by `CommitSequenceID`, so the sequenceID ahead
+// of the current one under the nonce window can be used.
+func (b *BlockAccount) WithdrawWithSequenceID(st *storage.LevelDBBackend, fund common.Amount, sequenceID uint64) error {
+	val, err := b.GetBalance().Sub(fund)
+	if err != nil {
+		return err
+	}
+	b.Balance = val
+
+	return b.CommitSequenceID(st, sequenceID)
+}
+
+// CommitSequenceID marks the sequenceID as used by the account. If it is the
+// current `SequenceID`, `SequenceID` moves forward over the already seen
+// sequenceIDs; if it is ahead of the current one under the nonce window, it is
+// kept as seen until the gap is filled.
+func (b *BlockAccount) CommitSequenceID(st *storage.LevelDBBackend, sequenceID uint64) (err error) {
+	if sequenceID != b.SequenceID {
+		err = st.New(GetBlockAccountSeenSequenceIDKey(b.Address, sequenceID), sequenceID)
+		return
+	}
+
+	var next uint64
+	if next, err = b.NextSequenceID(st, sequenceID); err != nil {
+		return
+	}
+	for b.SequenceID += 1; b.SequenceID < next; b.SequenceID += 1 {
+		if err = st.Remove(GetBlockAccountSeenSequenceIDKey(b.Address, b.SequenceID)); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// NextSequenceID returns the sequenceID of account after `CommitSequenceID`
+// with `sequenceID`; nothing is changed.
+func (b *BlockAccount) NextSequenceID(st *storage.LevelDBBackend, sequenceID uint64) (next uint64, err error) {
+	next = b.SequenceID
+	if sequenceID != b.SequenceID {
+		return
+	}
+
+	for next += 1; ; next += 1 {
+		var seen bool
+		if seen, err = ExistsBlockAccountSeenSequenceID(st, b.Address, next); err != nil || !seen {
+			return
+		}
+	}
+}
+
 // BlockAccountSequenceID is the one-and-one model of account and sequenceID in
 // block. the storage should support,
 //  * find by `Address`:
@@ -203,6 +612,16 @@ func GetBlockAccountSequenceIDByAddressKeyPrefix(address string) string {
 	return fmt.Sprintf("%s%s-", common.BlockAccountSequenceIDByAddressPrefix, address)
 }
 
+func GetBlockAccountSeenSequenceIDKey(address string, sequenceID uint64) string {
+	return fmt.Sprintf("%s%s-%v", common.BlockAccountSequenceIDSeenPrefix, address, sequenceID)
+}
+
+// ExistsBlockAccountSeenSequenceID checks the sequenceID, which is ahead of the
+// current `SequenceID` of account, was already used.
+func ExistsBlockAccountSeenSequenceID(st *storage.LevelDBBackend, address string, sequenceID uint64) (bool, error) {
+	return st.Has(GetBlockAccountSeenSequenceIDKey(address, sequenceID))
+}
+
 func (b *BlockAccountSequenceID) String() string {
 	return string(common.MustJSONMarshal(b))
 }
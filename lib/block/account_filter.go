@@ -0,0 +1,171 @@
+package block
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"sync"
+
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/error"
+	"boscoin.io/sebak/lib/storage"
+)
+
+const (
+	// AccountFilterBits is the size of `AccountFilter`; with 1 million
+	// accounts, about 2% of the unknown addresses are false positive.
+	AccountFilterBits uint64 = 1 << 23
+	// AccountFilterHashes is the number of the bits set by one address.
+	AccountFilterHashes uint64 = 7
+)
+
+// AccountFilter is the bloom filter of the account addresses. If
+// `MightHaveAccount` returns false, the account surely does not exist; if
+// true, the account may exist, so it must be checked by `ExistsBlockAccount`.
+// The removed accounts are not removed from the filter. The filter has the
+// accounts of one storage; it is loaded by `LoadAccountFilter` and the
+// created accounts are added by `BlockAccount.Save`. The nil filter has no
+// address, but every account might exist.
+type AccountFilter struct {
+	sync.RWMutex
+
+	bits []uint64
+}
+
+func NewAccountFilter() *AccountFilter {
+	return &AccountFilter{bits: make([]uint64, AccountFilterBits/64)}
+}
+
+// positions returns the bits of address by the double hashing.
+func (f *AccountFilter) positions(address string) (ps []uint64) {
+	h := fnv.New64a()
+	h.Write([]byte(address))
+	h1 := h.Sum64()
+
+	h = fnv.New64()
+	h.Write([]byte(address))
+	h2 := h.Sum64() | 1
+
+	for i := uint64(0); i < AccountFilterHashes; i++ {
+		ps = append(ps, (h1+i*h2)%AccountFilterBits)
+	}
+
+	return
+}
+
+func (f *AccountFilter) Add(address string) {
+	if f == nil {
+		return
+	}
+
+	f.Lock()
+	defer f.Unlock()
+
+	for _, p := range f.positions(address) {
+		f.bits[p/64] |= 1 << (p % 64)
+	}
+}
+
+// MightHaveAccount returns false if the account of address was never
+// created.
+func (f *AccountFilter) MightHaveAccount(address string) bool {
+	if f == nil {
+		return true
+	}
+
+	f.RLock()
+	defer f.RUnlock()
+
+	for _, p := range f.positions(address) {
+		if f.bits[p/64]&(1<<(p%64)) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// merge adds the bits of the other filter; the addresses in the both filters
+// are kept.
+func (f *AccountFilter) merge(bits []uint64) {
+	f.Lock()
+	defer f.Unlock()
+
+	for i := 0; i < len(f.bits) && i < len(bits); i++ {
+		f.bits[i] |= bits[i]
+	}
+}
+
+func (f *AccountFilter) encode() []byte {
+	f.RLock()
+	defer f.RUnlock()
+
+	b := make([]byte, len(f.bits)*8)
+	for i, v := range f.bits {
+		binary.BigEndian.PutUint64(b[i*8:], v)
+	}
+
+	return b
+}
+
+func decodeAccountFilterBits(b []byte) []uint64 {
+	bits := make([]uint64, len(b)/8)
+	for i := range bits {
+		bits[i] = binary.BigEndian.Uint64(b[i*8:])
+	}
+
+	return bits
+}
+
+// ExistsBlockAccountFiltered checks the filter first, and only if the account
+// might exist, checks the storage by `ExistsBlockAccount`.
+func ExistsBlockAccountFiltered(st *storage.LevelDBBackend, filter *AccountFilter, address string) (bool, error) {
+	if !filter.MightHaveAccount(address) {
+		return false, nil
+	}
+
+	return ExistsBlockAccount(st, address)
+}
+
+// SaveAccountFilter stores the filter of the storage to be loaded by
+// `LoadAccountFilter` at the next start.
+func SaveAccountFilter(st *storage.LevelDBBackend, filter *AccountFilter) (err error) {
+	var exists bool
+	if exists, err = st.Has(common.BlockAccountFilterPrefix); err != nil {
+		return
+	} else if exists {
+		return st.Set(common.BlockAccountFilterPrefix, filter.encode())
+	}
+
+	return st.New(common.BlockAccountFilterPrefix, filter.encode())
+}
+
+// LoadAccountFilter returns the filter of the accounts in storage. The filter
+// stored by `SaveAccountFilter` is used and removed, so the filter, which may
+// miss the accounts created after it was stored, is not used again; without
+// the stored filter, it is rebuilt from the accounts.
+func LoadAccountFilter(st *storage.LevelDBBackend) (filter *AccountFilter, err error) {
+	filter = NewAccountFilter()
+
+	var b []byte
+	if err = st.Get(common.BlockAccountFilterPrefix, &b); err == nil {
+		filter.merge(decodeAccountFilterBits(b))
+		err = st.Remove(common.BlockAccountFilterPrefix)
+		return
+	} else if err != errors.ErrorStorageRecordDoesNotExist {
+		return
+	}
+	err = nil
+
+	iterFunc, closeFunc := st.GetIterator(common.BlockAccountPrefixAddress, nil)
+	defer closeFunc()
+
+	for {
+		item, hasNext := iterFunc()
+		if !hasNext {
+			break
+		}
+		filter.Add(string(item.Key)[len(common.BlockAccountPrefixAddress):])
+	}
+
+	return
+}
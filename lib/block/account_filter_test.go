@@ -0,0 +1,96 @@
+package block
+
+import (
+	"testing"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stretchr/testify/require"
+
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/storage"
+)
+
+func TestAccountFilter(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+	filter := NewAccountFilter()
+
+	{ // never seen
+		kp, _ := keypair.Random()
+		require.False(t, filter.MightHaveAccount(kp.Address()))
+
+		exists, err := ExistsBlockAccountFiltered(st, filter, kp.Address())
+		require.Nil(t, err)
+		require.False(t, exists)
+	}
+
+	ba := TestMakeBlockAccount()
+	require.Nil(t, ba.Save(st, filter))
+	require.True(t, filter.MightHaveAccount(ba.Address))
+
+	exists, err := ExistsBlockAccountFiltered(st, filter, ba.Address)
+	require.Nil(t, err)
+	require.True(t, exists)
+
+	{ // removed account is still in filter, but the storage is checked
+		ba.Balance = 0
+		require.Nil(t, ba.Save(st, filter))
+		require.Nil(t, DeleteBlockAccount(st, ba.Address))
+		require.True(t, filter.MightHaveAccount(ba.Address))
+
+		exists, err := ExistsBlockAccountFiltered(st, filter, ba.Address)
+		require.Nil(t, err)
+		require.False(t, exists)
+	}
+
+	{ // the filter of the other storage does not have the account
+		other := storage.NewTestStorage()
+		defer other.Close()
+
+		otherFilter, err := LoadAccountFilter(other)
+		require.Nil(t, err)
+		require.False(t, otherFilter.MightHaveAccount(ba.Address))
+	}
+
+	{ // without filter, the storage is checked
+		kp, _ := keypair.Random()
+		exists, err := ExistsBlockAccountFiltered(st, nil, kp.Address())
+		require.Nil(t, err)
+		require.False(t, exists)
+
+		exists, err = ExistsBlockAccountFiltered(st, nil, ba.Address)
+		require.Nil(t, err)
+		require.False(t, exists)
+	}
+}
+
+func TestLoadAccountFilter(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	// stored without `BlockAccount.Save`, so not in filter
+	kp, _ := keypair.Random()
+	require.Nil(t, st.New(GetBlockAccountKey(kp.Address()), NewBlockAccount(kp.Address(), common.Amount(1))))
+
+	// rebuilt from the accounts
+	filter, err := LoadAccountFilter(st)
+	require.Nil(t, err)
+	require.True(t, filter.MightHaveAccount(kp.Address()))
+
+	require.Nil(t, SaveAccountFilter(st, filter))
+	require.Nil(t, SaveAccountFilter(st, filter))
+
+	var b []byte
+	require.Nil(t, st.Get(common.BlockAccountFilterPrefix, &b))
+	stored := NewAccountFilter()
+	stored.merge(decodeAccountFilterBits(b))
+	require.True(t, stored.MightHaveAccount(kp.Address()))
+
+	// stored filter is used only once
+	loaded, err := LoadAccountFilter(st)
+	require.Nil(t, err)
+	require.True(t, loaded.MightHaveAccount(kp.Address()))
+	exists, err := st.Has(common.BlockAccountFilterPrefix)
+	require.Nil(t, err)
+	require.False(t, exists)
+}
@@ -3,13 +3,17 @@ package block
 import (
 	"fmt"
 	"math/rand"
+	"sort"
 	"sync"
 	"testing"
 
 	"boscoin.io/sebak/lib/common"
 	"boscoin.io/sebak/lib/common/observer"
+	"boscoin.io/sebak/lib/error"
 	"boscoin.io/sebak/lib/storage"
+	"boscoin.io/sebak/lib/transaction"
 
+	"github.com/stellar/go/keypair"
 	"github.com/stretchr/testify/require"
 )
 
@@ -17,7 +21,7 @@ func TestSaveNewBlockAccount(t *testing.T) {
 	st := storage.NewTestStorage()
 
 	b := TestMakeBlockAccount()
-	err := b.Save(st)
+	err := b.Save(st, nil)
 	require.Nil(t, err)
 
 	exists, err := ExistsBlockAccount(st, b.Address)
@@ -29,25 +33,49 @@ func TestSaveExistingBlockAccount(t *testing.T) {
 	st := storage.NewTestStorage()
 
 	b := TestMakeBlockAccount()
-	b.Save(st)
+	b.Save(st, nil)
 
 	err := b.Deposit(common.Amount(100))
 	require.Nil(t, err)
 
-	err = b.Save(st)
+	err = b.Save(st, nil)
 	require.Nil(t, err)
 
 	fetched, _ := GetBlockAccount(st, b.Address)
 	require.Equal(t, b.GetBalance(), fetched.GetBalance())
 }
 
+func TestGetBlockAccount(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	{ // missing account
+		kp, _ := keypair.Random()
+		ba, err := GetBlockAccount(st, kp.Address())
+		require.Equal(t, errors.ErrorBlockAccountDoesNotExists, err)
+		require.Nil(t, ba)
+	}
+
+	{ // stored account
+		b := TestMakeBlockAccount()
+		b.SequenceID = 3
+		require.Nil(t, b.Save(st, nil))
+
+		ba, err := GetBlockAccount(st, b.Address)
+		require.Nil(t, err)
+		require.Equal(t, b.Address, ba.Address)
+		require.Equal(t, b.Balance, ba.Balance)
+		require.Equal(t, b.SequenceID, ba.SequenceID)
+	}
+}
+
 func TestSortMultipleBlockAccount(t *testing.T) {
 	st := storage.NewTestStorage()
 
 	var createdOrder []string
 	for i := 0; i < 50; i++ {
 		b := TestMakeBlockAccount()
-		b.Save(st)
+		b.Save(st, nil)
 
 		createdOrder = append(createdOrder, b.Address)
 	}
@@ -75,7 +103,7 @@ func TestGetSortedBlockAccounts(t *testing.T) {
 	var createdOrder []string
 	for i := 0; i < 50; i++ {
 		b := TestMakeBlockAccount()
-		b.Save(st)
+		b.Save(st, nil)
 
 		createdOrder = append(createdOrder, b.Address)
 	}
@@ -101,14 +129,14 @@ func TestBlockAccountSaveBlockAccountSequenceIDs(t *testing.T) {
 	st := storage.NewTestStorage()
 
 	b := TestMakeBlockAccount()
-	b.Save(st)
+	b.Save(st, nil)
 
 	expectedSavedLength := 10
 	var saved []BlockAccount
 	saved = append(saved, *b)
 	for i := 0; i < expectedSavedLength-len(saved); i++ {
 		b.SequenceID = rand.Uint64()
-		b.Save(st)
+		b.Save(st, nil)
 
 		saved = append(saved, *b)
 	}
@@ -148,7 +176,7 @@ func TestBlockAccountObserver(t *testing.T) {
 
 	st := storage.NewTestStorage()
 
-	b.Save(st)
+	b.Save(st, nil)
 
 	wg.Wait()
 
@@ -156,3 +184,273 @@ func TestBlockAccountObserver(t *testing.T) {
 	require.Equal(t, b.GetBalance(), triggered.GetBalance())
 	require.Equal(t, b.SequenceID, triggered.SequenceID)
 }
+
+func TestBlockAccountCommitSequenceID(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	b := TestMakeBlockAccount()
+	b.SequenceID = 1
+	b.Save(st, nil)
+
+	// ahead of the current sequenceID, it is just kept as seen
+	require.Nil(t, b.CommitSequenceID(st, 3))
+	require.Equal(t, uint64(1), b.SequenceID)
+	seen, err := ExistsBlockAccountSeenSequenceID(st, b.Address, 3)
+	require.Nil(t, err)
+	require.True(t, seen)
+
+	require.Nil(t, b.CommitSequenceID(st, 2))
+	require.Equal(t, uint64(1), b.SequenceID)
+
+	// filling the gap moves the sequenceID over the seen ones
+	require.Nil(t, b.CommitSequenceID(st, 1))
+	require.Equal(t, uint64(4), b.SequenceID)
+
+	seen, err = ExistsBlockAccountSeenSequenceID(st, b.Address, 3)
+	require.Nil(t, err)
+	require.False(t, seen)
+}
+
+func TestDeleteBlockAccount(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	other := TestMakeBlockAccount()
+	require.Nil(t, other.Save(st, nil))
+
+	kp, _ := keypair.Random()
+	b := NewBlockAccount(kp.Address(), common.BaseReserve)
+	require.Nil(t, b.Save(st, nil))
+	b.SequenceID = 1
+	require.Nil(t, b.Save(st, nil))
+	require.Nil(t, b.SetDelegate(st, other.Address))
+	require.Nil(t, b.Save(st, nil))
+
+	// the other account delegates to the account
+	delegator := TestMakeBlockAccount()
+	require.Nil(t, delegator.SetDelegate(st, b.Address))
+	require.Nil(t, delegator.Save(st, nil))
+
+	// history of the account
+	tx := transaction.TestMakeTransactionWithKeypair(networkID, 1, kp)
+	raw, _ := tx.Serialize()
+	bt := NewBlockTransactionFromTransaction("dummy", 1, common.NowISO8601(), tx, raw)
+	require.Nil(t, bt.Save(st))
+
+	prefixes := []string{
+		GetBlockAccountKey(b.Address),
+		fmt.Sprintf("%s%s-", common.BlockAccountSequenceIDPrefix, b.Address),
+		GetBlockAccountSequenceIDByAddressKeyPrefix(b.Address),
+		GetBlockOperationKeyPrefixSource(b.Address),
+		GetBlockTransactionKeyPrefixSource(b.Address),
+		GetBlockTransactionKeyPrefixAccount(b.Address),
+		GetBlockAccountDelegationKey(other.Address, b.Address),
+		GetBlockAccountDelegationKeyPrefix(b.Address),
+		GetBlockAccountCreatedByAddressKey(b.Address),
+	}
+	countKeys := func(prefix string) (n int) {
+		iterFunc, closeFunc := st.GetIterator(prefix, nil)
+		defer closeFunc()
+		for {
+			if _, hasNext := iterFunc(); !hasNext {
+				return
+			}
+			n++
+		}
+	}
+	for _, prefix := range prefixes {
+		require.True(t, countKeys(prefix) > 0, prefix)
+	}
+
+	// the account, which has balance, can not be removed
+	require.Equal(t, errors.ErrorBlockAccountHasBalance, DeleteBlockAccount(st, b.Address))
+	for _, prefix := range prefixes {
+		require.True(t, countKeys(prefix) > 0, prefix)
+	}
+
+	b.Balance = 0
+	require.Nil(t, b.Save(st, nil))
+
+	stats, err := GetBlockAccountStats(st)
+	require.Nil(t, err)
+
+	require.Nil(t, DeleteBlockAccount(st, b.Address))
+
+	for _, prefix := range prefixes {
+		require.Equal(t, 0, countKeys(prefix), prefix)
+	}
+	var addresses []string
+	iterFunc, closeFunc := GetBlockAccountAddressesByCreated(st, nil)
+	for {
+		address, hasNext, _ := iterFunc()
+		if !hasNext {
+			break
+		}
+		addresses = append(addresses, address)
+	}
+	closeFunc()
+	require.Equal(t, []string{other.Address, delegator.Address}, addresses)
+
+	// the supply is kept
+	deleted, err := GetBlockAccountStats(st)
+	require.Nil(t, err)
+	require.Equal(t, stats.Accounts-1, deleted.Accounts)
+	require.Equal(t, stats.Supply, deleted.Supply)
+
+	// the other account is not touched
+	exists, err := ExistsBlockAccount(st, other.Address)
+	require.Nil(t, err)
+	require.True(t, exists)
+
+	// the delegator does not delegate any more
+	saved, err := GetBlockAccount(st, delegator.Address)
+	require.Nil(t, err)
+	require.Equal(t, "", saved.Delegate)
+	require.Equal(t, delegator.Balance, saved.Balance)
+
+	require.Equal(t, errors.ErrorBlockAccountDoesNotExists, DeleteBlockAccount(st, b.Address))
+}
+
+func TestDeleteBlockAccountLinkedByFrozen(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	b := TestMakeBlockAccount()
+	require.Nil(t, b.Save(st, nil))
+
+	kpFrozen, _ := keypair.Random()
+	frozen := NewBlockAccountLinked(kpFrozen.Address(), common.Unit, b.Address)
+	require.Nil(t, frozen.Save(st, nil))
+
+	b.Balance = 0
+	require.Nil(t, b.Save(st, nil))
+	frozen.Balance = 0
+	require.Nil(t, frozen.Save(st, nil))
+
+	require.Equal(t, errors.ErrorBlockAccountLinkedByFrozen, DeleteBlockAccount(st, b.Address))
+
+	exists, err := ExistsBlockAccount(st, b.Address)
+	require.Nil(t, err)
+	require.True(t, exists)
+
+	// the frozen account itself can be removed with it's 'linked' index
+	require.Nil(t, DeleteBlockAccount(st, frozen.Address))
+	require.Nil(t, DeleteBlockAccount(st, b.Address))
+}
+
+// The accounts saved before the 'created' index by address and the 'linked'
+// index are found by scanning.
+func TestDeleteBlockAccountWithoutIndex(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	b := TestMakeBlockAccount()
+	require.Nil(t, b.Save(st, nil))
+
+	kpFrozen, _ := keypair.Random()
+	frozen := NewBlockAccountLinked(kpFrozen.Address(), 0, b.Address)
+	require.Nil(t, frozen.Save(st, nil))
+
+	b.Balance = 0
+	require.Nil(t, b.Save(st, nil))
+
+	for _, address := range []string{b.Address, frozen.Address} {
+		require.Nil(t, st.Remove(GetBlockAccountCreatedByAddressKey(address)))
+	}
+	require.Nil(t, st.Remove(GetBlockAccountLinkedKey(b.Address, frozen.Address)))
+
+	require.Equal(t, errors.ErrorBlockAccountLinkedByFrozen, DeleteBlockAccount(st, b.Address))
+
+	require.Nil(t, DeleteBlockAccount(st, frozen.Address))
+	require.Nil(t, DeleteBlockAccount(st, b.Address))
+
+	iterFunc, closeFunc := GetBlockAccountAddressesByCreated(st, nil)
+	_, hasNext, _ := iterFunc()
+	closeFunc()
+	require.False(t, hasNext)
+}
+
+func TestAccountStateHash(t *testing.T) {
+	st0 := storage.NewTestStorage()
+	defer st0.Close()
+	st1 := storage.NewTestStorage()
+	defer st1.Close()
+
+	var accounts []*BlockAccount
+	for i := 0; i < 10; i++ {
+		accounts = append(accounts, TestMakeBlockAccount())
+	}
+
+	// saved in the different order
+	for i := range accounts {
+		require.Nil(t, accounts[i].Save(st0, nil))
+		require.Nil(t, accounts[len(accounts)-1-i].Save(st1, nil))
+	}
+
+	{ // iterated in the order of address
+		var addresses []string
+		iterFunc, closeFunc := GetAllBlockAccounts(st0, nil)
+		for {
+			ba, hasNext, _ := iterFunc()
+			if !hasNext {
+				break
+			}
+			addresses = append(addresses, ba.Address)
+		}
+		closeFunc()
+
+		require.Equal(t, len(accounts), len(addresses))
+		require.True(t, sort.StringsAreSorted(addresses))
+	}
+
+	hash0, err := AccountStateHash(st0)
+	require.Nil(t, err)
+	hash1, err := AccountStateHash(st1)
+	require.Nil(t, err)
+	require.Equal(t, hash0, hash1)
+
+	// change balance
+	changed, err := GetBlockAccount(st1, accounts[3].Address)
+	require.Nil(t, err)
+	require.Nil(t, changed.Deposit(common.Amount(1)))
+	require.Nil(t, changed.Save(st1, nil))
+
+	hash1, err = AccountStateHash(st1)
+	require.Nil(t, err)
+	require.NotEqual(t, hash0, hash1)
+}
+
+// The storage budget of account is stored with the genesis block, so all the
+// nodes of network check the same budget.
+func TestAccountStorageBudgetFromGenesis(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	kp, _ := keypair.Random()
+	account := NewBlockAccount(kp.Address(), common.Amount(100))
+	require.Nil(t, account.Save(st, nil))
+	account.Data = map[string]string{"site": "showme"}
+
+	// not stored; the budget is disabled
+	budget, err := GetAccountStorageBudget(st)
+	require.Nil(t, err)
+	require.Equal(t, 0, budget)
+	require.Nil(t, account.CheckStorageBudget(st))
+
+	config := NewGenesisConfigFromAccount(*account)
+	config.AccountStorageBudget = account.StorageSize() - 1
+	_, err = MakeGenesisBlockFromConfig(st, config, networkID, kp)
+	require.Nil(t, err)
+
+	budget, err = GetAccountStorageBudget(st)
+	require.Nil(t, err)
+	require.Equal(t, config.AccountStorageBudget, budget)
+
+	err = account.CheckStorageBudget(st)
+	require.NotNil(t, err)
+	require.Equal(t, errors.ErrorAccountStorageBudgetExceeded.Code, err.(*errors.Error).Code)
+
+	account.Data = map[string]string{"site": "show"}
+	require.Nil(t, account.CheckStorageBudget(st))
+}
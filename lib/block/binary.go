@@ -0,0 +1,140 @@
+package block
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+
+	"boscoin.io/sebak/lib/error"
+)
+
+// MaxBlockBinaryFrameSize limits the size of one frame of the block binary
+// format, so the broken or hostile stream can not make the reader allocate
+// too much.
+var MaxBlockBinaryFrameSize uint32 = 16 * 1024 * 1024
+
+// MaxBlockBinaryDecodedSize limits the size of the decompressed JSON of one
+// frame, so the small frame can not be decompressed to too large.
+var MaxBlockBinaryDecodedSize int64 = 64 * 1024 * 1024
+
+const blockBinaryFrameHeaderSize = 4
+
+// EncodeBlockBinary encodes the block to the frame of the block binary
+// format; the gzipped JSON of block, prefixed by it's length as 4 bytes
+// big-endian unsigned integer. The frames can be concatenated into the
+// stream, see `WriteBlockStream()`.
+func EncodeBlockBinary(b Block) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Write(make([]byte, blockBinaryFrameHeaderSize))
+
+	gw, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.NewEncoder(gw).Encode(b); err != nil {
+		return nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+
+	frame := buf.Bytes()
+	binary.BigEndian.PutUint32(frame, uint32(len(frame)-blockBinaryFrameHeaderSize))
+
+	return frame, nil
+}
+
+// DecodeBlockBinary decodes the frame, which is made by
+// `EncodeBlockBinary()`.
+func DecodeBlockBinary(frame []byte) (b Block, err error) {
+	if len(frame) < blockBinaryFrameHeaderSize {
+		err = errors.ErrorInvalidBlockBinaryFrame
+		return
+	}
+
+	size := binary.BigEndian.Uint32(frame)
+	if size > MaxBlockBinaryFrameSize || int(size) != len(frame)-blockBinaryFrameHeaderSize {
+		err = errors.ErrorInvalidBlockBinaryFrame
+		return
+	}
+
+	return decodeBlockBinaryPayload(frame[blockBinaryFrameHeaderSize:])
+}
+
+func decodeBlockBinaryPayload(payload []byte) (b Block, err error) {
+	var gr *gzip.Reader
+	if gr, err = gzip.NewReader(bytes.NewReader(payload)); err != nil {
+		err = errors.ErrorInvalidBlockBinaryFrame.Clone().SetData("error", err.Error())
+		return
+	}
+	defer gr.Close()
+
+	var decoded []byte
+	if decoded, err = ioutil.ReadAll(io.LimitReader(gr, MaxBlockBinaryDecodedSize+1)); err != nil {
+		err = errors.ErrorInvalidBlockBinaryFrame.Clone().SetData("error", err.Error())
+		return
+	}
+	if int64(len(decoded)) > MaxBlockBinaryDecodedSize {
+		err = errors.ErrorInvalidBlockBinaryFrame.Clone().SetData("size", len(decoded))
+		return
+	}
+	if err = json.Unmarshal(decoded, &b); err != nil {
+		err = errors.ErrorInvalidBlockBinaryFrame.Clone().SetData("error", err.Error())
+		return
+	}
+
+	return
+}
+
+// WriteBlockStream writes the blocks to `w` as the stream of the block binary
+// frames.
+func WriteBlockStream(w io.Writer, blocks []Block) error {
+	for _, b := range blocks {
+		frame, err := EncodeBlockBinary(b)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(frame); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReadBlockStream reads the blocks from the stream of the block binary
+// frames until `io.EOF`. The stream, which ends in the middle of frame, is
+// invalid.
+func ReadBlockStream(r io.Reader) (blocks []Block, err error) {
+	header := make([]byte, blockBinaryFrameHeaderSize)
+	for {
+		if _, err = io.ReadFull(r, header); err == io.EOF {
+			err = nil
+			return
+		} else if err != nil {
+			err = errors.ErrorInvalidBlockBinaryFrame.Clone().SetData("error", err.Error())
+			return
+		}
+
+		size := binary.BigEndian.Uint32(header)
+		if size > MaxBlockBinaryFrameSize {
+			err = errors.ErrorInvalidBlockBinaryFrame.Clone().SetData("size", size)
+			return
+		}
+
+		payload := make([]byte, size)
+		if _, err = io.ReadFull(r, payload); err != nil {
+			err = errors.ErrorInvalidBlockBinaryFrame.Clone().SetData("error", err.Error())
+			return
+		}
+
+		var b Block
+		if b, err = decodeBlockBinaryPayload(payload); err != nil {
+			return
+		}
+		blocks = append(blocks, b)
+	}
+}
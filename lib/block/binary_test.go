@@ -0,0 +1,94 @@
+package block
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/error"
+)
+
+// requireEqualBlock compares the blocks by JSON; the location of
+// `Header.Timestamp` is not kept by JSON.
+func requireEqualBlock(t *testing.T, expected, actual Block) {
+	e, err := json.Marshal(expected)
+	require.Nil(t, err)
+	a, err := json.Marshal(actual)
+	require.Nil(t, err)
+	require.Equal(t, string(e), string(a))
+}
+
+func TestBlockBinaryRoundTrip(t *testing.T) {
+	b := TestMakeNewBlock([]string{common.GetUniqueIDFromUUID()})
+
+	frame, err := EncodeBlockBinary(b)
+	require.Nil(t, err)
+
+	decoded, err := DecodeBlockBinary(frame)
+	require.Nil(t, err)
+	requireEqualBlock(t, b, decoded)
+
+	{ // broken frames
+		_, err = DecodeBlockBinary(frame[:3])
+		require.Equal(t, errors.ErrorInvalidBlockBinaryFrame, err)
+
+		_, err = DecodeBlockBinary(frame[:len(frame)-1])
+		require.Equal(t, errors.ErrorInvalidBlockBinaryFrame, err)
+	}
+
+	{ // decompressed over `MaxBlockBinaryDecodedSize`
+		defer func(max int64) { MaxBlockBinaryDecodedSize = max }(MaxBlockBinaryDecodedSize)
+		MaxBlockBinaryDecodedSize = 10
+
+		_, err = DecodeBlockBinary(frame)
+		require.Error(t, err)
+		require.Equal(t, errors.ErrorInvalidBlockBinaryFrame.Code, err.(*errors.Error).Code)
+	}
+}
+
+func TestBlockBinaryStream(t *testing.T) {
+	var blocks []Block
+	var jsonSize int
+	for i := 0; i < 10; i++ {
+		var hashes []string
+		for j := 0; j < 10; j++ {
+			hashes = append(hashes, common.GetUniqueIDFromUUID())
+		}
+		b := TestMakeNewBlock(hashes)
+		blocks = append(blocks, b)
+
+		encoded, err := json.Marshal(b)
+		require.Nil(t, err)
+		jsonSize += len(encoded)
+	}
+
+	var buf bytes.Buffer
+	require.Nil(t, WriteBlockStream(&buf, blocks))
+
+	// the binary stream is smaller than the raw JSON of the blocks
+	require.True(t, buf.Len() < jsonSize, "binary=%d json=%d", buf.Len(), jsonSize)
+
+	stream := buf.Bytes()
+
+	read, err := ReadBlockStream(bytes.NewReader(stream))
+	require.Nil(t, err)
+	require.Equal(t, len(blocks), len(read))
+	for i := range blocks {
+		requireEqualBlock(t, blocks[i], read[i])
+	}
+
+	{ // empty stream
+		read, err := ReadBlockStream(bytes.NewReader(nil))
+		require.Nil(t, err)
+		require.Equal(t, 0, len(read))
+	}
+
+	{ // the stream ends in the middle of frame
+		_, err := ReadBlockStream(bytes.NewReader(stream[:len(stream)-1]))
+		require.Error(t, err)
+		require.Equal(t, errors.ErrorInvalidBlockBinaryFrame.Code, err.(*errors.Error).Code)
+	}
+}
@@ -3,8 +3,12 @@ package block
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/btcsuite/btcutil/base58"
+	"github.com/stellar/go/keypair"
 
 	"boscoin.io/sebak/lib/ballot"
 	"boscoin.io/sebak/lib/common"
@@ -18,6 +22,10 @@ import (
 const (
 	maxBlockHeightStringLength int    = 20
 	EventBlockPrefix           string = "bk-saved"
+	// EventBlockForkDetected is triggered with the
+	// `errors.ErrorBlockForkDetected` when the different block is saved at
+	// the existing height.
+	EventBlockForkDetected string = "bk-fork"
 )
 
 type Block struct {
@@ -41,11 +49,65 @@ func (bck Block) String() string {
 	return string(encoded)
 }
 
+// GenesisConfig is the configuration of the genesis block; the genesis
+// account and it's initial balance. `Confirmed` is the confirmed time of the
+// genesis block in ISO8601; if empty, `common.GenesisBlockConfirmedTime` is
+// used. The private networks can set their own time. `MaxSupply` is the max
+// supply of network; if 0, `common.MaxSupply` is used.
+type GenesisConfig struct {
+	Address    string
+	Balance    common.Amount
+	SequenceID uint64
+	Confirmed  string
+	MaxSupply  common.Amount
+	// AccountStorageBudget is the storage budget of account; by default,
+	// `common.AccountStorageBudget`.
+	AccountStorageBudget int
+	// FeePolicy is the fees of the operations of network; by default,
+	// `common.BaseFee` for each operation.
+	FeePolicy transaction.OperationFeePolicy
+	// Validators is the addresses of the initial validators of network; it is
+	// recorded as the validator set at the genesis block, see
+	// `GetValidatorSetAtHeight()`.
+	Validators []string
+}
+
+func (config GenesisConfig) maxSupply() common.Amount {
+	if config.MaxSupply == 0 {
+		return common.MaxSupply
+	}
+
+	return config.MaxSupply
+}
+
+func (config GenesisConfig) accountStorageBudget() int {
+	if config.AccountStorageBudget == 0 {
+		return common.AccountStorageBudget
+	}
+
+	return config.AccountStorageBudget
+}
+
+// NewGenesisConfigFromAccount makes `GenesisConfig` from the genesis account.
+func NewGenesisConfigFromAccount(account BlockAccount) GenesisConfig {
+	return GenesisConfig{
+		Address:    account.Address,
+		Balance:    account.Balance,
+		SequenceID: account.SequenceID,
+	}
+}
+
 // MakeGenesisBlock makes genesis block from genesis account and transaction.
 // The genesis block has different part from the other Block
 // * `Block.Proposer` is empty
 // * `Block.Round` is empty
-// * `Block.Confirmed` is `common.GenesisBlockConfirmedTime`
+// * `Block.Confirmed` and `Block.Header.Timestamp` are
+//   `common.GenesisBlockConfirmedTime`, so the hash of genesis block is
+//   decided only by the genesis account; see `GenesisBlockHash()`. The
+//   different time can be set by `GenesisConfig.Confirmed`.
+// * the network parameters, the max supply, the storage budget of account,
+//   the fee policy and the initial validator set are stored with the genesis
+//   block; see `GenesisConfig`.
 // * has only one `Transaction`
 //
 // This Transaction is different from other normal Transaction;
@@ -54,7 +116,30 @@ func (bck Block) String() string {
 // * `Transaction.B.Fee` is 0
 // * `OperationCreateAccount.Amount` is same with balance of genesis account
 // * `OperationCreateAccount.Target` is genesis account
-func MakeGenesisBlock(st *storage.LevelDBBackend, account BlockAccount, networdID []byte) (blk Block, err error) {
+//
+// The balance of genesis account must not be over `common.MaxSupply` and the
+// `common.MaxSupply` is stored with the genesis block; the other max supply
+// can be set by `GenesisConfig.MaxSupply`.
+//
+// The genesis transaction is signed by `kp`, so `kp` must be the keypair of
+// the genesis account.
+func MakeGenesisBlock(st *storage.LevelDBBackend, account BlockAccount, networdID []byte, kp *keypair.Full) (blk Block, err error) {
+	return MakeGenesisBlockFromConfig(st, NewGenesisConfigFromAccount(account), networdID, kp)
+}
+
+// MakeGenesisBlockFromConfig makes and stores the genesis block from
+// `GenesisConfig`. For details, see `MakeGenesisBlock()`.
+func MakeGenesisBlockFromConfig(st *storage.LevelDBBackend, config GenesisConfig, networdID []byte, kp *keypair.Full) (blk Block, err error) {
+	if kp == nil {
+		err = errors.ErrorGenesisKeypairMismatch.Clone().SetData("address", config.Address)
+		return
+	} else if kp.Address() != config.Address {
+		err = errors.ErrorGenesisKeypairMismatch.Clone().
+			SetData("address", config.Address).
+			SetData("keypair", kp.Address())
+		return
+	}
+
 	var exists bool
 	if exists, err = ExistsBlockByHeight(st, 1); exists || err != nil {
 		if exists {
@@ -64,8 +149,72 @@ func MakeGenesisBlock(st *storage.LevelDBBackend, account BlockAccount, networdI
 		return
 	}
 
+	var tx transaction.Transaction
+	if blk, tx, err = newGenesisBlock(config); err != nil {
+		return
+	}
+	if err = tx.Finalize(kp, networdID); err != nil {
+		return
+	}
+
+	if err = SaveMaxSupply(st, config.maxSupply()); err != nil {
+		return
+	}
+	if err = SaveAccountStorageBudget(st, config.accountStorageBudget()); err != nil {
+		return
+	}
+	if err = SaveFeePolicy(st, config.FeePolicy); err != nil {
+		return
+	}
+	if len(config.Validators) > 0 {
+		if err = SaveValidatorSet(st, blk.Height, config.Validators); err != nil {
+			return
+		}
+	}
+	if err = blk.Save(st); err != nil {
+		return
+	}
+
+	raw, _ := tx.Serialize()
+	bt := NewBlockTransactionFromTransaction(blk.Hash, blk.Height, blk.Confirmed, tx, raw)
+	if err = bt.Save(st); err != nil {
+		return
+	}
+
+	for i := range tx.B.Operations {
+		if err = AppendOpLog(st, NewOpLogEntry(blk.Height, tx, i)); err != nil {
+			return
+		}
+	}
+
+	err = NewTransactionJournal(st).Append(blk.Height, 0, tx)
+
+	return
+}
+
+// GenesisBlockHash returns the hash of the genesis block, which is made by
+// `MakeGenesisBlockFromConfig()`, without storage. The signature of the
+// genesis transaction is not the part of the hash, so the keypair is not
+// needed.
+func GenesisBlockHash(config GenesisConfig, networkID []byte) (string, error) {
+	blk, _, err := newGenesisBlock(config)
+	if err != nil {
+		return "", err
+	}
+
+	return blk.Hash, nil
+}
+
+// newGenesisBlock makes the genesis block and it's transaction in memory; the
+// transaction is not signed.
+func newGenesisBlock(config GenesisConfig) (blk Block, tx transaction.Transaction, err error) {
+	if config.Balance > config.maxSupply() {
+		err = errors.ErrorOverMaxSupply
+		return
+	}
+
 	// create create-account transaction.
-	opb := transaction.NewOperationBodyCreateAccount(account.Address, account.Balance, "")
+	opb := transaction.NewOperationBodyCreateAccount(config.Address, config.Balance, "")
 	op := transaction.Operation{
 		H: transaction.OperationHeader{
 			Type: transaction.OperationCreateAccount,
@@ -74,58 +223,89 @@ func MakeGenesisBlock(st *storage.LevelDBBackend, account BlockAccount, networdI
 	}
 
 	txBody := transaction.TransactionBody{
-		Source:     account.Address,
+		Source:     config.Address,
 		Fee:        0,
-		SequenceID: account.SequenceID,
+		SequenceID: config.SequenceID,
 		Operations: []transaction.Operation{op},
 	}
 
-	tx := transaction.Transaction{
+	confirmed := config.Confirmed
+	if len(confirmed) < 1 {
+		confirmed = common.GenesisBlockConfirmedTime
+	}
+
+	var timestamp time.Time
+	if timestamp, err = common.ParseISO8601(confirmed); err != nil {
+		err = errors.ErrorInvalidGenesisConfirmedTime.Clone().SetData("confirmed", confirmed)
+		return
+	}
+
+	tx = transaction.Transaction{
 		T: "transaction",
 		H: transaction.TransactionHeader{
-			Created: common.GenesisBlockConfirmedTime,
+			Created: confirmed,
 			Hash:    txBody.MakeHashString(),
 		},
 		B: txBody,
 	}
-	tx.Sign(kp, []byte(networdID))
-
 	transactions := []string{tx.GetHash()}
 
-	blk = NewBlock(
-		"",
-		round.Round{}, // empty round
-		transactions,
-		common.GenesisBlockConfirmedTime,
-	)
-	if err = blk.Save(st); err != nil {
-		return
-	}
+	header := NewBlockHeader(round.Round{}, uint64(len(transactions)), getTransactionRoot(CurrentBlockVersion, transactions))
+	header.Version = CurrentBlockVersion
+	header.Timestamp = timestamp
 
-	raw, _ := tx.Serialize()
-	bt := NewBlockTransactionFromTransaction(blk.Hash, blk.Height, blk.Confirmed, tx, raw)
-	if err = bt.Save(st); err != nil {
-		return
-	}
+	blk = newBlockWithHeader(*header, "", round.Round{}, transactions, confirmed)
 
 	return
 }
 
+// NewBlock makes the block of `CurrentBlockVersion`.
 func NewBlock(proposer string, round round.Round, transactions []string, confirmed string) Block {
+	return NewBlockWithVersion(CurrentBlockVersion, proposer, round, transactions, confirmed)
+}
+
+// NewBlockWithVersion makes the block of the version; `TransactionsRoot` is
+// made by the version.
+func NewBlockWithVersion(version uint32, proposer string, round round.Round, transactions []string, confirmed string) Block {
+	header := NewBlockHeader(round, uint64(len(transactions)), getTransactionRoot(version, transactions))
+	header.Version = version
+
+	return newBlockWithHeader(*header, proposer, round, transactions, confirmed)
+}
+
+func newBlockWithHeader(header Header, proposer string, round round.Round, transactions []string, confirmed string) Block {
 	b := &Block{
-		Header:       *NewBlockHeader(round, uint64(len(transactions)), getTransactionRoot(transactions)),
+		Header:       header,
 		Transactions: transactions,
 		Proposer:     proposer,
 		Round:        round,
 		Confirmed:    confirmed,
 	}
 
-	b.Hash = base58.Encode(common.MustMakeObjectHash(b))
+	b.Hash = b.MakeHashString()
 	return *b
 }
 
-func NewBlockFromBallot(b ballot.Ballot) Block {
-	return NewBlock(
+// MakeHashString makes the hash of block with the hash algorithm of the
+// header. `Hash` is not included. The other hash algorithm than
+// `common.HashAlgoArgon2` is hashed together with the block.
+func (b Block) MakeHashString() string {
+	b.Hash = ""
+	if b.Header.HashAlgo == common.HashAlgoArgon2 {
+		return base58.Encode(common.MustMakeObjectHashWithAlgo(common.HashAlgoArgon2, &b))
+	}
+
+	return base58.Encode(common.MustMakeObjectHashWithAlgo(
+		b.Header.HashAlgo,
+		[]interface{}{uint(b.Header.HashAlgo), &b},
+	))
+}
+
+// NewBlockFromBallot makes the block of the version from the ballot; the
+// version must be the version of network by `GetNetworkBlockVersion()`.
+func NewBlockFromBallot(b ballot.Ballot, version uint32) Block {
+	return NewBlockWithVersion(
+		version,
 		b.Proposer(),
 		b.Round(),
 		b.Transactions(),
@@ -133,8 +313,24 @@ func NewBlockFromBallot(b ballot.Ballot) Block {
 	)
 }
 
-func getTransactionRoot(txs []string) string {
-	return common.MustMakeObjectHashString(txs) // TODO make root
+func getTransactionRoot(version uint32, txs []string) string {
+	if version == BlockVersionLegacy {
+		return common.MustMakeObjectHashString(txs)
+	}
+
+	return MerkleRoot(txs)
+}
+
+// GetNetworkBlockVersion returns the version of the blocks of network, which
+// is the version of the genesis block.
+func GetNetworkBlockVersion(st *storage.LevelDBBackend) (version uint32, err error) {
+	var genesis Block
+	if genesis, err = GetBlockByHeight(st, 1); err != nil {
+		return
+	}
+
+	version = genesis.Version
+	return
 }
 
 func GetBlockKey(hash string) string {
@@ -170,6 +366,10 @@ func (b Block) Save(st *storage.LevelDBBackend) (err error) {
 		return errors.ErrorBlockAlreadyExists
 	}
 
+	if err = b.checkFork(st); err != nil {
+		return
+	}
+
 	if err = st.New(key, b); err != nil {
 		return
 	}
@@ -181,7 +381,138 @@ func (b Block) Save(st *storage.LevelDBBackend) (err error) {
 		return
 	}
 
-	observer.BlockObserver.Trigger(EventBlockPrefix, b)
+	observer.BlockDispatcher.Trigger(EventBlockPrefix, b)
+
+	return
+}
+
+// Remove removes the block and it's index records, which are stored by
+// `Save`. The missing index records are skipped, so the block, which is
+// partly stored, also can be removed. The transactions of the block are not
+// removed.
+func (b Block) Remove(st *storage.LevelDBBackend) (err error) {
+	keys := []string{GetBlockKey(b.Hash)}
+
+	var hash string
+	key := GetBlockKeyPrefixHeight(b.Height)
+	if err = st.Get(key, &hash); err == nil {
+		if hash == b.Hash {
+			keys = append(keys, key)
+		}
+	} else if err != errors.ErrorStorageRecordDoesNotExist {
+		return
+	}
+
+	iterFunc, closeFunc := st.GetIterator(GetBlockKeyPrefixConfirmed(b.Confirmed), nil)
+	for {
+		item, hasNext := iterFunc()
+		if !hasNext {
+			break
+		}
+		if err = json.Unmarshal(item.Value, &hash); err != nil {
+			closeFunc()
+			return
+		}
+		if hash == b.Hash {
+			keys = append(keys, string(item.Key))
+		}
+	}
+	closeFunc()
+
+	return st.Deletes(keys...)
+}
+
+// checkFork checks the different block is already stored at the same height.
+// The fork error has the both hashes.
+func (b Block) checkFork(st *storage.LevelDBBackend) (err error) {
+	key := GetBlockKeyPrefixHeight(b.Height)
+
+	var exists bool
+	if exists, err = st.Has(key); err != nil || !exists {
+		return
+	}
+
+	var hash string
+	if err = st.Get(key, &hash); err != nil {
+		return
+	}
+	if hash == b.Hash {
+		return
+	}
+
+	e := errors.ErrorBlockForkDetected.Clone().
+		SetData("height", b.Height).
+		SetData("existing", hash).
+		SetData("new", b.Hash)
+	observer.BlockDispatcher.Trigger(EventBlockForkDetected, e)
+
+	return e
+}
+
+// Verify checks the header is consistent with the block; `TransactionsRoot`
+// made by the version of block and `TotalTxs` must match with
+// `Transactions`, and `Hash` must match with the block.
+func (b Block) Verify() error {
+	if root := getTransactionRoot(b.Version, b.Transactions); root != b.TransactionsRoot {
+		return errors.ErrorBlockTransactionsRootMismatch.Clone().
+			SetData("expected", root).
+			SetData("actual", b.TransactionsRoot)
+	}
+
+	if totalTxs := b.Round.TotalTxs + uint64(len(b.Transactions)); totalTxs != b.TotalTxs {
+		return errors.ErrorBlockTotalTxsMismatch.Clone().
+			SetData("expected", totalTxs).
+			SetData("actual", b.TotalTxs)
+	}
+
+	if hash := b.MakeHashString(); hash != b.Hash {
+		return errors.ErrorHashDoesNotMatch.Clone().
+			SetData("expected", hash).
+			SetData("actual", b.Hash)
+	}
+
+	return nil
+}
+
+// VerifyPrevBlockHash checks `PrevBlockHash` is the hash of the stored block
+// at `Height - 1`. The genesis block does not have the previous block, so it's
+// `PrevBlockHash` must be empty.
+func (b Block) VerifyPrevBlockHash(st *storage.LevelDBBackend) (err error) {
+	if b.Height <= 1 {
+		if len(b.PrevBlockHash) > 0 {
+			err = errors.ErrorBlockPrevHashMismatch.Clone().
+				SetData("expected", "").
+				SetData("actual", b.PrevBlockHash)
+		}
+		return
+	}
+
+	var prev Block
+	if prev, err = GetBlockByHeight(st, b.Height-1); err != nil {
+		return
+	}
+	if prev.Hash != b.PrevBlockHash {
+		return errors.ErrorBlockPrevHashMismatch.Clone().
+			SetData("height", b.Height).
+			SetData("expected", prev.Hash).
+			SetData("actual", b.PrevBlockHash)
+	}
+
+	return
+}
+
+// VerifyTransactionsPresent checks all the transactions of `Block.Transactions`
+// are stored. If not, `errors.ErrorTransactionNotFound` is returned with the
+// first missing hash.
+func (b Block) VerifyTransactionsPresent(st *storage.LevelDBBackend) (err error) {
+	for _, hash := range b.Transactions {
+		var exists bool
+		if exists, err = ExistsBlockTransaction(st, hash); err != nil {
+			return
+		} else if !exists {
+			return errors.ErrorTransactionNotFound.Clone().SetData("hash", hash)
+		}
+	}
 
 	return
 }
@@ -191,6 +522,51 @@ func GetBlock(st *storage.LevelDBBackend, hash string) (bt Block, err error) {
 	return
 }
 
+// GetBlockWithTransactions returns the block and it's transactions by the
+// order of `Block.Transactions`. If the transaction is missing in the
+// storage, for example pruned, the returned `Transaction` has only the hash.
+func GetBlockWithTransactions(st *storage.LevelDBBackend, hash string) (blk Block, txs []transaction.Transaction, err error) {
+	var exists bool
+	if exists, err = ExistsBlock(st, hash); err != nil {
+		return
+	} else if !exists {
+		err = errors.ErrorBlockNotFound
+		return
+	}
+
+	if blk, err = GetBlock(st, hash); err != nil {
+		return
+	}
+
+	for _, txHash := range blk.Transactions {
+		missing := transaction.Transaction{H: transaction.TransactionHeader{Hash: txHash}}
+
+		if exists, err = ExistsBlockTransaction(st, txHash); err != nil {
+			return
+		} else if !exists {
+			txs = append(txs, missing)
+			continue
+		}
+
+		var bt BlockTransaction
+		if bt, err = GetBlockTransaction(st, txHash); err != nil {
+			return
+		}
+		if len(bt.Message) < 1 {
+			txs = append(txs, missing)
+			continue
+		}
+
+		var tx transaction.Transaction
+		if err = json.Unmarshal(bt.Message, &tx); err != nil {
+			return
+		}
+		txs = append(txs, tx)
+	}
+
+	return
+}
+
 func GetBlockHeader(st *storage.LevelDBBackend, hash string) (bt Header, err error) {
 	err = st.Get(GetBlockKey(hash), &bt)
 	return
@@ -206,6 +582,57 @@ func ExistsBlockByHeight(st *storage.LevelDBBackend, height uint64) (exists bool
 	return
 }
 
+// ExistsBlocksByHeights checks the existence of the blocks of the given
+// heights at once. Instead of checking each height, the range of height keys
+// from the lowest to the highest of `heights` is iterated once, so the gaps
+// of chain can be found in one pass.
+func ExistsBlocksByHeights(st *storage.LevelDBBackend, heights []uint64) (exists map[uint64]bool, err error) {
+	exists = map[uint64]bool{}
+	if len(heights) < 1 {
+		return
+	}
+
+	min, max := heights[0], heights[0]
+	for _, height := range heights {
+		exists[height] = false
+		if height < min {
+			min = height
+		}
+		if height > max {
+			max = height
+		}
+	}
+
+	options := storage.NewDefaultListOptions(false, []byte(GetBlockKeyPrefixHeight(min)), 0)
+	iterFunc, closeFunc := st.GetIterator(common.BlockPrefixHeight, options)
+	defer closeFunc()
+
+	for {
+		item, hasNext := iterFunc()
+		if !hasNext || len(item.Key) < 1 {
+			break
+		}
+
+		var height uint64
+		if height, err = parseBlockKeyPrefixHeight(string(item.Key)); err != nil {
+			return
+		}
+		if height > max {
+			break
+		}
+		if _, found := exists[height]; found {
+			exists[height] = true
+		}
+	}
+
+	return
+}
+
+func parseBlockKeyPrefixHeight(key string) (uint64, error) {
+	s := strings.TrimSuffix(strings.TrimPrefix(key, common.BlockPrefixHeight), "-")
+	return strconv.ParseUint(s, 10, 64)
+}
+
 func LoadBlocksInsideIterator(
 	st *storage.LevelDBBackend,
 	iterFunc func() (storage.IterItem, bool),
@@ -291,6 +718,79 @@ func GetBlockByHeight(st *storage.LevelDBBackend, height uint64) (bt Block, err
 	return GetBlock(st, hash)
 }
 
+// GetBlocksBetween returns the blocks from `fromHash` to `toHash`, both
+// included, ordered by height. The blocks are collected by walking
+// `PrevBlockHash` from `toHash`, so they are the chain of `toHash`. If
+// `fromHash` is not the ancestor of `toHash`, `errors.ErrorBlockNotAncestor`
+// is returned; if the chain is longer than `limit`,
+// `errors.ErrorBlocksRangeTooLarge` is returned.
+func GetBlocksBetween(st *storage.LevelDBBackend, fromHash, toHash string, limit uint64) (blocks []Block, err error) {
+	var from, to Block
+	for _, hash := range []string{fromHash, toHash} {
+		var exists bool
+		if exists, err = ExistsBlock(st, hash); err != nil {
+			return
+		} else if !exists {
+			err = errors.ErrorBlockNotFound.Clone().SetData("hash", hash)
+			return
+		}
+	}
+	if from, err = GetBlock(st, fromHash); err != nil {
+		return
+	}
+	if to, err = GetBlock(st, toHash); err != nil {
+		return
+	}
+
+	if to.Height < from.Height {
+		err = errors.ErrorBlockNotAncestor.Clone().
+			SetData("from", fromHash).
+			SetData("to", toHash)
+		return
+	}
+	if to.Height-from.Height >= limit {
+		err = errors.ErrorBlocksRangeTooLarge.Clone().SetData("limit", limit)
+		return
+	}
+
+	// the height must decrease by one at every step, so the walk can not
+	// be longer than the height difference even if the links are broken.
+	blocks = append(blocks, to)
+	for current := to; current.Height > from.Height; {
+		var exists bool
+		if exists, err = ExistsBlock(st, current.PrevBlockHash); err != nil {
+			return nil, err
+		} else if !exists {
+			return nil, errors.ErrorBlockNotFound.Clone().SetData("hash", current.PrevBlockHash)
+		}
+
+		var prev Block
+		if prev, err = GetBlock(st, current.PrevBlockHash); err != nil {
+			return nil, err
+		}
+		if prev.Height != current.Height-1 {
+			return nil, errors.ErrorBlockPrevHashMismatch.Clone().
+				SetData("height", current.Height).
+				SetData("actual", current.PrevBlockHash)
+		}
+
+		blocks = append(blocks, prev)
+		current = prev
+	}
+
+	if blocks[len(blocks)-1].Hash != fromHash {
+		return nil, errors.ErrorBlockNotAncestor.Clone().
+			SetData("from", fromHash).
+			SetData("to", toHash)
+	}
+
+	for i, j := 0, len(blocks)-1; i < j; i, j = i+1, j-1 {
+		blocks[i], blocks[j] = blocks[j], blocks[i]
+	}
+
+	return
+}
+
 func GetBlockHeaderByHeight(st *storage.LevelDBBackend, height uint64) (bt Header, err error) {
 	var hash string
 	if err = st.Get(GetBlockKeyPrefixHeight(height), &hash); err != nil {
@@ -300,10 +800,24 @@ func GetBlockHeaderByHeight(st *storage.LevelDBBackend, height uint64) (bt Heade
 	return GetBlockHeader(st, hash)
 }
 
+// GetLatestBlock returns the block, which has the highest height among the
+// blocks of the latest confirmed time. The confirmed time is not strictly
+// monotonic with the height, so the blocks, which share the latest confirmed
+// time, are all read and the tie is resolved by the height.
 func GetLatestBlock(st *storage.LevelDBBackend) (b Block, err error) {
-	// get latest blocks
-	iterFunc, closeFunc := GetBlocksByConfirmed(st, storage.NewDefaultListOptions(true, nil, 1))
-	b, _, _ = iterFunc()
+	iterFunc, closeFunc := GetBlocksByConfirmed(st, storage.NewDefaultListOptions(true, nil, 0))
+	for {
+		fetched, hasNext, _ := iterFunc()
+		if !hasNext {
+			break
+		}
+		if b.Hash != "" && fetched.Confirmed != b.Confirmed {
+			break
+		}
+		if b.Hash == "" || fetched.Height > b.Height {
+			b = fetched
+		}
+	}
 	closeFunc()
 
 	if b.Hash == "" {
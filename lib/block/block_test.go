@@ -6,9 +6,12 @@ import (
 	"time"
 
 	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/common/observer"
+	"boscoin.io/sebak/lib/consensus/round"
 	"boscoin.io/sebak/lib/error"
 	"boscoin.io/sebak/lib/storage"
 	"boscoin.io/sebak/lib/transaction"
+	"github.com/btcsuite/btcutil/base58"
 	"github.com/stellar/go/keypair"
 	"github.com/stretchr/testify/require"
 )
@@ -69,6 +72,40 @@ func TestBlockConfirmedOrdering(t *testing.T) {
 	}
 }
 
+// GetLatestBlock returns the block of the highest height, even if the blocks
+// share the latest confirmed time.
+func TestGetLatestBlockSameConfirmed(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	confirmed := common.NowISO8601()
+
+	older := TestMakeNewBlock([]string{})
+	older.Height = 1
+	older.Confirmed = common.FormatISO8601(time.Now().Add(-time.Minute))
+	require.Nil(t, older.Save(st))
+
+	for _, height := range []uint64{3, 300, 2} {
+		bk := TestMakeNewBlock([]string{})
+		bk.Height = height
+		bk.Confirmed = confirmed
+		require.Nil(t, bk.Save(st))
+	}
+
+	latest, err := GetLatestBlock(st)
+	require.Nil(t, err)
+	require.Equal(t, uint64(300), latest.Height)
+	require.Equal(t, confirmed, latest.Confirmed)
+}
+
+func TestGetLatestBlockNotFound(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	_, err := GetLatestBlock(st)
+	require.Equal(t, errors.ErrorBlockNotFound, err)
+}
+
 func TestBlockHeightOrdering(t *testing.T) {
 	st := storage.NewTestStorage()
 
@@ -103,6 +140,119 @@ func TestBlockHeightOrdering(t *testing.T) {
 	}
 }
 
+func TestGetBlocksBetween(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	// chain of 5 blocks and the fork from the height 3
+	var chain []Block
+	var prev Block
+	for i := 0; i < 5; i++ {
+		blk := NewBlock("", round.Round{BlockHeight: prev.Height, BlockHash: prev.Hash}, []string{}, common.NowISO8601())
+		require.Nil(t, blk.Save(st))
+		chain = append(chain, blk)
+		prev = blk
+	}
+	forked := NewBlock("", round.Round{BlockHeight: chain[1].Height, BlockHash: chain[1].Hash}, []string{}, common.NowISO8601())
+	require.Nil(t, st.New(GetBlockKey(forked.Hash), forked))
+
+	{ // whole chain
+		blocks, err := GetBlocksBetween(st, chain[0].Hash, chain[4].Hash, 10)
+		require.Nil(t, err)
+		require.Equal(t, len(chain), len(blocks))
+		for i, blk := range blocks {
+			require.Equal(t, chain[i].Hash, blk.Hash)
+		}
+	}
+
+	{ // same block
+		blocks, err := GetBlocksBetween(st, chain[2].Hash, chain[2].Hash, 10)
+		require.Nil(t, err)
+		require.Equal(t, 1, len(blocks))
+		require.Equal(t, chain[2].Hash, blocks[0].Hash)
+	}
+
+	{ // over the limit
+		_, err := GetBlocksBetween(st, chain[0].Hash, chain[4].Hash, 4)
+		require.NotNil(t, err)
+		require.Equal(t, errors.ErrorBlocksRangeTooLarge.Code, err.(*errors.Error).Code)
+	}
+
+	{ // reversed
+		_, err := GetBlocksBetween(st, chain[3].Hash, chain[1].Hash, 10)
+		require.NotNil(t, err)
+		require.Equal(t, errors.ErrorBlockNotAncestor.Code, err.(*errors.Error).Code)
+	}
+
+	{ // forked block is not the ancestor
+		_, err := GetBlocksBetween(st, forked.Hash, chain[4].Hash, 10)
+		require.NotNil(t, err)
+		require.Equal(t, errors.ErrorBlockNotAncestor.Code, err.(*errors.Error).Code)
+	}
+
+	{ // unknown block
+		_, err := GetBlocksBetween(st, chain[0].Hash, "unknown", 10)
+		require.NotNil(t, err)
+		require.Equal(t, errors.ErrorBlockNotFound.Code, err.(*errors.Error).Code)
+	}
+
+	{ // missing link
+		require.Nil(t, st.Remove(GetBlockKey(chain[2].Hash)))
+
+		_, err := GetBlocksBetween(st, chain[0].Hash, chain[4].Hash, 10)
+		require.NotNil(t, err)
+		require.Equal(t, errors.ErrorBlockNotFound.Code, err.(*errors.Error).Code)
+		require.Equal(t, chain[2].Hash, err.(*errors.Error).Data["hash"])
+	}
+}
+
+func TestExistsBlocksByHeights(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	// chain with holes at 3, 4 and 7
+	for _, height := range []uint64{1, 2, 5, 6, 8, 9, 10, 11} {
+		bk := TestMakeNewBlock([]string{})
+		bk.Height = height
+		require.Nil(t, bk.Save(st))
+	}
+
+	{ // nothing to check
+		exists, err := ExistsBlocksByHeights(st, nil)
+		require.Nil(t, err)
+		require.Equal(t, 0, len(exists))
+	}
+
+	{
+		heights := []uint64{9, 1, 2, 3, 4, 5, 6, 7, 8, 12}
+		exists, err := ExistsBlocksByHeights(st, heights)
+		require.Nil(t, err)
+		require.Equal(t, map[uint64]bool{
+			1: true, 2: true, 3: false, 4: false, 5: true,
+			6: true, 7: false, 8: true, 9: true, 12: false,
+		}, exists)
+
+		// same with `ExistsBlockByHeight`
+		for _, height := range heights {
+			expected, err := ExistsBlockByHeight(st, height)
+			require.Nil(t, err)
+			require.Equal(t, expected, exists[height], "height=%d", height)
+		}
+	}
+
+	{ // only the requested heights are returned
+		exists, err := ExistsBlocksByHeights(st, []uint64{3, 10})
+		require.Nil(t, err)
+		require.Equal(t, map[uint64]bool{3: false, 10: true}, exists)
+	}
+
+	{ // beyond the chain
+		exists, err := ExistsBlocksByHeights(st, []uint64{100, 200})
+		require.Nil(t, err)
+		require.Equal(t, map[uint64]bool{100: false, 200: false}, exists)
+	}
+}
+
 // TestMakeGenesisBlock basically tests MakeGenesisBlock can make genesis block,
 // and further with genesis block, genesis account can be found.
 func TestMakeGenesisBlock(t *testing.T) {
@@ -112,10 +262,10 @@ func TestMakeGenesisBlock(t *testing.T) {
 	kp, _ := keypair.Random()
 	balance := common.Amount(100)
 	account := NewBlockAccount(kp.Address(), balance)
-	err := account.Save(st)
+	err := account.Save(st, nil)
 	require.Nil(t, err)
 
-	bk, err := MakeGenesisBlock(st, *account, networkID)
+	bk, err := MakeGenesisBlock(st, *account, networkID, kp)
 	require.Nil(t, err)
 	require.Equal(t, uint64(1), bk.Height)
 	require.Equal(t, 1, len(bk.Transactions))
@@ -154,7 +304,7 @@ func TestMakeGenesisBlock(t *testing.T) {
 	require.Equal(t, account.Address, bo.Source)
 
 	{
-		opb, err := transaction.UnmarshalOperationBodyJSON(bo.Type, bo.Body)
+		opb, err := transaction.UnmarshalOperationBody(bo.Type, bo.Body)
 		require.Nil(t, err)
 
 		opbp := opb.(transaction.OperationBodyPayable)
@@ -164,6 +314,199 @@ func TestMakeGenesisBlock(t *testing.T) {
 	}
 }
 
+func TestMakeGenesisBlockKeypairMismatch(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	kp, _ := keypair.Random()
+	account := NewBlockAccount(kp.Address(), common.Amount(100))
+	require.Nil(t, account.Save(st, nil))
+
+	{ // other keypair
+		other, _ := keypair.Random()
+		_, err := MakeGenesisBlock(st, *account, networkID, other)
+		require.NotNil(t, err)
+		require.Equal(t, errors.ErrorGenesisKeypairMismatch.Code, err.(*errors.Error).Code)
+		require.Equal(t, account.Address, err.(*errors.Error).Data["address"])
+		require.Equal(t, other.Address(), err.(*errors.Error).Data["keypair"])
+	}
+
+	{ // without keypair
+		_, err := MakeGenesisBlock(st, *account, networkID, nil)
+		require.NotNil(t, err)
+		require.Equal(t, errors.ErrorGenesisKeypairMismatch.Code, err.(*errors.Error).Code)
+	}
+
+	exists, err := ExistsBlockByHeight(st, 1)
+	require.Nil(t, err)
+	require.False(t, exists)
+}
+
+func TestMakeGenesisBlockSignature(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	kp, _ := keypair.Random()
+	account := NewBlockAccount(kp.Address(), common.Amount(100))
+	require.Nil(t, account.Save(st, nil))
+
+	bk, err := MakeGenesisBlock(st, *account, networkID, kp)
+	require.Nil(t, err)
+
+	bt, err := GetBlockTransaction(st, bk.Transactions[0])
+	require.Nil(t, err)
+
+	tx, err := transaction.NewTransactionFromJSON(bt.Message)
+	require.Nil(t, err)
+
+	checker := &transaction.TransactionChecker{NetworkID: networkID, Transaction: tx}
+	require.Nil(t, transaction.CheckTransactionVerifySignature(checker))
+
+	// signed with the other network id
+	checker.NetworkID = []byte("other-network")
+	require.NotNil(t, transaction.CheckTransactionVerifySignature(checker))
+}
+
+// TestGenesisBlockHash checks `GenesisBlockHash()` returns the same hash with
+// the genesis block, which is stored by `MakeGenesisBlock()`.
+func TestGenesisBlockHash(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	kp, _ := keypair.Random()
+	account := NewBlockAccount(kp.Address(), common.Amount(100))
+	require.Nil(t, account.Save(st, nil))
+
+	config := NewGenesisConfigFromAccount(*account)
+	hash, err := GenesisBlockHash(config, networkID)
+	require.Nil(t, err)
+
+	// deterministic
+	again, err := GenesisBlockHash(config, networkID)
+	require.Nil(t, err)
+	require.Equal(t, hash, again)
+
+	bk, err := MakeGenesisBlock(st, *account, networkID, kp)
+	require.Nil(t, err)
+	require.Equal(t, hash, bk.Hash)
+
+	stored, err := GetBlockByHeight(st, 1)
+	require.Nil(t, err)
+	require.Equal(t, hash, stored.Hash)
+
+	{ // different balance makes different hash
+		other := config
+		other.Balance = common.Amount(101)
+		otherHash, err := GenesisBlockHash(other, networkID)
+		require.Nil(t, err)
+		require.NotEqual(t, hash, otherHash)
+	}
+
+	{ // over max supply
+		other := config
+		other.Balance = common.MaxSupply + 1
+		_, err := GenesisBlockHash(other, networkID)
+		require.Equal(t, errors.ErrorOverMaxSupply, err)
+	}
+
+	{ // custom confirmed time makes different hash
+		other := config
+		other.Confirmed = "2020-01-01T00:00:00.000000000Z"
+		otherHash, err := GenesisBlockHash(other, networkID)
+		require.Nil(t, err)
+		require.NotEqual(t, hash, otherHash)
+	}
+
+	{ // invalid confirmed time
+		other := config
+		other.Confirmed = "showme"
+		_, err := GenesisBlockHash(other, networkID)
+		require.NotNil(t, err)
+		e, ok := err.(*errors.Error)
+		require.True(t, ok)
+		require.Equal(t, errors.ErrorInvalidGenesisConfirmedTime.Code, e.Code)
+	}
+}
+
+func TestMakeGenesisBlockConfirmed(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	kp, _ := keypair.Random()
+	account := NewBlockAccount(kp.Address(), common.Amount(100))
+	require.Nil(t, account.Save(st, nil))
+
+	confirmed := "2020-01-01T00:00:00.000000000Z"
+	config := NewGenesisConfigFromAccount(*account)
+	config.Confirmed = confirmed
+
+	bk, err := MakeGenesisBlockFromConfig(st, config, networkID, kp)
+	require.Nil(t, err)
+	require.Equal(t, confirmed, bk.Confirmed)
+	require.Equal(t, confirmed, common.FormatISO8601(bk.Header.Timestamp))
+
+	bt, err := GetBlockTransaction(st, bk.Transactions[0])
+	require.Nil(t, err)
+	require.Equal(t, confirmed, bt.Confirmed)
+}
+
+// legacyHeader is `Header` before `Header.HashAlgo`.
+type legacyHeader struct {
+	Version          uint32
+	PrevBlockHash    string
+	TransactionsRoot string
+	Timestamp        time.Time
+	Height           uint64
+	TotalTxs         uint64
+}
+
+func TestBlockHashAlgo(t *testing.T) {
+	blk := TestMakeNewBlock([]string{"showme"})
+	require.Equal(t, common.DefaultHashAlgo, blk.Header.HashAlgo)
+	require.Equal(t, blk.Hash, blk.MakeHashString())
+
+	{ // the default hash algorithm is not encoded, so the block hash is not changed
+		legacy := struct {
+			Header       legacyHeader
+			Transactions []string
+			Hash         string
+			Confirmed    string
+			Proposer     string
+			Round        round.Round
+		}{
+			Header: legacyHeader{
+				Version:          blk.Header.Version,
+				PrevBlockHash:    blk.Header.PrevBlockHash,
+				TransactionsRoot: blk.Header.TransactionsRoot,
+				Timestamp:        blk.Header.Timestamp,
+				Height:           blk.Header.Height,
+				TotalTxs:         blk.Header.TotalTxs,
+			},
+			Transactions: blk.Transactions,
+			Proposer:     blk.Proposer,
+			Round:        blk.Round,
+			Confirmed:    blk.Confirmed,
+		}
+		require.Equal(t, base58.Encode(common.MustMakeObjectHash(legacy)), blk.Hash)
+	}
+
+	header := blk.Header
+	header.HashAlgo = common.HashAlgoSHA256
+	other := newBlockWithHeader(header, blk.Proposer, blk.Round, blk.Transactions, blk.Confirmed)
+	require.NotEqual(t, blk.Hash, other.Hash)
+	require.Equal(t, other.Hash, other.MakeHashString())
+
+	// the stored block keeps the hash algorithm
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	require.Nil(t, other.Save(st))
+	fetched, err := GetBlock(st, other.Hash)
+	require.Nil(t, err)
+	require.Equal(t, common.HashAlgoSHA256, fetched.Header.HashAlgo)
+	require.Equal(t, other.Hash, fetched.MakeHashString())
+}
+
 func TestMakeGenesisBlockOverride(t *testing.T) {
 	st := storage.NewTestStorage()
 	defer st.Close()
@@ -172,10 +515,10 @@ func TestMakeGenesisBlockOverride(t *testing.T) {
 		kp, _ := keypair.Random()
 		balance := common.Amount(100)
 		account := NewBlockAccount(kp.Address(), balance)
-		err := account.Save(st)
+		err := account.Save(st, nil)
 		require.Nil(t, err)
 
-		bk, err := MakeGenesisBlock(st, *account, networkID)
+		bk, err := MakeGenesisBlock(st, *account, networkID, kp)
 		require.Nil(t, err)
 		require.Equal(t, uint64(1), bk.Height)
 	}
@@ -184,10 +527,10 @@ func TestMakeGenesisBlockOverride(t *testing.T) {
 		kp, _ := keypair.Random()
 		balance := common.Amount(100)
 		account := NewBlockAccount(kp.Address(), balance)
-		err := account.Save(st)
+		err := account.Save(st, nil)
 		require.Nil(t, err)
 
-		_, err = MakeGenesisBlock(st, *account, networkID)
+		_, err = MakeGenesisBlock(st, *account, networkID, kp)
 		require.Equal(t, errors.ErrorBlockAlreadyExists, err)
 	}
 }
@@ -200,10 +543,10 @@ func TestMakeGenesisBlockFindGenesisAccount(t *testing.T) {
 	kp, _ := keypair.Random()
 	balance := common.Amount(100)
 	account := NewBlockAccount(kp.Address(), balance)
-	account.Save(st)
+	account.Save(st, nil)
 
 	{
-		bk, err := MakeGenesisBlock(st, *account, networkID)
+		bk, err := MakeGenesisBlock(st, *account, networkID, kp)
 		require.Nil(t, err)
 		require.Equal(t, uint64(1), bk.Height)
 	}
@@ -214,7 +557,7 @@ func TestMakeGenesisBlockFindGenesisAccount(t *testing.T) {
 		bt, _ := GetBlockTransaction(st, bk.Transactions[0])
 		bo, _ := GetBlockOperation(st, bt.Operations[0])
 
-		opb, err := transaction.UnmarshalOperationBodyJSON(bo.Type, bo.Body)
+		opb, err := transaction.UnmarshalOperationBody(bo.Type, bo.Body)
 		require.Nil(t, err)
 
 		opbp := opb.(transaction.OperationBodyPayable)
@@ -239,3 +582,381 @@ func TestMakeGenesisBlockFindGenesisAccount(t *testing.T) {
 		require.Equal(t, account.SequenceID, genesisAccount.SequenceID)
 	}
 }
+
+func TestGetBlockWithTransactions(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	_, tx0 := transaction.TestMakeTransaction(networkID, 1)
+	_, tx1 := transaction.TestMakeTransaction(networkID, 2)
+
+	blk := TestMakeNewBlock([]string{tx0.GetHash(), tx1.GetHash()})
+	require.Nil(t, blk.Save(st))
+	for _, tx := range []transaction.Transaction{tx0, tx1} {
+		raw, _ := tx.Serialize()
+		bt := NewBlockTransactionFromTransaction(blk.Hash, blk.Height, blk.Confirmed, tx, raw)
+		require.Nil(t, bt.Save(st))
+	}
+
+	{
+		fetched, txs, err := GetBlockWithTransactions(st, blk.Hash)
+		require.Nil(t, err)
+		require.Equal(t, blk.Hash, fetched.Hash)
+		require.Equal(t, 2, len(txs))
+		require.Equal(t, tx0.GetHash(), txs[0].GetHash())
+		require.Equal(t, tx0.B, txs[0].B)
+		require.Equal(t, tx1.GetHash(), txs[1].GetHash())
+		require.Equal(t, tx1.B, txs[1].B)
+	}
+
+	{ // missing transaction
+		_, missing := transaction.TestMakeTransaction(networkID, 1)
+		other := NewBlock(
+			kp.Address(),
+			round.Round{BlockHeight: blk.Height, BlockHash: blk.Hash},
+			[]string{tx0.GetHash(), missing.GetHash()},
+			common.NowISO8601(),
+		)
+		require.Nil(t, other.Save(st))
+
+		_, txs, err := GetBlockWithTransactions(st, other.Hash)
+		require.Nil(t, err)
+		require.Equal(t, 2, len(txs))
+		require.Equal(t, tx0.B, txs[0].B)
+		require.Equal(t, missing.GetHash(), txs[1].GetHash())
+		require.Equal(t, transaction.TransactionBody{}, txs[1].B)
+	}
+
+	{ // unknown block
+		_, _, err := GetBlockWithTransactions(st, "findme")
+		require.Equal(t, errors.ErrorBlockNotFound, err)
+	}
+}
+
+func TestBlockVerifyTransactionsPresent(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	_, tx0 := transaction.TestMakeTransaction(networkID, 1)
+	_, missing := transaction.TestMakeTransaction(networkID, 1)
+
+	raw, _ := tx0.Serialize()
+	blk := TestMakeNewBlock([]string{tx0.GetHash(), missing.GetHash()})
+	bt := NewBlockTransactionFromTransaction(blk.Hash, blk.Height, blk.Confirmed, tx0, raw)
+	require.Nil(t, bt.Save(st))
+
+	err := blk.VerifyTransactionsPresent(st)
+	require.NotNil(t, err)
+	e, ok := err.(*errors.Error)
+	require.True(t, ok)
+	require.Equal(t, errors.ErrorTransactionNotFound.Code, e.Code)
+	require.Equal(t, missing.GetHash(), e.Data["hash"])
+
+	// all present
+	raw, _ = missing.Serialize()
+	bt = NewBlockTransactionFromTransaction(blk.Hash, blk.Height, blk.Confirmed, missing, raw)
+	require.Nil(t, bt.Save(st))
+	require.Nil(t, blk.VerifyTransactionsPresent(st))
+
+	// empty block
+	require.Nil(t, TestMakeNewBlock(nil).VerifyTransactionsPresent(st))
+}
+
+func TestBlockVerify(t *testing.T) {
+	blk := TestMakeNewBlock([]string{"tx-0", "tx-1", "tx-2"})
+	require.Nil(t, blk.Verify())
+	require.Nil(t, TestMakeNewBlock(nil).Verify())
+
+	checkError := func(b Block, expected *errors.Error) {
+		err := b.Verify()
+		require.NotNil(t, err)
+		require.Equal(t, expected.Code, err.(*errors.Error).Code)
+	}
+
+	{ // tampered number of transactions in header
+		tampered := blk
+		tampered.TotalTxs = 2
+		checkError(tampered, errors.ErrorBlockTotalTxsMismatch)
+
+		// the hash is also updated
+		tampered.Hash = tampered.MakeHashString()
+		checkError(tampered, errors.ErrorBlockTotalTxsMismatch)
+	}
+
+	{ // transaction is removed
+		tampered := blk
+		tampered.Transactions = blk.Transactions[:2]
+		checkError(tampered, errors.ErrorBlockTransactionsRootMismatch)
+	}
+
+	{ // tampered transactions root
+		tampered := blk
+		tampered.TransactionsRoot = MerkleRoot([]string{"tx-0"})
+		checkError(tampered, errors.ErrorBlockTransactionsRootMismatch)
+	}
+
+	{ // tampered hash
+		tampered := blk
+		tampered.Proposer = "findme"
+		checkError(tampered, errors.ErrorHashDoesNotMatch)
+	}
+}
+
+// The block of `BlockVersionLegacy`, which was made before the Merkle root,
+// keeps the legacy transactions root, so it is still verified.
+func TestBlockVerifyLegacyVersion(t *testing.T) {
+	txs := []string{"tx-0", "tx-1", "tx-2"}
+	legacy := NewBlockWithVersion(BlockVersionLegacy, "", round.Round{}, txs, common.NowISO8601())
+	require.Equal(t, common.MustMakeObjectHashString(txs), legacy.TransactionsRoot)
+	require.Nil(t, legacy.Verify())
+
+	_, err := legacy.MerkleProof("tx-0")
+	require.Equal(t, errors.ErrorBlockNotMerkleRoot, err)
+
+	blk := NewBlock("", round.Round{}, txs, legacy.Confirmed)
+	require.Equal(t, CurrentBlockVersion, blk.Version)
+	require.Equal(t, MerkleRoot(txs), blk.TransactionsRoot)
+	require.Nil(t, blk.Verify())
+
+	{ // the root of other version
+		tampered := legacy
+		tampered.Version = BlockVersionMerkleRoot
+		err := tampered.Verify()
+		require.NotNil(t, err)
+		require.Equal(t, errors.ErrorBlockTransactionsRootMismatch.Code, err.(*errors.Error).Code)
+	}
+}
+
+// The network keeps the version of it's genesis block.
+func TestGetNetworkBlockVersion(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	_, err := GetNetworkBlockVersion(st)
+	require.Equal(t, errors.ErrorStorageRecordDoesNotExist, err)
+
+	kp, _ := keypair.Random()
+	account := NewBlockAccount(kp.Address(), common.Amount(100))
+	require.Nil(t, account.Save(st, nil))
+	genesis, err := MakeGenesisBlock(st, *account, networkID, kp)
+	require.Nil(t, err)
+	require.Equal(t, CurrentBlockVersion, genesis.Version)
+
+	version, err := GetNetworkBlockVersion(st)
+	require.Nil(t, err)
+	require.Equal(t, CurrentBlockVersion, version)
+
+	{ // network made before the Merkle root
+		st := storage.NewTestStorage()
+		defer st.Close()
+
+		legacy := NewBlockWithVersion(BlockVersionLegacy, "", round.Round{}, []string{"tx-0"}, common.NowISO8601())
+		require.Nil(t, legacy.Save(st))
+
+		version, err := GetNetworkBlockVersion(st)
+		require.Nil(t, err)
+		require.Equal(t, BlockVersionLegacy, version)
+	}
+}
+
+func TestBlockSaveForkDetected(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	blk := TestMakeNewBlock([]string{})
+	blk.Height = 3
+	require.Nil(t, blk.Save(st))
+
+	// same block
+	require.Equal(t, errors.ErrorBlockAlreadyExists, blk.Save(st))
+
+	// different block at the same height
+	forked := TestMakeNewBlock([]string{})
+	forked.Height = blk.Height
+
+	err := forked.Save(st)
+	require.NotNil(t, err)
+	e, ok := err.(*errors.Error)
+	require.True(t, ok)
+	require.Equal(t, errors.ErrorBlockForkDetected.Code, e.Code)
+	require.Equal(t, blk.Hash, e.Data["existing"])
+	require.Equal(t, forked.Hash, e.Data["new"])
+	require.Equal(t, blk.Height, e.Data["height"])
+
+	// the forked block is not stored
+	exists, err := ExistsBlock(st, forked.Hash)
+	require.Nil(t, err)
+	require.False(t, exists)
+
+	saved, err := GetBlockByHeight(st, blk.Height)
+	require.Nil(t, err)
+	require.Equal(t, blk.Hash, saved.Hash)
+}
+
+func TestBlockRemove(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	blk := TestMakeNewBlock([]string{})
+	blk.Height = 3
+	require.Nil(t, blk.Save(st))
+
+	// the other block has the same confirmed time
+	other := TestMakeNewBlock([]string{})
+	other.Height = 4
+	other.Confirmed = blk.Confirmed
+	require.Nil(t, other.Save(st))
+
+	require.Nil(t, blk.Remove(st))
+
+	exists, err := ExistsBlock(st, blk.Hash)
+	require.Nil(t, err)
+	require.False(t, exists)
+
+	_, err = GetBlockByHeight(st, blk.Height)
+	require.Equal(t, errors.ErrorStorageRecordDoesNotExist, err)
+
+	latest, err := GetLatestBlock(st)
+	require.Nil(t, err)
+	require.Equal(t, other.Hash, latest.Hash)
+
+	// the different block can be saved at the removed height
+	replaced := TestMakeNewBlock([]string{})
+	replaced.Height = blk.Height
+	require.Nil(t, replaced.Save(st))
+}
+
+func TestBlockRemovePartlyStored(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	blk := TestMakeNewBlock([]string{})
+	blk.Height = 3
+	require.Nil(t, blk.Save(st))
+
+	// the height index is missing
+	require.Nil(t, st.Remove(GetBlockKeyPrefixHeight(blk.Height)))
+
+	require.Nil(t, blk.Remove(st))
+
+	exists, err := ExistsBlock(st, blk.Hash)
+	require.Nil(t, err)
+	require.False(t, exists)
+}
+
+func TestBlockSaveObserverSync(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	require.False(t, observer.BlockDispatcher.IsAsync())
+
+	var saved []string
+	onSaved := func(args ...interface{}) {
+		saved = append(saved, args[0].(Block).Hash)
+	}
+	observer.BlockObserver.On(EventBlockPrefix, onSaved)
+	defer observer.BlockObserver.Off(EventBlockPrefix, onSaved)
+
+	var expected []string
+	for i := 0; i < 3; i++ {
+		blk := TestMakeNewBlock([]string{})
+		blk.Height = uint64(i + 1)
+		require.Nil(t, blk.Save(st))
+
+		// the event is received before `Save` returns
+		expected = append(expected, blk.Hash)
+		require.Equal(t, expected, saved)
+	}
+}
+
+func TestBlockSaveObserverAsync(t *testing.T) {
+	observer.BlockDispatcher.SetAsync(true)
+	defer observer.BlockDispatcher.SetAsync(false)
+
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	release := make(chan struct{})
+	saved := make(chan string, 10)
+	onSaved := func(args ...interface{}) {
+		<-release
+		saved <- args[0].(Block).Hash
+	}
+	observer.BlockObserver.On(EventBlockPrefix, onSaved)
+	defer observer.BlockObserver.Off(EventBlockPrefix, onSaved)
+
+	var expected []string
+	done := make(chan error)
+	go func() {
+		for i := 0; i < 3; i++ {
+			blk := TestMakeNewBlock([]string{})
+			blk.Height = uint64(i + 1)
+			if err := blk.Save(st); err != nil {
+				done <- err
+				return
+			}
+			expected = append(expected, blk.Hash)
+		}
+		close(done)
+	}()
+
+	// the slow subscriber does not block `Save`
+	select {
+	case err := <-done:
+		require.Nil(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Block.Save is blocked by the slow subscriber")
+	}
+
+	close(release)
+	for _, hash := range expected {
+		select {
+		case h := <-saved:
+			require.Equal(t, hash, h)
+		case <-time.After(time.Second):
+			t.Fatal("block event is not triggered")
+		}
+	}
+}
+
+func TestBlockVerifyPrevBlockHash(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	checkError := func(b Block) {
+		err := b.VerifyPrevBlockHash(st)
+		require.NotNil(t, err)
+		require.Equal(t, errors.ErrorBlockPrevHashMismatch.Code, err.(*errors.Error).Code)
+	}
+
+	genesis := TestMakeNewBlock([]string{})
+	require.Equal(t, uint64(1), genesis.Height)
+	require.Equal(t, "", genesis.PrevBlockHash)
+	require.Nil(t, genesis.VerifyPrevBlockHash(st))
+	require.Nil(t, genesis.Save(st))
+
+	{ // genesis block with previous block hash
+		wrong := genesis
+		wrong.PrevBlockHash = "showme"
+		checkError(wrong)
+	}
+
+	next := NewBlock(
+		kp.Address(),
+		round.Round{BlockHeight: genesis.Height, BlockHash: genesis.Hash},
+		[]string{},
+		common.NowISO8601(),
+	)
+	require.Equal(t, genesis.Hash, next.PrevBlockHash)
+	require.Nil(t, next.VerifyPrevBlockHash(st))
+
+	{ // previous block hash does not match
+		wrong := NewBlock(
+			kp.Address(),
+			round.Round{BlockHeight: genesis.Height, BlockHash: "findme"},
+			[]string{},
+			common.NowISO8601(),
+		)
+		checkError(wrong)
+	}
+}
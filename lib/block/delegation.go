@@ -0,0 +1,116 @@
+package block
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/storage"
+)
+
+// The delegation of the consensus weight from account to validator is kept in
+// `BlockAccount.Delegate` and the storage keeps the index of the delegators
+// by the delegate.
+//
+// models
+//  * 'delegate'
+// 	- 'bad-<delegate>-<delegator>': the address of delegator
+
+func GetBlockAccountDelegationKey(delegate, delegator string) string {
+	return fmt.Sprintf("%s%s-%s", common.BlockAccountDelegationPrefix, delegate, delegator)
+}
+
+func GetBlockAccountDelegationKeyPrefix(delegate string) string {
+	return fmt.Sprintf("%s%s-", common.BlockAccountDelegationPrefix, delegate)
+}
+
+// SetDelegate changes the delegate of account and updates the index of the
+// delegators. The account itself is not saved, so `Save()` must be called
+// after.
+func (b *BlockAccount) SetDelegate(st *storage.LevelDBBackend, delegate string) (err error) {
+	if b.Delegate == delegate {
+		return
+	}
+
+	if len(b.Delegate) > 0 {
+		key := GetBlockAccountDelegationKey(b.Delegate, b.Address)
+		var exists bool
+		if exists, err = st.Has(key); err != nil {
+			return
+		} else if exists {
+			if err = st.Remove(key); err != nil {
+				return
+			}
+		}
+	}
+
+	if len(delegate) > 0 {
+		if err = st.New(GetBlockAccountDelegationKey(delegate, b.Address), b.Address); err != nil {
+			return
+		}
+	}
+
+	b.Delegate = delegate
+
+	return
+}
+
+// GetDelegators returns the addresses of the accounts, which delegate to
+// `delegate`.
+func GetDelegators(st *storage.LevelDBBackend, delegate string) (delegators []string, err error) {
+	iterFunc, closeFunc := st.GetIterator(GetBlockAccountDelegationKeyPrefix(delegate), nil)
+	defer closeFunc()
+
+	for {
+		item, hasNext := iterFunc()
+		if !hasNext {
+			break
+		}
+
+		var delegator string
+		if err = json.Unmarshal(item.Value, &delegator); err != nil {
+			return
+		}
+		delegators = append(delegators, delegator)
+	}
+
+	return
+}
+
+// GetDelegatedWeight returns the consensus weight, which is delegated to
+// `delegate`. The weight is the sum of the balances of the delegating accounts
+// in coins, so splitting the balance into many accounts does not increase the
+// weight.
+func GetDelegatedWeight(st *storage.LevelDBBackend, delegate string) (weight uint64, err error) {
+	var delegators []string
+	if delegators, err = GetDelegators(st, delegate); err != nil {
+		return
+	}
+
+	for _, address := range delegators {
+		var ba *BlockAccount
+		if ba, err = GetBlockAccount(st, address); err != nil {
+			return
+		}
+		weight += uint64(ba.Balance / common.AmountPerCoin)
+	}
+
+	return
+}
+
+// GetStakedWeight returns the consensus weight of the stake of `validator`
+// itself. Like `GetDelegatedWeight`, the weight is the balance of the account
+// of validator in coins; if the account does not exist, it is `0`.
+func GetStakedWeight(st *storage.LevelDBBackend, validator string) (weight uint64, err error) {
+	var exists bool
+	if exists, err = ExistsBlockAccount(st, validator); err != nil || !exists {
+		return
+	}
+
+	var ba *BlockAccount
+	if ba, err = GetBlockAccount(st, validator); err != nil {
+		return
+	}
+
+	return uint64(ba.Balance / common.AmountPerCoin), nil
+}
@@ -0,0 +1,109 @@
+package block
+
+import (
+	"testing"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stretchr/testify/require"
+
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/storage"
+)
+
+func TestBlockAccountSetDelegate(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	kpA, _ := keypair.Random()
+	kpB, _ := keypair.Random()
+
+	var accounts []*BlockAccount
+	for i := 0; i < 3; i++ {
+		kp, _ := keypair.Random()
+		ba := NewBlockAccount(kp.Address(), common.Amount(10*common.AmountPerCoin))
+		require.Nil(t, ba.SetDelegate(st, kpA.Address()))
+		require.Nil(t, ba.Save(st, nil))
+		accounts = append(accounts, ba)
+	}
+
+	weight, err := GetDelegatedWeight(st, kpA.Address())
+	require.Nil(t, err)
+	require.Equal(t, uint64(30), weight)
+
+	// delegate to the other
+	require.Nil(t, accounts[0].SetDelegate(st, kpB.Address()))
+	require.Nil(t, accounts[0].Save(st, nil))
+
+	saved, err := GetBlockAccount(st, accounts[0].Address)
+	require.Nil(t, err)
+	require.Equal(t, kpB.Address(), saved.Delegate)
+
+	weight, err = GetDelegatedWeight(st, kpA.Address())
+	require.Nil(t, err)
+	require.Equal(t, uint64(20), weight)
+
+	delegators, err := GetDelegators(st, kpB.Address())
+	require.Nil(t, err)
+	require.Equal(t, []string{accounts[0].Address}, delegators)
+
+	// same delegate again
+	require.Nil(t, accounts[0].SetDelegate(st, kpB.Address()))
+	weight, err = GetDelegatedWeight(st, kpB.Address())
+	require.Nil(t, err)
+	require.Equal(t, uint64(10), weight)
+}
+
+// The weight is not increased by splitting the balance into many accounts.
+func TestGetDelegatedWeightByBalance(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	kpA, _ := keypair.Random()
+	kpB, _ := keypair.Random()
+
+	kp, _ := keypair.Random()
+	ba := NewBlockAccount(kp.Address(), common.Amount(100*common.AmountPerCoin))
+	require.Nil(t, ba.SetDelegate(st, kpA.Address()))
+	require.Nil(t, ba.Save(st, nil))
+
+	for i := 0; i < 100; i++ {
+		kp, _ := keypair.Random()
+		ba := NewBlockAccount(kp.Address(), common.Amount(common.AmountPerCoin))
+		require.Nil(t, ba.SetDelegate(st, kpB.Address()))
+		require.Nil(t, ba.Save(st, nil))
+	}
+
+	weightA, err := GetDelegatedWeight(st, kpA.Address())
+	require.Nil(t, err)
+	weightB, err := GetDelegatedWeight(st, kpB.Address())
+	require.Nil(t, err)
+	require.Equal(t, weightA, weightB)
+
+	// the balance under one coin has no weight
+	kpC, _ := keypair.Random()
+	small := NewBlockAccount(kp.Address(), common.Amount(common.AmountPerCoin-1))
+	require.Nil(t, small.SetDelegate(st, kpC.Address()))
+	require.Nil(t, small.Save(st, nil))
+
+	weight, err := GetDelegatedWeight(st, kpC.Address())
+	require.Nil(t, err)
+	require.Equal(t, uint64(0), weight)
+}
+
+func TestGetStakedWeight(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	kp, _ := keypair.Random()
+
+	// no account, no stake
+	weight, err := GetStakedWeight(st, kp.Address())
+	require.Nil(t, err)
+	require.Equal(t, uint64(0), weight)
+
+	ba := NewBlockAccount(kp.Address(), common.Amount(10*common.AmountPerCoin+1))
+	require.Nil(t, ba.Save(st, nil))
+	weight, err = GetStakedWeight(st, kp.Address())
+	require.Nil(t, err)
+	require.Equal(t, uint64(10), weight)
+}
@@ -0,0 +1,36 @@
+package block
+
+import (
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/error"
+	"boscoin.io/sebak/lib/storage"
+	"boscoin.io/sebak/lib/transaction"
+)
+
+// SaveFeePolicy stores the fee policy of network. It is stored when the
+// genesis block is created, so the nodes of network charge the same fees.
+func SaveFeePolicy(st *storage.LevelDBBackend, policy transaction.OperationFeePolicy) (err error) {
+	var exists bool
+	if exists, err = st.Has(common.BlockFeePolicyPrefix); err != nil {
+		return
+	}
+
+	if exists {
+		err = st.Set(common.BlockFeePolicyPrefix, policy)
+	} else {
+		err = st.New(common.BlockFeePolicyPrefix, policy)
+	}
+
+	return
+}
+
+// GetFeePolicy returns the stored fee policy. If it is not stored, the empty
+// `transaction.OperationFeePolicy`, which charges `common.BaseFee` for each
+// operation, is returned.
+func GetFeePolicy(st *storage.LevelDBBackend) (policy transaction.OperationFeePolicy, err error) {
+	if err = st.Get(common.BlockFeePolicyPrefix, &policy); err == errors.ErrorStorageRecordDoesNotExist {
+		err = nil
+	}
+
+	return
+}
@@ -0,0 +1,49 @@
+package block
+
+import (
+	"testing"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stretchr/testify/require"
+
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/storage"
+	"boscoin.io/sebak/lib/transaction"
+)
+
+func TestFeePolicyFromGenesis(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	// without genesis block, the base fee
+	policy, err := GetFeePolicy(st)
+	require.Nil(t, err)
+	require.Equal(t, 0, len(policy.Fees))
+	require.Equal(t, 0, len(policy.PerByteFees))
+
+	kp, _ := keypair.Random()
+	account := NewBlockAccount(kp.Address(), common.Amount(1000))
+	require.Nil(t, account.Save(st, nil))
+
+	config := NewGenesisConfigFromAccount(*account)
+	config.FeePolicy = transaction.OperationFeePolicy{
+		Fees: map[transaction.OperationType]common.Amount{
+			transaction.OperationPayment: common.Amount(20000),
+		},
+		PerByteFees: map[transaction.OperationType]common.Amount{
+			transaction.OperationSetAccountData: common.Amount(100),
+		},
+	}
+	_, err = MakeGenesisBlockFromConfig(st, config, networkID, kp)
+	require.Nil(t, err)
+
+	policy, err = GetFeePolicy(st)
+	require.Nil(t, err)
+	require.Equal(t, config.FeePolicy, policy)
+
+	op := transaction.Operation{
+		H: transaction.OperationHeader{Type: transaction.OperationPayment},
+		B: transaction.NewOperationBodyPayment(kp.Address(), common.Amount(1)),
+	}
+	require.Equal(t, common.Amount(20000), policy.FeeFor(op))
+}
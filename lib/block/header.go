@@ -4,17 +4,39 @@ import (
 	"encoding/json"
 	"time"
 
+	"boscoin.io/sebak/lib/common"
 	"boscoin.io/sebak/lib/consensus/round"
 )
 
+const (
+	// BlockVersionLegacy is the version of the block, whose
+	// `TransactionsRoot` is the hash of the transaction hashes.
+	BlockVersionLegacy uint32 = 0
+	// BlockVersionMerkleRoot is the version of the block, whose
+	// `TransactionsRoot` is the Merkle root of the transaction hashes; see
+	// `MerkleRoot()`.
+	BlockVersionMerkleRoot uint32 = 1
+
+	// CurrentBlockVersion is the version of the genesis block of the new
+	// network. The blocks of network have the same version with it's genesis
+	// block, so the network made before `BlockVersionMerkleRoot` keeps
+	// `BlockVersionLegacy`; see `GetNetworkBlockVersion()`.
+	CurrentBlockVersion = BlockVersionMerkleRoot
+)
+
 type Header struct {
 	// TODO rename `Header` to `BlockHeader`
 	Version          uint32    `json:"version"`
 	PrevBlockHash    string    `json:"prev-block-hash"`   // TODO Uint256 type
-	TransactionsRoot string    `json:"transactions-root"` // Merkle root of Txs // TODO Uint256 type
+	TransactionsRoot string    `json:"transactions-root"` // Merkle root of Txs; see `MerkleRoot` // TODO Uint256 type
 	Timestamp        time.Time `json:"timestamp"`
 	Height           uint64    `json:"height"`
 	TotalTxs         uint64    `json:"total-txs"`
+	// HashAlgo is the hash algorithm of the block hash. It is not RLP encoded,
+	// so the hash of the block with the default, `common.HashAlgoArgon2` is
+	// same with the block made before `HashAlgo`; see
+	// `Block.MakeHashString()`.
+	HashAlgo common.HashAlgo `json:"hash-algo,omitempty" rlp:"-"`
 
 	// TODO smart contract fields
 }
@@ -26,6 +48,7 @@ func NewBlockHeader(round round.Round, currentTxs uint64, txRoot string) *Header
 		Height:           round.BlockHeight + 1,
 		TotalTxs:         round.TotalTxs + currentTxs,
 		TransactionsRoot: txRoot,
+		HashAlgo:         common.DefaultHashAlgo,
 	}
 }
 
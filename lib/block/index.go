@@ -0,0 +1,314 @@
+package block
+
+import (
+	"encoding/json"
+
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/error"
+	"boscoin.io/sebak/lib/storage"
+	"boscoin.io/sebak/lib/transaction"
+)
+
+// indexGroup is the index records, which are removed and rebuilt together by
+// `RebuildIndexes`. `rebuild` stores the index records again from the
+// primary records.
+type indexGroup struct {
+	prefixes []string
+	rebuild  func(*storage.LevelDBBackend) error
+}
+
+// indexGroups are all the index records. The later group uses the indexes of
+// the former groups; the transactions are found by the blocks by height, and
+// the created accounts are ordered by the operations.
+var indexGroups = []indexGroup{
+	{
+		prefixes: []string{
+			common.BlockPrefixConfirmed,
+			common.BlockPrefixHeight,
+		},
+		rebuild: rebuildBlockIndexes,
+	},
+	{
+		prefixes: []string{
+			common.BlockTransactionPrefixSource,
+			common.BlockTransactionPrefixConfirmed,
+			common.BlockTransactionPrefixAccount,
+			common.BlockTransactionPrefixBlock,
+			common.BlockTransactionPrefixConfirmedTime,
+			common.BlockTransactionPrefixRecent,
+			common.BlockTransactionPrefixRecentSequence,
+			common.BlockOperationPrefixTxHash,
+			common.BlockOperationPrefixSource,
+			common.BlockOperationPrefixCreatedAccount,
+		},
+		rebuild: rebuildTransactionIndexes,
+	},
+	{
+		prefixes: []string{
+			common.BlockAccountPrefixCreated,
+			common.BlockAccountPrefixCreatedByAddress,
+			common.BlockAccountPrefixLinked,
+			common.BlockAccountDelegationPrefix,
+			common.BlockAccountFilterPrefix,
+		},
+		rebuild: rebuildAccountIndexes,
+	},
+}
+
+// RebuildIndexes regenerates all the index records from the stored blocks,
+// transactions, operations and accounts; see `indexGroups`. Each group of
+// indexes is removed and rebuilt in one storage transaction, so the crash
+// while rebuilding does not leave the node without the indexes. It can be run
+// again and again with the same result. The node must not save the new blocks
+// while rebuilding.
+func RebuildIndexes(st *storage.LevelDBBackend) (err error) {
+	for _, group := range indexGroups {
+		var ts *storage.LevelDBBackend
+		if ts, err = st.OpenTransaction(); err != nil {
+			return
+		}
+
+		for _, prefix := range group.prefixes {
+			if err = deleteIndexes(ts, prefix); err != nil {
+				ts.Discard()
+				return
+			}
+		}
+		if err = group.rebuild(ts); err != nil {
+			ts.Discard()
+			return
+		}
+
+		if err = ts.Commit(); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// deleteIndexes removes all the records under `prefix`.
+func deleteIndexes(st *storage.LevelDBBackend, prefix string) (err error) {
+	var keys []string
+	iterFunc, closeFunc := st.GetIterator(prefix, nil)
+	for {
+		item, hasNext := iterFunc()
+		if !hasNext {
+			break
+		}
+
+		keys = append(keys, string(item.Key))
+	}
+	closeFunc()
+
+	return st.Deletes(keys...)
+}
+
+// rebuildBlockIndexes stores the index records of the blocks like
+// `Block.Save`.
+func rebuildBlockIndexes(st *storage.LevelDBBackend) (err error) {
+	var blocks []Block
+	iterFunc, closeFunc := st.GetIterator(common.BlockPrefixHash, nil)
+	for {
+		item, hasNext := iterFunc()
+		if !hasNext {
+			break
+		}
+
+		var blk Block
+		if err = json.Unmarshal(item.Value, &blk); err != nil {
+			closeFunc()
+			return
+		}
+		blocks = append(blocks, blk)
+	}
+	closeFunc()
+
+	for _, blk := range blocks {
+		if err = blk.checkFork(st); err != nil {
+			return
+		}
+		if err = st.New(blk.NewBlockKeyConfirmed(), blk.Hash); err != nil {
+			return
+		}
+		if err = st.New(GetBlockKeyPrefixHeight(blk.Height), blk.Hash); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// walkBlockTransactions calls `f` with the stored transactions of the blocks
+// by height, in the order of the transactions in block. The transactions,
+// which are not stored, are skipped.
+func walkBlockTransactions(st *storage.LevelDBBackend, f func(Block, BlockTransaction) error) (err error) {
+	var hashes []string
+	iterFunc, closeFunc := st.GetIterator(common.BlockPrefixHeight, nil)
+	for {
+		item, hasNext := iterFunc()
+		if !hasNext {
+			break
+		}
+
+		var hash string
+		if err = json.Unmarshal(item.Value, &hash); err != nil {
+			closeFunc()
+			return
+		}
+		hashes = append(hashes, hash)
+	}
+	closeFunc()
+
+	for _, hash := range hashes {
+		var blk Block
+		if blk, err = GetBlock(st, hash); err != nil {
+			return
+		}
+
+		for _, txHash := range blk.Transactions {
+			var bt BlockTransaction
+			if bt, err = GetBlockTransaction(st, txHash); err == errors.ErrorStorageRecordDoesNotExist {
+				err = nil
+				continue
+			} else if err != nil {
+				return
+			}
+
+			if err = f(blk, bt); err != nil {
+				return
+			}
+		}
+	}
+
+	return
+}
+
+// rebuildTransactionIndexes stores the index records of the transactions and
+// their operations like `BlockTransaction.Save`. The operations, which are
+// not stored, are skipped.
+func rebuildTransactionIndexes(st *storage.LevelDBBackend) (err error) {
+	return walkBlockTransactions(st, func(blk Block, bt BlockTransaction) (err error) {
+		bt.blockHeight = blk.Height
+
+		var targets []string
+		for i, hash := range bt.Operations {
+			var bo BlockOperation
+			if bo, err = GetBlockOperation(st, hash); err == errors.ErrorStorageRecordDoesNotExist {
+				err = nil
+				continue
+			} else if err != nil {
+				return
+			}
+
+			bo.blockHeight = blk.Height
+			bo.opIndex = uint64(i)
+			bo.transaction.B.SequenceID = bt.SequenceID
+			if err = bo.saveIndexes(st); err != nil {
+				return
+			}
+
+			var body transaction.OperationBody
+			if body, err = transaction.UnmarshalOperationBody(bo.Type, bo.Body); err != nil {
+				return
+			}
+			if pop, ok := body.(transaction.OperationBodyPayable); ok {
+				targets = append(targets, pop.TargetAddress())
+			}
+		}
+
+		return bt.saveIndexes(st, targets)
+	})
+}
+
+// rebuildAccountIndexes stores the index records of the accounts like
+// `BlockAccount.Save` and `BlockAccount.SetDelegate`. The accounts are
+// ordered in the 'created' index by the create-account operations of the
+// blocks, and the accounts, which are not created by the operation, follow.
+// The stored `AccountFilter` is removed, so it is rebuilt from the accounts
+// at the next start.
+func rebuildAccountIndexes(st *storage.LevelDBBackend) (err error) {
+	created := map[string]bool{}
+	addCreated := func(address string) (err error) {
+		if created[address] {
+			return
+		}
+		created[address] = true
+
+		createdKey := GetBlockAccountCreatedKey(common.GetUniqueIDFromUUID())
+		if err = st.New(createdKey, address); err != nil {
+			return
+		}
+
+		return st.New(GetBlockAccountCreatedByAddressKey(address), createdKey)
+	}
+
+	err = walkBlockTransactions(st, func(blk Block, bt BlockTransaction) (err error) {
+		for _, hash := range bt.Operations {
+			var bo BlockOperation
+			if bo, err = GetBlockOperation(st, hash); err == errors.ErrorStorageRecordDoesNotExist {
+				err = nil
+				continue
+			} else if err != nil {
+				return
+			} else if bo.Type != transaction.OperationCreateAccount {
+				continue
+			}
+
+			var body transaction.OperationBodyCreateAccount
+			if err = json.Unmarshal(bo.Body, &body); err != nil {
+				return
+			}
+
+			var exists bool
+			if exists, err = ExistsBlockAccount(st, body.Target); err != nil {
+				return
+			} else if !exists {
+				continue
+			}
+			if err = addCreated(body.Target); err != nil {
+				return
+			}
+		}
+
+		return
+	})
+	if err != nil {
+		return
+	}
+
+	var accounts []BlockAccount
+	iterFunc, closeFunc := st.GetIterator(common.BlockAccountPrefixAddress, nil)
+	for {
+		item, hasNext := iterFunc()
+		if !hasNext {
+			break
+		}
+
+		var ba BlockAccount
+		if err = common.DecodeJSONValue(item.Value, &ba); err != nil {
+			closeFunc()
+			return
+		}
+		accounts = append(accounts, ba)
+	}
+	closeFunc()
+
+	for _, ba := range accounts {
+		if err = addCreated(ba.Address); err != nil {
+			return
+		}
+		if len(ba.Linked) > 0 {
+			if err = st.New(GetBlockAccountLinkedKey(ba.Linked, ba.Address), ba.Address); err != nil {
+				return
+			}
+		}
+		if len(ba.Delegate) > 0 {
+			if err = st.New(GetBlockAccountDelegationKey(ba.Delegate, ba.Address), ba.Address); err != nil {
+				return
+			}
+		}
+	}
+
+	return
+}
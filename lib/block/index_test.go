@@ -0,0 +1,163 @@
+package block
+
+import (
+	"testing"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stretchr/testify/require"
+
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/error"
+	"boscoin.io/sebak/lib/storage"
+	"boscoin.io/sebak/lib/transaction"
+)
+
+func countIndexes(st *storage.LevelDBBackend, prefix string) (n int) {
+	iterFunc, closeFunc := st.GetIterator(prefix, nil)
+	defer closeFunc()
+	for {
+		if _, hasNext := iterFunc(); !hasNext {
+			break
+		}
+		n++
+	}
+
+	return
+}
+
+func TestRebuildIndexes(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	var blocks []Block
+	for i := 1; i <= 5; i++ {
+		bk := TestMakeNewBlock([]string{})
+		bk.Height = uint64(i)
+		require.Nil(t, bk.Save(st))
+		blocks = append(blocks, bk)
+	}
+
+	// transaction, which creates accounts and pays
+	kpSource, _ := keypair.Random()
+	source := NewBlockAccount(kpSource.Address(), common.BaseReserve.MustMult(10))
+	require.Nil(t, source.Save(st, nil))
+
+	kpA, _ := keypair.Random()
+	kpB, _ := keypair.Random()
+	ops := []transaction.Operation{
+		{
+			H: transaction.OperationHeader{Type: transaction.OperationCreateAccount},
+			B: transaction.NewOperationBodyCreateAccount(kpA.Address(), common.BaseReserve, ""),
+		},
+		{
+			H: transaction.OperationHeader{Type: transaction.OperationCreateAccount},
+			B: transaction.NewOperationBodyCreateAccount(kpB.Address(), common.BaseReserve, ""),
+		},
+		{
+			H: transaction.OperationHeader{Type: transaction.OperationPayment},
+			B: transaction.NewOperationBodyPayment(kpA.Address(), common.Amount(1)),
+		},
+	}
+	tx, err := transaction.NewTransaction(kpSource.Address(), 0, ops...)
+	require.Nil(t, err)
+	tx.Sign(kpSource, networkID)
+
+	bk := TestMakeNewBlock([]string{tx.GetHash()})
+	bk.Height = 6
+	require.Nil(t, bk.Save(st))
+	bt := NewBlockTransactionFromTransaction(bk.Hash, bk.Height, bk.Confirmed, tx, nil)
+	require.Nil(t, bt.Save(st))
+	blocks = append(blocks, bk)
+
+	// the created accounts; B is linked to A and delegates to the source
+	a := NewBlockAccount(kpA.Address(), common.BaseReserve)
+	require.Nil(t, a.Save(st, nil))
+	b := NewBlockAccountLinked(kpB.Address(), common.BaseReserve, a.Address)
+	require.Nil(t, b.SetDelegate(st, source.Address))
+	require.Nil(t, b.Save(st, nil))
+
+	prefixes := []string{}
+	for _, group := range indexGroups {
+		prefixes = append(prefixes, group.prefixes...)
+	}
+	expected := map[string]int{}
+	for _, prefix := range prefixes {
+		expected[prefix] = countIndexes(st, prefix)
+	}
+	expected[common.BlockAccountFilterPrefix] = 0
+
+	// broken indexes
+	require.Nil(t, st.Remove(GetBlockKeyPrefixHeight(3)))
+	_, err = GetBlockByHeight(st, 3)
+	require.Equal(t, errors.ErrorStorageRecordDoesNotExist, err)
+	for _, prefix := range []string{
+		common.BlockTransactionPrefixAccount,
+		common.BlockOperationPrefixCreatedAccount,
+		common.BlockAccountPrefixCreated,
+		common.BlockAccountPrefixLinked,
+		common.BlockAccountDelegationPrefix,
+	} {
+		require.Nil(t, deleteIndexes(st, prefix))
+	}
+	require.Nil(t, SaveAccountFilter(st, NewAccountFilter()))
+
+	for i := 0; i < 2; i++ { // same result by running again
+		require.Nil(t, RebuildIndexes(st))
+
+		for _, prefix := range prefixes {
+			require.Equal(t, expected[prefix], countIndexes(st, prefix), "prefix=%x", prefix)
+		}
+
+		for _, blk := range blocks {
+			fetched, err := GetBlockByHeight(st, blk.Height)
+			require.Nil(t, err)
+			require.Equal(t, blk.Hash, fetched.Hash)
+		}
+
+		latest, err := GetLatestBlock(st)
+		require.Nil(t, err)
+		require.Equal(t, bk.Hash, latest.Hash)
+
+		iterFunc, closeFunc := GetBlockTransactionsByBlock(st, bk.Hash, nil)
+		fetched, hasNext, _ := iterFunc()
+		closeFunc()
+		require.True(t, hasNext)
+		require.Equal(t, bt.Hash, fetched.Hash)
+
+		// the transactions by the target of payment
+		iterFunc, closeFunc = GetBlockTransactionsByAccount(st, a.Address, nil)
+		fetched, hasNext, _ = iterFunc()
+		closeFunc()
+		require.True(t, hasNext)
+		require.Equal(t, bt.Hash, fetched.Hash)
+
+		// the created accounts in the order of the operations
+		accounts, err := GetAccountsCreatedInBlock(st, bk.Height)
+		require.Nil(t, err)
+		require.Equal(t, 2, len(accounts))
+		require.Equal(t, a.Address, accounts[0].Address)
+		require.Equal(t, b.Address, accounts[1].Address)
+
+		// the accounts by created; the accounts created by the operations
+		// come first
+		var addresses []string
+		addressIter, addressClose := GetBlockAccountAddressesByCreated(st, nil)
+		for {
+			address, hasNext, _ := addressIter()
+			if !hasNext {
+				break
+			}
+			addresses = append(addresses, address)
+		}
+		addressClose()
+		require.Equal(t, []string{a.Address, b.Address, source.Address}, addresses)
+
+		linked, err := isBlockAccountLinked(st, a.Address)
+		require.Nil(t, err)
+		require.True(t, linked)
+
+		delegators, err := GetDelegators(st, source.Address)
+		require.Nil(t, err)
+		require.Equal(t, []string{b.Address}, delegators)
+	}
+}
@@ -0,0 +1,95 @@
+package block
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/storage"
+	"boscoin.io/sebak/lib/transaction"
+)
+
+// TransactionJournalEntry is the entry of `TransactionJournal`; the whole
+// transaction with it's position in the block.
+type TransactionJournalEntry struct {
+	Sequence    uint64                  `json:"sequence"`
+	BlockHeight uint64                  `json:"block_height"`
+	Index       int                     `json:"index"`
+	Transaction transaction.Transaction `json:"transaction"`
+}
+
+// TransactionJournal is the append-only log of the stored transactions. It
+// is kept apart from the block and account records, so the account state can
+// be rebuilt or audited from it.
+//
+// The journal should be appended within the same storage transaction, which
+// stores the block, so the journal and the block are committed atomically.
+type TransactionJournal struct {
+	st *storage.LevelDBBackend
+}
+
+func NewTransactionJournal(st *storage.LevelDBBackend) *TransactionJournal {
+	return &TransactionJournal{st: st}
+}
+
+func GetTransactionJournalKey(sequence uint64) string {
+	return fmt.Sprintf("%s%s", common.TransactionJournalPrefix, common.EncodeUint64ToByteSlice(sequence))
+}
+
+// Append appends the `index`th transaction of the block at `blockHeight` to
+// the journal with the next sequence.
+func (j *TransactionJournal) Append(blockHeight uint64, index int, tx transaction.Transaction) (err error) {
+	var last uint64
+	var exists bool
+	if exists, err = j.st.Has(common.TransactionJournalPrefixSequence); err != nil {
+		return
+	} else if exists {
+		if err = j.st.Get(common.TransactionJournalPrefixSequence, &last); err != nil {
+			return
+		}
+	}
+
+	entry := TransactionJournalEntry{
+		Sequence:    last + 1,
+		BlockHeight: blockHeight,
+		Index:       index,
+		Transaction: tx,
+	}
+	if err = j.st.New(GetTransactionJournalKey(entry.Sequence), entry); err != nil {
+		return
+	}
+
+	if exists {
+		err = j.st.Set(common.TransactionJournalPrefixSequence, entry.Sequence)
+	} else {
+		err = j.st.New(common.TransactionJournalPrefixSequence, entry.Sequence)
+	}
+
+	return
+}
+
+// Entries returns the entries of the journal by the appended order. Unlike
+// `GetOpLog()`, the broken entry stops the iteration with the error, because
+// the skipped transaction breaks the replay.
+func (j *TransactionJournal) Entries(options storage.ListOptions) (
+	func() (TransactionJournalEntry, bool, error),
+	func(),
+) {
+	iterFunc, closeFunc := j.st.GetIterator(common.TransactionJournalPrefix, options)
+
+	return (func() (TransactionJournalEntry, bool, error) {
+			item, hasNext := iterFunc()
+			if !hasNext {
+				return TransactionJournalEntry{}, false, nil
+			}
+
+			var entry TransactionJournalEntry
+			if err := json.Unmarshal(item.Value, &entry); err != nil {
+				return TransactionJournalEntry{}, false, err
+			}
+
+			return entry, hasNext, nil
+		}), (func() {
+			closeFunc()
+		})
+}
@@ -0,0 +1,133 @@
+package block
+
+import (
+	"crypto/sha256"
+
+	"github.com/btcsuite/btcutil/base58"
+
+	"boscoin.io/sebak/lib/error"
+)
+
+// The leaves and the inner nodes of the Merkle tree are hashed with the
+// different prefix, so the inner node can not be used as leaf.
+const (
+	merkleLeafPrefix byte = 0x00
+	merkleNodePrefix byte = 0x01
+)
+
+// MerkleProofNode is the sibling in the path from the transaction to the
+// Merkle root.
+type MerkleProofNode struct {
+	Hash string `json:"hash"`
+	// Left is true when the sibling is on the left side.
+	Left bool `json:"left"`
+}
+
+func merkleLeaf(txHash string) []byte {
+	h := sha256.Sum256(append([]byte{merkleLeafPrefix}, txHash...))
+	return h[:]
+}
+
+func merkleNode(left, right []byte) []byte {
+	b := make([]byte, 0, 1+len(left)+len(right))
+	b = append(b, merkleNodePrefix)
+	b = append(b, left...)
+	b = append(b, right...)
+
+	h := sha256.Sum256(b)
+	return h[:]
+}
+
+// merkleLevels returns the all levels of the Merkle tree from the leaves. The
+// odd node of the level goes up to the next level without hashing.
+func merkleLevels(txs []string) [][][]byte {
+	level := make([][]byte, len(txs))
+	for i, tx := range txs {
+		level[i] = merkleLeaf(tx)
+	}
+
+	levels := [][][]byte{level}
+	for len(level) > 1 {
+		var next [][]byte
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+			next = append(next, merkleNode(level[i], level[i+1]))
+		}
+		levels = append(levels, next)
+		level = next
+	}
+
+	return levels
+}
+
+// MerkleRoot returns the base58 encoded SHA-256 Merkle root of the
+// transaction hashes. The root of no transactions is the hash of empty
+// bytes.
+func MerkleRoot(txs []string) string {
+	if len(txs) < 1 {
+		h := sha256.Sum256(nil)
+		return base58.Encode(h[:])
+	}
+
+	levels := merkleLevels(txs)
+	return base58.Encode(levels[len(levels)-1][0])
+}
+
+// MerkleProof returns the siblings in the path from the transaction to
+// `TransactionsRoot`, from the leaf level. The block of
+// `BlockVersionLegacy` does not have the Merkle root, so
+// `errors.ErrorBlockNotMerkleRoot` is returned.
+func (b Block) MerkleProof(txHash string) (proof []MerkleProofNode, err error) {
+	if b.Version == BlockVersionLegacy {
+		err = errors.ErrorBlockNotMerkleRoot
+		return
+	}
+
+	index := -1
+	for i, tx := range b.Transactions {
+		if tx == txHash {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		err = errors.ErrorBlockTransactionDoesNotExists
+		return
+	}
+
+	proof = []MerkleProofNode{}
+	levels := merkleLevels(b.Transactions)
+	for _, level := range levels[:len(levels)-1] {
+		if index%2 == 1 {
+			proof = append(proof, MerkleProofNode{Hash: base58.Encode(level[index-1]), Left: true})
+		} else if index+1 < len(level) {
+			proof = append(proof, MerkleProofNode{Hash: base58.Encode(level[index+1]), Left: false})
+		}
+		index /= 2
+	}
+
+	return
+}
+
+// VerifyMerkleProof checks the transaction is included in the Merkle root
+// with the proof from `Block.MerkleProof`.
+func VerifyMerkleProof(root, txHash string, proof []MerkleProofNode) bool {
+	h := merkleLeaf(txHash)
+	for _, p := range proof {
+		sibling := base58.Decode(p.Hash)
+		if len(sibling) != sha256.Size {
+			return false
+		}
+
+		if p.Left {
+			h = merkleNode(sibling, h)
+		} else {
+			h = merkleNode(h, sibling)
+		}
+	}
+
+	return base58.Encode(h) == root
+}
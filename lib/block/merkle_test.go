@@ -0,0 +1,41 @@
+package block
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"boscoin.io/sebak/lib/error"
+)
+
+func TestMerkleProof(t *testing.T) {
+	for n := 1; n < 8; n++ {
+		var txs []string
+		for i := 0; i < n; i++ {
+			txs = append(txs, fmt.Sprintf("tx-%d", i))
+		}
+
+		blk := TestMakeNewBlock(txs)
+		require.Equal(t, MerkleRoot(txs), blk.TransactionsRoot)
+
+		for _, tx := range txs {
+			proof, err := blk.MerkleProof(tx)
+			require.Nil(t, err)
+			require.True(t, VerifyMerkleProof(blk.TransactionsRoot, tx, proof), "n=%d tx=%s", n, tx)
+
+			// the proof of the other transaction does not verify
+			require.False(t, VerifyMerkleProof(blk.TransactionsRoot, "unknown", proof))
+		}
+	}
+
+	{ // not in block
+		blk := TestMakeNewBlock([]string{"tx-0", "tx-1"})
+		_, err := blk.MerkleProof("tx-2")
+		require.Equal(t, errors.ErrorBlockTransactionDoesNotExists, err)
+	}
+
+	{ // the order of transactions changes the root
+		require.NotEqual(t, MerkleRoot([]string{"tx-0", "tx-1"}), MerkleRoot([]string{"tx-1", "tx-0"}))
+	}
+}
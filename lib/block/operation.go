@@ -32,6 +32,9 @@ type BlockOperation struct {
 	transaction transaction.Transaction
 	isSaved     bool
 	blockHeight uint64
+	// opIndex is the index of the operation in the transaction; it is used
+	// only for `Save` time.
+	opIndex uint64
 }
 
 func NewBlockOperationKey(opHash, txHash string) string {
@@ -80,10 +83,7 @@ func (bo *BlockOperation) Save(st *storage.LevelDBBackend) (err error) {
 	if err = st.New(key, bo); err != nil {
 		return
 	}
-	if err = st.New(bo.NewBlockOperationTxHashKey(), bo.Hash); err != nil {
-		return
-	}
-	if err = st.New(bo.NewBlockOperationSourceKey(), bo.Hash); err != nil {
+	if err = bo.saveIndexes(st); err != nil {
 		return
 	}
 	bo.isSaved = true
@@ -97,6 +97,24 @@ func (bo *BlockOperation) Save(st *storage.LevelDBBackend) (err error) {
 	return nil
 }
 
+// saveIndexes stores the index records of the operation; the operations by
+// transaction, by source and the created accounts.
+func (bo BlockOperation) saveIndexes(st *storage.LevelDBBackend) (err error) {
+	if err = st.New(bo.NewBlockOperationTxHashKey(), bo.Hash); err != nil {
+		return
+	}
+	if err = st.New(bo.NewBlockOperationSourceKey(), bo.Hash); err != nil {
+		return
+	}
+	if bo.Type == transaction.OperationCreateAccount {
+		if err = st.New(bo.NewBlockOperationCreatedAccountKey(), bo.Hash); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
 func (bo BlockOperation) Serialize() (encoded []byte, err error) {
 	encoded, err = common.EncodeJSONValue(bo)
 	return
@@ -118,6 +136,10 @@ func GetBlockOperationKeyPrefixSource(source string) string {
 	return fmt.Sprintf("%s%s-", common.BlockOperationPrefixSource, source)
 }
 
+func GetBlockOperationKeyPrefixCreatedAccount(blockHeight uint64) string {
+	return fmt.Sprintf("%s%s-", common.BlockOperationPrefixCreatedAccount, common.EncodeUint64ToByteSlice(blockHeight))
+}
+
 func (bo BlockOperation) NewBlockOperationTxHashKey() string {
 	return fmt.Sprintf(
 		"%s%s%s%s",
@@ -138,6 +160,20 @@ func (bo BlockOperation) NewBlockOperationSourceKey() string {
 	)
 }
 
+// NewBlockOperationCreatedAccountKey makes the key of the created account
+// index. The key ends with the transaction hash and the operation index, so
+// the accounts, which are created by one transaction, are listed in the order
+// of the operations.
+func (bo BlockOperation) NewBlockOperationCreatedAccountKey() string {
+	return fmt.Sprintf(
+		"%s%s%s-%s",
+		GetBlockOperationKeyPrefixCreatedAccount(bo.blockHeight),
+		common.EncodeUint64ToByteSlice(bo.transaction.B.SequenceID),
+		bo.TxHash,
+		common.EncodeUint64ToByteSlice(bo.opIndex),
+	)
+}
+
 func ExistsBlockOperation(st *storage.LevelDBBackend, hash string) (bool, error) {
 	return st.Has(GetBlockOperationKey(hash))
 }
@@ -197,3 +233,53 @@ func GetBlockOperationsBySource(st *storage.LevelDBBackend, source string, optio
 
 	return LoadBlockOperationsInsideIterator(st, iterFunc, closeFunc)
 }
+
+// CreatedAccount is the account, which is created by the create-account
+// operation.
+type CreatedAccount struct {
+	Address string        `json:"address"`
+	Balance common.Amount `json:"balance"`
+}
+
+// GetAccountsCreatedInBlock returns the accounts, which are created by the
+// create-account operations in the block of the given height. The genesis
+// block has the genesis account.
+func GetAccountsCreatedInBlock(st *storage.LevelDBBackend, blockHeight uint64) (accounts []CreatedAccount, err error) {
+	var exists bool
+	if exists, err = ExistsBlockByHeight(st, blockHeight); err != nil {
+		return
+	} else if !exists {
+		err = errors.ErrorBlockNotFound
+		return
+	}
+
+	// `LoadBlockOperationsInsideIterator` stops silently at the error, so the
+	// index is iterated directly
+	iterFunc, closeFunc := st.GetIterator(GetBlockOperationKeyPrefixCreatedAccount(blockHeight), storage.NewDefaultListOptions(false, nil, 0))
+	defer closeFunc()
+
+	for {
+		item, hasNext := iterFunc()
+		if !hasNext {
+			break
+		}
+
+		var hash string
+		if err = json.Unmarshal(item.Value, &hash); err != nil {
+			return
+		}
+
+		var bo BlockOperation
+		if bo, err = GetBlockOperation(st, hash); err != nil {
+			return
+		}
+
+		var body transaction.OperationBodyCreateAccount
+		if err = json.Unmarshal(bo.Body, &body); err != nil {
+			return
+		}
+		accounts = append(accounts, CreatedAccount{Address: body.Target, Balance: body.Amount})
+	}
+
+	return
+}
@@ -1,9 +1,13 @@
 package block
 
 import (
+	"encoding/json"
 	"testing"
 
+	"github.com/stellar/go/keypair"
+
 	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/consensus/round"
 	"boscoin.io/sebak/lib/error"
 	"boscoin.io/sebak/lib/storage"
 
@@ -128,3 +132,81 @@ func TestBlockOperationSaveByTransacton(t *testing.T) {
 		require.Equal(t, bo.Body, encoded)
 	}
 }
+
+func TestGetAccountsCreatedInBlock(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	kpGenesis, _ := keypair.Random()
+	genesisAccount := NewBlockAccount(kpGenesis.Address(), common.MaxSupply)
+	require.Nil(t, genesisAccount.Save(st, nil))
+
+	genesis, err := MakeGenesisBlock(st, *genesisAccount, networkID, kpGenesis)
+	require.Nil(t, err)
+
+	{ // genesis block has the genesis account
+		accounts, err := GetAccountsCreatedInBlock(st, genesis.Height)
+		require.Nil(t, err)
+		require.Equal(t, []CreatedAccount{{Address: kpGenesis.Address(), Balance: common.MaxSupply}}, accounts)
+	}
+
+	kpA, _ := keypair.Random()
+	kpB, _ := keypair.Random()
+	var ops []transaction.Operation
+	for _, target := range []*keypair.Full{kpA, kpB} {
+		ops = append(ops, transaction.Operation{
+			H: transaction.OperationHeader{Type: transaction.OperationCreateAccount},
+			B: transaction.NewOperationBodyCreateAccount(target.Address(), common.BaseReserve, ""),
+		})
+	}
+	ops = append(ops, transaction.Operation{
+		H: transaction.OperationHeader{Type: transaction.OperationPayment},
+		B: transaction.NewOperationBodyPayment(kpA.Address(), common.Amount(1)),
+	})
+	tx, err := transaction.NewTransaction(kpGenesis.Address(), 0, ops...)
+	require.Nil(t, err)
+	tx.Sign(kpGenesis, networkID)
+
+	blk := NewBlock(
+		kpGenesis.Address(),
+		round.Round{BlockHeight: genesis.Height, BlockHash: genesis.Hash},
+		[]string{tx.GetHash()},
+		common.NowISO8601(),
+	)
+	require.Nil(t, blk.Save(st))
+	raw, _ := tx.Serialize()
+	bt := NewBlockTransactionFromTransaction(blk.Hash, blk.Height, blk.Confirmed, tx, raw)
+	require.Nil(t, bt.Save(st))
+
+	{ // two create-account operations in the order of the operations
+		accounts, err := GetAccountsCreatedInBlock(st, blk.Height)
+		require.Nil(t, err)
+		require.Equal(
+			t,
+			[]CreatedAccount{
+				{Address: kpA.Address(), Balance: common.BaseReserve},
+				{Address: kpB.Address(), Balance: common.BaseReserve},
+			},
+			accounts,
+		)
+	}
+
+	{ // unknown block
+		_, err := GetAccountsCreatedInBlock(st, blk.Height+1)
+		require.Equal(t, errors.ErrorBlockNotFound, err)
+	}
+
+	{ // the indexed operation is missing
+		iterFunc, closeFunc := st.GetIterator(GetBlockOperationKeyPrefixCreatedAccount(blk.Height), nil)
+		item, hasNext := iterFunc()
+		closeFunc()
+		require.True(t, hasNext)
+
+		var hash string
+		require.Nil(t, json.Unmarshal(item.Value, &hash))
+		require.Nil(t, st.Remove(GetBlockOperationKey(hash)))
+
+		_, err := GetAccountsCreatedInBlock(st, blk.Height)
+		require.Equal(t, errors.ErrorStorageRecordDoesNotExist, err)
+	}
+}
@@ -0,0 +1,99 @@
+package block
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/storage"
+	"boscoin.io/sebak/lib/transaction"
+)
+
+// OpLogEntry is the entry of the operation log, the append-only log of every
+// applied operation. Unlike the index by account, the operation log is the
+// global stream ordered by the applied order.
+type OpLogEntry struct {
+	Sequence    uint64                    `json:"sequence"`
+	BlockHeight uint64                    `json:"block_height"`
+	TxHash      string                    `json:"tx_hash"`
+	OpIndex     int                       `json:"op_index"`
+	Type        transaction.OperationType `json:"type"`
+	Accounts    []string                  `json:"accounts"`
+}
+
+// NewOpLogEntry makes the `OpLogEntry` of the `index`th operation of the
+// transaction; the affected accounts are the source of the transaction and
+// the target of the operation.
+func NewOpLogEntry(blockHeight uint64, tx transaction.Transaction, index int) OpLogEntry {
+	op := tx.B.Operations[index]
+
+	accounts := []string{tx.B.Source}
+	if pop, ok := op.B.(transaction.OperationBodyPayable); ok {
+		if target := pop.TargetAddress(); target != tx.B.Source {
+			accounts = append(accounts, target)
+		}
+	}
+
+	return OpLogEntry{
+		BlockHeight: blockHeight,
+		TxHash:      tx.GetHash(),
+		OpIndex:     index,
+		Type:        op.H.Type,
+		Accounts:    accounts,
+	}
+}
+
+func GetOpLogKey(sequence uint64) string {
+	return fmt.Sprintf("%s%s", common.OpLogPrefix, common.EncodeUint64ToByteSlice(sequence))
+}
+
+// AppendOpLog appends the entry to the operation log with the next
+// sequence; `entry.Sequence` is ignored.
+func AppendOpLog(st *storage.LevelDBBackend, entry OpLogEntry) (err error) {
+	var last uint64
+	var exists bool
+	if exists, err = st.Has(common.OpLogPrefixSequence); err != nil {
+		return
+	} else if exists {
+		if err = st.Get(common.OpLogPrefixSequence, &last); err != nil {
+			return
+		}
+	}
+
+	entry.Sequence = last + 1
+	if err = st.New(GetOpLogKey(entry.Sequence), entry); err != nil {
+		return
+	}
+
+	if exists {
+		err = st.Set(common.OpLogPrefixSequence, entry.Sequence)
+	} else {
+		err = st.New(common.OpLogPrefixSequence, entry.Sequence)
+	}
+
+	return
+}
+
+// GetOpLog returns the entries of the operation log by the applied order.
+func GetOpLog(st *storage.LevelDBBackend, options storage.ListOptions) (
+	func() (OpLogEntry, bool, []byte),
+	func(),
+) {
+	iterFunc, closeFunc := st.GetIterator(common.OpLogPrefix, options)
+
+	return (func() (OpLogEntry, bool, []byte) {
+			item, hasNext := iterFunc()
+			if !hasNext {
+				return OpLogEntry{}, false, item.Key
+			}
+
+			var entry OpLogEntry
+			if err := json.Unmarshal(item.Value, &entry); err != nil {
+				return OpLogEntry{}, false, item.Key
+			}
+
+			return entry, hasNext, item.Key
+		}), (func() {
+			closeFunc()
+		})
+}
@@ -0,0 +1,56 @@
+package block
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"boscoin.io/sebak/lib/storage"
+	"boscoin.io/sebak/lib/transaction"
+)
+
+func TestOpLogOrder(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	// the operations are appended by the applied order, not by the block
+	// height or the transaction hash.
+	var applied []OpLogEntry
+	for _, height := range []uint64{3, 2, 2, 5} {
+		_, tx := transaction.TestMakeTransaction(networkID, 2)
+		for i := range tx.B.Operations {
+			entry := NewOpLogEntry(height, tx, i)
+			require.Nil(t, AppendOpLog(st, entry))
+			applied = append(applied, entry)
+		}
+	}
+
+	var entries []OpLogEntry
+	iterFunc, closeFunc := GetOpLog(st, storage.NewDefaultListOptions(false, nil, 0))
+	for {
+		e, hasNext, _ := iterFunc()
+		if !hasNext {
+			break
+		}
+		entries = append(entries, e)
+	}
+	closeFunc()
+
+	require.Equal(t, len(applied), len(entries))
+	for i, e := range entries {
+		require.Equal(t, uint64(i+1), e.Sequence)
+		require.Equal(t, applied[i].BlockHeight, e.BlockHeight)
+		require.Equal(t, applied[i].TxHash, e.TxHash)
+		require.Equal(t, applied[i].OpIndex, e.OpIndex)
+		require.Equal(t, applied[i].Accounts, e.Accounts)
+	}
+
+	{ // reverse with limit
+		iterFunc, closeFunc := GetOpLog(st, storage.NewDefaultListOptions(true, nil, 1))
+		e, hasNext, _ := iterFunc()
+		closeFunc()
+
+		require.True(t, hasNext)
+		require.Equal(t, uint64(len(applied)), e.Sequence)
+	}
+}
@@ -0,0 +1,91 @@
+package block
+
+import (
+	"time"
+
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/error"
+	"boscoin.io/sebak/lib/storage"
+)
+
+// spentAmount is the amount, which the account sent by the transaction
+// confirmed at `Confirmed`.
+type spentAmount struct {
+	Confirmed time.Time
+	Amount    common.Amount
+}
+
+// spentAmounts is the rolling total of the amounts, which the account sent
+// within `common.SpendLimitPeriod`. `Amounts` are in the confirmed order and
+// `Total` is the sum of them.
+type spentAmounts struct {
+	Amounts []spentAmount
+	Total   common.Amount
+}
+
+func getSpentAmountsKey(address string) string {
+	return common.BlockAccountSpentPrefix + address
+}
+
+func getSpentAmounts(st *storage.LevelDBBackend, address string) (spent spentAmounts, err error) {
+	if err = st.Get(getSpentAmountsKey(address), &spent); err == errors.ErrorStorageRecordDoesNotExist {
+		err = nil
+	}
+
+	return
+}
+
+// AddSpentAmount adds the amount, which the account sent by the transaction
+// confirmed at `confirmed`, to the rolling total of account; the fees are not
+// included. The amounts older than `common.SpendLimitPeriod` from `confirmed`
+// are removed from the total.
+func AddSpentAmount(st *storage.LevelDBBackend, address string, confirmed time.Time, amount common.Amount) (err error) {
+	var spent spentAmounts
+	if spent, err = getSpentAmounts(st, address); err != nil {
+		return
+	}
+
+	since := confirmed.Add(-common.SpendLimitPeriod)
+	var expired int
+	for ; expired < len(spent.Amounts) && spent.Amounts[expired].Confirmed.Before(since); expired++ {
+		if spent.Total, err = spent.Total.Sub(spent.Amounts[expired].Amount); err != nil {
+			return
+		}
+	}
+	spent.Amounts = append(spent.Amounts[expired:], spentAmount{Confirmed: confirmed, Amount: amount})
+	if spent.Total, err = spent.Total.Add(amount); err != nil {
+		return
+	}
+
+	var exists bool
+	if exists, err = st.Has(getSpentAmountsKey(address)); err != nil {
+		return
+	} else if exists {
+		return st.Set(getSpentAmountsKey(address), spent)
+	}
+
+	return st.New(getSpentAmountsKey(address), spent)
+}
+
+// GetSpentAmount returns the sum of the amounts, which the account sent by the
+// transactions confirmed at or after `since`; the fees are not included. Only
+// the amounts within `common.SpendLimitPeriod` are kept by `AddSpentAmount`,
+// so `since` must not be older than that.
+func GetSpentAmount(st *storage.LevelDBBackend, address string, since time.Time) (amount common.Amount, err error) {
+	var spent spentAmounts
+	if spent, err = getSpentAmounts(st, address); err != nil {
+		return
+	}
+
+	amount = spent.Total
+	for _, s := range spent.Amounts {
+		if !s.Confirmed.Before(since) {
+			break
+		}
+		if amount, err = amount.Sub(s.Amount); err != nil {
+			return
+		}
+	}
+
+	return
+}
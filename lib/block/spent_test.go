@@ -0,0 +1,48 @@
+package block
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/storage"
+)
+
+func TestSpentAmount(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	address := "showme"
+	now := time.Now()
+
+	spent, err := GetSpentAmount(st, address, now.Add(-common.SpendLimitPeriod))
+	require.Nil(t, err)
+	require.Equal(t, common.Amount(0), spent)
+
+	require.Nil(t, AddSpentAmount(st, address, now.Add(-common.SpendLimitPeriod-time.Hour), common.Amount(100)))
+	require.Nil(t, AddSpentAmount(st, address, now.Add(-2*time.Hour), common.Amount(20)))
+	require.Nil(t, AddSpentAmount(st, address, now.Add(-time.Hour), common.Amount(3)))
+
+	spent, err = GetSpentAmount(st, address, now.Add(-common.SpendLimitPeriod))
+	require.Nil(t, err)
+	require.Equal(t, common.Amount(23), spent)
+
+	spent, err = GetSpentAmount(st, address, now.Add(-90*time.Minute))
+	require.Nil(t, err)
+	require.Equal(t, common.Amount(3), spent)
+
+	// the expired amounts are removed from the rolling total
+	require.Nil(t, AddSpentAmount(st, address, now, common.Amount(4000)))
+	amounts, err := getSpentAmounts(st, address)
+	require.Nil(t, err)
+	require.Equal(t, 3, len(amounts.Amounts))
+	require.Equal(t, common.Amount(4023), amounts.Total)
+
+	require.Nil(t, AddSpentAmount(st, address, now.Add(common.SpendLimitPeriod), common.Amount(50000)))
+	amounts, err = getSpentAmounts(st, address)
+	require.Nil(t, err)
+	require.Equal(t, 2, len(amounts.Amounts))
+	require.Equal(t, common.Amount(54000), amounts.Total)
+}
@@ -0,0 +1,129 @@
+package block
+
+import (
+	"time"
+
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/error"
+	"boscoin.io/sebak/lib/storage"
+)
+
+// BlockAccountStats is the running total of accounts. It is updated by
+// `BlockAccount.Save()`, so the total supply can be known without scanning all
+// the accounts.
+type BlockAccountStats struct {
+	Accounts uint64
+	Supply   common.Amount
+}
+
+func GetBlockAccountStats(st *storage.LevelDBBackend) (s BlockAccountStats, err error) {
+	if err = st.Get(common.BlockAccountPrefixStats, &s); err == errors.ErrorStorageRecordDoesNotExist {
+		err = nil
+	}
+
+	return
+}
+
+// updateBlockAccountStats adds the created or removed accounts and the change
+// of balance to the stats. If the total would go under zero, the stats are
+// not updated and `error` is returned.
+func updateBlockAccountStats(st *storage.LevelDBBackend, accounts int64, previous, balance common.Amount) (err error) {
+	var s BlockAccountStats
+	exists := true
+	if err = st.Get(common.BlockAccountPrefixStats, &s); err == errors.ErrorStorageRecordDoesNotExist {
+		exists = false
+	} else if err != nil {
+		return
+	}
+
+	if accounts < 0 && s.Accounts < uint64(-accounts) {
+		err = errors.ErrorBlockAccountStatsUnderZero
+		return
+	}
+	s.Accounts = uint64(int64(s.Accounts) + accounts)
+
+	if s.Supply, err = s.Supply.Sub(previous); err != nil {
+		err = errors.ErrorBlockAccountStatsUnderZero
+		return
+	}
+	if s.Supply, err = s.Supply.Add(balance); err != nil {
+		return
+	}
+
+	if exists {
+		err = st.Set(common.BlockAccountPrefixStats, s)
+	} else {
+		err = st.New(common.BlockAccountPrefixStats, s)
+	}
+
+	return
+}
+
+// Stats is the summary of network.
+type Stats struct {
+	Height           uint64        `json:"height"`
+	TotalAccounts    uint64        `json:"total-accounts"`
+	TotalSupply      common.Amount `json:"total-supply"`
+	Window           int           `json:"window"`
+	Transactions     uint64        `json:"transactions"`
+	AverageBlockTime float64       `json:"average-block-time"` // seconds
+}
+
+// NetworkStats collects the summary of network from the stored blocks and
+// accounts. `Transactions` and `AverageBlockTime` are calculated from the
+// latest `window` blocks.
+func NetworkStats(st *storage.LevelDBBackend, window int) (s Stats, err error) {
+	if window < 1 {
+		err = errors.ErrorInvalidQueryString
+		return
+	}
+
+	var latest Block
+	if latest, err = GetLatestBlock(st); err != nil {
+		return
+	}
+
+	var accountStats BlockAccountStats
+	if accountStats, err = GetBlockAccountStats(st); err != nil {
+		return
+	}
+
+	s = Stats{
+		Height:        latest.Height,
+		TotalAccounts: accountStats.Accounts,
+		TotalSupply:   accountStats.Supply,
+		Window:        window,
+	}
+
+	var first, last time.Time
+	var count int
+	for i := 0; i < window && uint64(i) < latest.Height; i++ {
+		var b Block
+		if b, err = GetBlockByHeight(st, latest.Height-uint64(i)); err != nil {
+			return
+		}
+		s.Transactions += uint64(len(b.Transactions))
+
+		// the confirmed time of genesis block is fixed by the network, not
+		// the time when it is made
+		if b.Height <= 1 {
+			continue
+		}
+
+		var confirmed time.Time
+		if confirmed, err = common.ParseISO8601(b.Confirmed); err != nil {
+			return
+		}
+		if count == 0 {
+			last = confirmed
+		}
+		first = confirmed
+		count++
+	}
+
+	if count > 1 {
+		s.AverageBlockTime = last.Sub(first).Seconds() / float64(count-1)
+	}
+
+	return
+}
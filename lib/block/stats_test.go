@@ -0,0 +1,76 @@
+package block
+
+import (
+	"testing"
+	"time"
+
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/consensus/round"
+	"boscoin.io/sebak/lib/error"
+	"boscoin.io/sebak/lib/storage"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetworkStatsTotalSupply(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	var accounts []*BlockAccount
+	for i := 0; i < 10; i++ {
+		ba := TestMakeBlockAccount()
+		ba.Balance = common.Amount(uint64(i+1) * 1000)
+		require.Nil(t, ba.Save(st, nil))
+		accounts = append(accounts, ba)
+	}
+
+	// update balances of existing accounts
+	require.Nil(t, accounts[0].Deposit(common.Amount(500)))
+	require.Nil(t, accounts[0].Save(st, nil))
+	require.Nil(t, accounts[1].Withdraw(common.Amount(700)))
+	require.Nil(t, accounts[1].Save(st, nil))
+
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		b := NewBlock(
+			"",
+			round.Round{BlockHeight: uint64(i)},
+			[]string{"tx0", "tx1"}[:i%2+1],
+			common.FormatISO8601(now.Add(time.Duration(i)*time.Second*5)),
+		)
+		require.Nil(t, b.Save(st))
+	}
+
+	s, err := NetworkStats(st, 3)
+	require.Nil(t, err)
+
+	var sum common.Amount
+	for _, ba := range accounts {
+		fetched, err := GetBlockAccount(st, ba.Address)
+		require.Nil(t, err)
+		sum = sum.MustAdd(fetched.Balance)
+	}
+
+	require.Equal(t, sum, s.TotalSupply)
+	require.Equal(t, uint64(len(accounts)), s.TotalAccounts)
+	require.Equal(t, uint64(5), s.Height)
+	require.Equal(t, uint64(1+2+1), s.Transactions)
+	require.Equal(t, float64(5), s.AverageBlockTime)
+}
+
+func TestBlockAccountStatsUnderZero(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	ba := TestMakeBlockAccount()
+	require.Nil(t, ba.Save(st, nil))
+
+	require.Equal(t, errors.ErrorBlockAccountStatsUnderZero, updateBlockAccountStats(st, -2, 0, 0))
+	require.Equal(t, errors.ErrorBlockAccountStatsUnderZero, updateBlockAccountStats(st, 0, ba.Balance+1, 0))
+
+	// the stats are not changed
+	s, err := GetBlockAccountStats(st)
+	require.Nil(t, err)
+	require.Equal(t, uint64(1), s.Accounts)
+	require.Equal(t, ba.Balance, s.Supply)
+}
@@ -0,0 +1,140 @@
+package block
+
+import (
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/error"
+	"boscoin.io/sebak/lib/storage"
+	"boscoin.io/sebak/lib/transaction"
+)
+
+// SaveMaxSupply stores the max supply of network. It is stored when the
+// genesis block is created.
+func SaveMaxSupply(st *storage.LevelDBBackend, maxSupply common.Amount) (err error) {
+	var exists bool
+	if exists, err = st.Has(common.BlockMaxSupplyPrefix); err != nil {
+		return
+	}
+
+	if exists {
+		err = st.Set(common.BlockMaxSupplyPrefix, maxSupply)
+	} else {
+		err = st.New(common.BlockMaxSupplyPrefix, maxSupply)
+	}
+
+	return
+}
+
+// GetMaxSupply returns the stored max supply. If it is not stored,
+// `common.MaxSupply` is returned.
+func GetMaxSupply(st *storage.LevelDBBackend) (maxSupply common.Amount, err error) {
+	var exists bool
+	if exists, err = st.Has(common.BlockMaxSupplyPrefix); err != nil {
+		return
+	} else if !exists {
+		maxSupply = common.MaxSupply
+		return
+	}
+
+	err = st.Get(common.BlockMaxSupplyPrefix, &maxSupply)
+	return
+}
+
+// CheckMaxSupply checks that the total supply does not go over the max
+// supply by `increase`. The operations, which issue new coins like
+// inflation, must be checked by `CheckMaxSupply` before they are applied.
+func CheckMaxSupply(st *storage.LevelDBBackend, increase common.Amount) (err error) {
+	var maxSupply common.Amount
+	if maxSupply, err = GetMaxSupply(st); err != nil {
+		return
+	}
+
+	var stats BlockAccountStats
+	if stats, err = GetBlockAccountStats(st); err != nil {
+		return
+	}
+
+	if stats.Supply > maxSupply || uint64(increase) > uint64(maxSupply-stats.Supply) {
+		err = errors.ErrorOverMaxSupply
+		return
+	}
+
+	return
+}
+
+// VerifySupplyInvariant checks the total supply is conserved after the block
+// is applied. The operations in block move the coins between accounts, the
+// fees are burned and only the operations, which implement
+// `transaction.OperationBodyIssuer`, issue the new coins, so the total supply
+// after the block must be the `previousSupply` minus the fees of the
+// transactions in block plus the issued coins. If not,
+// `errors.ErrorSupplyInvariantViolated` is returned.
+func VerifySupplyInvariant(st *storage.LevelDBBackend, blk Block, previousSupply common.Amount) (err error) {
+	var fees, issued common.Amount
+	for _, hash := range blk.Transactions {
+		var bt BlockTransaction
+		if bt, err = GetBlockTransaction(st, hash); err != nil {
+			return
+		}
+
+		if len(bt.Operations) < 1 {
+			continue
+		}
+
+		var fee common.Amount
+		if fee, err = bt.Fee.MultInt(len(bt.Operations)); err != nil {
+			return
+		}
+		if fees, err = fees.Add(fee); err != nil {
+			return
+		}
+
+		var amount common.Amount
+		if amount, err = getIssuedAmount(st, bt); err != nil {
+			return
+		}
+		if issued, err = issued.Add(amount); err != nil {
+			return
+		}
+	}
+
+	var stats BlockAccountStats
+	if stats, err = GetBlockAccountStats(st); err != nil {
+		return
+	}
+
+	expected, overflow := previousSupply.Add(issued)
+	if overflow != nil || fees > expected || stats.Supply != expected-fees {
+		err = errors.ErrorSupplyInvariantViolated.Clone().
+			SetData("block", blk.Hash).
+			SetData("previous", previousSupply).
+			SetData("fees", fees).
+			SetData("issued", issued).
+			SetData("supply", stats.Supply)
+		return
+	}
+
+	return
+}
+
+// getIssuedAmount returns the coins issued by the stored operations of the
+// transaction; see `transaction.OperationBodyIssuer`.
+func getIssuedAmount(st *storage.LevelDBBackend, bt BlockTransaction) (issued common.Amount, err error) {
+	for _, hash := range bt.Operations {
+		var bo BlockOperation
+		if bo, err = GetBlockOperation(st, hash); err != nil {
+			return
+		}
+
+		var body transaction.OperationBody
+		if body, err = transaction.UnmarshalOperationBody(bo.Type, bo.Body); err != nil {
+			return
+		}
+		if iop, ok := body.(transaction.OperationBodyIssuer); ok {
+			if issued, err = issued.Add(iop.IssuedAmount()); err != nil {
+				return
+			}
+		}
+	}
+
+	return
+}
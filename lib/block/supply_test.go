@@ -0,0 +1,148 @@
+package block
+
+import (
+	"testing"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stretchr/testify/require"
+
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/error"
+	"boscoin.io/sebak/lib/storage"
+	"boscoin.io/sebak/lib/transaction"
+)
+
+func TestMakeGenesisBlockOverMaxSupply(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	kp, _ := keypair.Random()
+	account := NewBlockAccount(kp.Address(), common.Amount(1001))
+	require.Nil(t, account.Save(st, nil))
+
+	config := NewGenesisConfigFromAccount(*account)
+	config.MaxSupply = common.Amount(1000)
+
+	_, err := MakeGenesisBlockFromConfig(st, config, networkID, kp)
+	require.Equal(t, errors.ErrorOverMaxSupply, err)
+
+	exists, err := ExistsBlockByHeight(st, 1)
+	require.Nil(t, err)
+	require.False(t, exists)
+}
+
+func makeGenesisBlockWithMaxSupply(t *testing.T, st *storage.LevelDBBackend, balance, maxSupply common.Amount) *BlockAccount {
+	kp, _ := keypair.Random()
+	account := NewBlockAccount(kp.Address(), balance)
+	require.Nil(t, account.Save(st, nil))
+
+	config := NewGenesisConfigFromAccount(*account)
+	config.MaxSupply = maxSupply
+
+	_, err := MakeGenesisBlockFromConfig(st, config, networkID, kp)
+	require.Nil(t, err)
+
+	return account
+}
+
+func TestCheckMaxSupply(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	makeGenesisBlockWithMaxSupply(t, st, common.Amount(900), common.Amount(1000))
+
+	// the stored max supply is used, not `common.MaxSupply`
+	maxSupply, err := GetMaxSupply(st)
+	require.Nil(t, err)
+	require.Equal(t, common.Amount(1000), maxSupply)
+
+	// just under the max supply
+	require.Nil(t, CheckMaxSupply(st, common.Amount(99)))
+	// just same with the max supply
+	require.Nil(t, CheckMaxSupply(st, common.Amount(100)))
+	// over the max supply
+	require.Equal(t, errors.ErrorOverMaxSupply, CheckMaxSupply(st, common.Amount(101)))
+}
+
+// The inflation, which issues new coins, is rejected when it makes the total
+// supply over the max supply.
+func TestCheckMaxSupplyInflation(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	account := makeGenesisBlockWithMaxSupply(t, st, common.Amount(900), common.Amount(1000))
+
+	inflate := func(amount common.Amount) error {
+		if err := CheckMaxSupply(st, amount); err != nil {
+			return err
+		}
+		if err := account.Deposit(amount); err != nil {
+			return err
+		}
+		return account.Save(st, nil)
+	}
+
+	require.Nil(t, inflate(common.Amount(60)))
+	require.Equal(t, errors.ErrorOverMaxSupply, inflate(common.Amount(41)))
+	require.Nil(t, inflate(common.Amount(40)))
+
+	// the total supply reached the max supply
+	require.Equal(t, errors.ErrorOverMaxSupply, inflate(common.Amount(1)))
+
+	stats, err := GetBlockAccountStats(st)
+	require.Nil(t, err)
+	require.Equal(t, common.Amount(1000), stats.Supply)
+}
+
+func testApplyBlockForSupply(t *testing.T, st *storage.LevelDBBackend, doctored common.Amount) (blk Block, previous common.Amount) {
+	kpSource, _ := keypair.Random()
+	kpTarget, _ := keypair.Random()
+
+	source := NewBlockAccount(kpSource.Address(), common.BaseReserve.MustMult(10))
+	require.Nil(t, source.Save(st, nil))
+	target := NewBlockAccount(kpTarget.Address(), common.BaseReserve)
+	require.Nil(t, target.Save(st, nil))
+
+	stats, err := GetBlockAccountStats(st)
+	require.Nil(t, err)
+	previous = stats.Supply
+
+	tx := transaction.TestMakeTransactionWithKeypair(networkID, 3, kpSource, kpTarget)
+
+	blk = TestMakeNewBlock([]string{tx.GetHash()})
+	require.Nil(t, blk.Save(st))
+
+	raw, _ := tx.Serialize()
+	bt := NewBlockTransactionFromTransaction(blk.Hash, blk.Height, blk.Confirmed, tx, raw)
+	require.Nil(t, bt.Save(st))
+
+	require.Nil(t, source.Withdraw(tx.TotalAmount(true)))
+	require.Nil(t, source.Save(st, nil))
+	require.Nil(t, target.Deposit(tx.TotalAmount(false).MustAdd(doctored)))
+	require.Nil(t, target.Save(st, nil))
+
+	return
+}
+
+func TestVerifySupplyInvariant(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	blk, previous := testApplyBlockForSupply(t, st, 0)
+	require.Nil(t, VerifySupplyInvariant(st, blk, previous))
+}
+
+func TestVerifySupplyInvariantDoctored(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	// the target receives 1 more than the payment
+	blk, previous := testApplyBlockForSupply(t, st, common.Amount(1))
+
+	err := VerifySupplyInvariant(st, blk, previous)
+	require.NotNil(t, err)
+
+	e, ok := err.(*errors.Error)
+	require.True(t, ok)
+	require.Equal(t, errors.ErrorSupplyInvariantViolated.Code, e.Code)
+}
@@ -3,6 +3,7 @@ package block
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"boscoin.io/sebak/lib/common"
 	"boscoin.io/sebak/lib/common/observer"
@@ -86,6 +87,23 @@ func (bt BlockTransaction) NewBlockTransactionKeyConfirmed() string {
 	)
 }
 
+// NewBlockTransactionKeyConfirmedTime makes the key of the index by confirmed
+// time. Unlike `NewBlockTransactionKeyConfirmed`, the confirmed time is
+// encoded in UTC nanoseconds, so the keys can be compared for the time range.
+func (bt BlockTransaction) NewBlockTransactionKeyConfirmedTime() (key string, err error) {
+	var confirmed time.Time
+	if confirmed, err = common.ParseISO8601(bt.Confirmed); err != nil {
+		return
+	}
+
+	key = fmt.Sprintf(
+		"%s%s",
+		GetBlockTransactionKeyPrefixConfirmedTime(confirmed),
+		common.GetUniqueIDFromUUID(),
+	)
+	return
+}
+
 func (bt BlockTransaction) NewBlockTransactionKeyByAccount(accountAddress string) string {
 	return fmt.Sprintf(
 		"%s%s%s%s",
@@ -124,34 +142,25 @@ func (bt *BlockTransaction) Save(st *storage.LevelDBBackend) (err error) {
 	if err = st.New(GetBlockTransactionKey(bt.Hash), bt); err != nil {
 		return
 	}
-	if err = st.New(bt.NewBlockTransactionKeySource(), bt.Hash); err != nil {
-		return
-	}
-	if err = st.New(bt.NewBlockTransactionKeyConfirmed(), bt.Hash); err != nil {
-		return
-	}
-	if err = st.New(bt.NewBlockTransactionKeyByAccount(bt.Source), bt.Hash); err != nil {
-		return
-	}
-	if err = st.New(bt.NewBlockTransactionKeyByBlock(bt.Block), bt.Hash); err != nil {
-		return
-	}
-	for _, op := range bt.transaction.B.Operations {
+
+	var targets []string
+	for i, op := range bt.transaction.B.Operations {
 		var bo BlockOperation
 		bo, err = NewBlockOperationFromOperation(op, bt.transaction, bt.blockHeight)
 		if err != nil {
 			return
 		}
+		bo.opIndex = uint64(i)
 		if err = bo.Save(st); err != nil {
 			return
 		}
 		if pop, ok := op.B.(transaction.OperationBodyPayable); ok {
-			target := pop.TargetAddress()
-			if err = st.New(bt.NewBlockTransactionKeyByAccount(target), bt.Hash); err != nil {
-				return
-			}
+			targets = append(targets, pop.TargetAddress())
 		}
 	}
+	if err = bt.saveIndexes(st, targets); err != nil {
+		return
+	}
 	event := "saved"
 	event += " " + fmt.Sprintf("source-%s", bt.Source)
 	event += " " + fmt.Sprintf("hash-%s", bt.Hash)
@@ -161,6 +170,42 @@ func (bt *BlockTransaction) Save(st *storage.LevelDBBackend) (err error) {
 	return nil
 }
 
+// saveIndexes stores the index records of the transaction; `targets` are the
+// target addresses of the payable operations. The index records of the
+// operations are stored by `BlockOperation`.
+func (bt BlockTransaction) saveIndexes(st *storage.LevelDBBackend, targets []string) (err error) {
+	if err = st.New(bt.NewBlockTransactionKeySource(), bt.Hash); err != nil {
+		return
+	}
+	if err = st.New(bt.NewBlockTransactionKeyConfirmed(), bt.Hash); err != nil {
+		return
+	}
+
+	var confirmedTimeKey string
+	if confirmedTimeKey, err = bt.NewBlockTransactionKeyConfirmedTime(); err != nil {
+		return
+	}
+	if err = st.New(confirmedTimeKey, bt.Hash); err != nil {
+		return
+	}
+	if err = st.New(bt.NewBlockTransactionKeyByAccount(bt.Source), bt.Hash); err != nil {
+		return
+	}
+	if err = st.New(bt.NewBlockTransactionKeyByBlock(bt.Block), bt.Hash); err != nil {
+		return
+	}
+	if err = appendRecentTransaction(st, bt.Hash); err != nil {
+		return
+	}
+	for _, target := range targets {
+		if err = st.New(bt.NewBlockTransactionKeyByAccount(target), bt.Hash); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
 func (bt BlockTransaction) Serialize() (encoded []byte, err error) {
 	encoded, err = common.EncodeJSONValue(bt)
 	return
@@ -183,6 +228,14 @@ func GetBlockTransactionKeyPrefixConfirmed(confirmed string) string {
 	return fmt.Sprintf("%s%s-", common.BlockTransactionPrefixConfirmed, confirmed)
 }
 
+func GetBlockTransactionKeyPrefixConfirmedTime(confirmed time.Time) string {
+	return fmt.Sprintf(
+		"%s%s",
+		common.BlockTransactionPrefixConfirmedTime,
+		common.EncodeUint64ToByteSlice(uint64(confirmed.UnixNano())),
+	)
+}
+
 func GetBlockTransactionKeyPrefixAccount(accountAddress string) string {
 	return fmt.Sprintf("%s%s-", common.BlockTransactionPrefixAccount, accountAddress)
 }
@@ -208,6 +261,42 @@ func ExistsBlockTransaction(st *storage.LevelDBBackend, hash string) (bool, erro
 	return st.Has(GetBlockTransactionKey(hash))
 }
 
+// ConfirmationDepth returns the number of the blocks from the block, which
+// has the transaction to the latest block. The transaction in the latest
+// block has the depth, `1`.
+func ConfirmationDepth(st *storage.LevelDBBackend, txHash string) (depth uint64, err error) {
+	var found bool
+	if found, err = ExistsBlockTransaction(st, txHash); err != nil {
+		return
+	} else if !found {
+		err = errors.ErrorBlockTransactionDoesNotExists
+		return
+	}
+
+	var bt BlockTransaction
+	if bt, err = GetBlockTransaction(st, txHash); err != nil {
+		return
+	}
+
+	var header Header
+	if header, err = GetBlockHeader(st, bt.Block); err != nil {
+		return
+	}
+
+	var latest Block
+	if latest, err = GetLatestBlock(st); err != nil {
+		return
+	}
+
+	if latest.Height < header.Height {
+		err = errors.ErrorBlockNotFound
+		return
+	}
+
+	depth = latest.Height - header.Height + 1
+	return
+}
+
 func LoadBlockTransactionsInsideIterator(
 	st *storage.LevelDBBackend,
 	iterFunc func() (storage.IterItem, bool),
@@ -271,4 +360,150 @@ func GetBlockTransactionsByBlock(st *storage.LevelDBBackend, hash string, option
 	return LoadBlockTransactionsInsideIterator(st, iterFunc, closeFunc)
 }
 
+// GetTransactionsByConfirmedRange returns the transactions confirmed from
+// `start` until `end`; `start` is inclusive and `end` is exclusive. `start`
+// and `end` are ISO8601 time, like `Block.Confirmed`. The cursor of `options`
+// is the key returned by iterator.
+func GetTransactionsByConfirmedRange(st *storage.LevelDBBackend, start, end string, options storage.ListOptions) (
+	func() (BlockTransaction, bool, []byte),
+	func(),
+	error,
+) {
+	startTime, err := common.ParseISO8601(start)
+	if err != nil {
+		return nil, nil, errors.ErrorInvalidTimeRange.Clone().SetData("start", start)
+	}
+	endTime, err := common.ParseISO8601(end)
+	if err != nil {
+		return nil, nil, errors.ErrorInvalidTimeRange.Clone().SetData("end", end)
+	}
+	if endTime.Before(startTime) {
+		return nil, nil, errors.ErrorInvalidTimeRange.Clone().SetData("start", start).SetData("end", end)
+	}
+
+	startKey := GetBlockTransactionKeyPrefixConfirmedTime(startTime)
+	endKey := GetBlockTransactionKeyPrefixConfirmedTime(endTime)
+
+	var reverse bool
+	var cursor []byte
+	var limit uint64
+	if options != nil {
+		reverse = options.Reverse()
+		cursor = options.Cursor()
+		limit = options.Limit()
+	}
+	if cursor == nil && !reverse {
+		cursor = []byte(startKey)
+	}
+
+	iterFunc, closeFunc := st.GetIterator(
+		common.BlockTransactionPrefixConfirmedTime,
+		storage.NewDefaultListOptions(reverse, cursor, 0),
+	)
+
+	var n uint64
+	rangeIterFunc := func() (storage.IterItem, bool) {
+		for {
+			item, hasNext := iterFunc()
+			if !hasNext {
+				return item, false
+			}
+
+			key := string(item.Key)
+			if key < startKey {
+				if reverse {
+					closeFunc()
+					return storage.IterItem{}, false
+				}
+				continue
+			}
+			if key >= endKey {
+				if !reverse {
+					closeFunc()
+					return storage.IterItem{}, false
+				}
+				continue
+			}
+
+			if limit != 0 && n >= limit {
+				closeFunc()
+				return storage.IterItem{}, false
+			}
+
+			n++
+			item.N = n
+			return item, true
+		}
+	}
+
+	iter, closeIter := LoadBlockTransactionsInsideIterator(st, rangeIterFunc, closeFunc)
+	return iter, closeIter, nil
+}
+
 var GetBlockTransactions = GetBlockTransactionsByConfirmed
+
+func GetBlockTransactionKeyRecent(sequence uint64) string {
+	return fmt.Sprintf("%s%s", common.BlockTransactionPrefixRecent, common.EncodeUint64ToByteSlice(sequence))
+}
+
+// appendRecentTransaction appends the transaction to the global index of the
+// transactions by the saved order; like `AppendOpLog`, the index has it's own
+// sequence, so the order does not depend on the time of the nodes.
+func appendRecentTransaction(st *storage.LevelDBBackend, hash string) (err error) {
+	var last uint64
+	var exists bool
+	if exists, err = st.Has(common.BlockTransactionPrefixRecentSequence); err != nil {
+		return
+	} else if exists {
+		if err = st.Get(common.BlockTransactionPrefixRecentSequence, &last); err != nil {
+			return
+		}
+	}
+
+	sequence := last + 1
+	if err = st.New(GetBlockTransactionKeyRecent(sequence), hash); err != nil {
+		return
+	}
+
+	if exists {
+		err = st.Set(common.BlockTransactionPrefixRecentSequence, sequence)
+	} else {
+		err = st.New(common.BlockTransactionPrefixRecentSequence, sequence)
+	}
+
+	return
+}
+
+// GetRecentTransactions returns the `limit` most recently saved transactions
+// of all the accounts, newest first.
+func GetRecentTransactions(st *storage.LevelDBBackend, limit int) (bts []BlockTransaction, err error) {
+	if limit < 1 {
+		return
+	}
+
+	iterFunc, closeFunc := st.GetIterator(
+		common.BlockTransactionPrefixRecent,
+		storage.NewDefaultListOptions(true, nil, uint64(limit)),
+	)
+	defer closeFunc()
+
+	for {
+		item, hasNext := iterFunc()
+		if !hasNext {
+			break
+		}
+
+		var hash string
+		if err = json.Unmarshal(item.Value, &hash); err != nil {
+			return
+		}
+
+		var bt BlockTransaction
+		if bt, err = GetBlockTransaction(st, hash); err != nil {
+			return
+		}
+		bts = append(bts, bt)
+	}
+
+	return
+}
@@ -2,11 +2,13 @@ package block
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stellar/go/keypair"
 	"github.com/stretchr/testify/require"
 
 	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/consensus/round"
 	"boscoin.io/sebak/lib/error"
 	"boscoin.io/sebak/lib/storage"
 	"boscoin.io/sebak/lib/transaction"
@@ -500,3 +502,152 @@ func TestMultipleBlockTransactionsOrderByBlockHeightAndCursor(t *testing.T) {
 		}
 	}
 }
+
+func TestConfirmationDepth(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	kpProposer, _ := keypair.Random()
+	var prev Block
+	saveBlock := func(transactions []string) Block {
+		blk := NewBlock(
+			kpProposer.Address(),
+			round.Round{BlockHeight: prev.Height, BlockHash: prev.Hash},
+			transactions,
+			common.NowISO8601(),
+		)
+		require.Nil(t, blk.Save(st))
+		prev = blk
+		return blk
+	}
+
+	saveBlock(nil)
+
+	_, tx := transaction.TestMakeTransaction(networkID, 1)
+	blk := saveBlock([]string{tx.GetHash()})
+	a, _ := tx.Serialize()
+	bt := NewBlockTransactionFromTransaction(blk.Hash, blk.Height, blk.Confirmed, tx, a)
+	require.Nil(t, bt.Save(st))
+
+	// in the latest block
+	depth, err := ConfirmationDepth(st, tx.GetHash())
+	require.Nil(t, err)
+	require.Equal(t, uint64(1), depth)
+
+	for i := 2; i < 5; i++ {
+		saveBlock(nil)
+
+		depth, err = ConfirmationDepth(st, tx.GetHash())
+		require.Nil(t, err)
+		require.Equal(t, uint64(i), depth)
+	}
+
+	// unknown transaction
+	_, err = ConfirmationDepth(st, "unknown")
+	require.Equal(t, errors.ErrorBlockTransactionDoesNotExists, err)
+}
+
+func TestGetTransactionsByConfirmedRange(t *testing.T) {
+	kp, _ := keypair.Random()
+	st := storage.NewTestStorage()
+
+	base, err := common.ParseISO8601("2018-01-01T00:00:00.000000000Z")
+	require.Nil(t, err)
+	// the confirmed time in the other timezone is ordered by the actual time
+	kst := time.FixedZone("KST", 9*60*60)
+
+	var hashes []string
+	for i := 0; i < 6; i++ {
+		tx := transaction.TestMakeTransactionWithKeypair(networkID, 1, kp)
+		block := TestMakeNewBlock([]string{tx.GetHash()})
+		confirmed := base.Add(time.Duration(i) * time.Second)
+		if i%2 == 1 {
+			confirmed = confirmed.In(kst)
+		}
+
+		a, _ := tx.Serialize()
+		bt := NewBlockTransactionFromTransaction(block.Hash, block.Height, common.FormatISO8601(confirmed), tx, a)
+		require.Nil(t, bt.Save(st))
+		hashes = append(hashes, tx.GetHash())
+	}
+
+	get := func(start, end time.Time, options storage.ListOptions) (fetched []string) {
+		iterFunc, closeFunc, err := GetTransactionsByConfirmedRange(
+			st,
+			common.FormatISO8601(start),
+			common.FormatISO8601(end),
+			options,
+		)
+		require.Nil(t, err)
+		defer closeFunc()
+
+		for {
+			bt, hasNext, _ := iterFunc()
+			if !hasNext {
+				break
+			}
+			fetched = append(fetched, bt.Hash)
+		}
+		return
+	}
+
+	start := base.Add(2 * time.Second)
+	end := base.Add(5 * time.Second)
+
+	require.Equal(t, hashes[2:5], get(start, end, nil))
+	require.Equal(t, hashes[2:5], get(start.In(kst), end.In(kst), nil))
+	require.Equal(t, hashes[2:4], get(start, end, storage.NewDefaultListOptions(false, nil, 2)))
+	require.Equal(t, []string{hashes[4], hashes[3], hashes[2]}, get(start, end, storage.NewDefaultListOptions(true, nil, 0)))
+	require.Equal(t, hashes, get(base, base.Add(time.Minute), nil))
+	require.Equal(t, 0, len(get(base.Add(time.Minute), base.Add(time.Hour), nil)))
+
+	{ // invalid time
+		_, _, err := GetTransactionsByConfirmedRange(st, "findme", common.FormatISO8601(end), nil)
+		require.Equal(t, errors.ErrorInvalidTimeRange.Code, err.(*errors.Error).Code)
+
+		_, _, err = GetTransactionsByConfirmedRange(st, common.FormatISO8601(start), "", nil)
+		require.Equal(t, errors.ErrorInvalidTimeRange.Code, err.(*errors.Error).Code)
+
+		_, _, err = GetTransactionsByConfirmedRange(st, common.FormatISO8601(end), common.FormatISO8601(start), nil)
+		require.Equal(t, errors.ErrorInvalidTimeRange.Code, err.(*errors.Error).Code)
+	}
+}
+
+func TestGetRecentTransactions(t *testing.T) {
+	kp, _ := keypair.Random()
+	st := storage.NewTestStorage()
+
+	var hashes []string
+	for height := 0; height < 2; height++ {
+		var txs []transaction.Transaction
+		var txHashes []string
+		for i := 0; i < 3; i++ {
+			tx := transaction.TestMakeTransactionWithKeypair(networkID, 1, kp)
+			txs = append(txs, tx)
+			txHashes = append(txHashes, tx.GetHash())
+		}
+
+		block := TestMakeNewBlock(txHashes)
+		block.Height += uint64(height)
+		for _, tx := range txs {
+			a, _ := tx.Serialize()
+			bt := NewBlockTransactionFromTransaction(block.Hash, block.Height, block.Confirmed, tx, a)
+			require.Nil(t, bt.Save(st))
+		}
+		hashes = append(hashes, txHashes...)
+	}
+
+	get := func(limit int) (fetched []string) {
+		bts, err := GetRecentTransactions(st, limit)
+		require.Nil(t, err)
+		for _, bt := range bts {
+			fetched = append(fetched, bt.Hash)
+		}
+		return
+	}
+
+	require.Equal(t, []string{hashes[5], hashes[4], hashes[3], hashes[2]}, get(4))
+	require.Equal(t, 6, len(get(10)))
+	require.Equal(t, hashes[0], get(10)[5])
+	require.Equal(t, 0, len(get(0)))
+}
@@ -0,0 +1,44 @@
+package block
+
+import (
+	"fmt"
+
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/error"
+	"boscoin.io/sebak/lib/storage"
+)
+
+func GetBlockUndoKey(height uint64) string {
+	f := fmt.Sprintf("%%s%%0%dd", maxBlockHeightStringLength)
+	return fmt.Sprintf(f, common.BlockPrefixUndo, height)
+}
+
+// SaveBlockUndo stores the undo record of the block at `height`, which is
+// recorded while the block is stored with it's transactions; see
+// `storage.LevelDBBackend.RecordUndo`.
+func SaveBlockUndo(st *storage.LevelDBBackend, height uint64, record storage.UndoRecord) error {
+	return st.New(GetBlockUndoKey(height), record)
+}
+
+// RollbackBlock reverts the block at `height` and all the changes of the
+// state by it with the undo record, which is stored by `SaveBlockUndo`. The
+// blocks must be rolled back from the latest one by one. If the undo record
+// is not stored, for example, the genesis block, it returns
+// `errors.ErrorBlockUndoNotFound`.
+func RollbackBlock(st *storage.LevelDBBackend, height uint64) (err error) {
+	key := GetBlockUndoKey(height)
+
+	var record storage.UndoRecord
+	if err = st.Get(key, &record); err == errors.ErrorStorageRecordDoesNotExist {
+		err = errors.ErrorBlockUndoNotFound.Clone().SetData("height", height)
+		return
+	} else if err != nil {
+		return
+	}
+
+	if err = st.ApplyUndo(record); err != nil {
+		return
+	}
+
+	return st.Remove(key)
+}
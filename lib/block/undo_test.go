@@ -0,0 +1,68 @@
+package block
+
+import (
+	"testing"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stretchr/testify/require"
+
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/error"
+	"boscoin.io/sebak/lib/storage"
+)
+
+func TestRollbackBlock(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	kp, _ := keypair.Random()
+	account := NewBlockAccount(kp.Address(), common.Amount(100))
+	require.Nil(t, account.Save(st, nil))
+
+	prev := TestMakeNewBlock([]string{})
+	prev.Height = 2
+	require.Nil(t, prev.Save(st))
+
+	blk := TestMakeNewBlock([]string{})
+	blk.Height = 3
+
+	{ // the block changes the account
+		ts, err := st.OpenTransaction()
+		require.Nil(t, err)
+		require.Nil(t, ts.RecordUndo())
+
+		require.Nil(t, account.Deposit(common.Amount(50)))
+		require.Nil(t, account.Save(ts, nil))
+		require.Nil(t, blk.Save(ts))
+
+		require.Nil(t, SaveBlockUndo(ts, blk.Height, ts.StopUndo()))
+		require.Nil(t, ts.Commit())
+	}
+
+	ts, err := st.OpenTransaction()
+	require.Nil(t, err)
+	require.Nil(t, RollbackBlock(ts, blk.Height))
+	require.Nil(t, ts.Commit())
+
+	exists, err := ExistsBlock(st, blk.Hash)
+	require.Nil(t, err)
+	require.False(t, exists)
+
+	latest, err := GetLatestBlock(st)
+	require.Nil(t, err)
+	require.Equal(t, prev.Hash, latest.Hash)
+
+	saved, err := GetBlockAccount(st, kp.Address())
+	require.Nil(t, err)
+	require.Equal(t, common.Amount(100), saved.Balance)
+
+	// the undo record is removed with the block
+	err = RollbackBlock(st, blk.Height)
+	require.NotNil(t, err)
+	require.Equal(t, errors.ErrorBlockUndoNotFound.Code, err.(*errors.Error).Code)
+
+	// the block without undo record can not be rolled back
+	err = RollbackBlock(st, prev.Height)
+	require.NotNil(t, err)
+	require.Equal(t, errors.ErrorBlockUndoNotFound.Code, err.(*errors.Error).Code)
+}
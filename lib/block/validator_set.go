@@ -0,0 +1,215 @@
+package block
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/error"
+	"boscoin.io/sebak/lib/storage"
+)
+
+// ValidatorSetDiff is the change of the validator set at the block height.
+// The diff is stored only when the validator set is changed and it has the
+// whole validator set after the change, `Validators`, so the validator set at
+// the height is found by the one diff; see `GetValidatorSetAtHeight()`.
+type ValidatorSetDiff struct {
+	Height     uint64   `json:"height"`
+	Added      []string `json:"added,omitempty"`
+	Removed    []string `json:"removed,omitempty"`
+	Validators []string `json:"validators"`
+}
+
+// GetValidatorSetDiffKey returns the key of the diff at the height. The
+// height is inverted in the key, so the diffs are ordered from the highest
+// and the diff at or below the height is found by seeking to the key.
+func GetValidatorSetDiffKey(height uint64) string {
+	return fmt.Sprintf("%s%s", common.ValidatorSetPrefixDiff, common.EncodeUint64ToByteSlice(math.MaxUint64-height))
+}
+
+// SaveValidatorSet records the validator set, the addresses of validators,
+// at the block height. Only the difference from the validator set at the
+// height is stored; if nothing is changed, nothing is stored. The diff is not
+// removed when the block is rolled back, so the diff kept at the height is
+// replaced by the diff from the previous height.
+func SaveValidatorSet(st *storage.LevelDBBackend, height uint64, validators []string) (err error) {
+	var previous []string
+	if previous, err = getValidatorSet(st, height); err != nil {
+		return
+	}
+	diff, changed := newValidatorSetDiff(height, previous, validators)
+	if !changed {
+		return
+	}
+
+	key := GetValidatorSetDiffKey(height)
+
+	var exists bool
+	if exists, err = st.Has(key); err != nil {
+		return
+	} else if !exists {
+		return st.New(key, diff)
+	}
+
+	if previous, err = getValidatorSet(st, height-1); err != nil {
+		return
+	}
+	if diff, changed = newValidatorSetDiff(height, previous, validators); !changed {
+		return st.Remove(key)
+	}
+
+	return st.Set(key, diff)
+}
+
+// getValidatorSet returns the validator set at the height like
+// `GetValidatorSetAtHeight`, but nil if it is not recorded.
+func getValidatorSet(st *storage.LevelDBBackend, height uint64) (validators []string, err error) {
+	if validators, err = GetValidatorSetAtHeight(st, height); err == errors.ErrorValidatorSetNotFound {
+		err = nil
+	}
+
+	return
+}
+
+// newValidatorSetDiff makes the diff from `previous` to `validators`; if
+// nothing is changed, `changed` is false.
+func newValidatorSetDiff(height uint64, previous, validators []string) (diff ValidatorSetDiff, changed bool) {
+	current := map[string]bool{}
+	for _, address := range validators {
+		current[address] = true
+	}
+
+	diff.Height = height
+	for _, address := range previous {
+		if !current[address] {
+			diff.Removed = append(diff.Removed, address)
+		}
+		delete(current, address)
+	}
+	for address := range current {
+		diff.Added = append(diff.Added, address)
+	}
+	if len(diff.Added) < 1 && len(diff.Removed) < 1 {
+		return
+	}
+	sort.Strings(diff.Added)
+	diff.Validators = applyValidatorSetDiff(previous, diff)
+	changed = true
+
+	return
+}
+
+// GetValidatorSetAtHeight returns the sorted addresses of the validator set
+// at the block height. If the validator set is not recorded until the
+// height, `errors.ErrorValidatorSetNotFound` is returned.
+func GetValidatorSetAtHeight(st *storage.LevelDBBackend, height uint64) (validators []string, err error) {
+	iterFunc, closeFunc := st.GetIterator(
+		common.ValidatorSetPrefixDiff,
+		storage.NewDefaultListOptions(false, []byte(GetValidatorSetDiffKey(height)), 1),
+	)
+	defer closeFunc()
+
+	// the first diff from the key of height is the last diff at or below the
+	// height
+	item, hasNext := iterFunc()
+	if !hasNext || len(item.Value) < 1 {
+		err = errors.ErrorValidatorSetNotFound
+		return
+	}
+
+	var diff ValidatorSetDiff
+	if err = json.Unmarshal(item.Value, &diff); err != nil {
+		return
+	}
+	validators = diff.Validators
+
+	return
+}
+
+func applyValidatorSetDiff(validators []string, diff ValidatorSetDiff) []string {
+	removed := map[string]bool{}
+	for _, address := range diff.Removed {
+		removed[address] = true
+	}
+
+	var applied []string
+	for _, address := range validators {
+		if !removed[address] {
+			applied = append(applied, address)
+		}
+	}
+	applied = append(applied, diff.Added...)
+	sort.Strings(applied)
+
+	return applied
+}
+
+// IsValidator checks the address is in the validator set at the latest block.
+// The validator set is recorded by the chain, from the initial validators of
+// genesis, and at each block height where it is changed; the diffs above the
+// latest block, which are kept after the blocks are rolled back, are not
+// used. If no validator set is recorded, the address is checked in
+// `configured`, the validators configured to the local node.
+func IsValidator(st *storage.LevelDBBackend, address string, configured []string) (bool, error) {
+	height := uint64(math.MaxUint64)
+	if latest, err := GetLatestBlock(st); err == nil {
+		height = latest.Height
+	} else if err != errors.ErrorBlockNotFound {
+		return false, err
+	}
+
+	validators, err := GetValidatorSetAtHeight(st, height)
+	if err == errors.ErrorValidatorSetNotFound {
+		for _, v := range configured {
+			if v == address {
+				return true, nil
+			}
+		}
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	i := sort.SearchStrings(validators, address)
+	return i < len(validators) && validators[i] == address, nil
+}
+
+func GetValidatorEndpointKey(address string) string {
+	return common.ValidatorEndpointPrefix + address
+}
+
+// SaveValidatorEndpoint stores the endpoint of validator, which is updated by
+// `transaction.OperationUpdateEndpoint`.
+func SaveValidatorEndpoint(st *storage.LevelDBBackend, address string, endpoint *common.Endpoint) (err error) {
+	key := GetValidatorEndpointKey(address)
+
+	var exists bool
+	if exists, err = st.Has(key); err != nil {
+		return
+	}
+
+	if exists {
+		err = st.Set(key, endpoint.String())
+	} else {
+		err = st.New(key, endpoint.String())
+	}
+	return
+}
+
+func ExistsValidatorEndpoint(st *storage.LevelDBBackend, address string) (bool, error) {
+	return st.Has(GetValidatorEndpointKey(address))
+}
+
+// GetValidatorEndpoint returns the stored endpoint of validator. If the
+// endpoint is never updated, `errors.ErrorStorageRecordDoesNotExist` is
+// returned.
+func GetValidatorEndpoint(st *storage.LevelDBBackend, address string) (endpoint *common.Endpoint, err error) {
+	var s string
+	if err = st.Get(GetValidatorEndpointKey(address), &s); err != nil {
+		return
+	}
+
+	return common.NewEndpoint(s)
+}
@@ -0,0 +1,147 @@
+package block
+
+import (
+	"testing"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stretchr/testify/require"
+
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/error"
+	"boscoin.io/sebak/lib/storage"
+)
+
+func TestValidatorSetAtHeight(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	_, err := GetValidatorSetAtHeight(st, 1)
+	require.Equal(t, errors.ErrorValidatorSetNotFound, err)
+
+	require.Nil(t, SaveValidatorSet(st, 2, []string{"v1", "v0"}))
+	require.Nil(t, SaveValidatorSet(st, 3, []string{"v0", "v1"}))
+	require.Nil(t, SaveValidatorSet(st, 4, []string{"v0", "v1"}))
+	// `v2` is added at 5
+	require.Nil(t, SaveValidatorSet(st, 5, []string{"v0", "v1", "v2"}))
+	// `v0` is removed at 7
+	require.Nil(t, SaveValidatorSet(st, 7, []string{"v1", "v2"}))
+
+	{ // only the changes are stored
+		var count int
+		iterFunc, closeFunc := st.GetIterator(common.ValidatorSetPrefixDiff, storage.NewDefaultListOptions(false, nil, 0))
+		for {
+			if _, hasNext := iterFunc(); !hasNext {
+				break
+			}
+			count++
+		}
+		closeFunc()
+		require.Equal(t, 3, count)
+	}
+
+	_, err = GetValidatorSetAtHeight(st, 1)
+	require.Equal(t, errors.ErrorValidatorSetNotFound, err)
+
+	expected := map[uint64][]string{
+		2:   {"v0", "v1"},
+		4:   {"v0", "v1"},
+		5:   {"v0", "v1", "v2"},
+		6:   {"v0", "v1", "v2"},
+		7:   {"v1", "v2"},
+		100: {"v1", "v2"},
+	}
+	for height, validators := range expected {
+		set, err := GetValidatorSetAtHeight(st, height)
+		require.Nil(t, err)
+		require.Equal(t, validators, set, "height=%d", height)
+	}
+
+	// `IsValidator` checks the last recorded validator set
+	for address, expected := range map[string]bool{"v0": false, "v1": true, "v2": true, "v3": false} {
+		isValidator, err := IsValidator(st, address, nil)
+		require.Nil(t, err)
+		require.Equal(t, expected, isValidator, "address=%s", address)
+	}
+}
+
+func TestValidatorSetFromGenesis(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	kp, _ := keypair.Random()
+	account := NewBlockAccount(kp.Address(), common.Amount(1000))
+	require.Nil(t, account.Save(st, nil))
+
+	config := NewGenesisConfigFromAccount(*account)
+	config.Validators = []string{"v1", "v0"}
+	genesis, err := MakeGenesisBlockFromConfig(st, config, networkID, kp)
+	require.Nil(t, err)
+
+	for _, height := range []uint64{genesis.Height, genesis.Height + 10} {
+		validators, err := GetValidatorSetAtHeight(st, height)
+		require.Nil(t, err)
+		require.Equal(t, []string{"v0", "v1"}, validators)
+	}
+
+	isValidator, err := IsValidator(st, "v0", nil)
+	require.Nil(t, err)
+	require.True(t, isValidator)
+}
+
+func TestIsValidatorConfigured(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	configured := []string{"v1", "v0"}
+
+	// without the recorded validator set, the configured validators are used
+	for address, expected := range map[string]bool{"v0": true, "v1": true, "v2": false} {
+		isValidator, err := IsValidator(st, address, configured)
+		require.Nil(t, err)
+		require.Equal(t, expected, isValidator, "address=%s", address)
+	}
+
+	// the recorded validator set is used over the configured validators
+	require.Nil(t, SaveValidatorSet(st, 2, []string{"v2"}))
+	for address, expected := range map[string]bool{"v0": false, "v1": false, "v2": true} {
+		isValidator, err := IsValidator(st, address, configured)
+		require.Nil(t, err)
+		require.Equal(t, expected, isValidator, "address=%s", address)
+	}
+}
+
+// The diff kept at the height, after the block is rolled back, is replaced by
+// the diff from the previous height, and the diffs above the latest block are
+// not used.
+func TestSaveValidatorSetKept(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	// the latest block is at 1
+	blk := TestMakeNewBlock(nil)
+	require.Nil(t, blk.Save(st))
+
+	require.Nil(t, SaveValidatorSet(st, 1, []string{"v0"}))
+	require.Nil(t, SaveValidatorSet(st, 2, []string{"v0", "v1"}))
+
+	// the block at 2 is not stored yet, so the diff at 2 is not used
+	isValidator, err := IsValidator(st, "v1", nil)
+	require.Nil(t, err)
+	require.False(t, isValidator)
+
+	require.Nil(t, SaveValidatorSet(st, 2, []string{"v0", "v2"}))
+	validators, err := GetValidatorSetAtHeight(st, 2)
+	require.Nil(t, err)
+	require.Equal(t, []string{"v0", "v2"}, validators)
+
+	var diff ValidatorSetDiff
+	require.Nil(t, st.Get(GetValidatorSetDiffKey(2), &diff))
+	require.Equal(t, []string{"v2"}, diff.Added)
+	require.Empty(t, diff.Removed)
+
+	// same with the previous height, so the kept diff is removed
+	require.Nil(t, SaveValidatorSet(st, 2, []string{"v0"}))
+	exists, err := st.Has(GetValidatorSetDiffKey(2))
+	require.Nil(t, err)
+	require.False(t, exists)
+}
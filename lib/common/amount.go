@@ -11,7 +11,9 @@
 package common
 
 import (
+	"encoding/json"
 	"fmt"
+	"reflect"
 	"strconv"
 
 	"boscoin.io/sebak/lib/error"
@@ -147,9 +149,20 @@ func (a Amount) MarshalJSON() ([]byte, error) {
 }
 
 // Implement JSON's Unmarshaler interface
-// If Unmarshalling errors, `a` will have an `invalidValue`
+// If Unmarshalling errors, `a` will have an `invalidValue` and the error is
+// `*json.UnmarshalTypeError`, so `encoding/json` sets the field of it.
 func (a *Amount) UnmarshalJSON(b []byte) (err error) {
-	*a, err = AmountFromString(string(b[1 : len(b)-1]))
+	var s string
+	if err = json.Unmarshal(b, &s); err == nil {
+		if *a, err = AmountFromString(s); err == nil {
+			return
+		}
+		err = &json.UnmarshalTypeError{Value: "string", Type: reflect.TypeOf(*a)}
+	} else if e, ok := err.(*json.UnmarshalTypeError); ok {
+		e.Type = reflect.TypeOf(*a)
+	}
+
+	*a = invalidValue
 	return
 }
 
@@ -1,6 +1,7 @@
 package common
 
 import (
+	"encoding/json"
 	"strconv"
 	"testing"
 )
@@ -74,3 +75,18 @@ func TestAmount_Uint64OutOfRange(t *testing.T) {
 		}
 	}
 }
+
+func TestAmountUnmarshalJSONError(t *testing.T) {
+	for _, data := range []string{`"showme"`, `100`, `true`, `"-1"`} {
+		var amount Amount
+		err := amount.UnmarshalJSON([]byte(data))
+		if e, ok := err.(*json.UnmarshalTypeError); !ok {
+			t.Errorf("expected *json.UnmarshalTypeError for %s, got: %#v", data, err)
+		} else if e.Type.Name() != "Amount" {
+			t.Errorf("expected type Amount for %s, got: %s", data, e.Type)
+		}
+		if amount != invalidValue {
+			t.Errorf("expected invalid value for %s, got: %d", data, amount)
+		}
+	}
+}
@@ -14,13 +14,18 @@ const (
 	// GenesisBlockConfirmedTime is the time for the confirmed time of genesis
 	// block. This time is of the first commit of SEBAK.
 	GenesisBlockConfirmedTime string = "2018-04-17T5:07:31.000000000Z"
+
+	// SpendLimitPeriod is the period, within which the amounts sent by account
+	// are summed for the spend limit of account.
+	SpendLimitPeriod time.Duration = 24 * time.Hour
 )
 
 var (
 	// BallotConfirmedTimeAllowDuration is the duration time for ballot from
 	// other nodes. If confirmed time of ballot has too late or ahead by
 	// BallotConfirmedTimeAllowDuration, it will be considered not-wellformed.
-	// For details, `Ballot.IsWellFormed()`
+	// It can be set by `--max-clock-skew`; the network with high latency
+	// needs more. For details, `Ballot.IsWellFormed()`
 	BallotConfirmedTimeAllowDuration time.Duration = time.Minute * time.Duration(1)
 
 	// MaxTransactionsInBallot limits the maximum number of `Transaction`s in
@@ -29,4 +34,36 @@ var (
 	// MaxOperationsInTransaction limits the maximum number of `Operation`s in
 	// one `Transaction`.
 	MaxOperationsInTransaction int = 1000
+	// MaxTransactionsInBatch limits the number of `Transaction`s, which are
+	// submitted at once by the batch API.
+	MaxTransactionsInBatch int = 100
+	// MaxAccountDataKeys limits the number of keys of the data, which is set
+	// by `set-account-data` operation, in one account.
+	MaxAccountDataKeys int = 16
+	// MaxAccountDataBytes limits the total bytes of keys and values of the
+	// data in one account.
+	MaxAccountDataBytes int = 1024
+	// AccountStorageBudget is the default of the network parameter, which
+	// limits the bytes of the metadata, which one account can store; see
+	// `BlockAccount.StorageSize()`. `0` disables the budget. Like
+	// `MaxSupply`, the budget is stored with the genesis block, so the nodes
+	// of network use the same budget; see `block.GetAccountStorageBudget()`.
+	AccountStorageBudget int = 0
+
+	// SequenceIDWindow is the size of the nonce window of account. The
+	// `Transaction` is accepted if it's sequenceID is in `[current,
+	// current+SequenceIDWindow)` and not yet used. `1` keeps the strict
+	// ordering of sequenceID.
+	SequenceIDWindow uint64 = 1
+
+	// ReplaceFeeBumpPercent is the minimum increase of fee, in percent, for
+	// the `Transaction`, which replaces the pending one with the same source
+	// and sequenceID in the `TransactionPool`.
+	ReplaceFeeBumpPercent uint64 = 10
+
+	// MaxSupply is the upper limit of total supply of network. The balance of
+	// genesis account can not be over `MaxSupply` and it is stored with the
+	// genesis block, so the operations, which increase the total supply, can
+	// be rejected by the stored one.
+	MaxSupply Amount = MaximumBalance
 )
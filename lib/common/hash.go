@@ -1,6 +1,9 @@
 package common
 
 import (
+	"crypto/sha256"
+	"fmt"
+
 	"github.com/btcsuite/btcutil/base58"
 	ethcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/rlp"
@@ -9,18 +12,83 @@ import (
 
 var HashSalt = []byte("sebak")
 
+// HashAlgo is the ID of the hash function for the object hash. The ID is
+// recorded in the block header, so it must not be changed once it is used.
+type HashAlgo uint8
+
+const (
+	// HashAlgoArgon2 is argon2 with `HashSalt`. It is the zero value, so the
+	// block header without the hash algorithm uses it; it is not encoded in
+	// the block hash.
+	HashAlgoArgon2 HashAlgo = iota
+	// HashAlgoSHA256 is SHA-256.
+	HashAlgoSHA256
+)
+
+// DefaultHashAlgo is the hash algorithm of `MakeHash` and `MakeObjectHash`.
+var DefaultHashAlgo HashAlgo = HashAlgoArgon2
+
+func (a HashAlgo) IsValid() bool {
+	switch a {
+	case HashAlgoArgon2, HashAlgoSHA256:
+		return true
+	default:
+		return false
+	}
+}
+
+func (a HashAlgo) String() string {
+	switch a {
+	case HashAlgoArgon2:
+		return "argon2"
+	case HashAlgoSHA256:
+		return "sha256"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(a))
+	}
+}
+
+// Hash returns the 32 bytes hash of `b`. The unknown algorithm returns nil.
+func (a HashAlgo) Hash(b []byte) []byte {
+	switch a {
+	case HashAlgoArgon2:
+		return argon2.Key(b, HashSalt, 3, 32*1024, 4, 32)
+	case HashAlgoSHA256:
+		h := sha256.Sum256(b)
+		return h[:]
+	default:
+		return nil
+	}
+}
+
 func MakeHash(b []byte) []byte {
-	return argon2.Key(b, HashSalt, 3, 32*1024, 4, 32)
+	return DefaultHashAlgo.Hash(b)
 }
 
 func MakeObjectHash(i interface{}) (b []byte, err error) {
+	return MakeObjectHashWithAlgo(DefaultHashAlgo, i)
+}
+
+// MakeObjectHashWithAlgo makes the hash of the RLP encoded object with the
+// given hash algorithm.
+func MakeObjectHashWithAlgo(algo HashAlgo, i interface{}) (b []byte, err error) {
+	if !algo.IsValid() {
+		err = fmt.Errorf("unknown hash algorithm: %d", uint8(algo))
+		return
+	}
+
 	var e []byte
 	if e, err = rlp.EncodeToBytes(i); err != nil {
 		return
 	}
 
-	b = MakeHash(e)
+	b = algo.Hash(e)
+
+	return
+}
 
+func MustMakeObjectHashWithAlgo(algo HashAlgo, i interface{}) (b []byte) {
+	b, _ = MakeObjectHashWithAlgo(algo, i)
 	return
 }
 
@@ -41,3 +109,8 @@ func BytesToHash(b []byte) Hash {
 	h.SetBytes(b)
 	return h
 }
+
+// IsValidHashString checks `s` is the base58 encoded 32 bytes hash.
+func IsValidHashString(s string) bool {
+	return len(s) > 0 && len(base58.Decode(s)) == 32
+}
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/stretchr/testify/require"
 )
 
 type intType uint64
@@ -29,3 +30,31 @@ func TestInt64StructHashableRLP(t *testing.T) {
 		return
 	}
 }
+
+func TestMakeObjectHashWithAlgo(t *testing.T) {
+	o := struct {
+		A string
+		B uint64
+	}{A: "showme", B: 10}
+
+	argon2Hash, err := MakeObjectHashWithAlgo(HashAlgoArgon2, o)
+	require.Nil(t, err)
+	require.Equal(t, 32, len(argon2Hash))
+
+	sha256Hash, err := MakeObjectHashWithAlgo(HashAlgoSHA256, o)
+	require.Nil(t, err)
+	require.Equal(t, 32, len(sha256Hash))
+
+	require.NotEqual(t, argon2Hash, sha256Hash)
+
+	// stable
+	require.Equal(t, argon2Hash, MustMakeObjectHashWithAlgo(HashAlgoArgon2, o))
+	require.Equal(t, sha256Hash, MustMakeObjectHashWithAlgo(HashAlgoSHA256, o))
+
+	// the default is argon2
+	require.Equal(t, argon2Hash, MustMakeObjectHash(o))
+
+	// unknown algorithm
+	_, err = MakeObjectHashWithAlgo(HashAlgo(100), o)
+	require.NotNil(t, err)
+}
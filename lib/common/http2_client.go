@@ -2,6 +2,7 @@ package common
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"io"
 	"net"
@@ -16,19 +17,51 @@ type HTTP2Client struct {
 	transport *http.Transport
 }
 
+// DefaultHTTP2ClientDialTimeout is the default timeout to connect to the
+// remote host.
+const DefaultHTTP2ClientDialTimeout time.Duration = time.Second
+
+// HTTP2ClientConfig is the configuration of `HTTP2Client`. The zero value of
+// each timeout means no timeout.
+type HTTP2ClientConfig struct {
+	// Timeout is the time limit of the whole request, including connecting
+	// and reading the response body.
+	Timeout time.Duration
+	// DialTimeout is the time limit to connect to the remote host.
+	DialTimeout time.Duration
+	// IdleTimeout is the maximum time of the idle connection before closing
+	// itself.
+	IdleTimeout time.Duration
+	// MaxIdleConns is the maximum number of the idle connections. `0` uses
+	// the default of `http.Transport`.
+	MaxIdleConns int
+	KeepAlive    bool
+}
+
 func NewHTTP2Client(timeout, idleTimeout time.Duration, keepAlive bool) (client *HTTP2Client, err error) {
 	if keepAlive {
 		timeout, idleTimeout = 0, 0
 	}
 
+	return NewHTTP2ClientWithConfig(HTTP2ClientConfig{
+		Timeout:     timeout,
+		DialTimeout: DefaultHTTP2ClientDialTimeout,
+		IdleTimeout: idleTimeout,
+		KeepAlive:   keepAlive,
+	})
+}
+
+func NewHTTP2ClientWithConfig(config HTTP2ClientConfig) (client *HTTP2Client, err error) {
 	transport := &http.Transport{
 		TLSClientConfig: &tls.Config{
 			InsecureSkipVerify: true,
 		},
-		IdleConnTimeout:   idleTimeout,
-		DisableKeepAlives: !keepAlive,
+		IdleConnTimeout:     config.IdleTimeout,
+		MaxIdleConns:        config.MaxIdleConns,
+		MaxIdleConnsPerHost: config.MaxIdleConns,
+		DisableKeepAlives:   !config.KeepAlive,
 		DialContext: (&net.Dialer{
-			Timeout:   1 * time.Second,
+			Timeout:   config.DialTimeout,
 			KeepAlive: 100000 * time.Second,
 			DualStack: true,
 		}).DialContext,
@@ -41,7 +74,7 @@ func NewHTTP2Client(timeout, idleTimeout time.Duration, keepAlive bool) (client
 	client = &HTTP2Client{
 		client: http.Client{
 			Transport: transport,
-			Timeout:   timeout,
+			Timeout:   config.Timeout,
 			CheckRedirect: func(req *http.Request, via []*http.Request) error {
 				return http.ErrUseLastResponse // NOTE prevent redirect
 			},
@@ -57,10 +90,17 @@ func (c *HTTP2Client) Close() {
 }
 
 func (c *HTTP2Client) Get(url string, headers http.Header) (response *http.Response, err error) {
+	return c.GetContext(context.Background(), url, headers)
+}
+
+// GetContext sends GET request with the context; the request is canceled
+// when the context is done.
+func (c *HTTP2Client) GetContext(ctx context.Context, url string, headers http.Header) (response *http.Response, err error) {
 	var request *http.Request
 	if request, err = http.NewRequest("GET", url, nil); err != nil {
 		return
 	}
+	request = request.WithContext(ctx)
 	request.Header = headers
 
 	if response, err = c.client.Do(request); err != nil {
@@ -71,10 +111,17 @@ func (c *HTTP2Client) Get(url string, headers http.Header) (response *http.Respo
 }
 
 func (c *HTTP2Client) Post(url string, b []byte, headers http.Header) (response *http.Response, err error) {
+	return c.PostContext(context.Background(), url, b, headers)
+}
+
+// PostContext sends POST request with the context; the request is canceled
+// when the context is done.
+func (c *HTTP2Client) PostContext(ctx context.Context, url string, b []byte, headers http.Header) (response *http.Response, err error) {
 	var request *http.Request
 	if request, err = http.NewRequest("POST", url, bytes.NewBuffer(b)); err != nil {
 		return
 	}
+	request = request.WithContext(ctx)
 	request.Header = headers
 
 	if response, err = c.client.Do(request); err != nil {
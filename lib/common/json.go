@@ -0,0 +1,134 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+type jsonFrame struct {
+	isArray    bool
+	key        string // last key of object
+	readingKey bool
+	count      int  // number of elements of array
+	counting   bool // array is located at the checked path
+}
+
+// JSONArrayExceedsLimit reads the json document token by token and checks
+// the number of elements of the array, which is located at `path`, like
+// `JSONArrayExceedsLimit(b, 1000, "B", "operations")`. The keys of `path` are
+// case-insensitive like `json.Unmarshal()`.
+//
+// It stops reading as soon as the number of elements is over `limit`, so the
+// huge document can be rejected before it is fully decoded. The syntax
+// error of the json document is not checked after the limit is exceeded.
+func JSONArrayExceedsLimit(data []byte, limit int, path ...string) (exceeded bool, err error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+
+	var stack []*jsonFrame
+	top := func() *jsonFrame {
+		if len(stack) < 1 {
+			return nil
+		}
+		return stack[len(stack)-1]
+	}
+	matchPath := func() bool {
+		var keys []string
+		for _, f := range stack {
+			if !f.isArray {
+				keys = append(keys, f.key)
+			}
+		}
+		if len(keys) != len(path) {
+			return false
+		}
+		for i, k := range keys {
+			if !strings.EqualFold(k, path[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	// startValue returns true when the limit is exceeded
+	startValue := func() bool {
+		f := top()
+		if f == nil || !f.isArray {
+			return false
+		}
+		f.count++
+		return f.counting && f.count > limit
+	}
+	endValue := func() {
+		if f := top(); f != nil && !f.isArray {
+			f.readingKey = true
+		}
+	}
+
+	for {
+		var token json.Token
+		if token, err = decoder.Token(); err != nil {
+			if err == io.EOF {
+				err = nil
+				if len(stack) > 0 {
+					err = io.ErrUnexpectedEOF
+				}
+			}
+			return
+		}
+
+		if f := top(); f != nil && !f.isArray && f.readingKey {
+			if key, ok := token.(string); ok {
+				f.key = key
+				f.readingKey = false
+				continue
+			}
+		}
+
+		switch token {
+		case json.Delim('{'):
+			if startValue() {
+				exceeded = true
+				return
+			}
+			stack = append(stack, &jsonFrame{readingKey: true})
+		case json.Delim('['):
+			if startValue() {
+				exceeded = true
+				return
+			}
+			counting := matchPath()
+			stack = append(stack, &jsonFrame{isArray: true, counting: counting})
+		case json.Delim('}'), json.Delim(']'):
+			stack = stack[:len(stack)-1]
+			endValue()
+		default:
+			if startValue() {
+				exceeded = true
+				return
+			}
+			endValue()
+		}
+	}
+}
+
+// CanonicalJSONMarshal marshals `v` into the canonical json; the keys of all
+// the objects are sorted and there is no whitespace. The result does not
+// depend on the order of struct fields, so it can be used for hashing.
+func CanonicalJSONMarshal(v interface{}) (b []byte, err error) {
+	var raw []byte
+	if raw, err = json.Marshal(v); err != nil {
+		return
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	var i interface{}
+	if err = decoder.Decode(&i); err != nil {
+		return
+	}
+
+	// `json.Marshal` sorts the keys of map
+	return json.Marshal(i)
+}
@@ -0,0 +1,61 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONArrayExceedsLimit(t *testing.T) {
+	data := []byte(`{"A": [1, 2, 3], "B": {"c": [[1], [2], {"d": 3}], "e": "f"}}`)
+
+	{ // under the limit
+		exceeded, err := JSONArrayExceedsLimit(data, 3, "b", "c")
+		require.Nil(t, err)
+		require.False(t, exceeded)
+	}
+
+	{ // over the limit
+		exceeded, err := JSONArrayExceedsLimit(data, 2, "B", "c")
+		require.Nil(t, err)
+		require.True(t, exceeded)
+	}
+
+	{ // the other array is not counted
+		exceeded, err := JSONArrayExceedsLimit(data, 2, "B", "e")
+		require.Nil(t, err)
+		require.False(t, exceeded)
+	}
+
+	{ // invalid json
+		_, err := JSONArrayExceedsLimit([]byte(`{"A": [1, 2,`), 2, "A")
+		require.NotNil(t, err)
+	}
+
+	{ // stops before reaching the syntax error
+		exceeded, err := JSONArrayExceedsLimit([]byte(`{"A": [1, 2, 3, 4 }}}`), 2, "A")
+		require.Nil(t, err)
+		require.True(t, exceeded)
+	}
+}
+
+func TestCanonicalJSONMarshal(t *testing.T) {
+	type inner struct {
+		Z string `json:"z"`
+		A uint64 `json:"a"`
+	}
+	type outer struct {
+		Inner inner             `json:"inner"`
+		Map   map[string]string `json:"map"`
+		Big   uint64            `json:"big"`
+	}
+
+	v := outer{
+		Inner: inner{Z: "z", A: 1},
+		Map:   map[string]string{"b": "2", "a": "1"},
+		Big:   18446744073709551615,
+	}
+	b, err := CanonicalJSONMarshal(v)
+	require.Nil(t, err)
+	require.Equal(t, `{"big":18446744073709551615,"inner":{"a":1,"z":"z"},"map":{"a":"1","b":"2"}}`, string(b))
+}
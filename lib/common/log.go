@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"reflect"
+	"sync"
 	"time"
 
 	logging "github.com/inconshreveable/log15"
@@ -19,7 +20,78 @@ var (
 
 // SetLogging set the logger
 func SetLogging(logger logging.Logger, level logging.Lvl, handler logging.Handler) {
-	logger.SetHandler(logging.LvlFilterHandler(level, handler))
+	logger.SetHandler(ModuleLvlFilterHandler(level, handler))
+}
+
+var moduleLogLevels = struct {
+	sync.RWMutex
+	levels map[string]logging.Lvl
+}{levels: map[string]logging.Lvl{}}
+
+// SetLogLevel sets the log level of the module, like "http", "connection"
+// and "consensus", at runtime.
+func SetLogLevel(module string, level logging.Lvl) {
+	moduleLogLevels.Lock()
+	defer moduleLogLevels.Unlock()
+
+	moduleLogLevels.levels[module] = level
+}
+
+// ResetLogLevel removes the log level of the module, so the module follows
+// the default level again.
+func ResetLogLevel(module string) {
+	moduleLogLevels.Lock()
+	defer moduleLogLevels.Unlock()
+
+	delete(moduleLogLevels.levels, module)
+}
+
+func GetLogLevel(module string) (level logging.Lvl, found bool) {
+	moduleLogLevels.RLock()
+	defer moduleLogLevels.RUnlock()
+
+	level, found = moduleLogLevels.levels[module]
+	return
+}
+
+// LogLevels returns the log levels set by `SetLogLevel`.
+func LogLevels() map[string]string {
+	moduleLogLevels.RLock()
+	defer moduleLogLevels.RUnlock()
+
+	levels := map[string]string{}
+	for module, level := range moduleLogLevels.levels {
+		levels[module] = level.String()
+	}
+
+	return levels
+}
+
+// ModuleLvlFilterHandler works like `logging.LvlFilterHandler`, but if the
+// log level of the "module" of the record is set by `SetLogLevel`, the record
+// is filtered by that level.
+func ModuleLvlFilterHandler(level logging.Lvl, handler logging.Handler) logging.Handler {
+	return logging.FilterHandler(func(r *logging.Record) bool {
+		lvl := level
+
+		// the child logger appends it's own "module" to the context, so the
+		// last one is the most specific.
+		for i := len(r.Ctx) - 2; i >= 0; i -= 2 {
+			if k, ok := r.Ctx[i].(string); !ok || k != "module" {
+				continue
+			}
+			module, ok := r.Ctx[i+1].(string)
+			if !ok {
+				continue
+			}
+			if l, found := GetLogLevel(module); found {
+				lvl = l
+				break
+			}
+		}
+
+		return r.Lvl <= lvl
+	}, handler)
 }
 
 // `formatJSONValue` and `JsonFormatEx` was derived from
@@ -0,0 +1,39 @@
+package common
+
+import (
+	"bytes"
+	"testing"
+
+	logging "github.com/inconshreveable/log15"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModuleLvlFilterHandler(t *testing.T) {
+	defer ResetLogLevel("http")
+
+	buf := &bytes.Buffer{}
+	networkLog := logging.New("module", "network")
+	SetLogging(networkLog, logging.LvlDebug, logging.StreamHandler(buf, logging.LogfmtFormat()))
+	httpLog := networkLog.New("module", "http")
+
+	httpLog.Debug("findme")
+	require.Contains(t, buf.String(), "findme")
+
+	// raise the level of http module
+	SetLogLevel("http", logging.LvlInfo)
+	require.Equal(t, map[string]string{"http": "info"}, LogLevels())
+
+	buf.Reset()
+	httpLog.Debug("showme")
+	httpLog.Info("infome")
+	networkLog.Debug("networkme")
+	require.NotContains(t, buf.String(), "showme")
+	require.Contains(t, buf.String(), "infome")
+	require.Contains(t, buf.String(), "networkme")
+
+	// back to the default level
+	ResetLogLevel("http")
+	buf.Reset()
+	httpLog.Debug("showme")
+	require.Contains(t, buf.String(), "showme")
+}
@@ -6,6 +6,7 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"path"
 	"strconv"
 	"strings"
 	"time"
@@ -81,6 +82,46 @@ func NewEndpointFromString(s string) (e *Endpoint, err error) {
 	return
 }
 
+// NewEndpoint parses the endpoint more strictly than
+// `NewEndpointFromString`; the scheme must be "http" or "https", the host and
+// the port must be given, and the user info, query and fragment are not
+// allowed. The returned `Endpoint` is normalized.
+func NewEndpoint(s string) (e *Endpoint, err error) {
+	var u *url.URL
+	if u, err = url.Parse(s); err != nil {
+		return
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+	default:
+		err = fmt.Errorf("unsupported scheme: %q", u.Scheme)
+		return
+	}
+
+	if len(u.Hostname()) < 1 {
+		err = errors.New("missing host")
+		return
+	}
+
+	var port int64
+	if port, err = strconv.ParseInt(u.Port(), 10, 64); err != nil {
+		err = errors.New("missing or invalid port")
+		return
+	} else if port < 1 || port > 65535 {
+		err = errors.New("invalid port")
+		return
+	}
+
+	if u.User != nil || len(u.RawQuery) > 0 || len(u.Fragment) > 0 || u.Opaque != "" {
+		err = errors.New("user info, query and fragment are not allowed")
+		return
+	}
+
+	e = NewEndpointFromURL(u).Normalize()
+	return
+}
+
 func (e *Endpoint) String() string {
 	return (&url.URL{
 		Scheme: e.Scheme,
@@ -89,6 +130,47 @@ func (e *Endpoint) String() string {
 	}).String()
 }
 
+// defaultPorts is the default port by scheme. The default port is removed by
+// `Endpoint.Normalize()`.
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// Normalize returns the new normalized `Endpoint`; the scheme and host are
+// lower-cased, the default port of scheme is removed and the path is cleaned
+// without the trailing slash. The query is kept.
+func (e *Endpoint) Normalize() *Endpoint {
+	u := *(*url.URL)(e)
+	u.Scheme = strings.ToLower(u.Scheme)
+
+	host := strings.ToLower(u.Host)
+	if port := (&url.URL{Host: host}).Port(); len(port) > 0 && defaultPorts[u.Scheme] == port {
+		host = strings.TrimSuffix(host, ":"+port)
+	}
+	u.Host = host
+
+	if len(u.Path) > 0 {
+		u.Path = path.Clean(u.Path)
+		if u.Path == "/" || u.Path == "." {
+			u.Path = ""
+		}
+	}
+	u.RawPath = ""
+
+	return (*Endpoint)(&u)
+}
+
+// Equal checks `e` and `other` point the same place after `Normalize()`. The
+// query is not compared.
+func (e *Endpoint) Equal(other *Endpoint) bool {
+	if e == nil || other == nil {
+		return e == other
+	}
+
+	return e.Normalize().String() == other.Normalize().String()
+}
+
 func (e *Endpoint) Query() url.Values {
 	return (*url.URL)(e).Query()
 }
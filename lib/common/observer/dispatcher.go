@@ -0,0 +1,86 @@
+package observer
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/GianlucaGuarini/go-observable"
+)
+
+// DispatcherQueueSize is the size of the queue of the asynchronous
+// `Dispatcher`.
+var DispatcherQueueSize int = 1000
+
+// BlockDispatcher triggers the events of `BlockObserver`.
+var BlockDispatcher = NewDispatcher(BlockObserver)
+
+type dispatchEvent struct {
+	event string
+	args  []interface{}
+}
+
+// Dispatcher triggers the events of the observable. By default it is
+// synchronous, so `Trigger` returns after all the handlers are finished.
+//
+// In asynchronous mode, `Trigger` puts the event into the bounded queue and
+// returns at once; the events are triggered in order by the separate
+// goroutine. If the queue is full, the event is dropped and counted in
+// `DroppedEvents`.
+type Dispatcher struct {
+	sync.RWMutex
+
+	ob    *observable.Observable
+	queue chan dispatchEvent
+}
+
+func NewDispatcher(ob *observable.Observable) *Dispatcher {
+	return &Dispatcher{ob: ob}
+}
+
+// SetAsync changes the mode of dispatcher. When asynchronous mode is turned
+// off, the events left in the queue are still triggered.
+func (d *Dispatcher) SetAsync(async bool) {
+	d.Lock()
+	defer d.Unlock()
+
+	if async == (d.queue != nil) {
+		return
+	}
+
+	if async {
+		d.queue = make(chan dispatchEvent, DispatcherQueueSize)
+		go d.run(d.queue)
+	} else {
+		close(d.queue)
+		d.queue = nil
+	}
+}
+
+func (d *Dispatcher) IsAsync() bool {
+	d.RLock()
+	defer d.RUnlock()
+
+	return d.queue != nil
+}
+
+func (d *Dispatcher) Trigger(event string, args ...interface{}) {
+	d.RLock()
+	if d.queue == nil {
+		d.RUnlock()
+		d.ob.Trigger(event, args...)
+		return
+	}
+	defer d.RUnlock()
+
+	select {
+	case d.queue <- dispatchEvent{event: event, args: args}:
+	default:
+		atomic.AddUint64(&droppedEvents, 1)
+	}
+}
+
+func (d *Dispatcher) run(queue chan dispatchEvent) {
+	for e := range queue {
+		d.ob.Trigger(e.event, e.args...)
+	}
+}
@@ -0,0 +1,95 @@
+package observer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GianlucaGuarini/go-observable"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDispatcherSync(t *testing.T) {
+	ob := observable.New()
+	d := NewDispatcher(ob)
+	require.False(t, d.IsAsync())
+
+	var received []interface{}
+	ob.On("saved", func(args ...interface{}) {
+		received = append(received, args[0])
+	})
+
+	d.Trigger("saved", 1)
+	d.Trigger("saved", 2)
+
+	// handlers are finished before `Trigger` returns
+	require.Equal(t, []interface{}{1, 2}, received)
+}
+
+func TestDispatcherAsync(t *testing.T) {
+	ob := observable.New()
+	d := NewDispatcher(ob)
+	d.SetAsync(true)
+	defer d.SetAsync(false)
+	require.True(t, d.IsAsync())
+
+	release := make(chan struct{})
+	received := make(chan interface{}, 10)
+	ob.On("saved", func(args ...interface{}) {
+		<-release
+		received <- args[0]
+	})
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 3; i++ {
+			d.Trigger("saved", i)
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Trigger is blocked by the slow handler")
+	}
+
+	close(release)
+	for i := 0; i < 3; i++ {
+		select {
+		case v := <-received:
+			require.Equal(t, i, v)
+		case <-time.After(time.Second):
+			t.Fatal("event is not triggered")
+		}
+	}
+}
+
+func TestDispatcherAsyncQueueFull(t *testing.T) {
+	defer func(s int) { DispatcherQueueSize = s }(DispatcherQueueSize)
+	DispatcherQueueSize = 2
+
+	ob := observable.New()
+	d := NewDispatcher(ob)
+	d.SetAsync(true)
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 10)
+	ob.On("saved", func(args ...interface{}) {
+		started <- struct{}{}
+		<-release
+	})
+
+	dropped := DroppedEvents()
+
+	// the first event is taken by the dispatcher goroutine, the next 2 events
+	// fill the queue and the others are dropped.
+	d.Trigger("saved", 0)
+	<-started
+	for i := 1; i < 6; i++ {
+		d.Trigger("saved", i)
+	}
+	require.Equal(t, uint64(3), DroppedEvents()-dropped)
+
+	close(release)
+	d.SetAsync(false)
+}
@@ -6,3 +6,4 @@ var BlockAccountObserver = observable.New()
 var BlockTransactionObserver = observable.New()
 var BlockObserver = observable.New()
 var BlockOperationObserver = observable.New()
+var ConsensusObserver = observable.New()
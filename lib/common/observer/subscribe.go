@@ -0,0 +1,57 @@
+package observer
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/GianlucaGuarini/go-observable"
+)
+
+// SubscribeBufferSize is the size of the channel buffer of `Subscribe`.
+var SubscribeBufferSize int = 100
+
+var droppedEvents uint64
+
+// DroppedEvents returns the number of the events, which are dropped because
+// the buffer of the subscriber or the queue of `Dispatcher` is full.
+func DroppedEvents() uint64 {
+	return atomic.LoadUint64(&droppedEvents)
+}
+
+// Subscribe returns the buffered channel, which receives the triggered event
+// of `ob`, and the unsubscribe func. If the event is triggered with one
+// argument, the channel receives the argument, otherwise it receives the
+// arguments as `[]interface{}`.
+//
+// The slow subscriber does not block `Trigger`; if the buffer is full, the
+// event is dropped and counted in `DroppedEvents`. After unsubscribe, the
+// channel is closed.
+func Subscribe(ob *observable.Observable, event string) (<-chan interface{}, func()) {
+	ch := make(chan interface{}, SubscribeBufferSize)
+
+	onFunc := func(args ...interface{}) {
+		var payload interface{} = args
+		if len(args) == 1 {
+			payload = args[0]
+		}
+
+		select {
+		case ch <- payload:
+		default:
+			atomic.AddUint64(&droppedEvents, 1)
+		}
+	}
+	ob.On(event, onFunc)
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			// `Off` waits the running `Trigger`, so nothing is sent to the
+			// closed channel.
+			ob.Off(event, onFunc)
+			close(ch)
+		})
+	}
+
+	return ch, unsubscribe
+}
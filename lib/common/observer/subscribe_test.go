@@ -0,0 +1,75 @@
+package observer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GianlucaGuarini/go-observable"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscribe(t *testing.T) {
+	ob := observable.New()
+
+	ch, unsubscribe := Subscribe(ob, "saved")
+	defer unsubscribe()
+
+	ob.Trigger("saved", "showme")
+	ob.Trigger("saved", "findme", 1)
+	ob.Trigger("other", "ignored")
+
+	require.Equal(t, "showme", <-ch)
+	require.Equal(t, []interface{}{"findme", 1}, <-ch)
+
+	select {
+	case v := <-ch:
+		t.Errorf("unexpected event: %v", v)
+	default:
+	}
+}
+
+func TestSubscribeSlowSubscriber(t *testing.T) {
+	defer func(s int) { SubscribeBufferSize = s }(SubscribeBufferSize)
+	SubscribeBufferSize = 3
+
+	ob := observable.New()
+
+	// this subscriber never receives
+	_, unsubscribe := Subscribe(ob, "saved")
+	defer unsubscribe()
+
+	dropped := DroppedEvents()
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 10; i++ {
+			ob.Trigger("saved", i)
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Trigger is blocked by the slow subscriber")
+	}
+
+	require.Equal(t, uint64(7), DroppedEvents()-dropped)
+}
+
+func TestSubscribeUnsubscribe(t *testing.T) {
+	ob := observable.New()
+
+	ch, unsubscribe := Subscribe(ob, "saved")
+	ob.Trigger("saved", 1)
+	unsubscribe()
+	unsubscribe() // can be called again
+
+	ob.Trigger("saved", 2)
+
+	var received []interface{}
+	for v := range ch { // closed
+		received = append(received, v)
+	}
+	require.Equal(t, []interface{}{1}, received)
+}
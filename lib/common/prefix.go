@@ -4,18 +4,41 @@ const (
 	BlockPrefixHash                       = string(0x00)
 	BlockPrefixConfirmed                  = string(0x01)
 	BlockPrefixHeight                     = string(0x02)
+	BlockPrefixUndo                       = string(0x03)
 	BlockTransactionPrefixHash            = string(0x10)
 	BlockTransactionPrefixSource          = string(0x11)
 	BlockTransactionPrefixConfirmed       = string(0x12)
 	BlockTransactionPrefixAccount         = string(0x13)
 	BlockTransactionPrefixBlock           = string(0x14)
+	BlockTransactionPrefixConfirmedTime   = string(0x15)
+	BlockTransactionPrefixRecent          = string(0x16)
+	BlockTransactionPrefixRecentSequence  = string(0x17)
 	BlockOperationPrefixHash              = string(0x20)
 	BlockOperationPrefixTxHash            = string(0x21)
 	BlockOperationPrefixSource            = string(0x22)
 	BlockOperationPrefixTarget            = string(0x23)
 	BlockOperationPrefixPeers             = string(0x24)
+	BlockOperationPrefixCreatedAccount    = string(0x25)
 	BlockAccountPrefixAddress             = string(0x30)
 	BlockAccountPrefixCreated             = string(0x31)
 	BlockAccountSequenceIDPrefix          = string(0x32)
 	BlockAccountSequenceIDByAddressPrefix = string(0x33)
+	BlockAccountSequenceIDSeenPrefix      = string(0x34)
+	BlockAccountPrefixStats               = string(0x35)
+	BlockMaxSupplyPrefix                  = string(0x36)
+	BlockAccountDelegationPrefix          = string(0x37)
+	BlockAccountFilterPrefix              = string(0x38)
+	BlockAccountPrefixCreatedByAddress    = string(0x39)
+	BlockAccountPrefixLinked              = string(0x3a)
+	BlockAccountStorageBudgetPrefix       = string(0x3b)
+	BlockFeePolicyPrefix                  = string(0x3c)
+	BlockAccountSpentPrefix               = string(0x3d)
+	BallotPrefixHeight                    = string(0x40)
+	OpLogPrefix                           = string(0x50)
+	OpLogPrefixSequence                   = string(0x51)
+	TransactionJournalPrefix              = string(0x60)
+	TransactionJournalPrefixSequence      = string(0x61)
+	ValidatorSetPrefixDiff                = string(0x70)
+	ValidatorEndpointPrefix               = string(0x72)
+	ConnStatsPrefix                       = string(0x80)
 )
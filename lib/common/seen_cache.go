@@ -0,0 +1,85 @@
+package common
+
+import (
+	"container/list"
+	"sync"
+)
+
+// SeenCache is the bounded LRU of the hashes of the recently seen messages.
+// The gossiped message arrives multiple times, so the message, which is
+// already in the cache, can be dropped before it is processed again. When the
+// cache is full, the least recently seen hash is evicted.
+type SeenCache struct {
+	sync.Mutex
+
+	size  int
+	order *list.List
+	items map[string]*list.Element
+}
+
+// NewSeenCache makes `SeenCache`, which keeps up to `size` hashes. Under 1,
+// the cache is disabled and nothing is seen.
+func NewSeenCache(size int) *SeenCache {
+	return &SeenCache{
+		size:  size,
+		order: list.New(),
+		items: map[string]*list.Element{},
+	}
+}
+
+// Has returns `true` if the hash is already in the cache. The hash is not
+// added; the hash is added by `Add`, for example, after the message is
+// accepted.
+func (c *SeenCache) Has(hash string) bool {
+	if c == nil || c.size < 1 {
+		return false
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	return c.has(hash)
+}
+
+// Add adds the hash to the cache.
+func (c *SeenCache) Add(hash string) {
+	if c == nil || c.size < 1 {
+		return
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	if !c.has(hash) {
+		c.add(hash)
+	}
+}
+
+func (c *SeenCache) has(hash string) bool {
+	e, found := c.items[hash]
+	if found {
+		c.order.MoveToFront(e)
+	}
+
+	return found
+}
+
+func (c *SeenCache) add(hash string) {
+	c.items[hash] = c.order.PushFront(hash)
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(string))
+	}
+}
+
+func (c *SeenCache) Len() int {
+	if c == nil {
+		return 0
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	return c.order.Len()
+}
@@ -0,0 +1,37 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeenCache(t *testing.T) {
+	c := NewSeenCache(2)
+
+	// `Has` does not add
+	require.False(t, c.Has("a"))
+	require.False(t, c.Has("a"))
+	require.Equal(t, 0, c.Len())
+
+	c.Add("a")
+	c.Add("a")
+	require.True(t, c.Has("a"))
+	require.Equal(t, 1, c.Len())
+
+	// "a" is seen more recently than "b", so "b" is evicted
+	c.Add("b")
+	require.True(t, c.Has("a"))
+	c.Add("c")
+	require.Equal(t, 2, c.Len())
+	require.False(t, c.Has("b"))
+	require.True(t, c.Has("a"))
+	require.True(t, c.Has("c"))
+}
+
+func TestSeenCacheDisabled(t *testing.T) {
+	c := NewSeenCache(0)
+	c.Add("a")
+	require.False(t, c.Has("a"))
+	require.Equal(t, 0, c.Len())
+}
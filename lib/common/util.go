@@ -143,6 +143,11 @@ func MakeSignature(kp keypair.KP, networkID []byte, hash string) ([]byte, error)
 	return kp.Sign(append(networkID, []byte(hash)...))
 }
 
+// VerifySignature verifies the signature, which is made by `MakeSignature`
+func VerifySignature(kp keypair.KP, networkID []byte, hash string, signature []byte) error {
+	return kp.Verify(append(networkID, []byte(hash)...), signature)
+}
+
 func EncodeUint64ToByteSlice(i uint64) [MaxUintEncodeByte]byte {
 	var b [MaxUintEncodeByte]byte
 	binary.BigEndian.PutUint64(b[:], i)
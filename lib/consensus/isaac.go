@@ -8,6 +8,7 @@ import (
 
 	"boscoin.io/sebak/lib/ballot"
 	"boscoin.io/sebak/lib/block"
+	"boscoin.io/sebak/lib/common"
 	"boscoin.io/sebak/lib/consensus/round"
 	"boscoin.io/sebak/lib/network"
 	"boscoin.io/sebak/lib/node"
@@ -209,6 +210,26 @@ func (is *ISAAC) HasRunningRound(roundHash string) bool {
 	return found
 }
 
+// IsRunningTransaction checks the transaction is proposed in the running
+// rounds.
+func (is *ISAAC) IsRunningTransaction(hash string) bool {
+	is.RLock()
+	defer is.RUnlock()
+
+	for _, runningRound := range is.RunningRounds {
+		runningRound.RLock()
+		for _, hashes := range runningRound.Transactions {
+			if _, found := common.InStringArray(hashes, hash); found {
+				runningRound.RUnlock()
+				return true
+			}
+		}
+		runningRound.RUnlock()
+	}
+
+	return false
+}
+
 func (is *ISAAC) HasSameProposer(b ballot.Ballot) bool {
 	is.RLock()
 	defer is.RUnlock()
@@ -232,6 +253,20 @@ func (is *ISAAC) AddRunningRound(roundHash string, theBallot ballot.Ballot) erro
 	return err
 }
 
+// ReceivedBallots returns the received ballots of the proposer in the running
+// round.
+func (is *ISAAC) ReceivedBallots(round round.Round, proposer string) []ballot.Ballot {
+	is.RLock()
+	defer is.RUnlock()
+
+	runningRound, found := is.RunningRounds[round.Hash()]
+	if !found {
+		return nil
+	}
+
+	return runningRound.ReceivedBallots(proposer)
+}
+
 func (is *ISAAC) LatestConfirmedBlock() block.Block {
 	is.RLock()
 	defer is.RUnlock()
@@ -18,6 +18,23 @@ type ISAACConfiguration struct {
 	BlockTime     time.Duration
 
 	TransactionsLimit uint64
+
+	// PersistBallots stores the ballots of the confirmed round for audit.
+	PersistBallots bool
+
+	// VerifySupply checks the total supply is conserved after every block is
+	// applied. It costs one more lookup of each transaction.
+	VerifySupply bool
+
+	// ValidationWorkers is the number of workers, which validate the
+	// transactions of ballot concurrently. Under 1, the transactions are
+	// validated one by one.
+	ValidationWorkers int
+
+	// SeenCacheSize is the number of the hashes of the recently received
+	// ballots and transactions, which are kept to drop the same message
+	// received again; see `common.SeenCache`. Under 1, nothing is dropped.
+	SeenCacheSize int
 }
 
 func NewISAACConfiguration() *ISAACConfiguration {
@@ -28,6 +45,8 @@ func NewISAACConfiguration() *ISAACConfiguration {
 	p.TimeoutACCEPT = 2 * time.Second
 	p.BlockTime = 5 * time.Second
 	p.TransactionsLimit = uint64(1000)
+	p.ValidationWorkers = 1
+	p.SeenCacheSize = 10000
 
 	return &p
 }
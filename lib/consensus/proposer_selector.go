@@ -15,7 +15,14 @@ type SequentialSelector struct {
 }
 
 func (s SequentialSelector) Select(blockHeight uint64, roundNumber uint64) string {
-	candidates := sort.StringSlice(s.cm.AllValidators())
+	return SelectSequential(s.cm.AllValidators(), blockHeight, roundNumber)
+}
+
+// SelectSequential selects the proposer from the validators like
+// `SequentialSelector`.
+func SelectSequential(validators []string, blockHeight uint64, roundNumber uint64) string {
+	candidates := make(sort.StringSlice, len(validators))
+	copy(candidates, validators)
 	candidates.Sort()
 	return candidates[(blockHeight+roundNumber)%uint64(len(candidates))]
 }
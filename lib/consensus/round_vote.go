@@ -11,12 +11,17 @@ type RoundVoteResult map[ /* Node.Address() */ string]ballot.VotingHole
 type RoundVote struct {
 	SIGN   RoundVoteResult
 	ACCEPT RoundVoteResult
+
+	// proposer is assumed to say `VotingYES` in SIGN state, because its
+	// ballots are not voted.
+	proposer string
 }
 
 func NewRoundVote(ballot ballot.Ballot) (rv *RoundVote) {
 	rv = &RoundVote{
-		SIGN:   RoundVoteResult{},
-		ACCEPT: RoundVoteResult{},
+		SIGN:     RoundVoteResult{},
+		ACCEPT:   RoundVoteResult{},
+		proposer: ballot.Proposer(),
 	}
 
 	rv.Vote(ballot)
@@ -66,63 +71,45 @@ func (rv *RoundVote) GetResult(state ballot.State) (result RoundVoteResult) {
 }
 
 func (rv *RoundVote) CanGetVotingResult(policy ballot.VotingThresholdPolicy, state ballot.State, log logging.Logger) (RoundVoteResult, ballot.VotingHole, bool) {
-	threshold := policy.Threshold(state)
+	threshold := policy.RequiredCount(state)
 	if threshold < 1 {
 		return RoundVoteResult{}, ballot.VotingNOTYET, false
 	}
 
+	// the votes are counted by the weight of validator
 	result := rv.GetResult(state)
-	if len(result) < int(threshold) {
-		return result, ballot.VotingNOTYET, false
+	var tally ballot.VoteTally
+	if state == ballot.StateSIGN && len(rv.proposer) > 0 {
+		if _, found := result[rv.proposer]; !found {
+			tally.Yes += policy.Weight(rv.proposer)
+		}
 	}
-
-	var yes, no, expired int
-	for _, votingHole := range result {
+	for address, votingHole := range result {
+		weight := policy.Weight(address)
 		switch votingHole {
 		case ballot.VotingYES:
-			yes++
+			tally.Yes += weight
 		case ballot.VotingNO:
-			no++
+			tally.No += weight
 		case ballot.VotingEXP:
-			expired++
+			tally.Expired += weight
 		}
 	}
 
+	if tally.Voted() < threshold {
+		return result, ballot.VotingNOTYET, false
+	}
+
 	log.Debug(
 		"check threshold in isaac",
 		"threshold", threshold,
-		"yes", yes,
-		"no", no,
-		"expired", expired,
+		"yes", tally.Yes,
+		"no", tally.No,
+		"expired", tally.Expired,
 		"state", state,
 	)
 
-	if state == ballot.StateSIGN {
-		if yes >= threshold {
-			return result, ballot.VotingYES, true
-		} else if no >= threshold+1 {
-			return result, ballot.VotingNO, true
-		}
-	} else if state == ballot.StateACCEPT {
-		if yes >= threshold {
-			return result, ballot.VotingYES, true
-		} else if no >= threshold {
-			return result, ballot.VotingNO, true
-		}
-	} else {
-		// do nothing
-	}
-
-	// check draw!
-	total := policy.Validators()
-	voted := yes + no + expired
-	if cannotBeOver(total-voted, threshold, yes, no) { // draw
-		return result, ballot.VotingEXP, true
-	}
-
-	return result, ballot.VotingNOTYET, false
-}
+	votingHole, ended := policy.Decide(tally, state)
 
-func cannotBeOver(remain, threshold, yes, no int) bool {
-	return remain+yes < threshold && remain+no < threshold
+	return result, votingHole, ended
 }
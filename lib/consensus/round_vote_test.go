@@ -0,0 +1,75 @@
+package consensus
+
+import (
+	"testing"
+
+	logging "github.com/inconshreveable/log15"
+	"github.com/stretchr/testify/require"
+
+	"boscoin.io/sebak/lib/ballot"
+)
+
+func TestRoundVoteDelegatedWeight(t *testing.T) {
+	policy, err := NewStakeVotingThresholdPolicy(66, 66)
+	require.Nil(t, err)
+	require.Nil(t, policy.SetValidators(4))
+
+	rv := &RoundVote{SIGN: RoundVoteResult{}, ACCEPT: RoundVoteResult{}}
+	rv.ACCEPT["n0"] = ballot.VotingYES
+	rv.ACCEPT["n1"] = ballot.VotingYES
+
+	// 2 of 4 validators can not reach the threshold
+	_, _, ended := rv.CanGetVotingResult(policy, ballot.StateACCEPT, logging.New())
+	require.False(t, ended)
+
+	// n0 gets the weight of 6 delegators; total weight is 6 and threshold is 4
+	policy.SetDelegatedWeights(map[string]uint64{"n0": 6})
+	require.Equal(t, 6, policy.Weight("n0"))
+	require.Equal(t, 0, policy.Weight("n1"))
+	require.Equal(t, 6, policy.TotalWeight())
+	require.Equal(t, 4, policy.RequiredCount(ballot.StateACCEPT))
+
+	_, hole, ended := rv.CanGetVotingResult(policy, ballot.StateACCEPT, logging.New())
+	require.True(t, ended)
+	require.Equal(t, ballot.VotingYES, hole)
+
+	// the default policy does not count the delegated weight
+	isaac, err := NewDefaultVotingThresholdPolicy(66, 66)
+	require.Nil(t, err)
+	require.Nil(t, isaac.SetValidators(4))
+	isaac.SetDelegatedWeights(map[string]uint64{"n0": 6})
+
+	_, _, ended = rv.CanGetVotingResult(isaac, ballot.StateACCEPT, logging.New())
+	require.False(t, ended)
+}
+
+func TestRoundVoteProposerWeight(t *testing.T) {
+	policy, err := NewStakeVotingThresholdPolicy(66, 66)
+	require.Nil(t, err)
+	require.Nil(t, policy.SetValidators(4))
+	policy.SetDelegatedWeights(map[string]uint64{"n0": 60, "n1": 10, "n2": 20, "n3": 10})
+	require.Equal(t, 66, policy.RequiredCount(ballot.StateSIGN))
+
+	// the proposer, n0, is counted as VotingYES by its weight
+	rv := &RoundVote{SIGN: RoundVoteResult{}, ACCEPT: RoundVoteResult{}, proposer: "n0"}
+	rv.SIGN["n1"] = ballot.VotingYES
+
+	_, hole, ended := rv.CanGetVotingResult(policy, ballot.StateSIGN, logging.New())
+	require.True(t, ended)
+	require.Equal(t, ballot.VotingYES, hole)
+
+	// the proposer with low weight can not make the agreement
+	rv = &RoundVote{SIGN: RoundVoteResult{}, ACCEPT: RoundVoteResult{}, proposer: "n1"}
+	rv.SIGN["n3"] = ballot.VotingYES
+	rv.SIGN["n2"] = ballot.VotingYES
+
+	_, _, ended = rv.CanGetVotingResult(policy, ballot.StateSIGN, logging.New())
+	require.False(t, ended)
+
+	// the proposer is not counted in ACCEPT state
+	rv = &RoundVote{SIGN: RoundVoteResult{}, ACCEPT: RoundVoteResult{}, proposer: "n0"}
+	rv.ACCEPT["n1"] = ballot.VotingYES
+
+	_, _, ended = rv.CanGetVotingResult(policy, ballot.StateACCEPT, logging.New())
+	require.False(t, ended)
+}
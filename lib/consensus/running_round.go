@@ -15,6 +15,7 @@ type RunningRound struct {
 	Proposer     string                              // LocalNode's `Proposer`
 	Transactions map[ /* Proposer */ string][]string /* Transaction.Hash */
 	Voted        map[ /* Proposer */ string]*RoundVote
+	Ballots      map[ /* Proposer */ string][]ballot.Ballot // received ballots
 }
 
 func NewRunningRound(proposer string, ballot ballot.Ballot) (*RunningRound, error) {
@@ -32,9 +33,16 @@ func NewRunningRound(proposer string, ballot ballot.Ballot) (*RunningRound, erro
 		Proposer:     proposer,
 		Transactions: transactions,
 		Voted:        voted,
+		Ballots:      newReceivedBallots(ballot),
 	}, nil
 }
 
+func newReceivedBallots(b ballot.Ballot) map[string][]ballot.Ballot {
+	return map[string][]ballot.Ballot{
+		b.Proposer(): []ballot.Ballot{b},
+	}
+}
+
 func (rr *RunningRound) RoundVote(proposer string) (rv *RoundVote, err error) {
 	var found bool
 	rv, found = rr.Voted[proposer]
@@ -64,4 +72,31 @@ func (rr *RunningRound) Vote(ballot ballot.Ballot) {
 	} else {
 		rr.Voted[ballot.Proposer()].Vote(ballot)
 	}
+	rr.appendBallot(ballot)
+}
+
+// appendBallot keeps the received ballot; the ballot of the same source and
+// state replaces the previous one, so one ballot is persisted by source and
+// state.
+func (rr *RunningRound) appendBallot(b ballot.Ballot) {
+	ballots := rr.Ballots[b.Proposer()]
+	for i, received := range ballots {
+		if received.Source() == b.Source() && received.State() == b.State() {
+			ballots[i] = b
+			return
+		}
+	}
+
+	rr.Ballots[b.Proposer()] = append(ballots, b)
+}
+
+// ReceivedBallots returns the ballots received for the proposer.
+func (rr *RunningRound) ReceivedBallots(proposer string) []ballot.Ballot {
+	rr.RLock()
+	defer rr.RUnlock()
+
+	ballots := make([]ballot.Ballot, len(rr.Ballots[proposer]))
+	copy(ballots, rr.Ballots[proposer])
+
+	return ballots
 }
@@ -0,0 +1,41 @@
+package consensus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"boscoin.io/sebak/lib/ballot"
+	"boscoin.io/sebak/lib/consensus/round"
+)
+
+func TestRunningRoundReceivedBallots(t *testing.T) {
+	proposed := ballot.NewBallot("n0", round.Round{Number: 0, BlockHeight: 1}, []string{})
+	proposed.SetVote(ballot.StateSIGN, ballot.VotingYES)
+
+	rr, err := NewRunningRound("n0", *proposed)
+	require.Nil(t, err)
+
+	vote := func(source string, state ballot.State, hole ballot.VotingHole) {
+		b := *proposed
+		b.SetSource(source)
+		b.SetVote(state, hole)
+		rr.Vote(b)
+	}
+
+	vote("n1", ballot.StateSIGN, ballot.VotingYES)
+	vote("n1", ballot.StateACCEPT, ballot.VotingYES)
+
+	// the same ballot is received again and the vote of same state is changed
+	vote("n1", ballot.StateSIGN, ballot.VotingYES)
+	vote("n1", ballot.StateACCEPT, ballot.VotingNO)
+
+	ballots := rr.ReceivedBallots("n0")
+	require.Equal(t, 3, len(ballots))
+	require.Equal(t, "n0", ballots[0].Source())
+	require.Equal(t, "n1", ballots[1].Source())
+	require.Equal(t, ballot.StateSIGN, ballots[1].State())
+	require.Equal(t, "n1", ballots[2].Source())
+	require.Equal(t, ballot.StateACCEPT, ballots[2].State())
+	require.Equal(t, ballot.VotingNO, ballots[2].Vote())
+}
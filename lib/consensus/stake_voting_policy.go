@@ -0,0 +1,97 @@
+package consensus
+
+import (
+	"encoding/json"
+
+	"boscoin.io/sebak/lib/ballot"
+)
+
+// StakeVotingThresholdPolicy counts the votes only by the stake, which is
+// staked by the validators or delegated to them; unlike
+// `ISAACVotingThresholdPolicy`, the validator does not have the base weight,
+// so the validator without stake can not affect the voting result. If nothing
+// is staked, for example on the new network, no validator has the weight, so
+// it falls back to `ISAACVotingThresholdPolicy`, one validator one vote.
+type StakeVotingThresholdPolicy struct {
+	*ISAACVotingThresholdPolicy
+}
+
+// hasStake checks anything is staked. The caller must hold the lock.
+func (vt *StakeVotingThresholdPolicy) hasStake() bool {
+	return vt.totalStakeWeight() > 0
+}
+
+// Weight returns the stake of validator with the stake delegated to it.
+func (vt *StakeVotingThresholdPolicy) Weight(address string) int {
+	vt.RLock()
+	defer vt.RUnlock()
+
+	if !vt.hasStake() {
+		return 1
+	}
+
+	return vt.stakeWeight(address)
+}
+
+// TotalWeight returns the sum of the stakes of all the validators.
+func (vt *StakeVotingThresholdPolicy) TotalWeight() int {
+	vt.RLock()
+	defer vt.RUnlock()
+
+	if !vt.hasStake() {
+		return vt.validators
+	}
+
+	return vt.totalStakeWeight()
+}
+
+// RequiredCount returns the threshold by the percentage of the total stake.
+func (vt *StakeVotingThresholdPolicy) RequiredCount(state ballot.State) int {
+	vt.RLock()
+	hasStake := vt.hasStake()
+	vt.RUnlock()
+
+	if !hasStake {
+		return vt.ISAACVotingThresholdPolicy.RequiredCount(state)
+	}
+
+	var t int
+	switch state {
+	case ballot.StateSIGN:
+		t = vt.sign
+	case ballot.StateACCEPT:
+		t = vt.accept
+	}
+
+	return requiredCount(vt.TotalWeight(), t)
+}
+
+func (vt *StakeVotingThresholdPolicy) Decide(tally ballot.VoteTally, state ballot.State) (ballot.VotingHole, bool) {
+	return decide(tally, state, vt.RequiredCount(state), vt.TotalWeight())
+}
+
+func (vt *StakeVotingThresholdPolicy) MarshalJSON() ([]byte, error) {
+	vt.RLock()
+	defer vt.RUnlock()
+
+	return json.Marshal(map[string]interface{}{
+		"policy":     "stake",
+		"sign":       vt.sign,
+		"accept":     vt.accept,
+		"validators": vt.validators,
+		"connected":  vt.connected,
+		"delegated":  vt.delegated,
+		"staked":     vt.staked,
+	})
+}
+
+func NewStakeVotingThresholdPolicy(sign, accept int) (vt *StakeVotingThresholdPolicy, err error) {
+	var p *ISAACVotingThresholdPolicy
+	if p, err = NewDefaultVotingThresholdPolicy(sign, accept); err != nil {
+		return
+	}
+
+	vt = &StakeVotingThresholdPolicy{ISAACVotingThresholdPolicy: p}
+
+	return
+}
@@ -9,6 +9,10 @@ import (
 	"boscoin.io/sebak/lib/error"
 )
 
+// ISAACVotingThresholdPolicy counts the votes by the number of validators;
+// every validator has one vote. The staked and delegated weights are kept,
+// but they are counted only by `StakeVotingThresholdPolicy`, which must be
+// chosen explicitly.
 type ISAACVotingThresholdPolicy struct {
 	sync.RWMutex
 
@@ -17,6 +21,13 @@ type ISAACVotingThresholdPolicy struct {
 
 	validators int
 	connected  int
+
+	// delegated is the weight delegated to validator by the accounts; see
+	// `transaction.OperationBodyDelegate`
+	delegated map[string]uint64
+	// staked is the weight of the stake of validator itself; see
+	// `SetValidatorWeights`
+	staked map[string]uint64
 }
 
 func (vt *ISAACVotingThresholdPolicy) Validators() int {
@@ -53,7 +64,74 @@ func (vt *ISAACVotingThresholdPolicy) SetConnected(n int) error {
 	return nil
 }
 
-func (vt *ISAACVotingThresholdPolicy) Threshold(state ballot.State) int {
+// Weight returns the voting weight of validator; every validator has `1`.
+func (vt *ISAACVotingThresholdPolicy) Weight(address string) int {
+	return 1
+}
+
+// TotalWeight returns the number of validators.
+func (vt *ISAACVotingThresholdPolicy) TotalWeight() int {
+	vt.RLock()
+	defer vt.RUnlock()
+
+	return vt.validators
+}
+
+// stakeWeight returns the sum of the staked and delegated weights of
+// validator. The caller must hold the lock.
+func (vt *ISAACVotingThresholdPolicy) stakeWeight(address string) int {
+	return int(vt.staked[address] + vt.delegated[address])
+}
+
+// totalStakeWeight returns the sum of the staked and delegated weights of all
+// the validators. The caller must hold the lock.
+func (vt *ISAACVotingThresholdPolicy) totalStakeWeight() (total int) {
+	for _, w := range vt.staked {
+		total += int(w)
+	}
+	for _, w := range vt.delegated {
+		total += int(w)
+	}
+
+	return
+}
+
+// SetValidatorWeights sets the weights of the stakes of validators
+// themselves, the balances of their own accounts; unlike
+// `SetDelegatedWeights`, they are not from the other accounts. Both weights
+// are added to the weight of validator by `StakeVotingThresholdPolicy`; see
+// `block.GetStakedWeight`.
+func (vt *ISAACVotingThresholdPolicy) SetValidatorWeights(weights map[string]uint64) {
+	staked := map[string]uint64{}
+	for address, w := range weights {
+		if w > 0 {
+			staked[address] = w
+		}
+	}
+
+	vt.Lock()
+	defer vt.Unlock()
+
+	vt.staked = staked
+}
+
+func (vt *ISAACVotingThresholdPolicy) SetDelegatedWeights(weights map[string]uint64) {
+	delegated := map[string]uint64{}
+	for address, w := range weights {
+		if w > 0 {
+			delegated[address] = w
+		}
+	}
+
+	vt.Lock()
+	defer vt.Unlock()
+
+	vt.delegated = delegated
+}
+
+// RequiredCount returns the threshold by the percentage of the number of
+// validators.
+func (vt *ISAACVotingThresholdPolicy) RequiredCount(state ballot.State) int {
 	var t int
 	switch state {
 	case ballot.StateSIGN:
@@ -62,19 +140,11 @@ func (vt *ISAACVotingThresholdPolicy) Threshold(state ballot.State) int {
 		t = vt.accept
 	}
 
-	v := float64(vt.validators) * (float64(t) / float64(100))
-	threshold := int(math.Ceil(v))
-
-	// in SIGN state, proposer assumes to say VotingYES
-	if state == ballot.StateSIGN {
-		threshold = threshold - 1
-	}
-
-	if threshold > 0 {
-		return threshold
-	}
+	return requiredCount(vt.TotalWeight(), t)
+}
 
-	return 0
+func (vt *ISAACVotingThresholdPolicy) Decide(tally ballot.VoteTally, state ballot.State) (ballot.VotingHole, bool) {
+	return decide(tally, state, vt.RequiredCount(state), vt.TotalWeight())
 }
 
 func (vt *ISAACVotingThresholdPolicy) MarshalJSON() ([]byte, error) {
@@ -86,9 +156,46 @@ func (vt *ISAACVotingThresholdPolicy) MarshalJSON() ([]byte, error) {
 		"accept":     vt.accept,
 		"validators": vt.validators,
 		"connected":  vt.connected,
+		"delegated":  vt.delegated,
+		"staked":     vt.staked,
 	})
 }
 
+// requiredCount returns the ceiling of the `percent` of `total`.
+func requiredCount(total, percent int) int {
+	v := float64(total) * (float64(percent) / float64(100))
+	return int(math.Ceil(v))
+}
+
+// decide is the common voting rule of the percentage based policies; in SIGN
+// state, the weight of the proposer is already counted as `VotingYES` by
+// `RoundVote`. If neither `VotingYES` nor `VotingNO` can reach the threshold
+// with the remaining votes, the result is draw, `VotingEXP`.
+func decide(tally ballot.VoteTally, state ballot.State, threshold, total int) (ballot.VotingHole, bool) {
+	if threshold < 1 || tally.Voted() < threshold {
+		return ballot.VotingNOTYET, false
+	}
+
+	if state == ballot.StateSIGN || state == ballot.StateACCEPT {
+		if tally.Yes >= threshold {
+			return ballot.VotingYES, true
+		} else if tally.No >= threshold {
+			return ballot.VotingNO, true
+		}
+	}
+
+	// check draw!
+	if cannotBeOver(total-tally.Voted(), threshold, tally.Yes, tally.No) {
+		return ballot.VotingEXP, true
+	}
+
+	return ballot.VotingNOTYET, false
+}
+
+func cannotBeOver(remain, threshold, yes, no int) bool {
+	return remain+yes < threshold && remain+no < threshold
+}
+
 func NewDefaultVotingThresholdPolicy(sign, accept int) (vt *ISAACVotingThresholdPolicy, err error) {
 	if sign <= 0 || accept <= 0 {
 		err = errors.ErrorInvalidVotingThresholdPolicy
@@ -103,7 +210,27 @@ func NewDefaultVotingThresholdPolicy(sign, accept int) (vt *ISAACVotingThreshold
 		sign:       sign,
 		accept:     accept,
 		validators: 0,
+		delegated:  map[string]uint64{},
+		staked:     map[string]uint64{},
 	}
 
 	return
 }
+
+const (
+	VotingPolicyISAAC string = "isaac"
+	VotingPolicyStake string = "stake"
+)
+
+// NewVotingThresholdPolicy creates the `ballot.VotingThresholdPolicy` by
+// name; `VotingPolicyISAAC` or `VotingPolicyStake`.
+func NewVotingThresholdPolicy(name string, sign, accept int) (ballot.VotingThresholdPolicy, error) {
+	switch name {
+	case VotingPolicyISAAC:
+		return NewDefaultVotingThresholdPolicy(sign, accept)
+	case VotingPolicyStake:
+		return NewStakeVotingThresholdPolicy(sign, accept)
+	default:
+		return nil, errors.ErrorInvalidVotingThresholdPolicy
+	}
+}
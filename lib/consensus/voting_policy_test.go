@@ -0,0 +1,169 @@
+package consensus
+
+import (
+	"testing"
+
+	logging "github.com/inconshreveable/log15"
+	"github.com/stretchr/testify/require"
+
+	"boscoin.io/sebak/lib/ballot"
+)
+
+func TestISAACVotingThresholdPolicyDecide(t *testing.T) {
+	policy, err := NewDefaultVotingThresholdPolicy(66, 66)
+	require.Nil(t, err)
+	require.Nil(t, policy.SetValidators(4))
+
+	require.Equal(t, 3, policy.RequiredCount(ballot.StateSIGN))
+	require.Equal(t, 3, policy.RequiredCount(ballot.StateACCEPT))
+
+	{ // not enough votes
+		hole, ended := policy.Decide(ballot.VoteTally{Yes: 2}, ballot.StateACCEPT)
+		require.False(t, ended)
+		require.Equal(t, ballot.VotingNOTYET, hole)
+	}
+
+	{ // agreed
+		hole, ended := policy.Decide(ballot.VoteTally{Yes: 3}, ballot.StateACCEPT)
+		require.True(t, ended)
+		require.Equal(t, ballot.VotingYES, hole)
+
+		hole, ended = policy.Decide(ballot.VoteTally{Yes: 3}, ballot.StateSIGN)
+		require.True(t, ended)
+		require.Equal(t, ballot.VotingYES, hole)
+	}
+
+	{ // disagreed
+		hole, ended := policy.Decide(ballot.VoteTally{No: 2}, ballot.StateSIGN)
+		require.False(t, ended)
+		require.Equal(t, ballot.VotingNOTYET, hole)
+
+		hole, ended = policy.Decide(ballot.VoteTally{No: 3}, ballot.StateSIGN)
+		require.True(t, ended)
+		require.Equal(t, ballot.VotingNO, hole)
+	}
+
+	{ // draw
+		hole, ended := policy.Decide(ballot.VoteTally{Yes: 2, No: 2}, ballot.StateACCEPT)
+		require.True(t, ended)
+		require.Equal(t, ballot.VotingEXP, hole)
+	}
+}
+
+func TestStakeVotingThresholdPolicyDecide(t *testing.T) {
+	policy, err := NewStakeVotingThresholdPolicy(66, 66)
+	require.Nil(t, err)
+	require.Nil(t, policy.SetValidators(4))
+
+	{ // nothing is staked, so every validator has the same weight
+		require.Equal(t, 1, policy.Weight("n0"))
+		require.Equal(t, 4, policy.TotalWeight())
+		require.Equal(t, 3, policy.RequiredCount(ballot.StateSIGN))
+		require.Equal(t, 3, policy.RequiredCount(ballot.StateACCEPT))
+
+		hole, ended := policy.Decide(ballot.VoteTally{Yes: 3}, ballot.StateACCEPT)
+		require.True(t, ended)
+		require.Equal(t, ballot.VotingYES, hole)
+
+		_, ended = policy.Decide(ballot.VoteTally{Yes: 2}, ballot.StateACCEPT)
+		require.False(t, ended)
+	}
+
+	policy.SetDelegatedWeights(map[string]uint64{"n0": 70, "n1": 20, "n2": 10})
+	require.Equal(t, 70, policy.Weight("n0"))
+	require.Equal(t, 0, policy.Weight("n3"))
+	require.Equal(t, 100, policy.TotalWeight())
+	require.Equal(t, 66, policy.RequiredCount(ballot.StateSIGN))
+	require.Equal(t, 66, policy.RequiredCount(ballot.StateACCEPT))
+
+	{ // 3 of 4 validators vote, but they do not have enough stake
+		hole, ended := policy.Decide(ballot.VoteTally{Yes: 30}, ballot.StateACCEPT)
+		require.False(t, ended)
+		require.Equal(t, ballot.VotingNOTYET, hole)
+	}
+
+	{ // 1 validator has enough stake
+		hole, ended := policy.Decide(ballot.VoteTally{Yes: 70}, ballot.StateACCEPT)
+		require.True(t, ended)
+		require.Equal(t, ballot.VotingYES, hole)
+	}
+
+	{ // draw; the remaining stake can not make the agreement
+		hole, ended := policy.Decide(ballot.VoteTally{Yes: 20, No: 60, Expired: 10}, ballot.StateACCEPT)
+		require.False(t, ended)
+		require.Equal(t, ballot.VotingNOTYET, hole)
+
+		hole, ended = policy.Decide(ballot.VoteTally{Yes: 30, No: 30, Expired: 10}, ballot.StateACCEPT)
+		require.True(t, ended)
+		require.Equal(t, ballot.VotingEXP, hole)
+	}
+}
+
+func TestRoundVoteStakeVotingThresholdPolicy(t *testing.T) {
+	policy, err := NewVotingThresholdPolicy(VotingPolicyStake, 66, 66)
+	require.Nil(t, err)
+	require.Nil(t, policy.SetValidators(4))
+	policy.SetDelegatedWeights(map[string]uint64{"n0": 70, "n1": 30})
+
+	rv := &RoundVote{SIGN: RoundVoteResult{}, ACCEPT: RoundVoteResult{}}
+	rv.ACCEPT["n1"] = ballot.VotingYES
+	rv.ACCEPT["n2"] = ballot.VotingYES
+	rv.ACCEPT["n3"] = ballot.VotingYES
+
+	_, _, ended := rv.CanGetVotingResult(policy, ballot.StateACCEPT, logging.New())
+	require.False(t, ended)
+
+	rv.ACCEPT["n0"] = ballot.VotingYES
+	_, hole, ended := rv.CanGetVotingResult(policy, ballot.StateACCEPT, logging.New())
+	require.True(t, ended)
+	require.Equal(t, ballot.VotingYES, hole)
+
+	_, err = NewVotingThresholdPolicy("findme", 66, 66)
+	require.NotNil(t, err)
+}
+
+func TestVotingThresholdPolicyValidatorWeights(t *testing.T) {
+	policy, err := NewVotingThresholdPolicy(VotingPolicyStake, 66, 66)
+	require.Nil(t, err)
+	require.Nil(t, policy.SetValidators(5))
+
+	// n0 and n1 have the most of stake; n4 has the delegated stake also
+	policy.SetValidatorWeights(map[string]uint64{"n0": 40, "n1": 35, "n2": 5, "n3": 5, "n4": 5})
+	policy.SetDelegatedWeights(map[string]uint64{"n4": 10})
+	require.Equal(t, 40, policy.Weight("n0"))
+	require.Equal(t, 15, policy.Weight("n4"))
+	require.Equal(t, 100, policy.TotalWeight())
+	require.Equal(t, 66, policy.RequiredCount(ballot.StateACCEPT))
+
+	{ // majority of the validators with low stake can not agree
+		rv := &RoundVote{SIGN: RoundVoteResult{}, ACCEPT: RoundVoteResult{}}
+		rv.ACCEPT["n2"] = ballot.VotingYES
+		rv.ACCEPT["n3"] = ballot.VotingYES
+		rv.ACCEPT["n4"] = ballot.VotingYES
+
+		_, _, ended := rv.CanGetVotingResult(policy, ballot.StateACCEPT, logging.New())
+		require.False(t, ended)
+	}
+
+	{ // minority of the validators with high stake can agree
+		rv := &RoundVote{SIGN: RoundVoteResult{}, ACCEPT: RoundVoteResult{}}
+		rv.ACCEPT["n0"] = ballot.VotingYES
+		rv.ACCEPT["n1"] = ballot.VotingYES
+
+		_, hole, ended := rv.CanGetVotingResult(policy, ballot.StateACCEPT, logging.New())
+		require.True(t, ended)
+		require.Equal(t, ballot.VotingYES, hole)
+	}
+
+	{ // isaac policy counts one vote for each validator regardless of stake
+		isaac, err := NewVotingThresholdPolicy(VotingPolicyISAAC, 66, 66)
+		require.Nil(t, err)
+		require.Nil(t, isaac.SetValidators(2))
+
+		isaac.SetValidatorWeights(map[string]uint64{"n0": 3, "n1": 0})
+		isaac.SetDelegatedWeights(map[string]uint64{"n1": 5})
+		require.Equal(t, 1, isaac.Weight("n0"))
+		require.Equal(t, 1, isaac.Weight("n1"))
+		require.Equal(t, 2, isaac.TotalWeight())
+	}
+}
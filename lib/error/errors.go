@@ -61,4 +61,53 @@ var (
 	ErrorFrozenAccountCreationWholeUnit       = NewError(154, "frozen account balance must be a whole number of units (10k)")
 	ErrorFrozenAccountMustWithdrawEverything  = NewError(155, "frozen account can only withdraw the full amount (minus tx fee)")
 	ErrorInsufficientAmountNewAccount         = NewError(156, "insufficient amount for new account")
+	ErrorTransactionAmountOverflow            = NewError(157, "total amount of transaction overflows")
+	ErrorInvalidValidatorsConfig              = NewError(158, "invalid validators config")
+	ErrorTooManyOperations                    = NewError(159, "too many operations; stopped decoding")
+	ErrorTooManyTransactions                  = NewError(160, "too many transactions; stopped decoding")
+	ErrorOverMaxSupply                        = NewError(161, "total supply is over max supply")
+	ErrorRequestBodyTooLarge                  = NewError(162, "request body too large")
+	ErrorSupplyInvariantViolated              = NewError(163, "total supply is not conserved by block")
+	ErrorAccountDataTooLarge                  = NewError(164, "account data is over the limit")
+	ErrorInvalidAccountData                   = NewError(165, "invalid account data")
+	ErrorAccountDataNotOwner                  = NewError(166, "account data can be set only by the owner")
+	ErrorTransactionReplacementUnderpriced    = NewError(167, "fee of replacement transaction is not enough")
+	ErrorSelfDelegation                       = NewError(168, "account can not delegate to itself")
+	ErrorReceiveChannelFull                   = NewError(169, "too many messages are waiting to be processed")
+	ErrorTransactionUnknownOperation          = NewError(170, "transaction has unknown operation type")
+	ErrorReceiveChannelClosed                 = NewError(171, "network is stopped; message can not be received")
+	ErrorBlockAccountLinkedByFrozen           = NewError(172, "account is linked by the frozen account")
+	ErrorInvalidLogLevel                      = NewError(173, "invalid log level")
+	ErrorSelfPayment                          = NewError(174, "account can not pay to itself")
+	ErrorBlockForkDetected                    = NewError(175, "different block already exists at the same height")
+	ErrorGenesisKeypairMismatch               = NewError(176, "keypair does not match with the genesis account")
+	ErrorInvalidTimeRange                     = NewError(177, "invalid time range")
+	ErrorBlockTransactionsRootMismatch        = NewError(178, "`TransactionsRoot` does not match with the transactions of block")
+	ErrorBlockTotalTxsMismatch                = NewError(179, "`TotalTxs` does not match with the number of transactions of block")
+	ErrorAccountStorageBudgetExceeded         = NewError(180, "account storage budget is exceeded")
+	ErrorTransactionAcceptancePaused          = NewError(181, "transaction acceptance is paused")
+	ErrorMalformedJSON                        = NewError(182, "malformed json")
+	ErrorReadOnlyStorage                      = NewError(183, "storage is read-only")
+	ErrorSpendLimitNotOwner                   = NewError(184, "only the owner can set the spend limit of account")
+	ErrorSpendLimitExceeded                   = NewError(185, "spend limit of account is exceeded")
+	ErrorBlockPrevHashMismatch                = NewError(186, "`PrevBlockHash` does not match with the previous block")
+	ErrorMalformedSignature                   = NewError(187, "malformed signature")
+	ErrorInvalidBlockBinaryFrame              = NewError(188, "invalid block binary frame")
+	ErrorValidatorSetNotFound                 = NewError(189, "validator set is not recorded at the height")
+	ErrorInvalidEndpoint                      = NewError(190, "invalid endpoint")
+	ErrorNotValidator                         = NewError(191, "source of transaction is not a validator")
+	ErrorAdminUnauthorized                    = NewError(192, "admin token is missing or does not match")
+	ErrorResyncBelowCheckpoint                = NewError(193, "blocks below the finalized checkpoint can not be resynced")
+	ErrorBlockNotAncestor                     = NewError(194, "block is not the ancestor of the other block")
+	ErrorBlocksRangeTooLarge                  = NewError(195, "range of blocks is over the limit")
+	ErrorInvalidGenesisConfirmedTime          = NewError(196, "confirmed time of genesis block is not ISO8601")
+	ErrorBlockAccountStatsUnderZero           = NewError(197, "total of accounts will be under zero")
+	ErrorUnknownCursor                        = NewError(198, "cursor is not found")
+	ErrorUnknownTransactionVersion            = NewError(199, "unknown version of transaction")
+	ErrorDelegateNotValidator                 = NewError(200, "delegate is not a validator")
+	ErrorTooManyValidators                    = NewError(201, "too many validators")
+	ErrorBlockNotMerkleRoot                   = NewError(202, "transactions root of block is not Merkle root")
+	ErrorBlockAccountHasBalance               = NewError(203, "account still has balance")
+	ErrorBlockUndoNotFound                    = NewError(204, "undo record of block not found")
+	ErrorResyncInProgress                     = NewError(205, "blocks are already being resynced")
 )
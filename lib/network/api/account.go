@@ -18,13 +18,6 @@ func (api NetworkHandlerAPI) GetAccountHandler(w http.ResponseWriter, r *http.Re
 	address := vars["id"]
 
 	readFunc := func() (payload interface{}, err error) {
-		found, err := block.ExistsBlockAccount(api.storage, address)
-		if err != nil {
-			return nil, err
-		}
-		if !found {
-			return nil, errors.ErrorBlockAccountDoesNotExists
-		}
 		ba, err := block.GetBlockAccount(api.storage, address)
 		if err != nil {
 			return nil, err
@@ -54,3 +47,85 @@ func (api NetworkHandlerAPI) GetAccountHandler(w http.ResponseWriter, r *http.Re
 		httputils.WriteJSONError(w, err)
 	}
 }
+
+// MaxAccountsSequenceAddresses is the maximum number of addresses in one
+// request of `GetAccountsSequenceHandler`.
+const MaxAccountsSequenceAddresses = 100
+
+// AccountSequence is the current sequenceID of account; the next transaction
+// of account should have it.
+type AccountSequence struct {
+	SequenceID uint64 `json:"sequenceID"`
+}
+
+// GetAccountSequenceHandler returns the current sequenceID of account without
+// the other fields of account.
+func (api NetworkHandlerAPI) GetAccountSequenceHandler(w http.ResponseWriter, r *http.Request) {
+	address := mux.Vars(r)["id"]
+
+	ba, err := block.GetBlockAccount(api.storage, address)
+	if err == errors.ErrorBlockAccountDoesNotExists {
+		httputils.WriteJSON(w, http.StatusNotFound, err)
+		return
+	} else if err != nil {
+		httputils.WriteJSONError(w, err)
+		return
+	}
+
+	if err = httputils.WriteJSON(w, 200, AccountSequence{SequenceID: ba.SequenceID}); err != nil {
+		httputils.WriteJSONError(w, err)
+	}
+}
+
+// GetAccountSignersHandler returns the signers of account with their weights
+// and the threshold; see `block.BlockAccount.GetSigners()`.
+func (api NetworkHandlerAPI) GetAccountSignersHandler(w http.ResponseWriter, r *http.Request) {
+	address := mux.Vars(r)["id"]
+
+	ba, err := block.GetBlockAccount(api.storage, address)
+	if err == errors.ErrorBlockAccountDoesNotExists {
+		httputils.WriteJSON(w, http.StatusNotFound, err)
+		return
+	} else if err != nil {
+		httputils.WriteJSONError(w, err)
+		return
+	}
+
+	if err = httputils.WriteJSON(w, 200, ba.GetSigners()); err != nil {
+		httputils.WriteJSONError(w, err)
+	}
+}
+
+// GetAccountsSequenceHandler returns the current sequenceIDs of the accounts
+// by the `address` queries, like `?address=<address>&address=<address>`. The
+// result is the json object by address; for the nonexistent account, it is
+// `null`.
+func (api NetworkHandlerAPI) GetAccountsSequenceHandler(w http.ResponseWriter, r *http.Request) {
+	addresses := r.URL.Query()["address"]
+	if len(addresses) < 1 || len(addresses) > MaxAccountsSequenceAddresses {
+		http.Error(w, errors.ErrorInvalidQueryString.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sequences := map[string]*AccountSequence{}
+	for _, address := range addresses {
+		if !api.accountFilter.MightHaveAccount(address) {
+			sequences[address] = nil
+			continue
+		}
+
+		ba, err := block.GetBlockAccount(api.storage, address)
+		if err == errors.ErrorBlockAccountDoesNotExists {
+			sequences[address] = nil
+			continue
+		} else if err != nil {
+			httputils.WriteJSONError(w, err)
+			return
+		}
+		sequences[address] = &AccountSequence{SequenceID: ba.SequenceID}
+	}
+
+	if err := httputils.WriteJSON(w, 200, sequences); err != nil {
+		httputils.WriteJSONError(w, err)
+	}
+}
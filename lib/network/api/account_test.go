@@ -3,12 +3,15 @@ package api
 import (
 	"bufio"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
+	"net/http"
 	"strings"
 	"sync"
 	"testing"
 
 	"boscoin.io/sebak/lib/block"
+	"boscoin.io/sebak/lib/common"
 	"boscoin.io/sebak/lib/common/observer"
 	"boscoin.io/sebak/lib/error"
 	"boscoin.io/sebak/lib/network/httputils"
@@ -23,7 +26,7 @@ func TestGetAccountHandler(t *testing.T) {
 	defer ts.Close()
 	// Make Dummy BlockAccount
 	ba := block.TestMakeBlockAccount()
-	ba.Save(storage)
+	ba.Save(storage, nil)
 	{
 		// Do a Request
 		url := strings.Replace(GetAccountHandlerPattern, "{id}", ba.Address, -1)
@@ -63,7 +66,7 @@ func TestGetAccountHandlerStream(t *testing.T) {
 				}
 				observer.BlockAccountObserver.RUnlock()
 			}
-			ba.Save(storage)
+			ba.Save(storage, nil)
 			wg.Done()
 		}()
 	}
@@ -113,3 +116,122 @@ func TestGetNonExistentAccountHandler(t *testing.T) {
 		require.Equal(t, pByte, readByte)
 	}
 }
+
+func TestGetAccountSequenceHandler(t *testing.T) {
+	ts, storage, err := prepareAPIServer()
+	require.Nil(t, err)
+	defer storage.Close()
+	defer ts.Close()
+
+	ba := block.TestMakeBlockAccount()
+	require.Nil(t, ba.Save(storage, nil))
+
+	get := func(url string) (int, []byte) {
+		resp, err := http.Get(ts.URL + url)
+		require.Nil(t, err)
+		defer resp.Body.Close()
+
+		b, err := ioutil.ReadAll(resp.Body)
+		require.Nil(t, err)
+		return resp.StatusCode, b
+	}
+
+	getSequence := func(address string) uint64 {
+		status, b := get(strings.Replace(GetAccountSequenceHandlerPattern, "{id}", address, -1))
+		require.Equal(t, http.StatusOK, status)
+
+		var sequence AccountSequence
+		require.Nil(t, json.Unmarshal(b, &sequence))
+		return sequence.SequenceID
+	}
+
+	before := getSequence(ba.Address)
+	require.Equal(t, ba.SequenceID, before)
+
+	{ // the source account of payment is updated like `finishBallot`
+		require.Nil(t, ba.WithdrawWithSequenceID(storage, common.Amount(100), ba.SequenceID))
+		require.Nil(t, ba.Save(storage, nil))
+
+		require.Equal(t, before+1, getSequence(ba.Address))
+	}
+
+	unknown, _ := keypair.Random()
+	{ // nonexistent account
+		status, b := get(strings.Replace(GetAccountSequenceHandlerPattern, "{id}", unknown.Address(), -1))
+		require.Equal(t, http.StatusNotFound, status)
+		require.Contains(t, string(b), errors.ErrorBlockAccountDoesNotExists.Message)
+	}
+
+	{ // batch
+		status, b := get(fmt.Sprintf("%s?address=%s&address=%s", GetAccountsSequenceHandlerPattern, ba.Address, unknown.Address()))
+		require.Equal(t, http.StatusOK, status)
+
+		var sequences map[string]*AccountSequence
+		require.Nil(t, json.Unmarshal(b, &sequences))
+		require.Equal(t, 2, len(sequences))
+		require.Equal(t, before+1, sequences[ba.Address].SequenceID)
+		require.Nil(t, sequences[unknown.Address()])
+
+		status, _ = get(GetAccountsSequenceHandlerPattern)
+		require.Equal(t, http.StatusBadRequest, status)
+	}
+}
+
+func TestGetAccountSignersHandler(t *testing.T) {
+	ts, storage, err := prepareAPIServer()
+	require.Nil(t, err)
+	defer storage.Close()
+	defer ts.Close()
+
+	get := func(address string) (int, []byte) {
+		resp, err := http.Get(ts.URL + strings.Replace(GetAccountSignersHandlerPattern, "{id}", address, -1))
+		require.Nil(t, err)
+		defer resp.Body.Close()
+
+		b, err := ioutil.ReadAll(resp.Body)
+		require.Nil(t, err)
+		return resp.StatusCode, b
+	}
+
+	{ // single key; only the master key
+		ba := block.TestMakeBlockAccount()
+		require.Nil(t, ba.Save(storage, nil))
+
+		status, b := get(ba.Address)
+		require.Equal(t, http.StatusOK, status)
+
+		var signers block.AccountSigners
+		require.Nil(t, json.Unmarshal(b, &signers))
+		require.Equal(t, uint64(1), signers.Threshold)
+		require.Equal(t, []block.AccountSigner{{Address: ba.Address, Weight: 1}}, signers.Signers)
+	}
+
+	{ // multisig
+		kp0, _ := keypair.Random()
+		kp1, _ := keypair.Random()
+
+		ba := block.TestMakeBlockAccount()
+		ba.Signers = []block.AccountSigner{
+			{Address: ba.Address, Weight: 1},
+			{Address: kp0.Address(), Weight: 2},
+			{Address: kp1.Address(), Weight: 2},
+		}
+		ba.Threshold = 3
+		require.Nil(t, ba.Save(storage, nil))
+
+		status, b := get(ba.Address)
+		require.Equal(t, http.StatusOK, status)
+
+		var signers block.AccountSigners
+		require.Nil(t, json.Unmarshal(b, &signers))
+		require.Equal(t, uint64(3), signers.Threshold)
+		require.Equal(t, ba.Signers, signers.Signers)
+	}
+
+	{ // nonexistent account
+		unknown, _ := keypair.Random()
+		status, b := get(unknown.Address())
+		require.Equal(t, http.StatusNotFound, status)
+		require.Contains(t, string(b), errors.ErrorBlockAccountDoesNotExists.Message)
+	}
+}
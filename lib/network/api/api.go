@@ -11,6 +11,7 @@ import (
 	"boscoin.io/sebak/lib/network/httputils"
 	"boscoin.io/sebak/lib/node"
 	"boscoin.io/sebak/lib/storage"
+	"boscoin.io/sebak/lib/transaction"
 )
 
 const APIVersionV1 = "v1"
@@ -19,28 +20,50 @@ const APIVersionV1 = "v1"
 const (
 	GetAccountTransactionsHandlerPattern   = "/accounts/{id}/transactions"
 	GetAccountHandlerPattern               = "/accounts/{id}"
+	GetAccountSequenceHandlerPattern       = "/accounts/{id}/sequence"
+	GetAccountsSequenceHandlerPattern      = "/accounts/sequence"
 	GetAccountOperationsHandlerPattern     = "/accounts/{id}/operations"
+	GetAccountSignersHandlerPattern        = "/accounts/{id}/signers"
 	GetTransactionsHandlerPattern          = "/transactions"
+	GetRecentTransactionsHandlerPattern    = "/transactions/recent"
 	GetTransactionByHashHandlerPattern     = "/transactions/{id}"
 	GetTransactionOperationsHandlerPattern = "/transactions/{id}/operations"
+	GetTransactionProofHandlerPattern      = "/transactions/{id}/proof"
 	PostTransactionPattern                 = "/transactions"
+	PostTransactionSimulatePattern         = "/transactions/simulate"
+	PostTransactionBatchPattern            = "/transactions/batch"
+	GetStatsHandlerPattern                 = "/stats"
+	GetMempoolHandlerPattern               = "/mempool"
+	GetBlocksRangeHandlerPattern           = "/blocks/range"
+	GetBlockHandlerPattern                 = "/blocks/{id}"
+	GetBlockCreatedAccountsHandlerPattern  = "/blocks/{height}/created-accounts"
+	GetOpLogHandlerPattern                 = "/oplog"
+	GetProposerScheduleHandlerPattern      = "/node/schedule"
+	GetNodeValidatorsHandlerPattern        = "/node/validators"
+	GetNodeValidatorsStatsHandlerPattern   = "/node/validators/stats"
+	PostVerifySignaturePattern             = "/verify"
+	PostFeeEstimatePattern                 = "/fee-estimate"
 )
 
 type NetworkHandlerAPI struct {
-	localNode *node.LocalNode
-	network   network.Network
-	storage   *storage.LevelDBBackend
-	urlPrefix string
-	version   string
+	localNode       *node.LocalNode
+	network         network.Network
+	storage         *storage.LevelDBBackend
+	transactionPool *transaction.TransactionPool
+	accountFilter   *block.AccountFilter
+	urlPrefix       string
+	version         string
 }
 
-func NewNetworkHandlerAPI(localNode *node.LocalNode, network network.Network, storage *storage.LevelDBBackend, urlPrefix string) *NetworkHandlerAPI {
+func NewNetworkHandlerAPI(localNode *node.LocalNode, network network.Network, storage *storage.LevelDBBackend, transactionPool *transaction.TransactionPool, accountFilter *block.AccountFilter, urlPrefix string) *NetworkHandlerAPI {
 	return &NetworkHandlerAPI{
-		localNode: localNode,
-		network:   network,
-		storage:   storage,
-		urlPrefix: urlPrefix,
-		version:   APIVersionV1,
+		localNode:       localNode,
+		network:         network,
+		storage:         storage,
+		transactionPool: transactionPool,
+		accountFilter:   accountFilter,
+		urlPrefix:       urlPrefix,
+		version:         APIVersionV1,
 	}
 }
 
@@ -68,6 +91,9 @@ func renderEventStream(args ...interface{}) ([]byte, error) {
 	case *block.BlockTransaction:
 		r := resource.NewTransaction(v)
 		return json.Marshal(r.Resource())
+	case *block.Block:
+		r := resource.NewBlock(v)
+		return json.Marshal(r.Resource())
 	case httputils.HALResource:
 		return json.Marshal(v.Resource())
 	}
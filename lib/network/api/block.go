@@ -0,0 +1,105 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"boscoin.io/sebak/lib/block"
+	"boscoin.io/sebak/lib/error"
+	"boscoin.io/sebak/lib/network/api/resource"
+	"boscoin.io/sebak/lib/network/httputils"
+)
+
+// GetBlockHandler returns the block of the given hash. With `full=true`, the
+// block has the full transactions instead of the transaction hashes.
+func (api NetworkHandlerAPI) GetBlockHandler(w http.ResponseWriter, r *http.Request) {
+	hash := mux.Vars(r)["id"]
+
+	var payload *resource.Block
+	if r.URL.Query().Get("full") == "true" {
+		blk, txs, err := block.GetBlockWithTransactions(api.storage, hash)
+		if err != nil {
+			httputils.WriteJSONError(w, err)
+			return
+		}
+		payload = resource.NewBlockWithTransactions(&blk, txs)
+	} else {
+		found, err := block.ExistsBlock(api.storage, hash)
+		if err != nil {
+			httputils.WriteJSONError(w, err)
+			return
+		}
+		if !found {
+			httputils.WriteJSONError(w, errors.ErrorBlockNotFound)
+			return
+		}
+
+		blk, err := block.GetBlock(api.storage, hash)
+		if err != nil {
+			httputils.WriteJSONError(w, err)
+			return
+		}
+		payload = resource.NewBlock(&blk)
+	}
+
+	if err := httputils.WriteJSON(w, 200, payload); err != nil {
+		httputils.WriteJSONError(w, err)
+	}
+}
+
+// MaxBlocksRange is the maximum number of blocks returned by
+// `GetBlocksRangeHandler`.
+const MaxBlocksRange uint64 = 1000
+
+// GetBlocksRangeHandler returns the blocks from `from_hash` to `to_hash`,
+// both included, ordered by height. The blocks are the chain of `to_hash`
+// linked by `PrevBlockHash`, so `from_hash` must be it's ancestor.
+func (api NetworkHandlerAPI) GetBlocksRangeHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	fromHash, toHash := query.Get("from_hash"), query.Get("to_hash")
+	if len(fromHash) < 1 || len(toHash) < 1 {
+		http.Error(w, errors.ErrorInvalidQueryString.Error(), http.StatusBadRequest)
+		return
+	}
+
+	blocks, err := block.GetBlocksBetween(api.storage, fromHash, toHash, MaxBlocksRange)
+	if err != nil {
+		httputils.WriteJSONError(w, err)
+		return
+	}
+
+	var rs []resource.Resource
+	for i := range blocks {
+		rs = append(rs, resource.NewBlock(&blocks[i]))
+	}
+
+	list := resource.NewResourceList(rs, r.URL.String(), "", "")
+	if err := httputils.WriteJSON(w, 200, list); err != nil {
+		httputils.WriteJSONError(w, err)
+	}
+}
+
+// GetBlockCreatedAccountsHandler returns the addresses and the initial
+// balances of the accounts, which are created in the block of `height`.
+func (api NetworkHandlerAPI) GetBlockCreatedAccountsHandler(w http.ResponseWriter, r *http.Request) {
+	height, err := strconv.ParseUint(mux.Vars(r)["height"], 10, 64)
+	if err != nil {
+		http.Error(w, errors.ErrorInvalidQueryString.Error(), http.StatusBadRequest)
+		return
+	}
+
+	accounts, err := block.GetAccountsCreatedInBlock(api.storage, height)
+	if err != nil {
+		httputils.WriteJSONError(w, err)
+		return
+	}
+	if accounts == nil {
+		accounts = []block.CreatedAccount{}
+	}
+
+	if err := httputils.WriteJSON(w, 200, accounts); err != nil {
+		httputils.WriteJSONError(w, err)
+	}
+}
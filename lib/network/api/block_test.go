@@ -0,0 +1,169 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stretchr/testify/require"
+
+	"boscoin.io/sebak/lib/block"
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/consensus/round"
+)
+
+func TestGetBlockHandler(t *testing.T) {
+	ts, st, err := prepareAPIServer()
+	require.Nil(t, err)
+	defer st.Close()
+	defer ts.Close()
+
+	kp, _ := keypair.Random()
+	account := block.NewBlockAccount(kp.Address(), common.MaxSupply)
+	require.Nil(t, account.Save(st, nil))
+	genesis, err := block.MakeGenesisBlock(st, *account, networkID, kp)
+	require.Nil(t, err)
+
+	{
+		url := strings.Replace(GetBlockHandlerPattern, "{id}", genesis.Hash, -1)
+		respBody, err := request(ts, url, false)
+		require.Nil(t, err)
+		defer respBody.Close()
+
+		b, err := ioutil.ReadAll(respBody)
+		require.Nil(t, err)
+
+		var m map[string]interface{}
+		require.Nil(t, json.Unmarshal(b, &m))
+		require.Equal(t, genesis.Hash, m["hash"])
+		require.Equal(t, float64(1), m["height"])
+		require.Equal(t, float64(1), m["transaction_count"])
+		require.Equal(t, []interface{}{genesis.Transactions[0]}, m["transactions"])
+	}
+
+	{ // full transactions
+		url := strings.Replace(GetBlockHandlerPattern, "{id}", genesis.Hash, -1) + "?full=true"
+		respBody, err := request(ts, url, false)
+		require.Nil(t, err)
+		defer respBody.Close()
+
+		b, err := ioutil.ReadAll(respBody)
+		require.Nil(t, err)
+
+		var m map[string]interface{}
+		require.Nil(t, json.Unmarshal(b, &m))
+		txs := m["transactions"].([]interface{})
+		require.Equal(t, 1, len(txs))
+
+		tx := txs[0].(map[string]interface{})
+		require.Equal(t, genesis.Transactions[0], tx["hash"])
+		body := tx["body"].(map[string]interface{})
+		require.Equal(t, kp.Address(), body["B"].(map[string]interface{})["source"])
+	}
+
+	{ // unknown block
+		url := strings.Replace(GetBlockHandlerPattern, "{id}", "findme", -1)
+		resp, err := http.Get(ts.URL + url)
+		require.Nil(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	}
+}
+
+func TestGetBlockCreatedAccountsHandler(t *testing.T) {
+	ts, st, err := prepareAPIServer()
+	require.Nil(t, err)
+	defer st.Close()
+	defer ts.Close()
+
+	kp, _ := keypair.Random()
+	account := block.NewBlockAccount(kp.Address(), common.MaxSupply)
+	require.Nil(t, account.Save(st, nil))
+	_, err = block.MakeGenesisBlock(st, *account, networkID, kp)
+	require.Nil(t, err)
+
+	url := strings.Replace(GetBlockCreatedAccountsHandlerPattern, "{height}", "1", -1)
+	{ // genesis account
+		respBody, err := request(ts, url, false)
+		require.Nil(t, err)
+		defer respBody.Close()
+
+		b, err := ioutil.ReadAll(respBody)
+		require.Nil(t, err)
+
+		var accounts []block.CreatedAccount
+		require.Nil(t, json.Unmarshal(b, &accounts))
+		require.Equal(t, []block.CreatedAccount{{Address: kp.Address(), Balance: common.MaxSupply}}, accounts)
+	}
+
+	{ // unknown block
+		url := strings.Replace(GetBlockCreatedAccountsHandlerPattern, "{height}", "2", -1)
+		resp, err := http.Get(ts.URL + url)
+		require.Nil(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	}
+
+	{ // invalid height
+		url := strings.Replace(GetBlockCreatedAccountsHandlerPattern, "{height}", "showme", -1)
+		resp, err := http.Get(ts.URL + url)
+		require.Nil(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	}
+
+}
+
+func TestGetBlocksRangeHandler(t *testing.T) {
+	ts, st, err := prepareAPIServer()
+	require.Nil(t, err)
+	defer st.Close()
+	defer ts.Close()
+
+	var chain []block.Block
+	var prev block.Block
+	for i := 0; i < 4; i++ {
+		blk := block.NewBlock("", round.Round{BlockHeight: prev.Height, BlockHash: prev.Hash}, []string{}, common.NowISO8601())
+		require.Nil(t, blk.Save(st))
+		chain = append(chain, blk)
+		prev = blk
+	}
+
+	{
+		url := fmt.Sprintf("%s?from_hash=%s&to_hash=%s", GetBlocksRangeHandlerPattern, chain[1].Hash, chain[3].Hash)
+		respBody, err := request(ts, url, false)
+		require.Nil(t, err)
+		defer respBody.Close()
+
+		b, err := ioutil.ReadAll(respBody)
+		require.Nil(t, err)
+
+		var recv map[string]interface{}
+		require.Nil(t, json.Unmarshal(b, &recv))
+		records := recv["_embedded"].(map[string]interface{})["records"].([]interface{})
+		require.Equal(t, 3, len(records))
+		for i, r := range records {
+			require.Equal(t, chain[i+1].Hash, r.(map[string]interface{})["hash"])
+		}
+	}
+
+	{ // not ancestor
+		url := fmt.Sprintf("%s?from_hash=%s&to_hash=%s", GetBlocksRangeHandlerPattern, chain[3].Hash, chain[1].Hash)
+		resp, err := http.Get(ts.URL + url)
+		require.Nil(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	}
+
+	{ // missing query
+		url := fmt.Sprintf("%s?from_hash=%s", GetBlocksRangeHandlerPattern, chain[1].Hash)
+		resp, err := http.Get(ts.URL + url)
+		require.Nil(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	}
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"net/http"
+
+	"boscoin.io/sebak/lib/error"
+	"boscoin.io/sebak/lib/network/api/resource"
+	"boscoin.io/sebak/lib/network/httputils"
+	"boscoin.io/sebak/lib/storage"
+	"boscoin.io/sebak/lib/transaction"
+)
+
+// GetMempoolHandler returns the pending transactions in the transaction pool
+// by the received order, the oldest first. `source` filters the transactions
+// by source account and `cursor` is the hash of the last transaction of the
+// previous page; if the cursor is not in the pool, 400 is returned.
+func (api NetworkHandlerAPI) GetMempoolHandler(w http.ResponseWriter, r *http.Request) {
+	options, err := storage.NewDefaultListOptionsFromQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, errors.ErrorInvalidQueryString.Error(), http.StatusBadRequest)
+		return
+	}
+
+	source := r.URL.Query().Get("source")
+
+	var txs []transaction.Transaction
+	if api.transactionPool != nil {
+		txs = api.transactionPool.Pending(source)
+	}
+	if options.Reverse() {
+		for i, j := 0, len(txs)-1; i < j; i, j = i+1, j-1 {
+			txs[i], txs[j] = txs[j], txs[i]
+		}
+	}
+
+	// skip until cursor; the cursor, which is not in the pool, is rejected
+	// instead of restarting from the first
+	if cursor := string(options.Cursor()); len(cursor) > 0 {
+		found := false
+		for i, tx := range txs {
+			if tx.GetHash() == cursor {
+				txs = txs[i+1:]
+				found = true
+				break
+			}
+		}
+		if !found {
+			httputils.WriteJSONError(w, errors.ErrorUnknownCursor)
+			return
+		}
+	}
+
+	var cursor []byte
+	var rs []resource.Resource
+	for _, tx := range txs {
+		if options.Limit() > 0 && uint64(len(rs)) >= options.Limit() {
+			break
+		}
+		received, found := api.transactionPool.GetReceived(tx.GetHash())
+		if !found { // removed from pool
+			continue
+		}
+		rs = append(rs, resource.NewPendingTransaction(tx, received))
+		cursor = []byte(tx.GetHash())
+	}
+
+	self := r.URL.String()
+	next := GetMempoolHandlerPattern + "?" + options.SetCursor(cursor).SetReverse(false).Encode()
+	prev := GetMempoolHandlerPattern + "?" + options.SetReverse(true).Encode()
+	if len(source) > 0 {
+		next += "&source=" + source
+		prev += "&source=" + source
+	}
+	list := resource.NewResourceList(rs, self, next, prev)
+
+	if err := httputils.WriteJSON(w, 200, list); err != nil {
+		httputils.WriteJSONError(w, err)
+		return
+	}
+}
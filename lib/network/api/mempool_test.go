@@ -0,0 +1,131 @@
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stellar/go/keypair"
+	"github.com/stretchr/testify/require"
+
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/transaction"
+)
+
+func prepareMempoolAPIServer() (*httptest.Server, *transaction.TransactionPool) {
+	pool := transaction.NewTransactionPool()
+	apiHandler := NetworkHandlerAPI{transactionPool: pool}
+
+	router := mux.NewRouter()
+	router.HandleFunc(GetMempoolHandlerPattern, apiHandler.GetMempoolHandler).Methods("GET")
+
+	return httptest.NewServer(router), pool
+}
+
+func requestMempool(t *testing.T, ts *httptest.Server, query string) (records []map[string]interface{}) {
+	respBody, err := request(ts, GetMempoolHandlerPattern+query, false)
+	require.Nil(t, err)
+	defer respBody.Close()
+
+	b, err := ioutil.ReadAll(respBody)
+	require.Nil(t, err)
+
+	var recv map[string]interface{}
+	require.Nil(t, json.Unmarshal(b, &recv))
+
+	embedded, ok := recv["_embedded"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	items, _ := embedded["records"].([]interface{})
+	for _, item := range items {
+		records = append(records, item.(map[string]interface{}))
+	}
+
+	return
+}
+
+func TestGetMempoolHandler(t *testing.T) {
+	ts, pool := prepareMempoolAPIServer()
+	defer ts.Close()
+
+	// empty pool
+	require.Equal(t, 0, len(requestMempool(t, ts, "")))
+
+	kp, _ := keypair.Random()
+	var txs []transaction.Transaction
+	for i := 0; i < 3; i++ {
+		tx := transaction.TestMakeTransactionWithKeypair(networkID, 1, kp)
+		require.True(t, pool.Add(tx))
+		txs = append(txs, tx)
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	_, other := transaction.TestMakeTransaction(networkID, 2)
+	require.True(t, pool.Add(other))
+
+	{ // the oldest first
+		records := requestMempool(t, ts, "")
+		require.Equal(t, 4, len(records))
+		for i, tx := range txs {
+			require.Equal(t, tx.GetHash(), records[i]["hash"])
+			require.Equal(t, tx.Source(), records[i]["source"])
+			require.Equal(t, tx.B.Fee.String(), records[i]["fee"])
+		}
+		require.Equal(t, other.GetHash(), records[3]["hash"])
+		require.Equal(t, float64(2), records[3]["operation_count"])
+
+		for i := 1; i < len(records); i++ {
+			require.True(t, records[i-1]["age"].(float64) >= records[i]["age"].(float64))
+
+			previous, err := common.ParseISO8601(records[i-1]["received"].(string))
+			require.Nil(t, err)
+			received, err := common.ParseISO8601(records[i]["received"].(string))
+			require.Nil(t, err)
+			require.False(t, received.Before(previous))
+		}
+	}
+
+	{ // newest first
+		records := requestMempool(t, ts, "?reverse=true")
+		require.Equal(t, 4, len(records))
+		require.Equal(t, other.GetHash(), records[0]["hash"])
+		require.Equal(t, txs[0].GetHash(), records[3]["hash"])
+	}
+
+	{ // filter by source
+		records := requestMempool(t, ts, "?source="+other.Source())
+		require.Equal(t, 1, len(records))
+		require.Equal(t, other.GetHash(), records[0]["hash"])
+	}
+
+	{ // pagination
+		records := requestMempool(t, ts, "?limit=2")
+		require.Equal(t, 2, len(records))
+		require.Equal(t, txs[0].GetHash(), records[0]["hash"])
+		require.Equal(t, txs[1].GetHash(), records[1]["hash"])
+
+		records = requestMempool(t, ts, "?limit=2&cursor="+txs[1].GetHash())
+		require.Equal(t, 2, len(records))
+		require.Equal(t, txs[2].GetHash(), records[0]["hash"])
+		require.Equal(t, other.GetHash(), records[1]["hash"])
+	}
+
+	{ // removed from pool
+		pool.Remove(txs[0].GetHash())
+		records := requestMempool(t, ts, "")
+		require.Equal(t, 3, len(records))
+		require.Equal(t, txs[1].GetHash(), records[0]["hash"])
+	}
+
+	{ // unknown cursor
+		resp, err := http.Get(ts.URL + GetMempoolHandlerPattern + "?cursor=" + txs[0].GetHash())
+		require.Nil(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	}
+}
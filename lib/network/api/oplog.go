@@ -0,0 +1,42 @@
+package api
+
+import (
+	"net/http"
+
+	"boscoin.io/sebak/lib/block"
+	"boscoin.io/sebak/lib/error"
+	"boscoin.io/sebak/lib/network/api/resource"
+	"boscoin.io/sebak/lib/network/httputils"
+	"boscoin.io/sebak/lib/storage"
+)
+
+// GetOpLogHandler returns the operation log by the applied order.
+func (api NetworkHandlerAPI) GetOpLogHandler(w http.ResponseWriter, r *http.Request) {
+	options, err := storage.NewDefaultListOptionsFromQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, errors.ErrorInvalidQueryString.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var cursor []byte
+	var entries []resource.Resource
+	iterFunc, closeFunc := block.GetOpLog(api.storage, options)
+	for {
+		e, hasNext, c := iterFunc()
+		cursor = c
+		if !hasNext {
+			break
+		}
+		entries = append(entries, resource.NewOpLogEntry(e))
+	}
+	closeFunc()
+
+	self := r.URL.String()
+	next := GetOpLogHandlerPattern + "?" + options.SetCursor(cursor).SetReverse(false).Encode()
+	prev := GetOpLogHandlerPattern + "?" + options.SetReverse(true).Encode()
+	list := resource.NewResourceList(entries, self, next, prev)
+
+	if err := httputils.WriteJSON(w, 200, list); err != nil {
+		httputils.WriteJSONError(w, err)
+	}
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stretchr/testify/require"
+
+	"boscoin.io/sebak/lib/block"
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/transaction"
+)
+
+func TestGetOpLogHandler(t *testing.T) {
+	ts, st, err := prepareAPIServer()
+	require.Nil(t, err)
+	defer st.Close()
+	defer ts.Close()
+
+	kp, _ := keypair.Random()
+	account := block.NewBlockAccount(kp.Address(), common.MaxSupply)
+	require.Nil(t, account.Save(st, nil))
+	genesis, err := block.MakeGenesisBlock(st, *account, networkID, kp)
+	require.Nil(t, err)
+
+	_, tx := transaction.TestMakeTransaction(networkID, 3)
+	for i := range tx.B.Operations {
+		require.Nil(t, block.AppendOpLog(st, block.NewOpLogEntry(genesis.Height+1, tx, i)))
+	}
+
+	readRecords := func(url string) []interface{} {
+		respBody, err := request(ts, url, false)
+		require.Nil(t, err)
+		defer respBody.Close()
+
+		b, err := ioutil.ReadAll(respBody)
+		require.Nil(t, err)
+
+		var received map[string]interface{}
+		require.Nil(t, json.Unmarshal(b, &received))
+		return received["_embedded"].(map[string]interface{})["records"].([]interface{})
+	}
+
+	{ // genesis operation comes first
+		records := readRecords(GetOpLogHandlerPattern)
+		require.Equal(t, 4, len(records))
+		for i, r := range records {
+			require.Equal(t, float64(i+1), r.(map[string]interface{})["sequence"])
+		}
+		require.Equal(t, float64(genesis.Height), records[0].(map[string]interface{})["block_height"])
+		require.Equal(t, tx.GetHash(), records[1].(map[string]interface{})["tx_hash"])
+	}
+
+	{ // pagination
+		records := readRecords(GetOpLogHandlerPattern + "?limit=2&reverse=true")
+		require.Equal(t, 2, len(records))
+		require.Equal(t, float64(4), records[0].(map[string]interface{})["sequence"])
+		require.Equal(t, float64(3), records[1].(map[string]interface{})["sequence"])
+	}
+}
@@ -0,0 +1,75 @@
+package resource
+
+import (
+	"strings"
+
+	"github.com/nvellon/hal"
+
+	"boscoin.io/sebak/lib/block"
+	"boscoin.io/sebak/lib/transaction"
+)
+
+// Block is the API output of `block.Block`. Unlike the JSON of `block.Block`,
+// it has the derived fields like `transaction_count`.
+type Block struct {
+	b            *block.Block
+	transactions []transaction.Transaction
+	full         bool
+}
+
+func NewBlock(b *block.Block) *Block {
+	return &Block{
+		b: b,
+	}
+}
+
+// NewBlockWithTransactions makes `Block` with the full transactions; see
+// `block.GetBlockWithTransactions`. The `transactions` has the hash and the
+// body of transaction and the body of the missing transaction is `null`.
+func NewBlockWithTransactions(b *block.Block, transactions []transaction.Transaction) *Block {
+	return &Block{
+		b:            b,
+		transactions: transactions,
+		full:         true,
+	}
+}
+
+func (b Block) GetMap() hal.Entry {
+	var transactions interface{} = b.b.Transactions
+	if b.full {
+		full := []hal.Entry{}
+		for _, tx := range b.transactions {
+			entry := hal.Entry{"hash": tx.GetHash(), "body": nil}
+			if len(tx.B.Source) > 0 {
+				entry["body"] = tx
+			}
+			full = append(full, entry)
+		}
+		transactions = full
+	} else if b.b.Transactions == nil {
+		transactions = []string{}
+	}
+
+	return hal.Entry{
+		"hash":              b.b.Hash,
+		"height":            b.b.Height,
+		"version":           b.b.Version,
+		"prev_block_hash":   b.b.PrevBlockHash,
+		"transactions_root": b.b.TransactionsRoot,
+		"timestamp":         b.b.Timestamp,
+		"total_txs":         b.b.TotalTxs,
+		"transaction_count": len(b.b.Transactions),
+		"transactions":      transactions,
+		"confirmed":         b.b.Confirmed,
+		"proposer":          b.b.Proposer,
+		"round":             b.b.Round.Number,
+	}
+}
+
+func (b Block) Resource() *hal.Resource {
+	return hal.NewResource(b, b.LinkSelf())
+}
+
+func (b Block) LinkSelf() string {
+	return strings.Replace(URLBlocks, "{id}", b.b.Hash, -1)
+}
@@ -4,4 +4,5 @@ const (
 	URLAccounts     = "/accounts/{id}"
 	URLTransactions = "/transactions/{id}"
 	URLOperations   = "/operations/{id}"
+	URLBlocks       = "/blocks/{id}"
 )
@@ -0,0 +1,36 @@
+package resource
+
+import (
+	"strings"
+
+	"github.com/nvellon/hal"
+
+	"boscoin.io/sebak/lib/block"
+)
+
+type OpLogEntry struct {
+	e block.OpLogEntry
+}
+
+func NewOpLogEntry(e block.OpLogEntry) *OpLogEntry {
+	return &OpLogEntry{e: e}
+}
+
+func (o OpLogEntry) GetMap() hal.Entry {
+	return hal.Entry{
+		"sequence":     o.e.Sequence,
+		"block_height": o.e.BlockHeight,
+		"tx_hash":      o.e.TxHash,
+		"op_index":     o.e.OpIndex,
+		"type":         o.e.Type,
+		"accounts":     o.e.Accounts,
+	}
+}
+
+func (o OpLogEntry) Resource() *hal.Resource {
+	return hal.NewResource(o, o.LinkSelf())
+}
+
+func (o OpLogEntry) LinkSelf() string {
+	return strings.Replace(URLTransactions, "{id}", o.e.TxHash, -1)
+}
@@ -0,0 +1,48 @@
+package resource
+
+import (
+	"strings"
+	"time"
+
+	"github.com/nvellon/hal"
+
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/transaction"
+)
+
+// PendingTransaction is the transaction, which is not yet confirmed and is
+// waiting in the transaction pool.
+type PendingTransaction struct {
+	tx       transaction.Transaction
+	received time.Time
+}
+
+func NewPendingTransaction(tx transaction.Transaction, received time.Time) *PendingTransaction {
+	return &PendingTransaction{
+		tx:       tx,
+		received: received,
+	}
+}
+
+func (t PendingTransaction) GetMap() hal.Entry {
+	return hal.Entry{
+		"hash":            t.tx.GetHash(),
+		"source":          t.tx.Source(),
+		"fee":             t.tx.B.Fee.String(),
+		"sequenceid":      t.tx.B.SequenceID,
+		"created":         t.tx.H.Created,
+		"received":        common.FormatISO8601(t.received),
+		"age":             time.Since(t.received).Seconds(),
+		"operation_count": len(t.tx.B.Operations),
+	}
+}
+
+func (t PendingTransaction) Resource() *hal.Resource {
+	r := hal.NewResource(t, t.LinkSelf())
+	r.AddLink("accounts", hal.NewLink(strings.Replace(URLAccounts, "{id}", t.tx.Source(), -1)))
+	return r
+}
+
+func (t PendingTransaction) LinkSelf() string {
+	return strings.Replace(URLTransactions, "{id}", t.tx.GetHash(), -1)
+}
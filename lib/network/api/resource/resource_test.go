@@ -20,7 +20,7 @@ func TestResourceAccount(t *testing.T) {
 	{
 		ba := block.TestMakeBlockAccount()
 		ba.SequenceID = 123
-		ba.Save(storage)
+		ba.Save(storage, nil)
 
 		ra := NewAccount(ba)
 		r := ra.Resource()
@@ -140,3 +140,28 @@ func TestResourceAccount(t *testing.T) {
 		}
 	}
 }
+
+func TestResourceBlock(t *testing.T) {
+	blk := block.TestMakeNewBlock([]string{"tx-1", "tx-2"})
+
+	r := NewBlock(&blk).Resource()
+	j, _ := json.MarshalIndent(r, "", " ")
+
+	var m map[string]interface{}
+	require.Nil(t, json.Unmarshal(j, &m))
+	require.Equal(t, blk.Hash, m["hash"])
+	require.Equal(t, float64(blk.Height), m["height"])
+	require.Equal(t, float64(2), m["transaction_count"])
+	require.Equal(t, []interface{}{"tx-1", "tx-2"}, m["transactions"])
+
+	l := m["_links"].(map[string]interface{})
+	require.Equal(t, strings.Replace(URLBlocks, "{id}", blk.Hash, -1), l["self"].(map[string]interface{})["href"])
+
+	// `block.Block` JSON is not changed
+	encoded, err := blk.Serialize()
+	require.Nil(t, err)
+	var raw map[string]interface{}
+	require.Nil(t, json.Unmarshal(encoded, &raw))
+	_, found := raw["transaction_count"]
+	require.False(t, found)
+}
@@ -8,7 +8,8 @@ import (
 )
 
 type Transaction struct {
-	bt *block.BlockTransaction
+	bt            *block.BlockTransaction
+	confirmations uint64
 }
 
 func NewTransaction(bt *block.BlockTransaction) *Transaction {
@@ -18,8 +19,17 @@ func NewTransaction(bt *block.BlockTransaction) *Transaction {
 	return t
 }
 
+// NewTransactionWithConfirmations makes `Transaction` with the
+// `confirmations`, the confirmation depth of the transaction; see
+// `block.ConfirmationDepth`.
+func NewTransactionWithConfirmations(bt *block.BlockTransaction, confirmations uint64) *Transaction {
+	t := NewTransaction(bt)
+	t.confirmations = confirmations
+	return t
+}
+
 func (t Transaction) GetMap() hal.Entry {
-	return hal.Entry{
+	entry := hal.Entry{
 		"hash":            t.bt.Hash,
 		"source":          t.bt.Source,
 		"fee":             t.bt.Fee.String(),
@@ -27,6 +37,11 @@ func (t Transaction) GetMap() hal.Entry {
 		"created":         t.bt.Created,
 		"operation_count": len(t.bt.Operations),
 	}
+	if t.confirmations > 0 {
+		entry["confirmations"] = t.confirmations
+	}
+
+	return entry
 }
 func (t Transaction) Resource() *hal.Resource {
 
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"boscoin.io/sebak/lib/block"
+	"boscoin.io/sebak/lib/error"
+	"boscoin.io/sebak/lib/network/httputils"
+)
+
+// DefaultStatsWindow is the number of latest blocks for `GetStatsHandler`, if
+// `window` is not given.
+const DefaultStatsWindow int = 100
+
+func (api NetworkHandlerAPI) GetStatsHandler(w http.ResponseWriter, r *http.Request) {
+	window := DefaultStatsWindow
+	if s := r.URL.Query().Get("window"); len(s) > 0 {
+		var err error
+		if window, err = strconv.Atoi(s); err != nil || window < 1 {
+			http.Error(w, errors.ErrorInvalidQueryString.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	stats, err := block.NetworkStats(api.storage, window)
+	if err != nil {
+		httputils.WriteJSONError(w, err)
+		return
+	}
+
+	if err := httputils.WriteJSON(w, 200, stats); err != nil {
+		httputils.WriteJSONError(w, err)
+	}
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"boscoin.io/sebak/lib/block"
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/consensus/round"
+)
+
+func TestGetStatsHandler(t *testing.T) {
+	ts, storage, err := prepareAPIServer()
+	require.Nil(t, err)
+	defer storage.Close()
+	defer ts.Close()
+
+	get := func(url string) (int, []byte) {
+		resp, err := http.Get(ts.URL + url)
+		require.Nil(t, err)
+		defer resp.Body.Close()
+
+		b, err := ioutil.ReadAll(resp.Body)
+		require.Nil(t, err)
+		return resp.StatusCode, b
+	}
+
+	var supply common.Amount
+	for i := 0; i < 3; i++ {
+		ba := block.TestMakeBlockAccount()
+		require.Nil(t, ba.Save(storage, nil))
+		supply = supply.MustAdd(ba.Balance)
+	}
+
+	now := time.Now()
+	for i := 0; i < 4; i++ {
+		b := block.NewBlock(
+			"",
+			round.Round{BlockHeight: uint64(i)},
+			[]string{"tx0"},
+			common.FormatISO8601(now.Add(time.Duration(i)*time.Second*2)),
+		)
+		require.Nil(t, b.Save(storage))
+	}
+
+	{
+		status, b := get(GetStatsHandlerPattern + "?window=2")
+		require.Equal(t, http.StatusOK, status)
+
+		var stats block.Stats
+		require.Nil(t, json.Unmarshal(b, &stats))
+		require.Equal(t, uint64(4), stats.Height)
+		require.Equal(t, uint64(3), stats.TotalAccounts)
+		require.Equal(t, supply, stats.TotalSupply)
+		require.Equal(t, 2, stats.Window)
+		require.Equal(t, uint64(2), stats.Transactions)
+		require.Equal(t, float64(2), stats.AverageBlockTime)
+	}
+
+	{ // without window
+		status, b := get(GetStatsHandlerPattern)
+		require.Equal(t, http.StatusOK, status)
+
+		var stats block.Stats
+		require.Nil(t, json.Unmarshal(b, &stats))
+		require.Equal(t, DefaultStatsWindow, stats.Window)
+		require.Equal(t, uint64(4), stats.Transactions)
+	}
+
+	for _, window := range []string{"0", "-1", "a"} {
+		status, _ := get(GetStatsHandlerPattern + "?window=" + window)
+		require.Equal(t, http.StatusBadRequest, status)
+	}
+}
@@ -23,15 +23,26 @@ func prepareAPIServer() (*httptest.Server, *storage.LevelDBBackend, error) {
 	apiHandler := NetworkHandlerAPI{storage: storage}
 
 	router := mux.NewRouter()
+	router.HandleFunc(GetAccountsSequenceHandlerPattern, apiHandler.GetAccountsSequenceHandler).Methods("GET")
 	router.HandleFunc(GetAccountHandlerPattern, apiHandler.GetAccountHandler).Methods("GET")
 	router.HandleFunc(GetAccountTransactionsHandlerPattern, apiHandler.GetTransactionsByAccountHandler).Methods("GET")
 	router.HandleFunc(GetAccountOperationsHandlerPattern, apiHandler.GetOperationsByAccountHandler).Methods("GET")
+	router.HandleFunc(GetAccountSequenceHandlerPattern, apiHandler.GetAccountSequenceHandler).Methods("GET")
+	router.HandleFunc(GetAccountSignersHandlerPattern, apiHandler.GetAccountSignersHandler).Methods("GET")
 	router.HandleFunc(GetTransactionsHandlerPattern, apiHandler.GetTransactionsHandler).Methods("GET")
+	router.HandleFunc(GetRecentTransactionsHandlerPattern, apiHandler.GetRecentTransactionsHandler).Methods("GET")
 	router.HandleFunc(GetTransactionByHashHandlerPattern, apiHandler.GetTransactionByHashHandler).Methods("GET")
+	router.HandleFunc(GetTransactionProofHandlerPattern, apiHandler.GetTransactionProofHandler).Methods("GET")
 	router.HandleFunc(GetAccountHandlerPattern, apiHandler.GetAccountHandler).Methods("GET")
 	router.HandleFunc(GetAccountHandlerPattern, apiHandler.GetAccountHandler).Methods("GET")
 	router.HandleFunc(GetAccountHandlerPattern, apiHandler.GetAccountHandler).Methods("GET")
+	router.HandleFunc(GetTransactionOperationsHandlerPattern, apiHandler.GetNormalizedOperationsByTxHashHandler).Methods("GET").Queries("format", "normalized")
 	router.HandleFunc(GetTransactionOperationsHandlerPattern, apiHandler.GetOperationsByTxHashHandler).Methods("GET")
+	router.HandleFunc(GetStatsHandlerPattern, apiHandler.GetStatsHandler).Methods("GET")
+	router.HandleFunc(GetBlocksRangeHandlerPattern, apiHandler.GetBlocksRangeHandler).Methods("GET")
+	router.HandleFunc(GetBlockHandlerPattern, apiHandler.GetBlockHandler).Methods("GET")
+	router.HandleFunc(GetBlockCreatedAccountsHandlerPattern, apiHandler.GetBlockCreatedAccountsHandler).Methods("GET")
+	router.HandleFunc(GetOpLogHandlerPattern, apiHandler.GetOpLogHandler).Methods("GET")
 	ts := httptest.NewServer(router)
 	return ts, storage, nil
 }
@@ -3,6 +3,7 @@ package api
 import (
 	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/gorilla/mux"
 
@@ -61,6 +62,43 @@ func (api NetworkHandlerAPI) GetTransactionsHandler(w http.ResponseWriter, r *ht
 	}
 }
 
+const (
+	DefaultRecentTransactionsLimit int = 10
+	MaxRecentTransactionsLimit     int = 100
+)
+
+// GetRecentTransactionsHandler returns the most recent transactions of all
+// the accounts, newest first. `limit` is up to `MaxRecentTransactionsLimit`.
+func (api NetworkHandlerAPI) GetRecentTransactionsHandler(w http.ResponseWriter, r *http.Request) {
+	limit := DefaultRecentTransactionsLimit
+	if s := r.URL.Query().Get("limit"); len(s) > 0 {
+		var err error
+		if limit, err = strconv.Atoi(s); err != nil || limit < 1 {
+			http.Error(w, errors.ErrorInvalidQueryString.Error(), http.StatusBadRequest)
+			return
+		}
+		if limit > MaxRecentTransactionsLimit {
+			limit = MaxRecentTransactionsLimit
+		}
+	}
+
+	bts, err := block.GetRecentTransactions(api.storage, limit)
+	if err != nil {
+		httputils.WriteJSONError(w, err)
+		return
+	}
+
+	var txs []resource.Resource
+	for i := range bts {
+		txs = append(txs, resource.NewTransaction(&bts[i]))
+	}
+
+	list := resource.NewResourceList(txs, r.URL.String(), "", "")
+	if err := httputils.WriteJSON(w, 200, list); err != nil {
+		http.Error(w, "Error reading request body", http.StatusInternalServerError)
+	}
+}
+
 func (api NetworkHandlerAPI) GetTransactionByHashHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	key := vars["id"]
@@ -77,7 +115,12 @@ func (api NetworkHandlerAPI) GetTransactionByHashHandler(w http.ResponseWriter,
 		if err != nil {
 			return nil, err
 		}
-		payload = resource.NewTransaction(&bt)
+		// NOTE the transaction without the stored block has no confirmations
+		depth, err := block.ConfirmationDepth(api.storage, key)
+		if err != nil {
+			return resource.NewTransaction(&bt), nil
+		}
+		payload = resource.NewTransactionWithConfirmations(&bt, depth)
 		return payload, nil
 	}
 
@@ -103,6 +146,57 @@ func (api NetworkHandlerAPI) GetTransactionByHashHandler(w http.ResponseWriter,
 	}
 }
 
+// TransactionProof is the inclusion proof of the transaction; the client can
+// check it by `block.VerifyMerkleProof(TransactionsRoot, Hash, Proof)`.
+type TransactionProof struct {
+	Hash             string                  `json:"hash"`
+	Block            string                  `json:"block"`
+	BlockHeight      uint64                  `json:"block_height"`
+	TransactionsRoot string                  `json:"transactions_root"`
+	Proof            []block.MerkleProofNode `json:"proof"`
+}
+
+// GetTransactionProofHandler returns the Merkle proof, that the transaction is
+// included in the block.
+func (api NetworkHandlerAPI) GetTransactionProofHandler(w http.ResponseWriter, r *http.Request) {
+	hash := mux.Vars(r)["id"]
+
+	found, err := block.ExistsBlockTransaction(api.storage, hash)
+	if err != nil {
+		httputils.WriteJSONError(w, err)
+		return
+	}
+	if !found {
+		httputils.WriteJSON(w, http.StatusNotFound, errors.ErrorBlockTransactionDoesNotExists)
+		return
+	}
+
+	bt, err := block.GetBlockTransaction(api.storage, hash)
+	if err != nil {
+		httputils.WriteJSONError(w, err)
+		return
+	}
+	blk, err := block.GetBlock(api.storage, bt.Block)
+	if err != nil {
+		httputils.WriteJSON(w, http.StatusNotFound, errors.ErrorBlockNotFound)
+		return
+	}
+
+	proof, err := blk.MerkleProof(hash)
+	if err != nil {
+		httputils.WriteJSON(w, http.StatusNotFound, err)
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, TransactionProof{
+		Hash:             hash,
+		Block:            blk.Hash,
+		BlockHeight:      blk.Height,
+		TransactionsRoot: blk.TransactionsRoot,
+		Proof:            proof,
+	})
+}
+
 func (api NetworkHandlerAPI) GetTransactionsByAccountHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	address := vars["id"]
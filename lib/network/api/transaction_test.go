@@ -46,6 +46,40 @@ func TestGetTransactionByHashHandler(t *testing.T) {
 	}
 }
 
+func TestGetTransactionByHashHandlerConfirmations(t *testing.T) {
+	ts, storage, err := prepareAPIServer()
+	require.Nil(t, err)
+	defer storage.Close()
+	defer ts.Close()
+
+	_, btList, err := prepareTxs(storage, 0, 1, nil)
+	require.Nil(t, err)
+	bt := btList[0]
+
+	getConfirmations := func() interface{} {
+		respBody, err := request(ts, GetTransactionsHandlerPattern+"/"+bt.Hash, false)
+		require.Nil(t, err)
+		defer respBody.Close()
+
+		readByte, err := ioutil.ReadAll(respBody)
+		require.Nil(t, err)
+		recv := make(map[string]interface{})
+		json.Unmarshal(readByte, &recv)
+
+		require.Equal(t, bt.Hash, recv["hash"], "hash is not same")
+		return recv["confirmations"]
+	}
+
+	// in the latest block
+	require.Equal(t, float64(1), getConfirmations())
+
+	// new block is added
+	newBlock := block.TestMakeNewBlock(nil)
+	newBlock.Height++
+	require.Nil(t, newBlock.Save(storage))
+	require.Equal(t, float64(2), getConfirmations())
+}
+
 func TestGetTransactionByHashHandlerStream(t *testing.T) {
 	var wg sync.WaitGroup
 	wg.Add(1)
@@ -387,3 +421,79 @@ func TestGetTransactionsHandlerPage(t *testing.T) {
 		}
 	}
 }
+
+func TestGetRecentTransactionsHandler(t *testing.T) {
+	ts, storage, err := prepareAPIServer()
+	require.Nil(t, err)
+	defer storage.Close()
+	defer ts.Close()
+
+	_, btList, err := prepareTxs(storage, 0, 3, nil)
+	require.Nil(t, err)
+
+	readRecords := func(url string) []interface{} {
+		respBody, err := request(ts, url, false)
+		require.Nil(t, err)
+		defer respBody.Close()
+
+		b, err := ioutil.ReadAll(respBody)
+		require.Nil(t, err)
+
+		var received map[string]interface{}
+		require.Nil(t, json.Unmarshal(b, &received))
+		return received["_embedded"].(map[string]interface{})["records"].([]interface{})
+	}
+
+	records := readRecords(GetRecentTransactionsHandlerPattern)
+	require.Equal(t, 3, len(records))
+	for i, r := range records {
+		require.Equal(t, btList[len(btList)-1-i].Hash, r.(map[string]interface{})["hash"])
+	}
+
+	records = readRecords(GetRecentTransactionsHandlerPattern + "?limit=1")
+	require.Equal(t, 1, len(records))
+	require.Equal(t, btList[2].Hash, records[0].(map[string]interface{})["hash"])
+
+	{ // invalid limit
+		resp, err := ts.Client().Get(ts.URL + GetRecentTransactionsHandlerPattern + "?limit=0")
+		require.Nil(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, 400, resp.StatusCode)
+	}
+}
+
+func TestGetTransactionProofHandler(t *testing.T) {
+	ts, storage, err := prepareAPIServer()
+	require.Nil(t, err)
+	defer storage.Close()
+	defer ts.Close()
+
+	_, btList, err := prepareTxs(storage, 0, 3, nil)
+	require.Nil(t, err)
+
+	for _, bt := range btList {
+		resp, err := ts.Client().Get(ts.URL + "/transactions/" + bt.Hash + "/proof")
+		require.Nil(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, 200, resp.StatusCode)
+
+		b, err := ioutil.ReadAll(resp.Body)
+		require.Nil(t, err)
+
+		var proof TransactionProof
+		require.Nil(t, json.Unmarshal(b, &proof))
+		require.Equal(t, bt.Block, proof.Block)
+
+		blk, err := block.GetBlock(storage, bt.Block)
+		require.Nil(t, err)
+		require.Equal(t, blk.TransactionsRoot, proof.TransactionsRoot)
+		require.True(t, block.VerifyMerkleProof(proof.TransactionsRoot, bt.Hash, proof.Proof))
+	}
+
+	{ // unknown transaction
+		resp, err := ts.Client().Get(ts.URL + "/transactions/findme/proof")
+		require.Nil(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, 404, resp.StatusCode)
+	}
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 
@@ -12,6 +13,7 @@ import (
 	"boscoin.io/sebak/lib/network/api/resource"
 	"boscoin.io/sebak/lib/network/httputils"
 	"boscoin.io/sebak/lib/storage"
+	"boscoin.io/sebak/lib/transaction"
 )
 
 func (api NetworkHandlerAPI) GetOperationsByTxHashHandler(w http.ResponseWriter, r *http.Request) {
@@ -66,3 +68,64 @@ func (api NetworkHandlerAPI) getOperationsByTxHash(txHash string, options storag
 	closeFunc()
 	return
 }
+
+// NormalizedOperation is the operation of transaction with it's body decoded
+// by `OperationHeader.Type`.
+type NormalizedOperation struct {
+	Index int                       `json:"index"`
+	Type  transaction.OperationType `json:"type"`
+	Body  interface{}               `json:"body"`
+}
+
+// GetNormalizedOperationsByTxHashHandler returns the operations of transaction
+// as json array of `NormalizedOperation` by the order in transaction. The body
+// of unknown operation type is passed through as it is stored.
+func (api NetworkHandlerAPI) GetNormalizedOperationsByTxHashHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	hash := vars["id"]
+
+	ops, err := api.getNormalizedOperationsByTxHash(hash)
+	if err != nil {
+		httputils.WriteJSONError(w, err)
+		return
+	}
+
+	if err := httputils.WriteJSON(w, 200, ops); err != nil {
+		httputils.WriteJSONError(w, err)
+		return
+	}
+}
+
+func (api NetworkHandlerAPI) getNormalizedOperationsByTxHash(hash string) (ops []NormalizedOperation, err error) {
+	var found bool
+	if found, err = block.ExistsBlockTransaction(api.storage, hash); err != nil {
+		return
+	} else if !found {
+		err = errors.ErrorBlockTransactionDoesNotExists
+		return
+	}
+
+	var bt block.BlockTransaction
+	if bt, err = block.GetBlockTransaction(api.storage, hash); err != nil {
+		return
+	}
+
+	ops = []NormalizedOperation{}
+	for i, key := range bt.Operations {
+		var bo block.BlockOperation
+		if bo, err = block.GetBlockOperation(api.storage, key); err != nil {
+			return
+		}
+
+		var body interface{}
+		if decoded, err := transaction.UnmarshalOperationBody(bo.Type, bo.Body); err == nil {
+			body = decoded
+		} else {
+			body = json.RawMessage(bo.Body)
+		}
+
+		ops = append(ops, NormalizedOperation{Index: i, Type: bo.Type, Body: body})
+	}
+
+	return
+}
@@ -12,6 +12,7 @@ import (
 	"boscoin.io/sebak/lib/block"
 	"boscoin.io/sebak/lib/common"
 	"boscoin.io/sebak/lib/common/observer"
+	"boscoin.io/sebak/lib/error"
 	"boscoin.io/sebak/lib/network/api/resource"
 	"boscoin.io/sebak/lib/transaction"
 	"github.com/stellar/go/keypair"
@@ -124,3 +125,79 @@ func TestGetOperationsByTxHashHandlerStream(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestGetNormalizedOperationsByTxHashHandler(t *testing.T) {
+	ts, st, err := prepareAPIServer()
+	require.Nil(t, err)
+	defer st.Close()
+	defer ts.Close()
+
+	kpSource, _ := keypair.Random()
+	kpTarget, _ := keypair.Random()
+	kpNew, _ := keypair.Random()
+
+	payment := transaction.NewOperationBodyPayment(kpTarget.Address(), common.Amount(100))
+	createAccount := transaction.NewOperationBodyCreateAccount(kpNew.Address(), common.BaseReserve, "")
+	tx, err := transaction.NewTransaction(
+		kpSource.Address(),
+		0,
+		transaction.Operation{H: transaction.OperationHeader{Type: transaction.OperationPayment}, B: payment},
+		transaction.Operation{H: transaction.OperationHeader{Type: transaction.OperationCreateAccount}, B: createAccount},
+	)
+	require.Nil(t, err)
+	tx.Sign(kpSource, networkID)
+
+	blk := block.TestMakeNewBlock([]string{tx.GetHash()})
+	raw, _ := tx.Serialize()
+	bt := block.NewBlockTransactionFromTransaction(blk.Hash, blk.Height, blk.Confirmed, tx, raw)
+	require.Nil(t, bt.Save(st))
+
+	requestNormalized := func(hash string) []byte {
+		url := strings.Replace(GetTransactionOperationsHandlerPattern, "{id}", hash, -1) + "?format=normalized"
+		respBody, err := request(ts, url, false)
+		require.Nil(t, err)
+		defer respBody.Close()
+
+		b, err := ioutil.ReadAll(respBody)
+		require.Nil(t, err)
+		return b
+	}
+
+	{
+		var ops []map[string]interface{}
+		require.Nil(t, json.Unmarshal(requestNormalized(tx.GetHash()), &ops))
+
+		require.Equal(t, []map[string]interface{}{
+			{
+				"index": float64(0),
+				"type":  string(transaction.OperationPayment),
+				"body":  map[string]interface{}{"target": kpTarget.Address(), "amount": "100"},
+			},
+			{
+				"index": float64(1),
+				"type":  string(transaction.OperationCreateAccount),
+				"body":  map[string]interface{}{"target": kpNew.Address(), "amount": common.BaseReserve.String()},
+			},
+		}, ops)
+	}
+
+	{ // unknown operation type is passed through
+		bo, err := block.GetBlockOperation(st, bt.Operations[1])
+		require.Nil(t, err)
+		bo.Type = "showme"
+		bo.Body = []byte(`{"findme":[1,2]}`)
+		require.Nil(t, st.Set(block.GetBlockOperationKey(bt.Operations[1]), bo))
+
+		var ops []map[string]interface{}
+		require.Nil(t, json.Unmarshal(requestNormalized(tx.GetHash()), &ops))
+		require.Equal(t, 2, len(ops))
+		require.Equal(t, "showme", ops[1]["type"])
+		require.Equal(t, map[string]interface{}{"findme": []interface{}{float64(1), float64(2)}}, ops[1]["body"])
+	}
+
+	{ // unknown transaction
+		var recv map[string]interface{}
+		require.Nil(t, json.Unmarshal(requestNormalized("showme"), &recv))
+		require.Equal(t, errors.ErrorBlockTransactionDoesNotExists.Message, recv["title"])
+	}
+}
@@ -1,12 +1,14 @@
 package network
 
 import (
+	"context"
 	"io"
 	"net"
 	"net/http"
 
 	"github.com/gorilla/mux"
 
+	"boscoin.io/sebak/lib/block"
 	"boscoin.io/sebak/lib/common"
 	"boscoin.io/sebak/lib/node"
 )
@@ -15,7 +17,9 @@ type Network interface {
 	Endpoint() *common.Endpoint
 	GetClient(endpoint *common.Endpoint) NetworkClient
 	AddWatcher(func(Network, net.Conn, http.ConnState))
-	AddHandler(string, http.HandlerFunc) *mux.Route
+	// AddHandler adds the handler of the pattern; the names of the
+	// middlewares, which are not applied to the handler, can be given.
+	AddHandler(pattern string, handler http.HandlerFunc, skipMiddlewares ...string) *mux.Route
 
 	// Starts network handling
 	// Blocks until finished, either because of an error
@@ -31,16 +35,42 @@ type Network interface {
 	ReceiveMessage() <-chan common.NetworkMessage
 }
 
+// NetworkClient is the client to the other node. The methods without context
+// use the default timeout of the client; the `Context` variants are also
+// canceled by the context.
 type NetworkClient interface {
 	Endpoint() *common.Endpoint
 
 	Connect(node node.Node) ([]byte, error)
+	ConnectContext(ctx context.Context, node node.Node) ([]byte, error)
 	GetNodeInfo() ([]byte, error)
+	GetNodeInfoContext(ctx context.Context) ([]byte, error)
 	SendMessage(common.Serializable) ([]byte, error)
+	SendMessageContext(context.Context, common.Serializable) ([]byte, error)
 	SendBallot(common.Serializable) ([]byte, error)
+	SendBallotContext(context.Context, common.Serializable) ([]byte, error)
+	// GetBlock returns the block of the hash; if not found, it returns
+	// `errors.ErrorBlockNotFound`.
+	GetBlock(hash string) (block.Block, error)
+	GetBlockContext(ctx context.Context, hash string) (block.Block, error)
+	// GetBlocks returns the blocks from the height `start` to `end`, `end`
+	// is not included. If one of them is missing, it returns
+	// `errors.ErrorBlockNotFound`.
+	GetBlocks(start, end uint64) ([]block.Block, error)
+	GetBlocksContext(ctx context.Context, start, end uint64) ([]block.Block, error)
+	// GetBlockTransactions returns the transactions of the blocks from the
+	// height `start` to `end`, `end` is not included, by the order in the
+	// blocks. If one of them is missing, it returns
+	// `errors.ErrorBlockNotFound`.
+	GetBlockTransactions(start, end uint64) ([]block.BlockTransaction, error)
+	GetBlockTransactionsContext(ctx context.Context, start, end uint64) ([]block.BlockTransaction, error)
+	// GetAccountSigners returns the signers and the threshold of account; if
+	// not found, it returns `errors.ErrorBlockAccountDoesNotExists`.
+	GetAccountSigners(address string) (block.AccountSigners, error)
+	GetAccountSignersContext(ctx context.Context, address string) (block.AccountSigners, error)
 }
 
 type MessageBroker interface {
 	Response(io.Writer, []byte) error
-	Receive(common.NetworkMessage)
+	Receive(common.NetworkMessage) error
 }
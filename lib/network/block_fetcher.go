@@ -0,0 +1,194 @@
+package network
+
+import (
+	"context"
+	"sync"
+
+	"boscoin.io/sebak/lib/block"
+	"boscoin.io/sebak/lib/error"
+)
+
+// DefaultBlockShardSize is the number of blocks, which is requested to one
+// client at once by `FetchBlocks`.
+var DefaultBlockShardSize uint64 = 100
+
+type blockShard struct {
+	start        uint64
+	end          uint64
+	blocks       []block.Block
+	transactions []block.BlockTransaction
+}
+
+// FetchBlocks fetches the blocks from the height `start` to `end`, `end` is
+// not included, from the clients in parallel. The range is split by
+// `shardSize` and the shards are assigned to the clients by round-robin. The
+// shards are fetched by `len(clients)` workers, so at most `len(clients)`
+// requests are running at once. If a client fails it's shard, the shard is
+// retried to the next clients; when all the clients fail the same shard, the
+// last error is returned. The returned blocks are ordered by height.
+func FetchBlocks(ctx context.Context, clients []NetworkClient, start, end, shardSize uint64) ([]block.Block, error) {
+	shards, err := fetchBlocks(ctx, clients, start, end, shardSize, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var blocks []block.Block
+	for _, shard := range shards {
+		blocks = append(blocks, shard.blocks...)
+	}
+
+	return blocks, nil
+}
+
+// FetchBlocksWithTransactions fetches the blocks like `FetchBlocks` with
+// their transactions; the transactions of each shard are fetched from the
+// same client of the blocks. The returned transactions are ordered by the
+// blocks and the order in each block.
+func FetchBlocksWithTransactions(ctx context.Context, clients []NetworkClient, start, end, shardSize uint64) ([]block.Block, []block.BlockTransaction, error) {
+	shards, err := fetchBlocks(ctx, clients, start, end, shardSize, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var blocks []block.Block
+	var bts []block.BlockTransaction
+	for _, shard := range shards {
+		blocks = append(blocks, shard.blocks...)
+		bts = append(bts, shard.transactions...)
+	}
+
+	return blocks, bts, nil
+}
+
+func fetchBlocks(ctx context.Context, clients []NetworkClient, start, end, shardSize uint64, withTransactions bool) ([]*blockShard, error) {
+	if len(clients) < 1 {
+		return nil, errors.ErrorBlockNotFound
+	}
+	if end <= start {
+		return nil, nil
+	}
+	if shardSize < 1 {
+		shardSize = DefaultBlockShardSize
+	}
+
+	var shards []*blockShard
+	for s := start; s < end; s += shardSize {
+		e := s + shardSize
+		if e > end {
+			e = end
+		}
+		shards = append(shards, &blockShard{start: s, end: e})
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	queue := make(chan int, len(shards))
+	for i := range shards {
+		queue <- i
+	}
+	close(queue)
+
+	workers := len(clients)
+	if workers > len(shards) {
+		workers = len(shards)
+	}
+
+	var wg sync.WaitGroup
+	var failOnce sync.Once
+	var failed error
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range queue {
+				if err := fetchBlockShardRetry(ctx, clients, i, shards[i], withTransactions); err != nil {
+					// the other shards are useless without this one
+					failOnce.Do(func() {
+						failed = err
+						cancel()
+					})
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if failed != nil {
+		return nil, failed
+	}
+
+	return shards, nil
+}
+
+// fetchBlockShardRetry fetches the i-th shard from the clients by
+// round-robin, starting from the i-th client, until one succeeds.
+func fetchBlockShardRetry(ctx context.Context, clients []NetworkClient, i int, shard *blockShard, withTransactions bool) (err error) {
+	for j := 0; j < len(clients); j++ {
+		if err = ctx.Err(); err != nil {
+			return
+		}
+
+		client := clients[(i+j)%len(clients)]
+		if shard.blocks, err = fetchBlockShard(ctx, client, shard.start, shard.end); err != nil {
+			continue
+		}
+		if !withTransactions {
+			return
+		}
+		if shard.transactions, err = fetchBlockShardTransactions(ctx, client, shard.blocks); err == nil {
+			return
+		}
+	}
+
+	return
+}
+
+// fetchBlockShard fetches the blocks of shard and checks the heights of the
+// received blocks are the requested ones.
+func fetchBlockShard(ctx context.Context, client NetworkClient, start, end uint64) ([]block.Block, error) {
+	blocks, err := client.GetBlocksContext(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	if uint64(len(blocks)) != end-start {
+		return nil, errors.ErrorBlockNotFound
+	}
+	for i, blk := range blocks {
+		if blk.Height != start+uint64(i) {
+			return nil, errors.ErrorBlockNotFound
+		}
+	}
+
+	return blocks, nil
+}
+
+// fetchBlockShardTransactions fetches the transactions of the blocks and
+// checks they are the transactions of the blocks by the same order.
+func fetchBlockShardTransactions(ctx context.Context, client NetworkClient, blocks []block.Block) ([]block.BlockTransaction, error) {
+	start := blocks[0].Height
+	end := blocks[len(blocks)-1].Height + 1
+
+	bts, err := client.GetBlockTransactionsContext(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	var i int
+	for _, blk := range blocks {
+		for _, hash := range blk.Transactions {
+			if i >= len(bts) || bts[i].Hash != hash || bts[i].Block != blk.Hash {
+				return nil, errors.ErrorTransactionNotFound.Clone().SetData("hash", hash)
+			}
+			i++
+		}
+	}
+	if i != len(bts) {
+		return nil, errors.ErrorBlockNotFound
+	}
+
+	return bts, nil
+}
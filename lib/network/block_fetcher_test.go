@@ -0,0 +1,186 @@
+package network
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"boscoin.io/sebak/lib/block"
+	"boscoin.io/sebak/lib/error"
+	"boscoin.io/sebak/lib/storage"
+	"boscoin.io/sebak/lib/transaction"
+)
+
+// failingBlocksClient fails all the `GetBlocksContext` requests and counts
+// them.
+type failingBlocksClient struct {
+	NetworkClient
+
+	sync.Mutex
+	requested [][2]uint64
+}
+
+func (c *failingBlocksClient) GetBlocksContext(ctx context.Context, start, end uint64) ([]block.Block, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.requested = append(c.requested, [2]uint64{start, end})
+	return nil, errors.ErrorBlockNotFound
+}
+
+// concurrentBlocksClient records the maximum number of the concurrent
+// `GetBlocksContext` requests.
+type concurrentBlocksClient struct {
+	NetworkClient
+
+	sync.Mutex
+	running    int
+	maxRunning int
+}
+
+func (c *concurrentBlocksClient) GetBlocksContext(ctx context.Context, start, end uint64) ([]block.Block, error) {
+	c.Lock()
+	c.running++
+	if c.running > c.maxRunning {
+		c.maxRunning = c.running
+	}
+	c.Unlock()
+
+	defer func() {
+		c.Lock()
+		c.running--
+		c.Unlock()
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	return c.NetworkClient.GetBlocksContext(ctx, start, end)
+}
+
+func makeBlockFetcherPeer(t *testing.T, blocks []block.Block) (NetworkClient, *storage.LevelDBBackend) {
+	st := storage.NewTestStorage()
+	for _, blk := range blocks {
+		require.Nil(t, blk.Save(st))
+	}
+
+	_, mn, _ := CreateMemoryNetwork(nil)
+	mn.SetStorage(st)
+
+	return mn.GetClient(mn.Endpoint()), st
+}
+
+func TestFetchBlocks(t *testing.T) {
+	var blocks []block.Block
+	for height := uint64(1); height <= 10; height++ {
+		blk := block.TestMakeNewBlock([]string{})
+		blk.Height = height
+		blocks = append(blocks, blk)
+	}
+
+	// peers serve the overlapping ranges; `c1` has only the lower blocks.
+	c0, st0 := makeBlockFetcherPeer(t, blocks)
+	defer st0.Close()
+	c1, st1 := makeBlockFetcherPeer(t, blocks[:6])
+	defer st1.Close()
+	c2, st2 := makeBlockFetcherPeer(t, blocks[3:])
+	defer st2.Close()
+
+	failing := &failingBlocksClient{NetworkClient: c0}
+
+	check := func(fetched []block.Block, start, end uint64) {
+		require.Equal(t, int(end-start), len(fetched))
+		for i, blk := range fetched {
+			require.Equal(t, blocks[start-1+uint64(i)].Hash, blk.Hash)
+		}
+	}
+
+	{ // all the shards
+		fetched, err := FetchBlocks(context.Background(), []NetworkClient{c0, c1, c2}, 1, 11, 3)
+		require.Nil(t, err)
+		check(fetched, 1, 11)
+	}
+
+	{ // shards of the failing peer are fetched from the other peers
+		fetched, err := FetchBlocks(context.Background(), []NetworkClient{failing, c1, c2}, 1, 11, 2)
+		require.Nil(t, err)
+		check(fetched, 1, 11)
+
+		// shard 0 and 3 are assigned to the failing peer by round-robin
+		require.Equal(t, 2, len(failing.requested))
+		require.Contains(t, failing.requested, [2]uint64{1, 3})
+		require.Contains(t, failing.requested, [2]uint64{7, 9})
+	}
+
+	{ // the partial range
+		fetched, err := FetchBlocks(context.Background(), []NetworkClient{c2}, 5, 9, 3)
+		require.Nil(t, err)
+		check(fetched, 5, 9)
+	}
+
+	{ // no peer has the shard
+		_, err := FetchBlocks(context.Background(), []NetworkClient{failing, c1}, 5, 11, 3)
+		require.Equal(t, errors.ErrorBlockNotFound, err)
+	}
+
+	{ // the shards are fetched by the workers as many as the clients
+		counting := &concurrentBlocksClient{NetworkClient: c0}
+		clients := []NetworkClient{counting, counting}
+		fetched, err := FetchBlocks(context.Background(), clients, 1, 11, 1)
+		require.Nil(t, err)
+		check(fetched, 1, 11)
+		require.True(t, counting.maxRunning <= len(clients))
+	}
+
+	{ // canceled
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := FetchBlocks(ctx, []NetworkClient{c0}, 1, 11, 3)
+		require.Equal(t, context.Canceled, err)
+	}
+}
+
+func TestFetchBlocksWithTransactions(t *testing.T) {
+	networkID := []byte("sebak-test-network")
+
+	var blocks []block.Block
+	var bts []block.BlockTransaction
+	for height := uint64(1); height <= 4; height++ {
+		_, tx := transaction.TestMakeTransaction(networkID, 1)
+		blk := block.TestMakeNewBlock([]string{tx.GetHash()})
+		blk.Height = height
+		blocks = append(blocks, blk)
+
+		raw, _ := tx.Serialize()
+		bts = append(bts, block.NewBlockTransactionFromTransaction(blk.Hash, blk.Height, blk.Confirmed, tx, raw))
+	}
+
+	c0, st0 := makeBlockFetcherPeer(t, blocks)
+	defer st0.Close()
+	for _, bt := range bts {
+		require.Nil(t, bt.Save(st0))
+	}
+
+	// `c1` has the blocks, but not the transactions
+	c1, st1 := makeBlockFetcherPeer(t, blocks)
+	defer st1.Close()
+
+	{ // the shards of `c1` are fetched from `c0`
+		fetched, fetchedTransactions, err := FetchBlocksWithTransactions(context.Background(), []NetworkClient{c1, c0}, 1, 5, 2)
+		require.Nil(t, err)
+		require.Equal(t, len(blocks), len(fetched))
+		require.Equal(t, len(bts), len(fetchedTransactions))
+		for i, bt := range fetchedTransactions {
+			require.Equal(t, blocks[i].Hash, fetched[i].Hash)
+			require.Equal(t, bts[i].Hash, bt.Hash)
+			require.Equal(t, bts[i].Message, bt.Message)
+		}
+	}
+
+	{ // no peer has the transactions
+		_, _, err := FetchBlocksWithTransactions(context.Background(), []NetworkClient{c1}, 1, 5, 2)
+		require.NotNil(t, err)
+	}
+}
@@ -0,0 +1,148 @@
+package network
+
+import (
+	"sync"
+	"time"
+
+	"boscoin.io/sebak/lib/ballot"
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/consensus/round"
+)
+
+var (
+	// BroadcastMaxRetries is the maximum number of the retries of the failed
+	// sends in `Broadcast`. `0` disables the retries.
+	BroadcastMaxRetries int = 3
+
+	// BroadcastRetryBackoff is the wait before the first retry of the failed
+	// send. It is doubled for each following retry.
+	BroadcastRetryBackoff time.Duration = 200 * time.Millisecond
+
+	// OutboundQueueSize is the maximum number of the messages waiting in the
+	// outbound queue of one validator; the message over it is dropped.
+	OutboundQueueSize int = 100
+)
+
+// outboundJob is the message in outbound queue. The result of send is sent
+// to `result`.
+type outboundJob struct {
+	message common.Message
+	result  chan error
+}
+
+// outboundQueue keeps the messages to one validator, which are being sent or
+// waiting for retry. The same message is queued only once and it is removed
+// from the queue when it is sent or when the retries are exhausted. The
+// messages in `jobs` are sent in order by one worker.
+type outboundQueue struct {
+	sync.Mutex
+
+	pending     map[ /* message key */ string]bool
+	latest      round.Round  // the latest round of the queued ballots
+	latestState ballot.State // the latest state of the queued ballots in `latest`
+	jobs        chan outboundJob
+}
+
+func newOutboundQueue() *outboundQueue {
+	return &outboundQueue{
+		pending: map[string]bool{},
+		jobs:    make(chan outboundJob, OutboundQueueSize),
+	}
+}
+
+// outboundKey makes the key of message for deduplication. The ballots with
+// the same hash, but different state are treated as different messages.
+func outboundKey(message common.Message) string {
+	if b, ok := message.(ballot.Ballot); ok {
+		return message.GetType() + "-" + b.State().String() + "-" + message.GetHash()
+	}
+
+	return message.GetType() + "-" + message.GetHash()
+}
+
+// isNewerRound checks `a` is newer than `b`.
+func isNewerRound(a, b round.Round) bool {
+	if a.BlockHeight != b.BlockHeight {
+		return a.BlockHeight > b.BlockHeight
+	}
+	return a.Number > b.Number
+}
+
+// isNewerBallot checks the ballot of round `a` and state `aState` is newer
+// than the ballot of round `b` and state `bState`; in the same round, the
+// later state is newer.
+func isNewerBallot(a round.Round, aState ballot.State, b round.Round, bState ballot.State) bool {
+	if isNewerRound(a, b) {
+		return true
+	} else if isNewerRound(b, a) {
+		return false
+	}
+
+	return aState > bState
+}
+
+// add marks the message as queued and returns `false` if it is already
+// queued.
+func (q *outboundQueue) add(message common.Message) bool {
+	q.Lock()
+	defer q.Unlock()
+
+	key := outboundKey(message)
+	if q.pending[key] {
+		return false
+	}
+	q.pending[key] = true
+
+	return true
+}
+
+// queued records the round and state of the ballot, which is put into `jobs`,
+// so the older ballots in queue are superseded by it.
+func (q *outboundQueue) queued(message common.Message) {
+	b, ok := message.(ballot.Ballot)
+	if !ok {
+		return
+	}
+
+	q.Lock()
+	defer q.Unlock()
+
+	if isNewerBallot(b.Round(), b.State(), q.latest, q.latestState) {
+		q.latest = b.Round()
+		q.latestState = b.State()
+	}
+}
+
+func (q *outboundQueue) remove(message common.Message) {
+	q.Lock()
+	defer q.Unlock()
+
+	delete(q.pending, outboundKey(message))
+}
+
+// isSuperseded checks whether the newer ballot is queued after the given
+// message; see `isNewerBallot`. The superseded ballot does not need to be
+// sent or retried. The INIT ballot is superseded only by the newer round; it
+// has the proposal, which the other validators need to vote.
+func (q *outboundQueue) isSuperseded(message common.Message) bool {
+	b, ok := message.(ballot.Ballot)
+	if !ok {
+		return false
+	}
+
+	q.Lock()
+	defer q.Unlock()
+
+	if b.State() == ballot.StateINIT {
+		return isNewerRound(q.latest, b.Round())
+	}
+
+	return isNewerBallot(q.latest, q.latestState, b.Round(), b.State())
+}
+
+func (q *outboundQueue) len() int {
+	q.Lock()
+	defer q.Unlock()
+
+	return len(q.pending)
+}
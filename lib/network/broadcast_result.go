@@ -0,0 +1,35 @@
+package network
+
+// BroadcastResult is the result of sending message to one validator by
+// `ConnectionManager.BroadcastWithResult`; `Error` is nil when the validator
+// received the message.
+type BroadcastResult struct {
+	Address string
+	Error   error
+}
+
+type BroadcastResults []BroadcastResult
+
+// Acknowledged returns the addresses of the validators, which received the
+// message.
+func (rs BroadcastResults) Acknowledged() (addresses []string) {
+	for _, r := range rs {
+		if r.Error == nil {
+			addresses = append(addresses, r.Address)
+		}
+	}
+
+	return
+}
+
+// Failed returns the addresses of the validators, which did not receive the
+// message.
+func (rs BroadcastResults) Failed() (addresses []string) {
+	for _, r := range rs {
+		if r.Error != nil {
+			addresses = append(addresses, r.Address)
+		}
+	}
+
+	return
+}
@@ -0,0 +1,45 @@
+package network
+
+import (
+	"net"
+	"net/url"
+)
+
+// ClientErrorKind is the kind of the error of the outbound client.
+type ClientErrorKind string
+
+const (
+	// ClientErrorNone means no error.
+	ClientErrorNone ClientErrorKind = ""
+	// ClientErrorDial means the client could not connect to the remote host
+	// within the dial timeout.
+	ClientErrorDial ClientErrorKind = "dial"
+	// ClientErrorTimeout means the client connected, but the response did
+	// not come within the request timeout.
+	ClientErrorTimeout ClientErrorKind = "timeout"
+	// ClientErrorOther is the other error, like the invalid response.
+	ClientErrorOther ClientErrorKind = "other"
+)
+
+// ClassifyClientError returns the kind of the error from `NetworkClient`, so
+// the slow response is not regarded as the dial failure.
+func ClassifyClientError(err error) ClientErrorKind {
+	if err == nil {
+		return ClientErrorNone
+	}
+
+	cause := err
+	if e, ok := err.(*url.Error); ok {
+		cause = e.Err
+	}
+
+	if e, ok := cause.(*net.OpError); ok && e.Op == "dial" {
+		return ClientErrorDial
+	}
+
+	if e, ok := err.(net.Error); ok && e.Timeout() {
+		return ClientErrorTimeout
+	}
+
+	return ClientErrorOther
+}
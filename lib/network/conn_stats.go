@@ -0,0 +1,170 @@
+package network
+
+import (
+	"sort"
+	"time"
+
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/storage"
+)
+
+// ConnStatsFlushInterval is the interval, by which
+// `ValidatorConnectionManager` persists the connection statistics of
+// validators.
+var ConnStatsFlushInterval time.Duration = time.Minute
+
+// ConnStats is the connectivity statistics of validator for the post-mortem
+// analysis. `Observed` is the total duration, since the connection state of
+// validator is known, and `Connected` is the part of it, while the validator
+// is connected; `UptimeRatio` is the ratio of them.
+type ConnStats struct {
+	Address         string        `json:"address"`
+	Connected       time.Duration `json:"connected"`
+	Observed        time.Duration `json:"observed"`
+	UptimeRatio     float64       `json:"uptime-ratio"`
+	DisconnectCount uint64        `json:"disconnect-count"`
+	Updated         string        `json:"updated"`
+}
+
+func GetConnStatsKey(address string) string {
+	return common.ConnStatsPrefix + address
+}
+
+func GetConnStats(st *storage.LevelDBBackend, address string) (stats ConnStats, err error) {
+	err = st.Get(GetConnStatsKey(address), &stats)
+	return
+}
+
+func saveConnStats(st *storage.LevelDBBackend, stats ConnStats) (err error) {
+	key := GetConnStatsKey(stats.Address)
+
+	var exists bool
+	if exists, err = st.Has(key); err != nil {
+		return
+	}
+
+	if exists {
+		err = st.Set(key, stats)
+	} else {
+		err = st.New(key, stats)
+	}
+	return
+}
+
+// SetStorage sets the storage, where the connection statistics are persisted.
+// The statistics, which are already persisted, are loaded, so they are
+// accumulated across the restarts.
+func (c *ValidatorConnectionManager) SetStorage(st *storage.LevelDBBackend) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.storage = st
+	for address := range c.validators {
+		if stats, err := GetConnStats(st, address); err == nil {
+			c.connStats[address] = &stats
+		}
+	}
+}
+
+func (c *ValidatorConnectionManager) connStatsUnlocked(address string) *ConnStats {
+	stats, found := c.connStats[address]
+	if !found {
+		stats = &ConnStats{Address: address}
+		c.connStats[address] = stats
+	}
+	return stats
+}
+
+// accrueConnStatsUnlocked adds the duration since the last accrual to the
+// statistics of validator, by it's current connection state.
+func (c *ValidatorConnectionManager) accrueConnStatsUnlocked(address string, now time.Time) {
+	if last, found := c.statsUpdated[address]; found {
+		stats := c.connStatsUnlocked(address)
+		elapsed := now.Sub(last)
+		stats.Observed += elapsed
+		if c.connected[address] {
+			stats.Connected += elapsed
+		}
+		if stats.Observed > 0 {
+			stats.UptimeRatio = float64(stats.Connected) / float64(stats.Observed)
+		}
+		stats.Updated = common.FormatISO8601(now)
+	}
+	c.statsUpdated[address] = now
+}
+
+// ConnectionStats returns the connection statistics of validators, ordered by
+// address. The validator, which is not checked yet, is not included.
+func (c *ValidatorConnectionManager) ConnectionStats() []ConnStats {
+	c.Lock()
+	defer c.Unlock()
+
+	now := time.Now()
+	for address := range c.statsUpdated {
+		c.accrueConnStatsUnlocked(address, now)
+	}
+
+	statsList := []ConnStats{}
+	for _, stats := range c.connStats {
+		statsList = append(statsList, *stats)
+	}
+
+	sort.Slice(statsList, func(i, j int) bool {
+		return statsList[i].Address < statsList[j].Address
+	})
+
+	return statsList
+}
+
+func (c *ValidatorConnectionManager) flushConnStats() {
+	c.RLock()
+	st := c.storage
+	c.RUnlock()
+	if st == nil {
+		return
+	}
+
+	for _, stats := range c.ConnectionStats() {
+		if err := saveConnStats(st, stats); err != nil {
+			c.log.Error("failed to save connection stats", "error", err, "validator", stats.Address)
+		}
+	}
+}
+
+// startStatsFlusher persists the connection statistics every
+// `ConnStatsFlushInterval` until `Stop` is called. Without storage, it does
+// nothing.
+func (c *ValidatorConnectionManager) startStatsFlusher() {
+	c.RLock()
+	st := c.storage
+	c.RUnlock()
+	if st == nil || ConnStatsFlushInterval < 1 {
+		return
+	}
+
+	c.flusher.Add(1)
+	go func() {
+		defer c.flusher.Done()
+
+		ticker := time.NewTicker(ConnStatsFlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				c.flushConnStats()
+			case <-c.stop:
+				c.flushConnStats()
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the flusher of connection statistics and the resolver of
+// validator endpoints; the statistics are persisted for the last time before
+// it returns.
+func (c *ValidatorConnectionManager) Stop() {
+	c.stopOnce.Do(func() { close(c.stop) })
+	c.flusher.Wait()
+	c.resolver.Wait()
+}
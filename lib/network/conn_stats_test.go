@@ -0,0 +1,58 @@
+package network
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"boscoin.io/sebak/lib/node"
+	"boscoin.io/sebak/lib/storage"
+)
+
+func TestValidatorConnectionManagerConnStats(t *testing.T) {
+	defer func(d time.Duration) { ConnectionStateDebounce = d }(ConnectionStateDebounce)
+	ConnectionStateDebounce = 0
+
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	cm, _, validators := makeTestValidatorConnectionManager(t, "10.0.0.1", "10.0.0.2")
+	cm.SetStorage(st)
+	cm.startStatsFlusher()
+
+	v := validators[0]
+	for i := 0; i < 3; i++ {
+		require.True(t, cm.setConnected(v, true))
+		time.Sleep(10 * time.Millisecond)
+		require.True(t, cm.setConnected(v, false))
+	}
+	require.True(t, cm.setConnected(validators[1], true))
+
+	// not persisted before flushing
+	_, err := GetConnStats(st, v.Address())
+	require.NotNil(t, err)
+
+	// `Stop` persists the stats for the last time
+	cm.Stop()
+
+	stats, err := GetConnStats(st, v.Address())
+	require.Nil(t, err)
+	require.Equal(t, uint64(3), stats.DisconnectCount)
+	require.True(t, stats.Connected >= 30*time.Millisecond)
+	require.True(t, stats.Observed >= stats.Connected)
+	require.True(t, stats.UptimeRatio > 0 && stats.UptimeRatio <= 1)
+
+	// the first connection is not counted as disconnection
+	stats, err = GetConnStats(st, validators[1].Address())
+	require.Nil(t, err)
+	require.Equal(t, uint64(0), stats.DisconnectCount)
+
+	// the persisted stats are loaded and accumulated
+	cm, _, _ = makeTestValidatorConnectionManager(t)
+	cm.validators = map[string]*node.Validator{v.Address(): v}
+	cm.SetStorage(st)
+	require.True(t, cm.setConnected(v, true))
+	require.True(t, cm.setConnected(v, false))
+	require.Equal(t, uint64(4), cm.ConnectionStats()[0].DisconnectCount)
+}
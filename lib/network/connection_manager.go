@@ -3,16 +3,25 @@ package network
 import (
 	"net"
 	"net/http"
+	"time"
 
 	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/storage"
 )
 
 type ConnectionManager interface {
 	GetNodeAddress() string
 	ConnectionWatcher(Network, net.Conn, http.ConnState)
 	Broadcast(common.Message)
-	Start()
+	BroadcastWithResult(common.Message, ...string) BroadcastResults
+	Start() error
+	Stop()
+	SetStorage(*storage.LevelDBBackend)
 	AllConnected() []string
 	AllValidators() []string
 	CountConnected() int
+	ClockSkews() map[string]time.Duration
+	ValidatorStatusList() []ValidatorStatus
+	ConnectionStats() []ConnStats
+	UpdateValidatorEndpoint(string, *common.Endpoint) bool
 }
@@ -1,12 +1,14 @@
 package network
 
 import (
+	"crypto/tls"
 	"fmt"
 	"io"
 	goLog "log"
 	"net"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -14,12 +16,15 @@ import (
 	"golang.org/x/net/http2"
 
 	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/error"
+	"boscoin.io/sebak/lib/network/httputils"
 	"boscoin.io/sebak/lib/node"
 )
 
 type Handlers map[string]func(http.ResponseWriter, *http.Request)
 
 const (
+	RouterNameBase = "base"
 	RouterNameNode = "node"
 	RouterNameAPI  = "api"
 )
@@ -38,8 +43,27 @@ func (r HTTP2MessageBroker) Response(w io.Writer, o []byte) error {
 	return err
 }
 
-func (r HTTP2MessageBroker) Receive(msg common.NetworkMessage) {
-	r.network.ReceiveChannel() <- msg
+func (r HTTP2MessageBroker) Receive(msg common.NetworkMessage) error {
+	return r.network.receive(msg)
+}
+
+// HTTP2MaxBytesHandler limits the size of request body. If the size is over
+// `max`, the request is rejected with `413 Request Entity Too Large`.
+type HTTP2MaxBytesHandler struct {
+	max     int64
+	handler http.Handler
+}
+
+func (h HTTP2MaxBytesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.max > 0 {
+		if r.ContentLength > h.max {
+			httputils.WriteJSONError(w, errors.ErrorRequestBodyTooLarge)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, h.max)
+	}
+
+	h.handler.ServeHTTP(w, r)
 }
 
 type HTTP2Network struct {
@@ -50,17 +74,28 @@ type HTTP2Network struct {
 	router *mux.Router
 
 	receiveChannel chan common.NetworkMessage
+	// receiveLock, receiveClosed and receiveWait make sure
+	// `receiveChannel` is closed only once, after all the in-flight
+	// `receive()` calls are finished.
+	receiveLock   sync.RWMutex
+	receiveClosed bool
+	receiveWait   sync.WaitGroup
 
 	messageBroker MessageBroker
 	ready         bool
 
-	watchers []func(Network, net.Conn, http.ConnState)
-	routers  map[string]*mux.Router
-	handlers map[string]func(http.ResponseWriter, *http.Request)
+	watchers    []func(Network, net.Conn, http.ConnState)
+	routers     map[string]*mux.Router
+	middlewares map[string]*MiddlewareChain
+	handlers    map[string]func(http.ResponseWriter, *http.Request)
 
 	config *HTTP2NetworkConfig
 	node   *node.LocalNode
 	log    logging.Logger
+
+	clientsLock sync.RWMutex
+	clients     map[string]*HTTP2NetworkClient
+	readyClient *HTTP2NetworkClient
 }
 
 type HandlerFunc func(w http.ResponseWriter, r *http.Request)
@@ -75,6 +110,10 @@ func NewHTTP2Network(config *HTTP2NetworkConfig) (h2n *HTTP2Network) {
 		ReadHeaderTimeout: config.ReadHeaderTimeout,
 		WriteTimeout:      config.WriteTimeout,
 		ErrorLog:          errorLog,
+		TLSConfig: &tls.Config{
+			MinVersion:   config.TLSMinVersion,
+			CipherSuites: config.TLSCipherSuites,
+		},
 	}
 	server.SetKeepAlivesEnabled(true)
 
@@ -95,38 +134,138 @@ func NewHTTP2Network(config *HTTP2NetworkConfig) (h2n *HTTP2Network) {
 		router:         baseRouter,
 		tlsCertFile:    config.TLSCertFile,
 		tlsKeyFile:     config.TLSKeyFile,
-		receiveChannel: make(chan common.NetworkMessage),
+		receiveChannel: make(chan common.NetworkMessage, config.ReceiveChannelSize),
 		log:            httpLog,
+		clients:        map[string]*HTTP2NetworkClient{},
 	}
 	h2n.handlers = map[string]func(http.ResponseWriter, *http.Request){}
 	h2n.routers = map[string]*mux.Router{
 		RouterNameNode: baseRouter.PathPrefix(UrlPathPrefixNode).Subrouter(),
 		RouterNameAPI:  baseRouter.PathPrefix(UrlPathPrefixAPI).Subrouter(),
 	}
-
 	h2n.config = config
 
+	h2n.middlewares = map[string]*MiddlewareChain{
+		RouterNameBase: &MiddlewareChain{},
+		RouterNameNode: &MiddlewareChain{},
+		RouterNameAPI:  &MiddlewareChain{},
+	}
+	for _, chain := range h2n.middlewares {
+		for _, m := range h2n.defaultMiddlewares() {
+			chain.Add(m)
+		}
+	}
+
+	// the requests, which are not routed, also pass the middlewares of base
+	// router.
+	baseRouter.NotFoundHandler = h2n.middlewares[RouterNameBase].Then(http.NotFoundHandler())
+	baseRouter.MethodNotAllowedHandler = h2n.middlewares[RouterNameBase].Then(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}),
+	)
+
 	h2n.setNotReadyHandler()
 	h2n.server.ConnState = h2n.ConnState
 
 	h2n.SetMessageBroker(HTTP2MessageBroker{network: h2n})
 
+	addReceiveChannel(h2n.receiveChannel)
+
 	return
 }
 
-// GetClient creates new keep-alive HTTP2 client
+// GetClient returns the keep-alive HTTP2 client for endpoint. The client is
+// created at the first call and reused until `Stop()`.
 func (t *HTTP2Network) GetClient(endpoint *common.Endpoint) NetworkClient {
-	rawClient, _ := common.NewHTTP2Client(defaultTimeout, 0, true)
+	key := endpoint.Normalize().String()
 
-	client := NewHTTP2NetworkClient(endpoint, rawClient)
+	t.clientsLock.RLock()
+	client, found := t.clients[key]
+	t.clientsLock.RUnlock()
+	if found {
+		return client
+	}
+
+	t.clientsLock.Lock()
+	defer t.clientsLock.Unlock()
+
+	if client, found = t.clients[key]; found {
+		return client
+	}
+
+	rawClient, err := common.NewHTTP2ClientWithConfig(t.clientConfig(endpoint))
+	if err != nil {
+		t.log.Error("failed to create HTTP2 client", "endpoint", endpoint, "error", err)
+		return nil
+	}
+
+	client = NewHTTP2NetworkClient(endpoint, rawClient)
 
 	headers := http.Header{}
 	headers.Set("User-Agent", fmt.Sprintf("v-%s", t.config.NodeName))
 	client.SetDefaultHeaders(headers)
 
+	t.clients[key] = client
+
 	return client
 }
 
+// clientConfig returns the configuration for the outbound clients from
+// `HTTP2NetworkConfig`. The clients keep their connections alive. The
+// "ClientTimeout" and "ClientDialTimeout" in the query of endpoint override
+// the configuration for the endpoint, like the validator on the high-latency
+// link.
+func (t *HTTP2Network) clientConfig(endpoint *common.Endpoint) common.HTTP2ClientConfig {
+	config := common.HTTP2ClientConfig{
+		Timeout:      t.config.ClientTimeout,
+		DialTimeout:  t.config.ClientDialTimeout,
+		IdleTimeout:  t.config.ClientIdleTimeout,
+		MaxIdleConns: t.config.ClientMaxIdleConns,
+		KeepAlive:    true,
+	}
+
+	if endpoint == nil {
+		return config
+	}
+
+	query := endpoint.Query()
+	for name, d := range map[string]*time.Duration{
+		"ClientTimeout":     &config.Timeout,
+		"ClientDialTimeout": &config.DialTimeout,
+	} {
+		v := query.Get(name)
+		if len(v) < 1 {
+			continue
+		}
+
+		timeout, err := time.ParseDuration(v)
+		if err != nil || timeout < 0 {
+			t.log.Warn("invalid timeout of endpoint; ignored", "endpoint", endpoint, "name", name, "value", v)
+			continue
+		}
+		*d = timeout
+	}
+
+	return config
+}
+
+// closeClients closes the pooled clients.
+func (t *HTTP2Network) closeClients() {
+	t.clientsLock.Lock()
+	defer t.clientsLock.Unlock()
+
+	for key, client := range t.clients {
+		client.client.Close()
+		delete(t.clients, key)
+	}
+
+	if t.readyClient != nil {
+		t.readyClient.client.Close()
+		t.readyClient = nil
+	}
+}
+
 func (t *HTTP2Network) Endpoint() *common.Endpoint {
 	return t.config.Endpoint
 }
@@ -142,17 +281,55 @@ func (t *HTTP2Network) ConnState(c net.Conn, state http.ConnState) {
 }
 
 func (t *HTTP2Network) setNotReadyHandler() {
-	t.router.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+	t.AddHandler("/", func(w http.ResponseWriter, r *http.Request) {
 		if !t.ready {
 			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
 			return
 		}
 	})
 
-	t.server.Handler = HTTP2Log15Handler{log: t.log, handler: t.router}
+	t.server.Handler = t.router
 }
 
-func (t *HTTP2Network) AddHandler(pattern string, handler http.HandlerFunc) (router *mux.Route) {
+// defaultMiddlewares returns the middlewares, which every router has; the
+// request logging and the limit of request body.
+func (t *HTTP2Network) defaultMiddlewares() []Middleware {
+	return []Middleware{
+		Middleware{
+			Name:  MiddlewareNameLog,
+			Order: MiddlewareOrderTracing,
+			Wrap: func(h http.Handler) http.Handler {
+				return HTTP2Log15Handler{
+					log:           t.log,
+					handler:       h,
+					router:        t.router,
+					slowThreshold: t.config.SlowRequestThreshold,
+				}
+			},
+		},
+		Middleware{
+			Name:  MiddlewareNameBodySize,
+			Order: MiddlewareOrderBodySize,
+			Wrap: func(h http.Handler) http.Handler {
+				return HTTP2MaxBytesHandler{max: t.config.MaxRequestBodyBytes, handler: h}
+			},
+		},
+	}
+}
+
+// UseMiddleware adds the middleware to the router, `RouterNameBase`,
+// `RouterNameNode` or `RouterNameAPI`. The middlewares are applied when the handler is added, so
+// they must be added before `AddHandler()`.
+func (t *HTTP2Network) UseMiddleware(routerName string, m Middleware) {
+	if chain, found := t.middlewares[routerName]; found {
+		chain.Add(m)
+	}
+}
+
+// AddHandler adds the handler to the router by the prefix of pattern. The
+// handler is wrapped by the middlewares of the router except the ones named in
+// `skipMiddlewares`; for example, the streaming route can skip gzip.
+func (t *HTTP2Network) AddHandler(pattern string, handler http.HandlerFunc, skipMiddlewares ...string) (router *mux.Route) {
 	var routerName string
 	var prefix string
 	switch {
@@ -164,12 +341,12 @@ func (t *HTTP2Network) AddHandler(pattern string, handler http.HandlerFunc) (rou
 		prefix = pattern[len(UrlPathPrefixAPI):]
 	default:
 		// if unknown pattern, it will be attached to base router
-		return t.router.HandleFunc(pattern, handler)
+		return t.router.Handle(pattern, t.middlewares[RouterNameBase].Then(handler, skipMiddlewares...))
 	}
 
 	r, _ := t.routers[routerName]
 
-	return r.HandleFunc(prefix, handler)
+	return r.Handle(prefix, t.middlewares[routerName].Then(handler, skipMiddlewares...))
 }
 
 func (t *HTTP2Network) SetMessageBroker(mb MessageBroker) {
@@ -181,7 +358,7 @@ func (t *HTTP2Network) MessageBroker() MessageBroker {
 }
 
 func (t *HTTP2Network) Ready() error {
-	t.server.Handler = HTTP2Log15Handler{log: t.log, handler: t.router}
+	t.server.Handler = t.router
 
 	t.ready = true
 
@@ -189,12 +366,18 @@ func (t *HTTP2Network) Ready() error {
 }
 
 func (t *HTTP2Network) IsReady() bool {
-	client, err := common.NewHTTP2Client(50*time.Millisecond, 50*time.Millisecond, false)
-	if err != nil {
-		return false
+	t.clientsLock.Lock()
+	if t.readyClient == nil {
+		client, err := common.NewHTTP2Client(50*time.Millisecond, 50*time.Millisecond, false)
+		if err != nil {
+			t.clientsLock.Unlock()
+			return false
+		}
+		t.readyClient = NewHTTP2NetworkClient(t.Endpoint(), client)
 	}
+	h2n := t.readyClient
+	t.clientsLock.Unlock()
 
-	h2n := NewHTTP2NetworkClient(t.Endpoint(), client)
 	if _, err := h2n.GetNodeInfo(); err != nil {
 		return false
 	}
@@ -204,19 +387,82 @@ func (t *HTTP2Network) IsReady() bool {
 
 // Start will start `HTTP2Network`.
 func (t *HTTP2Network) Start() (err error) {
-	defer func() {
-		close(t.receiveChannel)
-	}()
+	defer t.closeReceiveChannel()
 
-	if strings.ToLower(t.config.Endpoint.Scheme) == "http" {
+	if t.config.Endpoint.Normalize().Scheme == "http" {
 		return t.server.ListenAndServe()
 	}
 
+	var certificates []tls.Certificate
+	if certificates, err = t.config.tlsCertificates(); err != nil {
+		return
+	} else if len(certificates) > 0 {
+		t.server.TLSConfig.Certificates = certificates
+		return t.server.ListenAndServeTLS("", "")
+	}
+
 	return t.server.ListenAndServeTLS(t.tlsCertFile, t.tlsKeyFile)
 }
 
 func (t *HTTP2Network) Stop() {
 	t.server.Close()
+	t.closeClients()
+	removeReceiveChannel(t.receiveChannel)
+}
+
+// receive sends the message to the receive channel. If the channel is full,
+// it waits for `HTTP2NetworkConfig.ReceiveTimeout` and then the message is
+// rejected with `errors.ErrorReceiveChannelFull` instead of blocking the
+// handler.
+func (t *HTTP2Network) receive(msg common.NetworkMessage) error {
+	t.receiveLock.RLock()
+	if t.receiveClosed {
+		t.receiveLock.RUnlock()
+		return errors.ErrorReceiveChannelClosed
+	}
+	t.receiveWait.Add(1)
+	t.receiveLock.RUnlock()
+	defer t.receiveWait.Done()
+
+	select {
+	case t.receiveChannel <- msg:
+		return nil
+	default:
+	}
+
+	HTTP2ReceiveChannelBlocked.Inc()
+
+	if t.config.ReceiveTimeout > 0 {
+		timer := time.NewTimer(t.config.ReceiveTimeout)
+		defer timer.Stop()
+
+		select {
+		case t.receiveChannel <- msg:
+			return nil
+		case <-timer.C:
+		}
+	}
+
+	HTTP2ReceiveChannelDropped.Inc()
+	t.log.Warn("receive channel is full; message is dropped", "type", msg.Type, "depth", len(t.receiveChannel))
+
+	return errors.ErrorReceiveChannelFull
+}
+
+// closeReceiveChannel closes the receive channel. The new `receive()` is
+// rejected with `errors.ErrorReceiveChannelClosed` and the channel is closed
+// after the in-flight `receive()` calls are finished.
+func (t *HTTP2Network) closeReceiveChannel() {
+	t.receiveLock.Lock()
+	if t.receiveClosed {
+		t.receiveLock.Unlock()
+		return
+	}
+	t.receiveClosed = true
+	t.receiveLock.Unlock()
+
+	t.receiveWait.Wait()
+	close(t.receiveChannel)
 }
 
 func (t *HTTP2Network) ReceiveChannel() chan common.NetworkMessage {
@@ -1,12 +1,21 @@
 package network
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strconv"
 	"time"
 
+	"boscoin.io/sebak/lib/block"
 	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/error"
 	"boscoin.io/sebak/lib/node"
 )
 
@@ -53,14 +62,18 @@ func (c *HTTP2NetworkClient) resolvePath(path string) (u *url.URL) {
 	return u
 }
 
-func (c *HTTP2NetworkClient) GetNodeInfo() (body []byte, err error) {
+func (c *HTTP2NetworkClient) GetNodeInfo() ([]byte, error) {
+	return c.GetNodeInfoContext(context.Background())
+}
+
+func (c *HTTP2NetworkClient) GetNodeInfoContext(ctx context.Context) (body []byte, err error) {
 	headers := c.DefaultHeaders()
 	headers.Set("Content-Type", "application/json")
 
 	u := c.resolvePath(UrlPathPrefixNode + "/")
 
 	var response *http.Response
-	response, err = c.client.Get(u.String(), headers)
+	response, err = c.client.GetContext(ctx, u.String(), headers)
 	if err != nil {
 		return
 	}
@@ -71,13 +84,17 @@ func (c *HTTP2NetworkClient) GetNodeInfo() (body []byte, err error) {
 	return
 }
 
-func (c *HTTP2NetworkClient) Connect(n node.Node) (body []byte, err error) {
+func (c *HTTP2NetworkClient) Connect(n node.Node) ([]byte, error) {
+	return c.ConnectContext(context.Background(), n)
+}
+
+func (c *HTTP2NetworkClient) ConnectContext(ctx context.Context, n node.Node) (body []byte, err error) {
 	headers := c.DefaultHeaders()
 	headers.Set("Content-Type", "application/json")
 
 	serialized, _ := n.Serialize()
 	var response *http.Response
-	response, err = c.client.Post(c.resolvePath(UrlPathPrefixNode+"/connect").String(), serialized, headers)
+	response, err = c.client.PostContext(ctx, c.resolvePath(UrlPathPrefixNode+"/connect").String(), serialized, headers)
 	if err != nil {
 		return
 	}
@@ -88,7 +105,11 @@ func (c *HTTP2NetworkClient) Connect(n node.Node) (body []byte, err error) {
 	return
 }
 
-func (c *HTTP2NetworkClient) SendMessage(message common.Serializable) (retBody []byte, err error) {
+func (c *HTTP2NetworkClient) SendMessage(message common.Serializable) ([]byte, error) {
+	return c.SendMessageContext(context.Background(), message)
+}
+
+func (c *HTTP2NetworkClient) SendMessageContext(ctx context.Context, message common.Serializable) (retBody []byte, err error) {
 	headers := c.DefaultHeaders()
 	headers.Set("Content-Type", "application/json")
 
@@ -100,7 +121,7 @@ func (c *HTTP2NetworkClient) SendMessage(message common.Serializable) (retBody [
 	u := c.resolvePath(UrlPathPrefixNode + "/message")
 
 	var response *http.Response
-	response, err = c.client.Post(u.String(), body, headers)
+	response, err = c.client.PostContext(ctx, u.String(), body, headers)
 	if err != nil {
 		return
 	}
@@ -112,7 +133,11 @@ func (c *HTTP2NetworkClient) SendMessage(message common.Serializable) (retBody [
 	return
 }
 
-func (c *HTTP2NetworkClient) SendBallot(message common.Serializable) (retBody []byte, err error) {
+func (c *HTTP2NetworkClient) SendBallot(message common.Serializable) ([]byte, error) {
+	return c.SendBallotContext(context.Background(), message)
+}
+
+func (c *HTTP2NetworkClient) SendBallotContext(ctx context.Context, message common.Serializable) (retBody []byte, err error) {
 	headers := c.DefaultHeaders()
 	headers.Set("Content-Type", "application/json")
 
@@ -124,7 +149,7 @@ func (c *HTTP2NetworkClient) SendBallot(message common.Serializable) (retBody []
 	u := c.resolvePath(UrlPathPrefixNode + "/ballot")
 
 	var response *http.Response
-	response, err = c.client.Post(u.String(), body, headers)
+	response, err = c.client.PostContext(ctx, u.String(), body, headers)
 	if err != nil {
 		return
 	}
@@ -136,6 +161,257 @@ func (c *HTTP2NetworkClient) SendBallot(message common.Serializable) (retBody []
 	return
 }
 
+func (c *HTTP2NetworkClient) GetBlock(hash string) (block.Block, error) {
+	return c.GetBlockContext(context.Background(), hash)
+}
+
+func (c *HTTP2NetworkClient) GetBlockContext(ctx context.Context, hash string) (blk block.Block, err error) {
+	if !common.IsValidHashString(hash) {
+		err = errors.ErrorInvalidHash
+		return
+	}
+
+	headers := c.DefaultHeaders()
+	headers.Set("Accept", "application/json")
+
+	u := c.resolvePath(UrlPathPrefixNode + "/block/" + hash)
+
+	var response *http.Response
+	if response, err = c.client.GetContext(ctx, u.String(), headers); err != nil {
+		return
+	}
+	defer response.Body.Close()
+
+	switch response.StatusCode {
+	case http.StatusOK:
+		var body []byte
+		if body, err = ioutil.ReadAll(response.Body); err != nil {
+			return
+		}
+		err = json.Unmarshal(body, &blk)
+	case http.StatusNotFound:
+		err = errors.ErrorBlockNotFound
+	default:
+		err = fmt.Errorf("failed to get block: status=%d", response.StatusCode)
+	}
+
+	return
+}
+
+func (c *HTTP2NetworkClient) GetBlocks(start, end uint64) ([]block.Block, error) {
+	return c.GetBlocksContext(context.Background(), start, end)
+}
+
+// GetBlocksContext requests the blocks of the height range to the node
+// blocks API. The blocks are requested in the block binary format, which is
+// more compact than JSON; see `block.ReadBlockStream()`. The older node, which
+// does not know the binary format, responds 400, so the blocks are requested
+// again as the JSON items, like `block {...}`.
+func (c *HTTP2NetworkClient) GetBlocksContext(ctx context.Context, start, end uint64) (blocks []block.Block, err error) {
+	if end <= start {
+		err = errors.ErrorInvalidQueryString
+		return
+	}
+
+	mode := "binary"
+
+	var response *http.Response
+	if response, err = c.requestBlocks(ctx, start, end, mode); err != nil {
+		return
+	}
+	if response.StatusCode == http.StatusBadRequest {
+		response.Body.Close()
+
+		mode = "block"
+		if response, err = c.requestBlocks(ctx, start, end, mode); err != nil {
+			return
+		}
+	}
+	defer response.Body.Close()
+
+	switch response.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		err = errors.ErrorBlockNotFound
+		return
+	default:
+		err = fmt.Errorf("failed to get blocks: status=%d", response.StatusCode)
+		return
+	}
+
+	if mode == "binary" {
+		blocks, err = block.ReadBlockStream(response.Body)
+	} else {
+		blocks, err = readBlockItems(response.Body)
+	}
+	if err != nil {
+		return
+	}
+
+	if uint64(len(blocks)) != end-start {
+		err = errors.ErrorBlockNotFound
+	}
+
+	return
+}
+
+func (c *HTTP2NetworkClient) GetBlockTransactions(start, end uint64) ([]block.BlockTransaction, error) {
+	return c.GetBlockTransactionsContext(context.Background(), start, end)
+}
+
+// GetBlockTransactionsContext requests the blocks of the height range with
+// their transactions, `full` mode, to the node blocks API and returns the
+// transactions.
+func (c *HTTP2NetworkClient) GetBlockTransactionsContext(ctx context.Context, start, end uint64) (bts []block.BlockTransaction, err error) {
+	if end <= start {
+		err = errors.ErrorInvalidQueryString
+		return
+	}
+
+	var response *http.Response
+	if response, err = c.requestBlocks(ctx, start, end, "full"); err != nil {
+		return
+	}
+	defer response.Body.Close()
+
+	switch response.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		err = errors.ErrorBlockNotFound
+		return
+	default:
+		err = fmt.Errorf("failed to get blocks: status=%d", response.StatusCode)
+		return
+	}
+
+	return readBlockTransactionItems(response.Body)
+}
+
+func (c *HTTP2NetworkClient) requestBlocks(ctx context.Context, start, end uint64, mode string) (*http.Response, error) {
+	headers := c.DefaultHeaders()
+	if mode == "binary" {
+		headers.Set("Accept", "application/octet-stream")
+	} else {
+		headers.Set("Accept", "application/json")
+	}
+
+	u := c.resolvePath(UrlPathPrefixNode + "/blocks")
+	q := u.Query()
+	q.Set("height-range", fmt.Sprintf("%d-%d", start, end))
+	q.Set("limit", strconv.FormatUint(end-start, 10))
+	q.Set("mode", mode)
+	u.RawQuery = q.Encode()
+
+	return c.client.GetContext(ctx, u.String(), headers)
+}
+
+// readBlockItems reads the blocks from the response of node blocks API, which
+// has one item by line; the items except block are ignored and the `error`
+// item fails.
+func readBlockItems(r io.Reader) (blocks []block.Block, err error) {
+	err = readNodeItems(r, func(itemType string, b []byte) (err error) {
+		if itemType != "block" {
+			return
+		}
+
+		var blk block.Block
+		if err = json.Unmarshal(b, &blk); err != nil {
+			return
+		}
+		blocks = append(blocks, blk)
+
+		return
+	})
+
+	return
+}
+
+// readBlockTransactionItems reads the transactions from the response of node
+// blocks API in `full` mode like `readBlockItems`.
+func readBlockTransactionItems(r io.Reader) (bts []block.BlockTransaction, err error) {
+	err = readNodeItems(r, func(itemType string, b []byte) (err error) {
+		if itemType != "block-transaction" {
+			return
+		}
+
+		var bt block.BlockTransaction
+		if err = json.Unmarshal(b, &bt); err != nil {
+			return
+		}
+		bts = append(bts, bt)
+
+		return
+	})
+
+	return
+}
+
+// readNodeItems calls `f` with the type and data of the items of the node
+// API response, which has one item by line, like `block {...}`; the `error`
+// item fails.
+func readNodeItems(r io.Reader, f func(string, []byte) error) (err error) {
+	reader := bufio.NewReader(r)
+	for {
+		var line []byte
+		line, err = reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return
+		}
+		eof := err == io.EOF
+		err = nil
+
+		if sp := bytes.SplitN(bytes.TrimSpace(line), []byte(" "), 2); len(sp) == 2 {
+			if string(sp[0]) == "error" {
+				err = fmt.Errorf("failed to get blocks: %s", sp[1])
+				return
+			}
+			if err = f(string(sp[0]), sp[1]); err != nil {
+				return
+			}
+		}
+
+		if eof {
+			break
+		}
+	}
+
+	return
+}
+
+func (c *HTTP2NetworkClient) GetAccountSigners(address string) (block.AccountSigners, error) {
+	return c.GetAccountSignersContext(context.Background(), address)
+}
+
+// GetAccountSignersContext requests the signers of account to the accounts
+// API of node.
+func (c *HTTP2NetworkClient) GetAccountSignersContext(ctx context.Context, address string) (signers block.AccountSigners, err error) {
+	headers := c.DefaultHeaders()
+	headers.Set("Accept", "application/json")
+
+	u := c.resolvePath(UrlPathPrefixAPI + "/v1/accounts/" + url.PathEscape(address) + "/signers")
+
+	var response *http.Response
+	if response, err = c.client.GetContext(ctx, u.String(), headers); err != nil {
+		return
+	}
+	defer response.Body.Close()
+
+	switch response.StatusCode {
+	case http.StatusOK:
+		var body []byte
+		if body, err = ioutil.ReadAll(response.Body); err != nil {
+			return
+		}
+		err = json.Unmarshal(body, &signers)
+	case http.StatusNotFound:
+		err = errors.ErrorBlockAccountDoesNotExists
+	default:
+		err = fmt.Errorf("failed to get account signers: status=%d", response.StatusCode)
+	}
+
+	return
+}
+
 ///
 /// Perform a raw Get request on this peer
 ///
@@ -1,13 +1,45 @@
 package network
 
 import (
+	"crypto/tls"
 	"errors"
+	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
 	"boscoin.io/sebak/lib/common"
 )
 
+// DefaultMaxRequestBodyBytes is the default limit of request body. It is
+// enough for the ballot, which has `common.MaxTransactionsInBallot`
+// transactions and the transaction, which has
+// `common.MaxOperationsInTransaction` operations.
+const DefaultMaxRequestBodyBytes int64 = 4 * 1024 * 1024 // 4MiB
+
+// DefaultSlowRequestThreshold is the default duration of request, which is
+// logged as slow request.
+const DefaultSlowRequestThreshold time.Duration = time.Second
+
+// DefaultReceiveChannelSize is the default size of the buffer of the channel
+// for the received messages.
+const DefaultReceiveChannelSize int = 1024
+
+// DefaultReceiveTimeout is the default duration to wait for the receive
+// channel, when it is full.
+const DefaultReceiveTimeout time.Duration = 3 * time.Second
+
+// DefaultTLSMinVersion is the default minimum TLS version of the HTTPS
+// server.
+const DefaultTLSMinVersion uint16 = tls.VersionTLS12
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
 type HTTP2NetworkConfig struct {
 	NodeName string
 	Endpoint *common.Endpoint
@@ -20,6 +52,37 @@ type HTTP2NetworkConfig struct {
 
 	TLSCertFile,
 	TLSKeyFile string
+	// TLSCertPEM and TLSKeyPEM are the PEM encoded certificate and key. They
+	// can be used instead of `TLSCertFile` and `TLSKeyFile`, but not together.
+	TLSCertPEM,
+	TLSKeyPEM []byte
+
+	// TLSMinVersion is the minimum TLS version, which the server accepts.
+	TLSMinVersion uint16
+	// TLSCipherSuites is the list of the allowed cipher suites for TLS 1.2
+	// and lower. If empty, the default cipher suites of go are used.
+	TLSCipherSuites []uint16
+
+	MaxRequestBodyBytes int64
+
+	// SlowRequestThreshold is the duration of request, which is logged as
+	// slow request. `0` disables the slow request logging.
+	SlowRequestThreshold time.Duration
+
+	// ReceiveChannelSize is the size of the buffer of the receive channel.
+	ReceiveChannelSize int
+	// ReceiveTimeout is the duration to wait, when the receive channel is
+	// full. After that, the message is rejected with
+	// `errors.ErrorReceiveChannelFull`. `0` rejects it immediately.
+	ReceiveTimeout time.Duration
+
+	// ClientTimeout, ClientDialTimeout, ClientIdleTimeout and
+	// ClientMaxIdleConns are used for the outbound clients to the other
+	// nodes; see `common.HTTP2ClientConfig`.
+	ClientTimeout,
+	ClientDialTimeout,
+	ClientIdleTimeout time.Duration
+	ClientMaxIdleConns int
 }
 
 func NewHTTP2NetworkConfigFromEndpoint(nodeName string, endpoint *common.Endpoint) (config *HTTP2NetworkConfig, err error) {
@@ -30,6 +93,14 @@ func NewHTTP2NetworkConfigFromEndpoint(nodeName string, endpoint *common.Endpoin
 	var WriteTimeout time.Duration = 0
 	var IdleTimeout time.Duration = 5
 	var TLSCertFile, TLSKeyFile string
+	var MaxRequestBodyBytes int64
+	var SlowRequestThreshold time.Duration
+	var ReceiveChannelSize int64
+	var ReceiveTimeout time.Duration
+	var TLSMinVersion uint16
+	var ClientTimeout, ClientDialTimeout, ClientIdleTimeout time.Duration
+	var ClientMaxIdleConns int64
+	var TLSCipherSuites []uint16
 
 	if ReadTimeout, err = time.ParseDuration(common.GetUrlQuery(query, "ReadTimeout", "0s")); err != nil {
 		return
@@ -63,14 +134,88 @@ func NewHTTP2NetworkConfigFromEndpoint(nodeName string, endpoint *common.Endpoin
 		return
 	}
 
+	if MaxRequestBodyBytes, err = strconv.ParseInt(common.GetUrlQuery(query, "MaxRequestBodyBytes", strconv.FormatInt(DefaultMaxRequestBodyBytes, 10)), 10, 64); err != nil {
+		return
+	}
+	if MaxRequestBodyBytes < 1 {
+		err = errors.New("invalid 'MaxRequestBodyBytes'")
+		return
+	}
+
+	if SlowRequestThreshold, err = time.ParseDuration(common.GetUrlQuery(query, "SlowRequestThreshold", DefaultSlowRequestThreshold.String())); err != nil {
+		return
+	}
+	if SlowRequestThreshold < 0*time.Second {
+		err = errors.New("invalid 'SlowRequestThreshold'")
+		return
+	}
+
+	if ReceiveChannelSize, err = strconv.ParseInt(common.GetUrlQuery(query, "ReceiveChannelSize", strconv.Itoa(DefaultReceiveChannelSize)), 10, 64); err != nil {
+		return
+	}
+	if ReceiveChannelSize < 0 {
+		err = errors.New("invalid 'ReceiveChannelSize'")
+		return
+	}
+
+	if ReceiveTimeout, err = time.ParseDuration(common.GetUrlQuery(query, "ReceiveTimeout", DefaultReceiveTimeout.String())); err != nil {
+		return
+	}
+	if ReceiveTimeout < 0*time.Second {
+		err = errors.New("invalid 'ReceiveTimeout'")
+		return
+	}
+
+	if ClientTimeout, err = time.ParseDuration(common.GetUrlQuery(query, "ClientTimeout", "0s")); err != nil {
+		return
+	}
+	if ClientTimeout < 0*time.Second {
+		err = errors.New("invalid 'ClientTimeout'")
+		return
+	}
+
+	if ClientDialTimeout, err = time.ParseDuration(common.GetUrlQuery(query, "ClientDialTimeout", common.DefaultHTTP2ClientDialTimeout.String())); err != nil {
+		return
+	}
+	if ClientDialTimeout < 0*time.Second {
+		err = errors.New("invalid 'ClientDialTimeout'")
+		return
+	}
+
+	if ClientIdleTimeout, err = time.ParseDuration(common.GetUrlQuery(query, "ClientIdleTimeout", "0s")); err != nil {
+		return
+	}
+	if ClientIdleTimeout < 0*time.Second {
+		err = errors.New("invalid 'ClientIdleTimeout'")
+		return
+	}
+
+	if ClientMaxIdleConns, err = strconv.ParseInt(common.GetUrlQuery(query, "ClientMaxIdleConns", "0"), 10, 64); err != nil {
+		return
+	}
+	if ClientMaxIdleConns < 0 {
+		err = errors.New("invalid 'ClientMaxIdleConns'")
+		return
+	}
+
 	TLSCertFile = query.Get("TLSCertFile")
 	TLSKeyFile = query.Get("TLSKeyFile")
 
-	if strings.ToLower(endpoint.Scheme) == "https" && (len(TLSCertFile) < 1 || len(TLSKeyFile) < 1) {
+	// without both files, `TLSCertPEM` and `TLSKeyPEM` can be set later; they
+	// are checked by `tlsCertificates()`.
+	if endpoint.Normalize().Scheme == "https" && (len(TLSCertFile) < 1) != (len(TLSKeyFile) < 1) {
 		err = errors.New("HTTPS needs `TLSCertFile` and `TLSKeyFile`")
 		return
 	}
 
+	if TLSMinVersion, err = parseTLSVersion(query.Get("TLSMinVersion")); err != nil {
+		return
+	}
+
+	if TLSCipherSuites, err = parseTLSCipherSuites(query.Get("TLSCipherSuites")); err != nil {
+		return
+	}
+
 	config = &HTTP2NetworkConfig{
 		NodeName:          nodeName,
 		Endpoint:          endpoint,
@@ -81,11 +226,116 @@ func NewHTTP2NetworkConfigFromEndpoint(nodeName string, endpoint *common.Endpoin
 		IdleTimeout:       IdleTimeout,
 		TLSCertFile:       TLSCertFile,
 		TLSKeyFile:        TLSKeyFile,
+		TLSMinVersion:     TLSMinVersion,
+		TLSCipherSuites:   TLSCipherSuites,
+
+		MaxRequestBodyBytes:  MaxRequestBodyBytes,
+		SlowRequestThreshold: SlowRequestThreshold,
+		ReceiveChannelSize:   int(ReceiveChannelSize),
+		ReceiveTimeout:       ReceiveTimeout,
+
+		ClientTimeout:      ClientTimeout,
+		ClientDialTimeout:  ClientDialTimeout,
+		ClientIdleTimeout:  ClientIdleTimeout,
+		ClientMaxIdleConns: int(ClientMaxIdleConns),
+	}
+
+	return
+}
+
+// tlsCertificates checks exactly one of the files and the PEMs is set. If the
+// PEMs are set, it returns the certificate from them; if the files are set,
+// it returns nothing and the files are loaded by `http.Server`.
+func (config *HTTP2NetworkConfig) tlsCertificates() ([]tls.Certificate, error) {
+	hasFile := len(config.TLSCertFile) > 0 || len(config.TLSKeyFile) > 0
+	hasPEM := len(config.TLSCertPEM) > 0 || len(config.TLSKeyPEM) > 0
+
+	if hasFile && hasPEM {
+		return nil, errors.New("set only one of `TLSCertFile` and `TLSKeyFile`, or `TLSCertPEM` and `TLSKeyPEM`")
+	} else if !hasFile && !hasPEM {
+		return nil, errors.New("HTTPS needs `TLSCertFile` and `TLSKeyFile`, or `TLSCertPEM` and `TLSKeyPEM`")
+	} else if hasFile {
+		return nil, nil
+	}
+
+	cert, err := tls.X509KeyPair(config.TLSCertPEM, config.TLSKeyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("invalid `TLSCertPEM` and `TLSKeyPEM`: %v", err)
+	}
+
+	return []tls.Certificate{cert}, nil
+}
+
+// parseTLSVersion parses the TLS version like "1.2". The empty string is
+// `DefaultTLSMinVersion`.
+func parseTLSVersion(s string) (uint16, error) {
+	if len(s) < 1 {
+		return DefaultTLSMinVersion, nil
+	}
+
+	version, found := tlsVersions[s]
+	if !found {
+		return 0, fmt.Errorf("invalid 'TLSMinVersion': %q; must be one of 1.0, 1.1, 1.2 and 1.3", s)
+	}
+
+	return version, nil
+}
+
+// parseTLSCipherSuites parses the comma separated names of cipher suites, like
+// "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256". The cipher suites, which are not
+// allowed by HTTP/2(RFC 7540, Appendix A) are rejected.
+func parseTLSCipherSuites(s string) (suites []uint16, err error) {
+	if len(strings.TrimSpace(s)) < 1 {
+		return
+	}
+
+	known := map[string]uint16{}
+	for _, c := range tls.CipherSuites() {
+		known[c.Name] = c.ID
+	}
+	for _, c := range tls.InsecureCipherSuites() {
+		known[c.Name] = c.ID
+	}
+
+	var hasRequired bool
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		id, found := known[name]
+		if !found {
+			err = fmt.Errorf("invalid 'TLSCipherSuites': unknown cipher suite, %q", name)
+			return
+		}
+		if !isHTTP2CipherSuite(name) {
+			err = fmt.Errorf("invalid 'TLSCipherSuites': %q is not allowed in HTTP/2", name)
+			return
+		}
+		if id == tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256 || id == tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256 {
+			hasRequired = true
+		}
+
+		suites = append(suites, id)
+	}
+
+	if !hasRequired {
+		err = errors.New(
+			"invalid 'TLSCipherSuites': HTTP/2 needs 'TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256' or 'TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256'",
+		)
+		return
 	}
 
 	return
 }
 
+// isHTTP2CipherSuite checks the cipher suite is not in the HTTP/2 blacklist;
+// HTTP/2 allows only the ephemeral key exchange with AEAD ciphers.
+func isHTTP2CipherSuite(name string) bool {
+	if !strings.HasPrefix(name, "TLS_ECDHE_") {
+		return false
+	}
+
+	return strings.Contains(name, "_GCM_") || strings.Contains(name, "_CHACHA20_POLY1305")
+}
+
 func (config HTTP2NetworkConfig) IsHTTPS() bool {
 	return len(config.TLSCertFile) > 0 && len(config.TLSKeyFile) > 0
 }
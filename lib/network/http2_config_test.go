@@ -1,9 +1,12 @@
 package network
 
 import (
+	"crypto/tls"
 	"fmt"
+	"io/ioutil"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -70,3 +73,190 @@ func TestHTTP2NetworkConfigHTTPSAndTLS(t *testing.T) {
 		require.Nil(t, err)
 	}
 }
+
+func TestHTTP2NetworkConfigTLSMinVersion(t *testing.T) {
+	newEndpoint := func(queryValues url.Values) *common.Endpoint {
+		return &common.Endpoint{
+			Scheme:   "http",
+			Host:     fmt.Sprintf("localhost:%s", getPort()),
+			RawQuery: queryValues.Encode(),
+		}
+	}
+
+	{ // default
+		config, err := NewHTTP2NetworkConfigFromEndpoint("showme", newEndpoint(url.Values{}))
+		require.Nil(t, err)
+		require.Equal(t, uint16(tls.VersionTLS12), config.TLSMinVersion)
+		require.Nil(t, config.TLSCipherSuites)
+	}
+
+	{ // TLS 1.3
+		queryValues := url.Values{}
+		queryValues.Set("TLSMinVersion", "1.3")
+		config, err := NewHTTP2NetworkConfigFromEndpoint("showme", newEndpoint(queryValues))
+		require.Nil(t, err)
+		require.Equal(t, uint16(tls.VersionTLS13), config.TLSMinVersion)
+	}
+
+	{ // unknown version
+		queryValues := url.Values{}
+		queryValues.Set("TLSMinVersion", "2.0")
+		_, err := NewHTTP2NetworkConfigFromEndpoint("showme", newEndpoint(queryValues))
+		require.NotNil(t, err)
+	}
+}
+
+func TestHTTP2NetworkConfigTLSCipherSuites(t *testing.T) {
+	newEndpoint := func(suites string) *common.Endpoint {
+		queryValues := url.Values{}
+		queryValues.Set("TLSCipherSuites", suites)
+		return &common.Endpoint{
+			Scheme:   "http",
+			Host:     fmt.Sprintf("localhost:%s", getPort()),
+			RawQuery: queryValues.Encode(),
+		}
+	}
+
+	{ // valid
+		config, err := NewHTTP2NetworkConfigFromEndpoint(
+			"showme",
+			newEndpoint("TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256"),
+		)
+		require.Nil(t, err)
+		require.Equal(
+			t,
+			[]uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256},
+			config.TLSCipherSuites,
+		)
+	}
+
+	{ // unknown cipher suite
+		_, err := NewHTTP2NetworkConfigFromEndpoint("showme", newEndpoint("TLS_SHOWME"))
+		require.NotNil(t, err)
+	}
+
+	{ // CBC cipher suite is not allowed in HTTP/2
+		_, err := NewHTTP2NetworkConfigFromEndpoint(
+			"showme",
+			newEndpoint("TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA"),
+		)
+		require.NotNil(t, err)
+		require.Contains(t, err.Error(), "not allowed in HTTP/2")
+	}
+
+	{ // missing the cipher suite, which HTTP/2 requires
+		_, err := NewHTTP2NetworkConfigFromEndpoint("showme", newEndpoint("TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"))
+		require.NotNil(t, err)
+	}
+}
+
+func TestHTTP2NetworkConfigClient(t *testing.T) {
+	{ // default
+		endpoint := &common.Endpoint{
+			Scheme: "http",
+			Host:   fmt.Sprintf("localhost:%s", getPort()),
+		}
+
+		config, err := NewHTTP2NetworkConfigFromEndpoint("showme", endpoint)
+		require.Nil(t, err)
+		require.Equal(t, time.Duration(0), config.ClientTimeout)
+		require.Equal(t, common.DefaultHTTP2ClientDialTimeout, config.ClientDialTimeout)
+		require.Equal(t, time.Duration(0), config.ClientIdleTimeout)
+		require.Equal(t, 0, config.ClientMaxIdleConns)
+	}
+
+	{ // configured
+		queryValues := url.Values{}
+		queryValues.Set("ClientTimeout", "10s")
+		queryValues.Set("ClientDialTimeout", "3s")
+		queryValues.Set("ClientIdleTimeout", "1m")
+		queryValues.Set("ClientMaxIdleConns", "10")
+
+		endpoint := &common.Endpoint{
+			Scheme:   "http",
+			Host:     fmt.Sprintf("localhost:%s", getPort()),
+			RawQuery: queryValues.Encode(),
+		}
+
+		config, err := NewHTTP2NetworkConfigFromEndpoint("showme", endpoint)
+		require.Nil(t, err)
+		require.Equal(t, 10*time.Second, config.ClientTimeout)
+		require.Equal(t, 3*time.Second, config.ClientDialTimeout)
+		require.Equal(t, time.Minute, config.ClientIdleTimeout)
+		require.Equal(t, 10, config.ClientMaxIdleConns)
+	}
+
+	for _, name := range []string{"ClientTimeout", "ClientDialTimeout", "ClientIdleTimeout", "ClientMaxIdleConns"} {
+		queryValues := url.Values{}
+		if name == "ClientMaxIdleConns" {
+			queryValues.Set(name, "-1")
+		} else {
+			queryValues.Set(name, "-1s")
+		}
+
+		endpoint := &common.Endpoint{
+			Scheme:   "http",
+			Host:     fmt.Sprintf("localhost:%s", getPort()),
+			RawQuery: queryValues.Encode(),
+		}
+
+		_, err := NewHTTP2NetworkConfigFromEndpoint("showme", endpoint)
+		require.NotNil(t, err, name)
+	}
+}
+
+func TestHTTP2NetworkConfigTLSPEM(t *testing.T) {
+	g := NewKeyGenerator("tls_tmp", "sebak.cert", "sebak.key")
+	defer g.Close()
+
+	certPEM, err := ioutil.ReadFile(g.GetCertPath())
+	require.Nil(t, err)
+	keyPEM, err := ioutil.ReadFile(g.GetKeyPath())
+	require.Nil(t, err)
+
+	endpoint := &common.Endpoint{
+		Scheme: "https",
+		Host:   fmt.Sprintf("localhost:%s", getPort()),
+	}
+
+	{ // without files and PEMs
+		config, err := NewHTTP2NetworkConfigFromEndpoint("showme", endpoint)
+		require.Nil(t, err)
+
+		_, err = config.tlsCertificates()
+		require.NotNil(t, err)
+	}
+
+	{ // PEMs
+		config, err := NewHTTP2NetworkConfigFromEndpoint("showme", endpoint)
+		require.Nil(t, err)
+		config.TLSCertPEM = certPEM
+		config.TLSKeyPEM = keyPEM
+
+		certificates, err := config.tlsCertificates()
+		require.Nil(t, err)
+		require.Equal(t, 1, len(certificates))
+	}
+
+	{ // files and PEMs
+		config, err := NewHTTP2NetworkConfigFromEndpoint("showme", endpoint)
+		require.Nil(t, err)
+		config.TLSCertFile = g.GetCertPath()
+		config.TLSKeyFile = g.GetKeyPath()
+		config.TLSCertPEM = certPEM
+		config.TLSKeyPEM = keyPEM
+
+		_, err = config.tlsCertificates()
+		require.NotNil(t, err)
+	}
+
+	{ // invalid PEM
+		config, err := NewHTTP2NetworkConfigFromEndpoint("showme", endpoint)
+		require.Nil(t, err)
+		config.TLSCertPEM = certPEM
+		config.TLSKeyPEM = []byte("findme")
+
+		_, err = config.tlsCertificates()
+		require.NotNil(t, err)
+	}
+}
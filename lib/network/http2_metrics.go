@@ -0,0 +1,119 @@
+package network
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"boscoin.io/sebak/lib/common"
+)
+
+// HTTP2RequestDurations is the histogram of the durations of the requests by
+// the method and route. It is exposed by the metrics endpoint, `/metrics`.
+var HTTP2RequestDurations = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Namespace: "sebak",
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Durations of the HTTP requests by method and route.",
+		Buckets:   prometheus.DefBuckets,
+	},
+	[]string{"method", "route"},
+)
+
+var (
+	// HTTP2ReceiveChannelBlocked counts the received messages, which could
+	// not be sent to the receive channel at once because it was full.
+	HTTP2ReceiveChannelBlocked = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "sebak",
+		Subsystem: "network",
+		Name:      "receive_channel_blocked_total",
+		Help:      "Number of the received messages, which waited for the full receive channel.",
+	})
+
+	// HTTP2ReceiveChannelDropped counts the received messages, which are
+	// dropped because the receive channel was full.
+	HTTP2ReceiveChannelDropped = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "sebak",
+		Subsystem: "network",
+		Name:      "receive_channel_dropped_total",
+		Help:      "Number of the received messages, which are dropped by the full receive channel.",
+	})
+
+	// HTTP2ReceiveChannelDepth is the number of the messages, which are
+	// waiting in the receive channels.
+	HTTP2ReceiveChannelDepth = prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Namespace: "sebak",
+			Subsystem: "network",
+			Name:      "receive_channel_depth",
+			Help:      "Number of the messages waiting in the receive channel.",
+		},
+		receiveChannelDepth,
+	)
+)
+
+var receiveChannels = struct {
+	sync.RWMutex
+	channels map[chan common.NetworkMessage]bool
+}{channels: map[chan common.NetworkMessage]bool{}}
+
+func addReceiveChannel(ch chan common.NetworkMessage) {
+	receiveChannels.Lock()
+	defer receiveChannels.Unlock()
+
+	receiveChannels.channels[ch] = true
+}
+
+func removeReceiveChannel(ch chan common.NetworkMessage) {
+	receiveChannels.Lock()
+	defer receiveChannels.Unlock()
+
+	delete(receiveChannels.channels, ch)
+}
+
+func receiveChannelDepth() float64 {
+	receiveChannels.RLock()
+	defer receiveChannels.RUnlock()
+
+	var depth int
+	for ch := range receiveChannels.channels {
+		depth += len(ch)
+	}
+
+	return float64(depth)
+}
+
+func init() {
+	prometheus.MustRegister(HTTP2RequestDurations)
+	prometheus.MustRegister(HTTP2ReceiveChannelBlocked)
+	prometheus.MustRegister(HTTP2ReceiveChannelDropped)
+	prometheus.MustRegister(HTTP2ReceiveChannelDepth)
+}
+
+// routeNameUnknown is the route of the request, which does not match with any
+// route.
+const routeNameUnknown = "unknown"
+
+// routeName returns the path template of the route, which matches with the
+// request, like `/api/v1/accounts/{id}`. The path template is used instead of
+// the request path to keep the number of labels small.
+func routeName(router *mux.Router, r *http.Request) string {
+	if router == nil {
+		return routeNameUnknown
+	}
+
+	var match mux.RouteMatch
+	if !router.Match(r, &match) || match.Route == nil {
+		return routeNameUnknown
+	}
+
+	template, err := match.Route.GetPathTemplate()
+	if err != nil {
+		return routeNameUnknown
+	}
+
+	return template
+}
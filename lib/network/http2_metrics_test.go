@@ -0,0 +1,46 @@
+package network
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/error"
+)
+
+func counterValue(t *testing.T, counter prometheus.Counter) float64 {
+	m := &dto.Metric{}
+	require.Nil(t, counter.Write(m))
+
+	return m.GetCounter().GetValue()
+}
+
+func TestHTTP2NetworkReceiveChannelFull(t *testing.T) {
+	endpoint, err := common.NewEndpointFromString(
+		fmt.Sprintf("http://localhost:%s?ReceiveChannelSize=2&ReceiveTimeout=0s", getPort()),
+	)
+	require.Nil(t, err)
+
+	config, err := NewHTTP2NetworkConfigFromEndpoint("showme", endpoint)
+	require.Nil(t, err)
+	network := NewHTTP2Network(config)
+	defer network.Stop()
+
+	dropped := counterValue(t, HTTP2ReceiveChannelDropped)
+	broker := network.MessageBroker()
+
+	require.Nil(t, broker.Receive(common.NetworkMessage{Type: common.TransactionMessage}))
+	require.Nil(t, broker.Receive(common.NetworkMessage{Type: common.TransactionMessage}))
+	require.True(t, receiveChannelDepth() >= 2)
+
+	require.Equal(t, errors.ErrorReceiveChannelFull, broker.Receive(common.NetworkMessage{Type: common.TransactionMessage}))
+	require.Equal(t, dropped+1, counterValue(t, HTTP2ReceiveChannelDropped))
+
+	// consumed
+	<-network.ReceiveMessage()
+	require.Nil(t, broker.Receive(common.NetworkMessage{Type: common.TransactionMessage}))
+}
@@ -2,7 +2,9 @@ package network
 
 import (
 	"net/http"
+	"time"
 
+	"github.com/gorilla/mux"
 	logging "github.com/inconshreveable/log15"
 
 	"boscoin.io/sebak/lib/common"
@@ -56,6 +58,13 @@ func (l *HTTP2ResponseLog15Writer) Flush() {
 type HTTP2Log15Handler struct {
 	log     logging.Logger
 	handler http.Handler
+
+	// router is used to find the route of request for
+	// `HTTP2RequestDurations`.
+	router *mux.Router
+	// slowThreshold is the duration of request, which is logged as slow
+	// request. `0` disables the slow request logging.
+	slowThreshold time.Duration
 }
 
 var HeaderKeyFiltered []string = []string{
@@ -102,13 +111,31 @@ func (l HTTP2Log15Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		"user-agent", r.UserAgent(),
 	)
 
+	route := routeName(l.router, r)
+
 	writer := &HTTP2ResponseLog15Writer{w: w}
+	started := time.Now()
 	l.handler.ServeHTTP(writer, r)
+	elapsed := time.Since(started)
+
+	HTTP2RequestDurations.WithLabelValues(r.Method, route).Observe(elapsed.Seconds())
 
 	l.log.Debug(
 		"response",
 		"id", uid,
 		"status", writer.Status(),
 		"size", writer.Size(),
+		"elapsed", elapsed,
 	)
+
+	if l.slowThreshold > 0 && elapsed > l.slowThreshold {
+		l.log.Warn(
+			"slow request",
+			"id", uid,
+			"method", r.Method,
+			"path", r.URL.Path,
+			"route", route,
+			"duration", elapsed,
+		)
+	}
 }
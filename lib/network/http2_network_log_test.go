@@ -0,0 +1,88 @@
+package network
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	logging "github.com/inconshreveable/log15"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+)
+
+func histogramSampleCount(t *testing.T, method, route string) uint64 {
+	m := &dto.Metric{}
+	require.Nil(t, HTTP2RequestDurations.WithLabelValues(method, route).(prometheus.Metric).Write(m))
+
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestHTTP2Log15HandlerSlowRequest(t *testing.T) {
+	var lock sync.Mutex
+	var records []*logging.Record
+
+	logger := logging.New()
+	logger.SetHandler(logging.FuncHandler(func(r *logging.Record) error {
+		lock.Lock()
+		defer lock.Unlock()
+		records = append(records, r)
+		return nil
+	}))
+
+	router := mux.NewRouter()
+	router.HandleFunc("/slow/{id}", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+	})
+	router.HandleFunc("/fast", func(w http.ResponseWriter, r *http.Request) {})
+
+	handler := HTTP2Log15Handler{
+		log:           logger,
+		handler:       router,
+		router:        router,
+		slowThreshold: 20 * time.Millisecond,
+	}
+
+	slowCount := histogramSampleCount(t, "GET", "/slow/{id}")
+	fastCount := histogramSampleCount(t, "GET", "/fast")
+
+	findSlowRequest := func() (found *logging.Record) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		for _, r := range records {
+			if r.Msg == "slow request" {
+				found = r
+			}
+		}
+		return
+	}
+
+	{ // fast request is not logged as slow request
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/fast", nil))
+		require.Nil(t, findSlowRequest())
+		require.Equal(t, fastCount+1, histogramSampleCount(t, "GET", "/fast"))
+	}
+
+	{ // slow request
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/slow/showme", nil))
+
+		record := findSlowRequest()
+		require.NotNil(t, record)
+		require.Equal(t, logging.LvlWarn, record.Lvl)
+
+		ctx := map[string]interface{}{}
+		for i := 0; i < len(record.Ctx); i += 2 {
+			ctx[record.Ctx[i].(string)] = record.Ctx[i+1]
+		}
+		require.Equal(t, "GET", ctx["method"])
+		require.Equal(t, "/slow/showme", ctx["path"])
+		require.True(t, ctx["duration"].(time.Duration) >= 50*time.Millisecond)
+
+		// the histogram is recorded by the route, not by the path
+		require.Equal(t, slowCount+1, histogramSampleCount(t, "GET", "/slow/{id}"))
+	}
+}
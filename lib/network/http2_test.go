@@ -1,20 +1,32 @@
 package network
 
 import (
+	"bytes"
+	"context"
 	"crypto/tls"
-	"errors"
+	"encoding/json"
+	"encoding/pem"
 	"fmt"
+	"io/ioutil"
 	"math/rand"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"strconv"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/stellar/go/keypair"
 	"github.com/stretchr/testify/require"
 
+	"boscoin.io/sebak/lib/ballot"
+	"boscoin.io/sebak/lib/block"
 	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/consensus/round"
+	"boscoin.io/sebak/lib/error"
+	"boscoin.io/sebak/lib/network/httputils"
 )
 
 func getPort() string {
@@ -41,6 +53,11 @@ func makeTestHTTP2NetworkForTLS(endpoint *common.Endpoint) (network *HTTP2Networ
 		return
 	}
 
+	return startTestHTTP2Network(config)
+}
+
+func startTestHTTP2Network(config *HTTP2NetworkConfig) (network *HTTP2Network, err error) {
+	endpoint := config.Endpoint
 	network = NewHTTP2Network(config)
 	go network.Start()
 
@@ -121,6 +138,86 @@ func TestHTTP2NetworkTLSSupport(t *testing.T) {
 	}
 }
 
+// TestHTTP2NetworkTLSMinVersion checks the client, which uses the lower TLS
+// version than `TLSMinVersion` fails the handshake.
+func TestHTTP2NetworkTLSMinVersion(t *testing.T) {
+	g := NewKeyGenerator("tls_tmp", "sebak.cert", "sebak.key")
+	defer g.Close()
+
+	queryValues := url.Values{}
+	queryValues.Set("TLSCertFile", g.GetCertPath())
+	queryValues.Set("TLSKeyFile", g.GetKeyPath())
+
+	endpoint := &common.Endpoint{
+		Scheme:   "https",
+		Host:     fmt.Sprintf("localhost:%s", getPort()),
+		RawQuery: queryValues.Encode(),
+	}
+
+	network, err := makeTestHTTP2NetworkForTLS(endpoint)
+	require.Nil(t, err)
+	defer network.Stop()
+
+	{ // TLS 1.1 is rejected
+		conn, err := tls.Dial("tcp", endpoint.Host, &tls.Config{
+			InsecureSkipVerify: true,
+			MinVersion:         tls.VersionTLS10,
+			MaxVersion:         tls.VersionTLS11,
+		})
+		if conn != nil {
+			conn.Close()
+		}
+		require.NotNil(t, err)
+	}
+
+	{ // TLS 1.2 is accepted
+		conn, err := tls.Dial("tcp", endpoint.Host, &tls.Config{
+			InsecureSkipVerify: true,
+			MaxVersion:         tls.VersionTLS12,
+		})
+		require.Nil(t, err)
+		require.Equal(t, uint16(tls.VersionTLS12), conn.ConnectionState().Version)
+		conn.Close()
+	}
+}
+
+// TestHTTP2NetworkTLSPEM checks `HTTP2Network` serves TLS with the
+// certificate from `TLSCertPEM` and `TLSKeyPEM`.
+func TestHTTP2NetworkTLSPEM(t *testing.T) {
+	g := NewKeyGenerator("tls_tmp", "sebak.cert", "sebak.key")
+	defer g.Close()
+
+	certPEM, err := ioutil.ReadFile(g.GetCertPath())
+	require.Nil(t, err)
+	keyPEM, err := ioutil.ReadFile(g.GetKeyPath())
+	require.Nil(t, err)
+
+	endpoint := &common.Endpoint{
+		Scheme: "https",
+		Host:   fmt.Sprintf("localhost:%s", getPort()),
+	}
+
+	config, err := NewHTTP2NetworkConfigFromEndpoint("showme", endpoint)
+	require.Nil(t, err)
+	config.TLSCertPEM = certPEM
+	config.TLSKeyPEM = keyPEM
+
+	network, err := startTestHTTP2Network(config)
+	require.Nil(t, err)
+	defer network.Stop()
+
+	conn, err := tls.Dial("tcp", endpoint.Host, &tls.Config{InsecureSkipVerify: true})
+	require.Nil(t, err)
+	defer conn.Close()
+
+	require.Nil(t, conn.Handshake())
+	certs := conn.ConnectionState().PeerCertificates
+	require.Equal(t, 1, len(certs))
+
+	block, _ := pem.Decode(certPEM)
+	require.Equal(t, block.Bytes, certs[0].Raw)
+}
+
 // TestHTTP2NetworkWithoutTLS will test the HTTP2Network without TLS support.
 // Without TLS configurations, `TLSCertFile`, `TLSKeyFile`, `HTTP2Network`
 // will be `HTTP` server, not `HTTPS`.
@@ -153,3 +250,408 @@ func TestHTTP2NetworkWithoutTLS(t *testing.T) {
 		require.Nil(t, err)
 	}
 }
+
+// TestHTTP2NetworkClientPool checks `GetClient()` reuses the client for same
+// endpoint and `Stop()` closes them.
+func TestHTTP2NetworkClientPool(t *testing.T) {
+	endpoint, err := common.NewEndpointFromString(
+		fmt.Sprintf("http://localhost:%s", getPort()),
+	)
+	require.Nil(t, err)
+
+	config, err := NewHTTP2NetworkConfigFromEndpoint("showme", endpoint)
+	require.Nil(t, err)
+	network := NewHTTP2Network(config)
+
+	target, _ := common.NewEndpointFromString("https://localhost:12345")
+	other, _ := common.NewEndpointFromString("https://localhost:12346")
+
+	client0 := network.GetClient(target).(*HTTP2NetworkClient)
+	client1 := network.GetClient(target).(*HTTP2NetworkClient)
+	require.True(t, client0 == client1)
+	require.True(t, client0.client == client1.client)
+
+	client2 := network.GetClient(other).(*HTTP2NetworkClient)
+	require.False(t, client0.client == client2.client)
+	require.Equal(t, 2, len(network.clients))
+
+	network.Stop()
+	require.Equal(t, 0, len(network.clients))
+
+	// after `Stop()`, new client is created
+	client3 := network.GetClient(target).(*HTTP2NetworkClient)
+	require.False(t, client0 == client3)
+}
+
+// TestHTTP2NetworkStartError checks the receive channel is closed safely,
+// when `Start()` fails immediately.
+func TestHTTP2NetworkStartError(t *testing.T) {
+	queryValues := url.Values{}
+	queryValues.Set("TLSCertFile", "/showme/unknown.crt")
+	queryValues.Set("TLSKeyFile", "/showme/unknown.key")
+
+	endpoint := &common.Endpoint{
+		Scheme:   "https",
+		Host:     fmt.Sprintf("localhost:%s", getPort()),
+		RawQuery: queryValues.Encode(),
+	}
+
+	config, err := NewHTTP2NetworkConfigFromEndpoint("showme", endpoint)
+	require.Nil(t, err)
+	network := NewHTTP2Network(config)
+	defer network.Stop()
+	network.Ready()
+
+	broker := network.MessageBroker()
+	message := common.NetworkMessage{Type: common.TransactionMessage}
+
+	// receive concurrently while starting
+	var wg sync.WaitGroup
+	receiveErrors := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if err := broker.Receive(message); err != nil && err != errors.ErrorReceiveChannelClosed {
+					receiveErrors <- err
+					return
+				}
+			}
+		}()
+	}
+
+	require.NotNil(t, network.Start())
+	wg.Wait()
+	close(receiveErrors)
+	for err := range receiveErrors {
+		require.Nil(t, err)
+	}
+
+	// after the channel is closed
+	require.Equal(t, errors.ErrorReceiveChannelClosed, broker.Receive(message))
+	for range network.ReceiveMessage() {
+	}
+}
+
+// TestHTTP2NetworkClientTimeout checks the outbound client of `GetClient()`
+// follows the configured timeouts.
+func TestHTTP2NetworkClientTimeout(t *testing.T) {
+	{ // dial to the dead endpoint fails within `ClientDialTimeout`
+		queryValues := url.Values{}
+		queryValues.Set("ClientDialTimeout", "100ms")
+		endpoint := &common.Endpoint{
+			Scheme:   "http",
+			Host:     fmt.Sprintf("localhost:%s", getPort()),
+			RawQuery: queryValues.Encode(),
+		}
+
+		config, err := NewHTTP2NetworkConfigFromEndpoint("showme", endpoint)
+		require.Nil(t, err)
+		network := NewHTTP2Network(config)
+		defer network.Stop()
+
+		// non-routable address
+		target, _ := common.NewEndpointFromString("http://10.255.255.1:12345")
+		client := network.GetClient(target)
+
+		started := time.Now()
+		_, err = client.GetNodeInfo()
+		require.NotNil(t, err)
+		require.True(t, time.Since(started) < time.Second)
+	}
+
+	{ // no response from the endpoint fails within `ClientTimeout`
+		listener, err := net.Listen("tcp", "localhost:0")
+		require.Nil(t, err)
+		defer listener.Close()
+
+		queryValues := url.Values{}
+		queryValues.Set("ClientTimeout", "100ms")
+		endpoint := &common.Endpoint{
+			Scheme:   "http",
+			Host:     fmt.Sprintf("localhost:%s", getPort()),
+			RawQuery: queryValues.Encode(),
+		}
+
+		config, err := NewHTTP2NetworkConfigFromEndpoint("showme", endpoint)
+		require.Nil(t, err)
+		network := NewHTTP2Network(config)
+		defer network.Stop()
+
+		target, _ := common.NewEndpointFromString("http://" + listener.Addr().String())
+		client := network.GetClient(target)
+
+		started := time.Now()
+		_, err = client.GetNodeInfo()
+		require.NotNil(t, err)
+		require.True(t, time.Since(started) < time.Second)
+	}
+}
+
+// TestHTTP2NetworkClientSlowFirstByte checks the slow first byte over the
+// dial timeout does not fail, if it is within the request timeout, and the
+// request timeout can be set by endpoint.
+func TestHTTP2NetworkClientSlowFirstByte(t *testing.T) {
+	delay := 300 * time.Millisecond
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(delay)
+		w.Write([]byte("findme"))
+	}))
+	defer server.Close()
+
+	queryValues := url.Values{}
+	queryValues.Set("ClientDialTimeout", "100ms")
+	queryValues.Set("ClientTimeout", "2s")
+	endpoint := &common.Endpoint{
+		Scheme:   "http",
+		Host:     fmt.Sprintf("localhost:%s", getPort()),
+		RawQuery: queryValues.Encode(),
+	}
+
+	config, err := NewHTTP2NetworkConfigFromEndpoint("showme", endpoint)
+	require.Nil(t, err)
+	network := NewHTTP2Network(config)
+	defer network.Stop()
+
+	{ // first byte comes after the dial timeout, but within the request timeout
+		target, _ := common.NewEndpointFromString(server.URL)
+		b, err := network.GetClient(target).GetNodeInfo()
+		require.Nil(t, err)
+		require.Equal(t, "findme", string(b))
+	}
+
+	{ // the request timeout of endpoint is shorter than the delay
+		// the client is cached by the normalized endpoint, so the other host
+		// name is used
+		u, _ := url.Parse(server.URL)
+		target, _ := common.NewEndpointFromString(fmt.Sprintf("http://localhost:%s?ClientTimeout=100ms", u.Port()))
+		_, err := network.GetClient(target).GetNodeInfo()
+		require.NotNil(t, err)
+		require.Equal(t, ClientErrorTimeout, ClassifyClientError(err))
+	}
+
+	{ // nothing listens
+		listener, err := net.Listen("tcp", "localhost:0")
+		require.Nil(t, err)
+		addr := listener.Addr().String()
+		listener.Close()
+
+		target, _ := common.NewEndpointFromString("http://" + addr)
+		_, err = network.GetClient(target).GetNodeInfo()
+		require.NotNil(t, err)
+		require.Equal(t, ClientErrorDial, ClassifyClientError(err))
+	}
+}
+
+// TestHTTP2MaxBytesHandler checks the request body over the
+// `MaxRequestBodyBytes` is rejected with 413 and the ballot, which has
+// `common.MaxTransactionsInBallot` transactions, can pass with the default.
+func TestHTTP2MaxBytesHandler(t *testing.T) {
+	var received []byte
+	handler := HTTP2MaxBytesHandler{
+		max: DefaultMaxRequestBodyBytes,
+		handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := ioutil.ReadAll(r.Body)
+			if httputils.IsRequestBodyTooLarge(err) {
+				w.WriteHeader(http.StatusRequestEntityTooLarge)
+				return
+			}
+			require.Nil(t, err)
+			received = body
+		}),
+	}
+
+	kp, _ := keypair.Random()
+	var hashes []string
+	for i := 0; i < common.MaxTransactionsInBallot; i++ {
+		hashes = append(hashes, common.GetUniqueIDFromUUID())
+	}
+	b := ballot.NewBallot(kp.Address(), round.Round{}, hashes)
+	b.Sign(kp, []byte("sebak-test-network"))
+	body, err := b.Serialize()
+	require.Nil(t, err)
+
+	{ // normal ballot
+		r := httptest.NewRequest("POST", "/node/ballot", bytes.NewReader(body))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, body, received)
+	}
+
+	oversized := bytes.Repeat([]byte("0"), int(DefaultMaxRequestBodyBytes)+1)
+
+	received = nil
+
+	{ // oversized body with `Content-Length`
+		r := httptest.NewRequest("POST", "/node/ballot", bytes.NewReader(oversized))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		require.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	}
+
+	{ // oversized body without `Content-Length`
+		r := httptest.NewRequest("POST", "/node/ballot", ioutil.NopCloser(bytes.NewReader(oversized)))
+		r.ContentLength = -1
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		require.Nil(t, received)
+	}
+}
+
+// TestHTTP2NetworkClientContext checks the canceled context aborts the
+// in-flight request before the timeout of client.
+func TestHTTP2NetworkClientContext(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	queryValues := url.Values{}
+	queryValues.Set("ClientTimeout", "10s")
+	endpoint := &common.Endpoint{
+		Scheme:   "http",
+		Host:     fmt.Sprintf("localhost:%s", getPort()),
+		RawQuery: queryValues.Encode(),
+	}
+
+	config, err := NewHTTP2NetworkConfigFromEndpoint("showme", endpoint)
+	require.Nil(t, err)
+	network := NewHTTP2Network(config)
+	defer network.Stop()
+
+	target, _ := common.NewEndpointFromString(server.URL)
+	client := network.GetClient(target)
+
+	{ // canceled while waiting for the response
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(100*time.Millisecond, cancel)
+
+		started := time.Now()
+		_, err := client.GetNodeInfoContext(ctx)
+		require.NotNil(t, err)
+		require.True(t, time.Since(started) < time.Second)
+		require.Equal(t, context.Canceled, ctx.Err())
+	}
+
+	{ // deadline
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		ballot := common.NewNetworkMessage(common.BallotMessage, []byte("{}"))
+		started := time.Now()
+		_, err := client.SendBallotContext(ctx, ballot)
+		require.NotNil(t, err)
+		require.True(t, time.Since(started) < time.Second)
+	}
+
+	{ // already canceled
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := client.GetBlockContext(ctx, common.MustMakeObjectHashString("findme"))
+		require.NotNil(t, err)
+	}
+}
+
+// TestHTTP2NetworkClientGetBlocks checks the blocks are requested in the
+// block binary format and, if the node does not know the binary format, in
+// JSON items again.
+func TestHTTP2NetworkClientGetBlocks(t *testing.T) {
+	blocks := []block.Block{
+		block.TestMakeNewBlock(nil),
+		block.TestMakeNewBlock(nil),
+	}
+
+	var supportBinary bool
+	var modes []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mode := r.URL.Query().Get("mode")
+		modes = append(modes, mode)
+
+		switch {
+		case mode == "binary" && supportBinary:
+			block.WriteBlockStream(w, blocks)
+		case mode == "block":
+			for _, b := range blocks {
+				s, _ := json.Marshal(b)
+				fmt.Fprintf(w, "block %s\n", s)
+			}
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	endpoint := &common.Endpoint{Scheme: "http", Host: fmt.Sprintf("localhost:%s", getPort())}
+	config, err := NewHTTP2NetworkConfigFromEndpoint("showme", endpoint)
+	require.Nil(t, err)
+	network := NewHTTP2Network(config)
+	defer network.Stop()
+
+	target, _ := common.NewEndpointFromString(server.URL)
+	client := network.GetClient(target)
+
+	for _, binary := range []bool{true, false} {
+		supportBinary = binary
+		modes = nil
+
+		received, err := client.GetBlocks(1, 3)
+		require.Nil(t, err)
+		require.Equal(t, len(blocks), len(received))
+		for i, b := range blocks {
+			require.Equal(t, b.Hash, received[i].Hash)
+		}
+
+		if binary {
+			require.Equal(t, []string{"binary"}, modes)
+		} else {
+			require.Equal(t, []string{"binary", "block"}, modes)
+		}
+	}
+}
+
+// TestHTTP2NetworkClientGetBlockTransactions checks the transactions are
+// read from the blocks in `full` mode; the operations are ignored.
+func TestHTTP2NetworkClientGetBlockTransactions(t *testing.T) {
+	bt := block.TestMakeNewBlockTransaction([]byte("sebak-test-network"), 1)
+
+	var modes []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		modes = append(modes, r.URL.Query().Get("mode"))
+
+		s, _ := json.Marshal(block.TestMakeNewBlock([]string{bt.Hash}))
+		fmt.Fprintf(w, "block %s\n", s)
+		s, _ = json.Marshal(bt)
+		fmt.Fprintf(w, "block-transaction %s\n", s)
+		fmt.Fprintf(w, "block-operation {}\n")
+	}))
+	defer server.Close()
+
+	endpoint := &common.Endpoint{Scheme: "http", Host: fmt.Sprintf("localhost:%s", getPort())}
+	config, err := NewHTTP2NetworkConfigFromEndpoint("showme", endpoint)
+	require.Nil(t, err)
+	network := NewHTTP2Network(config)
+	defer network.Stop()
+
+	target, _ := common.NewEndpointFromString(server.URL)
+	client := network.GetClient(target)
+
+	received, err := client.GetBlockTransactions(1, 2)
+	require.Nil(t, err)
+	require.Equal(t, []string{"full"}, modes)
+	require.Equal(t, 1, len(received))
+	require.Equal(t, bt.Hash, received[0].Hash)
+	require.Equal(t, bt.Message, received[0].Message)
+}
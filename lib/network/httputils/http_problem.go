@@ -38,6 +38,9 @@ type problem struct {
 	//occurrence of the problem.  It may or may not yield further
 	//information if dereferenced.
 	Instance string `json:"instance,omitempty"`
+
+	// "data" is the extension member; the `Data` of `errors.Error`.
+	Data map[string]interface{} `json:"data,omitempty"`
 }
 
 func NewProblem(problemType string, title string) problem {
@@ -60,6 +63,7 @@ func NewErrorProblem(err error, status int) problem {
 	var p problem
 	if e, ok := err.(*errors.Error); ok {
 		p = NewProblem(fmt.Sprintf("%s%d", HttpProblemErrorTypePrefix, e.Code), e.Message)
+		p.Data = e.Data
 	} else {
 		p = NewProblem(HttpProblemDefaultType, err.Error())
 	}
@@ -62,9 +62,59 @@ var (
 		143: 400,
 		144: 400,
 		145: 400,
+		157: 400,
+		158: 400,
+		159: 400,
+		160: 400,
+		161: 400,
+		162: 413,
+		163: 500,
+		164: 400,
+		165: 400,
+		166: 400,
+		167: 400,
+		168: 400,
+		169: 503,
+		170: 400,
+		171: 503,
+		172: 400,
+		173: 400,
+		174: 400,
+		175: 400,
+		176: 400,
+		177: 400,
+		178: 400,
+		179: 400,
+		180: 400,
+		181: 503,
+		182: 400,
+		183: 500,
+		184: 400,
+		185: 400,
+		186: 400,
+		187: 400,
+		188: 400,
+		189: 400,
+		190: 400,
+		191: 400,
+		192: 401,
+		193: 400,
+		194: 400,
+		195: 400,
+		196: 400,
+		198: 400,
+		199: 400,
+		205: 409,
 	}
 )
 
+// IsRequestBodyTooLarge checks the error from reading request body, which is
+// limited by `http.MaxBytesReader`.
+func IsRequestBodyTooLarge(err error) bool {
+	_, ok := err.(*http.MaxBytesError)
+	return ok
+}
+
 func StatusCode(err error) int {
 	if e, ok := err.(*errors.Error); ok {
 		return ErrorsToStatus[e.Code]
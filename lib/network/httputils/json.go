@@ -2,9 +2,14 @@ package httputils
 
 import (
 	"encoding/json"
+	"io"
 	"net/http"
+	"reflect"
 
 	"github.com/nvellon/hal"
+
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/error"
 )
 
 type HALResource interface {
@@ -45,3 +50,57 @@ func WriteJSON(w http.ResponseWriter, code int, v interface{}) error {
 
 	return nil
 }
+
+// JSONDecodeError converts the syntax and type errors of `encoding/json` to
+// `errors.ErrorMalformedJSON`. Instead of the go types, the field path and the
+// json type which is expected are set in the data of error. The other errors
+// are returned as they are.
+func JSONDecodeError(err error) error {
+	if err == io.ErrUnexpectedEOF {
+		return errors.ErrorMalformedJSON.Clone().SetData("reason", "unexpected end of json")
+	}
+
+	switch e := err.(type) {
+	case *json.SyntaxError:
+		return errors.ErrorMalformedJSON.Clone().
+			SetData("offset", e.Offset).
+			SetData("reason", e.Error())
+	case *json.UnmarshalTypeError:
+		// the error of `json.Unmarshaler`, like `common.Amount`, may not
+		// have the field
+		merr := errors.ErrorMalformedJSON.Clone()
+		if len(e.Field) > 0 {
+			merr.SetData("field", e.Field)
+		}
+		return merr.
+			SetData("expected", jsonTypeName(e.Type)).
+			SetData("actual", e.Value)
+	}
+
+	return err
+}
+
+func jsonTypeName(t reflect.Type) string {
+	// `common.Amount` is the string of integer in json
+	if t == reflect.TypeOf(common.Amount(0)) {
+		return "amount"
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Array, reflect.Slice:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	}
+
+	return t.Kind().String()
+}
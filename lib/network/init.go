@@ -12,5 +12,5 @@ func init() {
 }
 
 func SetLogging(level logging.Lvl, handler logging.Handler) {
-	log.SetHandler(logging.LvlFilterHandler(level, handler))
+	common.SetLogging(log, level, handler)
 }
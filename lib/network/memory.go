@@ -3,25 +3,50 @@ package network
 import (
 	"net"
 	"net/http"
+	"sync"
+	"time"
 
+	"boscoin.io/sebak/lib/block"
 	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/error"
+	"boscoin.io/sebak/lib/storage"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 )
 
 type MemoryNetwork struct {
 	localNode  common.Serializable
+	storage    *storage.LevelDBBackend
 	endpoint   *common.Endpoint
 	connWriter chan common.NetworkMessage
 	close      chan bool
+	// stopped is closed after `Stop()`, so the messages sent to the stopped
+	// network are dropped.
+	stopped chan struct{}
 
 	receiveChannel chan common.NetworkMessage
 	// They all share the same map to find each other
 	peers map[ /* endpoint */ string]*MemoryNetwork
+
+	deliveryLock      sync.Mutex
+	flushLock         sync.Mutex
+	deliveryDelay     time.Duration
+	deliveryScheduler DeliveryScheduler
+	pending           []common.NetworkMessage
+	flushTimer        *time.Timer
 }
 
+// DeliveryManual is the delivery delay of `MemoryNetwork`, which holds the
+// messages until `FlushPending()` is called.
+const DeliveryManual time.Duration = -1
+
+// DeliveryScheduler decides the order of the pending messages of
+// `MemoryNetwork`. It must return the same order for the same messages, so
+// the tests can reproduce the order.
+type DeliveryScheduler func([]common.NetworkMessage) []common.NetworkMessage
+
 func (t *MemoryNetwork) GetClient(endpoint *common.Endpoint) NetworkClient {
-	n, ok := t.peers[endpoint.String()]
+	n, ok := t.peers[endpoint.Normalize().String()]
 	if !ok {
 		panic("Trying to get inexistant client, this is a bug in the tests!")
 	}
@@ -37,7 +62,7 @@ func (p *MemoryNetwork) Endpoint() *common.Endpoint {
 }
 
 func (p *MemoryNetwork) Start() error {
-	defer close(p.connWriter)
+	defer close(p.stopped)
 
 	p.receiveMessage()
 
@@ -69,11 +94,80 @@ func (p *MemoryNetwork) GetNodeInfo() []byte {
 }
 
 func (p *MemoryNetwork) Send(mt common.MessageType, b []byte) (err error) {
-	p.connWriter <- common.NewNetworkMessage(mt, b)
+	message := common.NewNetworkMessage(mt, b)
+
+	p.deliveryLock.Lock()
+	if p.deliveryDelay == 0 {
+		p.deliveryLock.Unlock()
+		p.write(message)
+		return
+	}
+
+	p.pending = append(p.pending, message)
+	if p.deliveryDelay > 0 && p.flushTimer == nil {
+		p.flushTimer = time.AfterFunc(p.deliveryDelay, func() { p.FlushPending() })
+	}
+	p.deliveryLock.Unlock()
 
 	return
 }
 
+// SetDeliveryDelay sets the delay of the received messages. With `0`, the
+// default, the message is delivered at once. With the positive delay, the
+// messages are held and delivered together after the delay since the first
+// held message. With `DeliveryManual`, they are held until `FlushPending()`.
+func (p *MemoryNetwork) SetDeliveryDelay(d time.Duration) {
+	p.deliveryLock.Lock()
+	defer p.deliveryLock.Unlock()
+
+	p.deliveryDelay = d
+}
+
+// SetDeliveryScheduler sets the scheduler, which orders the held messages in
+// `FlushPending()`; by default, they are delivered in the received order.
+func (p *MemoryNetwork) SetDeliveryScheduler(s DeliveryScheduler) {
+	p.deliveryLock.Lock()
+	defer p.deliveryLock.Unlock()
+
+	p.deliveryScheduler = s
+}
+
+// FlushPending delivers the held messages in the order of the scheduler and
+// returns the number of the delivered messages. It returns after all the
+// messages are delivered.
+func (p *MemoryNetwork) FlushPending() int {
+	// the batches of the concurrent flushes are not mixed
+	p.flushLock.Lock()
+	defer p.flushLock.Unlock()
+
+	p.deliveryLock.Lock()
+	pending := p.pending
+	p.pending = nil
+	if p.flushTimer != nil {
+		p.flushTimer.Stop()
+		p.flushTimer = nil
+	}
+	scheduler := p.deliveryScheduler
+	p.deliveryLock.Unlock()
+
+	if scheduler != nil && len(pending) > 0 {
+		pending = scheduler(pending)
+	}
+
+	for _, message := range pending {
+		p.write(message)
+	}
+
+	return len(pending)
+}
+
+func (p *MemoryNetwork) write(message common.NetworkMessage) {
+	select {
+	case p.connWriter <- message:
+	case <-p.stopped:
+	}
+}
+
 func (p *MemoryNetwork) ReceiveChannel() chan common.NetworkMessage {
 	return p.receiveChannel
 }
@@ -86,13 +180,95 @@ func (p *MemoryNetwork) receiveMessage() {
 	for {
 		select {
 		case <-p.close:
-			break
+			return
 		case d := <-p.connWriter:
-			p.receiveChannel <- d
+			select {
+			case <-p.close:
+				return
+			case p.receiveChannel <- d:
+			}
 		}
 	}
 }
 
+// SetStorage sets the storage, which is used to find the block by
+// `GetBlock` and `GetBlocks`, the transactions by `GetBlockTransactions`,
+// and the account by `GetAccountSigners`.
+func (p *MemoryNetwork) SetStorage(st *storage.LevelDBBackend) {
+	p.storage = st
+}
+
+func (p *MemoryNetwork) GetBlock(hash string) (block.Block, error) {
+	if p.storage == nil {
+		return block.Block{}, errors.ErrorBlockNotFound
+	}
+
+	if exists, err := block.ExistsBlock(p.storage, hash); err != nil {
+		return block.Block{}, err
+	} else if !exists {
+		return block.Block{}, errors.ErrorBlockNotFound
+	}
+
+	return block.GetBlock(p.storage, hash)
+}
+
+func (p *MemoryNetwork) GetBlocks(start, end uint64) (blocks []block.Block, err error) {
+	if p.storage == nil {
+		return nil, errors.ErrorBlockNotFound
+	}
+
+	for height := start; height < end; height++ {
+		var exists bool
+		if exists, err = block.ExistsBlockByHeight(p.storage, height); err != nil {
+			return nil, err
+		} else if !exists {
+			return nil, errors.ErrorBlockNotFound
+		}
+
+		var blk block.Block
+		if blk, err = block.GetBlockByHeight(p.storage, height); err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, blk)
+	}
+
+	return
+}
+
+func (p *MemoryNetwork) GetBlockTransactions(start, end uint64) (bts []block.BlockTransaction, err error) {
+	var blocks []block.Block
+	if blocks, err = p.GetBlocks(start, end); err != nil {
+		return
+	}
+
+	for _, blk := range blocks {
+		for _, hash := range blk.Transactions {
+			var bt block.BlockTransaction
+			if bt, err = block.GetBlockTransaction(p.storage, hash); err == errors.ErrorStorageRecordDoesNotExist {
+				return nil, errors.ErrorBlockNotFound
+			} else if err != nil {
+				return nil, err
+			}
+			bts = append(bts, bt)
+		}
+	}
+
+	return
+}
+
+func (p *MemoryNetwork) GetAccountSigners(address string) (block.AccountSigners, error) {
+	if p.storage == nil {
+		return block.AccountSigners{}, errors.ErrorBlockAccountDoesNotExists
+	}
+
+	ba, err := block.GetBlockAccount(p.storage, address)
+	if err != nil {
+		return block.AccountSigners{}, err
+	}
+
+	return ba.GetSigners(), nil
+}
+
 func (p *MemoryNetwork) SetLocalNode(localNode common.Serializable) {
 	p.localNode = localNode
 }
@@ -114,14 +290,15 @@ func (prev *MemoryNetwork) NewMemoryNetwork() *MemoryNetwork {
 		connWriter:     make(chan common.NetworkMessage),
 		receiveChannel: make(chan common.NetworkMessage),
 		close:          make(chan bool),
+		stopped:        make(chan struct{}),
 		peers:          peers,
 	}
 
-	n.peers[n.endpoint.String()] = n
+	n.peers[n.endpoint.Normalize().String()] = n
 
 	return n
 }
 
-func (p *MemoryNetwork) AddHandler(string, http.HandlerFunc) *mux.Route {
+func (p *MemoryNetwork) AddHandler(string, http.HandlerFunc, ...string) *mux.Route {
 	return &mux.Route{}
 }
@@ -1,6 +1,9 @@
 package network
 
 import (
+	"context"
+
+	"boscoin.io/sebak/lib/block"
 	"boscoin.io/sebak/lib/common"
 	"boscoin.io/sebak/lib/node"
 )
@@ -51,3 +54,78 @@ func (m *MemoryTransportClient) SendBallot(message common.Serializable) (body []
 
 	return
 }
+
+func (m *MemoryTransportClient) GetBlock(hash string) (block.Block, error) {
+	return m.server.GetBlock(hash)
+}
+
+func (m *MemoryTransportClient) GetBlocks(start, end uint64) ([]block.Block, error) {
+	return m.server.GetBlocks(start, end)
+}
+
+func (m *MemoryTransportClient) GetBlockTransactions(start, end uint64) ([]block.BlockTransaction, error) {
+	return m.server.GetBlockTransactions(start, end)
+}
+
+func (m *MemoryTransportClient) GetAccountSigners(address string) (block.AccountSigners, error) {
+	return m.server.GetAccountSigners(address)
+}
+
+// ConnectContext checks the context is not done and connects. The memory
+// network delivers at once, so like this, the other `Context` variants only
+// check the context.
+func (m *MemoryTransportClient) ConnectContext(ctx context.Context, node node.Node) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return m.Connect(node)
+}
+
+func (m *MemoryTransportClient) GetNodeInfoContext(ctx context.Context) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return m.GetNodeInfo()
+}
+
+func (m *MemoryTransportClient) SendMessageContext(ctx context.Context, message common.Serializable) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return m.SendMessage(message)
+}
+
+func (m *MemoryTransportClient) SendBallotContext(ctx context.Context, message common.Serializable) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return m.SendBallot(message)
+}
+
+func (m *MemoryTransportClient) GetBlockContext(ctx context.Context, hash string) (block.Block, error) {
+	if err := ctx.Err(); err != nil {
+		return block.Block{}, err
+	}
+	return m.GetBlock(hash)
+}
+
+func (m *MemoryTransportClient) GetBlocksContext(ctx context.Context, start, end uint64) ([]block.Block, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return m.GetBlocks(start, end)
+}
+
+func (m *MemoryTransportClient) GetBlockTransactionsContext(ctx context.Context, start, end uint64) ([]block.BlockTransaction, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return m.GetBlockTransactions(start, end)
+}
+
+func (m *MemoryTransportClient) GetAccountSignersContext(ctx context.Context, address string) (block.AccountSigners, error) {
+	if err := ctx.Err(); err != nil {
+		return block.AccountSigners{}, err
+	}
+	return m.GetAccountSigners(address)
+}
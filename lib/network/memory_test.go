@@ -5,10 +5,14 @@ import (
 	"testing"
 	"time"
 
+	"boscoin.io/sebak/lib/ballot"
 	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/consensus/round"
 	"boscoin.io/sebak/lib/node"
 
 	"github.com/btcsuite/btcutil/base58"
+	"github.com/stellar/go/keypair"
+	"github.com/stretchr/testify/require"
 )
 
 type DummyMessage struct {
@@ -75,6 +79,7 @@ func TestMemoryNetworkGetClient(t *testing.T) {
 	}()
 
 	go s0.Start()
+	defer s0.Stop()
 
 	c0 := s0.GetClient(s0.Endpoint())
 
@@ -140,3 +145,95 @@ func TestMemoryNetworkConnect(t *testing.T) {
 		return
 	}
 }
+
+// TestMemoryNetworkReorderedBallots reproduces the delivery, in which the
+// ACCEPT ballot of validator arrives before it's SIGN ballot; the order of the
+// delivery is same in every run. The consensus by this order is tested by
+// `runner.TestISAACSimulationReorderedBallots`.
+func TestMemoryNetworkReorderedBallots(t *testing.T) {
+	kp, _ := keypair.Random()
+	r := round.Round{BlockHeight: 1, BlockHash: "showme"}
+
+	reverse := func(messages []common.NetworkMessage) []common.NetworkMessage {
+		var reversed []common.NetworkMessage
+		for i := len(messages) - 1; i >= 0; i-- {
+			reversed = append(reversed, messages[i])
+		}
+		return reversed
+	}
+
+	run := func() (states []ballot.State) {
+		_, s0, _ := CreateMemoryNetwork(nil)
+		s0.SetDeliveryDelay(DeliveryManual)
+		s0.SetDeliveryScheduler(reverse)
+		go s0.Start()
+		defer s0.Stop()
+
+		c0 := s0.GetClient(s0.Endpoint())
+		for _, state := range []ballot.State{ballot.StateSIGN, ballot.StateACCEPT} {
+			b := ballot.NewBallot(kp.Address(), r, []string{})
+			b.SetVote(state, ballot.VotingYES)
+			b.Sign(kp, []byte("sebak-test-network"))
+			_, err := c0.SendBallot(b)
+			require.Nil(t, err)
+		}
+
+		// nothing is delivered before flush
+		select {
+		case <-s0.ReceiveMessage():
+			t.Fatal("message is delivered before FlushPending")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		done := make(chan int)
+		go func() { done <- s0.FlushPending() }()
+
+		for i := 0; i < 2; i++ {
+			select {
+			case message := <-s0.ReceiveMessage():
+				require.True(t, message.Type == common.BallotMessage)
+				b, err := ballot.NewBallotFromJSON(message.Data)
+				require.Nil(t, err)
+				states = append(states, b.State())
+			case <-time.After(time.Second):
+				t.Fatal("failed to get message")
+			}
+		}
+		require.Equal(t, 2, <-done)
+
+		return
+	}
+
+	for i := 0; i < 5; i++ {
+		require.Equal(t, []ballot.State{ballot.StateACCEPT, ballot.StateSIGN}, run())
+	}
+}
+
+func TestMemoryNetworkDeliveryDelay(t *testing.T) {
+	_, s0, _ := CreateMemoryNetwork(nil)
+	s0.SetDeliveryDelay(100 * time.Millisecond)
+	go s0.Start()
+	defer s0.Stop()
+
+	c0 := s0.GetClient(s0.Endpoint())
+
+	sent := time.Now()
+	for _, data := range []string{"showme", "findme"} {
+		_, err := c0.SendMessage(NewDummyMessage(data))
+		require.Nil(t, err)
+	}
+
+	for _, data := range []string{"showme", "findme"} {
+		select {
+		case message := <-s0.ReceiveMessage():
+			require.True(t, time.Since(sent) >= 100*time.Millisecond)
+			received, err := DummyMessageFromString(message.Data)
+			require.Nil(t, err)
+			require.Equal(t, data, received.Data)
+		case <-time.After(time.Second):
+			t.Fatal("failed to get message")
+		}
+	}
+
+	require.Equal(t, 0, s0.FlushPending())
+}
@@ -0,0 +1,73 @@
+package network
+
+import (
+	"net/http"
+	"sort"
+)
+
+// The orders of the known middlewares. The middleware of the lower order wraps
+// the higher ones, so tracing sees the whole request and auth is checked just
+// before the handler.
+const (
+	MiddlewareOrderTracing   int = 100
+	MiddlewareOrderRateLimit int = 200
+	MiddlewareOrderBodySize  int = 300
+	MiddlewareOrderGzip      int = 400
+	MiddlewareOrderAuth      int = 500
+)
+
+// The names of the middlewares, which are used by default or by the node.
+const (
+	MiddlewareNameLog      string = "log"
+	MiddlewareNameBodySize string = "body-size"
+	MiddlewareNameAuth     string = "auth"
+)
+
+// Middleware wraps the handler of route. `Name` is used to skip the
+// middleware for the route; see `HTTP2Network.AddHandler()`.
+type Middleware struct {
+	Name  string
+	Order int
+	Wrap  func(http.Handler) http.Handler
+}
+
+// MiddlewareChain is the middlewares of one router. The middlewares are
+// applied by `Order`; if the orders are same, the middleware added first is
+// the outer one.
+type MiddlewareChain struct {
+	middlewares []Middleware
+}
+
+func (c *MiddlewareChain) Add(m Middleware) {
+	c.middlewares = append(c.middlewares, m)
+	sort.SliceStable(c.middlewares, func(i, j int) bool {
+		return c.middlewares[i].Order < c.middlewares[j].Order
+	})
+}
+
+// Names returns the names of middlewares by the order, the outermost first.
+func (c *MiddlewareChain) Names() (names []string) {
+	for _, m := range c.middlewares {
+		names = append(names, m.Name)
+	}
+
+	return
+}
+
+// Then wraps the handler with the middlewares except `skip`.
+func (c *MiddlewareChain) Then(handler http.Handler, skip ...string) http.Handler {
+	skipped := map[string]bool{}
+	for _, name := range skip {
+		skipped[name] = true
+	}
+
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		m := c.middlewares[i]
+		if skipped[m.Name] {
+			continue
+		}
+		handler = m.Wrap(handler)
+	}
+
+	return handler
+}
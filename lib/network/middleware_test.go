@@ -0,0 +1,121 @@
+package network
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"boscoin.io/sebak/lib/common"
+)
+
+func TestMiddlewareOrder(t *testing.T) {
+	endpoint, _ := common.NewEndpointFromString("http://localhost:12345")
+	config, err := NewHTTP2NetworkConfigFromEndpoint("showme", endpoint)
+	require.Nil(t, err)
+	network := NewHTTP2Network(config)
+
+	var called []string
+	makeMiddleware := func(name string, order int) Middleware {
+		return Middleware{
+			Name:  name,
+			Order: order,
+			Wrap: func(h http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					called = append(called, name)
+					h.ServeHTTP(w, r)
+					called = append(called, "/"+name)
+				})
+			},
+		}
+	}
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		called = append(called, "handler")
+	}
+
+	// added not by the order
+	network.UseMiddleware(RouterNameAPI, makeMiddleware("auth", MiddlewareOrderAuth))
+	network.UseMiddleware(RouterNameAPI, makeMiddleware("gzip", MiddlewareOrderGzip))
+	network.UseMiddleware(RouterNameAPI, makeMiddleware("tracing", MiddlewareOrderTracing))
+	network.UseMiddleware(RouterNameAPI, makeMiddleware("tracing-2", MiddlewareOrderTracing))
+	require.Equal(
+		t,
+		[]string{
+			MiddlewareNameLog, "tracing", "tracing-2",
+			MiddlewareNameBodySize, "gzip", "auth",
+		},
+		network.middlewares[RouterNameAPI].Names(),
+	)
+
+	network.AddHandler(UrlPathPrefixAPI+"/showme", handler)
+	network.AddHandler(UrlPathPrefixAPI+"/stream", handler, "gzip")
+	network.AddHandler(UrlPathPrefixNode+"/findme", handler)
+
+	serve := func(path string) []string {
+		called = nil
+		w := httptest.NewRecorder()
+		network.router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		return called
+	}
+
+	require.Equal(
+		t,
+		[]string{"tracing", "tracing-2", "gzip", "auth", "handler", "/auth", "/gzip", "/tracing-2", "/tracing"},
+		serve(UrlPathPrefixAPI+"/showme"),
+	)
+
+	// skip gzip
+	require.Equal(
+		t,
+		[]string{"tracing", "tracing-2", "auth", "handler", "/auth", "/tracing-2", "/tracing"},
+		serve(UrlPathPrefixAPI+"/stream"),
+	)
+
+	// the middlewares of api router are not applied to node router
+	require.Equal(t, []string{"handler"}, serve(UrlPathPrefixNode+"/findme"))
+}
+
+// TestDefaultMiddlewares checks the request body limit and the logging are
+// applied to the routers by the default middlewares.
+func TestDefaultMiddlewares(t *testing.T) {
+	endpoint, _ := common.NewEndpointFromString("http://localhost:12345")
+	config, err := NewHTTP2NetworkConfigFromEndpoint("showme", endpoint)
+	require.Nil(t, err)
+	config.MaxRequestBodyBytes = 10
+	network := NewHTTP2Network(config)
+
+	for _, name := range []string{RouterNameBase, RouterNameNode, RouterNameAPI} {
+		require.Equal(
+			t,
+			[]string{MiddlewareNameLog, MiddlewareNameBodySize},
+			network.middlewares[name].Names(),
+		)
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if _, err := ioutil.ReadAll(r.Body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}
+	network.AddHandler(UrlPathPrefixNode+"/findme", handler)
+	network.AddHandler("/showme", handler)
+
+	serve := func(path string, body string) int {
+		w := httptest.NewRecorder()
+		network.server.Handler.ServeHTTP(w, httptest.NewRequest("POST", path, strings.NewReader(body)))
+		return w.Code
+	}
+
+	for _, path := range []string{UrlPathPrefixNode + "/findme", "/showme"} {
+		require.Equal(t, http.StatusOK, serve(path, "0123456789"))
+		require.Equal(t, http.StatusRequestEntityTooLarge, serve(path, "0123456789a"))
+	}
+
+	// not routed
+	require.Equal(t, http.StatusNotFound, serve(UrlPathPrefixAPI+"/killme", ""))
+}
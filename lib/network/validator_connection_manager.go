@@ -1,18 +1,123 @@
 package network
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"net"
 	"net/http"
+	"net/url"
+	"sort"
 	"sync"
 	"time"
 
 	"boscoin.io/sebak/lib/ballot"
 	"boscoin.io/sebak/lib/common"
 	"boscoin.io/sebak/lib/node"
+	"boscoin.io/sebak/lib/storage"
 	logging "github.com/inconshreveable/log15"
 )
 
+var (
+	// MissedHeartbeatsThreshold is the number of the failed connection checks
+	// in a row, before the validator is marked as disconnected.
+	MissedHeartbeatsThreshold int = 1
+
+	// ConnectionStateDebounce is the minimum duration between the changes of
+	// connection state of validator. The change within this duration is
+	// ignored, so the connection state does not oscillate rapidly.
+	ConnectionStateDebounce time.Duration = 500 * time.Millisecond
+
+	// MaxValidatorsSoftLimit is the number of validators, over which
+	// `ValidatorConnectionManager.Start` logs warning. `0` disables the
+	// warning.
+	MaxValidatorsSoftLimit int = 50
+
+	// MaxValidatorsHardLimit is the maximum number of validators;
+	// `ValidatorConnectionManager.Start` refuses to start with more
+	// validators, and `node.LocalNode.AddValidators` refuses to add more by
+	// `node.LocalNode.SetMaxValidators`. `0` disables the limit.
+	//
+	// `Broadcast` sends the messages to each validator by it's own worker, so
+	// the number of the concurrent sends is up to the number of validators,
+	// and every failed send can be retried `BroadcastMaxRetries` times. The
+	// limit keeps the number of workers and the open connections bounded.
+	MaxValidatorsHardLimit int = 200
+
+	// ClockSkewWarningThreshold is the estimated clock skew of validator,
+	// over which the warning is logged. The ballots of the validator, whose
+	// clock is skewed over `common.BallotConfirmedTimeAllowDuration`, are
+	// rejected as not well-formed.
+	ClockSkewWarningThreshold time.Duration = 10 * time.Second
+
+	// EndpointResolveInterval is the interval, by which the host names of
+	// validator endpoints are resolved again in background; see
+	// `ConnectionWatcher`.
+	EndpointResolveInterval time.Duration = time.Minute
+
+	// lookupHost resolves the host of validator endpoint to match it with the
+	// remote address of the closed connection.
+	lookupHost = net.LookupHost
+)
+
+var (
+	// ErrorUnknownValidator is returned by `GetConnection`, when the address
+	// is not of the validators.
+	ErrorUnknownValidator = errors.New("unknown validator")
+
+	// ErrorNoValidatorClient is returned by `GetConnection`, when the client
+	// of the validator can not be made.
+	ErrorNoValidatorClient = errors.New("failed to make client of validator")
+
+	// ErrorValidatorNotConnected is the result of `BroadcastWithResult` for
+	// the validator, which is not connected; the message is not sent to it.
+	ErrorValidatorNotConnected = errors.New("validator is not connected")
+
+	// ErrorMessageAlreadyQueued is returned by `send`, when the same message
+	// is already being sent to the validator.
+	ErrorMessageAlreadyQueued = errors.New("message is already queued")
+
+	// ErrorMessageSuperseded is returned by `send`, when the queued or failed
+	// ballot is dropped for the newer ballot; see `isNewerBallot`.
+	ErrorMessageSuperseded = errors.New("message is superseded by newer round")
+
+	// ErrorOutboundQueueFull is returned by `send`, when the outbound queue of
+	// the validator already has `OutboundQueueSize` messages.
+	ErrorOutboundQueueFull = errors.New("outbound queue is full")
+
+	// ErrorConnectionManagerStopped is returned by `send`, when the
+	// connection manager is stopped before the message is sent.
+	ErrorConnectionManagerStopped = errors.New("connection manager is stopped")
+)
+
+// CheckMaxValidators returns error when the number of validators exceeds
+// `MaxValidatorsHardLimit`.
+func CheckMaxValidators(validators int) error {
+	if MaxValidatorsHardLimit > 0 && validators > MaxValidatorsHardLimit {
+		return fmt.Errorf(
+			"too many validators: %d; the maximum number of validators is %d",
+			validators,
+			MaxValidatorsHardLimit,
+		)
+	}
+
+	return nil
+}
+
+// CheckValidatorsLimits returns error when `MaxValidatorsSoftLimit` exceeds
+// `MaxValidatorsHardLimit`.
+func CheckValidatorsLimits() error {
+	if MaxValidatorsHardLimit > 0 && MaxValidatorsSoftLimit > MaxValidatorsHardLimit {
+		return fmt.Errorf(
+			"soft limit of validators, %d must not exceed the hard limit, %d",
+			MaxValidatorsSoftLimit,
+			MaxValidatorsHardLimit,
+		)
+	}
+
+	return nil
+}
+
 type ValidatorConnectionManager struct {
 	sync.RWMutex
 
@@ -23,6 +128,27 @@ type ValidatorConnectionManager struct {
 	validators map[ /* node.Address() */ string]*node.Validator
 	clients    map[ /* node.Address() */ string]NetworkClient
 	connected  map[ /* node.Address() */ string]bool
+	changed    map[ /* node.Address() */ string]time.Time
+	missed     map[ /* node.Address() */ string]int
+	queues     map[ /* node.Address() */ string]*outboundQueue
+	skews      map[ /* node.Address() */ string]time.Duration
+	lastErrors map[ /* node.Address() */ string]error
+	lastSeen   map[ /* node.Address() */ string]time.Time
+	checking   map[ /* node.Address() */ string]bool
+	resolved   map[ /* node.Address() */ string][]net.IP
+
+	// probe checks the connection of validator, when the connection from it
+	// is closed; by default, `connectValidator`.
+	probe        func(*node.Validator) error
+	closedChecks sync.WaitGroup
+
+	storage      *storage.LevelDBBackend
+	connStats    map[ /* node.Address() */ string]*ConnStats
+	statsUpdated map[ /* node.Address() */ string]time.Time
+	stop         chan struct{}
+	stopOnce     sync.Once
+	flusher      sync.WaitGroup
+	resolver     sync.WaitGroup
 
 	log logging.Logger
 }
@@ -33,64 +159,182 @@ func NewValidatorConnectionManager(
 	policy ballot.VotingThresholdPolicy,
 	validators map[string]*node.Validator,
 ) ConnectionManager {
-	return &ValidatorConnectionManager{
+	c := &ValidatorConnectionManager{
 		localNode: localNode,
 
 		network:    network,
 		policy:     policy,
 		validators: validators,
 
-		clients:   map[string]NetworkClient{},
-		connected: map[string]bool{},
-		log:       log.New(logging.Ctx{"node": localNode.Alias()}),
+		clients:    map[string]NetworkClient{},
+		connected:  map[string]bool{},
+		changed:    map[string]time.Time{},
+		missed:     map[string]int{},
+		queues:     map[string]*outboundQueue{},
+		skews:      map[string]time.Duration{},
+		lastErrors: map[string]error{},
+		lastSeen:   map[string]time.Time{},
+		checking:   map[string]bool{},
+		resolved:   map[string][]net.IP{},
+
+		connStats:    map[string]*ConnStats{},
+		statsUpdated: map[string]time.Time{},
+		stop:         make(chan struct{}),
+
+		log: log.New(logging.Ctx{"module": "connection", "node": localNode.Alias()}),
 	}
+	c.probe = c.connectValidator
+
+	return c
 }
 
 func (c *ValidatorConnectionManager) GetNodeAddress() string {
 	return c.localNode.Address()
 }
 
-func (c *ValidatorConnectionManager) GetConnection(address string) (client NetworkClient) {
-	c.Lock()
-	defer c.Unlock()
+// GetConnection returns the client of validator. The new client is created
+// outside of the lock, so creating client does not block the other
+// connections and `Broadcast`. If the address is not of the validators or the
+// client can not be made, the client is nil with the error.
+func (c *ValidatorConnectionManager) GetConnection(address string) (client NetworkClient, err error) {
+	c.RLock()
+	client, found := c.clients[address]
+	validator, isValidator := c.validators[address]
+	c.RUnlock()
 
-	var ok bool
-	client, ok = c.clients[address]
-	if ok {
+	if found {
+		return
+	}
+	if !isValidator {
+		err = ErrorUnknownValidator
 		return
 	}
 
-	var validator *node.Validator
-	if validator, ok = c.validators[address]; !ok {
+	newClient := c.network.GetClient(validator.Endpoint())
+	if newClient == nil {
+		err = ErrorNoValidatorClient
 		return
 	}
 
-	client = c.network.GetClient(validator.Endpoint())
-	if client != nil {
-		c.clients[address] = client
+	c.Lock()
+	defer c.Unlock()
+
+	// the other goroutine may already store the client
+	if client, found = c.clients[address]; found {
+		return
 	}
 
+	client = newClient
+	c.clients[address] = client
+
 	return
 }
 
-func (c *ValidatorConnectionManager) Start() {
+// UpdateValidatorEndpoint changes the endpoint of validator and drops the
+// client of the old endpoint, so the next connection check connects to the
+// new endpoint. It returns `true` when the endpoint is changed.
+func (c *ValidatorConnectionManager) UpdateValidatorEndpoint(address string, endpoint *common.Endpoint) bool {
+	c.Lock()
+	defer c.Unlock()
+
+	v, found := c.validators[address]
+	if !found || v.Endpoint().Equal(endpoint) {
+		return false
+	}
+
+	v.SetEndpoint(endpoint)
+	delete(c.clients, address)
+	delete(c.resolved, address)
+
+	c.resolver.Add(1)
+	go func() {
+		defer c.resolver.Done()
+		c.resolveEndpoint(v)
+	}()
+
+	return true
+}
+
+func (c *ValidatorConnectionManager) Start() error {
+	if err := CheckMaxValidators(len(c.validators)); err != nil {
+		c.log.Error("failed to start connection manager", "error", err)
+		return err
+	}
+	if MaxValidatorsSoftLimit > 0 && len(c.validators) > MaxValidatorsSoftLimit {
+		c.log.Warn(
+			"number of validators exceeds the soft limit; broadcast may be slow",
+			"validators", len(c.validators),
+			"soft-limit", MaxValidatorsSoftLimit,
+		)
+	}
+
 	c.log.Debug("starting to connect to validators", "validators", c.validators)
 	for _, v := range c.validators {
 		go c.connectingValidator(v)
 	}
+
+	c.startStatsFlusher()
+	c.startEndpointResolver()
+
+	return nil
 }
 
 // setConnected returns `true` when the validator is newly connected or
-// disconnected at first
+// disconnected at first. The change within `ConnectionStateDebounce` after the
+// last change is ignored.
 func (c *ValidatorConnectionManager) setConnected(v *node.Validator, connected bool) bool {
 	c.Lock()
 	defer c.Unlock()
 
-	old, found := c.connected[v.Address()]
-	c.connected[v.Address()] = connected
+	address := v.Address()
+	if connected {
+		c.missed[address] = 0
+	}
+
+	now := time.Now()
+	c.accrueConnStatsUnlocked(address, now)
+
+	old, found := c.connected[address]
+	changed := !found || old != connected
+	if found && changed {
+		if last, ok := c.changed[address]; ok && now.Sub(last) < ConnectionStateDebounce {
+			return false
+		}
+	}
+
+	c.connected[address] = connected
+	if changed {
+		c.changed[address] = now
+		if found && !connected {
+			c.connStatsUnlocked(address).DisconnectCount++
+		}
+	}
 
 	c.policy.SetConnected(c.countConnectedUnlocked())
-	return !found || old != connected
+	return changed
+}
+
+// setConnectResult stores the result of the last connection check of
+// validator; the error of the failed check, or the time of the successful
+// check.
+func (c *ValidatorConnectionManager) setConnectResult(v *node.Validator, err error) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.lastErrors[v.Address()] = err
+	if err == nil {
+		c.lastSeen[v.Address()] = time.Now()
+	}
+}
+
+// missHeartbeat counts the failed connection check of validator and returns
+// `true` when it reaches `MissedHeartbeatsThreshold`.
+func (c *ValidatorConnectionManager) missHeartbeat(v *node.Validator) bool {
+	c.Lock()
+	defer c.Unlock()
+
+	c.missed[v.Address()]++
+	return c.missed[v.Address()] >= MissedHeartbeatsThreshold
 }
 
 func (c *ValidatorConnectionManager) AllConnected() []string {
@@ -141,12 +385,16 @@ func (c *ValidatorConnectionManager) connectingValidator(v *node.Validator) {
 	ticker := time.NewTicker(time.Second * 1)
 	for _ = range ticker.C {
 		err := c.connectValidator(v)
+		c.setConnectResult(v, err)
+		if err != nil && !c.missHeartbeat(v) {
+			continue
+		}
 
 		if c.setConnected(v, err == nil) {
 			if err == nil {
 				c.log.Debug("validator is connected", "validator", v)
 			} else {
-				c.log.Debug("validator is disconnected", "validator", v, "error", err)
+				c.log.Debug("validator is disconnected", "validator", v, "error", err, "error-kind", ClassifyClientError(err))
 			}
 		}
 	}
@@ -155,13 +403,18 @@ func (c *ValidatorConnectionManager) connectingValidator(v *node.Validator) {
 }
 
 func (c *ValidatorConnectionManager) connectValidator(v *node.Validator) (err error) {
-	client := c.GetConnection(v.Address())
+	var client NetworkClient
+	if client, err = c.GetConnection(v.Address()); err != nil {
+		return
+	}
 
 	var b []byte
+	sent := time.Now()
 	b, err = client.Connect(c.localNode)
 	if err != nil {
 		return
 	}
+	received := time.Now()
 
 	// load and check validator info; addresses are same?
 	var validator *node.Validator
@@ -174,35 +427,508 @@ func (c *ValidatorConnectionManager) connectValidator(v *node.Validator) (err er
 		return
 	}
 
+	if skew, ok := estimateClockSkew(b, sent, received); ok {
+		c.setClockSkew(v, skew)
+	}
+
 	return
 }
 
+// estimateClockSkew estimates the clock skew of validator from the "time" of
+// the node info, which is returned by the connect handshake. The time of
+// validator is compared with the middle of the request and the response; the
+// positive skew means the clock of validator is ahead.
+func estimateClockSkew(b []byte, sent, received time.Time) (skew time.Duration, ok bool) {
+	var info struct {
+		Time string `json:"time"`
+	}
+	if err := json.Unmarshal(b, &info); err != nil || len(info.Time) < 1 {
+		return
+	}
+
+	remote, err := common.ParseISO8601(info.Time)
+	if err != nil {
+		return
+	}
+
+	return remote.Sub(sent.Add(received.Sub(sent) / 2)), true
+}
+
+// setClockSkew stores the estimated clock skew of validator. The warning is
+// logged when the skew exceeds `ClockSkewWarningThreshold` at first, not in
+// every connection check.
+func (c *ValidatorConnectionManager) setClockSkew(v *node.Validator, skew time.Duration) {
+	c.Lock()
+	old, found := c.skews[v.Address()]
+	c.skews[v.Address()] = skew
+	c.Unlock()
+
+	if ClockSkewWarningThreshold < 1 || !isClockSkewed(skew) {
+		return
+	}
+	if found && isClockSkewed(old) {
+		return
+	}
+
+	c.log.Warn(
+		"clock of validator is skewed; it's ballots may be rejected",
+		"validator", v,
+		"skew", skew,
+		"threshold", ClockSkewWarningThreshold,
+	)
+}
+
+func isClockSkewed(skew time.Duration) bool {
+	if skew < 0 {
+		skew = -skew
+	}
+	return skew > ClockSkewWarningThreshold
+}
+
+// ClockSkews returns the estimated clock skews of validators by address. The
+// validator, which is not connected yet, is not included.
+func (c *ValidatorConnectionManager) ClockSkews() map[string]time.Duration {
+	c.RLock()
+	defer c.RUnlock()
+
+	skews := map[string]time.Duration{}
+	for address, skew := range c.skews {
+		skews[address] = skew
+	}
+	return skews
+}
+
+// ValidatorStatus is the configured validator with it's live connection
+// state. `LastError` is the error of the last connection check, and
+// `LastSeen` is the time of the last successful check.
+type ValidatorStatus struct {
+	Address   string `json:"address"`
+	Alias     string `json:"alias"`
+	Endpoint  string `json:"endpoint"`
+	Connected bool   `json:"connected"`
+	LastError string `json:"last-error,omitempty"`
+	LastSeen  string `json:"last-seen,omitempty"`
+}
+
+// ValidatorStatusList returns the status of the validators, except the local
+// node, ordered by address. The validator, which is not checked yet, is not
+// connected and does not have `LastError` and `LastSeen`.
+func (c *ValidatorConnectionManager) ValidatorStatusList() []ValidatorStatus {
+	c.RLock()
+	defer c.RUnlock()
+
+	statuses := []ValidatorStatus{}
+	for address, v := range c.validators {
+		status := ValidatorStatus{
+			Address:   address,
+			Alias:     v.Alias(),
+			Endpoint:  v.Endpoint().String(),
+			Connected: c.connected[address],
+		}
+		if err := c.lastErrors[address]; err != nil {
+			status.LastError = err.Error()
+		}
+		if seen, found := c.lastSeen[address]; found {
+			status.LastSeen = common.FormatISO8601(seen)
+		}
+		statuses = append(statuses, status)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Address < statuses[j].Address
+	})
+
+	return statuses
+}
+
+// ConnectionWatcher checks the connection of validator again, when the
+// incoming connection from validator is closed. The closed connection may be
+// just the idle keep-alive connection, so the validator is marked as
+// disconnected only when the check fails. The check waits until
+// `ConnectionStateDebounce` passes after the last change, so the close is not
+// ignored by the debounce.
+//
+// The validator is found by the addresses of it's endpoint host, which are
+// resolved in background; if the address is shared by multiple validators,
+// the closed connection is ignored.
 func (c *ValidatorConnectionManager) ConnectionWatcher(t Network, conn net.Conn, state http.ConnState) {
+	if state != http.StateClosed {
+		return
+	}
+
+	v := c.findValidatorByRemoteAddr(conn.RemoteAddr())
+	if v == nil {
+		return
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	// the check is already running
+	if c.checking[v.Address()] {
+		return
+	}
+	c.checking[v.Address()] = true
+
+	c.closedChecks.Add(1)
+	go c.checkClosedConnection(v)
+
 	return
 }
 
-func (c *ValidatorConnectionManager) Broadcast(message common.Message) {
+func (c *ValidatorConnectionManager) checkClosedConnection(v *node.Validator) {
+	defer c.closedChecks.Done()
+	defer func() {
+		c.Lock()
+		delete(c.checking, v.Address())
+		c.Unlock()
+	}()
+
+	c.RLock()
+	last := c.changed[v.Address()]
+	c.RUnlock()
+
+	if wait := ConnectionStateDebounce - time.Since(last); wait > 0 {
+		time.Sleep(wait)
+	}
+
+	err := c.probe(v)
+	c.setConnectResult(v, err)
+	if err == nil {
+		return
+	}
+
+	if c.setConnected(v, false) {
+		c.log.Debug("validator is disconnected; connection closed", "validator", v, "error", err)
+	}
+}
+
+// findValidatorByRemoteAddr finds the validator, whose endpoint host is the
+// address. The host names are not resolved here, `ConnectionWatcher` is
+// called by the http server for every connection; the addresses resolved by
+// `resolveEndpoints` are used instead.
+func (c *ValidatorConnectionManager) findValidatorByRemoteAddr(addr net.Addr) (found *node.Validator) {
+	if addr == nil {
+		return
+	}
+
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return
+	}
+	remote := net.ParseIP(host)
+	if remote == nil {
+		return
+	}
+
 	c.RLock()
 	defer c.RUnlock()
+
+	for address, v := range c.validators {
+		ips := c.resolved[address]
+		if hostIP := net.ParseIP((*url.URL)(v.Endpoint()).Hostname()); hostIP != nil {
+			ips = []net.IP{hostIP}
+		}
+		if !hasIP(ips, remote) {
+			continue
+		}
+		if found != nil {
+			return nil
+		}
+		found = v
+	}
+
+	return
+}
+
+// hasIP checks the ips has the ip.
+func hasIP(ips []net.IP, ip net.IP) bool {
+	for _, i := range ips {
+		if i.Equal(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// startEndpointResolver resolves the host names of validator endpoints at
+// first and again every `EndpointResolveInterval` until `Stop` is called.
+func (c *ValidatorConnectionManager) startEndpointResolver() {
+	c.resolver.Add(1)
+	go func() {
+		defer c.resolver.Done()
+
+		c.resolveEndpoints()
+		if EndpointResolveInterval < 1 {
+			return
+		}
+
+		ticker := time.NewTicker(EndpointResolveInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				c.resolveEndpoints()
+			case <-c.stop:
+				return
+			}
+		}
+	}()
+}
+
+// resolveEndpoints resolves the host names of all the validator endpoints.
+func (c *ValidatorConnectionManager) resolveEndpoints() {
+	c.RLock()
+	validators := make([]*node.Validator, 0, len(c.validators))
+	for _, v := range c.validators {
+		validators = append(validators, v)
+	}
+	c.RUnlock()
+
+	for _, v := range validators {
+		c.resolveEndpoint(v)
+	}
+}
+
+// resolveEndpoint resolves the host name of the validator endpoint outside of
+// the lock and keeps the addresses for `findValidatorByRemoteAddr`. The
+// addresses are not kept if the endpoint is changed while resolving. If the
+// host name can not be resolved, the last addresses are kept.
+func (c *ValidatorConnectionManager) resolveEndpoint(v *node.Validator) {
+	endpoint := v.Endpoint()
+	hostname := (*url.URL)(endpoint).Hostname()
+	if net.ParseIP(hostname) != nil {
+		return
+	}
+
+	addrs, err := lookupHost(hostname)
+	if err != nil {
+		c.log.Debug("failed to resolve validator endpoint", "validator", v, "error", err)
+		return
+	}
+
+	var ips []net.IP
+	for _, addr := range addrs {
+		if ip := net.ParseIP(addr); ip != nil {
+			ips = append(ips, ip)
+		}
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	if !v.Endpoint().Equal(endpoint) {
+		return
+	}
+	c.resolved[v.Address()] = ips
+}
+
+// Broadcast queues the message to the outbound queues of the connected
+// validators; see `send`. It does not wait until the message is sent.
+func (c *ValidatorConnectionManager) Broadcast(message common.Message) {
+	var targets []*node.Validator
+
+	c.RLock()
 	for addr, connected := range c.connected {
-		if connected {
-			go func(v *node.Validator) {
-				client := c.GetConnection(v.Address())
+		if !connected {
+			continue
+		}
+		v, found := c.validators[addr]
+		if !found {
+			c.log.Error("connected address is not of the validators; skipped", "address", addr)
+			continue
+		}
+		targets = append(targets, v)
+	}
+	c.RUnlock()
+
+	for _, v := range targets {
+		if _, err := c.enqueue(v, message); err != nil {
+			c.log.Debug("failed to queue message", "message", message.GetHash(), "validator", v, "error", err)
+		}
+	}
+
+	return
+}
+
+// BroadcastWithResult sends the message like `Broadcast`, but waits until
+// all the sends are finished and returns the result of each validator,
+// ordered by address. With `addresses`, the message is sent only to them even
+// if they are not connected; without `addresses`, it is sent to the connected
+// validators and the others fail with `ErrorValidatorNotConnected`.
+func (c *ValidatorConnectionManager) BroadcastWithResult(message common.Message, addresses ...string) BroadcastResults {
+	var results BroadcastResults
+	var targets []*node.Validator
+
+	c.RLock()
+	if len(addresses) > 0 {
+		for _, addr := range addresses {
+			v, found := c.validators[addr]
+			if !found {
+				results = append(results, BroadcastResult{Address: addr, Error: ErrorUnknownValidator})
+				continue
+			}
+			targets = append(targets, v)
+		}
+	} else {
+		for addr, v := range c.validators {
+			if !c.connected[addr] {
+				results = append(results, BroadcastResult{Address: addr, Error: ErrorValidatorNotConnected})
+				continue
+			}
+			targets = append(targets, v)
+		}
+	}
+	c.RUnlock()
+
+	sent := make(BroadcastResults, len(targets))
+
+	var wg sync.WaitGroup
+	for i, v := range targets {
+		wg.Add(1)
+		go func(i int, v *node.Validator) {
+			defer wg.Done()
+			sent[i] = BroadcastResult{Address: v.Address(), Error: c.send(v, message)}
+		}(i, v)
+	}
+	wg.Wait()
+
+	results = append(results, sent...)
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Address < results[j].Address
+	})
+
+	return results
+}
+
+// send sends the message to the validator through it's outbound queue and
+// waits for the result. The returned error is nil only when the validator
+// received the message.
+func (c *ValidatorConnectionManager) send(v *node.Validator, message common.Message) error {
+	result, err := c.enqueue(v, message)
+	if err != nil {
+		c.log.Debug("failed to queue message", "message", message.GetHash(), "validator", v, "error", err)
+		return err
+	}
+
+	select {
+	case err = <-result:
+		return err
+	case <-c.stop:
+		return ErrorConnectionManagerStopped
+	}
+}
+
+// outboundQueue returns the outbound queue of the validator. The queue and
+// it's worker are made at first, so the validator added later also has it's
+// queue.
+func (c *ValidatorConnectionManager) outboundQueue(v *node.Validator) *outboundQueue {
+	c.Lock()
+	defer c.Unlock()
+
+	queue, found := c.queues[v.Address()]
+	if !found {
+		queue = newOutboundQueue()
+		c.queues[v.Address()] = queue
+		go c.sendQueued(v, queue)
+	}
+
+	return queue
+}
+
+// enqueue adds the message to the outbound queue of the validator. The result
+// of send will be sent to the returned channel. If the same message is
+// already queued or the queue is full, the message is not queued.
+func (c *ValidatorConnectionManager) enqueue(v *node.Validator, message common.Message) (chan error, error) {
+	queue := c.outboundQueue(v)
+	if !queue.add(message) {
+		return nil, ErrorMessageAlreadyQueued
+	}
+
+	result := make(chan error, 1)
+	select {
+	case queue.jobs <- outboundJob{message: message, result: result}:
+		queue.queued(message)
+	default:
+		queue.remove(message)
+		return nil, ErrorOutboundQueueFull
+	}
+
+	return result, nil
+}
+
+// sendQueued is the worker of the outbound queue of the validator; it sends
+// the queued messages in order until the connection manager is stopped. The
+// ballot, which is superseded while waiting in queue, is dropped without
+// sending.
+func (c *ValidatorConnectionManager) sendQueued(v *node.Validator, queue *outboundQueue) {
+	for {
+		select {
+		case <-c.stop:
+			return
+		case job := <-queue.jobs:
+			var err error
+			if queue.isSuperseded(job.message) {
+				c.log.Debug("message is superseded in queue; dropped", "message", job.message.GetHash(), "validator", v)
+				err = ErrorMessageSuperseded
+			} else {
+				err = c.sendWithRetry(v, queue, job.message)
+			}
+			queue.remove(job.message)
+			job.result <- err
+		}
+	}
+}
+
+// sendWithRetry sends the message to the validator. The failed send is retried
+// up to `BroadcastMaxRetries` times with backoff; the ballot, which is
+// superseded by the ballot of newer round, is dropped instead of retrying.
+func (c *ValidatorConnectionManager) sendWithRetry(v *node.Validator, queue *outboundQueue, message common.Message) error {
+	backoff := BroadcastRetryBackoff
+	for retries := 0; ; retries++ {
+		err := c.sendMessage(v, message)
+		if err == nil {
+			return nil
+		} else if err == ErrorUnknownValidator {
+			c.log.Error("failed to send message", "error", err, "validator", v)
+			return err
+		}
+
+		kind := ClassifyClientError(err)
+		if retries >= BroadcastMaxRetries {
+			c.log.Error("failed to send message", "error", err, "error-kind", kind, "validator", v, "retries", retries)
+			return err
+		}
+		if queue.isSuperseded(message) {
+			c.log.Debug("message is superseded; not retried", "message", message.GetHash(), "validator", v)
+			return ErrorMessageSuperseded
+		}
 
-				var err error
-				if message.GetType() == common.BallotMessage {
-					_, err = client.SendBallot(message)
-				} else if message.GetType() == string(common.TransactionMessage) {
-					_, err = client.SendMessage(message)
-				} else {
-					panic("invalid message")
-				}
+		c.log.Debug("failed to send message; will retry", "error", err, "error-kind", kind, "validator", v, "backoff", backoff)
+		time.Sleep(backoff)
+		backoff *= 2
 
-				if err != nil {
-					c.log.Error("failed to SendBallot", "error", err, "validator", v)
-				}
-			}(c.validators[addr])
+		if queue.isSuperseded(message) {
+			c.log.Debug("message is superseded while waiting for retry; dropped", "message", message.GetHash(), "validator", v)
+			return ErrorMessageSuperseded
 		}
 	}
+}
+
+func (c *ValidatorConnectionManager) sendMessage(v *node.Validator, message common.Message) (err error) {
+	var client NetworkClient
+	if client, err = c.GetConnection(v.Address()); err != nil {
+		return
+	}
+
+	if message.GetType() == common.BallotMessage {
+		_, err = client.SendBallot(message)
+	} else if message.GetType() == string(common.TransactionMessage) {
+		_, err = client.SendMessage(message)
+	} else {
+		panic("invalid message")
+	}
+
 	return
 }
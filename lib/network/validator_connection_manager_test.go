@@ -0,0 +1,829 @@
+package network
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	logging "github.com/inconshreveable/log15"
+	"github.com/stellar/go/keypair"
+	"github.com/stretchr/testify/require"
+
+	"boscoin.io/sebak/lib/ballot"
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/consensus/round"
+	"boscoin.io/sebak/lib/node"
+)
+
+type testVotingThresholdPolicy struct {
+	validators int
+	connected  int
+}
+
+func (p *testVotingThresholdPolicy) RequiredCount(ballot.State) int { return 0 }
+func (p *testVotingThresholdPolicy) Decide(ballot.VoteTally, ballot.State) (ballot.VotingHole, bool) {
+	return ballot.VotingNOTYET, false
+}
+func (p *testVotingThresholdPolicy) Validators() int { return p.validators }
+func (p *testVotingThresholdPolicy) SetValidators(n int) error {
+	p.validators = n
+	return nil
+}
+func (p *testVotingThresholdPolicy) Connected() int { return p.connected }
+func (p *testVotingThresholdPolicy) SetConnected(n int) error {
+	p.connected = n
+	return nil
+}
+func (p *testVotingThresholdPolicy) Weight(string) int                     { return 1 }
+func (p *testVotingThresholdPolicy) TotalWeight() int                      { return p.validators }
+func (p *testVotingThresholdPolicy) SetDelegatedWeights(map[string]uint64) {}
+func (p *testVotingThresholdPolicy) SetValidatorWeights(map[string]uint64) {}
+
+type testConn struct {
+	net.Conn
+	remoteAddr net.Addr
+}
+
+func (c testConn) RemoteAddr() net.Addr {
+	return c.remoteAddr
+}
+
+func makeTestValidatorConnectionManager(t *testing.T, hosts ...string) (*ValidatorConnectionManager, *testVotingThresholdPolicy, []*node.Validator) {
+	_, _, localNode := CreateMemoryNetwork(nil)
+
+	var validators []*node.Validator
+	for _, host := range hosts {
+		kp, _ := keypair.Random()
+		endpoint, err := common.NewEndpointFromString("https://" + host + ":12345")
+		require.Nil(t, err)
+		v, err := node.NewValidator(kp.Address(), endpoint, "")
+		require.Nil(t, err)
+		validators = append(validators, v)
+	}
+	localNode.AddValidators(validators...)
+
+	policy := &testVotingThresholdPolicy{}
+	cm := NewValidatorConnectionManager(localNode, nil, policy, localNode.GetValidators())
+
+	return cm.(*ValidatorConnectionManager), policy, validators
+}
+
+func TestValidatorConnectionManagerConnectionClosed(t *testing.T) {
+	defer func(d time.Duration) { ConnectionStateDebounce = d }(ConnectionStateDebounce)
+	ConnectionStateDebounce = 100 * time.Millisecond
+
+	cm, policy, validators := makeTestValidatorConnectionManager(t, "10.0.0.1", "10.0.0.2")
+	for _, v := range validators {
+		require.True(t, cm.setConnected(v, true))
+	}
+	require.Equal(t, 2, policy.Connected())
+
+	var probeErr error
+	cm.probe = func(*node.Validator) error { return probeErr }
+
+	conn := testConn{remoteAddr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 54321}}
+
+	// not closed state does not change the connected
+	cm.ConnectionWatcher(nil, conn, http.StateIdle)
+	cm.closedChecks.Wait()
+	require.Equal(t, 2, policy.Connected())
+
+	// the closed keep-alive connection of the live validator
+	cm.ConnectionWatcher(nil, conn, http.StateClosed)
+	cm.closedChecks.Wait()
+	require.Equal(t, 2, policy.Connected())
+
+	// closed within debounce duration is checked after the debounce
+	probeErr = errors.New("connection refused")
+	cm.ConnectionWatcher(nil, conn, http.StateClosed)
+	cm.closedChecks.Wait()
+	require.Equal(t, 1, policy.Connected())
+	require.Equal(t, []string{validators[1].Address()}, cm.AllConnected())
+
+	// reconnecting within debounce duration is ignored
+	require.False(t, cm.setConnected(validators[0], true))
+	require.Equal(t, 1, policy.Connected())
+
+	time.Sleep(ConnectionStateDebounce)
+	require.True(t, cm.setConnected(validators[0], true))
+	require.Equal(t, 2, policy.Connected())
+}
+
+func TestValidatorConnectionManagerConnectionClosedHostName(t *testing.T) {
+	defer func(f func(string) ([]string, error)) { lookupHost = f }(lookupHost)
+	lookupHost = func(host string) ([]string, error) {
+		if host == "node1.sebak.test" {
+			return []string{"10.0.0.1"}, nil
+		}
+		return nil, errors.New("no such host")
+	}
+
+	cm, policy, validators := makeTestValidatorConnectionManager(t, "node1.sebak.test", "node2.sebak.test")
+	for _, v := range validators {
+		cm.setConnected(v, true)
+	}
+	cm.probe = func(*node.Validator) error { return errors.New("connection refused") }
+
+	// the host names are not resolved while watching connections
+	conn := testConn{remoteAddr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 54321}}
+	require.Nil(t, cm.findValidatorByRemoteAddr(conn.RemoteAddr()))
+
+	cm.resolveEndpoints()
+	require.Equal(t, validators[0], cm.findValidatorByRemoteAddr(conn.RemoteAddr()))
+
+	cm.ConnectionWatcher(nil, conn, http.StateClosed)
+	cm.closedChecks.Wait()
+	require.Equal(t, 1, policy.Connected())
+	require.Equal(t, []string{validators[1].Address()}, cm.AllConnected())
+}
+
+func TestValidatorConnectionManagerConnectionClosedSharedHost(t *testing.T) {
+	cm, policy, validators := makeTestValidatorConnectionManager(t, "10.0.0.1", "10.0.0.1")
+	for _, v := range validators {
+		cm.setConnected(v, true)
+	}
+
+	// the closed connection from the shared host can not be matched with
+	// validator
+	conn := testConn{remoteAddr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 54321}}
+	cm.ConnectionWatcher(nil, conn, http.StateClosed)
+	require.Equal(t, 2, policy.Connected())
+}
+
+func TestValidatorConnectionManagerMissedHeartbeats(t *testing.T) {
+	defer func(n int) { MissedHeartbeatsThreshold = n }(MissedHeartbeatsThreshold)
+	MissedHeartbeatsThreshold = 3
+
+	cm, _, validators := makeTestValidatorConnectionManager(t, "10.0.0.1")
+	v := validators[0]
+
+	require.False(t, cm.missHeartbeat(v))
+	require.False(t, cm.missHeartbeat(v))
+	require.True(t, cm.missHeartbeat(v))
+
+	// connected resets the missed heartbeats
+	cm.setConnected(v, true)
+	require.False(t, cm.missHeartbeat(v))
+}
+
+type testSlowNetwork struct {
+	Network
+
+	sync.Mutex
+	dialing chan struct{}
+	release chan struct{}
+	dialed  int
+}
+
+func (n *testSlowNetwork) GetClient(endpoint *common.Endpoint) NetworkClient {
+	n.dialing <- struct{}{}
+	<-n.release
+
+	n.Lock()
+	n.dialed++
+	n.Unlock()
+
+	return &testNetworkClient{endpoint: endpoint}
+}
+
+type testNetworkClient struct {
+	NetworkClient
+
+	endpoint *common.Endpoint
+	received chan common.Serializable
+}
+
+func (c *testNetworkClient) Endpoint() *common.Endpoint {
+	return c.endpoint
+}
+
+func (c *testNetworkClient) SendBallot(message common.Serializable) ([]byte, error) {
+	c.received <- message
+	return nil, nil
+}
+
+func TestValidatorConnectionManagerGetConnectionConcurrent(t *testing.T) {
+	cm, _, validators := makeTestValidatorConnectionManager(t, "10.0.0.1", "10.0.0.2")
+	network := &testSlowNetwork{dialing: make(chan struct{}, 10), release: make(chan struct{})}
+	cm.network = network
+
+	var wg sync.WaitGroup
+	clients := make([]NetworkClient, 10)
+	for i := 0; i < len(clients); i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			clients[i], _ = cm.GetConnection(validators[0].Address())
+		}(i)
+	}
+
+	<-network.dialing
+	close(network.release)
+	wg.Wait()
+
+	// all the goroutines get the same stored client
+	for _, client := range clients {
+		require.True(t, client == clients[0])
+	}
+	client, err := cm.GetConnection(validators[0].Address())
+	require.Nil(t, err)
+	require.True(t, client == clients[0])
+
+	// unknown validator
+	client, err = cm.GetConnection("unknown")
+	require.Nil(t, client)
+	require.Equal(t, ErrorUnknownValidator, err)
+}
+
+type testNilClientNetwork struct {
+	Network
+}
+
+func (n testNilClientNetwork) GetClient(*common.Endpoint) NetworkClient {
+	return nil
+}
+
+func TestValidatorConnectionManagerNilConnection(t *testing.T) {
+	cm, _, validators := makeTestValidatorConnectionManager(t, "10.0.0.1")
+	cm.network = testNilClientNetwork{}
+
+	{ // `GetClient` returns nil
+		client, err := cm.GetConnection(validators[0].Address())
+		require.Nil(t, client)
+		require.Equal(t, ErrorNoValidatorClient, err)
+
+		require.Equal(t, ErrorNoValidatorClient, cm.connectValidator(validators[0]))
+	}
+
+	{ // unknown address
+		kp, _ := keypair.Random()
+		endpoint, _ := common.NewEndpointFromString("https://10.0.0.9:12345")
+		unknown, _ := node.NewValidator(kp.Address(), endpoint, "")
+
+		client, err := cm.GetConnection(unknown.Address())
+		require.Nil(t, client)
+		require.Equal(t, ErrorUnknownValidator, err)
+
+		require.Equal(t, ErrorUnknownValidator, cm.connectValidator(unknown))
+
+		b := makeTestBroadcastBallot(round.Round{})
+		require.Equal(t, ErrorUnknownValidator, cm.sendMessage(unknown, b))
+
+		// the connected address, which is not of the validators, is skipped
+		cm.Lock()
+		cm.connected[unknown.Address()] = true
+		cm.Unlock()
+		require.NotPanics(t, func() { cm.Broadcast(b) })
+	}
+}
+
+func TestValidatorConnectionManagerBroadcastWhileConnecting(t *testing.T) {
+	cm, _, validators := makeTestValidatorConnectionManager(t, "10.0.0.1", "10.0.0.2")
+	network := &testSlowNetwork{dialing: make(chan struct{}, 1), release: make(chan struct{})}
+	cm.network = network
+	defer close(network.release)
+
+	// validators[1] is already connected
+	connected := &testNetworkClient{endpoint: validators[1].Endpoint(), received: make(chan common.Serializable, 1)}
+	cm.clients[validators[1].Address()] = connected
+	cm.setConnected(validators[1], true)
+
+	// creating new client of validators[0] is blocked
+	go cm.GetConnection(validators[0].Address())
+	<-network.dialing
+
+	kp, _ := keypair.Random()
+	b := ballot.NewBallot(kp.Address(), round.Round{}, []string{})
+	b.Sign(kp, []byte("sebak-test-network"))
+
+	broadcasted := make(chan struct{})
+	go func() {
+		cm.Broadcast(*b)
+		close(broadcasted)
+	}()
+
+	select {
+	case <-broadcasted:
+	case <-time.After(time.Second):
+		require.Fail(t, "Broadcast is blocked by GetConnection")
+	}
+
+	select {
+	case received := <-connected.received:
+		require.Equal(t, b.GetHash(), received.(ballot.Ballot).GetHash())
+	case <-time.After(time.Second):
+		require.Fail(t, "ballot is not broadcasted")
+	}
+}
+
+// testFlakyNetworkClient fails to receive the ballots `failures` times, and
+// then succeeds.
+type testFlakyNetworkClient struct {
+	NetworkClient
+
+	sync.Mutex
+	failures int
+	tried    int
+	received chan common.Serializable
+}
+
+func (c *testFlakyNetworkClient) SendBallot(message common.Serializable) ([]byte, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.tried++
+	if c.tried <= c.failures {
+		return nil, errors.New("flaky")
+	}
+
+	c.received <- message
+	return nil, nil
+}
+
+func (c *testFlakyNetworkClient) Tried() int {
+	c.Lock()
+	defer c.Unlock()
+
+	return c.tried
+}
+
+func makeTestBroadcastBallot(r round.Round) ballot.Ballot {
+	kp, _ := keypair.Random()
+	b := ballot.NewBallot(kp.Address(), r, []string{})
+	b.Sign(kp, []byte("sebak-test-network"))
+
+	return *b
+}
+
+func TestValidatorConnectionManagerBroadcastRetry(t *testing.T) {
+	defer func(backoff time.Duration) { BroadcastRetryBackoff = backoff }(BroadcastRetryBackoff)
+	BroadcastRetryBackoff = 10 * time.Millisecond
+
+	cm, _, validators := makeTestValidatorConnectionManager(t, "10.0.0.1")
+	client := &testFlakyNetworkClient{failures: 2, received: make(chan common.Serializable, 1)}
+	cm.clients[validators[0].Address()] = client
+	cm.setConnected(validators[0], true)
+
+	b := makeTestBroadcastBallot(round.Round{BlockHeight: 1})
+	cm.Broadcast(b)
+
+	// the same ballot is not queued twice
+	cm.send(validators[0], b)
+
+	select {
+	case received := <-client.received:
+		require.Equal(t, b.GetHash(), received.(ballot.Ballot).GetHash())
+	case <-time.After(time.Second):
+		require.Fail(t, "ballot is not delivered")
+	}
+	require.Equal(t, 3, client.Tried())
+
+	// dropped from queue after success
+	for i := 0; i < 100 && cm.queues[validators[0].Address()].len() > 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.Equal(t, 0, cm.queues[validators[0].Address()].len())
+}
+
+func TestValidatorConnectionManagerBroadcastRetryExhausted(t *testing.T) {
+	defer func(backoff time.Duration, retries int) {
+		BroadcastRetryBackoff = backoff
+		BroadcastMaxRetries = retries
+	}(BroadcastRetryBackoff, BroadcastMaxRetries)
+	BroadcastRetryBackoff = 10 * time.Millisecond
+	BroadcastMaxRetries = 1
+
+	cm, _, validators := makeTestValidatorConnectionManager(t, "10.0.0.1")
+	client := &testFlakyNetworkClient{failures: 2, received: make(chan common.Serializable, 1)}
+	cm.clients[validators[0].Address()] = client
+
+	cm.send(validators[0], makeTestBroadcastBallot(round.Round{BlockHeight: 1}))
+	require.Equal(t, 2, client.Tried())
+	require.Equal(t, 0, len(client.received))
+	require.Equal(t, 0, cm.queues[validators[0].Address()].len())
+}
+
+func TestValidatorConnectionManagerBroadcastWithResult(t *testing.T) {
+	defer func(retries int) { BroadcastMaxRetries = retries }(BroadcastMaxRetries)
+	BroadcastMaxRetries = 0
+
+	cm, _, validators := makeTestValidatorConnectionManager(t, "10.0.0.1", "10.0.0.2", "10.0.0.3")
+	received := make(chan common.Serializable, 10)
+	cm.clients[validators[0].Address()] = &testFlakyNetworkClient{received: received}
+	failing := &testFlakyNetworkClient{failures: 100, received: received}
+	cm.clients[validators[1].Address()] = failing
+	cm.setConnected(validators[0], true)
+	cm.setConnected(validators[1], true)
+
+	b := makeTestBroadcastBallot(round.Round{BlockHeight: 1})
+
+	expected := map[string]error{
+		validators[0].Address(): nil,
+		validators[1].Address(): errors.New("flaky"),
+		validators[2].Address(): ErrorValidatorNotConnected,
+	}
+
+	results := cm.BroadcastWithResult(b)
+	require.Equal(t, 3, len(results))
+	for i, r := range results {
+		if i > 0 {
+			require.True(t, results[i-1].Address < r.Address)
+		}
+		require.Equal(t, expected[r.Address], r.Error)
+	}
+	require.Equal(t, []string{validators[0].Address()}, results.Acknowledged())
+	require.Equal(t, 2, len(results.Failed()))
+	require.Equal(t, 1, len(received))
+	require.Equal(t, 1, failing.Tried())
+
+	// only to the given validators
+	results = cm.BroadcastWithResult(b, validators[1].Address())
+	require.Equal(t, 1, len(results))
+	require.Equal(t, validators[1].Address(), results[0].Address)
+	require.NotNil(t, results[0].Error)
+	require.Equal(t, 2, failing.Tried())
+	require.Equal(t, 1, len(received))
+}
+
+func TestValidatorConnectionManagerBroadcastSuperseded(t *testing.T) {
+	defer func(backoff time.Duration) { BroadcastRetryBackoff = backoff }(BroadcastRetryBackoff)
+	BroadcastRetryBackoff = 100 * time.Millisecond
+
+	cm, _, validators := makeTestValidatorConnectionManager(t, "10.0.0.1")
+	client := &testFlakyNetworkClient{failures: 1, received: make(chan common.Serializable, 2)}
+	cm.clients[validators[0].Address()] = client
+
+	old := makeTestBroadcastBallot(round.Round{BlockHeight: 1, Number: 0})
+	newer := makeTestBroadcastBallot(round.Round{BlockHeight: 1, Number: 1})
+
+	done := make(chan struct{})
+	go func() {
+		cm.send(validators[0], old)
+		close(done)
+	}()
+
+	// while the old ballot is waiting for retry, the ballot of newer round is
+	// sent
+	for i := 0; i < 1000 && client.Tried() < 1; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	cm.send(validators[0], newer)
+	<-done
+
+	require.Equal(t, 1, len(client.received))
+	require.Equal(t, newer.GetHash(), (<-client.received).(ballot.Ballot).GetHash())
+	require.Equal(t, 2, client.Tried())
+}
+
+// The ballot waiting in the outbound queue is dropped without sending, when
+// the newer ballot is queued after it.
+func TestValidatorConnectionManagerOutboundQueueSuperseded(t *testing.T) {
+	cm, _, validators := makeTestValidatorConnectionManager(t, "10.0.0.1")
+	defer cm.Stop()
+
+	client := testBlockingNetworkClient{sending: make(chan struct{}, 10), release: make(chan struct{})}
+	cm.clients[validators[0].Address()] = client
+
+	r := round.Round{BlockHeight: 1}
+	init := makeTestBroadcastBallot(r)
+	sign := makeTestBroadcastBallot(r)
+	sign.SetVote(ballot.StateSIGN, ballot.VotingYES)
+	accept := makeTestBroadcastBallot(r)
+	accept.SetVote(ballot.StateACCEPT, ballot.VotingYES)
+	newer := makeTestBroadcastBallot(round.Round{BlockHeight: 2})
+
+	// the INIT ballot is being sent by the worker
+	first, err := cm.enqueue(validators[0], init)
+	require.Nil(t, err)
+	<-client.sending
+
+	signed, err := cm.enqueue(validators[0], sign)
+	require.Nil(t, err)
+	accepted, err := cm.enqueue(validators[0], accept)
+	require.Nil(t, err)
+
+	close(client.release)
+	require.Nil(t, <-first)
+	require.Equal(t, ErrorMessageSuperseded, <-signed)
+	require.Nil(t, <-accepted)
+
+	// the INIT ballot is not superseded by the later state of the same round
+	queue := cm.queues[validators[0].Address()]
+	require.False(t, queue.isSuperseded(init))
+	require.True(t, queue.isSuperseded(sign))
+
+	_, err = cm.enqueue(validators[0], newer)
+	require.Nil(t, err)
+	require.True(t, queue.isSuperseded(init))
+}
+
+// testBlockingNetworkClient does not return until `release` is closed.
+type testBlockingNetworkClient struct {
+	NetworkClient
+	sending chan struct{}
+	release chan struct{}
+}
+
+func (c testBlockingNetworkClient) SendBallot(message common.Serializable) ([]byte, error) {
+	c.sending <- struct{}{}
+	<-c.release
+	return nil, nil
+}
+
+func TestValidatorConnectionManagerOutboundQueueFull(t *testing.T) {
+	defer func(size int) { OutboundQueueSize = size }(OutboundQueueSize)
+	OutboundQueueSize = 1
+
+	cm, _, validators := makeTestValidatorConnectionManager(t, "10.0.0.1")
+	defer cm.Stop()
+
+	client := testBlockingNetworkClient{sending: make(chan struct{}, 10), release: make(chan struct{})}
+	cm.clients[validators[0].Address()] = client
+
+	// the first one is being sent by the worker
+	first, err := cm.enqueue(validators[0], makeTestBroadcastBallot(round.Round{BlockHeight: 1}))
+	require.Nil(t, err)
+	<-client.sending
+
+	// the second one is waiting in queue
+	second, err := cm.enqueue(validators[0], makeTestBroadcastBallot(round.Round{BlockHeight: 2}))
+	require.Nil(t, err)
+
+	_, err = cm.enqueue(validators[0], makeTestBroadcastBallot(round.Round{BlockHeight: 3}))
+	require.Equal(t, ErrorOutboundQueueFull, err)
+	require.Equal(t, 2, cm.queues[validators[0].Address()].len())
+
+	close(client.release)
+	require.Nil(t, <-first)
+	require.Nil(t, <-second)
+	require.Equal(t, 1, len(client.sending))
+}
+
+// The outbound queue of the validator, which is added after the connection
+// manager is made, is made when the message is sent to it.
+func TestValidatorConnectionManagerOutboundQueueAddedValidator(t *testing.T) {
+	cm, _, _ := makeTestValidatorConnectionManager(t, "10.0.0.1")
+	defer cm.Stop()
+
+	kp, _ := keypair.Random()
+	endpoint, err := common.NewEndpointFromString("https://10.0.0.2:12345")
+	require.Nil(t, err)
+	v, err := node.NewValidator(kp.Address(), endpoint, "")
+	require.Nil(t, err)
+
+	received := make(chan common.Serializable, 1)
+	cm.Lock()
+	cm.validators[v.Address()] = v
+	cm.clients[v.Address()] = &testFlakyNetworkClient{received: received}
+	cm.Unlock()
+
+	b := makeTestBroadcastBallot(round.Round{BlockHeight: 1})
+	require.Nil(t, cm.send(v, b))
+	require.Equal(t, b.GetHash(), (<-received).(ballot.Ballot).GetHash())
+}
+
+type testUnreachableNetworkClient struct {
+	NetworkClient
+}
+
+func (c testUnreachableNetworkClient) Connect(node.Node) ([]byte, error) {
+	return nil, errors.New("unreachable")
+}
+
+func TestValidatorConnectionManagerStartMaxValidators(t *testing.T) {
+	defer func(soft, hard int) {
+		MaxValidatorsSoftLimit, MaxValidatorsHardLimit = soft, hard
+	}(MaxValidatorsSoftLimit, MaxValidatorsHardLimit)
+	MaxValidatorsSoftLimit = 1
+	MaxValidatorsHardLimit = 2
+
+	var lock sync.Mutex
+	var warnings []*logging.Record
+	captureWarnings := func(cm *ValidatorConnectionManager) {
+		cm.log = logging.New()
+		cm.log.SetHandler(logging.FuncHandler(func(r *logging.Record) error {
+			lock.Lock()
+			defer lock.Unlock()
+			if r.Lvl == logging.LvlWarn {
+				warnings = append(warnings, r)
+			}
+			return nil
+		}))
+	}
+
+	{ // exceeding hard limit
+		cm, _, _ := makeTestValidatorConnectionManager(t, "10.0.0.1", "10.0.0.2", "10.0.0.3")
+		captureWarnings(cm)
+		require.NotNil(t, cm.Start())
+		require.NotNil(t, CheckMaxValidators(3))
+		require.Equal(t, 0, len(warnings))
+	}
+
+	{ // exceeding soft limit; warning is logged, but it starts
+		cm, _, validators := makeTestValidatorConnectionManager(t, "10.0.0.1", "10.0.0.2")
+		for _, v := range validators {
+			cm.clients[v.Address()] = testUnreachableNetworkClient{}
+		}
+		captureWarnings(cm)
+		require.Nil(t, cm.Start())
+		require.Nil(t, CheckMaxValidators(2))
+
+		lock.Lock()
+		require.Equal(t, 1, len(warnings))
+		lock.Unlock()
+	}
+
+	{ // soft limit must not exceed hard limit
+		require.Nil(t, CheckValidatorsLimits())
+
+		MaxValidatorsSoftLimit = 3
+		require.NotNil(t, CheckValidatorsLimits())
+	}
+
+	{ // `0` disables the limits
+		MaxValidatorsSoftLimit, MaxValidatorsHardLimit = 0, 0
+		require.Nil(t, CheckMaxValidators(1000))
+		require.Nil(t, CheckValidatorsLimits())
+	}
+}
+
+// testSkewedNetworkClient returns the node info of validator, whose clock is
+// skewed by `offset`.
+type testSkewedNetworkClient struct {
+	NetworkClient
+	validator *node.Validator
+	offset    time.Duration
+}
+
+func (c testSkewedNetworkClient) Connect(node.Node) ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"address":  c.validator.Address(),
+		"endpoint": c.validator.Endpoint().String(),
+		"time":     common.FormatISO8601(time.Now().Add(c.offset)),
+	})
+}
+
+func TestValidatorConnectionManagerClockSkew(t *testing.T) {
+	cm, _, validators := makeTestValidatorConnectionManager(t, "10.0.0.1", "10.0.0.2")
+
+	var lock sync.Mutex
+	var warnings []*logging.Record
+	cm.log = logging.New()
+	cm.log.SetHandler(logging.FuncHandler(func(r *logging.Record) error {
+		lock.Lock()
+		defer lock.Unlock()
+		if r.Lvl == logging.LvlWarn {
+			warnings = append(warnings, r)
+		}
+		return nil
+	}))
+
+	skewed, synced := validators[0], validators[1]
+	offset := -2 * time.Minute
+	cm.clients[skewed.Address()] = testSkewedNetworkClient{validator: skewed, offset: offset}
+	cm.clients[synced.Address()] = testSkewedNetworkClient{validator: synced}
+
+	require.Equal(t, 0, len(cm.ClockSkews()))
+
+	for i := 0; i < 3; i++ {
+		require.Nil(t, cm.connectValidator(skewed))
+		require.Nil(t, cm.connectValidator(synced))
+	}
+
+	skews := cm.ClockSkews()
+	require.Equal(t, 2, len(skews))
+	require.True(t, skews[skewed.Address()] < offset+time.Second)
+	require.True(t, skews[skewed.Address()] > offset-time.Second)
+	require.True(t, skews[synced.Address()] < time.Second)
+	require.True(t, skews[synced.Address()] > -time.Second)
+
+	// the warning is logged only once for the skewed validator
+	lock.Lock()
+	require.Equal(t, 1, len(warnings))
+	lock.Unlock()
+
+	// the node info without time does not change the skew
+	skew, ok := estimateClockSkew([]byte(`{"address": "showme"}`), time.Now(), time.Now())
+	require.False(t, ok)
+	require.Equal(t, time.Duration(0), skew)
+}
+
+func TestValidatorConnectionManagerValidatorStatusList(t *testing.T) {
+	defer func(d time.Duration) { ConnectionStateDebounce = d }(ConnectionStateDebounce)
+	ConnectionStateDebounce = 0
+
+	cm, _, validators := makeTestValidatorConnectionManager(t, "10.0.0.1", "10.0.0.2", "10.0.0.3")
+
+	connected, disconnected, unchecked := validators[0], validators[1], validators[2]
+
+	cm.setConnectResult(connected, nil)
+	cm.setConnected(connected, true)
+
+	// once connected, but the last check is failed
+	cm.setConnectResult(disconnected, nil)
+	cm.setConnected(disconnected, true)
+	cm.setConnectResult(disconnected, errors.New("findme"))
+	cm.setConnected(disconnected, false)
+
+	statuses := cm.ValidatorStatusList()
+	require.Equal(t, 3, len(statuses))
+
+	byAddress := map[string]ValidatorStatus{}
+	for i, status := range statuses {
+		if i > 0 {
+			require.True(t, statuses[i-1].Address < status.Address)
+		}
+		byAddress[status.Address] = status
+	}
+
+	for _, v := range validators {
+		status := byAddress[v.Address()]
+		require.Equal(t, v.Alias(), status.Alias)
+		require.Equal(t, v.Endpoint().String(), status.Endpoint)
+	}
+
+	{
+		status := byAddress[connected.Address()]
+		require.True(t, status.Connected)
+		require.Equal(t, "", status.LastError)
+		require.NotEqual(t, "", status.LastSeen)
+	}
+
+	{
+		status := byAddress[disconnected.Address()]
+		require.False(t, status.Connected)
+		require.Equal(t, "findme", status.LastError)
+		require.NotEqual(t, "", status.LastSeen)
+	}
+
+	{
+		status := byAddress[unchecked.Address()]
+		require.False(t, status.Connected)
+		require.Equal(t, "", status.LastError)
+		require.Equal(t, "", status.LastSeen)
+	}
+}
+
+// testEndpointNetwork records the endpoints, which the clients are made for;
+// the client returns the node info of validator.
+type testEndpointNetwork struct {
+	Network
+
+	sync.Mutex
+	validator *node.Validator
+	endpoints []string
+}
+
+func (n *testEndpointNetwork) GetClient(endpoint *common.Endpoint) NetworkClient {
+	n.Lock()
+	defer n.Unlock()
+
+	n.endpoints = append(n.endpoints, endpoint.String())
+	return testSkewedNetworkClient{validator: n.validator}
+}
+
+func (n *testEndpointNetwork) Endpoints() []string {
+	n.Lock()
+	defer n.Unlock()
+
+	return append([]string{}, n.endpoints...)
+}
+
+func TestValidatorConnectionManagerUpdateValidatorEndpoint(t *testing.T) {
+	cm, _, validators := makeTestValidatorConnectionManager(t, "10.0.0.1")
+	v := validators[0]
+	network := &testEndpointNetwork{validator: v}
+	cm.network = network
+
+	require.Nil(t, cm.connectValidator(v))
+	require.Nil(t, cm.connectValidator(v))
+	require.Equal(t, []string{"https://10.0.0.1:12345"}, network.Endpoints())
+
+	{ // same endpoint; not changed
+		same, _ := common.NewEndpoint("https://10.0.0.1:12345/")
+		require.False(t, cm.UpdateValidatorEndpoint(v.Address(), same))
+		require.Nil(t, cm.connectValidator(v))
+		require.Equal(t, 1, len(network.Endpoints()))
+	}
+
+	{ // unknown validator
+		kp, _ := keypair.Random()
+		endpoint, _ := common.NewEndpoint("https://10.0.0.9:12345")
+		require.False(t, cm.UpdateValidatorEndpoint(kp.Address(), endpoint))
+	}
+
+	// the next connection check reconnects to the new endpoint
+	endpoint, _ := common.NewEndpoint("https://10.0.0.2:23456")
+	require.True(t, cm.UpdateValidatorEndpoint(v.Address(), endpoint))
+	require.Equal(t, endpoint.String(), v.Endpoint().String())
+
+	require.Nil(t, cm.connectValidator(v))
+	require.Equal(t, []string{"https://10.0.0.1:12345", "https://10.0.0.2:23456"}, network.Endpoints())
+
+	statuses := cm.ValidatorStatusList()
+	require.Equal(t, "https://10.0.0.2:23456", statuses[0].Endpoint)
+}
@@ -0,0 +1,77 @@
+package node
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/btcsuite/btcutil/base58"
+
+	"boscoin.io/sebak/lib/common"
+)
+
+type AliasFormat string
+
+const (
+	// AliasFormatShort is `<first 4 chars>.<4 chars before the last 4 chars>`
+	// of the address, like `GAWM.4N2I`. It is short, but the different
+	// addresses can have the same alias.
+	AliasFormatShort AliasFormat = "short"
+
+	// AliasFormatLong uses the longer slices of the address, like
+	// `GAWMRHEP.BUZI4N2I`.
+	AliasFormatLong AliasFormat = "long"
+
+	// AliasFormatHash is the first 8 chars of the base58 encoded SHA-256
+	// hash of the full address.
+	AliasFormatHash AliasFormat = "hash"
+)
+
+// DefaultAliasFormat is used by `MakeAlias`.
+var DefaultAliasFormat AliasFormat = AliasFormatShort
+
+func ParseAliasFormat(s string) (AliasFormat, error) {
+	switch f := AliasFormat(s); f {
+	case AliasFormatShort, AliasFormatLong, AliasFormatHash:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown alias format: '%s'", s)
+	}
+}
+
+func MakeAlias(address string) string {
+	return MakeAliasWithFormat(address, DefaultAliasFormat)
+}
+
+func MakeAliasWithFormat(address string, format AliasFormat) string {
+	l := len(address)
+	switch format {
+	case AliasFormatLong:
+		return fmt.Sprintf("%s.%s", address[:8], address[l-12:l-4])
+	case AliasFormatHash:
+		return base58.Encode(common.HashAlgoSHA256.Hash([]byte(address)))[:8]
+	default:
+		return fmt.Sprintf("%s.%s", address[:4], address[l-8:l-4])
+	}
+}
+
+// CheckAliasCollision returns the sorted aliases, which are used by more
+// than one validator.
+func CheckAliasCollision(validators map[string]*Validator) []string {
+	addresses := map[string]map[string]bool{}
+	for _, v := range validators {
+		if _, found := addresses[v.Alias()]; !found {
+			addresses[v.Alias()] = map[string]bool{}
+		}
+		addresses[v.Alias()][v.Address()] = true
+	}
+
+	var collided []string
+	for alias, a := range addresses {
+		if len(a) > 1 {
+			collided = append(collided, alias)
+		}
+	}
+	sort.Strings(collided)
+
+	return collided
+}
@@ -0,0 +1,50 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMakeAliasWithFormat(t *testing.T) {
+	address := "GAWMRHEPMJFTROGBNGIHRR5QEH7E33F7FZNLF6FC5V67BUZI4N2I7BXG"
+
+	require.Equal(t, "GAWM.4N2I", MakeAlias(address))
+	require.Equal(t, "GAWM.4N2I", MakeAliasWithFormat(address, AliasFormatShort))
+	require.Equal(t, "GAWMRHEP.BUZI4N2I", MakeAliasWithFormat(address, AliasFormatLong))
+
+	hashed := MakeAliasWithFormat(address, AliasFormatHash)
+	require.Equal(t, 8, len(hashed))
+	require.Equal(t, hashed, MakeAliasWithFormat(address, AliasFormatHash))
+
+	_, err := ParseAliasFormat("findme")
+	require.NotNil(t, err)
+
+	format, err := ParseAliasFormat("hash")
+	require.Nil(t, err)
+	require.Equal(t, AliasFormatHash, format)
+}
+
+func TestCheckAliasCollision(t *testing.T) {
+	// these addresses share the first 4 chars and the 4 chars before the last
+	// 4 chars, so they have the same alias in `AliasFormatShort`.
+	a := "GAWMRHEPMJFTROGBNGIHRR5QEH7E33F7FZNLF6FC5V67BUZI4N2I7BXG"
+	b := "GAWMXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXX4N2IXXXX"
+	require.Equal(t, MakeAlias(a), MakeAlias(b))
+
+	kp, _ := keypair.Random()
+	other, _ := NewValidator(kp.Address(), nil, "")
+
+	makeValidators := func(format AliasFormat) map[string]*Validator {
+		return map[string]*Validator{
+			a:               {address: a, alias: MakeAliasWithFormat(a, format)},
+			b:               {address: b, alias: MakeAliasWithFormat(b, format)},
+			other.Address(): other,
+		}
+	}
+
+	require.Equal(t, []string{MakeAlias(a)}, CheckAliasCollision(makeValidators(AliasFormatShort)))
+	require.Nil(t, CheckAliasCollision(makeValidators(AliasFormatLong)))
+	require.Nil(t, CheckAliasCollision(makeValidators(AliasFormatHash)))
+}
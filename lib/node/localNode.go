@@ -10,10 +10,10 @@ package node
 
 import (
 	"encoding/json"
-	"fmt"
 	"sync"
 
 	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/error"
 
 	"github.com/stellar/go/keypair"
 )
@@ -28,6 +28,7 @@ type LocalNode struct {
 	bindEndpoint    *common.Endpoint
 	publishEndpoint *common.Endpoint
 	validators      map[ /* Node.Address() */ string]*Validator
+	maxValidators   int
 }
 
 func NewLocalNode(kp *keypair.Full, bindEndpoint *common.Endpoint, alias string) (n *LocalNode, err error) {
@@ -115,10 +116,37 @@ func (n *LocalNode) GetValidators() map[string]*Validator {
 	return n.validators
 }
 
+// SetMaxValidators sets the maximum number of validators; `AddValidators`
+// refuses to add more validators. `0` disables the limit.
+func (n *LocalNode) SetMaxValidators(max int) {
+	n.Lock()
+	defer n.Unlock()
+
+	n.maxValidators = max
+}
+
+// AddValidators adds the validators. If the number of validators exceeds the
+// maximum number by `SetMaxValidators`, nothing is added and
+// `errors.ErrorTooManyValidators` is returned.
 func (n *LocalNode) AddValidators(validators ...*Validator) error {
 	n.Lock()
 	defer n.Unlock()
 
+	if n.maxValidators > 0 {
+		added := map[string]bool{}
+		for _, va := range validators {
+			if _, found := n.validators[va.Address()]; found || n.Address() == va.Address() {
+				continue
+			}
+			added[va.Address()] = true
+		}
+		if len(n.validators)+len(added) > n.maxValidators {
+			return errors.ErrorTooManyValidators.Clone().
+				SetData("validators", len(n.validators)+len(added)).
+				SetData("max", n.maxValidators)
+		}
+	}
+
 	for _, va := range validators {
 		if n.Address() == va.Address() {
 			continue
@@ -147,8 +175,3 @@ func (n *LocalNode) ConvertToValidator() *Validator {
 	v, _ := NewValidator(n.Address(), n.Endpoint(), n.Alias())
 	return v
 }
-
-func MakeAlias(address string) string {
-	l := len(address)
-	return fmt.Sprintf("%s.%s", address[:4], address[l-8:l-4])
-}
@@ -1,11 +1,13 @@
 package node
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 	"testing"
 
 	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/error"
 
 	"github.com/stellar/go/keypair"
 	"github.com/stretchr/testify/require"
@@ -98,3 +100,54 @@ func TestNodeMarshalJSONWithValidator(t *testing.T) {
 	require.Equal(t, true, strings.Contains(string(tmpByte), fmt.Sprintf(jsonStr, "v1", "5001", "NONE")))
 	require.Equal(t, true, strings.Contains(string(tmpByte), fmt.Sprintf(jsonStr, "v2", "5002", "NONE")))
 }
+
+// TestNodeMarshalJSONWithoutSecret checks the secret seed of `LocalNode` is
+// never serialized.
+func TestNodeMarshalJSONWithoutSecret(t *testing.T) {
+	kp, _ := keypair.Random()
+	endpoint, err := common.NewEndpointFromString("https://localhost:5000?NodeName=n1")
+	require.Nil(t, err)
+
+	node, _ := NewLocalNode(kp, endpoint, "")
+	node.SetPublishEndpoint(endpoint)
+
+	kpValidator, _ := keypair.Random()
+	validator, _ := NewValidator(kpValidator.Address(), endpoint, "")
+	node.AddValidators(validator)
+
+	b, err := node.Serialize()
+	require.Nil(t, err)
+	require.NotContains(t, string(b), kp.Seed())
+
+	b, err = json.Marshal(map[string]interface{}{"node": node})
+	require.Nil(t, err)
+	require.NotContains(t, string(b), kp.Seed())
+}
+
+func TestLocalNodeMaxValidators(t *testing.T) {
+	kp, _ := keypair.Random()
+	endpoint, _ := common.NewEndpointFromString("https://localhost:5000")
+	localNode, _ := NewLocalNode(kp, endpoint, "")
+	localNode.SetMaxValidators(2)
+
+	var validators []*Validator
+	for i := 0; i < 3; i++ {
+		kpValidator, _ := keypair.Random()
+		v, _ := NewValidator(kpValidator.Address(), endpoint, "")
+		validators = append(validators, v)
+	}
+
+	err := localNode.AddValidators(validators...)
+	require.Equal(t, errors.ErrorTooManyValidators.Code, err.(*errors.Error).Code)
+	require.Equal(t, 0, len(localNode.GetValidators()))
+
+	// the local node itself and the added validators are not counted again
+	require.Nil(t, localNode.AddValidators(validators[0], localNode.ConvertToValidator()))
+	require.Nil(t, localNode.AddValidators(validators[0], validators[1]))
+	require.Equal(t, 2, len(localNode.GetValidators()))
+
+	// added at runtime
+	err = localNode.AddValidators(validators[2])
+	require.Equal(t, errors.ErrorTooManyValidators.Code, err.(*errors.Error).Code)
+	require.Equal(t, 2, len(localNode.GetValidators()))
+}
@@ -0,0 +1,42 @@
+package runner
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"boscoin.io/sebak/lib/error"
+	"boscoin.io/sebak/lib/network"
+	"boscoin.io/sebak/lib/network/httputils"
+)
+
+// checkAdminToken checks the request has the admin token by
+// "Authorization: Bearer <token>". Without the admin token of node, all the
+// requests are refused.
+func (nh NetworkHandlerNode) checkAdminToken(r *http.Request) bool {
+	if len(nh.adminToken) < 1 {
+		return false
+	}
+
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	return subtle.ConstantTimeCompare([]byte(token), []byte(nh.adminToken)) == 1
+}
+
+// AdminAuthMiddleware refuses the request to the admin handlers without the
+// admin token.
+func (nh NetworkHandlerNode) AdminAuthMiddleware() network.Middleware {
+	return network.Middleware{
+		Name:  network.MiddlewareNameAuth,
+		Order: network.MiddlewareOrderAuth,
+		Wrap: func(h http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if !nh.checkAdminToken(r) {
+					httputils.WriteJSONError(w, errors.ErrorAdminUnauthorized)
+					return
+				}
+				h.ServeHTTP(w, r)
+			})
+		},
+	}
+}
@@ -5,13 +5,25 @@ import (
 	"bytes"
 	"encoding/json"
 	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
 
 	"boscoin.io/sebak/lib/block"
+	"boscoin.io/sebak/lib/common"
 	"boscoin.io/sebak/lib/error"
+	"boscoin.io/sebak/lib/network/httputils"
 	"boscoin.io/sebak/lib/transaction"
 )
 
-const GetBlocksPattern = "/blocks"
+const (
+	GetBlocksPattern = "/blocks"
+	GetBlockPattern  = "/block/{hash}"
+
+	// BlockStreamContentType is the content type of the blocks response of
+	// `GetBlocksOptionsModeBinary`.
+	BlockStreamContentType = "application/octet-stream"
+)
 
 type NodeItemDataType string
 
@@ -24,9 +36,38 @@ const (
 	NodeItemError            NodeItemDataType = "error"
 )
 
+// GetBlockHandler returns the serialized block of the hash; if not found, it
+// responds 404 problem.
+func (nh NetworkHandlerNode) GetBlockHandler(w http.ResponseWriter, r *http.Request) {
+	hash := mux.Vars(r)["hash"]
+	if !common.IsValidHashString(hash) {
+		httputils.WriteJSONError(w, errors.ErrorInvalidHash)
+		return
+	}
+
+	if exists, err := block.ExistsBlock(nh.storage, hash); err != nil {
+		httputils.WriteJSONError(w, err)
+		return
+	} else if !exists {
+		httputils.WriteJSON(w, http.StatusNotFound, errors.ErrorBlockNotFound)
+		return
+	}
+
+	blk, err := block.GetBlock(nh.storage, hash)
+	if err != nil {
+		httputils.WriteJSONError(w, err)
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, blk)
+}
+
 func (nh NetworkHandlerNode) GetBlocksHandler(w http.ResponseWriter, r *http.Request) {
 	options, err := NewGetBlocksOptionsFromRequest(r)
-	if err != nil {
+	if err == errors.ErrorRequestBodyTooLarge {
+		httputils.WriteJSONError(w, err)
+		return
+	} else if err != nil {
 		http.Error(w, errors.ErrorInvalidQueryString.Error(), http.StatusBadRequest)
 		return
 	}
@@ -103,11 +144,36 @@ func (nh NetworkHandlerNode) GetBlocksHandler(w http.ResponseWriter, r *http.Req
 		closeFunc()
 	}
 
+	if options.Mode == GetBlocksOptionsModeBinary {
+		w.Header().Set("Content-Type", BlockStreamContentType)
+		w.Header().Set("X-SEBAK-RESULT-COUNT", strconv.Itoa(len(bs)))
+
+		// the frames are written one by one, so the whole blocks are not
+		// buffered.
+		flusher, _ := w.(http.Flusher)
+		for i, b := range bs {
+			frame, err := block.EncodeBlockBinary(*b)
+			if err != nil {
+				if i == 0 {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+				}
+				return
+			}
+			if _, err := w.Write(frame); err != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 
 	// set header; `X-SEBAK-xxx` indicates the basic explanation of the
 	// response.
-	w.Header().Set("X-SEBAK-RESULT-COUNT", string(len(bs)))
+	w.Header().Set("X-SEBAK-RESULT-COUNT", strconv.Itoa(len(bs)))
 
 	for _, b := range bs {
 		var itemType NodeItemDataType
@@ -10,6 +10,7 @@ import (
 	"strings"
 
 	"boscoin.io/sebak/lib/error"
+	"boscoin.io/sebak/lib/network/httputils"
 	"boscoin.io/sebak/lib/storage"
 )
 
@@ -19,6 +20,9 @@ const (
 	GetBlocksOptionsModeHeader GetBlocksOptionsMode = "header" // default
 	GetBlocksOptionsModeBlock  GetBlocksOptionsMode = "block"
 	GetBlocksOptionsModeFull   GetBlocksOptionsMode = "full"
+	// GetBlocksOptionsModeBinary responds the blocks as the stream of the
+	// block binary frames; see `block.WriteBlockStream()`.
+	GetBlocksOptionsModeBinary GetBlocksOptionsMode = "binary"
 )
 
 type GetBlocksOptions struct {
@@ -112,6 +116,9 @@ func (g *GetBlocksOptions) parseBlockHashes() (err error) {
 
 		var body []byte
 		if body, err = ioutil.ReadAll(g.r.Body); err != nil {
+			if httputils.IsRequestBodyTooLarge(err) {
+				err = errors.ErrorRequestBodyTooLarge
+			}
 			return
 		} else if len(strings.TrimSpace(string(body))) < 1 {
 			goto end
@@ -154,6 +161,8 @@ func (g *GetBlocksOptions) parseGetBlocksOptionsMode() error {
 		mode = GetBlocksOptionsModeHeader
 	case GetBlocksOptionsModeFull:
 		mode = GetBlocksOptionsModeFull
+	case GetBlocksOptionsModeBinary:
+		mode = GetBlocksOptionsModeBinary
 	default:
 		return fmt.Errorf("unknown `mode`")
 	}
@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strconv"
 	"testing"
 
 	"github.com/gorilla/mux"
@@ -255,6 +256,35 @@ func TestGetBlocksHandlerOptions(t *testing.T) {
 	}
 }
 
+// TestGetBlocksHandlerBinary checks `/blocks` api streams the blocks in the
+// block binary format with `GetBlocksOptionsModeBinary`.
+func TestGetBlocksHandlerBinary(t *testing.T) {
+	p := &HelperTestGetBlocksHandler{}
+	p.Prepare()
+	defer p.Done()
+
+	options, err := NewGetBlocksOptionsFromRequest(nil)
+	require.Nil(t, err)
+	options.SetMode(GetBlocksOptionsModeBinary)
+	u := p.URL(options.URLValues())
+
+	req, _ := http.NewRequest("GET", u.String(), nil)
+	resp, err := p.server.Client().Do(req)
+	require.Nil(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, BlockStreamContentType, resp.Header.Get("Content-Type"))
+	require.Equal(t, strconv.Itoa(len(p.blocks)), resp.Header.Get("X-SEBAK-RESULT-COUNT"))
+
+	blocks, err := block.ReadBlockStream(resp.Body)
+	require.Nil(t, err)
+	require.Equal(t, len(p.blocks), len(blocks))
+	for i, b := range p.blocks {
+		require.Equal(t, b.Hash, blocks[i].Hash)
+	}
+}
+
 func TestGetBlocksHandlerWithInvalidLimit(t *testing.T) {
 	p := &HelperTestGetBlocksHandler{}
 	p.Prepare()
@@ -672,3 +702,75 @@ func TestGetBlocksHandlerWithInvalidMode(t *testing.T) {
 
 	}
 }
+
+func TestGetBlockHandler(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	blk := block.TestMakeNewBlock([]string{})
+	require.Nil(t, blk.Save(st))
+
+	apiHandler := NetworkHandlerNode{storage: st}
+
+	router := mux.NewRouter()
+	router.HandleFunc(GetBlockPattern, apiHandler.GetBlockHandler).Methods("GET")
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	get := func(hash string) (*http.Response, []byte) {
+		resp, err := http.Get(server.URL + "/block/" + hash)
+		require.Nil(t, err)
+		defer resp.Body.Close()
+
+		body, _ := ioutil.ReadAll(resp.Body)
+		return resp, body
+	}
+
+	{ // known hash
+		resp, body := get(blk.Hash)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var received block.Block
+		require.Nil(t, json.Unmarshal(body, &received))
+		require.Equal(t, blk.Hash, received.Hash)
+		require.Equal(t, blk.Height, received.Height)
+	}
+
+	{ // unknown hash
+		unknown := block.TestMakeNewBlock([]string{})
+		resp, body := get(unknown.Hash)
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+		require.Equal(t, "application/problem+json", resp.Header.Get("Content-Type"))
+		require.Contains(t, string(body), errors.ErrorBlockNotFound.Message)
+	}
+
+	{ // invalid hash
+		resp, _ := get("findme")
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	}
+}
+
+func TestHTTP2NetworkGetBlock(t *testing.T) {
+	_, s0, nodeRunner := createNewHTTP2Network(t)
+	s0.SetMessageBroker(TestMessageBroker{network: s0})
+	nodeRunner.Ready()
+
+	go nodeRunner.Start()
+	defer nodeRunner.Stop()
+
+	c0 := s0.GetClient(s0.Endpoint())
+	pingAndWait(t, c0)
+
+	genesis, err := block.GetLatestBlock(nodeRunner.Storage())
+	require.Nil(t, err)
+
+	received, err := c0.GetBlock(genesis.Hash)
+	require.Nil(t, err)
+	require.Equal(t, genesis.Hash, received.Hash)
+
+	_, err = c0.GetBlock(block.TestMakeNewBlock([]string{}).Hash)
+	require.Equal(t, errors.ErrorBlockNotFound, err)
+
+	_, err = c0.GetBlock("findme")
+	require.Equal(t, errors.ErrorInvalidHash, err)
+}
@@ -0,0 +1,81 @@
+package runner
+
+import (
+	"io/ioutil"
+	"net/http"
+
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/error"
+	"boscoin.io/sebak/lib/network/httputils"
+	"boscoin.io/sebak/lib/transaction"
+)
+
+// FeeEstimate is the fee which `transaction.DefaultFeePolicy` charges for the
+// operations of transaction. `Fee` is the value for `TransactionBody.Fee`, the
+// fee for each operation, and `Total` is the sum of the fees of operations.
+type FeeEstimate struct {
+	Fee         common.Amount   `json:"fee"`
+	Total       common.Amount   `json:"total"`
+	Operations  []common.Amount `json:"operations"`
+	BaseFee     common.Amount   `json:"base-fee"`
+	BaseReserve common.Amount   `json:"base-reserve"`
+}
+
+// EstimateFee calculates the fee of the operations of transaction by the
+// given `FeePolicy`. The signature, source and sequence ID of transaction are
+// not checked.
+func EstimateFee(tx transaction.Transaction, policy transaction.FeePolicy) (estimate FeeEstimate, err error) {
+	n := len(tx.B.Operations)
+	if n < 1 {
+		err = errors.ErrorTransactionEmptyOperations
+		return
+	} else if n > common.MaxOperationsInTransaction {
+		err = errors.ErrorTransactionHasOverMaxOperations
+		return
+	}
+
+	estimate.BaseFee = common.BaseFee
+	estimate.BaseReserve = common.BaseReserve
+	for _, op := range tx.B.Operations {
+		estimate.Operations = append(estimate.Operations, policy.FeeFor(op))
+	}
+
+	if estimate.Total, err = tx.RequiredFee(policy); err != nil {
+		return
+	}
+
+	// `TransactionBody.Fee` is multiplied by the number of operations, so it
+	// is rounded up to cover `Total`.
+	estimate.Fee = common.Amount((uint64(estimate.Total) + uint64(n) - 1) / uint64(n))
+
+	return
+}
+
+// FeeEstimateHandler returns the fee which the posted transaction must pay;
+// see `EstimateFee`. The transaction does not need to be signed and it's
+// source does not need to exist.
+func (nh NetworkHandlerNode) FeeEstimateHandler(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	defer r.Body.Close()
+	if err != nil {
+		writeReadBodyError(w, err)
+		return
+	}
+
+	tx, err := decodeTransaction(body)
+	if err != nil {
+		httputils.WriteJSONError(w, err)
+		return
+	}
+
+	estimate, err := EstimateFee(tx, transaction.DefaultFeePolicy)
+	if err != nil {
+		httputils.WriteJSONError(w, err)
+		return
+	}
+
+	if err = httputils.WriteJSON(w, 200, estimate); err != nil {
+		httputils.WriteJSONError(w, err)
+		return
+	}
+}
@@ -0,0 +1,114 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stellar/go/keypair"
+	"github.com/stretchr/testify/require"
+
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/error"
+	"boscoin.io/sebak/lib/storage"
+	"boscoin.io/sebak/lib/transaction"
+)
+
+// The estimated fee must be accepted by `ValidateTx` and the lower fee must
+// not be.
+func TestEstimateFeeMatchesValidateTx(t *testing.T) {
+	defer func(policy transaction.FeePolicy) { transaction.DefaultFeePolicy = policy }(transaction.DefaultFeePolicy)
+	createAccountFee := common.BaseFee.MustMult(3)
+	transaction.DefaultFeePolicy = transaction.OperationFeePolicy{
+		Fees: map[transaction.OperationType]common.Amount{
+			transaction.OperationCreateAccount: createAccountFee,
+		},
+	}
+
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	kps, kpt := makeSimulateTestAccounts(st)
+	kpNew, _ := keypair.Random()
+
+	ops := []transaction.Operation{
+		transaction.Operation{
+			H: transaction.OperationHeader{Type: transaction.OperationCreateAccount},
+			B: transaction.NewOperationBodyCreateAccount(kpNew.Address(), common.BaseReserve, ""),
+		},
+		transaction.Operation{
+			H: transaction.OperationHeader{Type: transaction.OperationPayment},
+			B: transaction.NewOperationBodyPayment(kpt.Address(), common.Amount(10000)),
+		},
+	}
+	tx, _ := transaction.NewTransaction(kps.Address(), 0, ops...)
+
+	estimate, err := EstimateFee(tx, transaction.DefaultFeePolicy)
+	require.Nil(t, err)
+	require.Equal(t, []common.Amount{createAccountFee, common.BaseFee}, estimate.Operations)
+	require.Equal(t, createAccountFee.MustAdd(common.BaseFee), estimate.Total)
+	require.Equal(t, common.BaseFee.MustMult(2), estimate.Fee)
+	require.Equal(t, common.BaseFee, estimate.BaseFee)
+	require.Equal(t, common.BaseReserve, estimate.BaseReserve)
+
+	tx.B.Fee = estimate.Fee
+	require.Nil(t, ValidateTx(st, tx, nil))
+
+	tx.B.Fee = estimate.Fee.MustSub(1)
+	require.Equal(t, errors.ErrorInvalidFee, ValidateTx(st, tx, nil))
+}
+
+func TestEstimateFeeEmptyOperations(t *testing.T) {
+	kp, _ := keypair.Random()
+	tx := transaction.Transaction{B: transaction.TransactionBody{Source: kp.Address()}}
+
+	_, err := EstimateFee(tx, transaction.DefaultFeePolicy)
+	require.Equal(t, errors.ErrorTransactionEmptyOperations, err)
+}
+
+// The transaction is not signed and it's source does not exist.
+func TestFeeEstimateHandler(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	apiHandler := NetworkHandlerNode{storage: st}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/fee-estimate", apiHandler.FeeEstimateHandler).Methods("POST")
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	kps, _ := keypair.Random()
+	kpt, _ := keypair.Random()
+	op := transaction.Operation{
+		H: transaction.OperationHeader{Type: transaction.OperationPayment},
+		B: transaction.NewOperationBodyPayment(kpt.Address(), common.Amount(10000)),
+	}
+	tx, _ := transaction.NewTransaction(kps.Address(), 0, op, op)
+
+	{ // valid
+		body, _ := json.Marshal(tx)
+		resp, err := http.Post(server.URL+"/fee-estimate", "application/json", bytes.NewBuffer(body))
+		require.Nil(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var estimate FeeEstimate
+		b, _ := ioutil.ReadAll(resp.Body)
+		require.Nil(t, json.Unmarshal(b, &estimate))
+		require.Equal(t, common.BaseFee, estimate.Fee)
+		require.Equal(t, common.BaseFee.MustMult(2), estimate.Total)
+		require.Equal(t, common.BaseReserve, estimate.BaseReserve)
+	}
+
+	{ // malformed
+		resp, err := http.Post(server.URL+"/fee-estimate", "application/json", bytes.NewBufferString("{"))
+		require.Nil(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	}
+}
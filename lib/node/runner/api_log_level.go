@@ -0,0 +1,56 @@
+package runner
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	logging "github.com/inconshreveable/log15"
+
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/error"
+	"boscoin.io/sebak/lib/network/httputils"
+)
+
+const LogLevelHandlerPattern string = "/admin/log-level"
+
+type LogLevelRequest struct {
+	Module string `json:"module"`
+	// Level is the log15 level name, like "debug" and "info"; empty Level
+	// resets the level of the module to the default.
+	Level string `json:"level"`
+}
+
+// LogLevelHandler returns the log levels of the modules by GET and sets the
+// log level of module by POST. Like the other admin handlers, the admin token
+// is checked by `AdminAuthMiddleware()`.
+func (api NetworkHandlerNode) LogLevelHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "POST" {
+		defer r.Body.Close()
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			writeReadBodyError(w, err)
+			return
+		}
+
+		var req LogLevelRequest
+		if err := json.Unmarshal(body, &req); err != nil || len(req.Module) < 1 {
+			httputils.WriteJSONError(w, errors.ErrorInvalidLogLevel)
+			return
+		}
+
+		if len(req.Level) < 1 {
+			common.ResetLogLevel(req.Module)
+		} else {
+			level, err := logging.LvlFromString(req.Level)
+			if err != nil {
+				httputils.WriteJSONError(w, errors.ErrorInvalidLogLevel.Clone().SetData("level", req.Level))
+				return
+			}
+			common.SetLogLevel(req.Module, level)
+		}
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, common.LogLevels())
+}
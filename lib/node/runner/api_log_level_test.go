@@ -0,0 +1,99 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	logging "github.com/inconshreveable/log15"
+	"github.com/stretchr/testify/require"
+
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/error"
+	"boscoin.io/sebak/lib/network/httputils"
+)
+
+func TestLogLevelHandler(t *testing.T) {
+	defer common.ResetLogLevel("http")
+
+	apiHandler := NetworkHandlerNode{adminToken: "showme"}
+
+	router := mux.NewRouter()
+	router.Handle(
+		LogLevelHandlerPattern,
+		apiHandler.AdminAuthMiddleware().Wrap(http.HandlerFunc(apiHandler.LogLevelHandler)),
+	).Methods("GET", "POST")
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	post := func(token, module, level string) (int, []byte) {
+		body, _ := json.Marshal(LogLevelRequest{Module: module, Level: level})
+		req, err := http.NewRequest("POST", server.URL+LogLevelHandlerPattern, bytes.NewBuffer(body))
+		require.Nil(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		require.Nil(t, err)
+		defer resp.Body.Close()
+
+		b, _ := ioutil.ReadAll(resp.Body)
+		return resp.StatusCode, b
+	}
+
+	{ // without token
+		resp, err := http.Get(server.URL + LogLevelHandlerPattern)
+		require.Nil(t, err)
+		resp.Body.Close()
+		require.Equal(t, httputils.StatusCode(errors.ErrorAdminUnauthorized), resp.StatusCode)
+
+		status, _ := post("findme", "http", "error")
+		require.Equal(t, httputils.StatusCode(errors.ErrorAdminUnauthorized), status)
+
+		_, found := common.GetLogLevel("http")
+		require.False(t, found)
+	}
+
+	{ // set
+		status, body := post("showme", "http", "error")
+		require.Equal(t, http.StatusOK, status)
+
+		var levels map[string]string
+		require.Nil(t, json.Unmarshal(body, &levels))
+		require.Equal(t, "eror", levels["http"])
+
+		level, found := common.GetLogLevel("http")
+		require.True(t, found)
+		require.Equal(t, logging.LvlError, level)
+	}
+
+	{ // get
+		req, err := http.NewRequest("GET", server.URL+LogLevelHandlerPattern, nil)
+		require.Nil(t, err)
+		req.Header.Set("Authorization", "Bearer showme")
+		resp, err := http.DefaultClient.Do(req)
+		require.Nil(t, err)
+		defer resp.Body.Close()
+
+		var levels map[string]string
+		b, _ := ioutil.ReadAll(resp.Body)
+		require.Nil(t, json.Unmarshal(b, &levels))
+		require.Equal(t, "eror", levels["http"])
+	}
+
+	{ // invalid level
+		status, _ := post("showme", "http", "findme")
+		require.Equal(t, httputils.StatusCode(errors.ErrorInvalidLogLevel), status)
+	}
+
+	{ // reset
+		status, _ := post("showme", "http", "")
+		require.Equal(t, http.StatusOK, status)
+
+		_, found := common.GetLogLevel("http")
+		require.False(t, found)
+	}
+}
@@ -9,24 +9,39 @@ import (
 
 	"boscoin.io/sebak/lib/common"
 	"boscoin.io/sebak/lib/consensus"
+	"boscoin.io/sebak/lib/error"
 	"boscoin.io/sebak/lib/network"
+	"boscoin.io/sebak/lib/network/httputils"
 	"boscoin.io/sebak/lib/node"
 	"boscoin.io/sebak/lib/storage"
 )
 
 const (
-	NodeInfoHandlerPattern string = "/"
-	ConnectHandlerPattern  string = "/connect"
-	MessageHandlerPattern  string = "/message"
-	BallotHandlerPattern   string = "/ballot"
+	NodeInfoHandlerPattern       string = "/"
+	NodeInfoDetailHandlerPattern string = "/info"
+	NodeReadyzHandlerPattern     string = "/readyz"
+	ConnectHandlerPattern        string = "/connect"
+	MessageHandlerPattern        string = "/message"
+	BallotHandlerPattern         string = "/ballot"
+	NodeRoundHandlerPattern      string = "/round"
 )
 
 type NetworkHandlerNode struct {
-	localNode *node.LocalNode
-	network   network.Network
-	storage   *storage.LevelDBBackend
-	consensus *consensus.ISAAC
-	urlPrefix string
+	localNode         *node.LocalNode
+	network           network.Network
+	storage           *storage.LevelDBBackend
+	consensus         *consensus.ISAAC
+	isaacStateManager *ISAACStateManager
+	acceptance        *transactionAcceptance
+	adminToken        string
+	urlPrefix         string
+
+	// handleTransaction checks and handles the submitted transaction; see
+	// `NodeRunner.handleTransaction`
+	handleTransaction func(common.NetworkMessage) error
+	// startResync starts to resync the blocks from the given height; see
+	// `NodeRunner.startResync`
+	startResync func(uint64) error
 }
 
 func NewNetworkHandlerNode(localNode *node.LocalNode, network network.Network, storage *storage.LevelDBBackend, consensus *consensus.ISAAC, urlPrefix string) *NetworkHandlerNode {
@@ -66,16 +81,95 @@ func (api NetworkHandlerNode) NodeInfoHandler(w http.ResponseWriter, r *http.Req
 	api.network.MessageBroker().Response(w, b)
 }
 
+// NodeInfoDetailHandler returns the public information of `LocalNode`. It
+// comes from `LocalNode.MarshalJSON()`, so the keypair of node is never
+// exposed.
+func (api NetworkHandlerNode) NodeInfoDetailHandler(w http.ResponseWriter, r *http.Request) {
+	b, err := api.localNode.Serialize()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var info map[string]interface{}
+	if err = json.Unmarshal(b, &info); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if api.localNode.PublishEndpoint() != nil {
+		info["publish-endpoint"] = api.localNode.PublishEndpoint().String()
+	}
+	info["validator-count"] = len(api.localNode.GetValidators())
+	if api.consensus != nil {
+		// the estimated clock skews of the connected validators
+		skews := map[string]string{}
+		for address, skew := range api.consensus.ConnectionManager().ClockSkews() {
+			skews[address] = skew.String()
+		}
+		info["clock-skews"] = skews
+	}
+
+	if b, err = json.Marshal(info); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(b)
+}
+
+// NodeReadyzHandler responds `503` when the node has stopped confirming the
+// blocks; the lag since the latest confirmed block is over `MaxConsensusLag`.
+func (api NetworkHandlerNode) NodeReadyzHandler(w http.ResponseWriter, r *http.Request) {
+	latest := api.consensus.LatestConfirmedBlock()
+	lag := consensusLag(latest)
+
+	status := http.StatusOK
+	ready := MaxConsensusLag < 1 || lag <= MaxConsensusLag
+	if !ready {
+		status = http.StatusServiceUnavailable
+	}
+
+	httputils.WriteJSON(w, status, map[string]interface{}{
+		"ready":            ready,
+		"consensus-lag":    lag.String(),
+		"max-lag":          MaxConsensusLag.String(),
+		"latest-height":    latest.Height,
+		"latest-confirmed": latest.Confirmed,
+	})
+}
+
+// NodeRoundHandler returns the current round of consensus, the proposer of
+// the round, the ballot state and the time at which the round was opened.
+func (api NetworkHandlerNode) NodeRoundHandler(w http.ResponseWriter, r *http.Request) {
+	state, opened := api.isaacStateManager.RoundState()
+
+	var openedStr string
+	if !opened.IsZero() {
+		openedStr = common.FormatISO8601(opened)
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"round":        state.Round,
+		"proposer":     api.consensus.SelectProposer(state.Round.BlockHeight, state.Round.Number),
+		"ballot-state": state.BallotState.String(),
+		"opened":       openedStr,
+	})
+}
+
 func (api NetworkHandlerNode) ConnectHandler(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
 	body, err := ioutil.ReadAll(r.Body)
 	if err != nil {
-		http.Error(w, "Error reading request body", http.StatusInternalServerError)
+		writeReadBodyError(w, err)
 		return
 	}
 
-	api.network.MessageBroker().Receive(common.NetworkMessage{Type: common.ConnectMessage, Data: body})
+	if err := api.network.MessageBroker().Receive(common.NetworkMessage{Type: common.ConnectMessage, Data: body}); err != nil {
+		httputils.WriteJSONError(w, err)
+		return
+	}
 
 	b, err := NodeInfoWithRequest(api.localNode, r)
 	if err != nil {
@@ -85,7 +179,17 @@ func (api NetworkHandlerNode) ConnectHandler(w http.ResponseWriter, r *http.Requ
 	api.network.MessageBroker().Response(w, b)
 }
 
+// MessageHandler receives the transaction from the other nodes; the
+// transactions from the clients are received by `PostTransactionHandler`.
 func (api NetworkHandlerNode) MessageHandler(w http.ResponseWriter, r *http.Request) {
+	api.receiveTransaction(w, r, false)
+}
+
+// receiveTransaction passes the posted transaction to the message broker. If
+// `decode` is true, the transaction is decoded before, so the malformed json
+// is rejected with the field-level error by `decodeTransaction`; otherwise it
+// is decoded only once by the `NodeRunner`.
+func (api NetworkHandlerNode) receiveTransaction(w http.ResponseWriter, r *http.Request, decode bool) {
 	defer r.Body.Close()
 
 	if ct := r.Header.Get("Content-Type"); strings.ToLower(ct) != "application/json" {
@@ -95,11 +199,21 @@ func (api NetworkHandlerNode) MessageHandler(w http.ResponseWriter, r *http.Requ
 
 	body, err := ioutil.ReadAll(r.Body)
 	if err != nil {
-		http.Error(w, "Error reading request body", http.StatusInternalServerError)
+		writeReadBodyError(w, err)
 		return
 	}
 
-	api.network.MessageBroker().Receive(common.NetworkMessage{Type: common.TransactionMessage, Data: body})
+	if decode {
+		if _, err := decodeTransaction(body); err != nil {
+			httputils.WriteJSONError(w, err)
+			return
+		}
+	}
+
+	if err := api.network.MessageBroker().Receive(common.NetworkMessage{Type: common.TransactionMessage, Data: body}); err != nil {
+		httputils.WriteJSONError(w, err)
+		return
+	}
 	api.network.MessageBroker().Response(w, body)
 }
 
@@ -113,11 +227,14 @@ func (api NetworkHandlerNode) BallotHandler(w http.ResponseWriter, r *http.Reque
 
 	body, err := ioutil.ReadAll(r.Body)
 	if err != nil {
-		http.Error(w, "Error reading request body", http.StatusInternalServerError)
+		writeReadBodyError(w, err)
 		return
 	}
 
-	api.network.MessageBroker().Receive(common.NetworkMessage{Type: common.BallotMessage, Data: body})
+	if err := api.network.MessageBroker().Receive(common.NetworkMessage{Type: common.BallotMessage, Data: body}); err != nil {
+		httputils.WriteJSONError(w, err)
+		return
+	}
 	api.network.MessageBroker().Response(w, body)
 
 	return
@@ -140,8 +257,20 @@ func NodeInfoWithRequest(localNode *node.LocalNode, r *http.Request) (b []byte,
 		"endpoint":   endpoint,
 		"state":      localNode.State().String(),
 		"validators": localNode.GetValidators(),
+		"time":       common.NowISO8601(),
 	}
 
 	b, err = json.Marshal(info)
 	return
 }
+
+// writeReadBodyError writes the error from reading request body. The request
+// body over `HTTP2NetworkConfig.MaxRequestBodyBytes` is rejected with 413.
+func writeReadBodyError(w http.ResponseWriter, err error) {
+	if httputils.IsRequestBodyTooLarge(err) {
+		httputils.WriteJSONError(w, errors.ErrorRequestBodyTooLarge)
+		return
+	}
+
+	http.Error(w, "Error reading request body", http.StatusInternalServerError)
+}
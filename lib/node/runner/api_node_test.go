@@ -21,9 +21,12 @@ import (
 	"github.com/stellar/go/keypair"
 	"github.com/stretchr/testify/require"
 
+	"boscoin.io/sebak/lib/ballot"
 	"boscoin.io/sebak/lib/block"
 	"boscoin.io/sebak/lib/common"
 	"boscoin.io/sebak/lib/consensus"
+	"boscoin.io/sebak/lib/consensus/round"
+	"boscoin.io/sebak/lib/error"
 	"boscoin.io/sebak/lib/network"
 	"boscoin.io/sebak/lib/node"
 	"boscoin.io/sebak/lib/storage"
@@ -110,8 +113,8 @@ func createNewHTTP2Network(t *testing.T) (kp *keypair.Full, n *network.HTTP2Netw
 		address := kp.Address()
 		balance := common.BaseFee.MustAdd(common.BaseReserve)
 		account := block.NewBlockAccount(address, balance)
-		account.Save(st)
-		block.MakeGenesisBlock(st, *account, networkID)
+		account.Save(st, nil)
+		block.MakeGenesisBlock(st, *account, networkID, kp)
 	}
 	conf := consensus.NewISAACConfiguration()
 	if nodeRunner, err = NewNodeRunner(string(networkID), localNode, p, n, is, st, conf); err != nil {
@@ -130,7 +133,7 @@ func (r TestMessageBroker) Response(w io.Writer, o []byte) error {
 	return err
 }
 
-func (r TestMessageBroker) Receive(common.NetworkMessage) {}
+func (r TestMessageBroker) Receive(common.NetworkMessage) error { return nil }
 
 func removeWhiteSpaces(str string) string {
 	return strings.Map(func(r rune) rune {
@@ -180,7 +183,7 @@ func (r StringResponseMessageBroker) Response(w io.Writer, _ []byte) error {
 	return err
 }
 
-func (r StringResponseMessageBroker) Receive(common.NetworkMessage) {}
+func (r StringResponseMessageBroker) Receive(common.NetworkMessage) error { return nil }
 
 func TestHTTP2NetworkMessageBrokerResponseMessage(t *testing.T) {
 	_, s0, nodeRunner := createNewHTTP2Network(t)
@@ -213,11 +216,57 @@ func TestHTTP2NetworkConnect(t *testing.T) {
 	nodeStr := removeWhiteSpaces(string(o))
 
 	returnMsg, _ := c0.Connect(nodeRunner.Node())
+
+	// the current time of node is returned to estimate the clock skew
+	var returned map[string]interface{}
+	require.Nil(t, json.Unmarshal(returnMsg, &returned))
+	_, err := common.ParseISO8601(returned["time"].(string))
+	require.Nil(t, err)
+	delete(returned, "time")
+
+	returnMsg, _ = json.Marshal(returned)
 	returnStr := removeWhiteSpaces(string(returnMsg))
 
 	require.Equal(t, returnStr, nodeStr, "The connectNode and the return should be the same.")
 }
 
+func TestHTTP2NetworkGetAccountSigners(t *testing.T) {
+	_, s0, nodeRunner := createNewHTTP2Network(t)
+	s0.SetMessageBroker(TestMessageBroker{network: s0})
+	nodeRunner.Ready()
+
+	go nodeRunner.Start()
+	defer nodeRunner.Stop()
+
+	c0 := s0.GetClient(s0.Endpoint())
+	pingAndWait(t, c0)
+
+	single := block.TestMakeBlockAccount()
+	require.Nil(t, single.Save(nodeRunner.Storage(), nil))
+
+	signers, err := c0.GetAccountSigners(single.Address)
+	require.Nil(t, err)
+	require.Equal(t, single.GetSigners(), signers)
+
+	kp, _ := keypair.Random()
+	multi := block.TestMakeBlockAccount()
+	multi.Signers = []block.AccountSigner{
+		{Address: multi.Address, Weight: 1},
+		{Address: kp.Address(), Weight: 1},
+	}
+	multi.Threshold = 2
+	require.Nil(t, multi.Save(nodeRunner.Storage(), nil))
+
+	signers, err = c0.GetAccountSigners(multi.Address)
+	require.Nil(t, err)
+	require.Equal(t, uint64(2), signers.Threshold)
+	require.Equal(t, multi.Signers, signers.Signers)
+
+	unknown, _ := keypair.Random()
+	_, err = c0.GetAccountSigners(unknown.Address())
+	require.Equal(t, errors.ErrorBlockAccountDoesNotExists, err)
+}
+
 // TestGetNodeInfoHandler checks `NodeInfoHandler`
 func TestGetNodeInfoHandler(t *testing.T) {
 	st := storage.NewTestStorage()
@@ -289,3 +338,290 @@ func TestGetNodeInfoHandler(t *testing.T) {
 		require.Equal(t, publishEndpoint.String(), received["endpoint"])
 	}
 }
+
+// TestGetNodeInfoDetailHandler checks `NodeInfoDetailHandler` returns the
+// public information and never exposes the secret seed of node.
+func TestGetNodeInfoDetailHandler(t *testing.T) {
+	kp, _ := keypair.Random()
+	endpoint, _ := common.NewEndpointFromString("http://localhost:12345")
+	localNode, _ := node.NewLocalNode(kp, endpoint, "node-info")
+
+	kpValidator, _ := keypair.Random()
+	validator, _ := node.NewValidator(kpValidator.Address(), endpoint, "")
+	localNode.AddValidators(validator)
+
+	publishEndpoint, _ := common.NewEndpointFromString("https://9.9.9.9:54321")
+	localNode.SetPublishEndpoint(publishEndpoint)
+
+	apiHandler := NetworkHandlerNode{localNode: localNode}
+
+	router := mux.NewRouter()
+	router.HandleFunc(NodeInfoDetailHandlerPattern, apiHandler.NodeInfoDetailHandler).Methods("GET")
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + NodeInfoDetailHandlerPattern)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	require.NotContains(t, string(body), kp.Seed())
+
+	var received map[string]interface{}
+	err = json.Unmarshal(body, &received)
+	require.Nil(t, err)
+
+	require.Equal(t, kp.Address(), received["address"])
+	require.Equal(t, "node-info", received["alias"])
+	require.Equal(t, endpoint.String(), received["endpoint"])
+	require.Equal(t, publishEndpoint.String(), received["publish-endpoint"])
+	require.Equal(t, localNode.State().String(), received["state"])
+	require.Equal(t, float64(1), received["validator-count"])
+}
+
+func TestNodeReadyzHandler(t *testing.T) {
+	defer func(lag time.Duration) { MaxConsensusLag = lag }(MaxConsensusLag)
+	MaxConsensusLag = 10 * time.Second
+
+	kp, _ := keypair.Random()
+	endpoint, _ := common.NewEndpointFromString("http://localhost:12345")
+	localNode, _ := node.NewLocalNode(kp, endpoint, "")
+
+	p, _ := consensus.NewDefaultVotingThresholdPolicy(30, 30)
+	connectionManager := network.NewValidatorConnectionManager(localNode, nil, p, localNode.GetValidators())
+	is, _ := consensus.NewISAAC(networkID, localNode, p, connectionManager)
+
+	apiHandler := NetworkHandlerNode{localNode: localNode, consensus: is}
+
+	router := mux.NewRouter()
+	router.HandleFunc(NodeReadyzHandlerPattern, apiHandler.NodeReadyzHandler).Methods("GET")
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	getReadyz := func() (int, map[string]interface{}) {
+		resp, err := http.Get(server.URL + NodeReadyzHandlerPattern)
+		require.Nil(t, err)
+		defer resp.Body.Close()
+
+		var received map[string]interface{}
+		body, _ := ioutil.ReadAll(resp.Body)
+		require.Nil(t, json.Unmarshal(body, &received))
+
+		return resp.StatusCode, received
+	}
+
+	// the latest block is just confirmed
+	latest := block.TestMakeNewBlock([]string{})
+	latest.Height = 10
+	is.SetLatestConsensusedBlock(latest)
+
+	status, received := getReadyz()
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, true, received["ready"])
+	require.Equal(t, float64(10), received["latest-height"])
+
+	// the latest block was confirmed 1 minute ago
+	latest.Confirmed = common.FormatISO8601(time.Now().Add(-1 * time.Minute))
+	is.SetLatestConsensusedBlock(latest)
+
+	status, received = getReadyz()
+	require.Equal(t, http.StatusServiceUnavailable, status)
+	require.Equal(t, false, received["ready"])
+
+	// disabled
+	MaxConsensusLag = 0
+	status, _ = getReadyz()
+	require.Equal(t, http.StatusOK, status)
+}
+
+func TestConsensusLag(t *testing.T) {
+	b := block.TestMakeNewBlock([]string{})
+	b.Confirmed = common.FormatISO8601(time.Now().Add(-1 * time.Minute))
+
+	lag := consensusLag(b)
+	require.True(t, lag >= time.Minute)
+	require.True(t, lag < 2*time.Minute)
+
+	// invalid confirmed time
+	b.Confirmed = "showme"
+	require.True(t, consensusLag(b) > 24*time.Hour)
+}
+
+// TestMessageHandlerReceiveChannelFull checks the handler returns 503 instead
+// of hanging, when the received messages are not consumed.
+func TestMessageHandlerReceiveChannelFull(t *testing.T) {
+	endpoint, err := common.NewEndpointFromString(
+		fmt.Sprintf("http://localhost:%s?ReceiveChannelSize=1&ReceiveTimeout=100ms", getPort()),
+	)
+	require.Nil(t, err)
+
+	config, err := network.NewHTTP2NetworkConfigFromEndpoint("showme", endpoint)
+	require.Nil(t, err)
+	n := network.NewHTTP2Network(config)
+	defer n.Stop()
+
+	kp, _ := keypair.Random()
+	localNode, _ := node.NewLocalNode(kp, endpoint, "")
+	apiHandler := NewNetworkHandlerNode(localNode, n, nil, nil, "")
+
+	post := func() *httptest.ResponseRecorder {
+		r := httptest.NewRequest("POST", "/message", strings.NewReader(`{}`))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		done := make(chan bool)
+		go func() {
+			apiHandler.MessageHandler(w, r)
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "handler hangs")
+		}
+		return w
+	}
+
+	// nobody consumes the receive channel; the first one fills the buffer
+	require.Equal(t, http.StatusOK, post().Code)
+
+	w := post()
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+	require.Contains(t, w.Body.String(), "too many messages")
+	require.Equal(t, 1, len(n.ReceiveChannel()))
+}
+
+type nodeRoundResponse struct {
+	Round       round.Round `json:"round"`
+	Proposer    string      `json:"proposer"`
+	BallotState string      `json:"ballot-state"`
+	Opened      string      `json:"opened"`
+}
+
+// TestPauseAcceptance checks the transactions from the clients are rejected
+// while the acceptance is paused, but the transactions from the other nodes
+// and the ballots are not.
+func TestPauseAcceptance(t *testing.T) {
+	nr := createTestNodeRunner(1, consensus.NewISAACConfiguration())[0]
+
+	endpoint, err := common.NewEndpointFromString(
+		fmt.Sprintf("http://localhost:%s?ReceiveChannelSize=10", getPort()),
+	)
+	require.Nil(t, err)
+
+	config, err := network.NewHTTP2NetworkConfigFromEndpoint("showme", endpoint)
+	require.Nil(t, err)
+	n := network.NewHTTP2Network(config)
+	defer n.Stop()
+
+	apiHandler := NewNetworkHandlerNode(nr.Node(), n, nr.Storage(), nr.Consensus(), "")
+	apiHandler.acceptance = nr.acceptance
+
+	post := func(handler http.HandlerFunc, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		handler(w, r)
+		return w
+	}
+
+	require.False(t, nr.IsAcceptancePaused())
+	require.Equal(t, http.StatusOK, post(apiHandler.PostTransactionHandler, `{}`).Code)
+
+	nr.PauseAcceptance()
+	require.True(t, nr.IsAcceptancePaused())
+	{
+		w := post(apiHandler.PostTransactionHandler, `{}`)
+		require.Equal(t, http.StatusServiceUnavailable, w.Code)
+		require.Contains(t, w.Body.String(), errors.ErrorTransactionAcceptancePaused.Message)
+
+		w = post(apiHandler.BatchTransactionsHandler, `[]`)
+		require.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+		// transactions from the other nodes and ballots are not affected
+		require.Equal(t, http.StatusOK, post(apiHandler.MessageHandler, `{}`).Code)
+		require.Equal(t, http.StatusOK, post(apiHandler.BallotHandler, `{}`).Code)
+	}
+
+	nr.ResumeAcceptance()
+	require.False(t, nr.IsAcceptancePaused())
+	require.Equal(t, http.StatusOK, post(apiHandler.PostTransactionHandler, `{}`).Code)
+	require.Equal(t, http.StatusOK, post(apiHandler.BatchTransactionsHandler, `[]`).Code)
+}
+
+func TestNodeRoundHandler(t *testing.T) {
+	conf := consensus.NewISAACConfiguration()
+	conf.TimeoutINIT = time.Hour
+	conf.TimeoutSIGN = time.Hour
+	conf.TimeoutACCEPT = time.Hour
+
+	// with 2 nodes, `OtherSelector` always selects the same proposer
+	recv := make(chan struct{})
+	nr, _, _ := createNodeRunnerForTesting(2, conf, recv)
+	nr.Consensus().SetProposerSelector(OtherSelector{nr.ConnectionManager()})
+	nr.Consensus().SetLatestConsensusedBlock(genesisBlock)
+
+	transited := make(chan struct{}, 10)
+	nr.isaacStateManager.SetTransitSignal(func() {
+		transited <- struct{}{}
+	})
+
+	nh := NetworkHandlerNode{consensus: nr.Consensus(), isaacStateManager: nr.isaacStateManager}
+	router := mux.NewRouter()
+	router.HandleFunc(NodeRoundHandlerPattern, nh.NodeRoundHandler).Methods("GET")
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	get := func() nodeRoundResponse {
+		resp, err := http.Get(server.URL + NodeRoundHandlerPattern)
+		require.Nil(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		body, err := ioutil.ReadAll(resp.Body)
+		require.Nil(t, err)
+
+		var r nodeRoundResponse
+		require.Nil(t, json.Unmarshal(body, &r))
+		return r
+	}
+
+	waitTransit := func() {
+		select {
+		case <-transited:
+		case <-time.After(5 * time.Second):
+			t.Fatal("ISAACState is not changed")
+		}
+	}
+
+	nr.StartStateManager()
+	defer nr.StopStateManager()
+	waitTransit()
+
+	first := get()
+	require.Equal(t, genesisBlock.Height, first.Round.BlockHeight)
+	require.Equal(t, uint64(0), first.Round.Number)
+	require.Equal(t, ballot.StateINIT.String(), first.BallotState)
+	require.Equal(t, nr.Consensus().SelectProposer(genesisBlock.Height, 0), first.Proposer)
+	firstOpened, err := common.ParseISO8601(first.Opened)
+	require.Nil(t, err)
+
+	time.Sleep(10 * time.Millisecond)
+	nr.isaacStateManager.IncreaseRound()
+	waitTransit()
+
+	second := get()
+	require.Equal(t, genesisBlock.Height, second.Round.BlockHeight)
+	require.Equal(t, uint64(1), second.Round.Number)
+	require.Equal(t, ballot.StateINIT.String(), second.BallotState)
+	require.Equal(t, nr.Consensus().SelectProposer(genesisBlock.Height, 1), second.Proposer)
+	secondOpened, err := common.ParseISO8601(second.Opened)
+	require.Nil(t, err)
+	require.True(t, secondOpened.After(firstOpened))
+}
@@ -0,0 +1,83 @@
+package runner
+
+import (
+	"net/http"
+	"strconv"
+
+	"boscoin.io/sebak/lib/block"
+	"boscoin.io/sebak/lib/consensus"
+	"boscoin.io/sebak/lib/error"
+	"boscoin.io/sebak/lib/network/httputils"
+)
+
+const (
+	DefaultProposerScheduleCount uint64 = 10
+	MaxProposerScheduleCount     uint64 = 100
+)
+
+type ProposerScheduleItem struct {
+	Height   uint64 `json:"height"`
+	Round    uint64 `json:"round"`
+	Proposer string `json:"proposer"`
+}
+
+// GetProposerScheduleHandler returns the proposers of the rounds from 0 to
+// `count`-1 at `height`. Like `round.Round.BlockHeight`, `height` is the
+// height of the latest block when the round starts; by default, it is the
+// height of the current latest block.
+//
+// The proposers are selected from the validator set recorded at `height`; see
+// `block.GetValidatorSetAtHeight()`. If the validator set is not recorded
+// until `height`, the proposers are selected by the current validators.
+func (nh NetworkHandlerNode) GetProposerScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var height uint64
+	if s := query.Get("height"); len(s) > 0 {
+		var err error
+		if height, err = strconv.ParseUint(s, 10, 64); err != nil {
+			http.Error(w, errors.ErrorInvalidQueryString.Error(), http.StatusBadRequest)
+			return
+		}
+	} else {
+		latest, err := block.GetLatestBlock(nh.storage)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		height = latest.Height
+	}
+
+	count := DefaultProposerScheduleCount
+	if s := query.Get("count"); len(s) > 0 {
+		var err error
+		if count, err = strconv.ParseUint(s, 10, 64); err != nil || count < 1 || count > MaxProposerScheduleCount {
+			http.Error(w, errors.ErrorInvalidQueryString.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	validators, err := block.GetValidatorSetAtHeight(nh.storage, height)
+	if err != nil && err != errors.ErrorValidatorSetNotFound {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	schedule := make([]ProposerScheduleItem, count)
+	for i := uint64(0); i < count; i++ {
+		var proposer string
+		if len(validators) > 0 {
+			proposer = consensus.SelectSequential(validators, height, i)
+		} else {
+			proposer = nh.consensus.SelectProposer(height, i)
+		}
+
+		schedule[i] = ProposerScheduleItem{
+			Height:   height,
+			Round:    i,
+			Proposer: proposer,
+		}
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, schedule)
+}
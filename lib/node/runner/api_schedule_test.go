@@ -0,0 +1,103 @@
+package runner
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"boscoin.io/sebak/lib/block"
+	"boscoin.io/sebak/lib/consensus"
+	"boscoin.io/sebak/lib/network/api"
+)
+
+func TestGetProposerScheduleHandler(t *testing.T) {
+	nodeRunners := createTestNodeRunner(3, consensus.NewISAACConfiguration())
+	nr := nodeRunners[0]
+
+	nh := NetworkHandlerNode{storage: nr.Storage(), consensus: nr.Consensus()}
+	router := mux.NewRouter()
+	router.HandleFunc(api.GetProposerScheduleHandlerPattern, nh.GetProposerScheduleHandler).Methods("GET")
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	get := func(query string) (int, []ProposerScheduleItem) {
+		resp, err := http.Get(server.URL + api.GetProposerScheduleHandlerPattern + query)
+		require.Nil(t, err)
+		defer resp.Body.Close()
+
+		var schedule []ProposerScheduleItem
+		if resp.StatusCode == http.StatusOK {
+			body, err := ioutil.ReadAll(resp.Body)
+			require.Nil(t, err)
+			require.Nil(t, json.Unmarshal(body, &schedule))
+		}
+
+		return resp.StatusCode, schedule
+	}
+
+	{
+		status, schedule := get("?height=5&count=4")
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, 4, len(schedule))
+
+		proposers := map[string]bool{}
+		for i, item := range schedule {
+			require.Equal(t, uint64(5), item.Height)
+			require.Equal(t, uint64(i), item.Round)
+			require.Equal(t, nr.Consensus().SelectProposer(5, uint64(i)), item.Proposer)
+			proposers[item.Proposer] = true
+		}
+		require.Equal(t, 3, len(proposers))
+	}
+
+	{ // by default, the latest block height and `DefaultProposerScheduleCount`
+		status, schedule := get("")
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, int(DefaultProposerScheduleCount), len(schedule))
+		require.Equal(t, genesisBlock.Height, schedule[0].Height)
+		require.Equal(t, nr.Consensus().SelectProposer(genesisBlock.Height, 0), schedule[0].Proposer)
+	}
+
+	{ // invalid query
+		status, _ := get("?height=findme")
+		require.Equal(t, http.StatusBadRequest, status)
+
+		status, _ = get("?count=0")
+		require.Equal(t, http.StatusBadRequest, status)
+
+		status, _ = get("?count=101")
+		require.Equal(t, http.StatusBadRequest, status)
+	}
+
+	{ // by the validator set at the height
+		validators := nr.ConnectionManager().AllValidators()
+		require.Equal(t, 3, len(validators))
+		require.Nil(t, block.SaveValidatorSet(nr.Storage(), 1, validators))
+		require.Nil(t, block.SaveValidatorSet(nr.Storage(), 4, validators[:2]))
+
+		status, schedule := get("?height=3&count=3")
+		require.Equal(t, http.StatusOK, status)
+		proposers := map[string]bool{}
+		for i, item := range schedule {
+			require.Equal(t, consensus.SelectSequential(validators, 3, uint64(i)), item.Proposer)
+			proposers[item.Proposer] = true
+		}
+		require.Equal(t, 3, len(proposers))
+
+		status, schedule = get("?height=5&count=3")
+		require.Equal(t, http.StatusOK, status)
+		proposers = map[string]bool{}
+		for i, item := range schedule {
+			require.Equal(t, consensus.SelectSequential(validators[:2], 5, uint64(i)), item.Proposer)
+			proposers[item.Proposer] = true
+		}
+		require.Equal(t, 2, len(proposers))
+		require.False(t, proposers[validators[2]])
+	}
+}
@@ -4,10 +4,13 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
+	"strconv"
 
 	"boscoin.io/sebak/lib/block"
 	"boscoin.io/sebak/lib/common"
 	"boscoin.io/sebak/lib/error"
+	"boscoin.io/sebak/lib/network/httputils"
+	"boscoin.io/sebak/lib/transaction"
 )
 
 const GetTransactionPattern string = "/transactions"
@@ -20,8 +23,12 @@ func (nh NetworkHandlerNode) GetNodeTransactionsHandler(w http.ResponseWriter, r
 			return
 		}
 
-		body, _ := ioutil.ReadAll(r.Body)
+		body, err := ioutil.ReadAll(r.Body)
 		defer r.Body.Close()
+		if err != nil {
+			writeReadBodyError(w, err)
+			return
+		}
 
 		if len(body) > 0 {
 			var postHashes []string
@@ -47,7 +54,7 @@ func (nh NetworkHandlerNode) GetNodeTransactionsHandler(w http.ResponseWriter, r
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("X-SEBAK-RESULT-COUNT", string(len(hashes)))
+	w.Header().Set("X-SEBAK-RESULT-COUNT", strconv.Itoa(len(hashes)))
 
 	unknown := map[string]struct{}{}
 
@@ -85,3 +92,137 @@ func (nh NetworkHandlerNode) GetNodeTransactionsHandler(w http.ResponseWriter, r
 
 	return
 }
+
+// SimulateTransactionHandler returns the changes of the accounts, which the
+// posted transaction will cause; see `SimulateTx`. The transaction is not
+// broadcasted.
+func (nh NetworkHandlerNode) SimulateTransactionHandler(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	defer r.Body.Close()
+	if err != nil {
+		writeReadBodyError(w, err)
+		return
+	}
+
+	tx, err := decodeTransaction(body)
+	if err != nil {
+		httputils.WriteJSONError(w, err)
+		return
+	}
+
+	if err = tx.IsWellFormed(nh.consensus.NetworkID); err != nil {
+		httputils.WriteJSONError(w, err)
+		return
+	}
+
+	diff, err := SimulateTx(nh.storage, tx, validatorSet(nh.localNode))
+	if err != nil {
+		httputils.WriteJSONError(w, err)
+		return
+	}
+
+	if err = httputils.WriteJSON(w, 200, diff); err != nil {
+		httputils.WriteJSONError(w, err)
+		return
+	}
+}
+
+// PostTransactionHandler receives the transaction from the clients. Unlike
+// `MessageHandler`, which receives the transactions forwarded by the other
+// nodes, the transaction is rejected while the acceptance is paused and the
+// malformed json is rejected with the field-level error.
+func (nh NetworkHandlerNode) PostTransactionHandler(w http.ResponseWriter, r *http.Request) {
+	if nh.acceptance.isPaused() {
+		r.Body.Close()
+		httputils.WriteJSONError(w, errors.ErrorTransactionAcceptancePaused)
+		return
+	}
+
+	nh.receiveTransaction(w, r, true)
+}
+
+// BatchTransactionResult is the result of one transaction in the batch.
+type BatchTransactionResult struct {
+	Hash   string `json:"hash,omitempty"`
+	Status string `json:"status"` // "accepted" or "rejected"
+	Reason string `json:"reason,omitempty"`
+}
+
+// BatchTransactionsHandler receives the json array of transactions. Each
+// transaction is validated independently; the accepted ones are pushed into
+// the transaction pool and broadcasted, and the rejected ones are not. The
+// result of each transaction is returned by the same order. The number of
+// transactions is limited by `common.MaxTransactionsInBatch`.
+func (nh NetworkHandlerNode) BatchTransactionsHandler(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	defer r.Body.Close()
+	if nh.acceptance.isPaused() {
+		httputils.WriteJSONError(w, errors.ErrorTransactionAcceptancePaused)
+		return
+	}
+	if err != nil {
+		writeReadBodyError(w, err)
+		return
+	}
+
+	var exceeded bool
+	if exceeded, err = common.JSONArrayExceedsLimit(body, common.MaxTransactionsInBatch); err != nil {
+		httputils.WriteJSONError(w, errors.ErrorInvalidMessage)
+		return
+	} else if exceeded {
+		httputils.WriteJSONError(w, errors.ErrorTooManyTransactions)
+		return
+	}
+
+	var items []json.RawMessage
+	if err = json.Unmarshal(body, &items); err != nil {
+		httputils.WriteJSONError(w, errors.ErrorInvalidMessage)
+		return
+	}
+
+	results := []BatchTransactionResult{}
+	for _, item := range items {
+		tx, err := nh.submitTransaction(item)
+
+		result := BatchTransactionResult{Hash: tx.GetHash(), Status: "accepted"}
+		if err != nil {
+			result.Status = "rejected"
+			result.Reason = err.Error()
+		}
+		results = append(results, result)
+	}
+
+	if err = httputils.WriteJSON(w, 200, results); err != nil {
+		httputils.WriteJSONError(w, err)
+		return
+	}
+}
+
+// decodeTransaction decodes the posted transaction. The syntax and type
+// errors of json are converted by `httputils.JSONDecodeError`, so the client
+// gets the field and the expected type instead of the raw error of
+// `encoding/json`; the other unknown errors become
+// `errors.ErrorInvalidMessage`.
+func decodeTransaction(b []byte) (tx transaction.Transaction, err error) {
+	if tx, err = transaction.NewTransactionFromJSON(b); err != nil {
+		err = httputils.JSONDecodeError(err)
+		if _, ok := err.(*errors.Error); !ok {
+			err = errors.ErrorInvalidMessage
+		}
+	}
+	return
+}
+
+// submitTransaction runs the transaction through the checkers of
+// `NodeRunner.handleTransaction`, `DefaultHandleTransactionCheckerFuncs`, which
+// push it into the transaction pool. Unlike the `MessageHandler`, the result is
+// returned synchronously.
+func (nh NetworkHandlerNode) submitTransaction(raw []byte) (tx transaction.Transaction, err error) {
+	if tx, err = decodeTransaction(raw); err != nil {
+		return
+	}
+
+	err = nh.handleTransaction(common.NetworkMessage{Type: common.TransactionMessage, Data: raw})
+
+	return
+}
@@ -373,3 +373,160 @@ func TestGetNodeTransactionsHandlerTooManyHashes(t *testing.T) {
 		require.Equal(t, errors.ErrorInvalidQueryString.Code, responseError.Code)
 	}
 }
+
+func TestBatchTransactionsHandler(t *testing.T) {
+	nr, localNode := MakeNodeRunner()
+	st := nr.Storage()
+	defer st.Close()
+
+	isaac := nr.Consensus()
+	apiHandler := NewNetworkHandlerNode(localNode, nr.Network(), st, isaac, "")
+	apiHandler.handleTransaction = nr.handleTransaction
+
+	router := mux.NewRouter()
+	router.HandleFunc("/batch", apiHandler.BatchTransactionsHandler).Methods("POST")
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	post := func(txs ...interface{}) *http.Response {
+		body, _ := json.Marshal(txs)
+		resp, err := http.Post(server.URL+"/batch", "application/json", bytes.NewBuffer(body))
+		require.Nil(t, err)
+		return resp
+	}
+
+	kpValid, kpTarget := makeSimulateTestAccounts(st)
+	kpPoor, _ := makeSimulateTestAccounts(st)
+
+	makePayment := func(kpSource *keypair.Full, amount common.Amount) transaction.Transaction {
+		op := transaction.Operation{
+			H: transaction.OperationHeader{Type: transaction.OperationPayment},
+			B: transaction.NewOperationBodyPayment(kpTarget.Address(), amount),
+		}
+		tx, _ := transaction.NewTransaction(kpSource.Address(), 0, op)
+		tx.Sign(kpSource, networkID)
+		return tx
+	}
+	valid := makePayment(kpValid, common.Amount(10000))
+	overBalance := makePayment(kpPoor, common.Amount(2*common.AmountPerCoin))
+
+	{ // mixed batch
+		resp := post(valid, overBalance, "showme")
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var results []BatchTransactionResult
+		b, _ := ioutil.ReadAll(resp.Body)
+		require.Nil(t, json.Unmarshal(b, &results))
+		require.Equal(t, 3, len(results))
+
+		require.Equal(t, valid.GetHash(), results[0].Hash)
+		require.Equal(t, "accepted", results[0].Status)
+		require.Equal(t, overBalance.GetHash(), results[1].Hash)
+		require.Equal(t, "rejected", results[1].Status)
+		require.Equal(t, errors.ErrorTransactionExcessAbilityToPay.Error(), results[1].Reason)
+		require.Equal(t, "rejected", results[2].Status)
+
+		require.True(t, isaac.TransactionPool.Has(valid.GetHash()))
+		require.False(t, isaac.TransactionPool.Has(overBalance.GetHash()))
+	}
+
+	{ // already in pool
+		resp := post(valid)
+		defer resp.Body.Close()
+
+		var results []BatchTransactionResult
+		b, _ := ioutil.ReadAll(resp.Body)
+		require.Nil(t, json.Unmarshal(b, &results))
+		require.Equal(t, "rejected", results[0].Status)
+	}
+
+	{ // over the limit
+		defer func(n int) { common.MaxTransactionsInBatch = n }(common.MaxTransactionsInBatch)
+		common.MaxTransactionsInBatch = 1
+
+		resp := post(valid, overBalance)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	}
+}
+
+// TestPostTransactionHandlerMalformedTransaction checks the malformed
+// transaction json is rejected with the field and the expected type, not with
+// the raw error of `encoding/json`.
+func TestPostTransactionHandlerMalformedTransaction(t *testing.T) {
+	apiHandler := NetworkHandlerNode{}
+
+	post := func(body []byte) (int, map[string]interface{}) {
+		r := httptest.NewRequest("POST", "/", bytes.NewBuffer(body))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		apiHandler.PostTransactionHandler(w, r)
+
+		var problem map[string]interface{}
+		require.Nil(t, json.Unmarshal(w.Body.Bytes(), &problem))
+		return w.Code, problem
+	}
+
+	_, tx := transaction.TestMakeTransaction(networkID, 1)
+	b, err := tx.Serialize()
+	require.Nil(t, err)
+
+	{ // string instead of integer
+		var raw map[string]interface{}
+		require.Nil(t, json.Unmarshal(b, &raw))
+		raw["B"].(map[string]interface{})["sequenceid"] = "showme"
+		body, _ := json.Marshal(raw)
+
+		status, problem := post(body)
+		require.Equal(t, http.StatusBadRequest, status)
+		require.Equal(t, errors.ErrorMalformedJSON.Message, problem["title"])
+
+		data := problem["data"].(map[string]interface{})
+		require.Equal(t, "B.sequenceid", data["field"])
+		require.Equal(t, "integer", data["expected"])
+		require.Equal(t, "string", data["actual"])
+	}
+
+	{ // string instead of amount
+		var raw map[string]interface{}
+		require.Nil(t, json.Unmarshal(b, &raw))
+		raw["B"].(map[string]interface{})["fee"] = "showme"
+		body, _ := json.Marshal(raw)
+
+		status, problem := post(body)
+		require.Equal(t, http.StatusBadRequest, status)
+		require.Equal(t, errors.ErrorMalformedJSON.Message, problem["title"])
+
+		data := problem["data"].(map[string]interface{})
+		require.Equal(t, "amount", data["expected"])
+		require.Equal(t, "string", data["actual"])
+
+		// amount of operation
+		raw["B"].(map[string]interface{})["fee"] = common.BaseFee.String()
+		op := raw["B"].(map[string]interface{})["operations"].([]interface{})[0]
+		op.(map[string]interface{})["B"].(map[string]interface{})["amount"] = 100
+		body, _ = json.Marshal(raw)
+
+		status, problem = post(body)
+		require.Equal(t, http.StatusBadRequest, status)
+
+		data = problem["data"].(map[string]interface{})
+		require.Equal(t, "amount", data["expected"])
+		require.Equal(t, "number", data["actual"])
+	}
+
+	{ // broken json
+		status, problem := post(bytes.Replace(b, []byte(":"), []byte("="), 1))
+		require.Equal(t, http.StatusBadRequest, status)
+		require.Equal(t, errors.ErrorMalformedJSON.Message, problem["title"])
+
+		data := problem["data"].(map[string]interface{})
+		require.NotNil(t, data["offset"])
+		require.Nil(t, data["field"])
+
+		status, problem = post(b[:len(b)-10])
+		require.Equal(t, http.StatusBadRequest, status)
+		require.Equal(t, errors.ErrorMalformedJSON.Message, problem["title"])
+	}
+}
@@ -0,0 +1,25 @@
+package runner
+
+import (
+	"net/http"
+
+	"boscoin.io/sebak/lib/network/httputils"
+)
+
+// GetValidatorsHandler returns the configured validators with their live
+// connection state; see `network.ValidatorStatus`.
+func (nh NetworkHandlerNode) GetValidatorsHandler(w http.ResponseWriter, r *http.Request) {
+	statuses := nh.consensus.ConnectionManager().ValidatorStatusList()
+	if err := httputils.WriteJSON(w, http.StatusOK, statuses); err != nil {
+		httputils.WriteJSONError(w, err)
+	}
+}
+
+// GetValidatorsStatsHandler returns the connection statistics of validators;
+// see `network.ConnStats`.
+func (nh NetworkHandlerNode) GetValidatorsStatsHandler(w http.ResponseWriter, r *http.Request) {
+	stats := nh.consensus.ConnectionManager().ConnectionStats()
+	if err := httputils.WriteJSON(w, http.StatusOK, stats); err != nil {
+		httputils.WriteJSONError(w, err)
+	}
+}
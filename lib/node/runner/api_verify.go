@@ -0,0 +1,70 @@
+package runner
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/stellar/go/keypair"
+	"golang.org/x/crypto/ed25519"
+
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/error"
+	"boscoin.io/sebak/lib/network/httputils"
+)
+
+// VerifySignatureRequest is the message and it's signature to be verified.
+// `Signature` is base58 encoded like the signature of transaction.
+type VerifySignatureRequest struct {
+	Address   string `json:"address"`
+	Message   string `json:"message"`
+	Signature string `json:"signature"`
+}
+
+type VerifySignatureResult struct {
+	Valid bool `json:"valid"`
+}
+
+// VerifySignatureHandler verifies the signature of the arbitrary message with
+// the network ID of node, like the signature of transaction; see
+// `common.MakeSignature`. The malformed address or signature is rejected, but
+// the signature which does not match is not an error, `valid` is just false.
+func (nh NetworkHandlerNode) VerifySignatureHandler(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	defer r.Body.Close()
+	if err != nil {
+		writeReadBodyError(w, err)
+		return
+	}
+
+	var request VerifySignatureRequest
+	if err = json.Unmarshal(body, &request); err != nil {
+		err = httputils.JSONDecodeError(err)
+		if _, ok := err.(*errors.Error); !ok {
+			err = errors.ErrorInvalidMessage
+		}
+		httputils.WriteJSONError(w, err)
+		return
+	}
+
+	var kp keypair.KP
+	if kp, err = keypair.Parse(request.Address); err != nil {
+		httputils.WriteJSONError(w, errors.ErrorBadPublicAddress)
+		return
+	}
+
+	signature := base58.Decode(request.Signature)
+	if len(signature) != ed25519.SignatureSize {
+		httputils.WriteJSONError(w, errors.ErrorMalformedSignature)
+		return
+	}
+
+	result := VerifySignatureResult{
+		Valid: common.VerifySignature(kp, nh.consensus.NetworkID, request.Message, signature) == nil,
+	}
+	if err = httputils.WriteJSON(w, 200, result); err != nil {
+		httputils.WriteJSONError(w, err)
+		return
+	}
+}
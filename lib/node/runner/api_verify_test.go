@@ -0,0 +1,108 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/gorilla/mux"
+	"github.com/stellar/go/keypair"
+	"github.com/stretchr/testify/require"
+
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/consensus"
+	"boscoin.io/sebak/lib/error"
+	"boscoin.io/sebak/lib/network"
+	"boscoin.io/sebak/lib/network/httputils"
+	"boscoin.io/sebak/lib/node"
+)
+
+func TestVerifySignatureHandler(t *testing.T) {
+	kp, _ := keypair.Random()
+	endpoint, _ := common.NewEndpointFromString("http://localhost:12345")
+	localNode, _ := node.NewLocalNode(kp, endpoint, "")
+	isaac, _ := consensus.NewISAAC(
+		networkID,
+		localNode,
+		nil,
+		network.NewValidatorConnectionManager(localNode, nil, nil, nil),
+	)
+	apiHandler := NetworkHandlerNode{consensus: isaac}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/verify", apiHandler.VerifySignatureHandler).Methods("POST")
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	signer, _ := keypair.Random()
+	message := "showme"
+	signature, _ := common.MakeSignature(signer, networkID, message)
+
+	post := func(request VerifySignatureRequest) *http.Response {
+		body, _ := json.Marshal(request)
+		resp, err := http.Post(server.URL+"/verify", "application/json", bytes.NewBuffer(body))
+		require.Nil(t, err)
+		return resp
+	}
+
+	checkValid := func(request VerifySignatureRequest, expected bool) {
+		resp := post(request)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var result VerifySignatureResult
+		b, _ := ioutil.ReadAll(resp.Body)
+		require.Nil(t, json.Unmarshal(b, &result))
+		require.Equal(t, expected, result.Valid)
+	}
+
+	request := VerifySignatureRequest{
+		Address:   signer.Address(),
+		Message:   message,
+		Signature: base58.Encode(signature),
+	}
+
+	{ // valid
+		checkValid(request, true)
+	}
+
+	{ // tampered message
+		tampered := request
+		tampered.Message = "findme"
+		checkValid(tampered, false)
+	}
+
+	{ // signed by the other keypair
+		other, _ := keypair.Random()
+		tampered := request
+		tampered.Address = other.Address()
+		checkValid(tampered, false)
+	}
+
+	{ // signed with the other network ID
+		s, _ := common.MakeSignature(signer, []byte("other-network"), message)
+		tampered := request
+		tampered.Signature = base58.Encode(s)
+		checkValid(tampered, false)
+	}
+
+	{ // malformed address
+		malformed := request
+		malformed.Address = "GABCD"
+		resp := post(malformed)
+		defer resp.Body.Close()
+		require.Equal(t, httputils.StatusCode(errors.ErrorBadPublicAddress), resp.StatusCode)
+	}
+
+	{ // malformed signature
+		malformed := request
+		malformed.Signature = base58.Encode(signature[:10])
+		resp := post(malformed)
+		defer resp.Body.Close()
+		require.Equal(t, httputils.StatusCode(errors.ErrorMalformedSignature), resp.StatusCode)
+	}
+}
@@ -0,0 +1,240 @@
+package runner
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+
+	logging "github.com/inconshreveable/log15"
+
+	"boscoin.io/sebak/lib/block"
+	"boscoin.io/sebak/lib/error"
+	"boscoin.io/sebak/lib/network"
+	"boscoin.io/sebak/lib/network/httputils"
+	"boscoin.io/sebak/lib/storage"
+	"boscoin.io/sebak/lib/transaction"
+)
+
+const ResyncHandlerPattern string = "/admin/resync"
+
+// ResyncCheckpointHeight is the height of the finalized checkpoint; the
+// blocks at and below it are never resynced. The genesis block is the only
+// checkpoint for now.
+const ResyncCheckpointHeight uint64 = 1
+
+type ResyncResult struct {
+	From      uint64 `json:"from"`
+	To        uint64 `json:"to"`
+	Confirmed bool   `json:"confirmed"`
+}
+
+// ResyncHandler rolls back the local blocks from the height `from` to the
+// latest and fetches them again from the connected validators; see
+// `NodeRunner.startResync`. Without `confirm`, which must be same with
+// `from`, nothing is changed and the range to be resynced is returned. The
+// blocks are resynced in background, so the confirmed request is responded
+// before they are resynced.
+func (nh NetworkHandlerNode) ResyncHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	from, err := strconv.ParseUint(query.Get("from"), 10, 64)
+	if err != nil {
+		httputils.WriteJSONError(w, errors.ErrorInvalidQueryString)
+		return
+	}
+	if from <= ResyncCheckpointHeight {
+		httputils.WriteJSONError(w, errors.ErrorResyncBelowCheckpoint.Clone().
+			SetData("from", from).
+			SetData("checkpoint", ResyncCheckpointHeight),
+		)
+		return
+	}
+
+	latest, err := block.GetLatestBlock(nh.storage)
+	if err != nil {
+		httputils.WriteJSONError(w, err)
+		return
+	}
+	if from > latest.Height {
+		httputils.WriteJSONError(w, errors.ErrorBlockNotFound.Clone().SetData("height", from))
+		return
+	}
+
+	result := ResyncResult{From: from, To: latest.Height}
+	if query.Get("confirm") != strconv.FormatUint(from, 10) {
+		httputils.WriteJSON(w, http.StatusOK, result)
+		return
+	}
+
+	if err = nh.startResync(from); err != nil {
+		httputils.WriteJSONError(w, err)
+		return
+	}
+
+	result.Confirmed = true
+
+	httputils.WriteJSON(w, http.StatusAccepted, result)
+}
+
+// resyncClients returns the clients of the connected validators.
+func (nr *NodeRunner) resyncClients() (clients []network.NetworkClient) {
+	validators := nr.localNode.GetValidators()
+	for _, address := range nr.connectionManager.AllConnected() {
+		v, ok := validators[address]
+		if !ok || v.Endpoint() == nil {
+			continue
+		}
+		clients = append(clients, nr.network.GetClient(v.Endpoint()))
+	}
+
+	return
+}
+
+// startResync resyncs the blocks from the height `from` in background; see
+// `NodeRunner.resyncBlocks`. Only one resync runs at a time.
+func (nr *NodeRunner) startResync(from uint64) error {
+	if !atomic.CompareAndSwapUint32(&nr.resyncing, 0, 1) {
+		return errors.ErrorResyncInProgress
+	}
+
+	go func() {
+		defer atomic.StoreUint32(&nr.resyncing, 0)
+
+		if _, err := nr.resyncBlocks(context.Background(), from); err != nil {
+			nr.log.Error("failed to resync blocks", "from", from, "error", err)
+		}
+	}()
+
+	return nil
+}
+
+// resyncBlocks fetches the blocks from the height `from` to the latest, see
+// `fetchResyncBlocks`, and then pauses the consensus, so no ballot is handled
+// and no block is stored, while the local blocks are replaced with them; see
+// `resyncBlocks`. The blocks stored by the consensus during the fetch are
+// rolled back too. The consensus is resumed from the resynced latest block.
+func (nr *NodeRunner) resyncBlocks(ctx context.Context, from uint64) (blocks []block.Block, err error) {
+	var latest block.Block
+	if latest, err = block.GetLatestBlock(nr.storage); err != nil {
+		return
+	}
+	if from <= ResyncCheckpointHeight || from > latest.Height {
+		err = errors.ErrorBlockNotFound.Clone().SetData("height", from)
+		return
+	}
+
+	var fetched []resyncBlock
+	if fetched, err = fetchResyncBlocks(ctx, nr.resyncClients(), from, latest.Height); err != nil {
+		return
+	}
+
+	nr.consensusLock.Lock()
+	defer nr.consensusLock.Unlock()
+
+	if latest, err = block.GetLatestBlock(nr.storage); err != nil {
+		return
+	}
+
+	if err = resyncBlocks(nr.storage, fetched, latest.Height, nr.validatorSet(), nr.accountFilter, nr.Conf().VerifySupply, nr.log); err != nil {
+		return
+	}
+
+	for _, f := range fetched {
+		blocks = append(blocks, f.Block)
+	}
+
+	nr.consensus.SetLatestConsensusedBlock(blocks[len(blocks)-1])
+	if err := nr.UpdateStakeWeights(); err != nil {
+		nr.log.Error("failed to update stake weights", "error", err)
+	}
+	if err := nr.UpdateValidatorEndpoints(); err != nil {
+		nr.log.Error("failed to update validator endpoints", "error", err)
+	}
+	nr.log.Info("blocks are resynced", "from", from, "to", blocks[len(blocks)-1].Height)
+
+	return
+}
+
+// resyncBlock is the fetched block with its transactions.
+type resyncBlock struct {
+	block.Block
+
+	transactions []transaction.Transaction
+	raws         [][]byte
+}
+
+// fetchResyncBlocks fetches the blocks from the height `from` to `to` with
+// their transactions from the clients. The transactions are checked against
+// the hashes of the blocks, but nothing is stored.
+func fetchResyncBlocks(ctx context.Context, clients []network.NetworkClient, from, to uint64) (fetched []resyncBlock, err error) {
+	var blocks []block.Block
+	var bts []block.BlockTransaction
+	if blocks, bts, err = network.FetchBlocksWithTransactions(ctx, clients, from, to+1, 0); err != nil {
+		return
+	}
+	if uint64(len(blocks)) != to-from+1 {
+		err = errors.ErrorBlockNotFound
+		return
+	}
+
+	for _, blk := range blocks {
+		f := resyncBlock{Block: blk}
+		for range blk.Transactions {
+			if len(bts) < 1 {
+				err = errors.ErrorTransactionNotFound
+				return
+			}
+			bt := bts[0]
+			bts = bts[1:]
+
+			var tx transaction.Transaction
+			if tx, err = transaction.NewTransactionFromJSON(bt.Message); err != nil {
+				return
+			}
+			if tx.GetHash() != bt.Hash {
+				err = errors.ErrorTransactionNotFound.Clone().SetData("hash", bt.Hash)
+				return
+			}
+			f.transactions = append(f.transactions, tx)
+			f.raws = append(f.raws, bt.Message)
+		}
+		fetched = append(fetched, f)
+	}
+
+	return
+}
+
+// resyncBlocks replaces the local blocks with the fetched blocks in one
+// storage transaction; the local blocks are rolled back from `latest` to the
+// first fetched block with the state changed by them, see
+// `block.RollbackBlock`, and the fetched blocks are stored and applied like
+// the consensused blocks. If the fetched blocks are not chained to the local
+// block before them, or one of them can not be applied, nothing is changed.
+func resyncBlocks(st *storage.LevelDBBackend, fetched []resyncBlock, latest uint64, validators []string, filter *block.AccountFilter, verifySupply bool, log logging.Logger) (err error) {
+	var ts *storage.LevelDBBackend
+	if ts, err = st.OpenTransaction(); err != nil {
+		return
+	}
+
+	for height := latest; height >= fetched[0].Height; height-- {
+		if err = block.RollbackBlock(ts, height); err != nil {
+			ts.Discard()
+			return
+		}
+	}
+
+	for _, f := range fetched {
+		if err = finishBlock(ts, f.Block, f.transactions, f.raws, validators, filter, verifySupply, log); err != nil {
+			ts.Discard()
+			return
+		}
+	}
+
+	if err = ts.Commit(); err != nil {
+		ts.Discard()
+		return
+	}
+
+	return
+}
@@ -0,0 +1,361 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	logging "github.com/inconshreveable/log15"
+	"github.com/stellar/go/keypair"
+	"github.com/stretchr/testify/require"
+
+	"boscoin.io/sebak/lib/block"
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/consensus/round"
+	"boscoin.io/sebak/lib/error"
+	"boscoin.io/sebak/lib/network"
+	"boscoin.io/sebak/lib/network/httputils"
+	"boscoin.io/sebak/lib/storage"
+	"boscoin.io/sebak/lib/transaction"
+)
+
+// makeResyncChain makes the `n` blocks chained from `prev`.
+func makeResyncChain(prev block.Block, n int) (blocks []block.Block) {
+	kp, _ := keypair.Random()
+	for i := 0; i < n; i++ {
+		blk := block.NewBlock(
+			kp.Address(),
+			round.Round{BlockHeight: prev.Height, BlockHash: prev.Hash},
+			[]string{},
+			common.NowISO8601(),
+		)
+		blocks = append(blocks, blk)
+		prev = blk
+	}
+
+	return
+}
+
+// saveResyncChain stores the blocks like the consensused blocks, so they can
+// be rolled back; the genesis block is stored without undo record.
+func saveResyncChain(t *testing.T, st *storage.LevelDBBackend, blocks []block.Block, transactions map[string]transaction.Transaction) {
+	for _, blk := range blocks {
+		if blk.Height <= ResyncCheckpointHeight {
+			require.Nil(t, blk.Save(st))
+			continue
+		}
+
+		var txs []transaction.Transaction
+		for _, hash := range blk.Transactions {
+			txs = append(txs, transactions[hash])
+		}
+
+		ts, err := st.OpenTransaction()
+		require.Nil(t, err)
+		require.Nil(t, finishBlock(ts, blk, txs, nil, nil, nil, false, logging.New()))
+		require.Nil(t, ts.Commit())
+	}
+}
+
+func makeResyncPeer(t *testing.T, blocks []block.Block) (network.NetworkClient, *storage.LevelDBBackend) {
+	st := storage.NewTestStorage()
+	for _, blk := range blocks {
+		require.Nil(t, blk.Save(st))
+	}
+
+	_, mn, _ := network.CreateMemoryNetwork(nil)
+	mn.SetStorage(st)
+
+	return mn.GetClient(mn.Endpoint()), st
+}
+
+// fetchAndResyncBlocks fetches the blocks from `from` to `to` from the client
+// and replaces the local blocks with them.
+func fetchAndResyncBlocks(st *storage.LevelDBBackend, client network.NetworkClient, from, to uint64) ([]resyncBlock, error) {
+	fetched, err := fetchResyncBlocks(context.Background(), []network.NetworkClient{client}, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return fetched, resyncBlocks(st, fetched, to, nil, nil, false, logging.New())
+}
+
+func TestResyncBlocks(t *testing.T) {
+	genesis := makeResyncChain(block.Block{}, 1)
+	shared := makeResyncChain(genesis[0], 2)
+	chain := append(append(genesis, shared...), makeResyncChain(shared[1], 3)...)
+
+	client, peerStorage := makeResyncPeer(t, chain)
+	defer peerStorage.Close()
+
+	// local blocks from the height 4 are forked from the peer
+	st := storage.NewTestStorage()
+	defer st.Close()
+	local := append(append(genesis, shared...), makeResyncChain(shared[1], 3)...)
+	saveResyncChain(t, st, local, nil)
+
+	blocks, err := fetchAndResyncBlocks(st, client, 4, 6)
+	require.Nil(t, err)
+	require.Equal(t, 3, len(blocks))
+
+	for _, blk := range chain {
+		saved, err := block.GetBlockByHeight(st, blk.Height)
+		require.Nil(t, err)
+		require.Equal(t, blk.Hash, saved.Hash)
+	}
+
+	// the forked blocks are removed
+	for _, blk := range local[3:] {
+		exists, err := block.ExistsBlock(st, blk.Hash)
+		require.Nil(t, err)
+		require.False(t, exists)
+	}
+
+	latest, err := block.GetLatestBlock(st)
+	require.Nil(t, err)
+	require.Equal(t, chain[5].Hash, latest.Hash)
+
+	// the resynced blocks can be resynced again
+	_, err = fetchAndResyncBlocks(st, client, 5, 6)
+	require.Nil(t, err)
+
+	// the block stored after the fetch is rolled back too
+	fetched, err := fetchResyncBlocks(context.Background(), []network.NetworkClient{client}, 5, 5)
+	require.Nil(t, err)
+	require.Nil(t, resyncBlocks(st, fetched, 6, nil, nil, false, logging.New()))
+
+	latest, err = block.GetLatestBlock(st)
+	require.Nil(t, err)
+	require.Equal(t, chain[4].Hash, latest.Hash)
+}
+
+// TestResyncBlocksState checks the state changed by the local blocks is
+// rolled back and the transactions of the fetched blocks are applied.
+func TestResyncBlocksState(t *testing.T) {
+	networkID := []byte("sebak-test-network")
+
+	kpSource, _ := keypair.Random()
+	kpLocal, _ := keypair.Random()
+	kpPeer, _ := keypair.Random()
+	balance := common.Amount(1000000000)
+	amount := common.Amount(100000000)
+
+	genesis := makeResyncChain(block.Block{}, 1)
+	shared := makeResyncChain(genesis[0], 1)
+
+	localTx := transaction.MakeTransactionCreateAccount(kpSource, kpLocal.Address(), amount)
+	localTx.Sign(kpSource, networkID)
+	peerTx := transaction.MakeTransactionCreateAccount(kpSource, kpPeer.Address(), amount)
+	peerTx.Sign(kpSource, networkID)
+
+	makeBlock := func(tx transaction.Transaction) block.Block {
+		return block.NewBlock(
+			kpSource.Address(),
+			round.Round{BlockHeight: shared[0].Height, BlockHash: shared[0].Hash},
+			[]string{tx.GetHash()},
+			common.NowISO8601(),
+		)
+	}
+
+	// peer
+	chain := append(append(genesis, shared...), makeBlock(peerTx))
+	client, peerStorage := makeResyncPeer(t, chain)
+	defer peerStorage.Close()
+	raw, _ := peerTx.Serialize()
+	bt := block.NewBlockTransactionFromTransaction(chain[2].Hash, chain[2].Height, chain[2].Confirmed, peerTx, raw)
+	require.Nil(t, bt.Save(peerStorage))
+
+	// local has the different transaction at the height 3
+	st := storage.NewTestStorage()
+	defer st.Close()
+	require.Nil(t, block.NewBlockAccount(kpSource.Address(), balance).Save(st, nil))
+
+	local := append(append(genesis, shared...), makeBlock(localTx))
+	saveResyncChain(t, st, local, map[string]transaction.Transaction{localTx.GetHash(): localTx})
+
+	exists, err := block.ExistsBlockAccount(st, kpLocal.Address())
+	require.Nil(t, err)
+	require.True(t, exists)
+
+	_, err = fetchAndResyncBlocks(st, client, 3, 3)
+	require.Nil(t, err)
+
+	// the account of the local transaction is rolled back
+	exists, err = block.ExistsBlockAccount(st, kpLocal.Address())
+	require.Nil(t, err)
+	require.False(t, exists)
+	exists, err = block.ExistsBlockTransaction(st, localTx.GetHash())
+	require.Nil(t, err)
+	require.False(t, exists)
+
+	// the transaction of the peer is applied
+	exists, err = block.ExistsBlockAccount(st, kpPeer.Address())
+	require.Nil(t, err)
+	require.True(t, exists)
+	exists, err = block.ExistsBlockTransaction(st, peerTx.GetHash())
+	require.Nil(t, err)
+	require.True(t, exists)
+
+	source, err := block.GetBlockAccount(st, kpSource.Address())
+	require.Nil(t, err)
+	require.Equal(t, balance-peerTx.TotalAmount(true), source.Balance)
+}
+
+func TestResyncBlocksNotChained(t *testing.T) {
+	genesis := makeResyncChain(block.Block{}, 1)
+
+	// peer has the different chain from the height 2
+	chain := append(append([]block.Block{}, genesis...), makeResyncChain(genesis[0], 3)...)
+	client, peerStorage := makeResyncPeer(t, chain[1:])
+	defer peerStorage.Close()
+
+	st := storage.NewTestStorage()
+	defer st.Close()
+	localGenesis := makeResyncChain(block.Block{}, 1)
+	local := append(append([]block.Block{}, localGenesis...), makeResyncChain(localGenesis[0], 3)...)
+	saveResyncChain(t, st, local, nil)
+
+	_, err := fetchAndResyncBlocks(st, client, 2, 4)
+	require.NotNil(t, err)
+	e, ok := err.(*errors.Error)
+	require.True(t, ok)
+	require.Equal(t, errors.ErrorBlockPrevHashMismatch.Code, e.Code)
+
+	// nothing is changed
+	for _, blk := range local {
+		saved, err := block.GetBlockByHeight(st, blk.Height)
+		require.Nil(t, err)
+		require.Equal(t, blk.Hash, saved.Hash)
+	}
+}
+
+// TestResyncBlocksWithoutUndo checks the blocks, which are stored without
+// undo record, are not resynced.
+func TestResyncBlocksWithoutUndo(t *testing.T) {
+	chain := makeResyncChain(block.Block{}, 3)
+	client, peerStorage := makeResyncPeer(t, chain)
+	defer peerStorage.Close()
+
+	st := storage.NewTestStorage()
+	defer st.Close()
+	for _, blk := range chain {
+		require.Nil(t, blk.Save(st))
+	}
+
+	_, err := fetchAndResyncBlocks(st, client, 2, 3)
+	require.NotNil(t, err)
+	require.Equal(t, errors.ErrorBlockUndoNotFound.Code, err.(*errors.Error).Code)
+}
+
+func TestResyncHandler(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+	chain := makeResyncChain(block.Block{}, 5)
+	for _, blk := range chain {
+		require.Nil(t, blk.Save(st))
+	}
+
+	resyncedFrom := make(chan uint64, 1)
+	apiHandler := NetworkHandlerNode{storage: st, adminToken: "showme"}
+	apiHandler.startResync = func(from uint64) error {
+		select {
+		case resyncedFrom <- from:
+			return nil
+		default:
+			return errors.ErrorResyncInProgress
+		}
+	}
+
+	router := mux.NewRouter()
+	router.Handle(
+		ResyncHandlerPattern,
+		apiHandler.AdminAuthMiddleware().Wrap(http.HandlerFunc(apiHandler.ResyncHandler)),
+	).Methods("POST")
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	post := func(token, query string) (int, []byte) {
+		req, err := http.NewRequest("POST", fmt.Sprintf("%s%s?%s", server.URL, ResyncHandlerPattern, query), nil)
+		require.Nil(t, err)
+		if len(token) > 0 {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.Nil(t, err)
+		defer resp.Body.Close()
+
+		b, _ := ioutil.ReadAll(resp.Body)
+		return resp.StatusCode, b
+	}
+
+	checkError := func(status int, body []byte, expected *errors.Error) {
+		require.Equal(t, httputils.StatusCode(expected), status)
+
+		var problem map[string]interface{}
+		require.Nil(t, json.Unmarshal(body, &problem))
+		require.Equal(t, expected.Message, problem["title"])
+	}
+
+	{ // without token
+		status, body := post("", "from=3")
+		checkError(status, body, errors.ErrorAdminUnauthorized)
+	}
+
+	{ // wrong token
+		status, body := post("findme", "from=3")
+		checkError(status, body, errors.ErrorAdminUnauthorized)
+	}
+
+	{ // below checkpoint
+		status, body := post("showme", "from=1")
+		checkError(status, body, errors.ErrorResyncBelowCheckpoint)
+	}
+
+	{ // over the latest
+		status, body := post("showme", "from=6")
+		checkError(status, body, errors.ErrorBlockNotFound)
+	}
+
+	{ // without confirm, nothing is changed
+		status, body := post("showme", "from=3&confirm=2")
+		require.Equal(t, http.StatusOK, status)
+
+		var result ResyncResult
+		require.Nil(t, json.Unmarshal(body, &result))
+		require.Equal(t, ResyncResult{From: 3, To: 5}, result)
+
+		latest, err := block.GetLatestBlock(st)
+		require.Nil(t, err)
+		require.Equal(t, uint64(5), latest.Height)
+	}
+
+	{ // confirmed; the resync is started in background
+		status, body := post("showme", "from=3&confirm=3")
+		require.Equal(t, http.StatusAccepted, status)
+
+		var result ResyncResult
+		require.Nil(t, json.Unmarshal(body, &result))
+		require.Equal(t, ResyncResult{From: 3, To: 5, Confirmed: true}, result)
+		require.Equal(t, uint64(3), <-resyncedFrom)
+	}
+
+	{ // already resyncing
+		resyncedFrom <- 4
+		status, body := post("showme", "from=3&confirm=3")
+		checkError(status, body, errors.ErrorResyncInProgress)
+	}
+
+	{ // disabled without the admin token of node
+		apiHandler := NetworkHandlerNode{storage: st}
+		req := httptest.NewRequest("POST", ResyncHandlerPattern+"?from=3", nil)
+		req.Header.Set("Authorization", "Bearer ")
+		w := httptest.NewRecorder()
+		apiHandler.AdminAuthMiddleware().Wrap(http.HandlerFunc(apiHandler.ResyncHandler)).ServeHTTP(w, req)
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+	}
+}
@@ -1,9 +1,14 @@
 package runner
 
 import (
+	"errors"
+	"sync"
 	"testing"
 
+	"boscoin.io/sebak/lib/ballot"
+	"boscoin.io/sebak/lib/common"
 	"boscoin.io/sebak/lib/consensus"
+	"boscoin.io/sebak/lib/network"
 	"github.com/stretchr/testify/require"
 )
 
@@ -21,3 +26,85 @@ func TestConnectionManagerBroadcaster(t *testing.T) {
 	<-recv
 	require.Equal(t, 1, len(cm.Messages()))
 }
+
+// resultConnectionManager fails the sends to the `failing` validators and
+// records the addresses of every `BroadcastWithResult`.
+type resultConnectionManager struct {
+	network.ConnectionManager
+
+	sync.Mutex
+	validators []string
+	failing    map[string]bool
+	broadcast  [][]string
+}
+
+func (c *resultConnectionManager) BroadcastWithResult(message common.Message, addresses ...string) (results network.BroadcastResults) {
+	c.Lock()
+	defer c.Unlock()
+
+	if len(addresses) < 1 {
+		addresses = c.validators
+	}
+	c.broadcast = append(c.broadcast, addresses)
+
+	for _, address := range addresses {
+		var err error
+		if c.failing[address] {
+			err = errors.New("failed")
+		}
+		results = append(results, network.BroadcastResult{Address: address, Error: err})
+	}
+
+	return
+}
+
+func TestBroadcastBallotRetryFailed(t *testing.T) {
+	conf := consensus.NewISAACConfiguration()
+
+	// 4 validators including the local node; ACCEPT needs 3
+	nr, nodes, _ := createNodeRunnerForTesting(4, conf, nil)
+	require.Equal(t, 3, nr.policy.RequiredCount(ballot.StateACCEPT))
+
+	var validators []string
+	for _, n := range nodes[1:] {
+		validators = append(validators, n.Address())
+	}
+
+	b := ballot.NewBallot(nr.localNode.Address(), genesisBlock.Round, []string{})
+	b.SetVote(ballot.StateACCEPT, ballot.VotingYES)
+	b.Sign(nr.localNode.Keypair(), networkID)
+
+	{ // under threshold; sent again only to the failed validators
+		cm := &resultConnectionManager{
+			ConnectionManager: nr.connectionManager,
+			validators:        validators,
+			failing: map[string]bool{
+				nodes[2].Address(): true,
+				nodes[3].Address(): true,
+			},
+		}
+		nr.connectionManager = cm
+
+		retried := nr.broadcastBallot(*b)
+		require.Equal(t, 2, len(cm.broadcast))
+		require.Equal(t, 3, len(cm.broadcast[0]))
+		require.Equal(t, retried, cm.broadcast[1])
+		require.Equal(t, 2, len(retried))
+		require.NotContains(t, retried, nodes[1].Address())
+		require.Contains(t, retried, nodes[2].Address())
+		require.Contains(t, retried, nodes[3].Address())
+	}
+
+	{ // threshold is reached; not sent again
+		cm := &resultConnectionManager{
+			ConnectionManager: nr.connectionManager.(*resultConnectionManager).ConnectionManager,
+			validators:        validators,
+			failing:           map[string]bool{nodes[3].Address(): true},
+		}
+		nr.connectionManager = cm
+
+		retried := nr.broadcastBallot(*b)
+		require.Equal(t, 1, len(cm.broadcast))
+		require.Nil(t, retried)
+	}
+}
@@ -2,12 +2,14 @@ package runner
 
 import (
 	"encoding/json"
+	"time"
 
 	logging "github.com/inconshreveable/log15"
 
 	"boscoin.io/sebak/lib/ballot"
 	"boscoin.io/sebak/lib/block"
 	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/common/observer"
 	"boscoin.io/sebak/lib/consensus"
 	"boscoin.io/sebak/lib/error"
 	"boscoin.io/sebak/lib/node"
@@ -62,6 +64,16 @@ func BallotUnmarshal(c common.Checker, args ...interface{}) (err error) {
 	}
 
 	if err = b.IsWellFormed(checker.NetworkID); err != nil {
+		if err == errors.ErrorMessageHasIncorrectTime {
+			skew, _ := b.ConfirmedTimeSkew(time.Now())
+			checker.Log.Warn(
+				"ballot is rejected by clock skew",
+				"ballot", b.GetHash(),
+				"from", b.Source(),
+				"skew", skew,
+				"allowed", common.BallotConfirmedTimeAllowDuration,
+			)
+		}
 		return
 	}
 
@@ -74,7 +86,8 @@ func BallotUnmarshal(c common.Checker, args ...interface{}) (err error) {
 		"from":     checker.Ballot.Source(),
 		"vote":     checker.Ballot.Vote(),
 	})
-	checker.Log.Debug("message is verified")
+	skew, _ := checker.Ballot.ConfirmedTimeSkew(time.Now())
+	checker.Log.Debug("message is verified", "skew", skew)
 
 	return
 }
@@ -221,6 +234,9 @@ func INITBallotValidateTransactions(c common.Checker, args ...interface{}) (err
 		Transactions:   checker.Ballot.Transactions(),
 		VotingHole:     ballot.VotingNOTYET,
 	}
+	if transactionsChecker.Confirmed, err = common.ParseISO8601(checker.Ballot.ProposerConfirmed()); err != nil {
+		return
+	}
 
 	err = common.RunChecker(transactionsChecker, common.DefaultDeferFunc)
 	if err != nil {
@@ -261,7 +277,7 @@ func SIGNBallotBroadcast(c common.Checker, args ...interface{}) (err error) {
 	}
 	checker.NodeRunner.Consensus().Vote(newBallot)
 
-	checker.NodeRunner.ConnectionManager().Broadcast(newBallot)
+	checker.NodeRunner.BroadcastBallot(newBallot)
 	checker.Log.Debug("ballot will be broadcasted", "newBallot", newBallot)
 
 	return
@@ -297,7 +313,7 @@ func ACCEPTBallotBroadcast(c common.Checker, args ...interface{}) (err error) {
 
 	}
 	checker.NodeRunner.Consensus().Vote(newBallot)
-	checker.NodeRunner.ConnectionManager().Broadcast(newBallot)
+	checker.NodeRunner.BroadcastBallot(newBallot)
 	checker.Log.Debug("ballot will be broadcasted", "newBallot", newBallot)
 
 	return
@@ -328,6 +344,9 @@ func FinishedBallotStore(c common.Checker, args ...interface{}) (err error) {
 			checker.NodeRunner.Storage(),
 			checker.Ballot,
 			checker.NodeRunner.Consensus().TransactionPool,
+			checker.NodeRunner.validatorSet(),
+			checker.NodeRunner.accountFilter,
+			checker.NodeRunner.Conf().VerifySupply,
 			checker.Log,
 			checker.NodeRunner.Log(),
 		)
@@ -336,7 +355,23 @@ func FinishedBallotStore(c common.Checker, args ...interface{}) (err error) {
 		}
 
 		checker.NodeRunner.Consensus().SetLatestConsensusedBlock(theBlock)
+		if err := checker.NodeRunner.UpdateStakeWeights(); err != nil {
+			checker.Log.Error("failed to update stake weights", "error", err)
+		}
+		if err := checker.NodeRunner.UpdateValidatorEndpoints(); err != nil {
+			checker.Log.Error("failed to update validator endpoints", "error", err)
+		}
 		checker.Log.Debug("ballot was stored", "block", theBlock)
+		observer.ConsensusObserver.Trigger(EventConsensusBallotConfirmed, checker.LocalNode.Address(), theBlock)
+
+		if checker.NodeRunner.Conf().PersistBallots {
+			ballots := checker.NodeRunner.Consensus().ReceivedBallots(checker.Ballot.Round(), checker.Ballot.Proposer())
+			for _, b := range ballots {
+				if err := ballot.SaveBallot(checker.NodeRunner.Storage(), b); err != nil {
+					checker.Log.Error("failed to persist ballot", "ballot", b.GetHash(), "error", err)
+				}
+			}
+		}
 		checker.NodeRunner.TransitISAACState(checker.Ballot.Round(), ballot.StateALLCONFIRM)
 
 		err = NewCheckerStopCloseConsensus(checker, "ballot got consensus and will be stored")
@@ -354,23 +389,44 @@ func FinishedBallotStore(c common.Checker, args ...interface{}) (err error) {
 	return
 }
 
-func finishBallot(st *storage.LevelDBBackend, b ballot.Ballot, transactionPool *transaction.TransactionPool, log, infoLog logging.Logger) (blk block.Block, err error) {
-	var ts *storage.LevelDBBackend
-	if ts, err = st.OpenTransaction(); err != nil {
-		return
-	}
-
-	transactions := map[string]transaction.Transaction{}
+// finishBallot stores the block of the ballot with it's transactions, and
+// records `validators`, the validators configured to the local node, at the
+// height of the block if they are changed; see `block.SaveValidatorSet`.
+func finishBallot(st *storage.LevelDBBackend, b ballot.Ballot, transactionPool *transaction.TransactionPool, validators []string, filter *block.AccountFilter, verifySupply bool, log, infoLog logging.Logger) (blk block.Block, err error) {
+	// all the transactions of the block must be in the pool before anything
+	// is stored
+	var transactions []transaction.Transaction
 	for _, hash := range b.B.Proposed.Transactions {
 		tx, found := transactionPool.Get(hash)
 		if !found {
-			err = errors.ErrorTransactionNotFound
+			err = errors.ErrorTransactionNotFound.Clone().SetData("hash", hash)
 			return
 		}
-		transactions[hash] = tx
+		transactions = append(transactions, tx)
+	}
+
+	var ts *storage.LevelDBBackend
+	if ts, err = st.OpenTransaction(); err != nil {
+		return
+	}
+
+	var version uint32
+	if version, err = block.GetNetworkBlockVersion(ts); err != nil {
+		ts.Discard()
+		return
 	}
 
-	blk = block.NewBlockFromBallot(b)
+	blk = block.NewBlockFromBallot(b, version)
+	if err = finishBlock(ts, blk, transactions, nil, validators, filter, verifySupply, infoLog); err != nil {
+		ts.Discard()
+		return
+	}
+	// the validator set is recorded out of the undo record of the block, so
+	// it is kept for audit even if the block is rolled back
+	if err = block.SaveValidatorSet(ts, blk.Height, validators); err != nil {
+		ts.Discard()
+		return
+	}
 	log.Debug("NewBlock created", "block", blk)
 	infoLog.Info("NewBlock created",
 		"height", blk.Height,
@@ -379,74 +435,163 @@ func finishBallot(st *storage.LevelDBBackend, b ballot.Ballot, transactionPool *
 		"total-txs", blk.Round.TotalTxs,
 		"proposer", blk.Proposer,
 	)
-	if err = blk.Save(ts); err != nil {
+
+	if err = ts.Commit(); err != nil {
+		ts.Discard()
+	}
+
+	return
+}
+
+// finishBlock stores the block and applies it's transactions, which must be
+// ordered like `Block.Transactions`, in the storage transaction. `raws` are
+// the stored messages of the transactions; if nil, the transactions are
+// marshaled. `validators` are the validators configured to the local node;
+// see `block.IsValidator`. The changes by the block are recorded and stored
+// as the undo record of the block, so it can be rolled back; see
+// `block.RollbackBlock`.
+func finishBlock(ts *storage.LevelDBBackend, blk block.Block, transactions []transaction.Transaction, raws [][]byte, validators []string, filter *block.AccountFilter, verifySupply bool, log logging.Logger) (err error) {
+	if err = ts.RecordUndo(); err != nil {
 		return
 	}
 
-	for _, hash := range b.B.Proposed.Transactions {
-		tx := transactions[hash]
-		raw, _ := json.Marshal(tx)
+	var stats block.BlockAccountStats
+	if verifySupply {
+		if stats, err = block.GetBlockAccountStats(ts); err != nil {
+			return
+		}
+	}
+
+	if err = blk.Verify(); err != nil {
+		return
+	}
+	if err = blk.VerifyPrevBlockHash(ts); err != nil {
+		return
+	}
+
+	var confirmed time.Time
+	if confirmed, err = common.ParseISO8601(blk.Confirmed); err != nil {
+		return
+	}
+
+	journal := block.NewTransactionJournal(ts)
+	for index, tx := range transactions {
+		var raw []byte
+		if raws != nil {
+			raw = raws[index]
+		} else {
+			raw, _ = json.Marshal(tx)
+		}
 
 		bt := block.NewBlockTransactionFromTransaction(blk.Hash, blk.Height, blk.Confirmed, tx, raw)
 		if err = bt.Save(ts); err != nil {
-			ts.Discard()
 			return
 		}
-		for _, op := range tx.B.Operations {
-			if err = finishOperation(ts, tx, op, log); err != nil {
-				ts.Discard()
+		if err = finishTransaction(ts, tx, validators, filter, log); err != nil {
+			return
+		}
+		if amount := tx.TotalAmount(false); amount > 0 {
+			if err = block.AddSpentAmount(ts, tx.B.Source, confirmed, amount); err != nil {
 				return
 			}
 		}
-
-		var baSource *block.BlockAccount
-		if baSource, err = block.GetBlockAccount(ts, tx.B.Source); err != nil {
-			err = errors.ErrorBlockAccountDoesNotExists
-			ts.Discard()
+		for i := range tx.B.Operations {
+			if err = block.AppendOpLog(ts, block.NewOpLogEntry(blk.Height, tx, i)); err != nil {
+				return
+			}
+		}
+		if err = journal.Append(blk.Height, index, tx); err != nil {
 			return
 		}
+	}
+
+	// the block must not have the transaction, which is not stored
+	if err = blk.VerifyTransactionsPresent(ts); err != nil {
+		return
+	}
+	if err = blk.Save(ts); err != nil {
+		return
+	}
 
-		if err = baSource.Withdraw(tx.TotalAmount(true)); err != nil {
-			ts.Discard()
+	if verifySupply {
+		if err = block.VerifySupplyInvariant(ts, blk, stats.Supply); err != nil {
+			log.Error("total supply is not conserved", "block", blk.Hash, "error", err)
 			return
 		}
+	}
+
+	return block.SaveBlockUndo(ts, blk.Height, ts.StopUndo())
+}
 
-		if err = baSource.Save(ts); err != nil {
-			ts.Discard()
+// finishTransaction applies the operations of the transaction and withdraws
+// the amount and fee from the source account. `validators` are the validators
+// configured to the local node; see `block.IsValidator`.
+func finishTransaction(st *storage.LevelDBBackend, tx transaction.Transaction, validators []string, filter *block.AccountFilter, log logging.Logger) (err error) {
+	for _, op := range tx.B.Operations {
+		if err = finishOperation(st, tx, op, validators, filter, log); err != nil {
 			return
 		}
+	}
 
+	var baSource *block.BlockAccount
+	if baSource, err = block.GetBlockAccount(st, tx.B.Source); err != nil {
+		err = errors.ErrorBlockAccountDoesNotExists
+		return
 	}
 
-	if err = ts.Commit(); err != nil {
-		ts.Discard()
+	if err = baSource.WithdrawWithSequenceID(st, tx.TotalAmount(true), tx.B.SequenceID); err != nil {
+		return
 	}
 
-	return
+	return baSource.Save(st, filter)
 }
 
 // finishOperation do finish the task after consensus by the type of each operation.
-func finishOperation(st *storage.LevelDBBackend, tx transaction.Transaction, op transaction.Operation, log logging.Logger) (err error) {
+func finishOperation(st *storage.LevelDBBackend, tx transaction.Transaction, op transaction.Operation, validators []string, filter *block.AccountFilter, log logging.Logger) (err error) {
 	switch op.H.Type {
 	case transaction.OperationCreateAccount:
 		pop, ok := op.B.(transaction.OperationBodyCreateAccount)
 		if !ok {
 			return errors.ErrorUnknownOperationType
 		}
-		return finishOperationCreateAccount(st, tx, pop, log)
+		return finishOperationCreateAccount(st, tx, pop, filter, log)
 	case transaction.OperationPayment:
 		pop, ok := op.B.(transaction.OperationBodyPayment)
 		if !ok {
 			return errors.ErrorUnknownOperationType
 		}
-		return finishOperationPayment(st, tx, pop, log)
+		return finishOperationPayment(st, tx, pop, filter, log)
+	case transaction.OperationSetAccountData:
+		pop, ok := op.B.(transaction.OperationBodySetAccountData)
+		if !ok {
+			return errors.ErrorUnknownOperationType
+		}
+		return finishOperationSetAccountData(st, tx, pop, filter, log)
+	case transaction.OperationDelegate:
+		pop, ok := op.B.(transaction.OperationBodyDelegate)
+		if !ok {
+			return errors.ErrorUnknownOperationType
+		}
+		return finishOperationDelegate(st, tx, pop, filter, log)
+	case transaction.OperationSetSpendLimit:
+		pop, ok := op.B.(transaction.OperationBodySetSpendLimit)
+		if !ok {
+			return errors.ErrorUnknownOperationType
+		}
+		return finishOperationSetSpendLimit(st, tx, pop, filter, log)
+	case transaction.OperationUpdateEndpoint:
+		pop, ok := op.B.(transaction.OperationBodyUpdateEndpoint)
+		if !ok {
+			return errors.ErrorUnknownOperationType
+		}
+		return finishOperationUpdateEndpoint(st, tx, pop, validators, log)
 	default:
 		err = errors.ErrorUnknownOperationType
 		return
 	}
 }
 
-func finishOperationCreateAccount(st *storage.LevelDBBackend, tx transaction.Transaction, op transaction.OperationBodyCreateAccount, log logging.Logger) (err error) {
+func finishOperationCreateAccount(st *storage.LevelDBBackend, tx transaction.Transaction, op transaction.OperationBodyCreateAccount, filter *block.AccountFilter, log logging.Logger) (err error) {
 
 	var baSource, baTarget *block.BlockAccount
 	if baSource, err = block.GetBlockAccount(st, tx.B.Source); err != nil {
@@ -465,7 +610,7 @@ func finishOperationCreateAccount(st *storage.LevelDBBackend, tx transaction.Tra
 		op.GetAmount(),
 		op.Linked,
 	)
-	if err = baTarget.Save(st); err != nil {
+	if err = baTarget.Save(st, filter); err != nil {
 		return
 	}
 
@@ -474,7 +619,7 @@ func finishOperationCreateAccount(st *storage.LevelDBBackend, tx transaction.Tra
 	return
 }
 
-func finishOperationPayment(st *storage.LevelDBBackend, tx transaction.Transaction, op transaction.OperationBodyPayment, log logging.Logger) (err error) {
+func finishOperationPayment(st *storage.LevelDBBackend, tx transaction.Transaction, op transaction.OperationBodyPayment, filter *block.AccountFilter, log logging.Logger) (err error) {
 
 	var baSource, baTarget *block.BlockAccount
 	if baSource, err = block.GetBlockAccount(st, tx.B.Source); err != nil {
@@ -489,7 +634,7 @@ func finishOperationPayment(st *storage.LevelDBBackend, tx transaction.Transacti
 	if err = baTarget.Deposit(op.GetAmount()); err != nil {
 		return
 	}
-	if err = baTarget.Save(st); err != nil {
+	if err = baTarget.Save(st, filter); err != nil {
 		return
 	}
 
@@ -497,3 +642,92 @@ func finishOperationPayment(st *storage.LevelDBBackend, tx transaction.Transacti
 
 	return
 }
+
+func finishOperationSetAccountData(st *storage.LevelDBBackend, tx transaction.Transaction, op transaction.OperationBodySetAccountData, filter *block.AccountFilter, log logging.Logger) (err error) {
+
+	var baSource *block.BlockAccount
+	if baSource, err = block.GetBlockAccount(st, tx.B.Source); err != nil {
+		err = errors.ErrorBlockAccountDoesNotExists
+		return
+	}
+	if baSource.Address != op.TargetAddress() {
+		err = errors.ErrorAccountDataNotOwner
+		return
+	}
+
+	baSource.Data = op.Apply(baSource.Data)
+	if err = baSource.Save(st, filter); err != nil {
+		return
+	}
+
+	log.Debug("account data set", "source", baSource, "data", op.Data)
+
+	return
+}
+
+func finishOperationDelegate(st *storage.LevelDBBackend, tx transaction.Transaction, op transaction.OperationBodyDelegate, filter *block.AccountFilter, log logging.Logger) (err error) {
+	var baSource *block.BlockAccount
+	if baSource, err = block.GetBlockAccount(st, tx.B.Source); err != nil {
+		err = errors.ErrorBlockAccountDoesNotExists
+		return
+	}
+	if baSource.Address == op.TargetAddress() {
+		err = errors.ErrorSelfDelegation
+		return
+	}
+
+	if err = baSource.SetDelegate(st, op.TargetAddress()); err != nil {
+		return
+	}
+	if err = baSource.Save(st, filter); err != nil {
+		return
+	}
+
+	log.Debug("consensus weight delegated", "source", baSource, "delegate", op.Delegate)
+
+	return
+}
+
+func finishOperationSetSpendLimit(st *storage.LevelDBBackend, tx transaction.Transaction, op transaction.OperationBodySetSpendLimit, filter *block.AccountFilter, log logging.Logger) (err error) {
+	var baSource *block.BlockAccount
+	if baSource, err = block.GetBlockAccount(st, tx.B.Source); err != nil {
+		err = errors.ErrorBlockAccountDoesNotExists
+		return
+	}
+	if baSource.Address != op.TargetAddress() {
+		err = errors.ErrorSpendLimitNotOwner
+		return
+	}
+
+	baSource.SpendLimit = op.Limit
+	if err = baSource.Save(st, filter); err != nil {
+		return
+	}
+
+	log.Debug("spend limit set", "source", baSource, "limit", op.Limit)
+
+	return
+}
+
+func finishOperationUpdateEndpoint(st *storage.LevelDBBackend, tx transaction.Transaction, op transaction.OperationBodyUpdateEndpoint, validators []string, log logging.Logger) (err error) {
+	var isValidator bool
+	if isValidator, err = block.IsValidator(st, tx.B.Source, validators); err != nil {
+		return
+	} else if !isValidator {
+		err = errors.ErrorNotValidator
+		return
+	}
+
+	var endpoint *common.Endpoint
+	if endpoint, err = op.ParsedEndpoint(); err != nil {
+		err = errors.ErrorInvalidEndpoint
+		return
+	}
+	if err = block.SaveValidatorEndpoint(st, tx.B.Source, endpoint); err != nil {
+		return
+	}
+
+	log.Debug("validator endpoint updated", "validator", tx.B.Source, "endpoint", endpoint)
+
+	return
+}
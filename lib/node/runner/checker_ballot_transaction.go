@@ -1,6 +1,9 @@
 package runner
 
 import (
+	"fmt"
+	"time"
+
 	"boscoin.io/sebak/lib/ballot"
 	"boscoin.io/sebak/lib/block"
 	"boscoin.io/sebak/lib/common"
@@ -22,6 +25,11 @@ type BallotTransactionChecker struct {
 	ValidTransactions    []string
 	validTransactionsMap map[string]bool
 	CheckAll             bool
+
+	// Confirmed is the confirmed time of the proposed ballot; the spend
+	// limit is checked at this time. If zero, the confirmed time of the latest
+	// block is used.
+	Confirmed time.Time
 }
 
 func (checker *BallotTransactionChecker) InvalidTransactions() (invalids []string) {
@@ -93,7 +101,8 @@ func GetMissingTransaction(c common.Checker, args ...interface{}) (err error) {
 }
 
 // BallotTransactionsSourceCheck checks there are transactions which has same
-// source in the `Transactions`.
+// source in the `Transactions`. Under the nonce window, the transactions which
+// has same source and same sequenceID are checked.
 func BallotTransactionsSameSource(c common.Checker, args ...interface{}) (err error) {
 	checker := c.(*BallotTransactionChecker)
 
@@ -101,7 +110,14 @@ func BallotTransactionsSameSource(c common.Checker, args ...interface{}) (err er
 	sources := map[string]bool{}
 	for _, hash := range checker.ValidTransactions {
 		tx, _ := checker.NodeRunner.Consensus().TransactionPool.Get(hash)
-		if found := common.InStringMap(sources, tx.B.Source); found {
+
+		// under the nonce window, the transactions of same source can be in
+		// ballot if their sequenceIDs are different.
+		source := tx.B.Source
+		if common.SequenceIDWindow > 1 {
+			source = fmt.Sprintf("%s-%d", tx.B.Source, tx.B.SequenceID)
+		}
+		if found := common.InStringMap(sources, source); found {
 			if !checker.CheckAll {
 				err = errors.ErrorTransactionSameSource
 				return
@@ -109,7 +125,7 @@ func BallotTransactionsSameSource(c common.Checker, args ...interface{}) (err er
 			continue
 		}
 
-		sources[tx.B.Source] = true
+		sources[source] = true
 		validTransactions = append(validTransactions, hash)
 	}
 	err = nil
@@ -118,15 +134,31 @@ func BallotTransactionsSameSource(c common.Checker, args ...interface{}) (err er
 	return
 }
 
-// BallotTransactionsSourceCheck calls `Transaction.Validate()`.
+// BallotTransactionsSourceCheck calls `ValidateTx()` for each transaction.
+// The transactions are validated concurrently by `ValidateTxs()`, but the
+// result is checked by the order of ballot.
 func BallotTransactionsSourceCheck(c common.Checker, args ...interface{}) (err error) {
 	checker := c.(*BallotTransactionChecker)
 
-	var validTransactions []string
-	for _, hash := range checker.ValidTransactions {
-		tx, _ := checker.NodeRunner.Consensus().TransactionPool.Get(hash)
+	txs := make([]transaction.Transaction, len(checker.ValidTransactions))
+	for i, hash := range checker.ValidTransactions {
+		txs[i], _ = checker.NodeRunner.Consensus().TransactionPool.Get(hash)
+	}
+
+	var errs []error
+	if errs, err = ValidateTxs(
+		checker.NodeRunner.Storage(),
+		txs,
+		checker.NodeRunner.Conf().ValidationWorkers,
+		checker.Confirmed,
+		checker.NodeRunner.validatorSet(),
+	); err != nil {
+		return
+	}
 
-		if err = ValidateTx(checker.NodeRunner.Storage(), tx); err != nil {
+	var validTransactions []string
+	for i, hash := range checker.ValidTransactions {
+		if err = errs[i]; err != nil {
 			if !checker.CheckAll {
 				return
 			}
@@ -156,44 +188,153 @@ func BallotTransactionsSourceCheck(c common.Checker, args ...interface{}) (err e
 //
 // Params:
 //   st = Storage backend to use (e.g. to access the blocks)
-//        Only ever read from, never written to.
+//        Only ever read from, never written to. The reads are done on the
+//        snapshot of it, so the block stored during validation is not seen.
 //   tx = Transaction to check
+//   validators = Validators configured to the local node, which are used
+//                when the validator set is not recorded yet
 //
-func ValidateTx(st *storage.LevelDBBackend, tx transaction.Transaction) (err error) {
+func ValidateTx(st *storage.LevelDBBackend, tx transaction.Transaction, validators []string) (err error) {
+	return validateTx(st, tx, newSourceState(time.Time{}, validators))
+}
+
+// sourceState keeps what the preceding transactions of the same source, which
+// are validated together but not yet stored, spent and used, and the account
+// data set by them. `sent` is the sum of the amounts sent by them without
+// fees, which is counted for the spend limit; see `checkSpendLimit()`.
+// `confirmed` is the time, which the spend limit is checked at; if zero, the
+// confirmed time of the latest block. `validators` are the validators
+// configured to the local node; see `block.IsValidator`.
+type sourceState struct {
+	spent       common.Amount
+	sent        common.Amount
+	confirmed   time.Time
+	validators  []string
+	sequenceIDs map[uint64]bool
+	data        map[string]string
+}
+
+func newSourceState(confirmed time.Time, validators []string) *sourceState {
+	return &sourceState{confirmed: confirmed, validators: validators, sequenceIDs: map[uint64]bool{}}
+}
+
+// validateTx validates the transaction like `ValidateTx` on top of the
+// preceding transactions of the same source in `state`; if it is valid,
+// `state` is updated with it.
+func validateTx(st *storage.LevelDBBackend, tx transaction.Transaction, state *sourceState) (err error) {
+	var snapshot *storage.LevelDBBackend
+	if snapshot, err = st.Snapshot(); err != nil {
+		return
+	}
+	defer snapshot.Release()
+	st = snapshot
+
 	// check, source exists
 	var ba *block.BlockAccount
 	if ba, err = block.GetBlockAccount(st, tx.B.Source); err != nil {
-		err = errors.ErrorBlockAccountDoesNotExists
 		return
 	}
 
+	// the latest sequenceID after the preceding transactions, like
+	// `block.BlockAccount.CommitSequenceID` moves it forward
+	sequenceID := ba.SequenceID
+	for state.sequenceIDs[sequenceID] {
+		committed := block.BlockAccount{Address: ba.Address, SequenceID: sequenceID}
+		if sequenceID, err = committed.NextSequenceID(st, sequenceID); err != nil {
+			return
+		}
+	}
+
 	// check, sequenceID is based on latest sequenceID
-	if !tx.IsValidSequenceID(ba.SequenceID) {
+	if !tx.IsValidSequenceIDWindow(sequenceID, common.SequenceIDWindow) {
+		err = errors.ErrorTransactionInvalidSequenceID
+		return
+	}
+
+	// check, sequenceID is not used by the preceding transactions
+	if state.sequenceIDs[tx.B.SequenceID] {
 		err = errors.ErrorTransactionInvalidSequenceID
 		return
 	}
 
-	// get the balance at sequenceID
+	// check, sequenceID ahead of latest sequenceID is not already used
+	if tx.B.SequenceID != sequenceID {
+		var seen bool
+		if seen, err = block.ExistsBlockAccountSeenSequenceID(st, tx.B.Source, tx.B.SequenceID); err != nil {
+			return
+		} else if seen {
+			err = errors.ErrorTransactionInvalidSequenceID
+			return
+		}
+	}
+
+	// get the balance at latest sequenceID
 	var bac block.BlockAccountSequenceID
-	bac, err = block.GetBlockAccountSequenceID(st, tx.B.Source, tx.B.SequenceID)
+	bac, err = block.GetBlockAccountSequenceID(st, tx.B.Source, ba.SequenceID)
 	if err != nil {
 		return
 	}
 
-	totalAmount := tx.TotalAmount(true)
+	// check, sum of amounts does not overflow
+	var totalAmount common.Amount
+	if totalAmount, err = tx.SafeTotalAmount(true); err != nil {
+		return
+	}
+
+	// check, fee covers the fees of the operations
+	if err = tx.CheckFee(transaction.DefaultFeePolicy); err != nil {
+		return
+	}
+
+	// check, the issued coins do not make the total supply over the max
+	// supply
+	var issued common.Amount
+	if issued, err = tx.IssuedAmount(); err != nil {
+		return
+	} else if issued > 0 {
+		if err = block.CheckMaxSupply(st, issued); err != nil {
+			return
+		}
+	}
 
-	// check, have enough balance at sequenceID
-	if bac.Balance < totalAmount {
+	// check, have enough balance at sequenceID after the preceding
+	// transactions
+	var balance common.Amount
+	if balance, err = bac.Balance.Sub(state.spent); err != nil || balance < totalAmount {
 		err = errors.ErrorTransactionExcessAbilityToPay
 		return
 	}
 
+	// check, the sent amounts are under the spend limit
+	if err = checkSpendLimit(st, ba, tx, state); err != nil {
+		return
+	}
+
+	// the account data set by the preceding transactions
+	if state.data != nil {
+		ba.Data = state.data
+	}
+	created := map[string]*block.BlockAccount{}
 	for _, op := range tx.B.Operations {
-		if err = ValidateOp(st, ba, op); err != nil {
+		if err = validateOp(st, ba, op, created, state.validators); err != nil {
 			return
 		}
+
+		// the next operations are validated with the account data set and
+		// the accounts created by this operation
+		switch body := op.B.(type) {
+		case transaction.OperationBodySetAccountData:
+			ba.Data = body.Apply(ba.Data)
+		case transaction.OperationBodyCreateAccount:
+			created[body.Target] = block.NewBlockAccountLinked(body.Target, body.Amount, body.Linked)
+		}
 	}
 
+	state.spent += totalAmount
+	state.sent += tx.TotalAmount(false)
+	state.sequenceIDs[tx.B.SequenceID] = true
+	state.data = ba.Data
+
 	return
 }
 
@@ -208,8 +349,16 @@ func ValidateTx(st *storage.LevelDBBackend, tx transaction.Transaction) (err err
 //        Only ever read from, never written to.
 //   source = Account from where the transaction (and ops) come from
 //   tx = Transaction to check
+//   validators = Validators configured to the local node; see `ValidateTx`
 //
-func ValidateOp(st *storage.LevelDBBackend, source *block.BlockAccount, op transaction.Operation) (err error) {
+func ValidateOp(st *storage.LevelDBBackend, source *block.BlockAccount, op transaction.Operation, validators []string) (err error) {
+	return validateOp(st, source, op, nil, validators)
+}
+
+// validateOp validates the operation like `ValidateOp`; `created` is the
+// accounts created by the preceding operations of the same transaction, so
+// the payment to them is allowed.
+func validateOp(st *storage.LevelDBBackend, source *block.BlockAccount, op transaction.Operation, created map[string]*block.BlockAccount, validators []string) (err error) {
 	switch op.H.Type {
 	case transaction.OperationCreateAccount:
 		var ok bool
@@ -234,10 +383,11 @@ func ValidateOp(st *storage.LevelDBBackend, source *block.BlockAccount, op trans
 			err = errors.ErrorTypeOperationBodyNotMatched
 			return
 		}
-		var taccount *block.BlockAccount
-		if taccount, err = block.GetBlockAccount(st, casted.Target); err != nil {
-			err = errors.ErrorBlockAccountDoesNotExists
-			return
+		taccount, found := created[casted.Target]
+		if !found {
+			if taccount, err = block.GetBlockAccount(st, casted.Target); err != nil {
+				return
+			}
 		}
 		// If it's a frozen account, it cannot receive payment
 		if taccount.Linked != "" {
@@ -253,9 +403,118 @@ func ValidateOp(st *storage.LevelDBBackend, source *block.BlockAccount, op trans
 				return
 			}
 		}
+	case transaction.OperationSetAccountData:
+		var ok bool
+		var casted transaction.OperationBodySetAccountData
+		if casted, ok = op.B.(transaction.OperationBodySetAccountData); !ok {
+			err = errors.ErrorTypeOperationBodyNotMatched
+			return
+		}
+		// Only the owner can set it's own data
+		if casted.Target != source.Address {
+			err = errors.ErrorAccountDataNotOwner
+			return
+		}
+		applied := casted.Apply(source.Data)
+		if err = transaction.CheckAccountDataLimit(applied); err != nil {
+			return
+		}
+
+		updated := *source
+		updated.Data = applied
+		if err = updated.CheckStorageBudget(st); err != nil {
+			return
+		}
+	case transaction.OperationDelegate:
+		var ok bool
+		var casted transaction.OperationBodyDelegate
+		if casted, ok = op.B.(transaction.OperationBodyDelegate); !ok {
+			err = errors.ErrorTypeOperationBodyNotMatched
+			return
+		}
+		if casted.Delegate == source.Address {
+			err = errors.ErrorSelfDelegation
+			return
+		}
+		// Only the validator can be delegated
+		var isValidator bool
+		if isValidator, err = block.IsValidator(st, casted.TargetAddress(), validators); err != nil {
+			return
+		} else if !isValidator {
+			err = errors.ErrorDelegateNotValidator
+			return
+		}
+	case transaction.OperationSetSpendLimit:
+		var ok bool
+		var casted transaction.OperationBodySetSpendLimit
+		if casted, ok = op.B.(transaction.OperationBodySetSpendLimit); !ok {
+			err = errors.ErrorTypeOperationBodyNotMatched
+			return
+		}
+		// Only the owner can set it's own limit
+		if casted.Target != source.Address {
+			err = errors.ErrorSpendLimitNotOwner
+			return
+		}
+	case transaction.OperationUpdateEndpoint:
+		if _, ok := op.B.(transaction.OperationBodyUpdateEndpoint); !ok {
+			err = errors.ErrorTypeOperationBodyNotMatched
+			return
+		}
+		// Only the validator can update it's own endpoint
+		var isValidator bool
+		if isValidator, err = block.IsValidator(st, source.Address, validators); err != nil {
+			return
+		} else if !isValidator {
+			err = errors.ErrorNotValidator
+			return
+		}
 	default:
 		err = errors.ErrorUnknownOperationType
 		return
 	}
 	return
 }
+
+// checkSpendLimit checks the sum of the amounts, which the source account
+// sends by the transaction, by the preceding transactions in `state` and by the
+// transactions confirmed within `common.SpendLimitPeriod` before
+// `sourceState.confirmed`, is not over `block.BlockAccount.SpendLimit`.
+func checkSpendLimit(st *storage.LevelDBBackend, source *block.BlockAccount, tx transaction.Transaction, state *sourceState) (err error) {
+	if source.SpendLimit < 1 {
+		return
+	}
+
+	amount := tx.TotalAmount(false)
+	if amount < 1 {
+		return
+	}
+
+	confirmed := state.confirmed
+	if confirmed.IsZero() {
+		var latest block.Block
+		if latest, err = block.GetLatestBlock(st); err != nil {
+			return
+		}
+		if confirmed, err = common.ParseISO8601(latest.Confirmed); err != nil {
+			return
+		}
+	}
+
+	var spent common.Amount
+	if spent, err = block.GetSpentAmount(st, source.Address, confirmed.Add(-common.SpendLimitPeriod)); err != nil {
+		return
+	}
+	if spent, err = spent.Add(state.sent); err != nil {
+		return
+	}
+
+	if total, e := spent.Add(amount); e != nil || total > source.SpendLimit {
+		err = errors.ErrorSpendLimitExceeded.Clone().
+			SetData("limit", source.SpendLimit).
+			SetData("spent", spent)
+		return
+	}
+
+	return
+}
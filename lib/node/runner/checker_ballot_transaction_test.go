@@ -12,11 +12,16 @@
 package runner
 
 import (
+	"math"
+	"strings"
 	"testing"
+	"time"
 
 	"boscoin.io/sebak/lib/block"
 	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/consensus"
 	"boscoin.io/sebak/lib/error"
+	"boscoin.io/sebak/lib/node"
 	"boscoin.io/sebak/lib/storage"
 	"boscoin.io/sebak/lib/transaction"
 
@@ -50,15 +55,15 @@ func TestValidateTxPaymentMissingBlockAccount(t *testing.T) {
 		},
 	}
 	tx.H.Hash = tx.B.MakeHashString()
-	require.Equal(t, ValidateTx(st, tx), errors.ErrorBlockAccountDoesNotExists)
+	require.Equal(t, ValidateTx(st, tx, nil), errors.ErrorBlockAccountDoesNotExists)
 
 	// Now add the source account but not the target
 	bas := block.BlockAccount{
 		Address: kps.Address(),
 		Balance: common.Amount(1 * common.AmountPerCoin),
 	}
-	bas.Save(st)
-	require.Equal(t, ValidateTx(st, tx), errors.ErrorBlockAccountDoesNotExists)
+	bas.Save(st, nil)
+	require.Equal(t, ValidateTx(st, tx, nil), errors.ErrorBlockAccountDoesNotExists)
 
 	// Now just the target
 	st1 := storage.NewTestStorage()
@@ -67,15 +72,15 @@ func TestValidateTxPaymentMissingBlockAccount(t *testing.T) {
 		Address: kpt.Address(),
 		Balance: common.Amount(1 * common.AmountPerCoin),
 	}
-	bat.Save(st1)
-	require.Equal(t, ValidateTx(st1, tx), errors.ErrorBlockAccountDoesNotExists)
+	bat.Save(st1, nil)
+	require.Equal(t, ValidateTx(st1, tx, nil), errors.ErrorBlockAccountDoesNotExists)
 
 	// And finally, bot
 	st2 := storage.NewTestStorage()
 	defer st2.Close()
-	bas.Save(st2)
-	bat.Save(st2)
-	require.Nil(t, ValidateTx(st2, tx))
+	bas.Save(st2, nil)
+	bat.Save(st2, nil)
+	require.Nil(t, ValidateTx(st2, tx, nil))
 }
 
 // Check for correct sequence ID
@@ -94,8 +99,8 @@ func TestValidateTxWrongSequenceID(t *testing.T) {
 		Address: kpt.Address(),
 		Balance: common.Amount(1 * common.AmountPerCoin),
 	}
-	bas.Save(st)
-	bat.Save(st)
+	bas.Save(st, nil)
+	bat.Save(st, nil)
 
 	tx := transaction.Transaction{
 		T: "transaction",
@@ -115,11 +120,144 @@ func TestValidateTxWrongSequenceID(t *testing.T) {
 		},
 	}
 	tx.H.Hash = tx.B.MakeHashString()
-	require.Equal(t, ValidateTx(st, tx), errors.ErrorTransactionInvalidSequenceID)
+	require.Equal(t, ValidateTx(st, tx, nil), errors.ErrorTransactionInvalidSequenceID)
 	tx.B.SequenceID = 2
-	require.Equal(t, ValidateTx(st, tx), errors.ErrorTransactionInvalidSequenceID)
+	require.Equal(t, ValidateTx(st, tx, nil), errors.ErrorTransactionInvalidSequenceID)
 	tx.B.SequenceID = 1
-	require.Nil(t, ValidateTx(st, tx))
+	require.Nil(t, ValidateTx(st, tx, nil))
+}
+
+// Check sequence ID under the nonce window
+func TestValidateTxSequenceIDWindow(t *testing.T) {
+	defer func(w uint64) { common.SequenceIDWindow = w }(common.SequenceIDWindow)
+	common.SequenceIDWindow = 3
+
+	kps, _ := keypair.Random()
+	kpt, _ := keypair.Random()
+
+	st := storage.NewTestStorage()
+	defer st.Close()
+	bas := block.BlockAccount{
+		Address:    kps.Address(),
+		Balance:    common.Amount(1 * common.AmountPerCoin),
+		SequenceID: 1,
+	}
+	bat := block.BlockAccount{
+		Address: kpt.Address(),
+		Balance: common.Amount(1 * common.AmountPerCoin),
+	}
+	bas.Save(st, nil)
+	bat.Save(st, nil)
+
+	tx := transaction.Transaction{
+		T: "transaction",
+		H: transaction.TransactionHeader{
+			Created: common.NowISO8601(),
+		},
+		B: transaction.TransactionBody{
+			Source:     kps.Address(),
+			Fee:        common.BaseFee,
+			SequenceID: 0,
+			Operations: []transaction.Operation{
+				transaction.Operation{
+					H: transaction.OperationHeader{Type: transaction.OperationPayment},
+					B: transaction.OperationBodyPayment{Target: kpt.Address(), Amount: common.Amount(10000)},
+				},
+			},
+		},
+	}
+	tx.H.Hash = tx.B.MakeHashString()
+	require.Equal(t, ValidateTx(st, tx, nil), errors.ErrorTransactionInvalidSequenceID)
+	tx.B.SequenceID = 4
+	require.Equal(t, ValidateTx(st, tx, nil), errors.ErrorTransactionInvalidSequenceID)
+
+	// out of order, but in the window
+	tx.B.SequenceID = 3
+	require.Nil(t, ValidateTx(st, tx, nil))
+	tx.B.SequenceID = 2
+	require.Nil(t, ValidateTx(st, tx, nil))
+
+	// the replayed sequenceID is rejected
+	require.Nil(t, bas.CommitSequenceID(st, 3))
+	require.Nil(t, bas.Save(st, nil))
+	tx.B.SequenceID = 3
+	require.Equal(t, ValidateTx(st, tx, nil), errors.ErrorTransactionInvalidSequenceID)
+	tx.B.SequenceID = 1
+	require.Nil(t, ValidateTx(st, tx, nil))
+
+	require.Nil(t, bas.CommitSequenceID(st, 1))
+	require.Nil(t, bas.Save(st, nil))
+	require.Equal(t, ValidateTx(st, tx, nil), errors.ErrorTransactionInvalidSequenceID)
+	tx.B.SequenceID = 2
+	require.Nil(t, ValidateTx(st, tx, nil))
+}
+
+// Check the transactions of same source under the nonce window are validated
+// on top of the preceding ones in the order of ballot
+func TestValidateTxsSequenceIDWindowCumulative(t *testing.T) {
+	defer func(w uint64) { common.SequenceIDWindow = w }(common.SequenceIDWindow)
+	common.SequenceIDWindow = 3
+
+	kps, _ := keypair.Random()
+	kpt, _ := keypair.Random()
+
+	st := storage.NewTestStorage()
+	defer st.Close()
+	bas := block.BlockAccount{
+		Address:    kps.Address(),
+		Balance:    common.Amount(1 * common.AmountPerCoin),
+		SequenceID: 1,
+	}
+	bat := block.BlockAccount{
+		Address: kpt.Address(),
+		Balance: common.Amount(1 * common.AmountPerCoin),
+	}
+	bas.Save(st, nil)
+	bat.Save(st, nil)
+
+	makeTx := func(sequenceID uint64, amount common.Amount) transaction.Transaction {
+		tx := transaction.Transaction{
+			T: "transaction",
+			H: transaction.TransactionHeader{
+				Created: common.NowISO8601(),
+			},
+			B: transaction.TransactionBody{
+				Source:     kps.Address(),
+				Fee:        common.BaseFee,
+				SequenceID: sequenceID,
+				Operations: []transaction.Operation{
+					transaction.Operation{
+						H: transaction.OperationHeader{Type: transaction.OperationPayment},
+						B: transaction.OperationBodyPayment{Target: kpt.Address(), Amount: amount},
+					},
+				},
+			},
+		}
+		tx.H.Hash = tx.B.MakeHashString()
+		return tx
+	}
+
+	half := common.Amount(common.AmountPerCoin / 2)
+
+	{ // each one is under the balance, but not the both
+		txs := []transaction.Transaction{makeTx(2, half), makeTx(1, half)}
+		require.Nil(t, ValidateTx(st, txs[0], nil))
+		require.Nil(t, ValidateTx(st, txs[1], nil))
+
+		errs, err := ValidateTxs(st, txs, 2, time.Time{}, nil)
+		require.Nil(t, err)
+		require.Nil(t, errs[0])
+		require.Equal(t, errors.ErrorTransactionExcessAbilityToPay, errs[1])
+	}
+
+	{ // same sequenceID is used once
+		txs := []transaction.Transaction{makeTx(2, 1000), makeTx(2, 2000), makeTx(1, 1000)}
+		errs, err := ValidateTxs(st, txs, 2, time.Time{}, nil)
+		require.Nil(t, err)
+		require.Nil(t, errs[0])
+		require.Equal(t, errors.ErrorTransactionInvalidSequenceID, errs[1])
+		require.Nil(t, errs[2])
+	}
 }
 
 // Check sending the whole balance
@@ -138,8 +276,8 @@ func TestValidateTxOverBalance(t *testing.T) {
 		Address: kpt.Address(),
 		Balance: common.Amount(1 * common.AmountPerCoin),
 	}
-	bas.Save(st)
-	bat.Save(st)
+	bas.Save(st, nil)
+	bat.Save(st, nil)
 
 	opbody := transaction.OperationBodyPayment{Target: kpt.Address(), Amount: bas.Balance}
 	tx := transaction.Transaction{
@@ -160,10 +298,10 @@ func TestValidateTxOverBalance(t *testing.T) {
 		},
 	}
 	tx.H.Hash = tx.B.MakeHashString()
-	require.Equal(t, ValidateTx(st, tx), errors.ErrorTransactionExcessAbilityToPay)
+	require.Equal(t, ValidateTx(st, tx, nil), errors.ErrorTransactionExcessAbilityToPay)
 	opbody.Amount = bas.Balance.MustSub(common.BaseFee)
 	tx.B.Operations[0].B = opbody
-	require.Nil(t, ValidateTx(st, tx))
+	require.Nil(t, ValidateTx(st, tx, nil))
 
 	// Also test multiple operations
 	// Note: The account balance is 1 BOS (10M units), so we make 4 ops of 2,5M
@@ -172,12 +310,144 @@ func TestValidateTxOverBalance(t *testing.T) {
 	opbody.Amount = common.Amount(2500000)
 	op.B = opbody
 	tx.B.Operations = []transaction.Operation{op, op, op, op}
-	require.Equal(t, ValidateTx(st, tx), errors.ErrorTransactionExcessAbilityToPay)
+	require.Equal(t, ValidateTx(st, tx, nil), errors.ErrorTransactionExcessAbilityToPay)
 
 	// Now the total amount of the ops + balance is equal to the balance
 	opbody.Amount = opbody.Amount.MustSub(common.BaseFee.MustMult(len(tx.B.Operations)))
 	tx.B.Operations[0].B = opbody
-	require.Nil(t, ValidateTx(st, tx))
+	require.Nil(t, ValidateTx(st, tx, nil))
+}
+
+// Check `ValidateTx` sees the consistent state, while the blocks are stored
+// concurrently
+func TestValidateTxConsistentSnapshot(t *testing.T) {
+	kps, _ := keypair.Random()
+	kpt, _ := keypair.Random()
+
+	st := storage.NewTestStorage()
+	defer st.Close()
+	bas := block.BlockAccount{
+		Address: kps.Address(),
+		Balance: common.Amount(1 * common.AmountPerCoin),
+	}
+	bat := block.BlockAccount{
+		Address: kpt.Address(),
+		Balance: common.Amount(1 * common.AmountPerCoin),
+	}
+	bas.Save(st, nil)
+	bat.Save(st, nil)
+
+	tx := transaction.Transaction{
+		T: "transaction",
+		H: transaction.TransactionHeader{
+			Created: common.NowISO8601(),
+		},
+		B: transaction.TransactionBody{
+			Source:     kps.Address(),
+			Fee:        common.BaseFee,
+			SequenceID: 0,
+			Operations: []transaction.Operation{
+				transaction.Operation{
+					H: transaction.OperationHeader{Type: transaction.OperationPayment},
+					B: transaction.OperationBodyPayment{Target: kpt.Address(), Amount: common.Amount(10000)},
+				},
+			},
+		},
+	}
+	tx.H.Hash = tx.B.MakeHashString()
+	require.Nil(t, ValidateTx(st, tx, nil))
+
+	// each block spends the balance of source and increases the sequenceID
+	done := make(chan error)
+	go func() {
+		for i := 0; i < 50; i++ {
+			ts, err := st.OpenTransaction()
+			if err != nil {
+				done <- err
+				return
+			}
+			ba, err := block.GetBlockAccount(ts, kps.Address())
+			if err != nil {
+				ts.Discard()
+				done <- err
+				return
+			}
+			ba.SequenceID++
+			ba.Balance = ba.Balance.MustSub(common.Amount(10000))
+			if err = ba.Save(ts, nil); err != nil {
+				ts.Discard()
+				done <- err
+				return
+			}
+			if err = ts.Commit(); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+
+	var validated int
+	for {
+		select {
+		case err := <-done:
+			require.Nil(t, err)
+			require.True(t, validated > 0)
+			require.Equal(t, errors.ErrorTransactionInvalidSequenceID, ValidateTx(st, tx, nil))
+			return
+		default:
+		}
+
+		err := ValidateTx(st, tx, nil)
+		if err != nil {
+			require.Equal(t, errors.ErrorTransactionInvalidSequenceID, err)
+		}
+		validated++
+	}
+}
+
+// Check the sum of amounts which overflows is rejected instead of wrapping
+func TestValidateTxAmountOverflow(t *testing.T) {
+	kps, _ := keypair.Random()
+	kpt, _ := keypair.Random()
+
+	st := storage.NewTestStorage()
+	defer st.Close()
+	bas := block.BlockAccount{
+		Address:    kps.Address(),
+		Balance:    common.Amount(1 * common.AmountPerCoin),
+		SequenceID: 1,
+	}
+	bat := block.BlockAccount{
+		Address: kpt.Address(),
+		Balance: common.Amount(1 * common.AmountPerCoin),
+	}
+	bas.Save(st, nil)
+	bat.Save(st, nil)
+
+	opbody := transaction.OperationBodyPayment{Target: kpt.Address(), Amount: common.Amount(math.MaxInt64)}
+	op := transaction.Operation{
+		H: transaction.OperationHeader{Type: transaction.OperationPayment},
+		B: opbody,
+	}
+	tx := transaction.Transaction{
+		T: "transaction",
+		H: transaction.TransactionHeader{
+			Created: common.NowISO8601(),
+		},
+		B: transaction.TransactionBody{
+			Source:     kps.Address(),
+			Fee:        common.BaseFee,
+			SequenceID: 1,
+			Operations: []transaction.Operation{op, op},
+		},
+	}
+	tx.H.Hash = tx.B.MakeHashString()
+	require.Equal(t, ValidateTx(st, tx, nil), errors.ErrorTransactionAmountOverflow)
+
+	// without overflow, it is just over balance
+	tx.B.Operations = []transaction.Operation{op}
+	require.Equal(t, ValidateTx(st, tx, nil), errors.ErrorTransactionExcessAbilityToPay)
 }
 
 // Test creating an already existing account
@@ -196,8 +466,8 @@ func TestValidateOpCreateExistsAccount(t *testing.T) {
 		Address: kpt.Address(),
 		Balance: common.Amount(1 * common.AmountPerCoin),
 	}
-	bat.Save(st)
-	bas.Save(st)
+	bat.Save(st, nil)
+	bas.Save(st, nil)
 
 	tx := transaction.Transaction{
 		T: "transaction",
@@ -217,10 +487,511 @@ func TestValidateOpCreateExistsAccount(t *testing.T) {
 		},
 	}
 	tx.H.Hash = tx.B.MakeHashString()
-	require.Equal(t, ValidateTx(st, tx), errors.ErrorBlockAccountAlreadyExists)
+	require.Equal(t, ValidateTx(st, tx, nil), errors.ErrorBlockAccountAlreadyExists)
 
 	st1 := storage.NewTestStorage()
 	defer st1.Close()
-	bas.Save(st1)
-	require.Nil(t, ValidateTx(st1, tx))
+	bas.Save(st1, nil)
+	require.Nil(t, ValidateTx(st1, tx, nil))
+}
+
+// Test setting the account data
+func TestValidateOpSetAccountData(t *testing.T) {
+	kps, _ := keypair.Random()
+	kpt, _ := keypair.Random()
+
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	bas := block.BlockAccount{
+		Address: kps.Address(),
+		Balance: common.Amount(1 * common.AmountPerCoin),
+	}
+	bas.Save(st, nil)
+
+	makeOp := func(target string, data ...transaction.AccountData) transaction.Operation {
+		return transaction.Operation{
+			H: transaction.OperationHeader{Type: transaction.OperationSetAccountData},
+			B: transaction.NewOperationBodySetAccountData(target, data),
+		}
+	}
+
+	// other account can not set the data
+	op := makeOp(kpt.Address(), transaction.AccountData{Key: "name", Value: "showme"})
+	require.Equal(t, errors.ErrorAccountDataNotOwner, ValidateOp(st, &bas, op, nil))
+
+	op = makeOp(kps.Address(), transaction.AccountData{Key: "name", Value: "showme"})
+	require.Nil(t, ValidateOp(st, &bas, op, nil))
+
+	// over the size cap with the existing data
+	bas.Data = map[string]string{"big": strings.Repeat("v", common.MaxAccountDataBytes-10)}
+	op = makeOp(kps.Address(), transaction.AccountData{Key: "name", Value: "showme"})
+	require.Equal(t, errors.ErrorAccountDataTooLarge, ValidateOp(st, &bas, op, nil))
+
+	// removing the existing data makes room
+	op = makeOp(
+		kps.Address(),
+		transaction.AccountData{Key: "big", Value: ""},
+		transaction.AccountData{Key: "name", Value: "showme"},
+	)
+	require.Nil(t, ValidateOp(st, &bas, op, nil))
+}
+
+func TestValidateOpSetAccountDataStorageBudget(t *testing.T) {
+	kps, _ := keypair.Random()
+	kpd, _ := keypair.Random()
+
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	bas := block.BlockAccount{
+		Address:  kps.Address(),
+		Balance:  common.Amount(1 * common.AmountPerCoin),
+		Delegate: kpd.Address(),
+		Data:     map[string]string{"name": "showme"},
+	}
+	bas.Save(st, nil)
+
+	makeOp := func(data ...transaction.AccountData) transaction.Operation {
+		return transaction.Operation{
+			H: transaction.OperationHeader{Type: transaction.OperationSetAccountData},
+			B: transaction.NewOperationBodySetAccountData(kps.Address(), data),
+		}
+	}
+
+	// the delegated address is also counted
+	require.Nil(t, block.SaveAccountStorageBudget(st, bas.StorageSize()+len("site")+10))
+
+	// within budget
+	op := makeOp(transaction.AccountData{Key: "site", Value: strings.Repeat("v", 10)})
+	require.Nil(t, ValidateOp(st, &bas, op, nil))
+
+	// over budget, though under `MaxAccountDataBytes`
+	op = makeOp(transaction.AccountData{Key: "site", Value: strings.Repeat("v", 11)})
+	err := ValidateOp(st, &bas, op, nil)
+	require.NotNil(t, err)
+	require.Equal(t, errors.ErrorAccountStorageBudgetExceeded.Code, err.(*errors.Error).Code)
+
+	// replacing the existing data is counted by the applied data
+	op = makeOp(
+		transaction.AccountData{Key: "name", Value: ""},
+		transaction.AccountData{Key: "site", Value: strings.Repeat("v", 11)},
+	)
+	require.Nil(t, ValidateOp(st, &bas, op, nil))
+
+	// `0` disables the budget
+	require.Nil(t, block.SaveAccountStorageBudget(st, 0))
+	op = makeOp(transaction.AccountData{Key: "site", Value: strings.Repeat("v", 100)})
+	require.Nil(t, ValidateOp(st, &bas, op, nil))
+}
+
+// The operations of one transaction are validated on top of the account data
+// set by the preceding operations
+func TestValidateTxSetAccountDataStorageBudget(t *testing.T) {
+	kps, _ := keypair.Random()
+
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	bas := block.NewBlockAccount(kps.Address(), common.Amount(1*common.AmountPerCoin))
+	require.Nil(t, bas.Save(st, nil))
+
+	makeOp := func(key string) transaction.Operation {
+		return transaction.Operation{
+			H: transaction.OperationHeader{Type: transaction.OperationSetAccountData},
+			B: transaction.NewOperationBodySetAccountData(kps.Address(), []transaction.AccountData{
+				{Key: key, Value: strings.Repeat("v", 10)},
+			}),
+		}
+	}
+
+	// one data is under the budget, but not the both
+	require.Nil(t, block.SaveAccountStorageBudget(st, bas.StorageSize()+len("site")+10))
+
+	tx := transaction.Transaction{
+		T: "transaction",
+		H: transaction.TransactionHeader{
+			Created: common.NowISO8601(),
+		},
+		B: transaction.TransactionBody{
+			Source:     kps.Address(),
+			Fee:        common.BaseFee,
+			SequenceID: bas.SequenceID,
+			Operations: []transaction.Operation{makeOp("site")},
+		},
+	}
+	tx.H.Hash = tx.B.MakeHashString()
+	require.Nil(t, ValidateTx(st, tx, nil))
+
+	tx.B.Operations = []transaction.Operation{makeOp("site"), makeOp("mail")}
+	tx.H.Hash = tx.B.MakeHashString()
+	err := ValidateTx(st, tx, nil)
+	require.NotNil(t, err)
+	require.Equal(t, errors.ErrorAccountStorageBudgetExceeded.Code, err.(*errors.Error).Code)
+
+	// the same data is set again
+	tx.B.Operations = []transaction.Operation{makeOp("site"), makeOp("site")}
+	tx.H.Hash = tx.B.MakeHashString()
+	require.Nil(t, ValidateTx(st, tx, nil))
+}
+
+func TestFinishOperationSetAccountData(t *testing.T) {
+	kps, _ := keypair.Random()
+
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	bas := block.NewBlockAccount(kps.Address(), common.Amount(1*common.AmountPerCoin))
+	bas.Data = map[string]string{"email": "showme@example.com"}
+	require.Nil(t, bas.Save(st, nil))
+
+	op := transaction.Operation{
+		H: transaction.OperationHeader{Type: transaction.OperationSetAccountData},
+		B: transaction.NewOperationBodySetAccountData(
+			kps.Address(),
+			[]transaction.AccountData{{Key: "name", Value: "showme"}, {Key: "email", Value: ""}},
+		),
+	}
+	tx, _ := transaction.NewTransaction(kps.Address(), 0, op)
+
+	require.Nil(t, finishOperation(st, tx, op, nil, nil, log))
+
+	saved, err := block.GetBlockAccount(st, kps.Address())
+	require.Nil(t, err)
+	require.Equal(t, map[string]string{"name": "showme"}, saved.Data)
+	require.Equal(t, bas.Balance, saved.Balance)
+
+	// other account can not set the data
+	kpo, _ := keypair.Random()
+	bao := block.NewBlockAccount(kpo.Address(), common.Amount(1*common.AmountPerCoin))
+	require.Nil(t, bao.Save(st, nil))
+	tx, _ = transaction.NewTransaction(kpo.Address(), 0, op)
+	require.Equal(t, errors.ErrorAccountDataNotOwner, finishOperation(st, tx, op, nil, nil, log))
+}
+
+func TestValidateTxSpendLimit(t *testing.T) {
+	defer func(w uint64) { common.SequenceIDWindow = w }(common.SequenceIDWindow)
+	common.SequenceIDWindow = 3
+
+	kps, _ := keypair.Random()
+	kpt, _ := keypair.Random()
+
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	bas := block.NewBlockAccount(kps.Address(), common.Amount(10*common.AmountPerCoin))
+	bas.SpendLimit = common.Amount(1000000)
+	require.Nil(t, bas.Save(st, nil))
+	bat := block.NewBlockAccount(kpt.Address(), common.Amount(1*common.AmountPerCoin))
+	require.Nil(t, bat.Save(st, nil))
+
+	makePayment := func(sequenceID uint64, amount common.Amount) transaction.Transaction {
+		op := transaction.Operation{
+			H: transaction.OperationHeader{Type: transaction.OperationPayment},
+			B: transaction.NewOperationBodyPayment(kpt.Address(), amount),
+		}
+		tx, _ := transaction.NewTransaction(kps.Address(), sequenceID, op)
+		tx.Sign(kps, networkID)
+		return tx
+	}
+
+	// the latest block; the spend limit is checked at it's confirmed time
+	now := time.Now()
+	latest := block.TestMakeNewBlock([]string{})
+	latest.Height = 2
+	latest.Confirmed = common.FormatISO8601(now)
+	require.Nil(t, latest.Save(st))
+
+	// the confirmed payments; the older one than `common.SpendLimitPeriod` is
+	// not counted
+	require.Nil(t, block.AddSpentAmount(st, kps.Address(), now.Add(-common.SpendLimitPeriod-time.Hour), common.Amount(5000000)))
+	require.Nil(t, block.AddSpentAmount(st, kps.Address(), now.Add(-time.Hour), common.Amount(600000)))
+
+	spent, err := block.GetSpentAmount(st, kps.Address(), now.Add(-common.SpendLimitPeriod))
+	require.Nil(t, err)
+	require.Equal(t, common.Amount(600000), spent)
+
+	// within the limit
+	require.Nil(t, ValidateTx(st, makePayment(0, common.Amount(400000)), nil))
+
+	// crossing the limit
+	err = ValidateTx(st, makePayment(0, common.Amount(400001)), nil)
+	require.NotNil(t, err)
+	require.Equal(t, errors.ErrorSpendLimitExceeded.Code, err.(*errors.Error).Code)
+
+	// the create-account operation is also counted
+	kpn, _ := keypair.Random()
+	op := transaction.Operation{
+		H: transaction.OperationHeader{Type: transaction.OperationCreateAccount},
+		B: transaction.NewOperationBodyCreateAccount(kpn.Address(), common.Amount(500000), ""),
+	}
+	tx, _ := transaction.NewTransaction(kps.Address(), 0, op)
+	tx.Sign(kps, networkID)
+	err = ValidateTx(st, tx, nil)
+	require.NotNil(t, err)
+	require.Equal(t, errors.ErrorSpendLimitExceeded.Code, err.(*errors.Error).Code)
+
+	{ // the preceding transactions of the same source in ballot are counted
+		txs := []transaction.Transaction{
+			makePayment(0, common.Amount(300000)),
+			makePayment(1, common.Amount(100000)),
+			makePayment(2, common.Amount(1)),
+		}
+		errs, err := ValidateTxs(st, txs, 2, now, nil)
+		require.Nil(t, err)
+		require.Nil(t, errs[0])
+		require.Nil(t, errs[1])
+		require.NotNil(t, errs[2])
+		require.Equal(t, errors.ErrorSpendLimitExceeded.Code, errs[2].(*errors.Error).Code)
+	}
+
+	{ // checked at the confirmed time of ballot; the payment of an hour ago
+		// is expired
+		errs, err := ValidateTxs(st, []transaction.Transaction{makePayment(0, common.Amount(1000000))}, 1, now.Add(common.SpendLimitPeriod), nil)
+		require.Nil(t, err)
+		require.Nil(t, errs[0])
+	}
+
+	// without limit
+	bas.SpendLimit = 0
+	require.Nil(t, bas.Save(st, nil))
+	require.Nil(t, ValidateTx(st, makePayment(0, common.Amount(400001)), nil))
+}
+
+func TestFinishOperationSetSpendLimit(t *testing.T) {
+	kps, _ := keypair.Random()
+
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	bas := block.NewBlockAccount(kps.Address(), common.Amount(1*common.AmountPerCoin))
+	require.Nil(t, bas.Save(st, nil))
+
+	op := transaction.Operation{
+		H: transaction.OperationHeader{Type: transaction.OperationSetSpendLimit},
+		B: transaction.NewOperationBodySetSpendLimit(kps.Address(), common.Amount(1000)),
+	}
+	tx, _ := transaction.NewTransaction(kps.Address(), 0, op)
+	require.Nil(t, ValidateOp(st, bas, op, nil))
+	require.Nil(t, finishOperation(st, tx, op, nil, nil, log))
+
+	saved, err := block.GetBlockAccount(st, kps.Address())
+	require.Nil(t, err)
+	require.Equal(t, common.Amount(1000), saved.SpendLimit)
+	require.Equal(t, bas.Balance, saved.Balance)
+
+	// other account can not set the limit
+	kpo, _ := keypair.Random()
+	bao := block.NewBlockAccount(kpo.Address(), common.Amount(1*common.AmountPerCoin))
+	require.Nil(t, bao.Save(st, nil))
+	tx, _ = transaction.NewTransaction(kpo.Address(), 0, op)
+	require.Equal(t, errors.ErrorSpendLimitNotOwner, ValidateOp(st, bao, op, nil))
+	require.Equal(t, errors.ErrorSpendLimitNotOwner, finishOperation(st, tx, op, nil, nil, log))
+}
+
+func TestFinishOperationDelegate(t *testing.T) {
+	nodeRunner, localNode := MakeNodeRunner()
+	st := nodeRunner.Storage()
+
+	// the delegated weight is counted by the stake policy
+	policy, err := consensus.NewStakeVotingThresholdPolicy(66, 66)
+	require.Nil(t, err)
+	require.Nil(t, policy.SetValidators(nodeRunner.Policy().Validators()))
+	nodeRunner.policy = policy
+
+	validator := localNode.Address()
+	require.Equal(t, 1, nodeRunner.Policy().Weight(validator))
+
+	kps, _ := keypair.Random()
+	bas := block.NewBlockAccount(kps.Address(), common.Amount(1*common.AmountPerCoin))
+	require.Nil(t, bas.Save(st, nil))
+
+	op := transaction.Operation{
+		H: transaction.OperationHeader{Type: transaction.OperationDelegate},
+		B: transaction.NewOperationBodyDelegate(validator),
+	}
+	tx, _ := transaction.NewTransaction(kps.Address(), 0, op)
+
+	// the configured validator, before the validator set is recorded
+	require.Equal(t, errors.ErrorDelegateNotValidator, ValidateOp(st, bas, op, nil))
+	require.Nil(t, ValidateOp(st, bas, op, nodeRunner.validatorSet()))
+
+	// not in the recorded validator set
+	kpv, _ := keypair.Random()
+	require.Nil(t, block.SaveValidatorSet(st, 2, []string{kpv.Address()}))
+	require.Equal(t, errors.ErrorDelegateNotValidator, ValidateOp(st, bas, op, nodeRunner.validatorSet()))
+
+	require.Nil(t, block.SaveValidatorSet(st, 3, []string{validator}))
+	require.Nil(t, ValidateOp(st, bas, op, nil))
+	require.Nil(t, finishOperation(st, tx, op, nil, nil, log))
+
+	saved, err := block.GetBlockAccount(st, kps.Address())
+	require.Nil(t, err)
+	require.Equal(t, validator, saved.Delegate)
+
+	require.Nil(t, nodeRunner.UpdateStakeWeights())
+	require.Equal(t, 1, nodeRunner.Policy().Weight(validator))
+	require.Equal(t, 1, nodeRunner.Policy().TotalWeight())
+
+	// the stake of validator itself is added
+	bav := block.NewBlockAccount(validator, common.Amount(2*common.AmountPerCoin))
+	require.Nil(t, bav.Save(st, nil))
+	require.Nil(t, nodeRunner.UpdateStakeWeights())
+	require.Equal(t, 3, nodeRunner.Policy().Weight(validator))
+	require.Equal(t, 3, nodeRunner.Policy().TotalWeight())
+
+	// self delegation
+	selfOp := transaction.Operation{
+		H: transaction.OperationHeader{Type: transaction.OperationDelegate},
+		B: transaction.NewOperationBodyDelegate(kps.Address()),
+	}
+	require.Equal(t, errors.ErrorSelfDelegation, ValidateOp(st, bas, selfOp, nil))
+}
+
+// Check the fee of transaction covers the fees of operations by
+// `transaction.DefaultFeePolicy`
+func TestValidateTxOperationFee(t *testing.T) {
+	defer func(policy transaction.FeePolicy) { transaction.DefaultFeePolicy = policy }(transaction.DefaultFeePolicy)
+	createAccountFee := common.BaseFee.MustMult(10)
+	transaction.DefaultFeePolicy = transaction.OperationFeePolicy{
+		Fees: map[transaction.OperationType]common.Amount{
+			transaction.OperationCreateAccount: createAccountFee,
+		},
+	}
+
+	kps, _ := keypair.Random()
+	kpt, _ := keypair.Random()
+	kpNew, _ := keypair.Random()
+
+	st := storage.NewTestStorage()
+	defer st.Close()
+	bas := block.BlockAccount{
+		Address: kps.Address(),
+		Balance: common.Amount(1 * common.AmountPerCoin),
+	}
+	bat := block.BlockAccount{
+		Address: kpt.Address(),
+		Balance: common.Amount(1 * common.AmountPerCoin),
+	}
+	bas.Save(st, nil)
+	bat.Save(st, nil)
+
+	amount := common.Amount(10000)
+	tx := transaction.Transaction{
+		T: "transaction",
+		H: transaction.TransactionHeader{
+			Created: common.NowISO8601(),
+		},
+		B: transaction.TransactionBody{
+			Source:     kps.Address(),
+			Fee:        common.BaseFee,
+			SequenceID: 0,
+			Operations: []transaction.Operation{
+				transaction.Operation{
+					H: transaction.OperationHeader{Type: transaction.OperationCreateAccount},
+					B: transaction.NewOperationBodyCreateAccount(kpNew.Address(), common.BaseReserve, ""),
+				},
+				transaction.Operation{
+					H: transaction.OperationHeader{Type: transaction.OperationPayment},
+					B: transaction.NewOperationBodyPayment(kpt.Address(), amount),
+				},
+			},
+		},
+	}
+	tx.H.Hash = tx.B.MakeHashString()
+
+	// `BaseFee` does not cover the fee of create-account
+	require.Equal(t, errors.ErrorInvalidFee, ValidateTx(st, tx, nil))
+
+	// 2 operations * 6 `BaseFee` covers 10 + 1 `BaseFee`
+	tx.B.Fee = common.BaseFee.MustMult(6)
+	tx.H.Hash = tx.B.MakeHashString()
+	require.Nil(t, ValidateTx(st, tx, nil))
+
+	// source is charged the fee of transaction
+	diff, err := SimulateTx(st, tx, nil)
+	require.Nil(t, err)
+	charged := common.BaseReserve.MustAdd(amount).MustAdd(tx.B.Fee.MustMult(2))
+	require.Equal(t, bas.Balance.MustSub(charged), diff[kps.Address()].BalanceAfter)
+
+	// the fee is over the balance
+	tx.B.Fee = bas.Balance
+	tx.H.Hash = tx.B.MakeHashString()
+	require.Equal(t, errors.ErrorTransactionExcessAbilityToPay, ValidateTx(st, tx, nil))
+}
+
+// The transaction by `Transaction.Finalize()` is valid without setting the
+// hash and signing manually.
+func TestValidateTxFinalized(t *testing.T) {
+	kps, _ := keypair.Random()
+	kpt, _ := keypair.Random()
+
+	st := storage.NewTestStorage()
+	defer st.Close()
+	bas := block.BlockAccount{
+		Address: kps.Address(),
+		Balance: common.Amount(1 * common.AmountPerCoin),
+	}
+	bat := block.BlockAccount{
+		Address: kpt.Address(),
+		Balance: common.Amount(1 * common.AmountPerCoin),
+	}
+	bas.Save(st, nil)
+	bat.Save(st, nil)
+
+	tx := transaction.Transaction{
+		T: "transaction",
+		B: transaction.TransactionBody{
+			Source:     kps.Address(),
+			Fee:        common.BaseFee,
+			SequenceID: 0,
+			Operations: []transaction.Operation{
+				transaction.Operation{
+					H: transaction.OperationHeader{Type: transaction.OperationPayment},
+					B: transaction.OperationBodyPayment{Target: kpt.Address(), Amount: common.Amount(10000)},
+				},
+			},
+		},
+	}
+	require.Nil(t, tx.Finalize(kps, networkID))
+	require.Nil(t, tx.IsWellFormed(networkID))
+	require.Nil(t, ValidateTx(st, tx, nil))
+}
+
+func TestFinishOperationUpdateEndpoint(t *testing.T) {
+	nodeRunner, localNode := MakeNodeRunner()
+	st := nodeRunner.Storage()
+
+	kpv, _ := keypair.Random()
+	oldEndpoint, _ := common.NewEndpoint("https://10.0.0.1:12345")
+	validator, _ := node.NewValidator(kpv.Address(), oldEndpoint, "")
+	require.Nil(t, localNode.AddValidators(validator))
+	validators := nodeRunner.validatorSet()
+
+	bav := block.NewBlockAccount(kpv.Address(), common.Amount(1*common.AmountPerCoin))
+	require.Nil(t, bav.Save(st, nil))
+
+	op := transaction.Operation{
+		H: transaction.OperationHeader{Type: transaction.OperationUpdateEndpoint},
+		B: transaction.NewOperationBodyUpdateEndpoint("https://10.0.0.2:23456"),
+	}
+	tx, _ := transaction.NewTransaction(kpv.Address(), 0, op)
+	require.Nil(t, ValidateOp(st, bav, op, validators))
+	require.Nil(t, finishOperation(st, tx, op, validators, nil, log))
+
+	saved, err := block.GetValidatorEndpoint(st, kpv.Address())
+	require.Nil(t, err)
+	require.Equal(t, "https://10.0.0.2:23456", saved.String())
+
+	// the connection manager picks up the new endpoint
+	require.Nil(t, nodeRunner.UpdateValidatorEndpoints())
+	require.Equal(t, "https://10.0.0.2:23456", validator.Endpoint().String())
+
+	// the account, which is not a validator, can not update the endpoint
+	kpo, _ := keypair.Random()
+	bao := block.NewBlockAccount(kpo.Address(), common.Amount(1*common.AmountPerCoin))
+	require.Nil(t, bao.Save(st, nil))
+	tx, _ = transaction.NewTransaction(kpo.Address(), 0, op)
+	require.Equal(t, errors.ErrorNotValidator, ValidateOp(st, bao, op, validators))
+	require.Equal(t, errors.ErrorNotValidator, finishOperation(st, tx, op, validators, nil, log))
 }
@@ -13,6 +13,8 @@
 package runner
 
 import (
+	"time"
+
 	logging "github.com/inconshreveable/log15"
 
 	"boscoin.io/sebak/lib/block"
@@ -20,7 +22,6 @@ import (
 	"boscoin.io/sebak/lib/error"
 	"boscoin.io/sebak/lib/node"
 	"boscoin.io/sebak/lib/transaction"
-	"encoding/json"
 )
 
 type MessageChecker struct {
@@ -41,7 +42,7 @@ func TransactionUnmarshal(c common.Checker, args ...interface{}) (err error) {
 	checker := c.(*MessageChecker)
 
 	var tx transaction.Transaction
-	if err = json.Unmarshal(checker.Message.Data, &tx); err != nil {
+	if tx, err = transaction.NewTransactionFromJSON(checker.Message.Data); err != nil {
 		return
 	}
 
@@ -97,7 +98,19 @@ func SaveTransactionHistory(c common.Checker, args ...interface{}) (err error) {
 func MessageHasSameSource(c common.Checker, args ...interface{}) (err error) {
 	checker := c.(*MessageChecker)
 
-	if checker.NodeRunner.Consensus().TransactionPool.IsSameSource(checker.Transaction.Source()) {
+	// under the nonce window, multiple transactions of same source are allowed
+	if common.SequenceIDWindow > 1 {
+		return
+	}
+
+	tp := checker.NodeRunner.Consensus().TransactionPool
+	if tp.IsSameSource(checker.Transaction.Source()) {
+		// the transaction with the same sequenceID can replace the pending
+		// one; the fee is checked in `PushIntoTransactionPool`
+		if _, found := tp.GetBySequenceID(checker.Transaction.Source(), checker.Transaction.B.SequenceID); found {
+			return
+		}
+
 		err = errors.ErrorTransactionSameSource
 		return
 	}
@@ -105,11 +118,28 @@ func MessageHasSameSource(c common.Checker, args ...interface{}) (err error) {
 	return
 }
 
-// MessageValidate validates.
+// MessageValidate validates. The amounts sent by the pending transactions of
+// the same source in `TransactionPool` are counted for the spend limit, except
+// the one which has the same sequenceID and can be replaced.
 func MessageValidate(c common.Checker, args ...interface{}) (err error) {
 	checker := c.(*MessageChecker)
 
-	if err = ValidateTx(checker.NodeRunner.Storage(), checker.Transaction); err != nil {
+	tx := checker.Transaction
+	state := newSourceState(time.Time{}, checker.NodeRunner.validatorSet())
+
+	tp := checker.NodeRunner.Consensus().TransactionPool
+	if tp.IsSameSource(tx.Source()) {
+		for _, pending := range tp.Pending(tx.Source()) {
+			if pending.B.SequenceID == tx.B.SequenceID {
+				continue
+			}
+			if state.sent, err = state.sent.Add(pending.TotalAmount(false)); err != nil {
+				return
+			}
+		}
+	}
+
+	if err = validateTx(checker.NodeRunner.Storage(), tx, state); err != nil {
 		return
 	}
 
@@ -123,7 +153,14 @@ func PushIntoTransactionPool(c common.Checker, args ...interface{}) (err error)
 
 	tx := checker.Transaction
 	is := checker.NodeRunner.Consensus()
-	is.TransactionPool.Add(tx)
+
+	var replaced string
+	if replaced, err = is.TransactionPool.AddOrReplace(tx, is.IsRunningTransaction); err != nil {
+		return
+	}
+	if len(replaced) > 0 {
+		checker.Log.Debug("transaction replaced the pending one", "replaced", replaced)
+	}
 
 	checker.Log.Debug("push transaction into transactionPool")
 
@@ -99,3 +99,77 @@ func TestMessageCheckerWithInvalidHash(t *testing.T) {
 	require.EqualError(t, err, "unexpected end of JSON input")
 	require.NotEqual(t, checker.Transaction, invalidTx)
 }
+
+func TestMessageCheckerWithTooManyOperations(t *testing.T) {
+	_, tx := transaction.TestMakeTransaction(networkID, 2000)
+
+	b, err := tx.Serialize()
+	require.Nil(t, err)
+
+	nodeRunner, localNode := MakeNodeRunner()
+	checker := &MessageChecker{
+		DefaultChecker: common.DefaultChecker{},
+		NodeRunner:     nodeRunner,
+		LocalNode:      localNode,
+		NetworkID:      networkID,
+		Message:        common.NetworkMessage{Type: common.TransactionMessage, Data: b},
+	}
+
+	err = TransactionUnmarshal(checker)
+	require.Equal(t, errors.ErrorTooManyOperations, err)
+
+	// the broken tail of document is not decoded
+	checker.Message = common.NetworkMessage{Type: common.TransactionMessage, Data: b[:len(b)-10]}
+	err = TransactionUnmarshal(checker)
+	require.Equal(t, errors.ErrorTooManyOperations, err)
+}
+
+func TestMessageCheckerReplaceByFee(t *testing.T) {
+	kp, pendingTx := transaction.TestMakeTransaction(networkID, 1)
+
+	nodeRunner, localNode := MakeNodeRunner()
+	tp := nodeRunner.Consensus().TransactionPool
+	require.True(t, tp.Add(pendingTx))
+
+	newChecker := func(fee common.Amount) *MessageChecker {
+		tx := pendingTx
+		tx.B.Fee = fee
+		tx.Sign(kp, networkID)
+
+		b, err := tx.Serialize()
+		require.Nil(t, err)
+
+		checker := &MessageChecker{
+			DefaultChecker: common.DefaultChecker{},
+			NodeRunner:     nodeRunner,
+			LocalNode:      localNode,
+			NetworkID:      networkID,
+			Message:        common.NetworkMessage{Type: common.TransactionMessage, Data: b},
+		}
+		require.Nil(t, TransactionUnmarshal(checker))
+
+		return checker
+	}
+
+	{ // insufficient fee bump
+		checker := newChecker(pendingTx.B.Fee + 1)
+		require.Nil(t, MessageHasSameSource(checker))
+		require.Equal(t, errors.ErrorTransactionReplacementUnderpriced, PushIntoTransactionPool(checker))
+		require.True(t, tp.Has(pendingTx.GetHash()))
+	}
+
+	{ // replaced
+		checker := newChecker(pendingTx.B.Fee.MustMult(2))
+		require.Nil(t, MessageHasSameSource(checker))
+		require.Nil(t, PushIntoTransactionPool(checker))
+		require.False(t, tp.Has(pendingTx.GetHash()))
+		require.True(t, tp.Has(checker.Transaction.GetHash()))
+	}
+
+	{ // different sequenceID from same source
+		checker := newChecker(pendingTx.B.Fee)
+		checker.Transaction.B.SequenceID++
+		checker.Transaction.Sign(kp, networkID)
+		require.Equal(t, errors.ErrorTransactionSameSource, MessageHasSameSource(checker))
+	}
+}
@@ -45,7 +45,7 @@ func TestOnlyValidTransactionInTransactionPool(t *testing.T) {
 
 	{ // valid transaction
 		targetAccount, targetKP := TestMakeBlockAccount(common.Amount(10000000000000) /* 100,00000 BOS */)
-		targetAccount.Save(nodeRunner.Storage())
+		targetAccount.Save(nodeRunner.Storage(), nil)
 
 		tx := transaction.TestMakeTransactionWithKeypair(networkID, 1, rootKP, targetKP)
 		tx.B.SequenceID = rootAccount.SequenceID
@@ -58,7 +58,7 @@ func TestOnlyValidTransactionInTransactionPool(t *testing.T) {
 
 	{ // invalid transaction: same source already in TransactionPool
 		targetAccount, targetKP := TestMakeBlockAccount(common.Amount(10000000000000))
-		targetAccount.Save(nodeRunner.Storage())
+		targetAccount.Save(nodeRunner.Storage(), nil)
 
 		tx := transaction.TestMakeTransactionWithKeypair(networkID, 1, rootKP, targetKP)
 		tx.B.SequenceID = rootAccount.SequenceID
@@ -76,7 +76,7 @@ func TestOnlyValidTransactionInTransactionPool(t *testing.T) {
 	{ // invalid transaction: source account does not exists
 		_, sourceKP := TestMakeBlockAccount(common.Amount(10000000000000))
 		targetAccount, targetKP := TestMakeBlockAccount(common.Amount(10000000000000))
-		targetAccount.Save(nodeRunner.Storage())
+		targetAccount.Save(nodeRunner.Storage(), nil)
 
 		tx := transaction.TestMakeTransactionWithKeypair(networkID, 1, sourceKP, targetKP)
 
@@ -92,7 +92,7 @@ func TestOnlyValidTransactionInTransactionPool(t *testing.T) {
 	{ // invalid transaction: target account does not exists
 		sourceAccount, sourceKP := TestMakeBlockAccount(common.Amount(10000000000000))
 		_, targetKP := TestMakeBlockAccount(common.Amount(10000000000000))
-		sourceAccount.Save(nodeRunner.Storage())
+		sourceAccount.Save(nodeRunner.Storage(), nil)
 
 		tx := transaction.TestMakeTransactionWithKeypair(networkID, 1, sourceKP, targetKP)
 		tx.B.SequenceID = sourceAccount.SequenceID
@@ -0,0 +1,137 @@
+package runner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"boscoin.io/sebak/lib/ballot"
+	"boscoin.io/sebak/lib/block"
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/common/observer"
+	"boscoin.io/sebak/lib/consensus"
+	"boscoin.io/sebak/lib/consensus/round"
+)
+
+// TestConsensusObserverBallotConfirmed checks that `EventConsensusBallotConfirmed`
+// is triggered with the new block when the transaction is confirmed.
+func TestConsensusObserverBallotConfirmed(t *testing.T) {
+	nr, nodes, _ := createNodeRunnerForTesting(5, consensus.NewISAACConfiguration(), nil)
+	tx, txByte := GetTransaction(t)
+
+	proposer := nr.localNode
+	nr.Consensus().SetLatestConsensusedBlock(genesisBlock)
+
+	confirmed := make(chan block.Block, 1)
+	onConfirmed := func(address string, b block.Block) {
+		if address != proposer.Address() {
+			return
+		}
+		select {
+		case confirmed <- b:
+		default:
+		}
+	}
+	observer.ConsensusObserver.On(EventConsensusBallotConfirmed, onConfirmed)
+	defer observer.ConsensusObserver.Off(EventConsensusBallotConfirmed, onConfirmed)
+
+	message := common.NetworkMessage{Type: common.TransactionMessage, Data: txByte}
+	err := nr.handleTransaction(message)
+	require.Nil(t, err)
+
+	roundNumber := uint64(0)
+	err = nr.proposeNewBallot(roundNumber)
+	require.Nil(t, err)
+
+	latest := nr.Consensus().LatestConfirmedBlock()
+	round := round.Round{
+		Number:      roundNumber,
+		BlockHeight: latest.Height,
+		BlockHash:   latest.Hash,
+		TotalTxs:    latest.TotalTxs,
+	}
+
+	for _, n := range nodes[1:] {
+		b := GenerateBallot(t, proposer, round, tx, ballot.StateSIGN, n)
+		err = ReceiveBallot(t, nr, b)
+		require.Nil(t, err)
+	}
+	require.Equal(t, 0, len(confirmed))
+
+	for _, n := range nodes[1:] {
+		b := GenerateBallot(t, proposer, round, tx, ballot.StateACCEPT, n)
+		err = ReceiveBallot(t, nr, b)
+	}
+	_, ok := err.(CheckerStopCloseConsensus)
+	require.True(t, ok)
+
+	select {
+	case b := <-confirmed:
+		require.Equal(t, latest.Height+1, b.Height)
+		require.Equal(t, nr.Consensus().LatestConfirmedBlock().Hash, b.Hash)
+		require.Equal(t, tx.GetHash(), b.Transactions[0])
+	case <-time.After(time.Second):
+		require.Fail(t, "ballot-confirmed event was not triggered")
+	}
+}
+
+// TestConsensusObserverRoundOpen checks that `EventConsensusRoundOpen` and
+// `EventConsensusStateChange` are triggered when `ISAACStateManager` starts.
+func TestConsensusObserverRoundOpen(t *testing.T) {
+	conf := consensus.NewISAACConfiguration()
+	conf.TimeoutINIT = time.Hour
+	conf.TimeoutSIGN = time.Hour
+	conf.TimeoutACCEPT = time.Hour
+
+	recv := make(chan struct{})
+	nr, _, _ := createNodeRunnerForTesting(3, conf, recv)
+	nr.Consensus().SetLatestConsensusedBlock(genesisBlock)
+
+	opened := make(chan consensus.ISAACState, 1)
+	onOpen := func(address string, state consensus.ISAACState) {
+		if address != nr.localNode.Address() {
+			return
+		}
+		select {
+		case opened <- state:
+		default:
+		}
+	}
+	changed := make(chan consensus.ISAACState, 10)
+	onChange := func(address string, state consensus.ISAACState) {
+		if address != nr.localNode.Address() {
+			return
+		}
+		select {
+		case changed <- state:
+		default:
+		}
+	}
+	observer.ConsensusObserver.On(EventConsensusRoundOpen, onOpen)
+	defer observer.ConsensusObserver.Off(EventConsensusRoundOpen, onOpen)
+	observer.ConsensusObserver.On(EventConsensusStateChange, onChange)
+	defer observer.ConsensusObserver.Off(EventConsensusStateChange, onChange)
+
+	nr.StartStateManager()
+	defer nr.StopStateManager()
+
+	<-recv
+
+	select {
+	case state := <-opened:
+		require.Equal(t, uint64(1), state.Round.BlockHeight)
+		require.Equal(t, uint64(0), state.Round.Number)
+		require.Equal(t, ballot.StateINIT, state.BallotState)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "round-open event was not triggered")
+	}
+
+	select {
+	case state := <-changed:
+		// proposer proposes the ballot and goes to SIGN
+		require.Equal(t, ballot.StateSIGN, state.BallotState)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "state-change event was not triggered")
+	}
+}
@@ -4,6 +4,22 @@ import (
 	"time"
 )
 
+// The events of `observer.ConsensusObserver`. The first argument of event is
+// the address of node.
+const (
+	// EventConsensusRoundOpen is triggered with `consensus.ISAACState` when
+	// new round is opened.
+	EventConsensusRoundOpen string = "consensus-round-open"
+
+	// EventConsensusBallotConfirmed is triggered with the stored
+	// `block.Block` when the ballot is confirmed.
+	EventConsensusBallotConfirmed string = "consensus-ballot-confirmed"
+
+	// EventConsensusStateChange is triggered with `consensus.ISAACState`
+	// when the `ISAACState` of node is changed.
+	EventConsensusStateChange string = "consensus-state-change"
+)
+
 var (
 	// TimeoutProposeNewBallot works when the consensus process is finished for
 	// a proposed `Ballot`, proposer will wait for `TimeoutProposeNewBallot` and
@@ -18,4 +34,9 @@ var (
 
 	// TimeoutExpireRound works if running `Round` is expired for consensus.
 	TimeoutExpireRound time.Duration = time.Second * 10
+
+	// MaxConsensusLag is the maximum duration since the latest confirmed
+	// block. If the node has not confirmed new block within it, the node is
+	// not ready; see `NodeReadyzHandler`. `0` disables the check.
+	MaxConsensusLag time.Duration = time.Minute
 )
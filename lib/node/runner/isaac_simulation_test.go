@@ -7,13 +7,19 @@ package runner
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
 	"boscoin.io/sebak/lib/ballot"
+	blk "boscoin.io/sebak/lib/block"
 	"boscoin.io/sebak/lib/common"
 	"boscoin.io/sebak/lib/consensus"
 	"boscoin.io/sebak/lib/consensus/round"
+	"boscoin.io/sebak/lib/error"
+	"boscoin.io/sebak/lib/network"
+	"boscoin.io/sebak/lib/storage"
+	"boscoin.io/sebak/lib/transaction"
 )
 
 /*
@@ -102,4 +108,202 @@ func TestISAACSimulationProposer(t *testing.T) {
 	require.Equal(t, proposer.Address(), block.Proposer)
 	require.Equal(t, 1, len(block.Transactions))
 	require.Equal(t, tx.GetHash(), block.Transactions[0])
+
+	// the operation log has the genesis operation and the operation of the
+	// confirmed transaction by the applied order
+	var entries []blk.OpLogEntry
+	iterFunc, closeFunc := blk.GetOpLog(nr.Storage(), storage.NewDefaultListOptions(false, nil, 0))
+	for {
+		e, hasNext, _ := iterFunc()
+		if !hasNext {
+			break
+		}
+		entries = append(entries, e)
+	}
+	closeFunc()
+
+	require.Equal(t, 2, len(entries))
+	require.Equal(t, genesisBlock.Height, entries[0].BlockHeight)
+	require.Equal(t, block.Height, entries[1].BlockHeight)
+	require.Equal(t, tx.GetHash(), entries[1].TxHash)
+	require.Equal(t, tx.B.Operations[0].H.Type, entries[1].Type)
+
+	// the validators of local node are recorded at the height of block
+	validators, err := blk.GetValidatorSetAtHeight(nr.Storage(), block.Height)
+	require.Nil(t, err)
+	require.Equal(t, 5, len(validators))
+	require.Contains(t, validators, proposer.Address())
+}
+
+// The same transaction and ballots received again are dropped before they are
+// handled, and the consensus goes on with the first ones.
+func TestISAACSimulationDropSeenMessages(t *testing.T) {
+	nr, nodes, _ := createNodeRunnerForTesting(5, consensus.NewISAACConfiguration(), nil)
+	tx, txByte := GetTransaction(t)
+
+	proposer := nr.localNode
+	nr.Consensus().SetLatestConsensusedBlock(genesisBlock)
+
+	{ // the rejected message is not marked as seen
+		_, unknown := transaction.TestMakeTransaction(networkID, 1)
+		data, err := unknown.Serialize()
+		require.Nil(t, err)
+
+		rejected := common.NetworkMessage{Type: common.TransactionMessage, Data: data}
+		nr.handleMessage(rejected)
+		nr.handleMessage(rejected)
+		require.False(t, nr.Consensus().TransactionPool.Has(unknown.GetHash()))
+		require.Equal(t, uint64(0), nr.DroppedSeenMessages())
+	}
+
+	message := common.NetworkMessage{Type: common.TransactionMessage, Data: txByte}
+	nr.handleMessage(message)
+	require.True(t, nr.Consensus().TransactionPool.Has(tx.GetHash()))
+	require.Equal(t, uint64(0), nr.DroppedSeenMessages())
+
+	nr.handleMessage(message)
+	require.Equal(t, uint64(1), nr.DroppedSeenMessages())
+	require.Equal(t, 1, nr.Consensus().TransactionPool.Len())
+
+	roundNumber := uint64(0)
+	require.Nil(t, nr.proposeNewBallot(roundNumber))
+
+	b := nr.Consensus().LatestConfirmedBlock()
+	round := round.Round{
+		Number:      roundNumber,
+		BlockHeight: b.Height,
+		BlockHash:   b.Hash,
+		TotalTxs:    b.TotalTxs,
+	}
+	rr := nr.Consensus().RunningRounds[round.Hash()]
+
+	receiveTwice := func(state ballot.State, from int) {
+		blt := GenerateBallot(t, proposer, round, tx, state, nodes[from])
+		data, err := blt.Serialize()
+		require.Nil(t, err)
+
+		ballotMessage := common.NetworkMessage{Type: common.BallotMessage, Data: data}
+		nr.handleMessage(ballotMessage)
+		nr.handleMessage(ballotMessage)
+	}
+
+	for i := 1; i < len(nodes); i++ {
+		receiveTwice(ballot.StateSIGN, i)
+	}
+	require.Equal(t, 4, len(rr.Voted[proposer.Address()].GetResult(ballot.StateSIGN)))
+
+	for i := 1; i < len(nodes); i++ {
+		receiveTwice(ballot.StateACCEPT, i)
+	}
+	require.Equal(t, uint64(1+8), nr.DroppedSeenMessages())
+
+	block := nr.Consensus().LatestConfirmedBlock()
+	require.Equal(t, genesisBlock.Height+1, block.Height)
+	require.Equal(t, []string{tx.GetHash()}, block.Transactions)
+}
+
+/*
+TestISAACSimulationReorderedBallots indicates the following:
+	1. The node is the proposer of the round; there are 5 nodes and threshold is 4.
+	2. The ballots of the other four validators are delivered through
+	   `network.MemoryNetwork`, which delivers the ACCEPT ballots before the
+	   SIGN ballots.
+	3. The block is confirmed by the ACCEPT ballots, which exceed the
+	   threshold, and the late SIGN ballots are rejected, because the ballot
+	   is already finished.
+	4. The same result is reproduced in every run.
+*/
+func TestISAACSimulationReorderedBallots(t *testing.T) {
+	acceptFirst := func(messages []common.NetworkMessage) []common.NetworkMessage {
+		var ordered []common.NetworkMessage
+		for _, state := range []ballot.State{ballot.StateACCEPT, ballot.StateSIGN} {
+			for _, message := range messages {
+				b, err := ballot.NewBallotFromJSON(message.Data)
+				require.Nil(t, err)
+				if b.State() == state {
+					ordered = append(ordered, message)
+				}
+			}
+		}
+		return ordered
+	}
+
+	type received struct {
+		state ballot.State
+		err   error
+	}
+
+	run := func() (results []received) {
+		nr, nodes, _ := createNodeRunnerForTesting(5, consensus.NewISAACConfiguration(), nil)
+		tx, txByte := GetTransaction(t)
+
+		proposer := nr.localNode
+		nr.Consensus().SetLatestConsensusedBlock(genesisBlock)
+
+		require.Nil(t, nr.handleTransaction(common.NetworkMessage{Type: common.TransactionMessage, Data: txByte}))
+		require.Nil(t, nr.proposeNewBallot(0))
+
+		b := nr.Consensus().LatestConfirmedBlock()
+		round := round.Round{
+			Number:      0,
+			BlockHeight: b.Height,
+			BlockHash:   b.Hash,
+			TotalTxs:    b.TotalTxs,
+		}
+
+		_, mn, _ := network.CreateMemoryNetwork(nil)
+		mn.SetDeliveryDelay(network.DeliveryManual)
+		mn.SetDeliveryScheduler(acceptFirst)
+		go mn.Start()
+		defer mn.Stop()
+
+		client := mn.GetClient(mn.Endpoint())
+		for _, state := range []ballot.State{ballot.StateSIGN, ballot.StateACCEPT} {
+			for i := 1; i < len(nodes); i++ {
+				_, err := client.SendBallot(GenerateBallot(t, proposer, round, tx, state, nodes[i]))
+				require.Nil(t, err)
+			}
+		}
+
+		done := make(chan int)
+		go func() { done <- mn.FlushPending() }()
+
+		for i := 0; i < 8; i++ {
+			select {
+			case message := <-mn.ReceiveMessage():
+				b, err := ballot.NewBallotFromJSON(message.Data)
+				require.Nil(t, err)
+
+				err = nr.handleBallotMessage(message)
+				if _, ok := err.(CheckerStopCloseConsensus); ok {
+					// the block is confirmed
+					confirmed := nr.Consensus().LatestConfirmedBlock()
+					require.Equal(t, genesisBlock.Height+1, confirmed.Height)
+					require.Equal(t, []string{tx.GetHash()}, confirmed.Transactions)
+					err = nil
+				}
+				results = append(results, received{state: b.State(), err: err})
+			case <-time.After(time.Second):
+				t.Fatal("failed to get ballot")
+			}
+		}
+		require.Equal(t, 8, <-done)
+
+		confirmed := nr.Consensus().LatestConfirmedBlock()
+		require.Equal(t, genesisBlock.Height+1, confirmed.Height)
+
+		return
+	}
+
+	var expected []received
+	for i := 0; i < 4; i++ {
+		expected = append(expected, received{state: ballot.StateACCEPT})
+	}
+	for i := 0; i < 4; i++ {
+		expected = append(expected, received{state: ballot.StateSIGN, err: errors.ErrorBallotAlreadyFinished})
+	}
+
+	for i := 0; i < 2; i++ {
+		require.Equal(t, expected, run())
+	}
 }
@@ -7,6 +7,7 @@ import (
 	"boscoin.io/sebak/lib/ballot"
 	"boscoin.io/sebak/lib/block"
 	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/common/observer"
 	"boscoin.io/sebak/lib/consensus"
 	"boscoin.io/sebak/lib/consensus/round"
 )
@@ -23,6 +24,7 @@ type ISAACStateManager struct {
 	blockTimeBuffer time.Duration // the time to wait to adjust the block creation time.
 	transitSignal   func()        // the function is called when the ISAACState is changed.
 	genesis         time.Time     // the time at which the GenesisBlock was saved. It is used for calculating `blockTimeBuffer`.
+	roundOpened     time.Time     // the time at which the current round was opened.
 
 	Conf *consensus.ISAACConfiguration
 }
@@ -216,7 +218,7 @@ func (sm *ISAACStateManager) broadcastExpiredBallot(state consensus.ISAACState)
 	newExpiredBallot.Sign(sm.nr.localNode.Keypair(), sm.nr.networkID)
 
 	sm.nr.Log().Debug("broadcast", "ballot", *newExpiredBallot)
-	sm.nr.ConnectionManager().Broadcast(*newExpiredBallot)
+	sm.nr.BroadcastBallot(*newExpiredBallot)
 }
 
 func (sm *ISAACStateManager) resetTimer(timer *time.Timer, state ballot.State) {
@@ -235,6 +237,8 @@ func (sm *ISAACStateManager) resetTimer(timer *time.Timer, state ballot.State) {
 // but if not, it waits for receiving ballot from the other proposer.
 func (sm *ISAACStateManager) proposeOrWait(timer *time.Timer, state consensus.ISAACState) {
 	timer.Reset(time.Duration(1 * time.Hour))
+	observer.ConsensusObserver.Trigger(EventConsensusRoundOpen, sm.nr.localNode.Address(), state)
+
 	proposer := sm.nr.Consensus().SelectProposer(state.Round.BlockHeight, state.Round.Number)
 	log.Debug("selected proposer", "proposer", proposer)
 
@@ -265,20 +269,42 @@ func (sm *ISAACStateManager) State() consensus.ISAACState {
 	return sm.state
 }
 
+// RoundState returns the current `ISAACState` and the time at which the
+// round of the state was opened.
+func (sm *ISAACStateManager) RoundState() (consensus.ISAACState, time.Time) {
+	sm.RLock()
+	defer sm.RUnlock()
+	return sm.state, sm.roundOpened
+}
+
 func (sm *ISAACStateManager) setState(state consensus.ISAACState) {
 	sm.Lock()
-	defer sm.Unlock()
 	sm.nr.Log().Debug("begin ISAACStateManager.setState()", "state", state)
+	changed := sm.state != state
+	if sm.state.Round != state.Round {
+		sm.roundOpened = time.Now()
+	}
 	sm.state = state
+	sm.Unlock()
+
+	if changed {
+		observer.ConsensusObserver.Trigger(EventConsensusStateChange, sm.nr.localNode.Address(), state)
+	}
 
 	return
 }
 
 func (sm *ISAACStateManager) setBallotState(ballotState ballot.State) {
 	sm.Lock()
-	defer sm.Unlock()
 	sm.nr.Log().Debug("begin ISAACStateManager.setBallotState()", "ballotState", ballotState)
+	changed := sm.state.BallotState != ballotState
 	sm.state.BallotState = ballotState
+	state := sm.state
+	sm.Unlock()
+
+	if changed {
+		observer.ConsensusObserver.Trigger(EventConsensusStateChange, sm.nr.localNode.Address(), state)
+	}
 
 	return
 }
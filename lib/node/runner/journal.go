@@ -0,0 +1,76 @@
+package runner
+
+import (
+	"boscoin.io/sebak/lib/block"
+	"boscoin.io/sebak/lib/error"
+	"boscoin.io/sebak/lib/storage"
+	"boscoin.io/sebak/lib/transaction"
+)
+
+// ReplayJournal rebuilds the `BlockAccount` state in `st` by applying the
+// transactions of the journal by the appended order. `st` should not have
+// the accounts yet; the genesis transaction creates the genesis account like
+// `block.MakeGenesisBlock()` does and the others are applied like
+// `finishBallot()` does with `validators`, the validators configured to the
+// local node. The accounts are committed only when the whole journal is
+// replayed. The replayed accounts are not added to the account filter; the
+// filter of `st` is built from the accounts by `block.LoadAccountFilter`.
+func ReplayJournal(journal *block.TransactionJournal, st *storage.LevelDBBackend, validators []string) (err error) {
+	var ts *storage.LevelDBBackend
+	if ts, err = st.OpenTransaction(); err != nil {
+		return
+	}
+
+	iterFunc, closeFunc := journal.Entries(storage.NewDefaultListOptions(false, nil, 0))
+	defer closeFunc()
+
+	for {
+		entry, hasNext, iterErr := iterFunc()
+		if iterErr != nil {
+			err = iterErr
+			ts.Discard()
+			return
+		}
+		if !hasNext {
+			break
+		}
+
+		if entry.BlockHeight == 1 {
+			err = replayGenesisTransaction(ts, entry.Transaction)
+		} else {
+			err = finishTransaction(ts, entry.Transaction, validators, nil, log)
+		}
+		if err != nil {
+			log.Error(
+				"failed to replay journal",
+				"sequence", entry.Sequence,
+				"height", entry.BlockHeight,
+				"index", entry.Index,
+				"error", err,
+			)
+			ts.Discard()
+			return
+		}
+	}
+
+	if err = ts.Commit(); err != nil {
+		ts.Discard()
+	}
+
+	return
+}
+
+func replayGenesisTransaction(st *storage.LevelDBBackend, tx transaction.Transaction) (err error) {
+	if len(tx.B.Operations) != 1 {
+		return errors.ErrorUnknownOperationType
+	}
+	op, ok := tx.B.Operations[0].B.(transaction.OperationBodyCreateAccount)
+	if !ok {
+		return errors.ErrorUnknownOperationType
+	}
+
+	ba := block.NewBlockAccount(op.TargetAddress(), op.GetAmount())
+	ba.SequenceID = tx.B.SequenceID
+
+	return ba.Save(st, nil)
+}
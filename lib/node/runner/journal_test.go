@@ -0,0 +1,218 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stretchr/testify/require"
+
+	"boscoin.io/sebak/lib/ballot"
+	"boscoin.io/sebak/lib/block"
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/consensus/round"
+	"boscoin.io/sebak/lib/error"
+	"boscoin.io/sebak/lib/storage"
+	"boscoin.io/sebak/lib/transaction"
+)
+
+func TestReplayJournal(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	genesisKP, _ := keypair.Random()
+	targetKP, _ := keypair.Random()
+
+	genesisAccount := block.NewBlockAccount(genesisKP.Address(), common.BaseReserve.MustMult(100))
+	require.Nil(t, genesisAccount.Save(st, nil))
+	latest, err := block.MakeGenesisBlock(st, *genesisAccount, networkID, genesisKP)
+	require.Nil(t, err)
+
+	pool := transaction.NewTransactionPool()
+	filter := block.NewAccountFilter()
+	confirm := func(ops ...transaction.Operation) {
+		source, err := block.GetBlockAccount(st, genesisKP.Address())
+		require.Nil(t, err)
+
+		tx, err := transaction.NewTransaction(source.Address, source.SequenceID, ops...)
+		require.Nil(t, err)
+		tx.Sign(genesisKP, networkID)
+		pool.Add(tx)
+
+		r := round.Round{
+			BlockHeight: latest.Height,
+			BlockHash:   latest.Hash,
+			TotalTxs:    latest.TotalTxs,
+		}
+		b := ballot.NewBallot(genesisKP.Address(), r, []string{tx.GetHash()})
+		b.SetVote(ballot.StateINIT, ballot.VotingYES)
+		b.Sign(genesisKP, networkID)
+		latest, err = finishBallot(st, *b, pool, nil, filter, false, log, log)
+		require.Nil(t, err)
+	}
+
+	confirm(transaction.Operation{
+		H: transaction.OperationHeader{Type: transaction.OperationCreateAccount},
+		B: transaction.NewOperationBodyCreateAccount(targetKP.Address(), common.BaseReserve.MustMult(2), ""),
+	})
+	confirm(transaction.Operation{
+		H: transaction.OperationHeader{Type: transaction.OperationPayment},
+		B: transaction.NewOperationBodyPayment(targetKP.Address(), common.Amount(1000)),
+	})
+
+	// the created account is added to the account filter of the storage
+	require.True(t, filter.MightHaveAccount(targetKP.Address()))
+
+	{ // the journal has the genesis transaction and the confirmed ones
+		var entries []block.TransactionJournalEntry
+		iterFunc, closeFunc := block.NewTransactionJournal(st).Entries(storage.NewDefaultListOptions(false, nil, 0))
+		for {
+			entry, hasNext, err := iterFunc()
+			require.Nil(t, err)
+			if !hasNext {
+				break
+			}
+			entries = append(entries, entry)
+		}
+		closeFunc()
+
+		require.Equal(t, 3, len(entries))
+		for i, entry := range entries {
+			require.Equal(t, uint64(i+1), entry.Sequence)
+			require.Equal(t, uint64(i+1), entry.BlockHeight)
+			require.Equal(t, 0, entry.Index)
+		}
+	}
+
+	replayed := storage.NewTestStorage()
+	defer replayed.Close()
+
+	require.Nil(t, ReplayJournal(block.NewTransactionJournal(st), replayed, nil))
+
+	for _, address := range []string{genesisKP.Address(), targetKP.Address()} {
+		expected, err := block.GetBlockAccount(st, address)
+		require.Nil(t, err)
+		actual, err := block.GetBlockAccount(replayed, address)
+		require.Nil(t, err)
+
+		require.Equal(t, expected.Balance, actual.Balance)
+		require.Equal(t, expected.SequenceID, actual.SequenceID)
+	}
+}
+
+// The ballot, which has the transaction missing in the pool, is not finished
+// and nothing is stored.
+func TestFinishBallotMissingTransaction(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	genesisKP, _ := keypair.Random()
+	genesisAccount := block.NewBlockAccount(genesisKP.Address(), common.BaseReserve.MustMult(100))
+	require.Nil(t, genesisAccount.Save(st, nil))
+	genesis, err := block.MakeGenesisBlock(st, *genesisAccount, networkID, genesisKP)
+	require.Nil(t, err)
+
+	tx, _ := transaction.NewTransaction(genesisKP.Address(), 0, transaction.Operation{
+		H: transaction.OperationHeader{Type: transaction.OperationPayment},
+		B: transaction.NewOperationBodyPayment(genesisKP.Address(), common.Amount(1000)),
+	})
+	tx.Sign(genesisKP, networkID)
+
+	r := round.Round{BlockHeight: genesis.Height, BlockHash: genesis.Hash, TotalTxs: genesis.TotalTxs}
+	b := ballot.NewBallot(genesisKP.Address(), r, []string{tx.GetHash()})
+	b.SetVote(ballot.StateINIT, ballot.VotingYES)
+	b.Sign(genesisKP, networkID)
+
+	_, err = finishBallot(st, *b, transaction.NewTransactionPool(), nil, nil, false, log, log)
+	require.NotNil(t, err)
+	require.Equal(t, errors.ErrorTransactionNotFound.Code, err.(*errors.Error).Code)
+
+	latest, err := block.GetLatestBlock(st)
+	require.Nil(t, err)
+	require.Equal(t, genesis.Hash, latest.Hash)
+
+	// the storage is not locked by the unfinished transaction
+	ts, err := st.OpenTransaction()
+	require.Nil(t, err)
+	require.Nil(t, ts.Discard())
+}
+
+// The validator set is recorded at the height of the block only when it is
+// changed. It is kept after the block is rolled back, but it is not used
+// above the latest block.
+func TestFinishBallotValidatorSet(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	genesisKP, _ := keypair.Random()
+	genesisAccount := block.NewBlockAccount(genesisKP.Address(), common.BaseReserve.MustMult(100))
+	require.Nil(t, genesisAccount.Save(st, nil))
+	latest, err := block.MakeGenesisBlock(st, *genesisAccount, networkID, genesisKP)
+	require.Nil(t, err)
+
+	kpv, _ := keypair.Random()
+	finish := func(validators []string) {
+		r := round.Round{BlockHeight: latest.Height, BlockHash: latest.Hash, TotalTxs: latest.TotalTxs}
+		b := ballot.NewBallot(genesisKP.Address(), r, []string{})
+		b.SetVote(ballot.StateINIT, ballot.VotingYES)
+		b.Sign(genesisKP, networkID)
+		latest, err = finishBallot(st, *b, transaction.NewTransactionPool(), validators, nil, false, log, log)
+		require.Nil(t, err)
+	}
+
+	finish([]string{genesisKP.Address()})
+	finish([]string{genesisKP.Address()})
+	finish([]string{genesisKP.Address(), kpv.Address()})
+	require.Equal(t, uint64(4), latest.Height)
+
+	for height, expected := range map[uint64]bool{2: true, 3: false, 4: true} {
+		exists, err := st.Has(block.GetValidatorSetDiffKey(height))
+		require.Nil(t, err)
+		require.Equal(t, expected, exists, "height=%d", height)
+	}
+
+	isValidator, err := block.IsValidator(st, kpv.Address(), nil)
+	require.Nil(t, err)
+	require.True(t, isValidator)
+
+	require.Nil(t, block.RollbackBlock(st, 4))
+	exists, err := st.Has(block.GetValidatorSetDiffKey(4))
+	require.Nil(t, err)
+	require.True(t, exists)
+
+	isValidator, err = block.IsValidator(st, kpv.Address(), nil)
+	require.Nil(t, err)
+	require.False(t, isValidator)
+}
+
+// The block, which has the transaction not given, is refused and nothing is
+// stored.
+func TestFinishBlockMissingTransaction(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	genesisKP, _ := keypair.Random()
+	genesisAccount := block.NewBlockAccount(genesisKP.Address(), common.BaseReserve.MustMult(100))
+	require.Nil(t, genesisAccount.Save(st, nil))
+	genesis, err := block.MakeGenesisBlock(st, *genesisAccount, networkID, genesisKP)
+	require.Nil(t, err)
+
+	tx, _ := transaction.NewTransaction(genesisKP.Address(), 0, transaction.Operation{
+		H: transaction.OperationHeader{Type: transaction.OperationPayment},
+		B: transaction.NewOperationBodyPayment(genesisKP.Address(), common.Amount(1000)),
+	})
+	tx.Sign(genesisKP, networkID)
+
+	r := round.Round{BlockHeight: genesis.Height, BlockHash: genesis.Hash, TotalTxs: genesis.TotalTxs}
+	blk := block.NewBlock(genesisKP.Address(), r, []string{tx.GetHash()}, common.NowISO8601())
+
+	ts, err := st.OpenTransaction()
+	require.Nil(t, err)
+	err = finishBlock(ts, blk, nil, nil, nil, nil, false, log)
+	require.NotNil(t, err)
+	require.Equal(t, errors.ErrorTransactionNotFound.Code, err.(*errors.Error).Code)
+	require.Nil(t, ts.Discard())
+
+	exists, err := block.ExistsBlock(st, blk.Hash)
+	require.Nil(t, err)
+	require.False(t, exists)
+}
@@ -9,6 +9,9 @@ package runner
 
 import (
 	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	logging "github.com/inconshreveable/log15"
@@ -23,6 +26,7 @@ import (
 	"boscoin.io/sebak/lib/network/api"
 	"boscoin.io/sebak/lib/node"
 	"boscoin.io/sebak/lib/storage"
+	"boscoin.io/sebak/lib/transaction"
 )
 
 var DefaultHandleTransactionCheckerFuncs = []common.CheckerFunc{
@@ -76,6 +80,13 @@ type NodeRunner struct {
 	connectionManager network.ConnectionManager
 	storage           *storage.LevelDBBackend
 	isaacStateManager *ISAACStateManager
+	acceptance        *transactionAcceptance
+
+	// consensusLock is held while the ballot is handled; the consensus is
+	// paused by holding it, see `resyncBlocks`.
+	consensusLock sync.Mutex
+	// resyncing is 1 while the blocks are resynced; see `startResync`.
+	resyncing uint32
 
 	handleTransactionCheckerFuncs  []common.CheckerFunc
 	handleBaseBallotCheckerFuncs   []common.CheckerFunc
@@ -86,6 +97,16 @@ type NodeRunner struct {
 	handleTransactionCheckerDeferFunc common.CheckerDeferFunc
 	handleBallotCheckerDeferFunc      common.CheckerDeferFunc
 
+	transactionSelector transaction.TransactionSelector
+
+	seen         *common.SeenCache
+	droppedSeens uint64
+
+	// accountFilter has the accounts in storage; see `block.AccountFilter`.
+	accountFilter *block.AccountFilter
+
+	adminToken string
+
 	log logging.Logger
 }
 
@@ -99,19 +120,32 @@ func NewNodeRunner(
 	conf *consensus.ISAACConfiguration,
 ) (nr *NodeRunner, err error) {
 	nr = &NodeRunner{
-		networkID: []byte(networkID),
-		localNode: localNode,
-		policy:    policy,
-		network:   n,
-		consensus: c,
-		storage:   storage,
-		log:       log.New(logging.Ctx{"node": localNode.Alias()}),
+		networkID:           []byte(networkID),
+		localNode:           localNode,
+		policy:              policy,
+		network:             n,
+		consensus:           c,
+		storage:             storage,
+		acceptance:          &transactionAcceptance{},
+		transactionSelector: transaction.DefaultTransactionSelector,
+		log:                 log.New(logging.Ctx{"node": localNode.Alias()}),
 	}
 	nr.isaacStateManager = NewISAACStateManager(nr, conf)
+	nr.seen = common.NewSeenCache(conf.SeenCacheSize)
 
 	nr.policy.SetValidators(len(nr.localNode.GetValidators()) + 1) // including self
+	if err = nr.UpdateStakeWeights(); err != nil {
+		return
+	}
+	if nr.accountFilter, err = block.LoadAccountFilter(nr.storage); err != nil {
+		return
+	}
 
 	nr.connectionManager = c.ConnectionManager()
+	nr.connectionManager.SetStorage(nr.storage)
+	if err = nr.UpdateValidatorEndpoints(); err != nil {
+		return
+	}
 	nr.network.AddWatcher(nr.connectionManager.ConnectionWatcher)
 
 	nr.SetHandleTransactionCheckerFuncs(nil, DefaultHandleTransactionCheckerFuncs...)
@@ -132,8 +166,16 @@ func (nr *NodeRunner) Ready() {
 		nr.consensus,
 		network.UrlPathPrefixNode,
 	)
+	nodeHandler.isaacStateManager = nr.isaacStateManager
+	nodeHandler.acceptance = nr.acceptance
+	nodeHandler.adminToken = nr.adminToken
+	nodeHandler.handleTransaction = nr.handleTransaction
+	nodeHandler.startResync = nr.startResync
 
 	nr.network.AddHandler(nodeHandler.HandlerURLPattern(NodeInfoHandlerPattern), nodeHandler.NodeInfoHandler)
+	nr.network.AddHandler(nodeHandler.HandlerURLPattern(NodeInfoDetailHandlerPattern), nodeHandler.NodeInfoDetailHandler).Methods("GET")
+	nr.network.AddHandler(nodeHandler.HandlerURLPattern(NodeReadyzHandlerPattern), nodeHandler.NodeReadyzHandler).Methods("GET")
+	nr.network.AddHandler(nodeHandler.HandlerURLPattern(NodeRoundHandlerPattern), nodeHandler.NodeRoundHandler).Methods("GET")
 	nr.network.AddHandler(nodeHandler.HandlerURLPattern(ConnectHandlerPattern), nodeHandler.ConnectHandler).Methods("POST")
 	nr.network.AddHandler(nodeHandler.HandlerURLPattern(MessageHandlerPattern), nodeHandler.MessageHandler).Methods("POST")
 	nr.network.AddHandler(nodeHandler.HandlerURLPattern(BallotHandlerPattern), nodeHandler.BallotHandler).Methods("POST")
@@ -141,18 +183,53 @@ func (nr *NodeRunner) Ready() {
 		nodeHandler.HandlerURLPattern(GetBlocksPattern),
 		nodeHandler.GetBlocksHandler,
 	).Methods("GET", "POST")
+	nr.network.AddHandler(
+		nodeHandler.HandlerURLPattern(GetBlockPattern),
+		nodeHandler.GetBlockHandler,
+	).Methods("GET")
 	nr.network.AddHandler(
 		nodeHandler.HandlerURLPattern(GetTransactionPattern),
 		nodeHandler.GetNodeTransactionsHandler,
 	).Methods("GET", "POST")
+
+	// admin handlers
+	adminMiddlewares := &network.MiddlewareChain{}
+	adminMiddlewares.Add(nodeHandler.AdminAuthMiddleware())
+	nr.network.AddHandler(
+		nodeHandler.HandlerURLPattern(LogLevelHandlerPattern),
+		adminMiddlewares.Then(http.HandlerFunc(nodeHandler.LogLevelHandler)).ServeHTTP,
+	).Methods("GET", "POST")
+	nr.network.AddHandler(
+		nodeHandler.HandlerURLPattern(ResyncHandlerPattern),
+		adminMiddlewares.Then(http.HandlerFunc(nodeHandler.ResyncHandler)).ServeHTTP,
+	).Methods("POST")
 	nr.network.AddHandler("/metrics", promhttp.Handler().ServeHTTP)
 
 	// api handlers
-	apiHandler := api.NewNetworkHandlerAPI(nr.localNode, nr.network, nr.storage, network.UrlPathPrefixAPI)
+	apiHandler := api.NewNetworkHandlerAPI(
+		nr.localNode,
+		nr.network,
+		nr.storage,
+		nr.consensus.TransactionPool,
+		nr.accountFilter,
+		network.UrlPathPrefixAPI,
+	)
+	nr.network.AddHandler(
+		apiHandler.HandlerURLPattern(api.GetAccountsSequenceHandlerPattern),
+		apiHandler.GetAccountsSequenceHandler,
+	).Methods("GET")
 	nr.network.AddHandler(
 		apiHandler.HandlerURLPattern(api.GetAccountHandlerPattern),
 		apiHandler.GetAccountHandler,
 	).Methods("GET")
+	nr.network.AddHandler(
+		apiHandler.HandlerURLPattern(api.GetAccountSequenceHandlerPattern),
+		apiHandler.GetAccountSequenceHandler,
+	).Methods("GET")
+	nr.network.AddHandler(
+		apiHandler.HandlerURLPattern(api.GetAccountSignersHandlerPattern),
+		apiHandler.GetAccountSignersHandler,
+	).Methods("GET")
 	nr.network.AddHandler(
 		apiHandler.HandlerURLPattern(api.GetAccountTransactionsHandlerPattern),
 		apiHandler.GetTransactionsByAccountHandler,
@@ -165,24 +242,92 @@ func (nr *NodeRunner) Ready() {
 		apiHandler.HandlerURLPattern(api.GetTransactionsHandlerPattern),
 		apiHandler.GetTransactionsHandler,
 	).Methods("GET")
+	nr.network.AddHandler(
+		apiHandler.HandlerURLPattern(api.GetRecentTransactionsHandlerPattern),
+		apiHandler.GetRecentTransactionsHandler,
+	).Methods("GET")
 	nr.network.AddHandler(
 		apiHandler.HandlerURLPattern(api.GetTransactionByHashHandlerPattern),
 		apiHandler.GetTransactionByHashHandler,
 	).Methods("GET")
+	nr.network.AddHandler(
+		apiHandler.HandlerURLPattern(api.GetTransactionProofHandlerPattern),
+		apiHandler.GetTransactionProofHandler,
+	).Methods("GET")
+	nr.network.AddHandler(
+		apiHandler.HandlerURLPattern(api.GetTransactionOperationsHandlerPattern),
+		apiHandler.GetNormalizedOperationsByTxHashHandler,
+	).Methods("GET").Queries("format", "normalized")
 	nr.network.AddHandler(
 		apiHandler.HandlerURLPattern(api.GetTransactionOperationsHandlerPattern),
 		apiHandler.GetOperationsByTxHashHandler,
 	).Methods("GET")
 	nr.network.AddHandler(
 		apiHandler.HandlerURLPattern(api.PostTransactionPattern),
-		nodeHandler.MessageHandler,
+		nodeHandler.PostTransactionHandler,
+	).Methods("POST")
+	nr.network.AddHandler(
+		apiHandler.HandlerURLPattern(api.PostTransactionSimulatePattern),
+		nodeHandler.SimulateTransactionHandler,
+	).Methods("POST")
+	nr.network.AddHandler(
+		apiHandler.HandlerURLPattern(api.PostTransactionBatchPattern),
+		nodeHandler.BatchTransactionsHandler,
+	).Methods("POST")
+	nr.network.AddHandler(
+		apiHandler.HandlerURLPattern(api.PostVerifySignaturePattern),
+		nodeHandler.VerifySignatureHandler,
 	).Methods("POST")
+	nr.network.AddHandler(
+		apiHandler.HandlerURLPattern(api.PostFeeEstimatePattern),
+		nodeHandler.FeeEstimateHandler,
+	).Methods("POST")
+	nr.network.AddHandler(
+		apiHandler.HandlerURLPattern(api.GetStatsHandlerPattern),
+		apiHandler.GetStatsHandler,
+	).Methods("GET")
+	nr.network.AddHandler(
+		apiHandler.HandlerURLPattern(api.GetMempoolHandlerPattern),
+		apiHandler.GetMempoolHandler,
+	).Methods("GET")
+	nr.network.AddHandler(
+		apiHandler.HandlerURLPattern(api.GetBlocksRangeHandlerPattern),
+		apiHandler.GetBlocksRangeHandler,
+	).Methods("GET")
+	nr.network.AddHandler(
+		apiHandler.HandlerURLPattern(api.GetBlockHandlerPattern),
+		apiHandler.GetBlockHandler,
+	).Methods("GET")
+	nr.network.AddHandler(
+		apiHandler.HandlerURLPattern(api.GetBlockCreatedAccountsHandlerPattern),
+		apiHandler.GetBlockCreatedAccountsHandler,
+	).Methods("GET")
+	nr.network.AddHandler(
+		apiHandler.HandlerURLPattern(api.GetOpLogHandlerPattern),
+		apiHandler.GetOpLogHandler,
+	).Methods("GET")
+	nr.network.AddHandler(
+		apiHandler.HandlerURLPattern(api.GetProposerScheduleHandlerPattern),
+		nodeHandler.GetProposerScheduleHandler,
+	).Methods("GET")
+	nr.network.AddHandler(
+		apiHandler.HandlerURLPattern(api.GetNodeValidatorsHandlerPattern),
+		nodeHandler.GetValidatorsHandler,
+	).Methods("GET")
+	nr.network.AddHandler(
+		apiHandler.HandlerURLPattern(api.GetNodeValidatorsStatsHandlerPattern),
+		nodeHandler.GetValidatorsStatsHandler,
+	).Methods("GET")
 
 	nr.network.Ready()
 }
 
 func (nr *NodeRunner) Start() (err error) {
 	nr.log.Debug("NodeRunner started")
+	if err = network.CheckMaxValidators(len(nr.localNode.GetValidators())); err != nil {
+		return
+	}
+
 	nr.Ready()
 
 	go nr.handleMessages()
@@ -199,6 +344,45 @@ func (nr *NodeRunner) Start() (err error) {
 func (nr *NodeRunner) Stop() {
 	nr.network.Stop()
 	nr.isaacStateManager.Stop()
+	nr.connectionManager.Stop()
+
+	if err := block.SaveAccountFilter(nr.storage, nr.accountFilter); err != nil {
+		nr.log.Error("failed to save account filter", "error", err)
+	}
+}
+
+// transactionAcceptance is shared by `NodeRunner` and the node handlers; while
+// it is paused, the new transactions from the clients are rejected. The
+// transactions from the other nodes are still received, so the transactions
+// of the incoming ballots can be fetched.
+type transactionAcceptance struct {
+	paused uint32
+}
+
+func (t *transactionAcceptance) isPaused() bool {
+	return t != nil && atomic.LoadUint32(&t.paused) == 1
+}
+
+// PauseAcceptance stops accepting the new transactions, for example, during
+// the maintenance. The transaction handlers of the clients return
+// `errors.ErrorTransactionAcceptancePaused` with 503 status, but the ballots
+// are still handled, so the consensus goes on with the transactions in the
+// transaction pool.
+func (nr *NodeRunner) PauseAcceptance() {
+	if atomic.CompareAndSwapUint32(&nr.acceptance.paused, 0, 1) {
+		nr.log.Info("transaction acceptance is paused")
+	}
+}
+
+// ResumeAcceptance starts accepting the new transactions again.
+func (nr *NodeRunner) ResumeAcceptance() {
+	if atomic.CompareAndSwapUint32(&nr.acceptance.paused, 1, 0) {
+		nr.log.Info("transaction acceptance is resumed")
+	}
+}
+
+func (nr *NodeRunner) IsAcceptancePaused() bool {
+	return nr.acceptance.isPaused()
 }
 
 func (nr *NodeRunner) Node() *node.LocalNode {
@@ -221,6 +405,49 @@ func (nr *NodeRunner) ConnectionManager() network.ConnectionManager {
 	return nr.connectionManager
 }
 
+// BroadcastBallot sends the ballot to the validators in background; see
+// `broadcastBallot`.
+func (nr *NodeRunner) BroadcastBallot(b ballot.Ballot) {
+	go nr.broadcastBallot(b)
+}
+
+// broadcastBallot sends the ballot to the validators and waits the results.
+// If the weight of the validators, which received the ballot, including the
+// local node, is under the threshold of the ballot state, the ballot is sent
+// once more only to the failed validators. The proposal, INIT ballot, must
+// be received to be signed, so the threshold of SIGN is used for it. It
+// returns the addresses of the validators, to which the ballot is sent again.
+func (nr *NodeRunner) broadcastBallot(b ballot.Ballot) (retried []string) {
+	results := nr.ConnectionManager().BroadcastWithResult(b)
+
+	state := b.State()
+	if state == ballot.StateINIT {
+		state = ballot.StateSIGN
+	}
+
+	acknowledged := nr.policy.Weight(nr.localNode.Address())
+	for _, address := range results.Acknowledged() {
+		acknowledged += nr.policy.Weight(address)
+	}
+	if acknowledged >= nr.policy.RequiredCount(state) {
+		return
+	}
+
+	if retried = results.Failed(); len(retried) < 1 {
+		return
+	}
+
+	nr.log.Debug(
+		"ballot is not received by enough validators; broadcast again to the failed validators",
+		"ballot", b.GetHash(),
+		"acknowledged", acknowledged,
+		"failed", retried,
+	)
+	nr.ConnectionManager().BroadcastWithResult(b, retried...)
+
+	return
+}
+
 func (nr *NodeRunner) Storage() *storage.LevelDBBackend {
 	return nr.storage
 }
@@ -229,6 +456,71 @@ func (nr *NodeRunner) Policy() ballot.VotingThresholdPolicy {
 	return nr.policy
 }
 
+// UpdateStakeWeights loads the weights of the stakes of the validators
+// themselves and the weights, which are delegated to them, from storage and
+// sets them to the `VotingThresholdPolicy`.
+func (nr *NodeRunner) UpdateStakeWeights() (err error) {
+	staked := map[string]uint64{}
+	delegated := map[string]uint64{}
+	for _, address := range nr.validatorSet() {
+		if staked[address], err = block.GetStakedWeight(nr.storage, address); err != nil {
+			return
+		}
+		if delegated[address], err = block.GetDelegatedWeight(nr.storage, address); err != nil {
+			return
+		}
+	}
+
+	nr.policy.SetValidatorWeights(staked)
+	nr.policy.SetDelegatedWeights(delegated)
+
+	return
+}
+
+// UpdateValidatorEndpoints loads the endpoints of validators, which are
+// updated by `transaction.OperationUpdateEndpoint`, from storage and lets the
+// connection manager connect to the new endpoints.
+func (nr *NodeRunner) UpdateValidatorEndpoints() (err error) {
+	for address := range nr.localNode.GetValidators() {
+		var exists bool
+		if exists, err = block.ExistsValidatorEndpoint(nr.storage, address); err != nil {
+			return
+		} else if !exists {
+			continue
+		}
+
+		var endpoint *common.Endpoint
+		if endpoint, err = block.GetValidatorEndpoint(nr.storage, address); err != nil {
+			return
+		}
+
+		if nr.connectionManager.UpdateValidatorEndpoint(address, endpoint) {
+			nr.log.Info("validator endpoint is updated", "validator", address, "endpoint", endpoint)
+		}
+	}
+
+	return
+}
+
+// validatorSet returns the addresses of the validators configured to the
+// local node, including itself.
+func (nr *NodeRunner) validatorSet() []string {
+	return validatorSet(nr.localNode)
+}
+
+func validatorSet(localNode *node.LocalNode) []string {
+	validators := []string{localNode.Address()}
+	for address := range localNode.GetValidators() {
+		validators = append(validators, address)
+	}
+
+	return validators
+}
+
+func (nr *NodeRunner) Conf() *consensus.ISAACConfiguration {
+	return nr.isaacStateManager.Conf
+}
+
 func (nr *NodeRunner) Log() logging.Logger {
 	return nr.log
 }
@@ -247,7 +539,9 @@ func (nr *NodeRunner) ConnectValidators() {
 	nr.log.Debug("trying to connect to the validators", "validators", nr.localNode.GetValidators())
 
 	nr.log.Debug("initializing connectionManager for validators")
-	nr.connectionManager.Start()
+	if err := nr.connectionManager.Start(); err != nil {
+		nr.log.Error("failed to start connectionManager", "error", err)
+	}
 }
 
 func (nr *NodeRunner) SetHandleTransactionCheckerFuncs(
@@ -285,6 +579,19 @@ func (nr *NodeRunner) SetHandleMessageCheckerDeferFunc(f common.CheckerDeferFunc
 	nr.handleTransactionCheckerDeferFunc = f
 }
 
+// SetAdminToken sets the token, which is required by the admin handlers like
+// `ResyncHandler`. It must be set before `Start`; if empty, the admin
+// handlers refuse all the requests.
+func (nr *NodeRunner) SetAdminToken(token string) {
+	nr.adminToken = token
+}
+
+// SetTransactionSelector sets the `TransactionSelector`, which selects the
+// transactions from `TransactionPool` for the new proposal.
+func (nr *NodeRunner) SetTransactionSelector(s transaction.TransactionSelector) {
+	nr.transactionSelector = s
+}
+
 // Read from the network channel and forwards to `handleMessage`
 func (nr *NodeRunner) handleMessages() {
 	for message := range nr.network.ReceiveMessage() {
@@ -300,6 +607,7 @@ func (nr *NodeRunner) handleMessage(message common.NetworkMessage) {
 		nr.log.Error("got empty message")
 		return
 	}
+	var seen string
 	switch message.Type {
 	case common.ConnectMessage:
 		if _, err := node.NewValidatorFromString(message.Data); err != nil {
@@ -307,19 +615,71 @@ func (nr *NodeRunner) handleMessage(message common.NetworkMessage) {
 			return
 		}
 	case common.TransactionMessage:
+		seen = seenMessageHash(message)
+		if nr.isSeenMessage(seen, message) {
+			return
+		}
 		err = nr.handleTransaction(message)
 	case common.BallotMessage:
+		seen = seenMessageHash(message)
+		if nr.isSeenMessage(seen, message) {
+			return
+		}
+		nr.consensusLock.Lock()
 		err = nr.handleBallotMessage(message)
+		nr.consensusLock.Unlock()
 	default:
 		err = errors.New("got unknown message")
 	}
 
 	if err != nil {
 		if _, ok := err.(common.CheckerStop); ok {
+			nr.markSeenMessage(seen)
 			return
 		}
 		nr.log.Debug("failed to handle message", "message", message.Head(50), "error", err)
+		return
 	}
+
+	nr.markSeenMessage(seen)
+}
+
+// seenMessageHash returns the key of the message in the seen cache. It uses
+// SHA-256 regardless of `common.DefaultHashAlgo`, because it is made for every
+// received message in the message loop.
+func seenMessageHash(message common.NetworkMessage) string {
+	return string(message.Type) + string(common.HashAlgoSHA256.Hash(message.Data))
+}
+
+// isSeenMessage checks the same message is already accepted recently; the
+// message is compared by the hash of it's data, see `seenMessageHash`. The
+// seen message is dropped and counted; see `DroppedSeenMessages`.
+func (nr *NodeRunner) isSeenMessage(seen string, message common.NetworkMessage) bool {
+	if !nr.seen.Has(seen) {
+		return false
+	}
+
+	atomic.AddUint64(&nr.droppedSeens, 1)
+	nr.log.Debug("message is already seen; dropped", "type", message.Type, "message", message.Head(50))
+
+	return true
+}
+
+// markSeenMessage marks the ballot or transaction message as seen. It is
+// called only after the checkers accept the message, so the message, which is
+// rejected, for example, because it's account is not yet synced, can be
+// handled again when it is received again.
+func (nr *NodeRunner) markSeenMessage(seen string) {
+	if len(seen) < 1 {
+		return
+	}
+	nr.seen.Add(seen)
+}
+
+// DroppedSeenMessages returns the number of the ballots and transactions,
+// which are dropped because they are already received recently.
+func (nr *NodeRunner) DroppedSeenMessages() uint64 {
+	return atomic.LoadUint64(&nr.droppedSeens)
 }
 
 func (nr *NodeRunner) handleTransaction(message common.NetworkMessage) (err error) {
@@ -458,6 +818,20 @@ func (nr *NodeRunner) StopStateManager() {
 	return
 }
 
+// ConsensusLag returns the duration since the latest confirmed block.
+func (nr *NodeRunner) ConsensusLag() time.Duration {
+	return consensusLag(nr.consensus.LatestConfirmedBlock())
+}
+
+func consensusLag(b block.Block) time.Duration {
+	confirmed, err := common.ParseISO8601(b.Confirmed)
+	if err != nil {
+		return time.Since(time.Time{})
+	}
+
+	return time.Since(confirmed)
+}
+
 func (nr *NodeRunner) TransitISAACState(round round.Round, ballotState ballot.State) {
 	nr.isaacStateManager.TransitISAACState(round, ballotState)
 }
@@ -472,7 +846,11 @@ func (nr *NodeRunner) proposeNewBallot(roundNumber uint64) error {
 	}
 
 	// collect incoming transactions from `TransactionPool`
-	availableTransactions := nr.consensus.TransactionPool.AvailableTransactions(int(nr.isaacStateManager.Conf.TransactionsLimit))
+	limit := int(nr.isaacStateManager.Conf.TransactionsLimit)
+	if limit > common.MaxTransactionsInBallot {
+		limit = common.MaxTransactionsInBallot
+	}
+	availableTransactions := nr.transactionSelector.Select(nr.consensus.TransactionPool, limit)
 	nr.log.Debug("new round proposed", "round", round, "transactions", availableTransactions)
 
 	transactionsChecker := &BallotTransactionChecker{
@@ -500,7 +878,7 @@ func (nr *NodeRunner) proposeNewBallot(roundNumber uint64) error {
 
 	nr.log.Debug("new ballot created", "ballot", theBallot)
 
-	nr.ConnectionManager().Broadcast(*theBallot)
+	nr.BroadcastBallot(*theBallot)
 
 	return nr.consensus.AddRunningRound(round.Hash(), *theBallot)
 }
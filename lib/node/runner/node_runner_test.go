@@ -70,8 +70,8 @@ func createTestNodeRunner(n int, conf *consensus.ISAACConfiguration) []*NodeRunn
 		is, _ := consensus.NewISAAC(networkID, localNode, policy, connectionManager)
 		st := storage.NewTestStorage()
 
-		account.Save(st)
-		genesisBlock, _ = block.MakeGenesisBlock(st, *account, networkID)
+		account.Save(st, nil)
+		genesisBlock, _ = block.MakeGenesisBlock(st, *account, networkID, kp)
 
 		nr, err := NewNodeRunner(string(networkID), localNode, policy, ns[i], is, st, conf)
 		if err != nil {
@@ -172,8 +172,8 @@ func createTestNodeRunnersHTTP2Network(n int) (nodeRunners []*NodeRunner, rootKP
 
 		is, _ := consensus.NewISAAC(networkID, node, policy, connectionManager)
 
-		genesisAccount.Save(st)
-		block.MakeGenesisBlock(st, *genesisAccount, networkID)
+		genesisAccount.Save(st, nil)
+		block.MakeGenesisBlock(st, *genesisAccount, networkID, rootKP)
 
 		nodeRunner, _ := NewNodeRunner(string(networkID), node, policy, n, is, st, consensus.NewISAACConfiguration())
 		nodeRunners = append(nodeRunners, nodeRunner)
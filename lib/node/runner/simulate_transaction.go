@@ -0,0 +1,146 @@
+package runner
+
+import (
+	"boscoin.io/sebak/lib/block"
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/error"
+	"boscoin.io/sebak/lib/storage"
+	"boscoin.io/sebak/lib/transaction"
+)
+
+// AccountStateDiff is the change of one account, which is caused by the
+// transaction.
+type AccountStateDiff struct {
+	Address          string            `json:"address"`
+	Created          bool              `json:"created"`
+	BalanceBefore    common.Amount     `json:"balance-before"`
+	BalanceAfter     common.Amount     `json:"balance-after"`
+	SequenceIDBefore uint64            `json:"sequenceid-before"`
+	SequenceIDAfter  uint64            `json:"sequenceid-after"`
+	DataBefore       map[string]string `json:"data-before,omitempty"`
+	DataAfter        map[string]string `json:"data-after,omitempty"`
+	DelegateBefore   string            `json:"delegate-before,omitempty"`
+	DelegateAfter    string            `json:"delegate-after,omitempty"`
+	SpendLimitBefore common.Amount     `json:"spend-limit-before,omitempty"`
+	SpendLimitAfter  common.Amount     `json:"spend-limit-after,omitempty"`
+	EndpointBefore   string            `json:"endpoint-before,omitempty"`
+	EndpointAfter    string            `json:"endpoint-after,omitempty"`
+}
+
+// StateDiff is the changes of the accounts by the transaction. The key is the
+// address of account.
+type StateDiff map[string]AccountStateDiff
+
+// SimulateTx returns the changes of the accounts, which the transaction will
+// cause, without storing anything. Like `ValidateTx`, it runs on the snapshot
+// of storage and the transaction must be valid. The operations are applied in
+// order like `finishTransaction`. `validators` are the validators configured
+// to the local node; see `ValidateTx`.
+func SimulateTx(st *storage.LevelDBBackend, tx transaction.Transaction, validators []string) (diff StateDiff, err error) {
+	var snapshot *storage.LevelDBBackend
+	if snapshot, err = st.Snapshot(); err != nil {
+		return
+	}
+	defer snapshot.Release()
+
+	if err = ValidateTx(snapshot, tx, validators); err != nil {
+		return
+	}
+
+	accounts := map[string]*AccountStateDiff{}
+	getAccount := func(address string) (*AccountStateDiff, error) {
+		if d, found := accounts[address]; found {
+			return d, nil
+		}
+
+		ba, err := block.GetBlockAccount(snapshot, address)
+		if err != nil {
+			return nil, err
+		}
+		d := &AccountStateDiff{
+			Address:          address,
+			BalanceBefore:    ba.Balance,
+			BalanceAfter:     ba.Balance,
+			SequenceIDBefore: ba.SequenceID,
+			SequenceIDAfter:  ba.SequenceID,
+			DataBefore:       ba.Data,
+			DataAfter:        ba.Data,
+			DelegateBefore:   ba.Delegate,
+			DelegateAfter:    ba.Delegate,
+			SpendLimitBefore: ba.SpendLimit,
+			SpendLimitAfter:  ba.SpendLimit,
+		}
+		accounts[address] = d
+
+		return d, nil
+	}
+
+	for _, op := range tx.B.Operations {
+		var source *AccountStateDiff
+		if source, err = getAccount(tx.B.Source); err != nil {
+			return
+		}
+
+		switch body := op.B.(type) {
+		case transaction.OperationBodyCreateAccount:
+			accounts[body.TargetAddress()] = &AccountStateDiff{
+				Address:      body.TargetAddress(),
+				Created:      true,
+				BalanceAfter: body.GetAmount(),
+			}
+		case transaction.OperationBodyPayment:
+			var target *AccountStateDiff
+			if target, err = getAccount(body.TargetAddress()); err != nil {
+				return
+			}
+			if target.BalanceAfter, err = target.BalanceAfter.Add(body.GetAmount()); err != nil {
+				return
+			}
+		case transaction.OperationBodySetAccountData:
+			source.DataAfter = body.Apply(source.DataAfter)
+		case transaction.OperationBodyDelegate:
+			source.DelegateAfter = body.TargetAddress()
+		case transaction.OperationBodySetSpendLimit:
+			source.SpendLimitAfter = body.Limit
+		case transaction.OperationBodyUpdateEndpoint:
+			var endpoint *common.Endpoint
+			if endpoint, err = block.GetValidatorEndpoint(snapshot, tx.B.Source); err == nil {
+				source.EndpointBefore = endpoint.String()
+			} else if err != errors.ErrorStorageRecordDoesNotExist {
+				return
+			}
+			if endpoint, err = body.ParsedEndpoint(); err != nil {
+				err = errors.ErrorInvalidEndpoint
+				return
+			}
+			source.EndpointAfter = endpoint.String()
+		default:
+			err = errors.ErrorUnknownOperationType
+			return
+		}
+	}
+
+	// like `block.BlockAccount.WithdrawWithSequenceID`
+	var source *AccountStateDiff
+	if source, err = getAccount(tx.B.Source); err != nil {
+		return
+	}
+	if source.BalanceAfter, err = source.BalanceAfter.Sub(tx.TotalAmount(true)); err != nil {
+		return
+	}
+
+	var ba *block.BlockAccount
+	if ba, err = block.GetBlockAccount(snapshot, tx.B.Source); err != nil {
+		return
+	}
+	if source.SequenceIDAfter, err = ba.NextSequenceID(snapshot, tx.B.SequenceID); err != nil {
+		return
+	}
+
+	diff = StateDiff{}
+	for address, d := range accounts {
+		diff[address] = *d
+	}
+
+	return
+}
@@ -0,0 +1,256 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stellar/go/keypair"
+	"github.com/stretchr/testify/require"
+
+	"boscoin.io/sebak/lib/block"
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/consensus"
+	"boscoin.io/sebak/lib/error"
+	"boscoin.io/sebak/lib/network"
+	"boscoin.io/sebak/lib/network/httputils"
+	"boscoin.io/sebak/lib/node"
+	"boscoin.io/sebak/lib/storage"
+	"boscoin.io/sebak/lib/transaction"
+)
+
+func makeSimulateTestAccounts(st *storage.LevelDBBackend) (kps, kpt *keypair.Full) {
+	kps, _ = keypair.Random()
+	kpt, _ = keypair.Random()
+
+	bas := block.NewBlockAccount(kps.Address(), common.Amount(1*common.AmountPerCoin))
+	bat := block.NewBlockAccount(kpt.Address(), common.Amount(1*common.AmountPerCoin))
+	bas.Save(st, nil)
+	bat.Save(st, nil)
+
+	return
+}
+
+func TestSimulateTxPayment(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	kps, kpt := makeSimulateTestAccounts(st)
+
+	amount := common.Amount(10000)
+	op := transaction.Operation{
+		H: transaction.OperationHeader{Type: transaction.OperationPayment},
+		B: transaction.NewOperationBodyPayment(kpt.Address(), amount),
+	}
+	tx, _ := transaction.NewTransaction(kps.Address(), 0, op)
+	tx.Sign(kps, networkID)
+
+	diff, err := SimulateTx(st, tx, nil)
+	require.Nil(t, err)
+	require.Equal(t, 2, len(diff))
+
+	source := diff[kps.Address()]
+	require.False(t, source.Created)
+	require.Equal(t, common.Amount(1*common.AmountPerCoin), source.BalanceBefore)
+	require.Equal(t, source.BalanceBefore.MustSub(amount.MustAdd(common.BaseFee)), source.BalanceAfter)
+	require.Equal(t, uint64(0), source.SequenceIDBefore)
+	require.Equal(t, uint64(1), source.SequenceIDAfter)
+
+	target := diff[kpt.Address()]
+	require.False(t, target.Created)
+	require.Equal(t, target.BalanceBefore.MustAdd(amount), target.BalanceAfter)
+	require.Equal(t, target.SequenceIDBefore, target.SequenceIDAfter)
+
+	// nothing is stored
+	ba, err := block.GetBlockAccount(st, kps.Address())
+	require.Nil(t, err)
+	require.Equal(t, source.BalanceBefore, ba.Balance)
+	require.Equal(t, uint64(0), ba.SequenceID)
+}
+
+func TestSimulateTxCreateAccount(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	kps, _ := makeSimulateTestAccounts(st)
+	kpNew, _ := keypair.Random()
+
+	amount := common.BaseReserve
+	op := transaction.Operation{
+		H: transaction.OperationHeader{Type: transaction.OperationCreateAccount},
+		B: transaction.NewOperationBodyCreateAccount(kpNew.Address(), amount, ""),
+	}
+	tx, _ := transaction.NewTransaction(kps.Address(), 0, op)
+	tx.Sign(kps, networkID)
+
+	diff, err := SimulateTx(st, tx, nil)
+	require.Nil(t, err)
+
+	created := diff[kpNew.Address()]
+	require.True(t, created.Created)
+	require.Equal(t, common.Amount(0), created.BalanceBefore)
+	require.Equal(t, amount, created.BalanceAfter)
+
+	exists, err := block.ExistsBlockAccount(st, kpNew.Address())
+	require.Nil(t, err)
+	require.False(t, exists)
+}
+
+func TestSimulateTxPaymentToCreatedAccount(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	kps, _ := makeSimulateTestAccounts(st)
+	kpNew, _ := keypair.Random()
+
+	amount := common.BaseReserve
+	ops := []transaction.Operation{
+		transaction.Operation{
+			H: transaction.OperationHeader{Type: transaction.OperationCreateAccount},
+			B: transaction.NewOperationBodyCreateAccount(kpNew.Address(), amount, ""),
+		},
+		transaction.Operation{
+			H: transaction.OperationHeader{Type: transaction.OperationPayment},
+			B: transaction.NewOperationBodyPayment(kpNew.Address(), amount),
+		},
+	}
+	tx, _ := transaction.NewTransaction(kps.Address(), 0, ops...)
+	tx.Sign(kps, networkID)
+
+	diff, err := SimulateTx(st, tx, nil)
+	require.Nil(t, err)
+
+	created := diff[kpNew.Address()]
+	require.True(t, created.Created)
+	require.Equal(t, amount.MustAdd(amount), created.BalanceAfter)
+
+	// same with the stored result
+	require.Nil(t, finishTransaction(st, tx, nil, nil, log))
+	ba, err := block.GetBlockAccount(st, kpNew.Address())
+	require.Nil(t, err)
+	require.Equal(t, created.BalanceAfter, ba.Balance)
+	bas, err := block.GetBlockAccount(st, kps.Address())
+	require.Nil(t, err)
+	require.Equal(t, diff[kps.Address()].BalanceAfter, bas.Balance)
+	require.Equal(t, diff[kps.Address()].SequenceIDAfter, bas.SequenceID)
+}
+
+func TestSimulateTxAccountOperations(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	kps, _ := makeSimulateTestAccounts(st)
+	kpv, _ := keypair.Random()
+	require.Nil(t, block.SaveValidatorSet(st, 1, []string{kpv.Address()}))
+
+	limit := common.Amount(common.AmountPerCoin)
+	ops := []transaction.Operation{
+		transaction.Operation{
+			H: transaction.OperationHeader{Type: transaction.OperationSetAccountData},
+			B: transaction.NewOperationBodySetAccountData(
+				kps.Address(),
+				[]transaction.AccountData{transaction.AccountData{Key: "name", Value: "showme"}},
+			),
+		},
+		transaction.Operation{
+			H: transaction.OperationHeader{Type: transaction.OperationDelegate},
+			B: transaction.NewOperationBodyDelegate(kpv.Address()),
+		},
+		transaction.Operation{
+			H: transaction.OperationHeader{Type: transaction.OperationSetSpendLimit},
+			B: transaction.NewOperationBodySetSpendLimit(kps.Address(), limit),
+		},
+	}
+	tx, _ := transaction.NewTransaction(kps.Address(), 0, ops...)
+	tx.Sign(kps, networkID)
+
+	diff, err := SimulateTx(st, tx, nil)
+	require.Nil(t, err)
+	require.Equal(t, 1, len(diff))
+
+	source := diff[kps.Address()]
+	require.Nil(t, source.DataBefore)
+	require.Equal(t, map[string]string{"name": "showme"}, source.DataAfter)
+	require.Equal(t, "", source.DelegateBefore)
+	require.Equal(t, kpv.Address(), source.DelegateAfter)
+	require.Equal(t, common.Amount(0), source.SpendLimitBefore)
+	require.Equal(t, limit, source.SpendLimitAfter)
+	require.Equal(t, source.BalanceBefore.MustSub(tx.TotalAmount(true)), source.BalanceAfter)
+}
+
+func TestSimulateTxInvalid(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	kps, kpt := makeSimulateTestAccounts(st)
+
+	op := transaction.Operation{
+		H: transaction.OperationHeader{Type: transaction.OperationPayment},
+		B: transaction.NewOperationBodyPayment(kpt.Address(), common.Amount(2*common.AmountPerCoin)),
+	}
+	tx, _ := transaction.NewTransaction(kps.Address(), 0, op)
+	tx.Sign(kps, networkID)
+
+	_, err := SimulateTx(st, tx, nil)
+	require.Equal(t, errors.ErrorTransactionExcessAbilityToPay, err)
+}
+
+func TestSimulateTransactionHandler(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	kps, kpt := makeSimulateTestAccounts(st)
+
+	kp, _ := keypair.Random()
+	endpoint, _ := common.NewEndpointFromString("http://localhost:12345")
+	localNode, _ := node.NewLocalNode(kp, endpoint, "")
+	isaac, _ := consensus.NewISAAC(
+		networkID,
+		localNode,
+		nil,
+		network.NewValidatorConnectionManager(localNode, nil, nil, nil),
+	)
+	apiHandler := NetworkHandlerNode{localNode: localNode, storage: st, consensus: isaac}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/simulate", apiHandler.SimulateTransactionHandler).Methods("POST")
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	amount := common.Amount(10000)
+	op := transaction.Operation{
+		H: transaction.OperationHeader{Type: transaction.OperationPayment},
+		B: transaction.NewOperationBodyPayment(kpt.Address(), amount),
+	}
+	tx, _ := transaction.NewTransaction(kps.Address(), 0, op)
+	tx.Sign(kps, networkID)
+
+	{ // valid
+		body, _ := tx.Serialize()
+		resp, err := http.Post(server.URL+"/simulate", "application/json", bytes.NewBuffer(body))
+		require.Nil(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var diff StateDiff
+		b, _ := ioutil.ReadAll(resp.Body)
+		require.Nil(t, json.Unmarshal(b, &diff))
+		require.Equal(t, diff[kpt.Address()].BalanceBefore.MustAdd(amount), diff[kpt.Address()].BalanceAfter)
+	}
+
+	{ // not enough balance
+		op.B = transaction.NewOperationBodyPayment(kpt.Address(), common.Amount(2*common.AmountPerCoin))
+		invalid, _ := transaction.NewTransaction(kps.Address(), 0, op)
+		invalid.Sign(kps, networkID)
+
+		body, _ := invalid.Serialize()
+		resp, err := http.Post(server.URL+"/simulate", "application/json", bytes.NewBuffer(body))
+		require.Nil(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, httputils.StatusCode(errors.ErrorTransactionExcessAbilityToPay), resp.StatusCode)
+	}
+}
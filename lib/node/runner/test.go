@@ -144,8 +144,8 @@ func createNodeRunnerForTesting(n int, conf *consensus.ISAACConfiguration, recv
 	is.SetProposerSelector(SelfSelector{connectionManager})
 	st := storage.NewTestStorage()
 
-	account.Save(st)
-	genesisBlock, _ = block.MakeGenesisBlock(st, *account, networkID)
+	account.Save(st, nil)
+	genesisBlock, _ = block.MakeGenesisBlock(st, *account, networkID, kp)
 
 	nr, err := NewNodeRunner(string(networkID), localNode, policy, ns[0], is, st, conf)
 	if err != nil {
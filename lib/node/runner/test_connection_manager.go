@@ -43,6 +43,13 @@ func (c *TestConnectionManager) Broadcast(message common.Message) {
 	return
 }
 
+// BroadcastWithResult records the message like `Broadcast`; no validator
+// receives it, so the results are empty.
+func (c *TestConnectionManager) BroadcastWithResult(message common.Message, _ ...string) network.BroadcastResults {
+	c.Broadcast(message)
+	return nil
+}
+
 func (c *TestConnectionManager) Messages() []common.Message {
 	c.RLock()
 	defer c.RUnlock()
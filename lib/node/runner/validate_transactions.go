@@ -0,0 +1,124 @@
+package runner
+
+import (
+	"sync"
+	"time"
+
+	"boscoin.io/sebak/lib/block"
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/error"
+	"boscoin.io/sebak/lib/storage"
+	"boscoin.io/sebak/lib/transaction"
+)
+
+// ValidateTxs validates the transactions by `ValidateTx` with `workers`
+// workers concurrently. All the transactions are validated against the same
+// snapshot of storage. The transactions of the same source are validated by
+// one worker in the order of `txs`, which is the order they are applied, on
+// top of the balance, the sequenceID and the account data left by the
+// preceding valid ones; see `sourceState`. The transactions of the different
+// sources are validated concurrently. The returned errors are in the same
+// order with `txs`, so the result does not depend on the number of workers.
+// The spend limit is checked at `confirmed`. The coins issued by the
+// transactions are checked against the max supply together; see
+// `checkIssuedSupply`. `validators` are the validators configured to the
+// local node; see `ValidateTx`.
+func ValidateTxs(st *storage.LevelDBBackend, txs []transaction.Transaction, workers int, confirmed time.Time, validators []string) ([]error, error) {
+	return validateTxsWith(st, txs, workers, confirmed, validators, validateTx)
+}
+
+func validateTxsWith(
+	st *storage.LevelDBBackend,
+	txs []transaction.Transaction,
+	workers int,
+	confirmed time.Time,
+	validators []string,
+	validate func(*storage.LevelDBBackend, transaction.Transaction, *sourceState) error,
+) ([]error, error) {
+	snapshot, err := st.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	defer snapshot.Release()
+
+	// the indexes of transactions are grouped by source
+	var sources []string
+	groups := map[string][]int{}
+	for i, tx := range txs {
+		if _, found := groups[tx.B.Source]; !found {
+			sources = append(sources, tx.B.Source)
+		}
+		groups[tx.B.Source] = append(groups[tx.B.Source], i)
+	}
+
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(sources) {
+		workers = len(sources)
+	}
+
+	errs := make([]error, len(txs))
+	queue := make(chan []int, len(sources))
+	for _, source := range sources {
+		queue <- groups[source]
+	}
+	close(queue)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for group := range queue {
+				state := newSourceState(confirmed, validators)
+				for _, index := range group {
+					errs[index] = validate(snapshot, txs[index], state)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err = checkIssuedSupply(snapshot, txs, errs); err != nil {
+		return nil, err
+	}
+
+	return errs, nil
+}
+
+// checkIssuedSupply checks the coins issued by all the valid transactions do
+// not make the total supply over the max supply; each transaction is checked
+// on top of the coins issued by the preceding valid ones. The transaction,
+// which makes it over, is marked by `errors.ErrorOverMaxSupply` in `errs`.
+func checkIssuedSupply(st *storage.LevelDBBackend, txs []transaction.Transaction, errs []error) (err error) {
+	var issued common.Amount
+	for i, tx := range txs {
+		if errs[i] != nil {
+			continue
+		}
+
+		var amount common.Amount
+		if amount, errs[i] = tx.IssuedAmount(); errs[i] != nil || amount < 1 {
+			continue
+		}
+
+		total, overflow := issued.Add(amount)
+		if overflow != nil {
+			errs[i] = errors.ErrorOverMaxSupply
+			continue
+		}
+		if err = block.CheckMaxSupply(st, total); err == errors.ErrorOverMaxSupply {
+			errs[i] = err
+			err = nil
+			continue
+		} else if err != nil {
+			return
+		}
+
+		issued = total
+	}
+
+	return
+}
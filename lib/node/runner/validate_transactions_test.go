@@ -0,0 +1,291 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stretchr/testify/require"
+
+	"boscoin.io/sebak/lib/block"
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/error"
+	"boscoin.io/sebak/lib/storage"
+	"boscoin.io/sebak/lib/transaction"
+)
+
+func makeValidateTxsTestTransactions(st *storage.LevelDBBackend, n int) (txs []transaction.Transaction) {
+	target, _ := keypair.Random()
+	block.NewBlockAccount(target.Address(), common.Amount(1*common.AmountPerCoin)).Save(st, nil)
+
+	for i := 0; i < n; i++ {
+		source, _ := keypair.Random()
+		block.NewBlockAccount(source.Address(), common.Amount(1*common.AmountPerCoin)).Save(st, nil)
+
+		op := transaction.Operation{
+			H: transaction.OperationHeader{Type: transaction.OperationPayment},
+			B: transaction.NewOperationBodyPayment(target.Address(), common.Amount(10000)),
+		}
+		tx, _ := transaction.NewTransaction(source.Address(), 0, op)
+		tx.Sign(source, networkID)
+		txs = append(txs, tx)
+	}
+
+	return
+}
+
+func TestValidateTxs(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	txs := makeValidateTxsTestTransactions(st, 20)
+
+	// not enough balance
+	op := transaction.Operation{
+		H: transaction.OperationHeader{Type: transaction.OperationPayment},
+		B: transaction.NewOperationBodyPayment(txs[0].B.Source, common.Amount(2*common.AmountPerCoin)),
+	}
+	invalid, _ := transaction.NewTransaction(txs[5].B.Source, 0, op)
+	txs[5] = invalid
+
+	// unknown source
+	unknown, _ := keypair.Random()
+	invalid, _ = transaction.NewTransaction(unknown.Address(), 0, op)
+	txs[12] = invalid
+
+	serial, err := ValidateTxs(st, txs, 1, time.Time{}, nil)
+	require.Nil(t, err)
+	require.Equal(t, len(txs), len(serial))
+
+	for _, workers := range []int{0, 2, 4, 100} {
+		errs, err := ValidateTxs(st, txs, workers, time.Time{}, nil)
+		require.Nil(t, err)
+		require.Equal(t, serial, errs)
+	}
+
+	for i, err := range serial {
+		switch i {
+		case 5:
+			require.Equal(t, errors.ErrorTransactionExcessAbilityToPay, err)
+		case 12:
+			require.Equal(t, errors.ErrorBlockAccountDoesNotExists, err)
+		default:
+			require.Nil(t, err)
+		}
+	}
+}
+
+// TestValidateTxsCarrySourceState checks the transactions of same source are
+// validated on top of the balance and the sequenceID left by the preceding
+// ones, regardless of the number of workers.
+func TestValidateTxsCarrySourceState(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	target, _ := keypair.Random()
+	block.NewBlockAccount(target.Address(), common.Amount(1*common.AmountPerCoin)).Save(st, nil)
+
+	var sources []*keypair.Full
+	for i := 0; i < 3; i++ {
+		kp, _ := keypair.Random()
+		block.NewBlockAccount(kp.Address(), common.Amount(1*common.AmountPerCoin)).Save(st, nil)
+		sources = append(sources, kp)
+	}
+
+	makeTx := func(kp *keypair.Full, sequenceID uint64, amount common.Amount) transaction.Transaction {
+		op := transaction.Operation{
+			H: transaction.OperationHeader{Type: transaction.OperationPayment},
+			B: transaction.NewOperationBodyPayment(target.Address(), amount),
+		}
+		tx, _ := transaction.NewTransaction(kp.Address(), sequenceID, op)
+		tx.Sign(kp, networkID)
+		return tx
+	}
+
+	third := common.Amount(common.AmountPerCoin / 3)
+
+	var txs []transaction.Transaction
+	var expected []error
+	for _, kp := range sources {
+		txs = append(
+			txs,
+			makeTx(kp, 0, third),
+			makeTx(kp, 1, third), // sequenceID after the preceding one
+			makeTx(kp, 1, 1),     // already used by the preceding one
+			makeTx(kp, 3, 1),     // not the next sequenceID
+			makeTx(kp, 2, third), // over the balance left
+			makeTx(kp, 2, 1),
+		)
+		expected = append(
+			expected,
+			nil,
+			nil,
+			errors.ErrorTransactionInvalidSequenceID,
+			errors.ErrorTransactionInvalidSequenceID,
+			errors.ErrorTransactionExcessAbilityToPay,
+			nil,
+		)
+	}
+
+	for _, workers := range []int{1, 2, 3} {
+		errs, err := ValidateTxs(st, txs, workers, time.Time{}, nil)
+		require.Nil(t, err)
+		require.Equal(t, expected, errs)
+	}
+}
+
+func TestValidateTxsSameSourceOrder(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	var sources []string
+	for i := 0; i < 5; i++ {
+		kp, _ := keypair.Random()
+		sources = append(sources, kp.Address())
+	}
+
+	// the transactions of same source are shuffled in ballot
+	var txs []transaction.Transaction
+	for sequenceID := uint64(0); sequenceID < 10; sequenceID++ {
+		for _, source := range sources {
+			txs = append(txs, transaction.Transaction{
+				B: transaction.TransactionBody{Source: source, SequenceID: sequenceID},
+			})
+		}
+	}
+	rand.Shuffle(len(txs), func(i, j int) { txs[i], txs[j] = txs[j], txs[i] })
+
+	expected := map[string][]uint64{}
+	for _, tx := range txs {
+		expected[tx.B.Source] = append(expected[tx.B.Source], tx.B.SequenceID)
+	}
+
+	var l sync.Mutex
+	validated := map[string][]uint64{}
+	validate := func(_ *storage.LevelDBBackend, tx transaction.Transaction, _ *sourceState) error {
+		l.Lock()
+		defer l.Unlock()
+
+		validated[tx.B.Source] = append(validated[tx.B.Source], tx.B.SequenceID)
+		return fmt.Errorf("%s-%d", tx.B.Source, tx.B.SequenceID)
+	}
+
+	errs, err := validateTxsWith(st, txs, 4, time.Time{}, nil, validate)
+	require.Nil(t, err)
+
+	// the transactions of same source are validated in the order of ballot
+	for _, source := range sources {
+		require.Equal(t, expected[source], validated[source])
+	}
+
+	// the errors are in the order of transactions
+	for i, tx := range txs {
+		require.Equal(t, fmt.Sprintf("%s-%d", tx.B.Source, tx.B.SequenceID), errs[i].Error())
+	}
+}
+
+func benchmarkValidateTxs(b *testing.B, workers int) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	txs := makeValidateTxsTestTransactions(st, 100)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ValidateTxs(st, txs, workers, time.Time{}, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkValidateTxsSerial(b *testing.B) {
+	benchmarkValidateTxs(b, 1)
+}
+
+func BenchmarkValidateTxsConcurrent(b *testing.B) {
+	benchmarkValidateTxs(b, runtime.NumCPU())
+}
+
+// testOperationBodyIssue issues `Amount` new coins like inflation.
+type testOperationBodyIssue struct {
+	Amount common.Amount `json:"amount"`
+}
+
+func (o testOperationBodyIssue) IsWellFormed([]byte) error {
+	return nil
+}
+
+func (o testOperationBodyIssue) Serialize() ([]byte, error) {
+	return json.Marshal(o)
+}
+
+func (o testOperationBodyIssue) IssuedAmount() common.Amount {
+	return o.Amount
+}
+
+// The transactions in block, which issue the new coins over the max supply
+// together, are rejected.
+func TestValidateTxsOverMaxSupply(t *testing.T) {
+	st := storage.NewTestStorage()
+	defer st.Close()
+
+	kp, _ := keypair.Random()
+	account := block.NewBlockAccount(kp.Address(), common.Amount(900))
+	require.Nil(t, account.Save(st, nil))
+
+	config := block.NewGenesisConfigFromAccount(*account)
+	config.MaxSupply = common.Amount(1000)
+	_, err := block.MakeGenesisBlockFromConfig(st, config, networkID, kp)
+	require.Nil(t, err)
+
+	makeTx := func(amount common.Amount) transaction.Transaction {
+		return transaction.Transaction{
+			B: transaction.TransactionBody{
+				Source: kp.Address(),
+				Operations: []transaction.Operation{
+					{
+						H: transaction.OperationHeader{Type: "test-issue"},
+						B: testOperationBodyIssue{Amount: amount},
+					},
+				},
+			},
+		}
+	}
+
+	invalid := fmt.Errorf("invalid")
+	validate := func(_ *storage.LevelDBBackend, tx transaction.Transaction, _ *sourceState) error {
+		if tx.B.Operations[0].B.(testOperationBodyIssue).Amount == 1 {
+			return invalid
+		}
+		return nil
+	}
+
+	{ // each one is under the max supply, but not the both
+		txs := []transaction.Transaction{makeTx(60), makeTx(41), makeTx(40)}
+		errs, err := validateTxsWith(st, txs, 2, time.Time{}, nil, validate)
+		require.Nil(t, err)
+		require.Nil(t, errs[0])
+		require.Equal(t, errors.ErrorOverMaxSupply, errs[1])
+		require.Nil(t, errs[2])
+	}
+
+	{ // the invalid transaction is not counted
+		txs := []transaction.Transaction{makeTx(1), makeTx(100)}
+		errs, err := validateTxsWith(st, txs, 2, time.Time{}, nil, validate)
+		require.Nil(t, err)
+		require.Equal(t, invalid, errs[0])
+		require.Nil(t, errs[1])
+	}
+
+	{ // one transaction over the max supply
+		txs := []transaction.Transaction{makeTx(101)}
+		errs, err := validateTxsWith(st, txs, 2, time.Time{}, nil, validate)
+		require.Nil(t, err)
+		require.Equal(t, errors.ErrorOverMaxSupply, errs[0])
+	}
+}
@@ -75,9 +75,21 @@ func (v *Validator) Alias() string {
 }
 
 func (v *Validator) Endpoint() *common.Endpoint {
+	v.Lock()
+	defer v.Unlock()
+
 	return v.endpoint
 }
 
+// SetEndpoint changes the endpoint of validator, when it is updated by
+// `transaction.OperationUpdateEndpoint`.
+func (v *Validator) SetEndpoint(endpoint *common.Endpoint) {
+	v.Lock()
+	defer v.Unlock()
+
+	v.endpoint = endpoint
+}
+
 func (v *Validator) MarshalJSON() ([]byte, error) {
 	return json.Marshal(map[string]interface{}{
 		"address":  v.Address(),
@@ -0,0 +1,68 @@
+package node
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/stellar/go/keypair"
+
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/error"
+)
+
+// ValidatorConfig is the entry of validators config, like,
+//
+//	[
+//	  {"address": "GD..", "endpoint": "https://localhost:12345", "alias": "v1"},
+//	  ...
+//	]
+type ValidatorConfig struct {
+	Address  string `json:"address"`
+	Endpoint string `json:"endpoint"`
+	Alias    string `json:"alias"`
+}
+
+// LoadValidatorsFromJSON makes `Validator`s from the validators config. Every
+// entry is checked and all the found errors are returned in
+// `errors.ErrorInvalidValidatorsConfig`, `Data["errors"]`.
+func LoadValidatorsFromJSON(b []byte) (validators []*Validator, err error) {
+	var configs []ValidatorConfig
+	if err = json.Unmarshal(b, &configs); err != nil {
+		return
+	}
+
+	var messages []string
+	addresses := map[string]bool{}
+	for i, config := range configs {
+		if _, err := keypair.Parse(config.Address); err != nil {
+			messages = append(messages, fmt.Sprintf("#%d: invalid address, '%s': %v", i, config.Address, err))
+			continue
+		}
+		if _, found := addresses[config.Address]; found {
+			messages = append(messages, fmt.Sprintf("#%d: duplicated address, '%s'", i, config.Address))
+			continue
+		}
+		addresses[config.Address] = true
+
+		endpoint, err := common.ParseEndpoint(config.Endpoint)
+		if err != nil {
+			messages = append(messages, fmt.Sprintf("#%d: invalid endpoint, '%s': %v", i, config.Endpoint, err))
+			continue
+		}
+
+		validator, err := NewValidator(config.Address, endpoint, config.Alias)
+		if err != nil {
+			messages = append(messages, fmt.Sprintf("#%d: %v", i, err))
+			continue
+		}
+		validators = append(validators, validator)
+	}
+
+	if len(messages) > 0 {
+		validators = nil
+		err = errors.ErrorInvalidValidatorsConfig.Clone().SetData("errors", messages)
+		return
+	}
+
+	return
+}
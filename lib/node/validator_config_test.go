@@ -0,0 +1,75 @@
+package node
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stretchr/testify/require"
+
+	"boscoin.io/sebak/lib/error"
+)
+
+func TestLoadValidatorsFromJSON(t *testing.T) {
+	kp0, _ := keypair.Random()
+	kp1, _ := keypair.Random()
+
+	b := []byte(fmt.Sprintf(`[
+		{"address": "%s", "endpoint": "https://localhost:12345", "alias": "v0"},
+		{"address": "%s", "endpoint": "https://localhost:12346"}
+	]`, kp0.Address(), kp1.Address()))
+
+	validators, err := LoadValidatorsFromJSON(b)
+	require.Nil(t, err)
+	require.Equal(t, 2, len(validators))
+
+	require.Equal(t, kp0.Address(), validators[0].Address())
+	require.Equal(t, "v0", validators[0].Alias())
+	require.Equal(t, "https://localhost:12345", validators[0].Endpoint().String())
+
+	require.Equal(t, kp1.Address(), validators[1].Address())
+	require.Equal(t, MakeAlias(kp1.Address()), validators[1].Alias())
+	require.Equal(t, "https://localhost:12346", validators[1].Endpoint().String())
+}
+
+func TestLoadValidatorsFromJSONMalformedEndpoint(t *testing.T) {
+	kp0, _ := keypair.Random()
+	kp1, _ := keypair.Random()
+
+	b := []byte(fmt.Sprintf(`[
+		{"address": "%s", "endpoint": "https://localhost:invalid-port"},
+		{"address": "%s", "endpoint": "https://localhost:12346"}
+	]`, kp0.Address(), kp1.Address()))
+
+	validators, err := LoadValidatorsFromJSON(b)
+	require.Nil(t, validators)
+	require.NotNil(t, err)
+
+	e, ok := err.(*errors.Error)
+	require.True(t, ok)
+	require.Equal(t, errors.ErrorInvalidValidatorsConfig.Code, e.Code)
+	require.Equal(t, 1, len(e.Data["errors"].([]string)))
+}
+
+func TestLoadValidatorsFromJSONDuplicatedAddress(t *testing.T) {
+	kp0, _ := keypair.Random()
+
+	b := []byte(fmt.Sprintf(`[
+		{"address": "%s", "endpoint": "https://localhost:12345"},
+		{"address": "%s", "endpoint": "https://localhost:12346"},
+		{"address": "showme", "endpoint": "https://localhost:12347"}
+	]`, kp0.Address(), kp0.Address()))
+
+	validators, err := LoadValidatorsFromJSON(b)
+	require.Nil(t, validators)
+
+	e, ok := err.(*errors.Error)
+	require.True(t, ok)
+	require.Equal(t, errors.ErrorInvalidValidatorsConfig.Code, e.Code)
+
+	// all the errors are aggregated
+	messages := e.Data["errors"].([]string)
+	require.Equal(t, 2, len(messages))
+	require.Contains(t, messages[0], "duplicated address")
+	require.Contains(t, messages[1], "invalid address")
+}
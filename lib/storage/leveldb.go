@@ -27,6 +27,26 @@ type LevelDBBackend struct {
 	DB *leveldb.DB
 
 	Core LevelDBCore
+
+	release  func()
+	readOnly bool
+}
+
+// levelDBSnapshotCore is the read-only `LevelDBCore` of `*leveldb.Snapshot`.
+type levelDBSnapshotCore struct {
+	*leveldb.Snapshot
+}
+
+func (c levelDBSnapshotCore) Put([]byte, []byte, *leveldbOpt.WriteOptions) error {
+	return errors.New("snapshot is read-only")
+}
+
+func (c levelDBSnapshotCore) Write(*leveldb.Batch, *leveldbOpt.WriteOptions) error {
+	return errors.New("snapshot is read-only")
+}
+
+func (c levelDBSnapshotCore) Delete([]byte, *leveldbOpt.WriteOptions) error {
+	return errors.New("snapshot is read-only")
 }
 
 func setLevelDBCoreError(err error) error {
@@ -62,13 +82,34 @@ func (st *LevelDBBackend) Init(config *Config) (err error) {
 	return
 }
 
+// NewReadOnlyLevelDBBackend opens the existing file storage in read-only mode,
+// for example, to analyze the storage of node without risking writes. The
+// writes to the returned storage fail with `errors.ErrorReadOnlyStorage`.
+func NewReadOnlyLevelDBBackend(path string) (*LevelDBBackend, error) {
+	db, err := leveldb.OpenFile(path, &leveldbOpt.Options{ReadOnly: true, ErrorIfMissing: true})
+	if err != nil {
+		return nil, setLevelDBCoreError(err)
+	}
+
+	return &LevelDBBackend{DB: db, Core: db, readOnly: true}, nil
+}
+
+// IsReadOnly returns true when the storage is opened by
+// `NewReadOnlyLevelDBBackend`.
+func (st *LevelDBBackend) IsReadOnly() bool {
+	return st.readOnly
+}
+
 func (st *LevelDBBackend) Close() error {
 	return st.DB.Close()
 }
 
 func (st *LevelDBBackend) OpenTransaction() (*LevelDBBackend, error) {
-	_, ok := st.Core.(*leveldb.Transaction)
-	if ok {
+	if st.readOnly {
+		return nil, errors.ErrorReadOnlyStorage
+	}
+
+	if _, ok := st.transaction(); ok {
 		return nil, errors.New("this is already *leveldb.Transaction")
 	}
 
@@ -84,8 +125,20 @@ func (st *LevelDBBackend) OpenTransaction() (*LevelDBBackend, error) {
 	}, nil
 }
 
+// transaction returns the `*leveldb.Transaction` of `OpenTransaction`; it is
+// found also while `RecordUndo`.
+func (st *LevelDBBackend) transaction() (*leveldb.Transaction, bool) {
+	core := st.Core
+	if c, ok := core.(*undoCore); ok {
+		core = c.LevelDBCore
+	}
+
+	ts, ok := core.(*leveldb.Transaction)
+	return ts, ok
+}
+
 func (st *LevelDBBackend) Discard() error {
-	ts, ok := st.Core.(*leveldb.Transaction)
+	ts, ok := st.transaction()
 	if !ok {
 		return setLevelDBCoreError(errors.New("this is not *leveldb.Transaction"))
 	}
@@ -95,7 +148,7 @@ func (st *LevelDBBackend) Discard() error {
 }
 
 func (st *LevelDBBackend) Commit() error {
-	ts, ok := st.Core.(*leveldb.Transaction)
+	ts, ok := st.transaction()
 	if !ok {
 		return setLevelDBCoreError(errors.New("this is not *leveldb.Transaction"))
 	}
@@ -103,6 +156,43 @@ func (st *LevelDBBackend) Commit() error {
 	return setLevelDBCoreError(ts.Commit())
 }
 
+// Snapshot returns the read-only `LevelDBBackend`, which sees the state of
+// storage at the time of `Snapshot()`; the later writes are not seen. The
+// returned snapshot must be released by `Release()`.
+//
+// `*leveldb.Transaction` holds the write lock until it is committed or
+// discarded, and snapshot is not changed, so for these the returned one just
+// shares the same core.
+func (st *LevelDBBackend) Snapshot() (*LevelDBBackend, error) {
+	db, ok := st.Core.(*leveldb.DB)
+	if !ok {
+		return &LevelDBBackend{DB: st.DB, Core: st.Core, readOnly: st.readOnly}, nil
+	}
+
+	snapshot, err := db.GetSnapshot()
+	if err != nil {
+		return nil, setLevelDBCoreError(err)
+	}
+
+	return &LevelDBBackend{
+		DB:       st.DB,
+		Core:     levelDBSnapshotCore{snapshot},
+		release:  snapshot.Release,
+		readOnly: st.readOnly,
+	}, nil
+}
+
+// Release releases the snapshot, which is made by `Snapshot()`. It is safe
+// to call it multiple times.
+func (st *LevelDBBackend) Release() {
+	if st.release == nil {
+		return
+	}
+
+	st.release()
+	st.release = nil
+}
+
 func (st *LevelDBBackend) makeKey(key string) []byte {
 	return []byte(key)
 }
@@ -119,16 +209,12 @@ func (st *LevelDBBackend) Has(k string) (bool, error) {
 	return ok, nil
 }
 
+// GetRaw returns the raw value of key by one read. If the key does not exist,
+// `errors.ErrorStorageRecordDoesNotExist` is returned.
 func (st *LevelDBBackend) GetRaw(k string) (b []byte, err error) {
-	var exists bool
-	if exists, err = st.Has(k); err != nil || !exists {
-		if !exists {
-			err = errors.ErrorStorageRecordDoesNotExist
-		}
-		return
+	if b, err = st.Core.Get(st.makeKey(k), nil); err == leveldb.ErrNotFound {
+		return nil, errors.ErrorStorageRecordDoesNotExist
 	}
-
-	b, err = st.Core.Get(st.makeKey(k), nil)
 	err = setLevelDBCoreError(err)
 
 	return
@@ -149,6 +235,10 @@ func (st *LevelDBBackend) Get(k string, i interface{}) (err error) {
 }
 
 func (st *LevelDBBackend) New(k string, v interface{}) (err error) {
+	if st.readOnly {
+		return errors.ErrorReadOnlyStorage
+	}
+
 	var encoded []byte
 	serializable, ok := v.(common.Serializable)
 	if ok {
@@ -176,6 +266,10 @@ func (st *LevelDBBackend) New(k string, v interface{}) (err error) {
 }
 
 func (st *LevelDBBackend) News(vs ...Item) (err error) {
+	if st.readOnly {
+		return errors.ErrorReadOnlyStorage
+	}
+
 	if len(vs) < 1 {
 		err = setLevelDBCoreError(errors.New("empty values"))
 		return
@@ -208,6 +302,10 @@ func (st *LevelDBBackend) News(vs ...Item) (err error) {
 }
 
 func (st *LevelDBBackend) Set(k string, v interface{}) (err error) {
+	if st.readOnly {
+		return errors.ErrorReadOnlyStorage
+	}
+
 	var encoded []byte
 	if encoded, err = common.EncodeJSONValue(v); err != nil {
 		err = setLevelDBCoreError(err)
@@ -229,6 +327,10 @@ func (st *LevelDBBackend) Set(k string, v interface{}) (err error) {
 }
 
 func (st *LevelDBBackend) Sets(vs ...Item) (err error) {
+	if st.readOnly {
+		return errors.ErrorReadOnlyStorage
+	}
+
 	if len(vs) < 1 {
 		err = setLevelDBCoreError(errors.New("empty values"))
 		return
@@ -262,6 +364,10 @@ func (st *LevelDBBackend) Sets(vs ...Item) (err error) {
 }
 
 func (st *LevelDBBackend) Remove(k string) (err error) {
+	if st.readOnly {
+		return errors.ErrorReadOnlyStorage
+	}
+
 	var exists bool
 	if exists, err = st.Has(k); !exists || err != nil {
 		if !exists {
@@ -276,6 +382,34 @@ func (st *LevelDBBackend) Remove(k string) (err error) {
 	return
 }
 
+// Delete removes the record. Unlike `Remove`, the missing record is not an
+// error.
+func (st *LevelDBBackend) Delete(k string) error {
+	if st.readOnly {
+		return errors.ErrorReadOnlyStorage
+	}
+
+	return setLevelDBCoreError(st.Core.Delete(st.makeKey(k), nil))
+}
+
+// Deletes removes the records at once by the batch.
+func (st *LevelDBBackend) Deletes(ks ...string) error {
+	if st.readOnly {
+		return errors.ErrorReadOnlyStorage
+	}
+
+	if len(ks) < 1 {
+		return nil
+	}
+
+	batch := new(leveldb.Batch)
+	for _, k := range ks {
+		batch.Delete(st.makeKey(k))
+	}
+
+	return setLevelDBCoreError(st.Core.Write(batch, nil))
+}
+
 func (st *LevelDBBackend) GetIterator(prefix string, option ListOptions) (func() (IterItem, bool), func()) {
 	var reverse = false
 	var cursor []byte
@@ -2,6 +2,8 @@ package storage
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
 	"reflect"
 	"sort"
 	"testing"
@@ -156,6 +158,148 @@ func TestLevelDBBackendRemove(t *testing.T) {
 	}
 }
 
+func TestLevelDBBackendDelete(t *testing.T) {
+	st := NewTestStorage()
+	defer st.Close()
+
+	// missing key
+	require.Nil(t, st.Delete("showme"))
+
+	require.Nil(t, st.New("showme", 1))
+	require.Nil(t, st.Delete("showme"))
+	exists, _ := st.Has("showme")
+	require.False(t, exists)
+
+	require.Nil(t, st.News(Item{Key: "a", Value: 1}, Item{Key: "b", Value: 2}, Item{Key: "c", Value: 3}))
+	require.Nil(t, st.Deletes("a", "b", "findme"))
+	for key, expected := range map[string]bool{"a": false, "b": false, "c": true} {
+		exists, _ := st.Has(key)
+		require.Equal(t, expected, exists, key)
+	}
+}
+
+func TestLevelDBBackendReadOnly(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sebak-storage")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	config, err := NewConfigFromString("file://" + dir)
+	require.Nil(t, err)
+	st, err := NewStorage(config)
+	require.Nil(t, err)
+	require.False(t, st.IsReadOnly())
+	require.Nil(t, st.New("a", 1))
+	require.Nil(t, st.New("b", 2))
+	require.Nil(t, st.Close())
+
+	// missing storage is not created
+	_, err = NewReadOnlyLevelDBBackend(dir + "-findme")
+	require.NotNil(t, err)
+
+	ro, err := NewReadOnlyLevelDBBackend(dir)
+	require.Nil(t, err)
+	defer ro.Close()
+	require.True(t, ro.IsReadOnly())
+
+	{ // read
+		var v int
+		require.Nil(t, ro.Get("a", &v))
+		require.Equal(t, 1, v)
+
+		var keys []string
+		iterFunc, closeFunc := ro.GetIterator("", NewDefaultListOptions(false, nil, 10))
+		for {
+			item, hasNext := iterFunc()
+			if !hasNext {
+				break
+			}
+			keys = append(keys, string(item.Key))
+		}
+		closeFunc()
+		require.Equal(t, []string{"a", "b"}, keys)
+
+		snapshot, err := ro.Snapshot()
+		require.Nil(t, err)
+		require.True(t, snapshot.IsReadOnly())
+		require.Nil(t, snapshot.Get("b", &v))
+		require.Equal(t, 2, v)
+		snapshot.Release()
+	}
+
+	{ // write
+		require.Equal(t, errors.ErrorReadOnlyStorage, ro.New("c", 3))
+		require.Equal(t, errors.ErrorReadOnlyStorage, ro.News(Item{Key: "c", Value: 3}))
+		require.Equal(t, errors.ErrorReadOnlyStorage, ro.Set("a", 3))
+		require.Equal(t, errors.ErrorReadOnlyStorage, ro.Sets(Item{Key: "a", Value: 3}))
+		require.Equal(t, errors.ErrorReadOnlyStorage, ro.Remove("a"))
+		require.Equal(t, errors.ErrorReadOnlyStorage, ro.Delete("a"))
+		require.Equal(t, errors.ErrorReadOnlyStorage, ro.Deletes("a"))
+
+		_, err = ro.OpenTransaction()
+		require.Equal(t, errors.ErrorReadOnlyStorage, err)
+
+		exists, _ := ro.Has("a")
+		require.True(t, exists)
+		exists, _ = ro.Has("c")
+		require.False(t, exists)
+	}
+}
+
+func TestLevelDBBackendSnapshot(t *testing.T) {
+	st := NewTestStorage()
+	defer st.Close()
+
+	require.Nil(t, st.New("showme", "1"))
+
+	snapshot, err := st.Snapshot()
+	require.Nil(t, err)
+	defer snapshot.Release()
+
+	// the writes after snapshot are not seen
+	require.Nil(t, st.Set("showme", "2"))
+	require.Nil(t, st.New("findme", "1"))
+
+	var value string
+	require.Nil(t, snapshot.Get("showme", &value))
+	require.Equal(t, "1", value)
+
+	exists, err := snapshot.Has("findme")
+	require.Nil(t, err)
+	require.False(t, exists)
+
+	iterFunc, closeFunc := snapshot.GetIterator("", nil)
+	var keys []string
+	for {
+		item, hasNext := iterFunc()
+		if !hasNext {
+			break
+		}
+		keys = append(keys, string(item.Key))
+	}
+	closeFunc()
+	require.Equal(t, []string{"showme"}, keys)
+
+	require.Nil(t, st.Get("showme", &value))
+	require.Equal(t, "2", value)
+
+	// snapshot is read-only
+	require.NotNil(t, snapshot.New("new", "1"))
+
+	{ // snapshot of transaction shares the transaction
+		ts, err := st.OpenTransaction()
+		require.Nil(t, err)
+		require.Nil(t, ts.Set("showme", "3"))
+
+		tsSnapshot, err := ts.Snapshot()
+		require.Nil(t, err)
+		require.Nil(t, tsSnapshot.Get("showme", &value))
+		require.Equal(t, "3", value)
+		tsSnapshot.Release()
+
+		require.Nil(t, ts.Discard())
+	}
+}
+
 func TestLevelDBIterator(t *testing.T) {
 	st := NewTestStorage()
 	defer st.Close()
@@ -0,0 +1,161 @@
+package storage
+
+import (
+	"encoding/json"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	leveldbOpt "github.com/syndtr/goleveldb/leveldb/opt"
+
+	"boscoin.io/sebak/lib/error"
+)
+
+// UndoRecord has the previous values of the records, which are written while
+// recording; see `LevelDBBackend.RecordUndo`. The value of the record, which
+// did not exist, is nil, so it is deleted by `LevelDBBackend.ApplyUndo`.
+type UndoRecord map[string][]byte
+
+// undoRecordItem is the stored form of the record of `UndoRecord`. The key is
+// stored as bytes, not as the key of JSON object, because the key can have
+// the bytes, which are not valid UTF-8, like the encoded height.
+type undoRecordItem struct {
+	Key   []byte `json:"key"`
+	Value []byte `json:"value"`
+}
+
+func (r UndoRecord) MarshalJSON() ([]byte, error) {
+	items := make([]undoRecordItem, 0, len(r))
+	for k, v := range r {
+		items = append(items, undoRecordItem{Key: []byte(k), Value: v})
+	}
+
+	return json.Marshal(items)
+}
+
+func (r *UndoRecord) UnmarshalJSON(b []byte) error {
+	var items []undoRecordItem
+	if err := json.Unmarshal(b, &items); err != nil {
+		return err
+	}
+
+	*r = UndoRecord{}
+	for _, item := range items {
+		(*r)[string(item.Key)] = item.Value
+	}
+
+	return nil
+}
+
+// undoCore is the `LevelDBCore`, which keeps the previous value of the record
+// before it is written at first.
+type undoCore struct {
+	LevelDBCore
+
+	record UndoRecord
+}
+
+func (c *undoCore) keep(key []byte) error {
+	if _, found := c.record[string(key)]; found {
+		return nil
+	}
+
+	b, err := c.LevelDBCore.Get(key, nil)
+	if err == leveldb.ErrNotFound {
+		c.record[string(key)] = nil
+		return nil
+	} else if err != nil {
+		return err
+	}
+	c.record[string(key)] = b
+
+	return nil
+}
+
+func (c *undoCore) Put(key, value []byte, o *leveldbOpt.WriteOptions) error {
+	if err := c.keep(key); err != nil {
+		return err
+	}
+
+	return c.LevelDBCore.Put(key, value, o)
+}
+
+func (c *undoCore) Delete(key []byte, o *leveldbOpt.WriteOptions) error {
+	if err := c.keep(key); err != nil {
+		return err
+	}
+
+	return c.LevelDBCore.Delete(key, o)
+}
+
+func (c *undoCore) Write(batch *leveldb.Batch, o *leveldbOpt.WriteOptions) error {
+	keys := &batchKeys{}
+	if err := batch.Replay(keys); err != nil {
+		return err
+	}
+	for _, key := range keys.keys {
+		if err := c.keep(key); err != nil {
+			return err
+		}
+	}
+
+	return c.LevelDBCore.Write(batch, o)
+}
+
+// batchKeys collects the keys of `leveldb.Batch`.
+type batchKeys struct {
+	keys [][]byte
+}
+
+func (b *batchKeys) Put(key, value []byte) {
+	b.keys = append(b.keys, append([]byte(nil), key...))
+}
+
+func (b *batchKeys) Delete(key []byte) {
+	b.keys = append(b.keys, append([]byte(nil), key...))
+}
+
+// RecordUndo starts to keep the previous values of the records, which are
+// written after; the kept values are returned by `StopUndo`. It works only
+// in the transaction of `OpenTransaction`, which is committed or discarded
+// as usual.
+func (st *LevelDBBackend) RecordUndo() error {
+	if _, ok := st.Core.(*leveldb.Transaction); !ok {
+		return setLevelDBCoreError(errors.New("this is not *leveldb.Transaction"))
+	}
+
+	st.Core = &undoCore{LevelDBCore: st.Core, record: UndoRecord{}}
+
+	return nil
+}
+
+// StopUndo stops `RecordUndo` and returns the kept values.
+func (st *LevelDBBackend) StopUndo() UndoRecord {
+	core, ok := st.Core.(*undoCore)
+	if !ok {
+		return nil
+	}
+	st.Core = core.LevelDBCore
+
+	return core.record
+}
+
+// ApplyUndo restores the records to the values of `UndoRecord`.
+func (st *LevelDBBackend) ApplyUndo(record UndoRecord) error {
+	if st.readOnly {
+		return errors.ErrorReadOnlyStorage
+	}
+
+	if len(record) < 1 {
+		return nil
+	}
+
+	batch := new(leveldb.Batch)
+	for k, v := range record {
+		if v == nil {
+			batch.Delete(st.makeKey(k))
+		} else {
+			batch.Put(st.makeKey(k), v)
+		}
+	}
+
+	return setLevelDBCoreError(st.Core.Write(batch, nil))
+}
@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"boscoin.io/sebak/lib/error"
+)
+
+func TestLevelDBBackendUndo(t *testing.T) {
+	st := NewTestStorage()
+	defer st.Close()
+
+	require.Nil(t, st.New("changed", 1))
+	require.Nil(t, st.New("removed", 2))
+	require.Nil(t, st.New("kept", 3))
+
+	ts, err := st.OpenTransaction()
+	require.Nil(t, err)
+	require.Nil(t, ts.RecordUndo())
+
+	require.Nil(t, ts.Set("changed", 10))
+	require.Nil(t, ts.Set("changed", 100))
+	require.Nil(t, ts.Remove("removed"))
+	require.Nil(t, ts.News(Item{"created", 4}))
+
+	record := ts.StopUndo()
+	require.Equal(t, 3, len(record))
+	require.Nil(t, record["created"])
+
+	// not recorded after `StopUndo`
+	require.Nil(t, ts.Set("kept", 30))
+	require.Nil(t, ts.Commit())
+
+	var v int
+	require.Nil(t, st.Get("changed", &v))
+	require.Equal(t, 100, v)
+
+	ts, err = st.OpenTransaction()
+	require.Nil(t, err)
+	require.Nil(t, ts.ApplyUndo(record))
+	require.Nil(t, ts.Commit())
+
+	require.Nil(t, st.Get("changed", &v))
+	require.Equal(t, 1, v)
+	require.Nil(t, st.Get("removed", &v))
+	require.Equal(t, 2, v)
+	require.Nil(t, st.Get("kept", &v))
+	require.Equal(t, 30, v)
+	require.Equal(t, errors.ErrorStorageRecordDoesNotExist, st.Get("created", &v))
+
+	// only in transaction
+	require.NotNil(t, st.RecordUndo())
+}
+
+func TestLevelDBBackendUndoDiscard(t *testing.T) {
+	st := NewTestStorage()
+	defer st.Close()
+
+	ts, err := st.OpenTransaction()
+	require.Nil(t, err)
+	require.Nil(t, ts.RecordUndo())
+	require.Nil(t, ts.New("created", 1))
+	require.Nil(t, ts.Discard())
+
+	exists, err := st.Has("created")
+	require.Nil(t, err)
+	require.False(t, exists)
+}
+
+// The stored `UndoRecord` keeps the keys, which are not valid UTF-8.
+func TestUndoRecordStored(t *testing.T) {
+	st := NewTestStorage()
+	defer st.Close()
+
+	key := string([]byte{0x06, 0xff, 0xfe, 0x00})
+	record := UndoRecord{key: []byte("1"), "created": nil}
+	require.Nil(t, st.New("undo", record))
+
+	var stored UndoRecord
+	require.Nil(t, st.Get("undo", &stored))
+	require.Equal(t, record, stored)
+}
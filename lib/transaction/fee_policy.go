@@ -0,0 +1,91 @@
+package transaction
+
+import (
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/error"
+)
+
+// FeePolicy decides the fee of each operation. The fee of transaction,
+// `TransactionBody.Fee` for each operation, must cover the sum of the fees of
+// it's operations.
+type FeePolicy interface {
+	FeeFor(op Operation) common.Amount
+}
+
+// DefaultFeePolicy is used to validate the fee of transaction. The fee
+// policy is the network parameter, which is stored with the genesis block, so
+// the node sets it by `block.GetFeePolicy()` at start.
+var DefaultFeePolicy FeePolicy = OperationFeePolicy{}
+
+// OperationFeePolicy charges the fee by the type of operation. The operation
+// type, which is not in `Fees`, is charged `common.BaseFee`.
+//
+// The operation type in `PerByteFees` is also charged it's fee for each byte
+// of the serialized operation, so the larger operation costs more.
+type OperationFeePolicy struct {
+	Fees        map[OperationType]common.Amount
+	PerByteFees map[OperationType]common.Amount
+}
+
+func (p OperationFeePolicy) FeeFor(op Operation) common.Amount {
+	fee := common.BaseFee
+	if f, found := p.Fees[op.H.Type]; found {
+		fee = f
+	}
+
+	perByteFee, found := p.PerByteFees[op.H.Type]
+	if !found || perByteFee < 1 {
+		return fee
+	}
+
+	// the operation, which can not be serialized, can not be covered by any
+	// fee
+	size, err := op.SerializedSize()
+	if err != nil {
+		return common.MaximumBalance
+	}
+
+	// the overflowed fee can not be covered by any fee
+	sizeFee, err := perByteFee.MultInt(size)
+	if err != nil {
+		return common.MaximumBalance
+	}
+	if fee, err = fee.Add(sizeFee); err != nil {
+		return common.MaximumBalance
+	}
+
+	return fee
+}
+
+// RequiredFee returns the sum of the fees of the operations by the
+// `FeePolicy`.
+func (tx Transaction) RequiredFee(policy FeePolicy) (fee common.Amount, err error) {
+	for _, op := range tx.B.Operations {
+		if fee, err = fee.Add(policy.FeeFor(op)); err != nil {
+			err = errors.ErrorTransactionAmountOverflow
+			return
+		}
+	}
+
+	return
+}
+
+// CheckFee checks the fee of transaction, `TransactionBody.Fee` for each
+// operation, covers `RequiredFee()`.
+func (tx Transaction) CheckFee(policy FeePolicy) (err error) {
+	var required, fee common.Amount
+	if required, err = tx.RequiredFee(policy); err != nil {
+		return
+	}
+	if fee, err = tx.B.Fee.MultInt(len(tx.B.Operations)); err != nil {
+		err = errors.ErrorTransactionAmountOverflow
+		return
+	}
+
+	if fee < required {
+		err = errors.ErrorInvalidFee
+		return
+	}
+
+	return
+}
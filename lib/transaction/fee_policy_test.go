@@ -0,0 +1,138 @@
+package transaction
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stretchr/testify/require"
+
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/error"
+)
+
+func TestOperationFeePolicy(t *testing.T) {
+	kpNew, _ := keypair.Random()
+	kpTarget, _ := keypair.Random()
+
+	createAccount := Operation{
+		H: OperationHeader{Type: OperationCreateAccount},
+		B: NewOperationBodyCreateAccount(kpNew.Address(), common.BaseReserve, ""),
+	}
+	payment := Operation{
+		H: OperationHeader{Type: OperationPayment},
+		B: NewOperationBodyPayment(kpTarget.Address(), common.Amount(100)),
+	}
+
+	{ // default
+		policy := OperationFeePolicy{}
+		require.Equal(t, common.BaseFee, policy.FeeFor(createAccount))
+		require.Equal(t, common.BaseFee, policy.FeeFor(payment))
+
+		tx, _ := NewTransaction(kp.Address(), 0, createAccount, payment)
+		required, err := tx.RequiredFee(policy)
+		require.Nil(t, err)
+		require.Equal(t, common.BaseFee.MustMult(2), required)
+		require.Nil(t, tx.CheckFee(policy))
+	}
+
+	{ // create-account costs more
+		policy := OperationFeePolicy{
+			Fees: map[OperationType]common.Amount{
+				OperationCreateAccount: common.BaseFee.MustMult(10),
+			},
+		}
+		require.Equal(t, common.BaseFee.MustMult(10), policy.FeeFor(createAccount))
+		require.Equal(t, common.BaseFee, policy.FeeFor(payment))
+
+		tx, _ := NewTransaction(kp.Address(), 0, createAccount, payment)
+		required, err := tx.RequiredFee(policy)
+		require.Nil(t, err)
+		require.Equal(t, common.BaseFee.MustMult(11), required)
+		require.Equal(t, errors.ErrorInvalidFee, tx.CheckFee(policy))
+
+		// fee for each operation; 6 * 2 covers 11
+		tx.B.Fee = common.BaseFee.MustMult(6)
+		require.Nil(t, tx.CheckFee(policy))
+
+		tx.B.Operations = []Operation{payment}
+		tx.B.Fee = common.BaseFee
+		require.Nil(t, tx.CheckFee(policy))
+	}
+}
+
+func TestOperationFeePolicyPerByteFee(t *testing.T) {
+	makeOp := func(size int) Operation {
+		return Operation{
+			H: OperationHeader{Type: OperationSetAccountData},
+			B: NewOperationBodySetAccountData(kp.Address(), []AccountData{
+				{Key: "k", Value: strings.Repeat("v", size-1)},
+			}),
+		}
+	}
+	small := makeOp(10)
+	large := makeOp(500)
+
+	kpTarget, _ := keypair.Random()
+	payment := Operation{
+		H: OperationHeader{Type: OperationPayment},
+		B: NewOperationBodyPayment(kpTarget.Address(), common.Amount(100)),
+	}
+
+	policy := OperationFeePolicy{
+		PerByteFees: map[OperationType]common.Amount{
+			OperationSetAccountData: common.Amount(100),
+		},
+	}
+
+	sizeOf := func(op Operation) int {
+		size, err := op.SerializedSize()
+		require.Nil(t, err)
+		return size
+	}
+
+	// base fee and the fee by the size of serialized operation
+	require.Equal(t, common.BaseFee+common.Amount(100*sizeOf(small)), policy.FeeFor(small))
+	require.Equal(t, common.BaseFee+common.Amount(100*sizeOf(large)), policy.FeeFor(large))
+	require.True(t, sizeOf(large) > 500)
+
+	// the operation type without per byte fee is not charged by size
+	require.Equal(t, common.BaseFee, policy.FeeFor(payment))
+
+	{ // the fee, which covers the small data, does not cover the large one
+		tx, _ := NewTransaction(kp.Address(), 0, small)
+		tx.B.Fee = policy.FeeFor(small)
+		require.Nil(t, tx.CheckFee(policy))
+
+		tx.B.Operations = []Operation{large}
+		require.Equal(t, errors.ErrorInvalidFee, tx.CheckFee(policy))
+
+		tx.B.Fee = policy.FeeFor(large)
+		require.Nil(t, tx.CheckFee(policy))
+
+		// one unit under the minimum by size
+		tx.B.Fee = policy.FeeFor(large) - 1
+		require.Equal(t, errors.ErrorInvalidFee, tx.CheckFee(policy))
+	}
+
+	{ // any operation type can be charged by size
+		policy := OperationFeePolicy{
+			PerByteFees: map[OperationType]common.Amount{
+				OperationPayment: common.Amount(10),
+			},
+		}
+		required := common.BaseFee + common.Amount(10*sizeOf(payment))
+		require.Equal(t, required, policy.FeeFor(payment))
+
+		tx, _ := NewTransaction(kp.Address(), 0, payment)
+		require.Equal(t, errors.ErrorInvalidFee, tx.CheckFee(policy))
+
+		tx.B.Fee = required
+		require.Nil(t, tx.CheckFee(policy))
+	}
+
+	{ // overflowed fee
+		policy.PerByteFees[OperationSetAccountData] = common.MaximumBalance
+		require.Equal(t, common.MaximumBalance, policy.FeeFor(large))
+	}
+}
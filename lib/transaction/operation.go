@@ -12,10 +12,23 @@ import (
 type OperationType string
 
 const (
-	OperationCreateAccount OperationType = "create-account"
-	OperationPayment                     = "payment"
+	OperationCreateAccount  OperationType = "create-account"
+	OperationPayment                      = "payment"
+	OperationSetAccountData               = "set-account-data"
+	OperationDelegate                     = "delegate"
+	OperationSetSpendLimit                = "set-spend-limit"
+	OperationUpdateEndpoint               = "update-endpoint"
 )
 
+// OperationTypes is the set of the known operation types. The new operation
+// type must be registered by `RegisterOperationBody`, otherwise the
+// transaction, which has it, is rejected by `IsWellFormed`.
+var OperationTypes = map[OperationType]bool{}
+
+func (t OperationType) IsKnown() bool {
+	return OperationTypes[t]
+}
+
 type Operation struct {
 	H OperationHeader
 	B OperationBody
@@ -47,6 +60,14 @@ type OperationBodyPayable interface {
 	GetAmount() common.Amount
 }
 
+// OperationBodyIssuer is the operation body, which issues the new coins like
+// inflation. The total supply is increased by `IssuedAmount()`, so the
+// operation is checked against the max supply before it is applied.
+type OperationBodyIssuer interface {
+	OperationBody
+	IssuedAmount() common.Amount
+}
+
 func (o Operation) MakeHash() []byte {
 	return common.MustMakeObjectHash(o)
 }
@@ -55,6 +76,18 @@ func (o Operation) MakeHashString() string {
 	return base58.Encode(o.MakeHash())
 }
 
+// MakeCanonicalHash makes the hash from the canonical json of `Operation`,
+// so the hash does not depend on how the `OperationBody` is constructed. It
+// is used by the transaction of `TransactionVersionCanonical`.
+func (o Operation) MakeCanonicalHash() ([]byte, error) {
+	b, err := o.SerializeCanonical()
+	if err != nil {
+		return nil, err
+	}
+
+	return common.MakeHash(b), nil
+}
+
 func (o Operation) IsWellFormed(networkID []byte) (err error) {
 	return o.B.IsWellFormed(networkID)
 }
@@ -63,6 +96,24 @@ func (o Operation) Serialize() (encoded []byte, err error) {
 	return json.Marshal(o)
 }
 
+// SerializeCanonical serializes `Operation` into the canonical json, which
+// has the sorted keys. It is used for hashing; `Serialize()` is for display
+// and transfer.
+func (o Operation) SerializeCanonical() (encoded []byte, err error) {
+	return common.CanonicalJSONMarshal(o)
+}
+
+// SerializedSize returns the bytes of the canonical json of `Operation`; see
+// `OperationFeePolicy`.
+func (o Operation) SerializedSize() (size int, err error) {
+	var b []byte
+	if b, err = o.SerializeCanonical(); err != nil {
+		return
+	}
+
+	return len(b), nil
+}
+
 func (o Operation) String() string {
 	encoded, _ := json.MarshalIndent(o, "", "  ")
 
@@ -86,7 +137,7 @@ func (o *Operation) UnmarshalJSON(b []byte) (err error) {
 	o.H = oj.H
 
 	var body OperationBody
-	if body, err = UnmarshalOperationBodyJSON(oj.H.Type, envelop); err != nil {
+	if body, err = UnmarshalOperationBody(oj.H.Type, envelop); err != nil {
 		return
 	}
 	o.B = body
@@ -94,24 +145,28 @@ func (o *Operation) UnmarshalJSON(b []byte) (err error) {
 	return
 }
 
-func UnmarshalOperationBodyJSON(t OperationType, b []byte) (body OperationBody, err error) {
-	switch t {
-	case OperationCreateAccount:
-		var ob OperationBodyCreateAccount
-		if err = json.Unmarshal(b, &ob); err != nil {
-			return
-		}
-		body = ob
-	case OperationPayment:
-		var ob OperationBodyPayment
-		if err = json.Unmarshal(b, &ob); err != nil {
-			return
-		}
-		body = ob
-	default:
-		err = errors.ErrorInvalidOperation
-		return
+// OperationBodyDecoder decodes the json of the `OperationBody` of one
+// operation type.
+type OperationBodyDecoder func(json.RawMessage) (OperationBody, error)
+
+var operationBodyDecoders = map[OperationType]OperationBodyDecoder{}
+
+// RegisterOperationBody registers the decoder of the operation type and adds
+// the type to `OperationTypes`. Every operation type registers itself in
+// it's `init()`.
+func RegisterOperationBody(t OperationType, decoder OperationBodyDecoder) {
+	operationBodyDecoders[t] = decoder
+	OperationTypes[t] = true
+}
+
+// UnmarshalOperationBody decodes the json of `OperationBody` by the decoder
+// of the operation type. If the type is not registered,
+// `errors.ErrorTransactionUnknownOperation` is returned.
+func UnmarshalOperationBody(t OperationType, raw json.RawMessage) (OperationBody, error) {
+	decoder, found := operationBodyDecoders[t]
+	if !found {
+		return nil, errors.ErrorTransactionUnknownOperation
 	}
 
-	return
+	return decoder(raw)
 }
@@ -14,6 +14,16 @@ type OperationBodyCreateAccount struct {
 	Linked string        `json:"linked,omitempty"`
 }
 
+func init() {
+	RegisterOperationBody(OperationCreateAccount, func(b json.RawMessage) (OperationBody, error) {
+		var ob OperationBodyCreateAccount
+		if err := json.Unmarshal(b, &ob); err != nil {
+			return nil, err
+		}
+		return ob, nil
+	})
+}
+
 func NewOperationBodyCreateAccount(target string, amount common.Amount, linked string) OperationBodyCreateAccount {
 	return OperationBodyCreateAccount{
 		Target: target,
@@ -0,0 +1,47 @@
+package transaction
+
+import (
+	"encoding/json"
+
+	"github.com/stellar/go/keypair"
+)
+
+// OperationBodyDelegate delegates the consensus weight of the source account
+// of transaction to the validator, `Delegate`. The account can not delegate
+// to itself.
+type OperationBodyDelegate struct {
+	Delegate string `json:"delegate"`
+}
+
+func init() {
+	RegisterOperationBody(OperationDelegate, func(b json.RawMessage) (OperationBody, error) {
+		var ob OperationBodyDelegate
+		if err := json.Unmarshal(b, &ob); err != nil {
+			return nil, err
+		}
+		return ob, nil
+	})
+}
+
+func NewOperationBodyDelegate(delegate string) OperationBodyDelegate {
+	return OperationBodyDelegate{
+		Delegate: delegate,
+	}
+}
+
+func (o OperationBodyDelegate) Serialize() (encoded []byte, err error) {
+	return json.Marshal(o)
+}
+
+// Implement transaction/operation : OperationBody.IsWellFormed
+func (o OperationBodyDelegate) IsWellFormed([]byte) (err error) {
+	if _, err = keypair.Parse(o.Delegate); err != nil {
+		return
+	}
+
+	return
+}
+
+func (o OperationBodyDelegate) TargetAddress() string {
+	return o.Delegate
+}
@@ -0,0 +1,72 @@
+package transaction
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stretchr/testify/require"
+
+	"boscoin.io/sebak/lib/error"
+)
+
+func makeTransactionDelegate(kpSource *keypair.Full, delegate string) (tx Transaction) {
+	op := Operation{
+		H: OperationHeader{Type: OperationDelegate},
+		B: NewOperationBodyDelegate(delegate),
+	}
+	tx, _ = NewTransaction(kpSource.Address(), 0, op)
+	tx.Sign(kpSource, networkID)
+
+	return
+}
+
+func TestDelegateOperation(t *testing.T) {
+	kpDelegate, _ := keypair.Random()
+
+	{ // valid
+		o := NewOperationBodyDelegate(kpDelegate.Address())
+		require.Nil(t, o.IsWellFormed(networkID))
+	}
+
+	{ // not keypair
+		o := NewOperationBodyDelegate("showme")
+		require.NotNil(t, o.IsWellFormed(networkID))
+	}
+
+	{ // empty
+		o := NewOperationBodyDelegate("")
+		require.NotNil(t, o.IsWellFormed(networkID))
+	}
+}
+
+func TestDelegateOperationUnmarshal(t *testing.T) {
+	kpDelegate, _ := keypair.Random()
+	tx := makeTransactionDelegate(kp, kpDelegate.Address())
+
+	b, err := json.Marshal(tx)
+	require.Nil(t, err)
+
+	var unmarshaled Transaction
+	require.Nil(t, json.Unmarshal(b, &unmarshaled))
+	require.Equal(t, tx.B.Operations[0].B, unmarshaled.B.Operations[0].B)
+	require.Equal(t, tx.B.MakeHashString(), unmarshaled.B.MakeHashString())
+}
+
+func TestIsWellFormedTransactionDelegate(t *testing.T) {
+	{ // delegate to other
+		kpDelegate, _ := keypair.Random()
+		tx := makeTransactionDelegate(kp, kpDelegate.Address())
+		require.Nil(t, tx.IsWellFormed(networkID))
+	}
+
+	{ // self delegation
+		tx := makeTransactionDelegate(kp, kp.Address())
+		require.Equal(t, errors.ErrorSelfDelegation, tx.IsWellFormed(networkID))
+	}
+
+	{ // not keypair
+		tx := makeTransactionDelegate(kp, "showme")
+		require.NotNil(t, tx.IsWellFormed(networkID))
+	}
+}
@@ -14,6 +14,16 @@ type OperationBodyPayment struct {
 	Amount common.Amount `json:"amount"`
 }
 
+func init() {
+	RegisterOperationBody(OperationPayment, func(b json.RawMessage) (OperationBody, error) {
+		var ob OperationBodyPayment
+		if err := json.Unmarshal(b, &ob); err != nil {
+			return nil, err
+		}
+		return ob, nil
+	})
+}
+
 func NewOperationBodyPayment(target string, amount common.Amount) OperationBodyPayment {
 	return OperationBodyPayment{
 		Target: target,
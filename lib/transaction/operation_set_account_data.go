@@ -0,0 +1,120 @@
+package transaction
+
+import (
+	"encoding/json"
+
+	"github.com/stellar/go/keypair"
+
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/error"
+)
+
+// AccountData is the key and value pair of the account data. The empty
+// `Value` removes the `Key` from the account.
+type AccountData struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// OperationBodySetAccountData sets the small metadata of account. Only the
+// owner of account can set it's own data, so `Target` must be same with the
+// source of transaction.
+type OperationBodySetAccountData struct {
+	Target string        `json:"target"`
+	Data   []AccountData `json:"data"`
+}
+
+func init() {
+	RegisterOperationBody(OperationSetAccountData, func(b json.RawMessage) (OperationBody, error) {
+		var ob OperationBodySetAccountData
+		if err := json.Unmarshal(b, &ob); err != nil {
+			return nil, err
+		}
+		return ob, nil
+	})
+}
+
+func NewOperationBodySetAccountData(target string, data []AccountData) OperationBodySetAccountData {
+	return OperationBodySetAccountData{
+		Target: target,
+		Data:   data,
+	}
+}
+
+func (o OperationBodySetAccountData) Serialize() (encoded []byte, err error) {
+	return json.Marshal(o)
+}
+
+// Implement transaction/operation : OperationBody.IsWellFormed
+func (o OperationBodySetAccountData) IsWellFormed([]byte) (err error) {
+	if _, err = keypair.Parse(o.Target); err != nil {
+		return
+	}
+
+	if len(o.Data) < 1 {
+		err = errors.ErrorInvalidAccountData
+		return
+	}
+
+	var size int
+	keys := map[string]bool{}
+	for _, d := range o.Data {
+		if len(d.Key) < 1 {
+			err = errors.ErrorInvalidAccountData
+			return
+		}
+		if _, found := keys[d.Key]; found {
+			err = errors.ErrorInvalidAccountData
+			return
+		}
+		keys[d.Key] = true
+		size += len(d.Key) + len(d.Value)
+	}
+
+	if len(keys) > common.MaxAccountDataKeys || size > common.MaxAccountDataBytes {
+		err = errors.ErrorAccountDataTooLarge
+		return
+	}
+
+	return
+}
+
+func (o OperationBodySetAccountData) TargetAddress() string {
+	return o.Target
+}
+
+// Apply returns the new account data, which `o.Data` is applied to the
+// given data. The given data is not modified.
+func (o OperationBodySetAccountData) Apply(data map[string]string) map[string]string {
+	applied := map[string]string{}
+	for k, v := range data {
+		applied[k] = v
+	}
+	for _, d := range o.Data {
+		if len(d.Value) < 1 {
+			delete(applied, d.Key)
+			continue
+		}
+		applied[d.Key] = d.Value
+	}
+
+	return applied
+}
+
+// CheckAccountDataLimit checks the number of keys and the total bytes of the
+// account data.
+func CheckAccountDataLimit(data map[string]string) error {
+	if len(data) > common.MaxAccountDataKeys {
+		return errors.ErrorAccountDataTooLarge
+	}
+
+	var size int
+	for k, v := range data {
+		size += len(k) + len(v)
+	}
+	if size > common.MaxAccountDataBytes {
+		return errors.ErrorAccountDataTooLarge
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package transaction
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stretchr/testify/require"
+
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/error"
+)
+
+func makeTransactionSetAccountData(kpSource *keypair.Full, target string, data []AccountData) (tx Transaction) {
+	op := Operation{
+		H: OperationHeader{Type: OperationSetAccountData},
+		B: NewOperationBodySetAccountData(target, data),
+	}
+	tx, _ = NewTransaction(kpSource.Address(), 0, op)
+	tx.Sign(kpSource, networkID)
+
+	return
+}
+
+func TestSetAccountDataOperation(t *testing.T) {
+	{ // valid
+		o := NewOperationBodySetAccountData(kp.Address(), []AccountData{{Key: "name", Value: "showme"}})
+		require.Nil(t, o.IsWellFormed(networkID))
+	}
+
+	{ // empty data
+		o := NewOperationBodySetAccountData(kp.Address(), nil)
+		require.Equal(t, errors.ErrorInvalidAccountData, o.IsWellFormed(networkID))
+	}
+
+	{ // empty key
+		o := NewOperationBodySetAccountData(kp.Address(), []AccountData{{Key: "", Value: "showme"}})
+		require.Equal(t, errors.ErrorInvalidAccountData, o.IsWellFormed(networkID))
+	}
+
+	{ // duplicated key
+		o := NewOperationBodySetAccountData(
+			kp.Address(),
+			[]AccountData{{Key: "name", Value: "showme"}, {Key: "name", Value: "findme"}},
+		)
+		require.Equal(t, errors.ErrorInvalidAccountData, o.IsWellFormed(networkID))
+	}
+
+	{ // too many keys
+		var data []AccountData
+		for i := 0; i < common.MaxAccountDataKeys+1; i++ {
+			data = append(data, AccountData{Key: fmt.Sprintf("k%d", i), Value: "v"})
+		}
+		o := NewOperationBodySetAccountData(kp.Address(), data)
+		require.Equal(t, errors.ErrorAccountDataTooLarge, o.IsWellFormed(networkID))
+	}
+
+	{ // too many bytes
+		value := strings.Repeat("v", common.MaxAccountDataBytes)
+		o := NewOperationBodySetAccountData(kp.Address(), []AccountData{{Key: "name", Value: value}})
+		require.Equal(t, errors.ErrorAccountDataTooLarge, o.IsWellFormed(networkID))
+	}
+}
+
+func TestSetAccountDataOperationApply(t *testing.T) {
+	data := map[string]string{"name": "showme", "email": "showme@example.com"}
+
+	o := NewOperationBodySetAccountData(
+		kp.Address(),
+		[]AccountData{{Key: "name", Value: "findme"}, {Key: "email", Value: ""}, {Key: "url", Value: "sebak"}},
+	)
+	applied := o.Apply(data)
+	require.Equal(t, map[string]string{"name": "findme", "url": "sebak"}, applied)
+
+	// the given data is not modified
+	require.Equal(t, "showme", data["name"])
+	require.Equal(t, 2, len(data))
+
+	require.Nil(t, CheckAccountDataLimit(applied))
+	applied["big"] = strings.Repeat("v", common.MaxAccountDataBytes)
+	require.Equal(t, errors.ErrorAccountDataTooLarge, CheckAccountDataLimit(applied))
+}
+
+func TestSetAccountDataOperationUnmarshal(t *testing.T) {
+	tx := makeTransactionSetAccountData(kp, kp.Address(), []AccountData{{Key: "name", Value: "showme"}})
+
+	b, err := json.Marshal(tx)
+	require.Nil(t, err)
+
+	var unmarshaled Transaction
+	require.Nil(t, json.Unmarshal(b, &unmarshaled))
+	require.Equal(t, tx.B.Operations[0].B, unmarshaled.B.Operations[0].B)
+	require.Equal(t, tx.B.MakeHashString(), unmarshaled.B.MakeHashString())
+}
+
+func TestIsWellFormedTransactionSetAccountData(t *testing.T) {
+	{ // owner sets it's own data
+		tx := makeTransactionSetAccountData(kp, kp.Address(), []AccountData{{Key: "name", Value: "showme"}})
+		require.Nil(t, tx.IsWellFormed(networkID))
+	}
+
+	{ // other account can not set the data
+		kpOther, _ := keypair.Random()
+		tx := makeTransactionSetAccountData(kpOther, kp.Address(), []AccountData{{Key: "name", Value: "showme"}})
+		require.Equal(t, errors.ErrorAccountDataNotOwner, tx.IsWellFormed(networkID))
+	}
+
+	{ // over the size cap
+		value := strings.Repeat("v", common.MaxAccountDataBytes)
+		tx := makeTransactionSetAccountData(kp, kp.Address(), []AccountData{{Key: "name", Value: value}})
+		require.Equal(t, errors.ErrorAccountDataTooLarge, tx.IsWellFormed(networkID))
+	}
+}
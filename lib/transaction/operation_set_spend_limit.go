@@ -0,0 +1,53 @@
+package transaction
+
+import (
+	"encoding/json"
+
+	"github.com/stellar/go/keypair"
+
+	"boscoin.io/sebak/lib/common"
+)
+
+// OperationBodySetSpendLimit sets the daily spend limit of account; the sum of
+// the amounts, which the account sends by `OperationPayment` and
+// `OperationCreateAccount` within `common.SpendLimitPeriod`, can not be over
+// `Limit`. `0` removes the limit. Only the owner of account can set it's own
+// limit, so `Target` must be same with the source of transaction.
+type OperationBodySetSpendLimit struct {
+	Target string        `json:"target"`
+	Limit  common.Amount `json:"limit"`
+}
+
+func init() {
+	RegisterOperationBody(OperationSetSpendLimit, func(b json.RawMessage) (OperationBody, error) {
+		var ob OperationBodySetSpendLimit
+		if err := json.Unmarshal(b, &ob); err != nil {
+			return nil, err
+		}
+		return ob, nil
+	})
+}
+
+func NewOperationBodySetSpendLimit(target string, limit common.Amount) OperationBodySetSpendLimit {
+	return OperationBodySetSpendLimit{
+		Target: target,
+		Limit:  limit,
+	}
+}
+
+func (o OperationBodySetSpendLimit) Serialize() (encoded []byte, err error) {
+	return json.Marshal(o)
+}
+
+// Implement transaction/operation : OperationBody.IsWellFormed
+func (o OperationBodySetSpendLimit) IsWellFormed([]byte) (err error) {
+	if _, err = keypair.Parse(o.Target); err != nil {
+		return
+	}
+
+	return
+}
+
+func (o OperationBodySetSpendLimit) TargetAddress() string {
+	return o.Target
+}
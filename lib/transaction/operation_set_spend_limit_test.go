@@ -0,0 +1,65 @@
+package transaction
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stretchr/testify/require"
+
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/error"
+)
+
+func makeTransactionSetSpendLimit(kpSource *keypair.Full, target string, limit common.Amount) (tx Transaction) {
+	op := Operation{
+		H: OperationHeader{Type: OperationSetSpendLimit},
+		B: NewOperationBodySetSpendLimit(target, limit),
+	}
+	tx, _ = NewTransaction(kpSource.Address(), 0, op)
+	tx.Sign(kpSource, networkID)
+
+	return
+}
+
+func TestSetSpendLimitOperation(t *testing.T) {
+	{ // valid
+		o := NewOperationBodySetSpendLimit(kp.Address(), common.Amount(100))
+		require.Nil(t, o.IsWellFormed(networkID))
+	}
+
+	{ // `0` removes the limit
+		o := NewOperationBodySetSpendLimit(kp.Address(), common.Amount(0))
+		require.Nil(t, o.IsWellFormed(networkID))
+	}
+
+	{ // invalid target
+		o := NewOperationBodySetSpendLimit("showme", common.Amount(100))
+		require.NotNil(t, o.IsWellFormed(networkID))
+	}
+}
+
+func TestSetSpendLimitOperationUnmarshal(t *testing.T) {
+	tx := makeTransactionSetSpendLimit(kp, kp.Address(), common.Amount(100))
+
+	b, err := json.Marshal(tx)
+	require.Nil(t, err)
+
+	var unmarshaled Transaction
+	require.Nil(t, json.Unmarshal(b, &unmarshaled))
+	require.Equal(t, tx.B.Operations[0].B, unmarshaled.B.Operations[0].B)
+	require.Equal(t, tx.B.MakeHashString(), unmarshaled.B.MakeHashString())
+}
+
+func TestIsWellFormedTransactionSetSpendLimit(t *testing.T) {
+	{ // owner sets it's own limit
+		tx := makeTransactionSetSpendLimit(kp, kp.Address(), common.Amount(100))
+		require.Nil(t, tx.IsWellFormed(networkID))
+	}
+
+	{ // other account can not set the limit
+		kpOther, _ := keypair.Random()
+		tx := makeTransactionSetSpendLimit(kpOther, kp.Address(), common.Amount(100))
+		require.Equal(t, errors.ErrorSpendLimitNotOwner, tx.IsWellFormed(networkID))
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"testing"
 
 	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/error"
 
 	"encoding/json"
+	"github.com/btcsuite/btcutil/base58"
 	"github.com/stellar/go/keypair"
 	"github.com/stretchr/testify/require"
 )
@@ -25,6 +27,11 @@ func TestMakeHashOfOperationBodyPayment(t *testing.T) {
 
 	expected := "8AALKhfgCu2w3ZtbESXHG5ko93Jb1L1yCmFopoJubQh9"
 	require.Equal(t, hashed, expected)
+
+	// the hash of `TransactionVersionCanonical`
+	canonical, err := op.MakeCanonicalHash()
+	require.Nil(t, err)
+	require.Equal(t, "D3kM2Ck78Gs6aJVnW4tw5iN2vreBgrxYGbjhKXVeUc8A", base58.Encode(canonical))
 }
 
 func TestIsWellFormedOperation(t *testing.T) {
@@ -49,3 +56,131 @@ func TestSerializeOperation(t *testing.T) {
 	err = json.Unmarshal(b, &o)
 	require.Nil(t, err)
 }
+
+func TestOperationSerializeCanonical(t *testing.T) {
+	kp := keypair.Master("find me")
+
+	op := Operation{
+		H: OperationHeader{Type: OperationCreateAccount},
+		B: NewOperationBodyCreateAccount(kp.Address(), common.Amount(100), ""),
+	}
+	b, err := op.SerializeCanonical()
+	require.Nil(t, err)
+	require.Equal(
+		t,
+		`{"B":{"amount":"100","target":"`+kp.Address()+`"},"H":{"type":"create-account"}}`,
+		string(b),
+	)
+}
+
+// TestOperationHashStable checks the hashes of operation and transaction are
+// same regardless of how the operation body is constructed.
+func TestOperationHashStable(t *testing.T) {
+	kpSource := keypair.Master("source")
+	kpTarget := keypair.Master("target")
+
+	makeTransactionBody := func(ops ...Operation) TransactionBody {
+		return TransactionBody{
+			Source:     kpSource.Address(),
+			Fee:        common.BaseFee,
+			SequenceID: 0,
+			Operations: ops,
+		}
+	}
+
+	hashBody := func(tb TransactionBody) string {
+		hash, err := tb.MakeHashStringVersion(TransactionVersionCanonical)
+		require.Nil(t, err)
+		return hash
+	}
+	hashOp := func(op Operation) string {
+		hash, err := op.MakeCanonicalHash()
+		require.Nil(t, err)
+		return base58.Encode(hash)
+	}
+
+	payment := Operation{
+		H: OperationHeader{Type: OperationPayment},
+		B: NewOperationBodyPayment(kpTarget.Address(), common.Amount(100)),
+	}
+	createAccount := Operation{
+		H: OperationHeader{Type: OperationCreateAccount},
+		B: NewOperationBodyCreateAccount(kpTarget.Address(), common.Amount(common.BaseReserve), ""),
+	}
+	expected := hashBody(makeTransactionBody(payment, createAccount))
+
+	{ // struct literal
+		p := Operation{
+			H: OperationHeader{Type: OperationPayment},
+			B: OperationBodyPayment{Amount: common.Amount(100), Target: kpTarget.Address()},
+		}
+		c := Operation{
+			H: OperationHeader{Type: OperationCreateAccount},
+			B: OperationBodyCreateAccount{Amount: common.Amount(common.BaseReserve), Target: kpTarget.Address()},
+		}
+		require.Equal(t, hashOp(payment), hashOp(p))
+		require.Equal(t, hashOp(createAccount), hashOp(c))
+		require.Equal(t, expected, hashBody(makeTransactionBody(p, c)))
+	}
+
+	{ // unmarshaled from json of different key order and whitespace
+		var p, c Operation
+		err := json.Unmarshal(
+			[]byte(`{"B": {"amount": "100", "target": "`+kpTarget.Address()+`"}, "H": {"type": "payment"}}`),
+			&p,
+		)
+		require.Nil(t, err)
+		err = json.Unmarshal(
+			[]byte(`{"H":{"type":"create-account"},"B":{"target":"`+kpTarget.Address()+`","linked":"","amount":"`+common.BaseReserve.String()+`"}}`),
+			&c,
+		)
+		require.Nil(t, err)
+
+		require.Equal(t, hashOp(payment), hashOp(p))
+		require.Equal(t, hashOp(createAccount), hashOp(c))
+		require.Equal(t, expected, hashBody(makeTransactionBody(p, c)))
+	}
+
+	{ // serialized and unmarshaled
+		b, err := json.Marshal(makeTransactionBody(payment, createAccount))
+		require.Nil(t, err)
+		var tb TransactionBody
+		require.Nil(t, json.Unmarshal(b, &tb))
+		require.Equal(t, expected, hashBody(tb))
+	}
+
+	// the order of operations matters
+	require.NotEqual(t, expected, hashBody(makeTransactionBody(createAccount, payment)))
+}
+
+func TestUnmarshalOperationBody(t *testing.T) {
+	kp := keypair.Master("find me")
+
+	bodies := map[OperationType]OperationBody{
+		OperationCreateAccount:  NewOperationBodyCreateAccount(kp.Address(), common.Amount(100), ""),
+		OperationPayment:        NewOperationBodyPayment(kp.Address(), common.Amount(100)),
+		OperationSetAccountData: NewOperationBodySetAccountData(kp.Address(), []AccountData{{Key: "k", Value: "v"}}),
+		OperationDelegate:       NewOperationBodyDelegate(kp.Address()),
+		OperationSetSpendLimit:  NewOperationBodySetSpendLimit(kp.Address(), common.Amount(100)),
+		OperationUpdateEndpoint: NewOperationBodyUpdateEndpoint("https://localhost:12345"),
+	}
+	require.Equal(t, len(OperationTypes), len(bodies))
+
+	for opType, body := range bodies {
+		raw, err := body.Serialize()
+		require.Nil(t, err)
+
+		decoded, err := UnmarshalOperationBody(opType, raw)
+		require.Nil(t, err, string(opType))
+		require.Equal(t, body, decoded, string(opType))
+	}
+
+	{ // unknown type
+		_, err := UnmarshalOperationBody(OperationType("showme"), []byte(`{}`))
+		require.Equal(t, errors.ErrorTransactionUnknownOperation, err)
+
+		var op Operation
+		err = json.Unmarshal([]byte(`{"H":{"type":"showme"},"B":{}}`), &op)
+		require.Equal(t, errors.ErrorTransactionUnknownOperation, err)
+	}
+}
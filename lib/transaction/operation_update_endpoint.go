@@ -0,0 +1,49 @@
+package transaction
+
+import (
+	"encoding/json"
+
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/error"
+)
+
+// OperationBodyUpdateEndpoint updates the endpoint of validator, when it's
+// host is changed, so the other validators learn the new endpoint from the
+// block. The source of transaction must be a registered validator.
+type OperationBodyUpdateEndpoint struct {
+	Endpoint string `json:"endpoint"`
+}
+
+func init() {
+	RegisterOperationBody(OperationUpdateEndpoint, func(b json.RawMessage) (OperationBody, error) {
+		var ob OperationBodyUpdateEndpoint
+		if err := json.Unmarshal(b, &ob); err != nil {
+			return nil, err
+		}
+		return ob, nil
+	})
+}
+
+func NewOperationBodyUpdateEndpoint(endpoint string) OperationBodyUpdateEndpoint {
+	return OperationBodyUpdateEndpoint{
+		Endpoint: endpoint,
+	}
+}
+
+func (o OperationBodyUpdateEndpoint) Serialize() (encoded []byte, err error) {
+	return json.Marshal(o)
+}
+
+// Implement transaction/operation : OperationBody.IsWellFormed
+func (o OperationBodyUpdateEndpoint) IsWellFormed([]byte) (err error) {
+	if _, err = common.NewEndpoint(o.Endpoint); err != nil {
+		return errors.ErrorInvalidEndpoint.Clone().SetData("error", err.Error())
+	}
+
+	return
+}
+
+// ParsedEndpoint returns the normalized `common.Endpoint` of `Endpoint`.
+func (o OperationBodyUpdateEndpoint) ParsedEndpoint() (*common.Endpoint, error) {
+	return common.NewEndpoint(o.Endpoint)
+}
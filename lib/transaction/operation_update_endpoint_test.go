@@ -0,0 +1,58 @@
+package transaction
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"boscoin.io/sebak/lib/error"
+)
+
+func TestUpdateEndpointOperation(t *testing.T) {
+	{ // valid
+		o := NewOperationBodyUpdateEndpoint("https://node1.example.com:12345")
+		require.Nil(t, o.IsWellFormed(networkID))
+
+		endpoint, err := o.ParsedEndpoint()
+		require.Nil(t, err)
+		require.Equal(t, "https://node1.example.com:12345", endpoint.String())
+	}
+
+	for _, endpoint := range []string{"", "showme", "memory://node1", "https://node1", "https://node1:12345?a=1"} {
+		o := NewOperationBodyUpdateEndpoint(endpoint)
+		err := o.IsWellFormed(networkID)
+		require.NotNil(t, err, endpoint)
+		require.Equal(t, errors.ErrorInvalidEndpoint.Code, err.(*errors.Error).Code, endpoint)
+	}
+}
+
+func TestIsWellFormedTransactionUpdateEndpoint(t *testing.T) {
+	op := Operation{
+		H: OperationHeader{Type: OperationUpdateEndpoint},
+		B: NewOperationBodyUpdateEndpoint("https://node1.example.com:12345"),
+	}
+
+	{ // valid
+		tx, _ := NewTransaction(kp.Address(), 0, op)
+		tx.Sign(kp, networkID)
+		require.Nil(t, tx.IsWellFormed(networkID))
+
+		b, err := json.Marshal(tx)
+		require.Nil(t, err)
+
+		var unmarshaled Transaction
+		require.Nil(t, json.Unmarshal(b, &unmarshaled))
+		require.Equal(t, op.B, unmarshaled.B.Operations[0].B)
+	}
+
+	{ // only one endpoint in transaction
+		other := Operation{
+			H: OperationHeader{Type: OperationUpdateEndpoint},
+			B: NewOperationBodyUpdateEndpoint("https://node2.example.com:12345"),
+		}
+		tx, _ := NewTransaction(kp.Address(), 0, op, other)
+		tx.Sign(kp, networkID)
+		require.Equal(t, errors.ErrorDuplicatedOperation, tx.IsWellFormed(networkID))
+	}
+}
@@ -41,6 +41,29 @@ type TransactionBody struct {
 	Operations []Operation   `json:"operations"`
 }
 
+const (
+	// TransactionVersionLegacy is the version of the transactions, whose
+	// hash is made from the RLP encoding of `TransactionBody`. The
+	// transactions without version have it, so the hashes of the existing
+	// transactions are not changed.
+	TransactionVersionLegacy = ""
+
+	// TransactionVersionCanonical is the version of the transactions, whose
+	// hash is made with the canonical json of the operations; see
+	// `Operation.SerializeCanonical()`.
+	TransactionVersionCanonical = "2"
+)
+
+// transactionBodyHashable is used to make the hash of `TransactionBody` of
+// `TransactionVersionCanonical`; the operations are replaced by their
+// canonical json.
+type transactionBodyHashable struct {
+	Source     string
+	Fee        common.Amount
+	SequenceID uint64
+	Operations [][]byte
+}
+
 func (tb TransactionBody) MakeHash() []byte {
 	return common.MustMakeObjectHash(tb)
 }
@@ -49,6 +72,43 @@ func (tb TransactionBody) MakeHashString() string {
 	return base58.Encode(tb.MakeHash())
 }
 
+// MakeHashVersion makes the hash of `TransactionBody` by the version of
+// transaction. The unknown version returns
+// `errors.ErrorUnknownTransactionVersion`.
+func (tb TransactionBody) MakeHashVersion(version string) ([]byte, error) {
+	switch version {
+	case TransactionVersionLegacy:
+		return tb.MakeHash(), nil
+	case TransactionVersionCanonical:
+	default:
+		return nil, errors.ErrorUnknownTransactionVersion
+	}
+
+	h := transactionBodyHashable{
+		Source:     tb.Source,
+		Fee:        tb.Fee,
+		SequenceID: tb.SequenceID,
+	}
+	for _, op := range tb.Operations {
+		b, err := op.SerializeCanonical()
+		if err != nil {
+			return nil, err
+		}
+		h.Operations = append(h.Operations, b)
+	}
+
+	return common.MakeObjectHash(h)
+}
+
+func (tb TransactionBody) MakeHashStringVersion(version string) (string, error) {
+	b, err := tb.MakeHashVersion(version)
+	if err != nil {
+		return "", err
+	}
+
+	return base58.Encode(b), nil
+}
+
 func (t *Transaction) UnmarshalJSON(b []byte) (err error) {
 	var tj transactionEnvelop
 	if err = json.Unmarshal(b, &tj); err != nil {
@@ -58,7 +118,28 @@ func (t *Transaction) UnmarshalJSON(b []byte) (err error) {
 	t.T = tj.T
 	t.H = tj.H
 	t.B = tj.B
-	t.H.Hash = t.B.MakeHashString()
+	t.H.Hash, err = t.B.MakeHashStringVersion(t.H.Version)
+	return
+}
+
+// NewTransactionFromJSON decodes `Transaction`. Before decoding, it checks
+// the number of operations and if it is over
+// `common.MaxOperationsInTransaction`, it stops with
+// `errors.ErrorTooManyOperations`.
+func NewTransactionFromJSON(b []byte) (tx Transaction, err error) {
+	var exceeded bool
+	exceeded, err = common.JSONArrayExceedsLimit(b, common.MaxOperationsInTransaction, "B", "operations")
+	if err != nil {
+		return
+	} else if exceeded {
+		err = errors.ErrorTooManyOperations
+		return
+	}
+
+	if err = json.Unmarshal(b, &tx); err != nil {
+		return
+	}
+
 	return
 }
 
@@ -88,10 +169,12 @@ func NewTransaction(source string, sequenceID uint64, ops ...Operation) (tx Tran
 }
 
 var TransactionWellFormedCheckerFuncs = []common.CheckerFunc{
+	CheckTransactionVersion,
 	CheckTransactionOverOperationsLimit,
 	CheckTransactionSequenceID,
 	CheckTransactionSource,
 	CheckTransactionBaseFee,
+	CheckTransactionOperationTypes,
 	CheckTransactionOperation,
 	CheckTransactionVerifySignature,
 }
@@ -123,6 +206,16 @@ func (tx Transaction) IsValidSequenceID(sequenceID uint64) bool {
 	return tx.B.SequenceID == sequenceID
 }
 
+// IsValidSequenceIDWindow checks the sequenceID of transaction is in the nonce
+// window, `[sequenceID, sequenceID+window)`.
+func (tx Transaction) IsValidSequenceIDWindow(sequenceID, window uint64) bool {
+	if window < 2 {
+		return tx.IsValidSequenceID(sequenceID)
+	}
+
+	return tx.B.SequenceID >= sequenceID && tx.B.SequenceID-sequenceID < window
+}
+
 func (tx Transaction) GetHash() string {
 	return tx.H.Hash
 }
@@ -158,6 +251,64 @@ func (tx Transaction) TotalAmount(withFee bool) common.Amount {
 	return amount
 }
 
+// IssuedAmount returns the sum of the coins issued by the operations; see
+// `OperationBodyIssuer`.
+func (tx Transaction) IssuedAmount() (amount common.Amount, err error) {
+	for _, op := range tx.B.Operations {
+		iop, ok := op.B.(OperationBodyIssuer)
+		if !ok {
+			continue
+		}
+		if amount, err = amount.Add(iop.IssuedAmount()); err != nil {
+			err = errors.ErrorTransactionAmountOverflow
+			return
+		}
+	}
+
+	return
+}
+
+// SafeTotalAmount is the overflow-safe `TotalAmount`. If the sum of amounts
+// and fees goes over `common.MaximumBalance`,
+// `errors.ErrorTransactionAmountOverflow` is returned.
+func (tx Transaction) SafeTotalAmount(withFee bool) (amount common.Amount, err error) {
+	add := func(a common.Amount) (err error) {
+		if a > common.MaximumBalance {
+			return errors.ErrorTransactionAmountOverflow
+		}
+		if amount, err = amount.Add(a); err != nil {
+			return errors.ErrorTransactionAmountOverflow
+		}
+		return
+	}
+
+	for _, op := range tx.B.Operations {
+		if pop, ok := op.B.(OperationBodyPayable); ok {
+			if err = add(pop.GetAmount()); err != nil {
+				return
+			}
+		}
+	}
+
+	if withFee && len(tx.B.Operations) > 0 {
+		if tx.B.Fee > common.MaximumBalance {
+			err = errors.ErrorTransactionAmountOverflow
+			return
+		}
+
+		var fee common.Amount
+		if fee, err = tx.B.Fee.MultInt(len(tx.B.Operations)); err != nil {
+			err = errors.ErrorTransactionAmountOverflow
+			return
+		}
+		if err = add(fee); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
 func (tx Transaction) Serialize() (encoded []byte, err error) {
 	encoded, err = json.Marshal(tx)
 	return
@@ -168,11 +319,56 @@ func (tx Transaction) String() string {
 	return string(encoded)
 }
 
+// Sign makes the hash by the version of transaction and signs it. The
+// transaction of unknown version is not signed; it is rejected by
+// `CheckTransactionVersion`.
 func (tx *Transaction) Sign(kp keypair.KP, networkID []byte) {
-	tx.H.Hash = tx.B.MakeHashString()
+	hash, err := tx.B.MakeHashStringVersion(tx.H.Version)
+	if err != nil {
+		return
+	}
+	tx.H.Hash = hash
 	signature, _ := common.MakeSignature(kp, networkID, tx.H.Hash)
 
 	tx.H.Signature = base58.Encode(signature)
 
 	return
 }
+
+// TransactionFinalizeCheckerFuncs checks the body of transaction in
+// `Finalize()`. The fee and the targets of operations are not checked,
+// because the genesis transaction, which creates it's source account without
+// fee, is also finalized.
+var TransactionFinalizeCheckerFuncs = []common.CheckerFunc{
+	CheckTransactionVersion,
+	CheckTransactionOverOperationsLimit,
+	CheckTransactionSource,
+	CheckTransactionEmptyOperations,
+	CheckTransactionOperationTypes,
+	CheckTransactionOperationBodies,
+}
+
+// Finalize sets `Created` if it is empty, makes the hash and signs the
+// transaction by `kp`, which must be the keypair of the source.
+func (tx *Transaction) Finalize(kp *keypair.Full, networkID []byte) (err error) {
+	checker := &TransactionChecker{
+		DefaultChecker: common.DefaultChecker{Funcs: TransactionFinalizeCheckerFuncs},
+		NetworkID:      networkID,
+		Transaction:    *tx,
+	}
+	if err = common.RunChecker(checker, common.DefaultDeferFunc); err != nil {
+		return
+	}
+
+	if kp == nil || kp.Address() != tx.B.Source {
+		err = errors.ErrorSignatureVerificationFailed
+		return
+	}
+
+	if len(tx.H.Created) < 1 {
+		tx.H.Created = common.NowISO8601()
+	}
+	tx.Sign(kp, networkID)
+
+	return
+}
@@ -17,6 +17,20 @@ type TransactionChecker struct {
 	Transaction Transaction
 }
 
+// CheckTransactionVersion checks the version of transaction is known, so it's
+// hash can be made.
+func CheckTransactionVersion(c common.Checker, args ...interface{}) (err error) {
+	checker := c.(*TransactionChecker)
+
+	switch checker.Transaction.H.Version {
+	case TransactionVersionLegacy, TransactionVersionCanonical:
+	default:
+		err = errors.ErrorUnknownTransactionVersion
+	}
+
+	return
+}
+
 func CheckTransactionSource(c common.Checker, args ...interface{}) (err error) {
 	checker := c.(*TransactionChecker)
 	if _, err = keypair.Parse(checker.Transaction.B.Source); err != nil {
@@ -53,6 +67,51 @@ func CheckTransactionBaseFee(c common.Checker, args ...interface{}) (err error)
 	return
 }
 
+func CheckTransactionEmptyOperations(c common.Checker, args ...interface{}) (err error) {
+	checker := c.(*TransactionChecker)
+	if len(checker.Transaction.B.Operations) < 1 {
+		err = errors.ErrorTransactionEmptyOperations
+		return
+	}
+
+	return
+}
+
+// CheckTransactionOperationTypes checks the types of operations are in
+// `OperationTypes`.
+func CheckTransactionOperationTypes(c common.Checker, args ...interface{}) (err error) {
+	checker := c.(*TransactionChecker)
+
+	for _, op := range checker.Transaction.B.Operations {
+		if !op.H.Type.IsKnown() {
+			err = errors.ErrorTransactionUnknownOperation
+			return
+		}
+	}
+
+	return
+}
+
+// CheckTransactionOperationBodies checks only the operation bodies are well
+// formed; unlike `CheckTransactionOperation`, the relation between the source
+// and the targets is not checked. The create-account operation to the source
+// itself is only in the genesis transaction, which creates the genesis
+// account with any balance, so it's body is not checked.
+func CheckTransactionOperationBodies(c common.Checker, args ...interface{}) (err error) {
+	checker := c.(*TransactionChecker)
+
+	for _, op := range checker.Transaction.B.Operations {
+		if cop, ok := op.B.(OperationBodyCreateAccount); ok && cop.TargetAddress() == checker.Transaction.B.Source {
+			continue
+		}
+		if err = op.IsWellFormed(checker.NetworkID); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
 func CheckTransactionOperation(c common.Checker, args ...interface{}) (err error) {
 	checker := c.(*TransactionChecker)
 
@@ -65,7 +124,12 @@ func CheckTransactionOperation(c common.Checker, args ...interface{}) (err error
 	for _, op := range checker.Transaction.B.Operations {
 		if pop, ok := op.B.(OperationBodyPayable); ok {
 			if checker.Transaction.B.Source == pop.TargetAddress() {
-				err = errors.ErrorInvalidOperation
+				// the self-payment changes nothing, but wastes fee
+				if op.H.Type == OperationPayment {
+					err = errors.ErrorSelfPayment
+				} else {
+					err = errors.ErrorInvalidOperation
+				}
 				return
 			}
 			if err = op.IsWellFormed(checker.NetworkID); err != nil {
@@ -79,6 +143,66 @@ func CheckTransactionOperation(c common.Checker, args ...interface{}) (err error
 				return
 			}
 
+			hashes = append(hashes, u)
+		} else if sop, ok := op.B.(OperationBodySetAccountData); ok {
+			// only the owner can set it's own account data
+			if checker.Transaction.B.Source != sop.TargetAddress() {
+				err = errors.ErrorAccountDataNotOwner
+				return
+			}
+			if err = op.IsWellFormed(checker.NetworkID); err != nil {
+				return
+			}
+			u := fmt.Sprintf("%s-%s", op.H.Type, sop.TargetAddress())
+			if _, found := common.InStringArray(hashes, u); found {
+				err = errors.ErrorDuplicatedOperation
+				return
+			}
+
+			hashes = append(hashes, u)
+		} else if dop, ok := op.B.(OperationBodyDelegate); ok {
+			if checker.Transaction.B.Source == dop.TargetAddress() {
+				err = errors.ErrorSelfDelegation
+				return
+			}
+			if err = op.IsWellFormed(checker.NetworkID); err != nil {
+				return
+			}
+			// account can delegate to only one validator at once
+			u := string(op.H.Type)
+			if _, found := common.InStringArray(hashes, u); found {
+				err = errors.ErrorDuplicatedOperation
+				return
+			}
+
+			hashes = append(hashes, u)
+		} else if lop, ok := op.B.(OperationBodySetSpendLimit); ok {
+			// only the owner can set it's own spend limit
+			if checker.Transaction.B.Source != lop.TargetAddress() {
+				err = errors.ErrorSpendLimitNotOwner
+				return
+			}
+			if err = op.IsWellFormed(checker.NetworkID); err != nil {
+				return
+			}
+			u := string(op.H.Type)
+			if _, found := common.InStringArray(hashes, u); found {
+				err = errors.ErrorDuplicatedOperation
+				return
+			}
+
+			hashes = append(hashes, u)
+		} else if _, ok := op.B.(OperationBodyUpdateEndpoint); ok {
+			if err = op.IsWellFormed(checker.NetworkID); err != nil {
+				return
+			}
+			// validator has only one endpoint
+			u := string(op.H.Type)
+			if _, found := common.InStringArray(hashes, u); found {
+				err = errors.ErrorDuplicatedOperation
+				return
+			}
+
 			hashes = append(hashes, u)
 		}
 	}
@@ -93,8 +217,10 @@ func CheckTransactionVerifySignature(c common.Checker, args ...interface{}) (err
 	if kp, err = keypair.Parse(checker.Transaction.B.Source); err != nil {
 		return
 	}
-	err = kp.Verify(
-		append(checker.NetworkID, []byte(checker.Transaction.H.Hash)...),
+	err = common.VerifySignature(
+		kp,
+		checker.NetworkID,
+		checker.Transaction.H.Hash,
 		base58.Decode(checker.Transaction.H.Signature),
 	)
 	if err != nil {
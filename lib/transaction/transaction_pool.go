@@ -1,24 +1,29 @@
 package transaction
 
 import (
+	"math/big"
 	"sync"
+	"time"
 
 	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/error"
 )
 
 type TransactionPool struct {
 	sync.RWMutex
 
-	Pool    map[ /* Transaction.GetHash() */ string]Transaction
-	Hashes  []string // Transaction.GetHash()
-	Sources map[ /* Transaction.Source() */ string]bool
+	Pool     map[ /* Transaction.GetHash() */ string]Transaction
+	Hashes   []string // Transaction.GetHash()
+	Sources  map[ /* Transaction.Source() */ string]bool
+	Received map[ /* Transaction.GetHash() */ string]time.Time
 }
 
 func NewTransactionPool() *TransactionPool {
 	return &TransactionPool{
-		Pool:    map[string]Transaction{},
-		Hashes:  []string{},
-		Sources: map[string]bool{},
+		Pool:     map[string]Transaction{},
+		Hashes:   []string{},
+		Sources:  map[string]bool{},
+		Received: map[string]time.Time{},
 	}
 }
 
@@ -47,10 +52,97 @@ func (tp *TransactionPool) Add(tx Transaction) bool {
 	tp.Pool[tx.GetHash()] = tx
 	tp.Hashes = append(tp.Hashes, tx.GetHash())
 	tp.Sources[tx.Source()] = true
+	tp.Received[tx.GetHash()] = time.Now()
 
 	return true
 }
 
+// GetBySequenceID returns the pending transaction, which has the same source
+// and sequenceID.
+func (tp *TransactionPool) GetBySequenceID(source string, sequenceID uint64) (tx Transaction, found bool) {
+	tp.RLock()
+	defer tp.RUnlock()
+
+	return tp.getBySequenceID(source, sequenceID)
+}
+
+func (tp *TransactionPool) getBySequenceID(source string, sequenceID uint64) (tx Transaction, found bool) {
+	if !tp.Sources[source] {
+		return
+	}
+
+	for _, hash := range tp.Hashes {
+		tx = tp.Pool[hash]
+		if tx.Source() == source && tx.B.SequenceID == sequenceID {
+			found = true
+			return
+		}
+	}
+
+	return Transaction{}, false
+}
+
+// AddOrReplace adds the transaction like `Add`, but if the pending
+// transaction with the same source and sequenceID exists, the new one
+// replaces it. The fee of the new transaction must be higher than the pending
+// one by at least `common.ReplaceFeeBumpPercent`; if not,
+// `errors.ErrorTransactionReplacementUnderpriced` is returned. The pending
+// transaction, which is already proposed in the running rounds, can not be
+// replaced, `inFlight` tells it; if it is in flight,
+// `errors.ErrorTransactionSameSource` is returned. `replaced` is the hash of
+// the evicted transaction.
+func (tp *TransactionPool) AddOrReplace(tx Transaction, inFlight func(hash string) bool) (replaced string, err error) {
+	tp.Lock()
+	defer tp.Unlock()
+
+	if _, found := tp.Pool[tx.GetHash()]; found {
+		return
+	}
+
+	if pending, found := tp.getBySequenceID(tx.Source(), tx.B.SequenceID); found {
+		if inFlight != nil && inFlight(pending.GetHash()) {
+			err = errors.ErrorTransactionSameSource
+			return
+		}
+		if !IsEnoughReplaceFee(pending.B.Fee, tx.B.Fee, common.ReplaceFeeBumpPercent) {
+			err = errors.ErrorTransactionReplacementUnderpriced
+			return
+		}
+
+		replaced = pending.GetHash()
+		if index, found := common.InStringArray(tp.Hashes, replaced); found {
+			tp.Hashes = append(tp.Hashes[:index:index], tp.Hashes[index+1:]...)
+		}
+		delete(tp.Pool, replaced)
+		delete(tp.Received, replaced)
+	}
+
+	tp.Pool[tx.GetHash()] = tx
+	tp.Hashes = append(tp.Hashes, tx.GetHash())
+	tp.Sources[tx.Source()] = true
+	tp.Received[tx.GetHash()] = time.Now()
+
+	return
+}
+
+// IsEnoughReplaceFee checks `fee` is higher than `pendingFee` by at least
+// `bumpPercent` percent.
+func IsEnoughReplaceFee(pendingFee, fee common.Amount, bumpPercent uint64) bool {
+	if fee <= pendingFee {
+		return false
+	}
+
+	// fee * 100 >= pendingFee * (100 + bumpPercent); it is calculated by
+	// `big.Int` not to be overflowed.
+	required := new(big.Int).SetUint64(uint64(pendingFee))
+	required.Mul(required, new(big.Int).Add(big.NewInt(100), new(big.Int).SetUint64(bumpPercent)))
+
+	given := new(big.Int).SetUint64(uint64(fee))
+	given.Mul(given, big.NewInt(100))
+
+	return given.Cmp(required) >= 0
+}
+
 func (tp *TransactionPool) Remove(hashes ...string) {
 	if len(hashes) < 1 {
 		return
@@ -89,6 +181,7 @@ func (tp *TransactionPool) Remove(hashes ...string) {
 		}
 
 		delete(tp.Pool, hash)
+		delete(tp.Received, hash)
 	}
 
 	tp.Hashes = newHashes
@@ -112,3 +205,30 @@ func (tp *TransactionPool) IsSameSource(source string) (found bool) {
 
 	return
 }
+
+// Pending returns the transactions in the pool by the received order, the
+// oldest first. If `source` is not empty, only the transactions from `source`
+// are returned.
+func (tp *TransactionPool) Pending(source string) (txs []Transaction) {
+	tp.RLock()
+	defer tp.RUnlock()
+
+	for _, hash := range tp.Hashes {
+		tx := tp.Pool[hash]
+		if len(source) > 0 && tx.Source() != source {
+			continue
+		}
+		txs = append(txs, tx)
+	}
+
+	return
+}
+
+// GetReceived returns the time when the transaction was added to the pool.
+func (tp *TransactionPool) GetReceived(hash string) (received time.Time, found bool) {
+	tp.RLock()
+	defer tp.RUnlock()
+
+	received, found = tp.Received[hash]
+	return
+}
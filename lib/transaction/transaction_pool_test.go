@@ -0,0 +1,92 @@
+package transaction
+
+import (
+	"testing"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stretchr/testify/require"
+
+	"boscoin.io/sebak/lib/common"
+	"boscoin.io/sebak/lib/error"
+)
+
+func TestTransactionPoolReplaceByFee(t *testing.T) {
+	tp := NewTransactionPool()
+
+	kp, _ := keypair.Random()
+	pending := makeTransactionForSelector(kp, 10000, 3)
+	other := makeTransactionForSelector(kp, 10000, 4)
+	require.True(t, tp.Add(pending))
+	require.True(t, tp.Add(other))
+
+	{ // same sequenceID and higher fee by `common.ReplaceFeeBumpPercent`
+		replacing := makeTransactionForSelector(kp, 11000, 3)
+		replaced, err := tp.AddOrReplace(replacing, nil)
+		require.Nil(t, err)
+		require.Equal(t, pending.GetHash(), replaced)
+
+		require.False(t, tp.Has(pending.GetHash()))
+		require.True(t, tp.Has(replacing.GetHash()))
+		require.True(t, tp.Has(other.GetHash()))
+		require.Equal(t, []string{other.GetHash(), replacing.GetHash()}, tp.Hashes)
+		require.True(t, tp.IsSameSource(kp.Address()))
+
+		_, found := tp.GetReceived(pending.GetHash())
+		require.False(t, found)
+	}
+
+	{ // new sequenceID is just added
+		tx := makeTransactionForSelector(kp, 10000, 5)
+		replaced, err := tp.AddOrReplace(tx, nil)
+		require.Nil(t, err)
+		require.Equal(t, "", replaced)
+		require.Equal(t, 3, tp.Len())
+	}
+}
+
+func TestTransactionPoolReplaceByFeeInsufficientBump(t *testing.T) {
+	tp := NewTransactionPool()
+
+	kp, _ := keypair.Random()
+	pending := makeTransactionForSelector(kp, 10000, 3)
+	require.True(t, tp.Add(pending))
+
+	for _, fee := range []common.Amount{9000, 10000, 10999} {
+		replacing := makeTransactionForSelector(kp, fee, 3)
+		replaced, err := tp.AddOrReplace(replacing, nil)
+		require.Equal(t, errors.ErrorTransactionReplacementUnderpriced, err)
+		require.Equal(t, "", replaced)
+
+		require.True(t, tp.Has(pending.GetHash()))
+		require.False(t, tp.Has(replacing.GetHash()))
+		require.Equal(t, 1, tp.Len())
+	}
+}
+
+func TestTransactionPoolReplaceByFeeInFlight(t *testing.T) {
+	tp := NewTransactionPool()
+
+	kp, _ := keypair.Random()
+	pending := makeTransactionForSelector(kp, 10000, 3)
+	require.True(t, tp.Add(pending))
+
+	inFlight := func(hash string) bool { return hash == pending.GetHash() }
+
+	replacing := makeTransactionForSelector(kp, 20000, 3)
+	replaced, err := tp.AddOrReplace(replacing, inFlight)
+	require.Equal(t, errors.ErrorTransactionSameSource, err)
+	require.Equal(t, "", replaced)
+	require.True(t, tp.Has(pending.GetHash()))
+	require.False(t, tp.Has(replacing.GetHash()))
+}
+
+func TestIsEnoughReplaceFee(t *testing.T) {
+	require.True(t, IsEnoughReplaceFee(10000, 11000, 10))
+	require.False(t, IsEnoughReplaceFee(10000, 10999, 10))
+	require.True(t, IsEnoughReplaceFee(10000, 10001, 0))
+	require.False(t, IsEnoughReplaceFee(10000, 10000, 0))
+
+	// not overflowed with the large fees
+	require.True(t, IsEnoughReplaceFee(common.MaximumBalance/2, common.MaximumBalance, 10))
+	require.False(t, IsEnoughReplaceFee(common.MaximumBalance-1, common.MaximumBalance, 10))
+}
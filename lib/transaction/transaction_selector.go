@@ -0,0 +1,89 @@
+package transaction
+
+import (
+	"sort"
+)
+
+// TransactionSelector selects the transactions from `TransactionPool` for the
+// new proposal.
+type TransactionSelector interface {
+	// Select returns the hashes of the selected transactions, up to `limit`.
+	Select(tp *TransactionPool, limit int) []string
+}
+
+// DefaultTransactionSelector is used for the new proposal if no other
+// `TransactionSelector` is set.
+var DefaultTransactionSelector TransactionSelector = FeeTransactionSelector{}
+
+// FeeTransactionSelector prefers the transaction of the higher fee, and the
+// transaction which arrived earlier for the same fee. The transactions from
+// the same source are always selected by the order of their sequenceID, so
+// the later transaction of one source can not be selected before the earlier
+// one.
+type FeeTransactionSelector struct{}
+
+type selectorItem struct {
+	tx      Transaction
+	arrival int
+}
+
+// higher checks `a` is preferred to `b`.
+func (s FeeTransactionSelector) higher(a, b selectorItem) bool {
+	if a.tx.B.Fee != b.tx.B.Fee {
+		return a.tx.B.Fee > b.tx.B.Fee
+	}
+	return a.arrival < b.arrival
+}
+
+func (s FeeTransactionSelector) Select(tp *TransactionPool, limit int) (selected []string) {
+	tp.RLock()
+	defer tp.RUnlock()
+
+	if limit < 1 {
+		return
+	}
+
+	// group by source; the transactions of one source are ordered by
+	// sequenceID
+	var sources []string
+	queues := map[string][]selectorItem{}
+	for i, hash := range tp.Hashes {
+		tx, found := tp.Pool[hash]
+		if !found {
+			continue
+		}
+		source := tx.Source()
+		if _, found := queues[source]; !found {
+			sources = append(sources, source)
+		}
+		queues[source] = append(queues[source], selectorItem{tx: tx, arrival: i})
+	}
+	for _, source := range sources {
+		queue := queues[source]
+		sort.SliceStable(queue, func(i, j int) bool {
+			return queue[i].tx.B.SequenceID < queue[j].tx.B.SequenceID
+		})
+	}
+
+	// pick the preferred one among the first transactions of each source
+	for len(selected) < limit {
+		var best string
+		for _, source := range sources {
+			queue := queues[source]
+			if len(queue) < 1 {
+				continue
+			}
+			if len(best) < 1 || s.higher(queue[0], queues[best][0]) {
+				best = source
+			}
+		}
+		if len(best) < 1 {
+			break
+		}
+
+		selected = append(selected, queues[best][0].tx.GetHash())
+		queues[best] = queues[best][1:]
+	}
+
+	return
+}
@@ -0,0 +1,76 @@
+package transaction
+
+import (
+	"testing"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stretchr/testify/require"
+
+	"boscoin.io/sebak/lib/common"
+)
+
+func makeTransactionForSelector(kp *keypair.Full, fee common.Amount, sequenceID uint64) Transaction {
+	tx := TestMakeTransactionWithKeypair(networkID, 1, kp)
+	tx.B.Fee = fee
+	tx.B.SequenceID = sequenceID
+	tx.Sign(kp, networkID)
+
+	return tx
+}
+
+func TestFeeTransactionSelectorPreferHigherFee(t *testing.T) {
+	tp := NewTransactionPool()
+
+	var txs []Transaction
+	for i, fee := range []common.Amount{10000, 30000, 10000, 20000, 30000} {
+		kp, _ := keypair.Random()
+		tx := makeTransactionForSelector(kp, fee, uint64(i))
+		require.True(t, tp.Add(tx))
+		txs = append(txs, tx)
+	}
+
+	selected := FeeTransactionSelector{}.Select(tp, 10)
+	require.Equal(t, []string{
+		txs[1].GetHash(), // 30000, arrived earlier
+		txs[4].GetHash(), // 30000
+		txs[3].GetHash(), // 20000
+		txs[0].GetHash(), // 10000, arrived earlier
+		txs[2].GetHash(), // 10000
+	}, selected)
+
+	// up to limit
+	selected = FeeTransactionSelector{}.Select(tp, 2)
+	require.Equal(t, []string{txs[1].GetHash(), txs[4].GetHash()}, selected)
+
+	require.Equal(t, 0, len(FeeTransactionSelector{}.Select(tp, 0)))
+	require.Equal(t, 0, len(FeeTransactionSelector{}.Select(NewTransactionPool(), 10)))
+}
+
+func TestFeeTransactionSelectorSequenceOrder(t *testing.T) {
+	tp := NewTransactionPool()
+
+	kpA, _ := keypair.Random()
+	kpB, _ := keypair.Random()
+
+	// the later transactions of A pay more, but they can not be selected
+	// before the earlier one
+	a2 := makeTransactionForSelector(kpA, 50000, 2)
+	a0 := makeTransactionForSelector(kpA, 10000, 0)
+	a1 := makeTransactionForSelector(kpA, 40000, 1)
+	b0 := makeTransactionForSelector(kpB, 20000, 0)
+	for _, tx := range []Transaction{a2, a0, a1, b0} {
+		require.True(t, tp.Add(tx))
+	}
+
+	selected := FeeTransactionSelector{}.Select(tp, 10)
+	require.Equal(t, []string{
+		b0.GetHash(), // 20000 is higher than the first of A, 10000
+		a0.GetHash(),
+		a1.GetHash(),
+		a2.GetHash(),
+	}, selected)
+
+	// with limit, the sequence of A is still kept
+	selected = FeeTransactionSelector{}.Select(tp, 2)
+	require.Equal(t, []string{b0.GetHash(), a0.GetHash()}, selected)
+}
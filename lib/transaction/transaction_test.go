@@ -82,6 +82,27 @@ func TestIsWellFormedTransactionWithTargetAddressIsSameWithSourceAddress(t *test
 	require.NotNil(t, err, "Transaction to self should be rejected")
 }
 
+func TestIsWellFormedTransactionSelfPayment(t *testing.T) {
+	kp, _ := keypair.Random()
+	kpTarget, _ := keypair.Random()
+
+	makeTx := func(target string) Transaction {
+		op := Operation{
+			H: OperationHeader{Type: OperationPayment},
+			B: NewOperationBodyPayment(target, common.Amount(1)),
+		}
+		tx, _ := NewTransaction(kp.Address(), 0, op)
+		tx.Sign(kp, networkID)
+		return tx
+	}
+
+	// self-payment
+	require.Equal(t, errors.ErrorSelfPayment, makeTx(kp.Address()).IsWellFormed(networkID))
+
+	// normal payment
+	require.Nil(t, makeTx(kpTarget.Address()).IsWellFormed(networkID))
+}
+
 func TestIsWellFormedTransactionWithInvalidSignature(t *testing.T) {
 	var err error
 
@@ -96,6 +117,22 @@ func TestIsWellFormedTransactionWithInvalidSignature(t *testing.T) {
 	require.NotNil(t, err)
 }
 
+func TestIsWellFormedTransactionUnknownOperation(t *testing.T) {
+	kpSource, tx := TestMakeTransaction(networkID, 1)
+	tx.B.Operations[0].H.Type = OperationType("showme")
+	tx.Sign(kpSource, networkID)
+
+	err := tx.IsWellFormed(networkID)
+	require.Equal(t, errors.ErrorTransactionUnknownOperation, err)
+
+	// all the known types pass
+	for opType := range OperationTypes {
+		tx.B.Operations[0].H.Type = opType
+		checker := &TransactionChecker{NetworkID: networkID, Transaction: tx}
+		require.Nil(t, CheckTransactionOperationTypes(checker), string(opType))
+	}
+}
+
 func TestIsWellFormedTransactionMaxOperationsInTransaction(t *testing.T) {
 	var err error
 
@@ -111,3 +148,99 @@ func TestIsWellFormedTransactionMaxOperationsInTransaction(t *testing.T) {
 		require.Nil(t, err)
 	}
 }
+
+func TestTransactionFinalize(t *testing.T) {
+	kpSource, _ := keypair.Random()
+
+	tx := Transaction{
+		T: "transaction",
+		B: TransactionBody{
+			Source:     kpSource.Address(),
+			Fee:        common.BaseFee,
+			Operations: []Operation{TestMakeOperation(-1)},
+		},
+	}
+	require.Nil(t, tx.Finalize(kpSource, networkID))
+	require.NotEmpty(t, tx.H.Created)
+	require.Equal(t, tx.B.MakeHashString(), tx.H.Hash)
+	require.Nil(t, tx.IsWellFormed(networkID))
+
+	{ // `Created` is kept
+		created := tx.H.Created
+		tx.B.SequenceID++
+		require.Nil(t, tx.Finalize(kpSource, networkID))
+		require.Equal(t, created, tx.H.Created)
+		require.Equal(t, tx.B.MakeHashString(), tx.H.Hash)
+		require.Nil(t, tx.IsWellFormed(networkID))
+	}
+
+	{ // not the keypair of source
+		other := tx
+		kpOther, _ := keypair.Random()
+		require.Equal(t, errors.ErrorSignatureVerificationFailed, other.Finalize(kpOther, networkID))
+	}
+
+	{ // empty operations
+		other := tx
+		other.B.Operations = nil
+		require.Equal(t, errors.ErrorTransactionEmptyOperations, other.Finalize(kpSource, networkID))
+	}
+
+	{ // invalid source
+		other := tx
+		other.B.Source = "findme"
+		require.Equal(t, errors.ErrorBadPublicAddress, other.Finalize(kpSource, networkID))
+	}
+
+	{ // invalid operation body
+		other := tx
+		opb := NewOperationBodyPayment("findme", common.Amount(1))
+		other.B.Operations = []Operation{{H: OperationHeader{Type: OperationPayment}, B: opb}}
+		require.NotNil(t, other.Finalize(kpSource, networkID))
+
+		opb = NewOperationBodyPayment(tx.B.Operations[0].B.(OperationBodyPayable).TargetAddress(), common.Amount(0))
+		other.B.Operations = []Operation{{H: OperationHeader{Type: OperationPayment}, B: opb}}
+		require.Equal(t, errors.ErrorOperationAmountUnderflow, other.Finalize(kpSource, networkID))
+	}
+}
+
+// The hash of transaction is made by it's version; the transaction without
+// version keeps the legacy hash.
+func TestTransactionHashVersion(t *testing.T) {
+	kp, tx := TestMakeTransaction(networkID, 2)
+	require.Equal(t, TransactionVersionLegacy, tx.H.Version)
+	require.Equal(t, tx.B.MakeHashString(), tx.GetHash())
+
+	canonical, err := tx.B.MakeHashStringVersion(TransactionVersionCanonical)
+	require.Nil(t, err)
+	require.NotEqual(t, tx.GetHash(), canonical)
+
+	tx.H.Version = TransactionVersionCanonical
+	tx.Sign(kp, networkID)
+	require.Equal(t, canonical, tx.GetHash())
+	require.Nil(t, tx.IsWellFormed(networkID))
+
+	{ // the hash is made by the version, when it is unmarshaled
+		b, err := tx.Serialize()
+		require.Nil(t, err)
+
+		var unmarshaled Transaction
+		require.Nil(t, json.Unmarshal(b, &unmarshaled))
+		require.Equal(t, canonical, unmarshaled.GetHash())
+		require.Nil(t, unmarshaled.IsWellFormed(networkID))
+	}
+
+	{ // unknown version
+		tx.H.Version = "100"
+		require.Equal(t, errors.ErrorUnknownTransactionVersion, tx.IsWellFormed(networkID))
+
+		b, err := tx.Serialize()
+		require.Nil(t, err)
+
+		var unmarshaled Transaction
+		require.Equal(t, errors.ErrorUnknownTransactionVersion, json.Unmarshal(b, &unmarshaled))
+
+		_, err = tx.B.MakeHashVersion(tx.H.Version)
+		require.Equal(t, errors.ErrorUnknownTransactionVersion, err)
+	}
+}
@@ -8,7 +8,10 @@ cd -- `dirname ${BASH_SOURCE[0]}`
 TEST_DIRS=$(find . -mindepth 1 -maxdepth 1 -type d -print)
 ROOT_DIR=".."
 export SEBAK_NODE_ARGS=""
-export SEBAK_GENESIS=GDIRF4UWPACXPPI4GW7CMTACTCNDIKJEHZK44RITZB4TD3YUM6CCVNGJ
+# The secret seed of the genesis account is not committed; copy
+# `docker/genesis.env.example` to `docker/genesis.env` and set it
+source ${ROOT_DIR}/docker/genesis.env
+export SEBAK_GENESIS=${SEBAK_GENESIS_BLOCK}
 
 # We can only have one trap active at a time, so just save the IDs of containers we started.
 # Single quotes around  trap ensure that the variable is evaluated at exit time.
@@ -42,13 +45,13 @@ for dir in ${TEST_DIRS}; do
     # We need to keep the container around after we stop it when we report coverage,
     # because the reports are written on program's exit, which also means container's shutdown
     # Also SUPER IMPORTANT: the `-test` args need to be before any other args, or they are simply ignored...
-    export NODE1=$(docker run -d --network host --env-file=${ROOT_DIR}/docker/node1.env \
+    export NODE1=$(docker run -d --network host --env-file=${ROOT_DIR}/docker/node1.env --env-file=${ROOT_DIR}/docker/genesis.env \
                           ${NODE_DOCKER_IMAGE} -test.coverprofile=coverage.txt node --genesis=${SEBAK_GENESIS}\
                           --log-level=debug --timeout-init=4 --timeout-sign=4 --timeout-accept=4 --block-time=10)
-    export NODE2=$(docker run -d --network host --env-file=${ROOT_DIR}/docker/node2.env \
+    export NODE2=$(docker run -d --network host --env-file=${ROOT_DIR}/docker/node2.env --env-file=${ROOT_DIR}/docker/genesis.env \
                           ${NODE_DOCKER_IMAGE} -test.coverprofile=coverage.txt node --genesis=${SEBAK_GENESIS}\
                           --log-level=debug --timeout-init=4 --timeout-sign=4 --timeout-accept=4 --block-time=10)
-    export NODE3=$(docker run -d --network host --env-file=${ROOT_DIR}/docker/node3.env \
+    export NODE3=$(docker run -d --network host --env-file=${ROOT_DIR}/docker/node3.env --env-file=${ROOT_DIR}/docker/genesis.env \
                           ${NODE_DOCKER_IMAGE} -test.coverprofile=coverage.txt node --genesis=${SEBAK_GENESIS}\
                           --log-level=debug --timeout-init=4 --timeout-sign=4 --timeout-accept=4 --block-time=10)
 